@@ -0,0 +1,67 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	almodels "github.com/abhinavxd/libredesk/internal/auditlog/models"
+	amodels "github.com/abhinavxd/libredesk/internal/auth/models"
+	"github.com/abhinavxd/libredesk/internal/envelope"
+	"github.com/valyala/fasthttp"
+	"github.com/zerodha/fastglue"
+)
+
+// handleGetAuditLogs returns the audit logs matching the query filters, newest first.
+func handleGetAuditLogs(r *fastglue.Request) error {
+	var (
+		app         = r.Context.(*App)
+		args        = r.RequestCtx.QueryArgs()
+		page, _     = strconv.Atoi(string(args.Peek("page")))
+		pageSize, _ = strconv.Atoi(string(args.Peek("page_size")))
+		total       = 0
+		filter      = almodels.Filter{
+			Action:     string(args.Peek("action")),
+			TargetType: string(args.Peek("target_type")),
+		}
+	)
+	filter.ActorID, _ = strconv.Atoi(string(args.Peek("actor_id")))
+	filter.TargetID, _ = strconv.Atoi(string(args.Peek("target_id")))
+
+	for key, dst := range map[string]*time.Time{"from": &filter.From, "to": &filter.To} {
+		v := string(args.Peek(key))
+		if v == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.invalid", "name", "`"+key+"`"), nil, envelope.InputError)
+		}
+		*dst = t
+	}
+
+	logs, err := app.auditLog.GetLogs(filter, page, pageSize)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if len(logs) > 0 {
+		total = logs[0].Total
+	}
+	return r.SendEnvelope(envelope.PageResults{
+		Results:    logs,
+		Total:      total,
+		PerPage:    pageSize,
+		TotalPages: (total + max(pageSize, 1) - 1) / max(pageSize, 1),
+		Page:       page,
+	})
+}
+
+// recordAudit records an administrative action by the user of the request in the audit log.
+func recordAudit(r *fastglue.Request, action, targetType string, targetID int, before, after any) {
+	var (
+		app      = r.Context.(*App)
+		auser, _ = r.RequestCtx.UserValue("user").(amodels.User)
+	)
+	name := strings.TrimSpace(auser.FirstName + " " + auser.LastName)
+	app.auditLog.Record(auser.ID, name, action, targetType, targetID, before, after)
+}
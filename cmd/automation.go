@@ -3,6 +3,7 @@ package main
 import (
 	"strconv"
 
+	almodels "github.com/abhinavxd/libredesk/internal/auditlog/models"
 	amodels "github.com/abhinavxd/libredesk/internal/automation/models"
 	"github.com/abhinavxd/libredesk/internal/envelope"
 	"github.com/valyala/fasthttp"
@@ -41,9 +42,18 @@ func handleToggleAutomationRule(r *fastglue.Request) error {
 		app   = r.Context.(*App)
 		id, _ = strconv.Atoi(r.RequestCtx.UserValue("id").(string))
 	)
+	before, err := app.automation.GetRule(id)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
 	if err := app.automation.ToggleRule(id); err != nil {
 		return sendErrorEnvelope(r, err)
 	}
+	after, err := app.automation.GetRule(id)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	recordAudit(r, almodels.ActionUpdate, almodels.TargetAutomationRule, id, before, after)
 	return r.SendEnvelope(true)
 }
 
@@ -62,9 +72,18 @@ func handleUpdateAutomationRule(r *fastglue.Request) error {
 		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.errorParsing", "name", "{globals.terms.request}"), nil, envelope.InputError)
 	}
 
+	before, err := app.automation.GetRule(id)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
 	if err = app.automation.UpdateRule(id, rule); err != nil {
 		return sendErrorEnvelope(r, err)
 	}
+	after, err := app.automation.GetRule(id)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	recordAudit(r, almodels.ActionUpdate, almodels.TargetAutomationRule, id, before, after)
 	return r.SendEnvelope(true)
 }
 
@@ -77,9 +96,11 @@ func handleCreateAutomationRule(r *fastglue.Request) error {
 	if err := r.Decode(&rule, "json"); err != nil {
 		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.errorParsing", "name", "{globals.terms.request}"), nil, envelope.InputError)
 	}
-	if err := app.automation.CreateRule(rule); err != nil {
+	created, err := app.automation.CreateRule(rule)
+	if err != nil {
 		return sendErrorEnvelope(r, err)
 	}
+	recordAudit(r, almodels.ActionCreate, almodels.TargetAutomationRule, created.ID, nil, created)
 	return r.SendEnvelope(true)
 }
 
@@ -93,9 +114,14 @@ func handleDeleteAutomationRule(r *fastglue.Request) error {
 	if err != nil || id == 0 {
 		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.invalid", "name", "`id`"), nil, envelope.InputError)
 	}
+	before, err := app.automation.GetRule(id)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
 	if err = app.automation.DeleteRule(id); err != nil {
 		return sendErrorEnvelope(r, err)
 	}
+	recordAudit(r, almodels.ActionDelete, almodels.TargetAutomationRule, id, before, nil)
 	return r.SendEnvelope(true)
 }
 
@@ -130,3 +156,62 @@ func handleUpdateAutomationRuleExecutionMode(r *fastglue.Request) error {
 	}
 	return r.SendEnvelope(true)
 }
+
+// handleGetFailedAutomationActions returns the automation rule actions that failed to apply and are queued for retry.
+func handleGetFailedAutomationActions(r *fastglue.Request) error {
+	var (
+		app         = r.Context.(*App)
+		args        = r.RequestCtx.QueryArgs()
+		status      = string(args.Peek("status"))
+		page, _     = strconv.Atoi(string(args.Peek("page")))
+		pageSize, _ = strconv.Atoi(string(args.Peek("page_size")))
+		total       = 0
+	)
+	if status != "" && status != amodels.FailedActionStatusPending && status != amodels.FailedActionStatusExhausted && status != amodels.FailedActionStatusSuppressed && status != amodels.FailedActionStatusFailed {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.invalid", "name", "`status`"), nil, envelope.InputError)
+	}
+	actions, err := app.automation.GetFailedActions(status, page, pageSize)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if len(actions) > 0 {
+		total = actions[0].Total
+	}
+	return r.SendEnvelope(envelope.PageResults{
+		Results:    actions,
+		Total:      total,
+		PerPage:    pageSize,
+		TotalPages: (total + max(pageSize, 1) - 1) / max(pageSize, 1),
+		Page:       page,
+	})
+}
+
+// handleRetryFailedAutomationAction queues a failed automation action for an immediate retry.
+func handleRetryFailedAutomationAction(r *fastglue.Request) error {
+	var (
+		app     = r.Context.(*App)
+		id, err = strconv.Atoi(r.RequestCtx.UserValue("id").(string))
+	)
+	if err != nil || id <= 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.invalid", "name", "`id`"), nil, envelope.InputError)
+	}
+	if err := app.automation.RetryFailedAction(id); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(true)
+}
+
+// handleDeleteFailedAutomationAction removes a failed automation action from the retry queue.
+func handleDeleteFailedAutomationAction(r *fastglue.Request) error {
+	var (
+		app     = r.Context.(*App)
+		id, err = strconv.Atoi(r.RequestCtx.UserValue("id").(string))
+	)
+	if err != nil || id <= 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.invalid", "name", "`id`"), nil, envelope.InputError)
+	}
+	if err := app.automation.DeleteFailedAction(id); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(true)
+}
@@ -1,10 +1,12 @@
 package main
 
 import (
+	"fmt"
 	"path/filepath"
 	"strconv"
 	"strings"
 
+	almodels "github.com/abhinavxd/libredesk/internal/auditlog/models"
 	amodels "github.com/abhinavxd/libredesk/internal/auth/models"
 	"github.com/abhinavxd/libredesk/internal/envelope"
 	"github.com/abhinavxd/libredesk/internal/stringutil"
@@ -196,6 +198,46 @@ func handleCreateContactNote(r *fastglue.Request) error {
 	return r.SendEnvelope(true)
 }
 
+// handleUpdateContactNote updates a note for a contact.
+func handleUpdateContactNote(r *fastglue.Request) error {
+	var (
+		app          = r.Context.(*App)
+		contactID, _ = strconv.Atoi(r.RequestCtx.UserValue("id").(string))
+		noteID, _    = strconv.Atoi(r.RequestCtx.UserValue("note_id").(string))
+		auser        = r.RequestCtx.UserValue("user").(amodels.User)
+		note         = string(r.RequestCtx.PostArgs().Peek("note"))
+	)
+	if contactID <= 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.invalid", "name", "`id`"), nil, envelope.InputError)
+	}
+	if noteID <= 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.invalid", "name", "`note_id`"), nil, envelope.InputError)
+	}
+	if len(note) == 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.empty", "name", "note"), nil, envelope.InputError)
+	}
+
+	// Allow editing of only own notes, `Admin` can edit any note.
+	agent, err := app.user.GetAgent(auser.ID, "")
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if !agent.HasAdminRole() {
+		existing, err := app.user.GetNote(noteID)
+		if err != nil {
+			return sendErrorEnvelope(r, err)
+		}
+		if existing.UserID != auser.ID {
+			return r.SendErrorEnvelope(fasthttp.StatusForbidden, app.i18n.Ts("globals.messages.canOnlyEditOwn", "name", "{globals.terms.note}"), nil, envelope.InputError)
+		}
+	}
+
+	if err := app.user.UpdateNote(noteID, contactID, note); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(true)
+}
+
 // handleDeleteContactNote deletes a note for a contact.
 func handleDeleteContactNote(r *fastglue.Request) error {
 	var (
@@ -248,3 +290,40 @@ func handleBlockContact(r *fastglue.Request) error {
 	}
 	return r.SendEnvelope(true)
 }
+
+// handleExportContactData returns the personal data of a contact as a JSON file download.
+func handleExportContactData(r *fastglue.Request) error {
+	var (
+		app          = r.Context.(*App)
+		contactID, _ = strconv.Atoi(r.RequestCtx.UserValue("id").(string))
+	)
+	if contactID <= 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.invalid", "name", "`id`"), nil, envelope.InputError)
+	}
+	data, err := app.conversation.ExportContactData(contactID)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	recordAudit(r, almodels.ActionExport, almodels.TargetContact, contactID, nil, nil)
+
+	r.RequestCtx.Response.Header.Set("Content-Type", "application/json")
+	r.RequestCtx.Response.Header.Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"contact-%d.json\"", contactID))
+	r.RequestCtx.SetBody(data)
+	return nil
+}
+
+// handleEraseContactData anonymizes a contact and erases the personal data in their conversations.
+func handleEraseContactData(r *fastglue.Request) error {
+	var (
+		app          = r.Context.(*App)
+		contactID, _ = strconv.Atoi(r.RequestCtx.UserValue("id").(string))
+	)
+	if contactID <= 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.invalid", "name", "`id`"), nil, envelope.InputError)
+	}
+	if err := app.conversation.EraseContactData(contactID); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	recordAudit(r, almodels.ActionErase, almodels.TargetContact, contactID, nil, map[string]any{"erased": true})
+	return r.SendEnvelope(true)
+}
@@ -2,10 +2,12 @@ package main
 
 import (
 	"encoding/json"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 
+	almodels "github.com/abhinavxd/libredesk/internal/auditlog/models"
 	amodels "github.com/abhinavxd/libredesk/internal/auth/models"
 	authzModels "github.com/abhinavxd/libredesk/internal/authz/models"
 	"github.com/abhinavxd/libredesk/internal/automation/models"
@@ -21,6 +23,7 @@ import (
 func handleGetAllConversations(r *fastglue.Request) error {
 	var (
 		app         = r.Context.(*App)
+		auser       = r.RequestCtx.UserValue("user").(amodels.User)
 		order       = string(r.RequestCtx.QueryArgs().Peek("order"))
 		orderBy     = string(r.RequestCtx.QueryArgs().Peek("order_by"))
 		page, _     = strconv.Atoi(string(r.RequestCtx.QueryArgs().Peek("page")))
@@ -29,7 +32,7 @@ func handleGetAllConversations(r *fastglue.Request) error {
 		total       = 0
 	)
 
-	conversations, err := app.conversation.GetAllConversationsList(order, orderBy, filters, page, pageSize)
+	conversations, err := app.conversation.GetAllConversationsList(auser.ID, order, orderBy, filters, page, pageSize)
 	if err != nil {
 		return sendErrorEnvelope(r, err)
 	}
@@ -80,6 +83,7 @@ func handleGetAssignedConversations(r *fastglue.Request) error {
 func handleGetUnassignedConversations(r *fastglue.Request) error {
 	var (
 		app         = r.Context.(*App)
+		auser       = r.RequestCtx.UserValue("user").(amodels.User)
 		order       = string(r.RequestCtx.QueryArgs().Peek("order"))
 		orderBy     = string(r.RequestCtx.QueryArgs().Peek("order_by"))
 		filters     = string(r.RequestCtx.QueryArgs().Peek("filters"))
@@ -88,7 +92,7 @@ func handleGetUnassignedConversations(r *fastglue.Request) error {
 		total       = 0
 	)
 
-	conversations, err := app.conversation.GetUnassignedConversationsList(order, orderBy, filters, page, pageSize)
+	conversations, err := app.conversation.GetUnassignedConversationsList(auser.ID, order, orderBy, filters, page, pageSize)
 	if err != nil {
 		return sendErrorEnvelope(r, err)
 	}
@@ -176,6 +180,93 @@ func handleGetViewConversations(r *fastglue.Request) error {
 	})
 }
 
+// handleGetNextConversation returns the conversation after the passed one in a conversations list, so agents can
+// move through a list with a shortcut. Null is returned once the list is exhausted.
+func handleGetNextConversation(r *fastglue.Request) error {
+	var (
+		app       = r.Context.(*App)
+		auser     = r.RequestCtx.UserValue("user").(amodels.User)
+		args      = r.RequestCtx.QueryArgs()
+		afterUUID = string(args.Peek("after"))
+		filter    = conversationFilterFromArgs(args)
+	)
+	if err := enforceConversationListAccess(app, auser.ID, filter); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+
+	conversation, err := app.conversation.GetNextConversation(afterUUID, filter, auser.ID)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(conversation)
+}
+
+// handleGetConversationStatusCounts returns the number of conversations by status in a conversations list, for the
+// badges of the lists.
+func handleGetConversationStatusCounts(r *fastglue.Request) error {
+	var (
+		app    = r.Context.(*App)
+		auser  = r.RequestCtx.UserValue("user").(amodels.User)
+		filter = conversationFilterFromArgs(r.RequestCtx.QueryArgs())
+	)
+	if err := enforceConversationListAccess(app, auser.ID, filter); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+
+	counts, err := app.conversation.GetStatusCounts(filter, auser.ID)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(counts)
+}
+
+// conversationFilterFromArgs returns the conversations list filter in the query args.
+func conversationFilterFromArgs(args *fasthttp.Args) cmodels.ConversationFilter {
+	teamID, _ := strconv.Atoi(string(args.Peek("team_id")))
+	return cmodels.ConversationFilter{
+		ListType: string(args.Peek("list_type")),
+		TeamID:   teamID,
+		Order:    string(args.Peek("order")),
+		OrderBy:  string(args.Peek("order_by")),
+		Filters:  string(args.Peek("filters")),
+	}
+}
+
+// enforceConversationListAccess returns an error if the user can't read the conversations list of the filter.
+func enforceConversationListAccess(app *App, userID int, filter cmodels.ConversationFilter) error {
+	user, err := app.user.GetAgent(userID, "")
+	if err != nil {
+		return err
+	}
+
+	var listPerms = map[string]string{
+		cmodels.AllConversations:            authzModels.PermConversationsReadAll,
+		cmodels.AssignedConversations:       authzModels.PermConversationsReadAssigned,
+		cmodels.UnassignedConversations:     authzModels.PermConversationsReadUnassigned,
+		cmodels.TeamUnassignedConversations: authzModels.PermConversationsReadTeamInbox,
+	}
+	perm, ok := listPerms[filter.ListType]
+	if !ok {
+		return envelope.NewError(envelope.InputError, app.i18n.Ts("globals.messages.invalid", "name", "`list_type`"), nil)
+	}
+	if !slices.Contains(user.Permissions, perm) {
+		return envelope.NewError(envelope.PermissionError, app.i18n.Ts("globals.messages.denied", "name", "{globals.terms.permission}"), nil)
+	}
+	if filter.ListType == cmodels.TeamUnassignedConversations {
+		if filter.TeamID < 1 {
+			return envelope.NewError(envelope.InputError, app.i18n.Ts("globals.messages.invalid", "name", "`team_id`"), nil)
+		}
+		exists, err := app.team.UserBelongsToTeam(filter.TeamID, userID)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return envelope.NewError(envelope.PermissionError, app.i18n.T("conversation.notMemberOfTeam"), nil)
+		}
+	}
+	return nil
+}
+
 // handleGetTeamUnassignedConversations returns conversations assigned to a team but not to any user.
 func handleGetTeamUnassignedConversations(r *fastglue.Request) error {
 	var (
@@ -204,7 +295,7 @@ func handleGetTeamUnassignedConversations(r *fastglue.Request) error {
 		return sendErrorEnvelope(r, envelope.NewError(envelope.PermissionError, app.i18n.T("conversation.notMemberOfTeam"), nil))
 	}
 
-	conversations, err := app.conversation.GetTeamUnassignedConversationsList(teamID, order, orderBy, filters, page, pageSize)
+	conversations, err := app.conversation.GetTeamUnassignedConversationsList(auser.ID, teamID, order, orderBy, filters, page, pageSize)
 	if err != nil {
 		return sendErrorEnvelope(r, err)
 	}
@@ -262,9 +353,45 @@ func handleUpdateConversationAssigneeLastSeen(r *fastglue.Request) error {
 	if err = app.conversation.UpdateConversationAssigneeLastSeen(uuid); err != nil {
 		return sendErrorEnvelope(r, err)
 	}
+	// Viewing the conversation also reads it for the agent, clearing a previous mark as unread.
+	if _, err := app.conversation.SetConversationReadState([]string{uuid}, user.ID, true); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
 	return r.SendEnvelope(true)
 }
 
+// handleSetConversationsReadState marks conversations read or unread for the requesting agent.
+func handleSetConversationsReadState(r *fastglue.Request) error {
+	var (
+		app   = r.Context.(*App)
+		auser = r.RequestCtx.UserValue("user").(amodels.User)
+		req   = struct {
+			UUIDs []string `json:"uuids"`
+			Read  bool     `json:"read"`
+		}{}
+	)
+	if err := r.Decode(&req, "json"); err != nil {
+		app.lo.Error("error unmarshalling read state request", "error", err)
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.errorParsing", "name", "{globals.terms.request}"), nil, envelope.InputError)
+	}
+
+	user, err := app.user.GetAgent(auser.ID, "")
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	for _, uuid := range req.UUIDs {
+		if _, err := enforceConversationAccess(app, uuid, user); err != nil {
+			return sendErrorEnvelope(r, err)
+		}
+	}
+
+	states, err := app.conversation.SetConversationReadState(req.UUIDs, user.ID, req.Read)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(states)
+}
+
 // handleGetConversationParticipants retrieves participants of a conversation.
 func handleGetConversationParticipants(r *fastglue.Request) error {
 	var (
@@ -287,6 +414,113 @@ func handleGetConversationParticipants(r *fastglue.Request) error {
 	return r.SendEnvelope(p)
 }
 
+// handleAddConversationParticipant adds an agent to the participants of a conversation with a role.
+func handleAddConversationParticipant(r *fastglue.Request) error {
+	var (
+		app       = r.Context.(*App)
+		uuid      = r.RequestCtx.UserValue("uuid").(string)
+		auser     = r.RequestCtx.UserValue("user").(amodels.User)
+		userID, _ = strconv.Atoi(string(r.RequestCtx.PostArgs().Peek("user_id")))
+		role      = string(r.RequestCtx.PostArgs().Peek("role"))
+	)
+	if userID <= 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.invalid", "name", "`user_id`"), nil, envelope.InputError)
+	}
+	user, err := app.user.GetAgent(auser.ID, "")
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if _, err = enforceConversationAccess(app, uuid, user); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	// Only agents can be participants.
+	if _, err := app.user.GetAgent(userID, ""); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if err := app.conversation.AddConversationParticipant(userID, uuid, role); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(true)
+}
+
+// handleRemoveConversationParticipant removes an agent from the participants of a conversation.
+func handleRemoveConversationParticipant(r *fastglue.Request) error {
+	var (
+		app       = r.Context.(*App)
+		uuid      = r.RequestCtx.UserValue("uuid").(string)
+		auser     = r.RequestCtx.UserValue("user").(amodels.User)
+		userID, _ = strconv.Atoi(r.RequestCtx.UserValue("user_id").(string))
+	)
+	if userID <= 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.invalid", "name", "`user_id`"), nil, envelope.InputError)
+	}
+	user, err := app.user.GetAgent(auser.ID, "")
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if _, err = enforceConversationAccess(app, uuid, user); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if err := app.conversation.RemoveConversationParticipant(userID, uuid); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(true)
+}
+
+// handleGetConversationWatchers returns the agents watching a conversation.
+func handleGetConversationWatchers(r *fastglue.Request) error {
+	var (
+		app   = r.Context.(*App)
+		uuid  = r.RequestCtx.UserValue("uuid").(string)
+		auser = r.RequestCtx.UserValue("user").(amodels.User)
+	)
+	user, err := app.user.GetAgent(auser.ID, "")
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if _, err = enforceConversationAccess(app, uuid, user); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	watchers, err := app.conversation.GetConversationWatchers(uuid)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(watchers)
+}
+
+// handleWatchConversation subscribes the current agent to the activity digest of a conversation.
+func handleWatchConversation(r *fastglue.Request) error {
+	var (
+		app   = r.Context.(*App)
+		uuid  = r.RequestCtx.UserValue("uuid").(string)
+		auser = r.RequestCtx.UserValue("user").(amodels.User)
+	)
+	user, err := app.user.GetAgent(auser.ID, "")
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if _, err = enforceConversationAccess(app, uuid, user); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if err := app.conversation.WatchConversation(user.ID, uuid); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(true)
+}
+
+// handleUnwatchConversation unsubscribes the current agent from the activity digest of a conversation.
+func handleUnwatchConversation(r *fastglue.Request) error {
+	var (
+		app   = r.Context.(*App)
+		uuid  = r.RequestCtx.UserValue("uuid").(string)
+		auser = r.RequestCtx.UserValue("user").(amodels.User)
+	)
+	if err := app.conversation.UnwatchConversation(auser.ID, uuid); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(true)
+}
+
 // handleUpdateUserAssignee updates the user assigned to a conversation.
 func handleUpdateUserAssignee(r *fastglue.Request) error {
 	var (
@@ -319,6 +553,34 @@ func handleUpdateUserAssignee(r *fastglue.Request) error {
 	return r.SendEnvelope(true)
 }
 
+// handleAssignToSelf assigns a conversation to the current user.
+func handleAssignToSelf(r *fastglue.Request) error {
+	var (
+		app   = r.Context.(*App)
+		uuid  = r.RequestCtx.UserValue("uuid").(string)
+		auser = r.RequestCtx.UserValue("user").(amodels.User)
+	)
+	user, err := app.user.GetAgent(auser.ID, "")
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if _, err := enforceConversationAccess(app, uuid, user); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+
+	assigned, err := app.conversation.AssignToSelf(uuid, user)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+
+	// Evaluate automation rules.
+	if assigned {
+		app.automation.EvaluateConversationUpdateRules(uuid, models.EventConversationUserAssigned)
+	}
+
+	return r.SendEnvelope(true)
+}
+
 // handleUpdateTeamAssignee updates the team assigned to a conversation.
 func handleUpdateTeamAssignee(r *fastglue.Request) error {
 	var (
@@ -385,6 +647,92 @@ func handleUpdateConversationPriority(r *fastglue.Request) error {
 	return r.SendEnvelope(true)
 }
 
+// handleUpdateConversationLocale sets the locale override of a conversation, an empty locale removes it.
+func handleUpdateConversationLocale(r *fastglue.Request) error {
+	var (
+		app    = r.Context.(*App)
+		uuid   = r.RequestCtx.UserValue("uuid").(string)
+		auser  = r.RequestCtx.UserValue("user").(amodels.User)
+		locale = string(r.RequestCtx.PostArgs().Peek("locale"))
+	)
+	user, err := app.user.GetAgent(auser.ID, "")
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if _, err := enforceConversationAccess(app, uuid, user); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if err := app.conversation.UpdateConversationLocale(uuid, locale); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(true)
+}
+
+// handleUpdateConversationSubject changes the subject of a conversation.
+func handleUpdateConversationSubject(r *fastglue.Request) error {
+	var (
+		app     = r.Context.(*App)
+		uuid    = r.RequestCtx.UserValue("uuid").(string)
+		auser   = r.RequestCtx.UserValue("user").(amodels.User)
+		subject = string(r.RequestCtx.PostArgs().Peek("subject"))
+	)
+	user, err := app.user.GetAgent(auser.ID, "")
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if _, err := enforceConversationAccess(app, uuid, user); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if err := app.conversation.UpdateConversationSubject(uuid, subject, user); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(true)
+}
+
+// handleDeleteConversation soft-deletes a conversation, it's purged after the retention period unless restored.
+func handleDeleteConversation(r *fastglue.Request) error {
+	var (
+		app   = r.Context.(*App)
+		uuid  = r.RequestCtx.UserValue("uuid").(string)
+		auser = r.RequestCtx.UserValue("user").(amodels.User)
+	)
+	user, err := app.user.GetAgent(auser.ID, "")
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	conv, err := enforceConversationAccess(app, uuid, user)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if err := app.conversation.SoftDeleteConversation(uuid, user); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	recordAudit(r, almodels.ActionDelete, almodels.TargetConversation, conv.ID, map[string]any{"uuid": uuid}, nil)
+	return r.SendEnvelope(true)
+}
+
+// handleRestoreConversation restores a soft-deleted conversation.
+func handleRestoreConversation(r *fastglue.Request) error {
+	var (
+		app   = r.Context.(*App)
+		uuid  = r.RequestCtx.UserValue("uuid").(string)
+		auser = r.RequestCtx.UserValue("user").(amodels.User)
+	)
+	user, err := app.user.GetAgent(auser.ID, "")
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	conv, err := enforceConversationAccess(app, uuid, user)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if err := app.conversation.RestoreConversation(uuid, user); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	recordAudit(r, almodels.ActionRestore, almodels.TargetConversation, conv.ID, nil, map[string]any{"uuid": uuid})
+	return r.SendEnvelope(true)
+}
+
 // handleUpdateConversationStatus updates the status of a conversation.
 func handleUpdateConversationStatus(r *fastglue.Request) error {
 	var (
@@ -431,20 +779,6 @@ func handleUpdateConversationStatus(r *fastglue.Request) error {
 
 	// Evaluate automation rules.
 	app.automation.EvaluateConversationUpdateRules(uuid, models.EventConversationStatusChange)
-
-	// If status is `Resolved`, send CSAT survey if enabled on inbox.
-	if status == cmodels.StatusResolved {
-		// Check if CSAT is enabled on the inbox and send CSAT survey message.
-		inbox, err := app.inbox.GetDBRecord(conversation.InboxID)
-		if err != nil {
-			return sendErrorEnvelope(r, err)
-		}
-		if inbox.CSATEnabled {
-			if err := app.conversation.SendCSATReply(user.ID, *conversation); err != nil {
-				return sendErrorEnvelope(r, err)
-			}
-		}
-	}
 	return r.SendEnvelope(true)
 }
 
@@ -475,9 +809,62 @@ func handleUpdateConversationtags(r *fastglue.Request) error {
 	if err := app.conversation.SetConversationTags(uuid, models.ActionSetTags, tagNames, user); err != nil {
 		return sendErrorEnvelope(r, err)
 	}
+
+	// Evaluate automation rules.
+	app.automation.EvaluateConversationUpdateRules(uuid, models.EventConversationTagsChange)
 	return r.SendEnvelope(true)
 }
 
+// handleGetAssigneeSuggestions returns agents suggested as assignee of a conversation.
+func handleGetAssigneeSuggestions(r *fastglue.Request) error {
+	var (
+		app   = r.Context.(*App)
+		uuid  = r.RequestCtx.UserValue("uuid").(string)
+		auser = r.RequestCtx.UserValue("user").(amodels.User)
+	)
+	user, err := app.user.GetAgent(auser.ID, "")
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if _, err := enforceConversationAccess(app, uuid, user); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	suggestions, err := app.conversation.SuggestAssignee(uuid)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(suggestions)
+}
+
+// handleGetMergeSuggestions returns the conversations that look like duplicates of a conversation, the ones the
+// agent can't access are left out.
+func handleGetMergeSuggestions(r *fastglue.Request) error {
+	var (
+		app   = r.Context.(*App)
+		uuid  = r.RequestCtx.UserValue("uuid").(string)
+		auser = r.RequestCtx.UserValue("user").(amodels.User)
+	)
+	user, err := app.user.GetAgent(auser.ID, "")
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if _, err := enforceConversationAccess(app, uuid, user); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	candidates, err := app.conversation.SuggestMerges(uuid)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	var accessible = make([]cmodels.MergeCandidate, 0, len(candidates))
+	for _, c := range candidates {
+		if _, err := enforceConversationAccess(app, c.UUID, user); err != nil {
+			continue
+		}
+		accessible = append(accessible, c)
+	}
+	return r.SendEnvelope(accessible)
+}
+
 // handleUpdateConversationCustomAttributes updates custom attributes of a conversation.
 func handleUpdateConversationCustomAttributes(r *fastglue.Request) error {
 	var (
@@ -628,12 +1015,14 @@ func filterCurrentConv(convs []cmodels.Conversation, uuid string) []cmodels.Conv
 	return []cmodels.Conversation{}
 }
 
-// handleCreateConversation creates a new conversation and sends a message to it.
+// handleCreateConversation creates a new conversation with a contact and sends a message to it, the contact is
+// created from the email if no contact ID is given.
 func handleCreateConversation(r *fastglue.Request) error {
 	var (
 		app             = r.Context.(*App)
 		auser           = r.RequestCtx.UserValue("user").(amodels.User)
 		inboxID         = r.RequestCtx.PostArgs().GetUintOrZero("inbox_id")
+		contactID       = r.RequestCtx.PostArgs().GetUintOrZero("contact_id")
 		assignedAgentID = r.RequestCtx.PostArgs().GetUintOrZero("agent_id")
 		assignedTeamID  = r.RequestCtx.PostArgs().GetUintOrZero("team_id")
 		email           = strings.TrimSpace(string(r.RequestCtx.PostArgs().Peek("contact_email")))
@@ -646,78 +1035,61 @@ func handleCreateConversation(r *fastglue.Request) error {
 	if inboxID <= 0 {
 		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.fieldRequired", "name", "`inbox_id`"), nil, envelope.InputError)
 	}
-	if strings.TrimSpace(subject) == "" {
-		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.fieldRequired", "name", "`subject`"), nil, envelope.InputError)
-	}
-	if strings.TrimSpace(content) == "" {
-		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.fieldRequired", "name", "`content`"), nil, envelope.InputError)
-	}
-	if strings.TrimSpace(email) == "" {
+	if contactID <= 0 && email == "" {
 		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.fieldRequired", "name", "`contact_email`"), nil, envelope.InputError)
 	}
-	if firstName == "" {
+	if contactID <= 0 && firstName == "" {
 		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.fieldRequired", "name", "`first_name`"), nil, envelope.InputError)
 	}
 
-	user, err := app.user.GetAgent(auser.ID, "")
-	if err != nil {
-		return sendErrorEnvelope(r, err)
+	// Assigning on creation needs the same permissions as assigning later.
+	if assignedAgentID > 0 {
+		if err := requirePermission(r, authzModels.PermConversationsUpdateUserAssignee); err != nil {
+			return sendErrorEnvelope(r, err)
+		}
+	}
+	if assignedTeamID > 0 {
+		if err := requirePermission(r, authzModels.PermConversationsUpdateTeamAssignee); err != nil {
+			return sendErrorEnvelope(r, err)
+		}
 	}
 
-	// Check if inbox exists and is enabled.
-	inbox, err := app.inbox.GetDBRecord(inboxID)
+	user, err := app.user.GetAgent(auser.ID, "")
 	if err != nil {
 		return sendErrorEnvelope(r, err)
 	}
-	if !inbox.Enabled {
-		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.disabled", "name", "inbox"), nil, envelope.InputError)
-	}
 
-	// Find or create contact.
-	contact := umodels.User{
-		Email:           null.StringFrom(email),
-		SourceChannelID: null.StringFrom(email),
-		FirstName:       firstName,
-		LastName:        lastName,
-		InboxID:         inboxID,
-	}
-	if err := app.user.CreateContact(&contact); err != nil {
-		return sendErrorEnvelope(r, envelope.NewError(envelope.GeneralError, app.i18n.Ts("globals.messages.errorCreating", "name", "{globals.terms.contact}"), nil))
+	// Find or create the contact if only an email is given.
+	if contactID <= 0 {
+		contact := umodels.User{
+			Email:           null.StringFrom(email),
+			SourceChannelID: null.StringFrom(email),
+			FirstName:       firstName,
+			LastName:        lastName,
+			InboxID:         inboxID,
+		}
+		if err := app.user.CreateContact(&contact); err != nil {
+			return sendErrorEnvelope(r, envelope.NewError(envelope.GeneralError, app.i18n.Ts("globals.messages.errorCreating", "name", "{globals.terms.contact}"), nil))
+		}
+		contactID = contact.ID
 	}
 
-	// Create conversation
-	conversationID, conversationUUID, err := app.conversation.CreateConversation(
-		contact.ID,
-		contact.ContactChannelID,
-		inboxID,
-		"", /** last_message **/
-		time.Now(),
-		subject,
-		true, /** append reference number to subject **/
-	)
+	conversation, err := app.conversation.CreateOutboundConversation(contactID, inboxID, subject, content, user)
 	if err != nil {
-		app.lo.Error("error creating conversation", "error", err)
-		return sendErrorEnvelope(r, envelope.NewError(envelope.GeneralError, app.i18n.Ts("globals.messages.errorCreating", "name", "{globals.terms.conversation}"), nil))
-	}
-
-	// Send reply to the created conversation.
-	if err := app.conversation.SendReply(nil /**media**/, inboxID, auser.ID, conversationUUID, content, nil /**cc**/, nil /**bcc**/, map[string]any{} /**meta**/); err != nil {
-		// Delete the conversation if sending the reply fails.
-		if err := app.conversation.DeleteConversation(conversationUUID); err != nil {
-			app.lo.Error("error deleting conversation", "error", err)
-		}
-		return sendErrorEnvelope(r, envelope.NewError(envelope.GeneralError, app.i18n.Ts("globals.messages.errorSending", "name", "{globals.terms.message}"), nil))
+		return sendErrorEnvelope(r, err)
 	}
 
 	// Assign the conversation to the agent or team.
 	if assignedAgentID > 0 {
-		app.conversation.UpdateConversationUserAssignee(conversationUUID, assignedAgentID, user)
+		app.conversation.UpdateConversationUserAssignee(conversation.UUID, assignedAgentID, user)
 	}
 	if assignedTeamID > 0 {
-		app.conversation.UpdateConversationTeamAssignee(conversationUUID, assignedTeamID, user)
+		app.conversation.UpdateConversationTeamAssignee(conversation.UUID, assignedTeamID, user)
 	}
 
-	// Send the created conversation back to the client.
-	conversation, _ := app.conversation.GetConversation(conversationID, "")
+	// Send the created conversation back to the client, with the assignees set above.
+	if assignedAgentID > 0 || assignedTeamID > 0 {
+		conversation, _ = app.conversation.GetConversation(conversation.ID, "")
+	}
 	return r.SendEnvelope(conversation)
 }
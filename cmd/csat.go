@@ -3,17 +3,21 @@ package main
 import (
 	"strconv"
 
+	csatmodels "github.com/abhinavxd/libredesk/internal/csat/models"
+	"github.com/valyala/fasthttp"
 	"github.com/zerodha/fastglue"
 )
 
 // handleShowCSAT renders the CSAT page for a given csat.
 func handleShowCSAT(r *fastglue.Request) error {
 	var (
-		app  = r.Context.(*App)
-		uuid = r.RequestCtx.UserValue("uuid").(string)
+		app   = r.Context.(*App)
+		uuid  = r.RequestCtx.UserValue("uuid").(string)
+		token = string(r.RequestCtx.QueryArgs().Peek("token"))
 	)
 
-	csat, err := app.csat.Get(uuid)
+	// Same page for a missing survey and a wrong token, so valid UUIDs can't be told apart.
+	csat, err := app.csat.GetWithToken(uuid, token)
 	if err != nil {
 		return app.tmpl.RenderWebPage(r.RequestCtx, "error", map[string]interface{}{
 			"Data": map[string]interface{}{
@@ -40,12 +44,17 @@ func handleShowCSAT(r *fastglue.Request) error {
 		})
 	}
 
-	return app.tmpl.RenderWebPage(r.RequestCtx, "csat", map[string]interface{}{
+	// The survey is shown in the conversation's locale when there's a template for it.
+	locale := conversation.EffectiveLocale()
+	return app.tmpl.RenderLocalizedWebPage(r.RequestCtx, "csat", locale, map[string]interface{}{
 		"Data": map[string]interface{}{
 			"Title":    "Rate your interaction with us",
+			"Locale":   locale,
 			"CSAT": map[string]interface{}{
-				"UUID": csat.UUID,
+				"UUID":  csat.UUID,
+				"Token": token,
 			},
+			"Questions": app.csat.Questions(),
 			"Conversation": map[string]interface{}{
 				"Subject":         conversation.Subject.String,
 				"ReferenceNumber": conversation.ReferenceNumber,
@@ -59,25 +68,39 @@ func handleUpdateCSATResponse(r *fastglue.Request) error {
 	var (
 		app      = r.Context.(*App)
 		uuid     = r.RequestCtx.UserValue("uuid").(string)
-		rating   = r.RequestCtx.FormValue("rating")
+		token    = string(r.RequestCtx.FormValue("token"))
 		feedback = string(r.RequestCtx.FormValue("feedback"))
 	)
 
-	ratingI, err := strconv.Atoi(string(rating))
-	if err != nil {
+	if !app.csat.AllowSubmission(r.RequestCtx.RemoteIP().String()) {
+		r.RequestCtx.SetStatusCode(fasthttp.StatusTooManyRequests)
 		return app.tmpl.RenderWebPage(r.RequestCtx, "error", map[string]interface{}{
 			"Data": map[string]interface{}{
-				"ErrorMessage": "Invalid `rating`",
+				"ErrorMessage": "Too many requests, please try again later",
 			},
 		})
 	}
 
-	if ratingI < 1 || ratingI > 5 {
-		return app.tmpl.RenderWebPage(r.RequestCtx, "error", map[string]interface{}{
-			"Data": map[string]interface{}{
-				"ErrorMessage": "Invalid `rating`",
-			},
-		})
+	// Each question is rated with a `rating_<question id>` field. Survey pages with a single `rating` field
+	// answer the first question.
+	var answers []csatmodels.Answer
+	for i, q := range app.csat.Questions() {
+		rating := r.RequestCtx.FormValue("rating_" + q.ID)
+		if len(rating) == 0 && i == 0 {
+			rating = r.RequestCtx.FormValue("rating")
+		}
+		if len(rating) == 0 {
+			continue
+		}
+		score, err := strconv.Atoi(string(rating))
+		if err != nil {
+			return app.tmpl.RenderWebPage(r.RequestCtx, "error", map[string]interface{}{
+				"Data": map[string]interface{}{
+					"ErrorMessage": "Invalid `rating`",
+				},
+			})
+		}
+		answers = append(answers, csatmodels.Answer{QuestionID: q.ID, Score: score})
 	}
 
 	if uuid == "" {
@@ -88,7 +111,7 @@ func handleUpdateCSATResponse(r *fastglue.Request) error {
 		})
 	}
 
-	if err := app.csat.UpdateResponse(uuid, ratingI, feedback); err != nil {
+	if err := app.csat.UpdateResponse(uuid, token, answers, feedback); err != nil {
 		return app.tmpl.RenderWebPage(r.RequestCtx, "error", map[string]interface{}{
 			"Data": map[string]interface{}{
 				"ErrorMessage": err.Error(),
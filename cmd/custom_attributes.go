@@ -25,6 +25,9 @@ var (
 		"hours_since_last_reply",
 		"hours_since_resolved",
 		"inbox",
+		"last_incoming_message",
+		"hours_since_unanswered",
+		"business_hours_since_unanswered",
 	}
 )
 
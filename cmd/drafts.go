@@ -0,0 +1,81 @@
+package main
+
+import (
+	amodels "github.com/abhinavxd/libredesk/internal/auth/models"
+	"github.com/abhinavxd/libredesk/internal/envelope"
+	"github.com/valyala/fasthttp"
+	"github.com/zerodha/fastglue"
+)
+
+type draftReq struct {
+	Content string `json:"content"`
+	Private bool   `json:"private"`
+}
+
+// handleGetDraft returns the draft of the current user in a conversation.
+func handleGetDraft(r *fastglue.Request) error {
+	var (
+		app   = r.Context.(*App)
+		auser = r.RequestCtx.UserValue("user").(amodels.User)
+		uuid  = r.RequestCtx.UserValue("uuid").(string)
+	)
+	user, err := app.user.GetAgent(auser.ID, "")
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if _, err := enforceConversationAccess(app, uuid, user); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	draft, err := app.conversation.GetDraft(user.ID, uuid)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(draft)
+}
+
+// handleSaveDraft saves the draft of the current user in a conversation.
+func handleSaveDraft(r *fastglue.Request) error {
+	var (
+		app   = r.Context.(*App)
+		auser = r.RequestCtx.UserValue("user").(amodels.User)
+		uuid  = r.RequestCtx.UserValue("uuid").(string)
+		req   = draftReq{}
+	)
+	if err := r.Decode(&req, "json"); err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.errorParsing", "name", "{globals.terms.request}"), nil, envelope.InputError)
+	}
+	user, err := app.user.GetAgent(auser.ID, "")
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if _, err := enforceConversationAccess(app, uuid, user); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+
+	// Saving an empty draft is the same as discarding it.
+	if req.Content == "" {
+		if err := app.conversation.DeleteDraft(user.ID, uuid); err != nil {
+			return sendErrorEnvelope(r, err)
+		}
+		return r.SendEnvelope(true)
+	}
+
+	draft, err := app.conversation.SaveDraft(user.ID, uuid, req.Content, req.Private)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(draft)
+}
+
+// handleDeleteDraft deletes the draft of the current user in a conversation.
+func handleDeleteDraft(r *fastglue.Request) error {
+	var (
+		app   = r.Context.(*App)
+		auser = r.RequestCtx.UserValue("user").(amodels.User)
+		uuid  = r.RequestCtx.UserValue("uuid").(string)
+	)
+	if err := app.conversation.DeleteDraft(auser.ID, uuid); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(true)
+}
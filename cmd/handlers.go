@@ -46,27 +46,57 @@ func initHandlers(g *fastglue.Fastglue, hub *ws.Hub) {
 	g.GET("/api/v1/conversations/all", perm(handleGetAllConversations, "conversations:read_all"))
 	g.GET("/api/v1/conversations/unassigned", perm(handleGetUnassignedConversations, "conversations:read_unassigned"))
 	g.GET("/api/v1/conversations/assigned", perm(handleGetAssignedConversations, "conversations:read_assigned"))
+	g.GET("/api/v1/conversations/next", perm(handleGetNextConversation, "conversations:read"))
+	g.GET("/api/v1/conversations/status-counts", perm(handleGetConversationStatusCounts, "conversations:read"))
 	g.GET("/api/v1/teams/{id}/conversations/unassigned", perm(handleGetTeamUnassignedConversations, "conversations:read_team_inbox"))
 	g.GET("/api/v1/views/{id}/conversations", perm(handleGetViewConversations, "conversations:read"))
 	g.GET("/api/v1/conversations/{uuid}", perm(handleGetConversation, "conversations:read"))
 	g.GET("/api/v1/conversations/{uuid}/participants", perm(handleGetConversationParticipants, "conversations:read"))
+	g.POST("/api/v1/conversations/{uuid}/participants", perm(handleAddConversationParticipant, "conversations:write"))
+	g.DELETE("/api/v1/conversations/{uuid}/participants/{user_id}", perm(handleRemoveConversationParticipant, "conversations:write"))
+	g.GET("/api/v1/conversations/{uuid}/watchers", perm(handleGetConversationWatchers, "conversations:read"))
+	g.PUT("/api/v1/conversations/{uuid}/watch", perm(handleWatchConversation, "conversations:read"))
+	g.DELETE("/api/v1/conversations/{uuid}/watch", perm(handleUnwatchConversation, "conversations:read"))
+	g.POST("/api/v1/conversations/{uuid}/reminders", perm(handleSetConversationReminder, "conversations:read"))
+	g.GET("/api/v1/conversations/{uuid}/viewers", perm(func(r *fastglue.Request) error {
+		return handleGetConversationViewers(r, hub)
+	}, "conversations:read"))
 	g.PUT("/api/v1/conversations/{uuid}/assignee/user", perm(handleUpdateUserAssignee, "conversations:update_user_assignee"))
+	g.PUT("/api/v1/conversations/{uuid}/assignee/me", perm(handleAssignToSelf, "conversations:update_user_assignee"))
 	g.PUT("/api/v1/conversations/{uuid}/assignee/team", perm(handleUpdateTeamAssignee, "conversations:update_team_assignee"))
+	g.GET("/api/v1/conversations/{uuid}/assignee/suggestions", perm(handleGetAssigneeSuggestions, "conversations:update_user_assignee"))
+	g.GET("/api/v1/conversations/{uuid}/merge/suggestions", perm(handleGetMergeSuggestions, "conversations:read"))
 	g.PUT("/api/v1/conversations/{uuid}/assignee/user/remove", perm(handleRemoveUserAssignee, "conversations:update_user_assignee"))
 	g.PUT("/api/v1/conversations/{uuid}/assignee/team/remove", perm(handleRemoveTeamAssignee, "conversations:update_team_assignee"))
+	g.PUT("/api/v1/conversations/{uuid}/queue", perm(handleRouteConversationToQueue, "conversations:update_team_assignee"))
 	g.PUT("/api/v1/conversations/{uuid}/priority", perm(handleUpdateConversationPriority, "conversations:update_priority"))
+	g.DELETE("/api/v1/conversations/{uuid}", perm(handleDeleteConversation, "conversations:delete"))
+	g.PUT("/api/v1/conversations/{uuid}/restore", perm(handleRestoreConversation, "conversations:delete"))
+	g.PUT("/api/v1/conversations/{uuid}/locale", perm(handleUpdateConversationLocale, "conversations:update_custom_attributes"))
+	g.PUT("/api/v1/conversations/{uuid}/subject", perm(handleUpdateConversationSubject, "conversations:update_subject"))
 	g.PUT("/api/v1/conversations/{uuid}/status", perm(handleUpdateConversationStatus, "conversations:update_status"))
+	g.PUT("/api/v1/conversations/read-state", perm(handleSetConversationsReadState, "conversations:read"))
 	g.PUT("/api/v1/conversations/{uuid}/last-seen", perm(handleUpdateConversationAssigneeLastSeen, "conversations:read"))
 	g.POST("/api/v1/conversations/{uuid}/tags", perm(handleUpdateConversationtags, "conversations:update_tags"))
 	g.GET("/api/v1/conversations/{cuuid}/messages/{uuid}", perm(handleGetMessage, "messages:read"))
 	g.GET("/api/v1/conversations/{uuid}/messages", perm(handleGetMessages, "messages:read"))
 	g.POST("/api/v1/conversations/{cuuid}/messages", perm(handleSendMessage, "messages:write"))
+	g.POST("/api/v1/conversations/{cuuid}/messages/validate", perm(handleValidateMessage, "messages:write"))
 	g.PUT("/api/v1/conversations/{cuuid}/messages/{uuid}/retry", perm(handleRetryMessage, "messages:write"))
+	g.PUT("/api/v1/conversations/{cuuid}/messages/retry-failed", perm(handleRetryFailedMessages, "messages:write"))
+	g.POST("/api/v1/conversations/{cuuid}/messages/{uuid}/attachments", perm(handleAttachToMessage, "messages:write"))
+	g.POST("/api/v1/conversations/{cuuid}/messages/{uuid}/translate", perm(handleTranslateMessage, "messages:read"))
+	g.GET("/api/v1/conversations/{cuuid}/messages/{uuid}/tracking", perm(handleGetMessageTrackingEvents, "messages:read"))
+	g.GET("/api/v1/conversations/{cuuid}/messages/{uuid}/status-history", perm(handleGetMessageStatusHistory, "messages:read"))
 	g.POST("/api/v1/conversations", perm(handleCreateConversation, "conversations:write"))
-	g.PUT("/api/v1/conversations/{uuid}/custom-attributes", auth(handleUpdateConversationCustomAttributes))
-	g.PUT("/api/v1/conversations/{uuid}/contacts/custom-attributes", auth(handleUpdateContactCustomAttributes))
+	g.PUT("/api/v1/conversations/{uuid}/custom-attributes", perm(handleUpdateConversationCustomAttributes, "conversations:update_custom_attributes"))
+	g.PUT("/api/v1/conversations/{uuid}/contacts/custom-attributes", perm(handleUpdateContactCustomAttributes, "conversations:update_custom_attributes"))
+	g.GET("/api/v1/conversations/{uuid}/draft", perm(handleGetDraft, "messages:write"))
+	g.PUT("/api/v1/conversations/{uuid}/draft", perm(handleSaveDraft, "messages:write"))
+	g.DELETE("/api/v1/conversations/{uuid}/draft", perm(handleDeleteDraft, "messages:write"))
 
 	// Search.
+	g.GET("/api/v1/search/conversations", perm(handleSearchAllConversations, "conversations:read"))
 	g.GET("/api/v1/conversations/search", perm(handleSearchConversations, "conversations:read"))
 	g.GET("/api/v1/messages/search", perm(handleSearchMessages, "messages:read"))
 	g.GET("/api/v1/contacts/search", perm(handleSearchContacts, "contacts:read"))
@@ -90,13 +120,23 @@ func initHandlers(g *fastglue.Fastglue, hub *ws.Hub) {
 	g.PUT("/api/v1/tags/{id}", perm(handleUpdateTag, "tags:manage"))
 	g.DELETE("/api/v1/tags/{id}", perm(handleDeleteTag, "tags:manage"))
 
+	// Queues.
+	g.GET("/api/v1/queues", auth(handleGetQueues))
+	g.POST("/api/v1/queues", perm(handleCreateQueue, "queues:manage"))
+	g.PUT("/api/v1/queues/{id}", perm(handleUpdateQueue, "queues:manage"))
+	g.DELETE("/api/v1/queues/{id}", perm(handleDeleteQueue, "queues:manage"))
+	g.GET("/api/v1/queues/{id}/conversations", perm(handleGetQueueConversations, "conversations:read"))
+	g.POST("/api/v1/queues/{id}/pull", perm(handlePullQueueConversation, "conversations:read"))
+
 	// Macros.
 	g.GET("/api/v1/macros", auth(handleGetMacros))
+	g.GET("/api/v1/macros/suggestions", auth(handleSuggestMacros))
 	g.GET("/api/v1/macros/{id}", perm(handleGetMacro, "macros:manage"))
 	g.POST("/api/v1/macros", perm(handleCreateMacro, "macros:manage"))
 	g.PUT("/api/v1/macros/{id}", perm(handleUpdateMacro, "macros:manage"))
 	g.DELETE("/api/v1/macros/{id}", perm(handleDeleteMacro, "macros:manage"))
 	g.POST("/api/v1/conversations/{uuid}/macros/{id}/apply", auth(handleApplyMacro))
+	g.POST("/api/v1/conversations/{uuid}/macros/{id}/execute", auth(handleExecuteMacro))
 
 	// Agents.
 	g.GET("/api/v1/agents/me", auth(handleGetCurrentAgent))
@@ -104,6 +144,13 @@ func initHandlers(g *fastglue.Fastglue, hub *ws.Hub) {
 	g.GET("/api/v1/agents/me/teams", auth(handleGetCurrentAgentTeams))
 	g.PUT("/api/v1/agents/me/availability", auth(handleUpdateAgentAvailability))
 	g.DELETE("/api/v1/agents/me/avatar", auth(handleDeleteCurrentAgentAvatar))
+	g.GET("/api/v1/agents/me/notification-preferences", auth(handleGetNotificationPreferences))
+	g.PUT("/api/v1/agents/me/notification-preferences", auth(handleUpdateNotificationPreferences))
+	g.DELETE("/api/v1/agents/me/notification-preferences", auth(handleResetNotificationPreferences))
+	g.GET("/api/v1/agents/me/quiet-hours", auth(handleGetQuietHours))
+	g.PUT("/api/v1/agents/me/quiet-hours", auth(handleUpdateQuietHours))
+	g.GET("/api/v1/agents/me/reminders", auth(handleGetReminders))
+	g.DELETE("/api/v1/agents/me/reminders/{id}", auth(handleCancelReminder))
 
 	g.GET("/api/v1/agents/compact", auth(handleGetAgentsCompact))
 	g.GET("/api/v1/agents", perm(handleGetAgents, "users:manage"))
@@ -111,18 +158,30 @@ func initHandlers(g *fastglue.Fastglue, hub *ws.Hub) {
 	g.POST("/api/v1/agents", perm(handleCreateAgent, "users:manage"))
 	g.PUT("/api/v1/agents/{id}", perm(handleUpdateAgent, "users:manage"))
 	g.DELETE("/api/v1/agents/{id}", perm(handleDeleteAgent, "users:manage"))
+	g.GET("/api/v1/agents/{id}/skills", perm(handleGetAgentSkills, "users:manage"))
+	g.PUT("/api/v1/agents/{id}/skills", perm(handleSetAgentSkills, "users:manage"))
+	g.POST("/api/v1/agents/{id}/skills", perm(handleAddAgentSkill, "users:manage"))
+	g.DELETE("/api/v1/agents/{id}/skills/{skill}", perm(handleRemoveAgentSkill, "users:manage"))
 	g.POST("/api/v1/agents/reset-password", tryAuth(handleResetPassword))
 	g.POST("/api/v1/agents/set-password", tryAuth(handleSetPassword))
 
+	// API tokens of the current agent, these routes only accept a session so tokens can't create other tokens.
+	g.GET("/api/v1/agents/me/api-tokens", sessionAuth(handleGetAPITokens))
+	g.POST("/api/v1/agents/me/api-tokens", sessionAuth(handleCreateAPIToken))
+	g.DELETE("/api/v1/agents/me/api-tokens/{id}", sessionAuth(handleRevokeAPIToken))
+
 	// Contacts.
 	g.GET("/api/v1/contacts", perm(handleGetContacts, "contacts:read_all"))
 	g.GET("/api/v1/contacts/{id}", perm(handleGetContact, "contacts:read"))
 	g.PUT("/api/v1/contacts/{id}", perm(handleUpdateContact, "contacts:write"))
 	g.PUT("/api/v1/contacts/{id}/block", perm(handleBlockContact, "contacts:block"))
+	g.GET("/api/v1/contacts/{id}/export", perm(handleExportContactData, "contacts:export"))
+	g.POST("/api/v1/contacts/{id}/erase", perm(handleEraseContactData, "contacts:erase"))
 
 	// Contact notes.
 	g.GET("/api/v1/contacts/{id}/notes", perm(handleGetContactNotes, "contact_notes:read"))
 	g.POST("/api/v1/contacts/{id}/notes", perm(handleCreateContactNote, "contact_notes:write"))
+	g.PUT("/api/v1/contacts/{id}/notes/{note_id}", perm(handleUpdateContactNote, "contact_notes:write"))
 	g.DELETE("/api/v1/contacts/{id}/notes/{note_id}", perm(handleDeleteContactNote, "contact_notes:delete"))
 
 	// Teams.
@@ -142,12 +201,23 @@ func initHandlers(g *fastglue.Fastglue, hub *ws.Hub) {
 	g.PUT("/api/v1/automations/rules/weights", perm(handleUpdateAutomationRuleWeights, "automations:manage"))
 	g.PUT("/api/v1/automations/rules/execution-mode", perm(handleUpdateAutomationRuleExecutionMode, "automations:manage"))
 	g.DELETE("/api/v1/automations/rules/{id}", perm(handleDeleteAutomationRule, "automations:manage"))
+	g.GET("/api/v1/automations/failed-actions", perm(handleGetFailedAutomationActions, "automations:manage"))
+	g.POST("/api/v1/automations/failed-actions/{id}/retry", perm(handleRetryFailedAutomationAction, "automations:manage"))
+	g.DELETE("/api/v1/automations/failed-actions/{id}", perm(handleDeleteFailedAutomationAction, "automations:manage"))
 
 	// Inboxes.
 	g.GET("/api/v1/inboxes", auth(handleGetInboxes))
+	g.GET("/api/v1/inboxes/health", perm(handleGetInboxesHealth, "inboxes:manage"))
+	g.GET("/api/v1/inboxes/stats", auth(handleGetInboxesStats))
+	g.GET("/api/v1/inboxes/paused", perm(handleGetPausedInboxes, "inboxes:manage"))
+	g.GET("/api/v1/inboxes/blocked-messages", perm(handleGetBlockedIncomingMessages, "inboxes:manage"))
+	g.DELETE("/api/v1/inboxes/blocked-messages/{id}", perm(handleDeleteBlockedIncomingMessage, "inboxes:manage"))
+	g.POST("/api/v1/inboxes/blocked-messages/{id}/release", perm(handleReleaseBlockedIncomingMessage, "inboxes:manage"))
 	g.GET("/api/v1/inboxes/{id}", perm(handleGetInbox, "inboxes:manage"))
 	g.POST("/api/v1/inboxes", perm(handleCreateInbox, "inboxes:manage"))
 	g.PUT("/api/v1/inboxes/{id}/toggle", perm(handleToggleInbox, "inboxes:manage"))
+	g.PUT("/api/v1/inboxes/{id}/pause", perm(handlePauseInbox, "inboxes:manage"))
+	g.PUT("/api/v1/inboxes/{id}/resume", perm(handleResumeInbox, "inboxes:manage"))
 	g.PUT("/api/v1/inboxes/{id}", perm(handleUpdateInbox, "inboxes:manage"))
 	g.DELETE("/api/v1/inboxes/{id}", perm(handleDeleteInbox, "inboxes:manage"))
 
@@ -161,6 +231,13 @@ func initHandlers(g *fastglue.Fastglue, hub *ws.Hub) {
 	// Reports.
 	g.GET("/api/v1/reports/overview/counts", perm(handleDashboardCounts, "reports:manage"))
 	g.GET("/api/v1/reports/overview/charts", perm(handleDashboardCharts, "reports:manage"))
+	g.GET("/api/v1/reports/agents", perm(handleGetAgentStats, "reports:manage"))
+	g.GET("/api/v1/reports/teams/{id}/workload", perm(handleGetTeamWorkload, "reports:manage"))
+	g.GET("/api/v1/reports/csat/responses", perm(handleGetCSATResponses, "reports:manage"))
+	g.GET("/api/v1/reports/csat/stats", perm(handleGetCSATStats, "reports:manage"))
+
+	// Audit logs.
+	g.GET("/api/v1/audit-logs", perm(handleGetAuditLogs, "audit_logs:read"))
 
 	// Templates.
 	g.GET("/api/v1/templates", perm(handleGetTemplates, "templates:manage"))
@@ -219,6 +296,9 @@ func initHandlers(g *fastglue.Fastglue, hub *ws.Hub) {
 	// Public pages.
 	g.GET("/csat/{uuid}", handleShowCSAT)
 	g.POST("/csat/{uuid}", handleUpdateCSATResponse)
+	g.POST("/webhooks/inboxes/{id}", handleInboxWebhook)
+	g.GET("/tracking/open/{uuid}", handleTrackMessageOpen)
+	g.GET("/tracking/click/{uuid}", handleTrackMessageClick)
 
 	// Health check.
 	g.GET("/health", handleHealthCheck)
@@ -304,7 +384,30 @@ func sendErrorEnvelope(r *fastglue.Request, err error) error {
 		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError,
 			"Error interface conversion failed", nil, fastglue.ErrorType(envelope.GeneralError))
 	}
-	return r.SendErrorEnvelope(e.Code, e.Error(), e.Data, fastglue.ErrorType(e.ErrorType))
+	if e.ErrorCode == "" {
+		return r.SendErrorEnvelope(e.Code, e.Error(), e.Data, fastglue.ErrorType(e.ErrorType))
+	}
+
+	// Errors with a code are sent in the error envelope with an additional `error_code` field.
+	var (
+		msg = e.Error()
+		et  = fastglue.ErrorType(e.ErrorType)
+	)
+	return r.SendJSON(e.Code, codedErrorEnvelope{
+		Envelope: fastglue.Envelope{
+			Status:    "error",
+			Message:   &msg,
+			Data:      e.Data,
+			ErrorType: &et,
+		},
+		ErrorCode: e.ErrorCode,
+	})
+}
+
+// codedErrorEnvelope is the error envelope of errors with an error code.
+type codedErrorEnvelope struct {
+	fastglue.Envelope
+	ErrorCode string `json:"error_code"`
 }
 
 // handleHealthCheck handles the health check endpoint.
@@ -1,10 +1,14 @@
 package main
 
 import (
+	"encoding/json"
+	"errors"
 	"net/mail"
 	"strconv"
 
+	amodels "github.com/abhinavxd/libredesk/internal/auth/models"
 	"github.com/abhinavxd/libredesk/internal/envelope"
+	"github.com/abhinavxd/libredesk/internal/inbox/channel/webhook"
 	imodels "github.com/abhinavxd/libredesk/internal/inbox/models"
 	"github.com/valyala/fasthttp"
 	"github.com/zerodha/fastglue"
@@ -37,6 +41,158 @@ func handleGetInbox(r *fastglue.Request) error {
 	return r.SendEnvelope(inbox)
 }
 
+// handleGetInboxesStats returns the inboxes with their health, paused state and the open and unassigned conversation
+// counts in the conversations list of the filter, for inbox switchers.
+func handleGetInboxesStats(r *fastglue.Request) error {
+	var (
+		app    = r.Context.(*App)
+		auser  = r.RequestCtx.UserValue("user").(amodels.User)
+		filter = conversationFilterFromArgs(r.RequestCtx.QueryArgs())
+	)
+	if err := enforceConversationListAccess(app, auser.ID, filter); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+
+	health, err := app.inbox.Health()
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	counts, err := app.conversation.GetInboxCounts(filter, auser.ID)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+
+	pausedIDs, err := app.conversation.GetPausedInboxIDs()
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+
+	var (
+		paused = make(map[int]bool)
+		out    = make([]imodels.Stats, 0, len(health))
+	)
+	for _, id := range pausedIDs {
+		paused[id] = true
+	}
+	for _, h := range health {
+		out = append(out, imodels.Stats{
+			ID:              h.InboxID,
+			Name:            h.Name,
+			Channel:         h.Channel,
+			Enabled:         h.Status != imodels.HealthStatusDisabled,
+			Paused:          paused[h.InboxID],
+			HealthStatus:    h.Status,
+			OpenCount:       counts[h.InboxID].Open,
+			UnassignedCount: counts[h.InboxID].Unassigned,
+		})
+	}
+	return r.SendEnvelope(out)
+}
+
+// handleGetInboxesHealth returns the connectivity health of all inboxes.
+func handleGetInboxesHealth(r *fastglue.Request) error {
+	var app = r.Context.(*App)
+	health, err := app.inbox.Health()
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(health)
+}
+
+// handleGetPausedInboxes returns the IDs of the inboxes with dispatching paused.
+func handleGetPausedInboxes(r *fastglue.Request) error {
+	var app = r.Context.(*App)
+	ids, err := app.conversation.GetPausedInboxIDs()
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(ids)
+}
+
+// handlePauseInbox pauses dispatching outgoing messages of an inbox.
+func handlePauseInbox(r *fastglue.Request) error {
+	var (
+		app   = r.Context.(*App)
+		id, _ = strconv.Atoi(r.RequestCtx.UserValue("id").(string))
+	)
+	if id <= 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.invalid", "name", "`id`"), nil, envelope.InputError)
+	}
+	if err := app.conversation.PauseInbox(id); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(true)
+}
+
+// handleResumeInbox resumes dispatching outgoing messages of a paused inbox.
+func handleResumeInbox(r *fastglue.Request) error {
+	var (
+		app   = r.Context.(*App)
+		id, _ = strconv.Atoi(r.RequestCtx.UserValue("id").(string))
+	)
+	if id <= 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.invalid", "name", "`id`"), nil, envelope.InputError)
+	}
+	if err := app.conversation.ResumeInbox(id); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(true)
+}
+
+// handleGetBlockedIncomingMessages returns the incoming messages blocked by the sender rate limits.
+func handleGetBlockedIncomingMessages(r *fastglue.Request) error {
+	var (
+		app         = r.Context.(*App)
+		page, _     = strconv.Atoi(string(r.RequestCtx.QueryArgs().Peek("page")))
+		pageSize, _ = strconv.Atoi(string(r.RequestCtx.QueryArgs().Peek("page_size")))
+		total       = 0
+	)
+	messages, err := app.conversation.GetBlockedIncomingMessages(page, pageSize)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if len(messages) > 0 {
+		total = messages[0].Total
+	}
+	return r.SendEnvelope(envelope.PageResults{
+		Results:    messages,
+		Total:      total,
+		PerPage:    pageSize,
+		TotalPages: (total + max(pageSize, 1) - 1) / max(pageSize, 1),
+		Page:       page,
+	})
+}
+
+// handleDeleteBlockedIncomingMessage deletes a reviewed blocked incoming message.
+func handleDeleteBlockedIncomingMessage(r *fastglue.Request) error {
+	var (
+		app   = r.Context.(*App)
+		id, _ = strconv.Atoi(r.RequestCtx.UserValue("id").(string))
+	)
+	if id <= 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.invalid", "name", "`id`"), nil, envelope.InputError)
+	}
+	if err := app.conversation.DeleteBlockedIncomingMessage(id); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(true)
+}
+
+// handleReleaseBlockedIncomingMessage processes a blocked incoming message that was blocked by mistake.
+func handleReleaseBlockedIncomingMessage(r *fastglue.Request) error {
+	var (
+		app   = r.Context.(*App)
+		id, _ = strconv.Atoi(r.RequestCtx.UserValue("id").(string))
+	)
+	if id <= 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.invalid", "name", "`id`"), nil, envelope.InputError)
+	}
+	if err := app.conversation.ReleaseBlockedIncomingMessage(id); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(true)
+}
+
 // handleCreateInbox creates a new inbox
 func handleCreateInbox(r *fastglue.Request) error {
 	var (
@@ -132,6 +288,35 @@ func handleDeleteInbox(r *fastglue.Request) error {
 	return r.SendEnvelope(true)
 }
 
+// handleInboxWebhook ingests a message pushed to a webhook inbox, the body is verified against the signature header.
+func handleInboxWebhook(r *fastglue.Request) error {
+	var (
+		app   = r.Context.(*App)
+		id, _ = strconv.Atoi(r.RequestCtx.UserValue("id").(string))
+	)
+	ibx, err := app.inbox.Get(id)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusNotFound, app.i18n.T("inbox.webhookNotFound"), nil, envelope.NotFoundError)
+	}
+	wh, ok := ibx.(*webhook.Webhook)
+	if !ok {
+		return r.SendErrorEnvelope(fasthttp.StatusNotFound, app.i18n.T("inbox.webhookNotFound"), nil, envelope.NotFoundError)
+	}
+
+	signature := string(r.RequestCtx.Request.Header.Peek(webhook.SignatureHeader))
+	if err := wh.Ingest(r.RequestCtx.PostBody(), signature); err != nil {
+		switch {
+		case errors.Is(err, webhook.ErrInvalidSignature):
+			return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, app.i18n.T("inbox.invalidWebhookSignature"), nil, envelope.UnauthorizedError)
+		case errors.Is(err, webhook.ErrInvalidPayload):
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.T("inbox.invalidWebhookPayload"), err.Error(), envelope.InputError)
+		}
+		app.lo.Error("error ingesting webhook message", "inbox_id", id, "error", err)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, app.i18n.Ts("globals.messages.errorCreating", "name", "{globals.terms.message}"), nil, envelope.GeneralError)
+	}
+	return r.SendEnvelope(true)
+}
+
 // validateInbox validates the inbox
 func validateInbox(app *App, inbox imodels.Inbox) error {
 	// Validate from address.
@@ -147,5 +332,18 @@ func validateInbox(app *App, inbox imodels.Inbox) error {
 	if inbox.Channel == "" {
 		return envelope.NewError(envelope.InputError, app.i18n.Ts("globals.messages.empty", "name", "channel"), nil)
 	}
+
+	// Validate the aliases replies can be sent from.
+	var cfg struct {
+		Aliases []string `json:"aliases"`
+	}
+	if err := json.Unmarshal(inbox.Config, &cfg); err != nil {
+		return envelope.NewError(envelope.InputError, app.i18n.Ts("globals.messages.invalid", "name", "config"), nil)
+	}
+	for _, alias := range cfg.Aliases {
+		if _, err := mail.ParseAddress(alias); err != nil {
+			return envelope.NewError(envelope.InputError, app.i18n.Ts("admin.inbox.invalidAlias", "address", alias), nil)
+		}
+	}
 	return nil
 }
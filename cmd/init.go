@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"maps"
 	"os"
 	"path/filepath"
 	"time"
@@ -13,6 +14,7 @@ import (
 	"html/template"
 
 	"github.com/abhinavxd/libredesk/internal/ai"
+	"github.com/abhinavxd/libredesk/internal/auditlog"
 	auth_ "github.com/abhinavxd/libredesk/internal/auth"
 	"github.com/abhinavxd/libredesk/internal/authz"
 	"github.com/abhinavxd/libredesk/internal/autoassigner"
@@ -20,20 +22,26 @@ import (
 	businesshours "github.com/abhinavxd/libredesk/internal/business_hours"
 	"github.com/abhinavxd/libredesk/internal/colorlog"
 	"github.com/abhinavxd/libredesk/internal/conversation"
+	cmodels "github.com/abhinavxd/libredesk/internal/conversation/models"
 	"github.com/abhinavxd/libredesk/internal/conversation/priority"
 	"github.com/abhinavxd/libredesk/internal/conversation/status"
 	"github.com/abhinavxd/libredesk/internal/csat"
+	csatmodels "github.com/abhinavxd/libredesk/internal/csat/models"
 	customAttribute "github.com/abhinavxd/libredesk/internal/custom_attribute"
 	"github.com/abhinavxd/libredesk/internal/inbox"
 	"github.com/abhinavxd/libredesk/internal/inbox/channel/email"
+	"github.com/abhinavxd/libredesk/internal/inbox/channel/webhook"
 	imodels "github.com/abhinavxd/libredesk/internal/inbox/models"
 	"github.com/abhinavxd/libredesk/internal/macro"
 	"github.com/abhinavxd/libredesk/internal/media"
 	fs "github.com/abhinavxd/libredesk/internal/media/stores/localfs"
 	"github.com/abhinavxd/libredesk/internal/media/stores/s3"
 	notifier "github.com/abhinavxd/libredesk/internal/notification"
+	"github.com/abhinavxd/libredesk/internal/notification/preference"
 	emailnotifier "github.com/abhinavxd/libredesk/internal/notification/providers/email"
 	"github.com/abhinavxd/libredesk/internal/oidc"
+	"github.com/abhinavxd/libredesk/internal/queue"
+	"github.com/abhinavxd/libredesk/internal/report"
 	"github.com/abhinavxd/libredesk/internal/role"
 	"github.com/abhinavxd/libredesk/internal/search"
 	"github.com/abhinavxd/libredesk/internal/setting"
@@ -216,14 +224,33 @@ func initConversations(
 	mediaStore *media.Manager,
 	settings *setting.Manager,
 	csat *csat.Manager,
+	customAttribute *customAttribute.Manager,
 	automationEngine *automation.Engine,
 	template *tmpl.Manager,
 ) *conversation.Manager {
-	c, err := conversation.New(hub, i18n, notif, sla, status, priority, inboxStore, userStore, teamStore, mediaStore, settings, csat, automationEngine, template, conversation.Opts{
-		DB:                       db,
-		Lo:                       initLogger("conversation_manager"),
-		OutgoingMessageQueueSize: ko.MustInt("message.outgoing_queue_size"),
-		IncomingMessageQueueSize: ko.MustInt("message.incoming_queue_size"),
+	c, err := conversation.New(hub, i18n, notif, sla, status, priority, inboxStore, userStore, teamStore, mediaStore, settings, csat, customAttribute, automationEngine, template, conversation.Opts{
+		DB:                         db,
+		Lo:                         initLogger("conversation_manager"),
+		OutgoingMessageQueueSize:   ko.MustInt("message.outgoing_queue_size"),
+		IncomingMessageQueueSize:   ko.MustInt("message.incoming_queue_size"),
+		MaxConcurrentSendsPerInbox: ko.Int("message.outgoing_inbox_concurrency"),
+		OutgoingWorkersMax:         ko.Int("message.outgoing_queue_max_workers"),
+		OutgoingQueueTargetDepth:   ko.Int("message.outgoing_queue_target_depth"),
+		OutgoingWorkerIdleTimeout:  ko.Duration("message.outgoing_worker_idle_timeout"),
+		LastMessagePreviewLen:      ko.Int("message.last_message_preview_length"),
+		IncomingContactRateLimit:   ko.Int("message.incoming_contact_rate_limit"),
+		IncomingInboxRateLimit:     ko.Int("message.incoming_inbox_rate_limit"),
+		IncomingRateWindow:         ko.Duration("message.incoming_rate_window"),
+		IncomingBlockDuration:      ko.Duration("message.incoming_block_duration"),
+		DisableEmailTracking:       ko.Bool("privacy.disable_email_tracking"),
+		MessageRetryMaxAge:         ko.Duration("message.retry_max_age"),
+		BlockedRecipients:          ko.Strings("message.blocked_recipients"),
+		FirstReplyOnReassign:       ko.String("conversation.first_reply_on_reassign"),
+		ProcessingTimeout:          ko.Duration("message.processing_timeout"),
+		OutgoingPriorityOrder:      ko.Bool("message.outgoing_priority_order"),
+		OutgoingPriorityAgeBoost:   ko.Duration("message.outgoing_priority_age_boost"),
+		MaxContentSize:             ko.Int("message.max_content_size"),
+		OversizedContent:           ko.String("message.oversized_content"),
 	})
 	if err != nil {
 		log.Fatalf("error initializing conversation manager: %v", err)
@@ -245,6 +272,20 @@ func initTag(db *sqlx.DB, i18n *i18n.I18n) *tag.Manager {
 	return mgr
 }
 
+// initQueue inits queue manager.
+func initQueue(db *sqlx.DB, i18n *i18n.I18n) *queue.Manager {
+	var lo = initLogger("queue_manager")
+	mgr, err := queue.New(queue.Opts{
+		DB:   db,
+		Lo:   lo,
+		I18n: i18n,
+	})
+	if err != nil {
+		log.Fatalf("error initializing queues: %v", err)
+	}
+	return mgr
+}
+
 // initViews inits view manager.
 func initView(db *sqlx.DB) *view.Manager {
 	var lo = initLogger("view_manager")
@@ -301,12 +342,34 @@ func initSLA(db *sqlx.DB, teamManager *team.Manager, settings *setting.Manager,
 }
 
 // initCSAT inits CSAT manager.
-func initCSAT(db *sqlx.DB, i18n *i18n.I18n) *csat.Manager {
+func initCSAT(db *sqlx.DB, i18n *i18n.I18n, inboxManager *inbox.Manager, teamManager *team.Manager) *csat.Manager {
 	var lo = initLogger("csat")
+
+	// Surveys were always sent on resolve before the statuses were configurable.
+	sendOnStatuses := []string{cmodels.StatusResolved}
+	if ko.Exists("csat.send_on_statuses") {
+		sendOnStatuses = ko.Strings("csat.send_on_statuses")
+	}
+	var questions []csatmodels.Question
+	if err := ko.UnmarshalWithConf("csat.questions", &questions, koanf.UnmarshalConf{Tag: "json"}); err != nil {
+		log.Fatalf("error reading CSAT questions config: %v", err)
+	}
+	// A nil *WordFilter would make a non-nil Moderator, only set it when there are words.
+	var moderator csat.Moderator
+	if f := csat.NewWordFilter(ko.Strings("csat.moderation_words")); f != nil {
+		moderator = f
+	}
 	m, err := csat.New(csat.Opts{
-		DB:   db,
-		Lo:   lo,
-		I18n: i18n,
+		DB:               db,
+		InboxStore:       inboxManager,
+		TeamStore:        teamManager,
+		SendOnStatuses:   sendOnStatuses,
+		Questions:        questions,
+		SubmitRateLimit:  ko.Int("csat.submit_rate_limit"),
+		SubmitRateWindow: ko.Duration("csat.submit_rate_window"),
+		Moderator:        moderator,
+		Lo:               lo,
+		I18n:             i18n,
 	})
 	if err != nil {
 		log.Fatalf("error initializing CSAT manager: %v", err)
@@ -340,9 +403,10 @@ func initTemplate(db *sqlx.DB, fs stuffbin.FileSystem, consts *constants, i18n *
 	return m
 }
 
-// getTmplFuncs returns the template functions.
+// getTmplFuncs returns the template functions, the app functions on top of the template package's helper functions.
 func getTmplFuncs(consts *constants) template.FuncMap {
-	return template.FuncMap{
+	funcs := tmpl.Funcs()
+	maps.Copy(funcs, template.FuncMap{
 		"RootURL": func() string {
 			return consts.AppBaseURL
 		},
@@ -361,7 +425,8 @@ func getTmplFuncs(consts *constants) template.FuncMap {
 		"SiteName": func() string {
 			return consts.SiteName
 		},
-	}
+	})
+	return funcs
 }
 
 // reloadSettings reloads the settings from the database into the Koanf instance.
@@ -478,12 +543,33 @@ func initInbox(db *sqlx.DB, i18n *i18n.I18n) *inbox.Manager {
 }
 
 // initAutomationEngine initializes the automation engine.
-func initAutomationEngine(db *sqlx.DB, i18n *i18n.I18n) *automation.Engine {
+func initAutomationEngine(db *sqlx.DB, i18n *i18n.I18n, userManager *user.Manager) *automation.Engine {
 	var lo = initLogger("automation_engine")
+	name := ko.String("automation.system_user_name")
+	if name == "" {
+		name = "Automation"
+	}
+	systemUser, err := userManager.GetOrCreateAutomationUser(name, ko.String("automation.system_user_avatar_url"))
+	if err != nil {
+		log.Fatalf("error fetching automation system user: %v", err)
+	}
 	engine, err := automation.New(automation.Opts{
-		DB:   db,
-		Lo:   lo,
-		I18n: i18n,
+		DB:         db,
+		Lo:         lo,
+		I18n:       i18n,
+		SystemUser: systemUser,
+		AutoClose: automation.AutoCloseOpts{
+			After:        ko.Duration("automation.auto_close_after"),
+			FromStatuses: ko.Strings("automation.auto_close_statuses"),
+			ToStatus:     ko.String("automation.auto_close_status"),
+		},
+		ReassignmentCooldown: ko.Duration("automation.reassignment_cooldown"),
+		ExternalRouter: automation.ExternalRouterOpts{
+			URL:     ko.String("automation.external_router.url"),
+			Token:   ko.String("automation.external_router.token"),
+			Timeout: ko.Duration("automation.external_router.timeout"),
+		},
+		UserStore: userManager,
 	})
 	if err != nil {
 		log.Fatalf("error initializing automation engine: %v", err)
@@ -526,6 +612,20 @@ func initNotifier() *notifier.Service {
 	return notifier.NewService(notifierProviders, ko.MustInt("notification.concurrency"), ko.MustInt("notification.queue_size"), initLogger("notifier"))
 }
 
+// initNotificationPreference inits notification preference manager.
+func initNotificationPreference(db *sqlx.DB, i18n *i18n.I18n) *preference.Manager {
+	m, err := preference.New(preference.Opts{
+		DB:           db,
+		Lo:           initLogger("notification_preference"),
+		I18n:         i18n,
+		UrgentEvents: ko.Strings("notification.urgent_events"),
+	})
+	if err != nil {
+		log.Fatalf("error initializing notification preference manager: %v", err)
+	}
+	return m
+}
+
 // initEmailInbox initializes the email inbox.
 func initEmailInbox(inboxRecord imodels.Inbox, msgStore inbox.MessageStore, usrStore inbox.UserStore) (inbox.Inbox, error) {
 	var config email.Config
@@ -568,11 +668,40 @@ func initEmailInbox(inboxRecord imodels.Inbox, msgStore inbox.MessageStore, usrS
 	return inbox, nil
 }
 
+// initWebhookInbox initializes the webhook inbox.
+func initWebhookInbox(inboxRecord imodels.Inbox, msgStore inbox.MessageStore, usrStore inbox.UserStore) (inbox.Inbox, error) {
+	var config webhook.Config
+	if err := json.Unmarshal(inboxRecord.Config, &config); err != nil {
+		return nil, fmt.Errorf("unmarshalling `%s` %s config: %w", inboxRecord.Channel, inboxRecord.Name, err)
+	}
+
+	if config.ReplyURL == "" {
+		log.Printf("WARNING: No `reply_url` set for `%s` inbox, replies can't be sent: Name: `%s`", inboxRecord.Channel, inboxRecord.Name)
+	}
+
+	config.From = inboxRecord.From
+
+	inbox, err := webhook.New(msgStore, usrStore, webhook.Opts{
+		ID:     inboxRecord.ID,
+		Config: config,
+		Lo:     initLogger("webhook_inbox"),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("initializing `%s` inbox: `%s` error : %w", inboxRecord.Channel, inboxRecord.Name, err)
+	}
+
+	log.Printf("`%s` inbox successfully initialized", inboxRecord.Name)
+
+	return inbox, nil
+}
+
 // initializeInboxes handles inbox initialization.
 func initializeInboxes(inboxR imodels.Inbox, msgStore inbox.MessageStore, usrStore inbox.UserStore) (inbox.Inbox, error) {
 	switch inboxR.Channel {
 	case "email":
 		return initEmailInbox(inboxR, msgStore, usrStore)
+	case "webhook":
+		return initWebhookInbox(inboxR, msgStore, usrStore)
 	default:
 		return nil, fmt.Errorf("unknown inbox channel: %s", inboxR.Channel)
 	}
@@ -794,6 +923,36 @@ func initSearch(db *sqlx.DB, i18n *i18n.I18n) *search.Manager {
 	return m
 }
 
+// initReport inits report manager.
+func initReport(db *sqlx.DB, i18n *i18n.I18n, conversation *conversation.Manager) *report.Manager {
+	lo := initLogger("report")
+	m, err := report.New(report.Opts{
+		DB:                db,
+		ConversationStore: conversation,
+		Lo:                lo,
+		I18n:              i18n,
+		CacheTTL:          ko.Duration("reports.cache_ttl"),
+	})
+	if err != nil {
+		log.Fatalf("error initializing report manager: %v", err)
+	}
+	return m
+}
+
+// initAuditLog inits audit log manager.
+func initAuditLog(db *sqlx.DB, i18n *i18n.I18n) *auditlog.Manager {
+	lo := initLogger("audit-log")
+	m, err := auditlog.New(auditlog.Opts{
+		DB:   db,
+		Lo:   lo,
+		I18n: i18n,
+	})
+	if err != nil {
+		log.Fatalf("error initializing audit log manager: %v", err)
+	}
+	return m
+}
+
 // initCustomAttribute inits custom attribute manager.
 func initCustomAttribute(db *sqlx.DB, i18n *i18n.I18n) *customAttribute.Manager {
 	lo := initLogger("custom-attribute")
@@ -2,12 +2,12 @@ package main
 
 import (
 	"encoding/json"
-	"slices"
 	"strconv"
 
 	amodels "github.com/abhinavxd/libredesk/internal/auth/models"
 	autoModels "github.com/abhinavxd/libredesk/internal/automation/models"
 	"github.com/abhinavxd/libredesk/internal/envelope"
+	macros "github.com/abhinavxd/libredesk/internal/macro"
 	"github.com/abhinavxd/libredesk/internal/macro/models"
 	"github.com/valyala/fasthttp"
 	"github.com/zerodha/fastglue"
@@ -36,6 +36,25 @@ func handleGetMacros(r *fastglue.Request) error {
 	return r.SendEnvelope(macros)
 }
 
+// handleSuggestMacros returns the macros visible to the agent matching the typed prefix, for the composer's slash command.
+func handleSuggestMacros(r *fastglue.Request) error {
+	var (
+		app      = r.Context.(*App)
+		auser    = r.RequestCtx.UserValue("user").(amodels.User)
+		prefix   = string(r.RequestCtx.QueryArgs().Peek("q"))
+		limit, _ = strconv.Atoi(string(r.RequestCtx.QueryArgs().Peek("limit")))
+	)
+	user, err := app.user.GetAgent(auser.ID, "")
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	macros, err := app.macro.Suggest(prefix, limit, user.ID, user.Teams.IDs())
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(macros)
+}
+
 // handleGetMacro returns a macro.
 func handleGetMacro(r *fastglue.Request) error {
 	var (
@@ -176,11 +195,11 @@ func handleApplyMacro(r *fastglue.Request) error {
 
 	// Validate action permissions.
 	for _, act := range incomingActions {
-		if !isMacroActionAllowed(act.Type) {
+		if !macros.IsActionAllowed(act.Type) {
 			app.lo.Warn("action not allowed in macro", "action", act.Type, "user_id", user.ID)
 			return r.SendErrorEnvelope(fasthttp.StatusForbidden, app.i18n.Ts("macro.actionNotAllowed", "name", act.Type), nil, envelope.PermissionError)
 		}
-		if !hasActionPermission(act.Type, user.Permissions) {
+		if !macros.HasActionPermission(act.Type, user.Permissions) {
 			app.lo.Warn("no permission to execute macro action", "action", act.Type, "user_id", user.ID)
 			return r.SendErrorEnvelope(fasthttp.StatusForbidden, app.i18n.T("macro.permissionDenied"), nil, envelope.PermissionError)
 		}
@@ -212,13 +231,28 @@ func handleApplyMacro(r *fastglue.Request) error {
 	})
 }
 
-// hasActionPermission checks user permission for given action
-func hasActionPermission(action string, userPerms []string) bool {
-	requiredPerm, exists := autoModels.ActionPermissions[action]
-	if !exists {
-		return false
+// handleExecuteMacro applies the actions of a macro to a conversation and sends its reply, in one step.
+func handleExecuteMacro(r *fastglue.Request) error {
+	var (
+		app              = r.Context.(*App)
+		auser            = r.RequestCtx.UserValue("user").(amodels.User)
+		conversationUUID = r.RequestCtx.UserValue("uuid").(string)
+	)
+	id, err := strconv.Atoi(r.RequestCtx.UserValue("id").(string))
+	if err != nil || id <= 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.invalid", "name", "`id`"), nil, envelope.InputError)
+	}
+	user, err := app.user.GetAgent(auser.ID, "")
+	if err != nil {
+		return sendErrorEnvelope(r, err)
 	}
-	return slices.Contains(userPerms, requiredPerm)
+	if _, err := enforceConversationAccess(app, conversationUUID, user); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if err := app.macro.ApplyMacro(conversationUUID, id, user); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(true)
 }
 
 // setDisplayValues sets display values for actions.
@@ -286,15 +320,3 @@ func validateMacro(app *App, macro models.Macro) error {
 	}
 	return nil
 }
-
-// isMacroActionAllowed returns true if the action is allowed in a macro.
-func isMacroActionAllowed(action string) bool {
-	switch action {
-	case autoModels.ActionSendPrivateNote, autoModels.ActionReply:
-		return false
-	case autoModels.ActionAssignTeam, autoModels.ActionAssignUser, autoModels.ActionSetStatus, autoModels.ActionSetPriority, autoModels.ActionAddTags, autoModels.ActionSetTags, autoModels.ActionRemoveTags:
-		return true
-	default:
-		return false
-	}
-}
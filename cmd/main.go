@@ -14,6 +14,7 @@ import (
 	_ "time/tzdata"
 
 	"github.com/abhinavxd/libredesk/internal/ai"
+	"github.com/abhinavxd/libredesk/internal/auditlog"
 	auth_ "github.com/abhinavxd/libredesk/internal/auth"
 	"github.com/abhinavxd/libredesk/internal/authz"
 	businesshours "github.com/abhinavxd/libredesk/internal/business_hours"
@@ -22,6 +23,8 @@ import (
 	customAttribute "github.com/abhinavxd/libredesk/internal/custom_attribute"
 	"github.com/abhinavxd/libredesk/internal/macro"
 	notifier "github.com/abhinavxd/libredesk/internal/notification"
+	"github.com/abhinavxd/libredesk/internal/notification/preference"
+	"github.com/abhinavxd/libredesk/internal/report"
 	"github.com/abhinavxd/libredesk/internal/search"
 	"github.com/abhinavxd/libredesk/internal/sla"
 	"github.com/abhinavxd/libredesk/internal/view"
@@ -33,6 +36,7 @@ import (
 	"github.com/abhinavxd/libredesk/internal/inbox"
 	"github.com/abhinavxd/libredesk/internal/media"
 	"github.com/abhinavxd/libredesk/internal/oidc"
+	"github.com/abhinavxd/libredesk/internal/queue"
 	"github.com/abhinavxd/libredesk/internal/role"
 	"github.com/abhinavxd/libredesk/internal/setting"
 	"github.com/abhinavxd/libredesk/internal/tag"
@@ -75,6 +79,7 @@ type App struct {
 	status          *status.Manager
 	priority        *priority.Manager
 	tag             *tag.Manager
+	queue           *queue.Manager
 	inbox           *inbox.Manager
 	tmpl            *template.Manager
 	macro           *macro.Manager
@@ -86,7 +91,10 @@ type App struct {
 	view            *view.Manager
 	ai              *ai.Manager
 	search          *search.Manager
+	report          *report.Manager
+	auditLog        *auditlog.Manager
 	notifier        *notifier.Service
+	notifPref       *preference.Manager
 	customAttribute *customAttribute.Manager
 
 	// Global state that stores data on an available app update.
@@ -156,43 +164,54 @@ func main() {
 	loadSettings(settings)
 
 	var (
-		autoAssignInterval          = ko.MustDuration("autoassigner.autoassign_interval")
-		unsnoozeInterval            = ko.MustDuration("conversation.unsnooze_interval")
-		automationWorkers           = ko.MustInt("automation.worker_count")
-		messageOutgoingQWorkers     = ko.MustDuration("message.outgoing_queue_workers")
-		messageIncomingQWorkers     = ko.MustDuration("message.incoming_queue_workers")
-		messageOutgoingScanInterval = ko.MustDuration("message.message_outoing_scan_interval")
-		slaEvaluationInterval       = ko.MustDuration("sla.evaluation_interval")
-		lo                          = initLogger(appName)
-		rdb                         = initRedis()
-		constants                   = initConstants()
-		i18n                        = initI18n(fs)
-		csat                        = initCSAT(db, i18n)
-		oidc                        = initOIDC(db, settings, i18n)
-		status                      = initStatus(db, i18n)
-		priority                    = initPriority(db, i18n)
-		auth                        = initAuth(oidc, rdb, i18n)
-		template                    = initTemplate(db, fs, constants, i18n)
-		media                       = initMedia(db, i18n)
-		inbox                       = initInbox(db, i18n)
-		team                        = initTeam(db, i18n)
-		businessHours               = initBusinessHours(db, i18n)
-		user                        = initUser(i18n, db)
-		wsHub                       = initWS(user)
-		notifier                    = initNotifier()
-		automation                  = initAutomationEngine(db, i18n)
-		sla                         = initSLA(db, team, settings, businessHours, notifier, template, user, i18n)
-		conversation                = initConversations(i18n, sla, status, priority, wsHub, notifier, db, inbox, user, team, media, settings, csat, automation, template)
-		autoassigner                = initAutoAssigner(team, user, conversation)
+		autoAssignInterval           = ko.MustDuration("autoassigner.autoassign_interval")
+		unsnoozeInterval             = ko.MustDuration("conversation.unsnooze_interval")
+		draftTTL                     = ko.Duration("conversation.draft_ttl")
+		deletedRetention             = ko.Duration("conversation.deleted_retention")
+		automationWorkers            = ko.MustInt("automation.worker_count")
+		messageOutgoingQWorkers      = ko.MustDuration("message.outgoing_queue_workers")
+		messageIncomingQWorkers      = ko.MustDuration("message.incoming_queue_workers")
+		messageOutgoingScanInterval  = ko.MustDuration("message.message_outoing_scan_interval")
+		slaEvaluationInterval        = ko.MustDuration("sla.evaluation_interval")
+		deferredNotificationInterval = ko.Duration("notification.deferred_scan_interval")
+		watchDigestInterval          = ko.Duration("notification.watch_digest_interval")
+		lo                           = initLogger(appName)
+		rdb                          = initRedis()
+		constants                    = initConstants()
+		i18n                         = initI18n(fs)
+		oidc                         = initOIDC(db, settings, i18n)
+		status                       = initStatus(db, i18n)
+		priority                     = initPriority(db, i18n)
+		auth                         = initAuth(oidc, rdb, i18n)
+		template                     = initTemplate(db, fs, constants, i18n)
+		media                        = initMedia(db, i18n)
+		inbox                        = initInbox(db, i18n)
+		team                         = initTeam(db, i18n)
+		csat                         = initCSAT(db, i18n, inbox, team)
+		businessHours                = initBusinessHours(db, i18n)
+		user                         = initUser(i18n, db)
+		wsHub                        = initWS(user)
+		notifier                     = initNotifier()
+		notifPref                    = initNotificationPreference(db, i18n)
+		automation                   = initAutomationEngine(db, i18n, user)
+		sla                          = initSLA(db, team, settings, businessHours, notifier, template, user, i18n)
+		customAttribute              = initCustomAttribute(db, i18n)
+		conversation                 = initConversations(i18n, sla, status, priority, wsHub, notifier, db, inbox, user, team, media, settings, csat, customAttribute, automation, template)
+		autoassigner                 = initAutoAssigner(team, user, conversation)
 	)
 	automation.SetConversationStore(conversation)
+	notifier.SetPreferenceStore(notifPref)
 
 	startInboxes(ctx, inbox, conversation, user)
 	go automation.Run(ctx, automationWorkers)
 	go autoassigner.Run(ctx, autoAssignInterval)
 	go conversation.Run(ctx, messageIncomingQWorkers, messageOutgoingQWorkers, messageOutgoingScanInterval)
 	go conversation.RunUnsnoozer(ctx, unsnoozeInterval)
+	go conversation.RunDraftCleaner(ctx, draftTTL)
+	go conversation.RunDeletedConversationPurger(ctx, deletedRetention)
+	go conversation.RunWatchDigest(ctx, watchDigestInterval)
 	go notifier.Run(ctx)
+	go notifPref.RunDeferredSender(ctx, notifier, deferredNotificationInterval)
 	go sla.Run(ctx, slaEvaluationInterval)
 	go sla.SendNotifications(ctx)
 	go media.DeleteUnlinkedMedia(ctx)
@@ -214,21 +233,28 @@ func main() {
 		priority:        priority,
 		tmpl:            template,
 		notifier:        notifier,
+		notifPref:       notifPref,
 		consts:          atomic.Value{},
 		conversation:    conversation,
 		automation:      automation,
 		businessHours:   businessHours,
-		customAttribute: initCustomAttribute(db, i18n),
+		customAttribute: customAttribute,
 		authz:           initAuthz(i18n),
 		view:            initView(db),
-		csat:            initCSAT(db, i18n),
+		csat:            csat,
 		search:          initSearch(db, i18n),
+		report:          initReport(db, i18n, conversation),
+		auditLog:        initAuditLog(db, i18n),
 		role:            initRole(db, i18n),
 		tag:             initTag(db, i18n),
+		queue:           initQueue(db, i18n),
 		macro:           initMacro(db, i18n),
 		ai:              initAI(db, i18n),
 	}
 	app.consts.Store(constants)
+	wsHub.SetViewAuthorizer(conversationViewAuthorizer(app))
+	conversation.SetTranslator(app.ai)
+	app.macro.SetConversationStore(conversation)
 
 	g := fastglue.NewGlue()
 	g.SetContext(app)
@@ -10,9 +10,11 @@ import (
 	"slices"
 
 	"github.com/abhinavxd/libredesk/internal/attachment"
+	almodels "github.com/abhinavxd/libredesk/internal/auditlog/models"
 	amodels "github.com/abhinavxd/libredesk/internal/auth/models"
 	"github.com/abhinavxd/libredesk/internal/envelope"
 	"github.com/abhinavxd/libredesk/internal/image"
+	mmodels "github.com/abhinavxd/libredesk/internal/media/models"
 	"github.com/abhinavxd/libredesk/internal/stringutil"
 	"github.com/google/uuid"
 	"github.com/valyala/fasthttp"
@@ -28,6 +30,7 @@ const (
 func handleMediaUpload(r *fastglue.Request) error {
 	var (
 		app     = r.Context.(*App)
+		auser   = r.RequestCtx.UserValue("user").(amodels.User)
 		cleanUp = false
 	)
 
@@ -133,7 +136,7 @@ func handleMediaUpload(r *fastglue.Request) error {
 	}
 
 	// Insert in DB.
-	media, err := app.media.Insert(disposition, srcFileName, srcContentType, "" /**content_id**/, null.NewString(linkedModel, linkedModel != ""), uuid.String(), null.Int{} /**model_id**/, int(srcFileSize), meta)
+	media, err := app.media.Insert(disposition, srcFileName, srcContentType, "" /**content_id**/, null.NewString(linkedModel, linkedModel != ""), uuid.String(), null.Int{} /**model_id**/, int(srcFileSize), meta, auser.ID)
 	if err != nil {
 		cleanUp = true
 		app.lo.Error("error inserting metadata into database", "error", err)
@@ -168,7 +171,8 @@ func handleServeMedia(r *fastglue.Request) error {
 	}
 
 	// For messages, check access to the conversation this message is part of.
-	if media.Model.String == "messages" {
+	var conversationUUID string
+	if media.Model.String == mmodels.ModelMessages {
 		conversation, err := app.conversation.GetConversationByMessageID(media.ModelID.Int)
 		if err != nil {
 			return sendErrorEnvelope(r, err)
@@ -177,17 +181,34 @@ func handleServeMedia(r *fastglue.Request) error {
 		if err != nil {
 			return sendErrorEnvelope(r, err)
 		}
+		conversationUUID = conversation.UUID
 	}
 
 	if !allowed {
-		return r.SendErrorEnvelope(http.StatusUnauthorized, app.i18n.Ts("globals.messages.denied", "name", "{globals.terms.permission}"), nil, envelope.UnauthorizedError)
+		app.lo.Warn("media access denied", "user_id", user.ID, "media_uuid", media.UUID, "conversation_uuid", conversationUUID)
+		return r.SendErrorEnvelope(http.StatusForbidden, app.i18n.Ts("globals.messages.denied", "name", "{globals.terms.permission}"), nil, envelope.PermissionError)
 	}
+
+	// Record downloads of message attachments, thumbnails and inline images are loaded with the conversation.
+	if conversationUUID != "" && !strings.HasPrefix(uuid, thumbPrefix) && media.Disposition.String != attachment.DispositionInline {
+		recordAudit(r, almodels.ActionDownload, almodels.TargetMedia, media.ID, nil, map[string]any{
+			"uuid":              media.UUID,
+			"filename":          media.Filename,
+			"conversation_uuid": conversationUUID,
+		})
+	}
+	// Identical files share a blob, serve the blob of the media.
+	blobName := media.BlobName
+	if strings.HasPrefix(uuid, thumbPrefix) {
+		blobName = thumbPrefix + blobName
+	}
+
 	consts := app.consts.Load().(*constants)
 	switch consts.UploadProvider {
 	case "fs":
-		fasthttp.ServeFile(r.RequestCtx, filepath.Join(ko.String("upload.fs.upload_path"), uuid))
+		fasthttp.ServeFile(r.RequestCtx, filepath.Join(ko.String("upload.fs.upload_path"), blobName))
 	case "s3":
-		r.RequestCtx.Redirect(app.media.GetURL(uuid), http.StatusFound)
+		r.RequestCtx.Redirect(app.media.GetURL(blobName), http.StatusFound)
 	}
 	return nil
 }
@@ -5,6 +5,7 @@ import (
 
 	amodels "github.com/abhinavxd/libredesk/internal/auth/models"
 	"github.com/abhinavxd/libredesk/internal/automation/models"
+	cmodels "github.com/abhinavxd/libredesk/internal/conversation/models"
 	"github.com/abhinavxd/libredesk/internal/envelope"
 	medModels "github.com/abhinavxd/libredesk/internal/media/models"
 	"github.com/valyala/fasthttp"
@@ -17,6 +18,14 @@ type messageReq struct {
 	Private     bool     `json:"private"`
 	CC          []string `json:"cc"`
 	BCC         []string `json:"bcc"`
+	// ReplyAll also sends the reply to the To and Cc recipients of the latest incoming message.
+	ReplyAll bool `json:"reply_all"`
+	// TranslateTo translates the reply to the language before sending, usually the contact's language.
+	TranslateTo string `json:"translate_to"`
+}
+
+type translateMessageReq struct {
+	TargetLang string `json:"target_lang"`
 }
 
 // handleGetMessages returns messages for a conversation.
@@ -27,6 +36,7 @@ func handleGetMessages(r *fastglue.Request) error {
 		auser       = r.RequestCtx.UserValue("user").(amodels.User)
 		page, _     = strconv.Atoi(string(r.RequestCtx.QueryArgs().Peek("page")))
 		pageSize, _ = strconv.Atoi(string(r.RequestCtx.QueryArgs().Peek("page_size")))
+		typeFilter  = string(r.RequestCtx.QueryArgs().Peek("type"))
 		total       = 0
 	)
 
@@ -41,7 +51,7 @@ func handleGetMessages(r *fastglue.Request) error {
 		return sendErrorEnvelope(r, err)
 	}
 
-	messages, pageSize, err := app.conversation.GetConversationMessages(uuid, page, pageSize)
+	messages, pageSize, err := app.conversation.GetConversationMessages(uuid, typeFilter, page, pageSize)
 	if err != nil {
 		return sendErrorEnvelope(r, err)
 	}
@@ -50,7 +60,8 @@ func handleGetMessages(r *fastglue.Request) error {
 		total = messages[i].Total
 		// Populate attachment URLs
 		for j := range messages[i].Attachments {
-			messages[i].Attachments[j].URL = app.media.GetURL(messages[i].Attachments[j].UUID)
+			messages[i].Attachments[j].URL = app.media.GetMediaURL(messages[i].Attachments[j].UUID, messages[i].Attachments[j].BlobName)
+			messages[i].Attachments[j].BlobName = ""
 		}
 		// Redact CSAT survey link
 		messages[i].CensorCSATContent()
@@ -93,12 +104,53 @@ func handleGetMessage(r *fastglue.Request) error {
 	message.CensorCSATContent()
 
 	for j := range message.Attachments {
-		message.Attachments[j].URL = app.media.GetURL(message.Attachments[j].UUID)
+		message.Attachments[j].URL = app.media.GetMediaURL(message.Attachments[j].UUID, message.Attachments[j].BlobName)
+		message.Attachments[j].BlobName = ""
 	}
 
 	return r.SendEnvelope(message)
 }
 
+// handleTranslateMessage returns the message content translated to the requested language.
+func handleTranslateMessage(r *fastglue.Request) error {
+	var (
+		app   = r.Context.(*App)
+		uuid  = r.RequestCtx.UserValue("uuid").(string)
+		cuuid = r.RequestCtx.UserValue("cuuid").(string)
+		auser = r.RequestCtx.UserValue("user").(amodels.User)
+		req   = translateMessageReq{}
+	)
+
+	if err := r.Decode(&req, "json"); err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.errorParsing", "name", "{globals.terms.request}"), nil, envelope.InputError)
+	}
+
+	user, err := app.user.GetAgent(auser.ID, "")
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+
+	// Check permission
+	if _, err = enforceConversationAccess(app, cuuid, user); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+
+	// Make sure the message belongs to the conversation.
+	msgConvUUID, err := app.conversation.GetMessageConversationUUID(uuid)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if msgConvUUID != cuuid {
+		return r.SendErrorEnvelope(fasthttp.StatusNotFound, app.i18n.Ts("globals.messages.notFound", "name", "{globals.terms.message}"), nil, envelope.NotFoundError)
+	}
+
+	translated, err := app.conversation.TranslateMessage(uuid, req.TargetLang)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(map[string]string{"content": translated, "lang": req.TargetLang})
+}
+
 // handleRetryMessage changes message status so it can be retried for sending.
 func handleRetryMessage(r *fastglue.Request) error {
 	var (
@@ -125,13 +177,111 @@ func handleRetryMessage(r *fastglue.Request) error {
 	return r.SendEnvelope(true)
 }
 
+// handleRetryFailedMessages requeues all the failed outgoing messages of a conversation.
+func handleRetryFailedMessages(r *fastglue.Request) error {
+	var (
+		app   = r.Context.(*App)
+		cuuid = r.RequestCtx.UserValue("cuuid").(string)
+		auser = r.RequestCtx.UserValue("user").(amodels.User)
+	)
+
+	user, err := app.user.GetAgent(auser.ID, "")
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+
+	_, err = enforceConversationAccess(app, cuuid, user)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+
+	requeued, err := app.conversation.RetryFailedMessages(cuuid, user)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(map[string]int{"requeued": requeued})
+}
+
+// handleGetMessageStatusHistory returns the status transitions of a message.
+func handleGetMessageStatusHistory(r *fastglue.Request) error {
+	var (
+		app   = r.Context.(*App)
+		uuid  = r.RequestCtx.UserValue("uuid").(string)
+		cuuid = r.RequestCtx.UserValue("cuuid").(string)
+		auser = r.RequestCtx.UserValue("user").(amodels.User)
+	)
+	user, err := app.user.GetAgent(auser.ID, "")
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if _, err = enforceConversationAccess(app, cuuid, user); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+
+	// Make sure the message belongs to the conversation.
+	msgConvUUID, err := app.conversation.GetMessageConversationUUID(uuid)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if msgConvUUID != cuuid {
+		return r.SendErrorEnvelope(fasthttp.StatusNotFound, app.i18n.Ts("globals.messages.notFound", "name", "{globals.terms.message}"), nil, envelope.NotFoundError)
+	}
+
+	history, err := app.conversation.GetMessageStatusHistory(uuid)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(history)
+}
+
+// handleAttachToMessage attaches media uploaded by the agent to a pending outgoing message before it's sent.
+func handleAttachToMessage(r *fastglue.Request) error {
+	var (
+		app   = r.Context.(*App)
+		uuid  = r.RequestCtx.UserValue("uuid").(string)
+		cuuid = r.RequestCtx.UserValue("cuuid").(string)
+		auser = r.RequestCtx.UserValue("user").(amodels.User)
+		req   = struct {
+			MediaIDs []int `json:"media_ids"`
+		}{}
+	)
+
+	if err := r.Decode(&req, "json"); err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.errorParsing", "name", "{globals.terms.request}"), nil, envelope.InputError)
+	}
+	if len(req.MediaIDs) == 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.empty", "name", "`media_ids`"), nil, envelope.InputError)
+	}
+
+	user, err := app.user.GetAgent(auser.ID, "")
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+
+	// Check permission
+	if _, err = enforceConversationAccess(app, cuuid, user); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	msgConversationUUID, err := app.conversation.GetMessageConversationUUID(uuid)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if msgConversationUUID != cuuid {
+		return r.SendErrorEnvelope(fasthttp.StatusNotFound, app.i18n.Ts("globals.messages.notFound", "name", "{globals.terms.message}"), nil, envelope.NotFoundError)
+	}
+
+	if err := app.conversation.AttachToMessage(uuid, req.MediaIDs, user.ID); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(true)
+}
+
 // handleSendMessage sends a message in a conversation.
 func handleSendMessage(r *fastglue.Request) error {
 	var (
 		app   = r.Context.(*App)
 		auser = r.RequestCtx.UserValue("user").(amodels.User)
 		cuuid = r.RequestCtx.UserValue("cuuid").(string)
-		media = []medModels.Media{}
 		req   = messageReq{}
 	)
 
@@ -151,13 +301,9 @@ func handleSendMessage(r *fastglue.Request) error {
 		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, app.i18n.Ts("globals.messages.errorParsing", "name", "{globals.terms.request}"), nil, envelope.InputError)
 	}
 
-	for _, id := range req.Attachments {
-		m, err := app.media.Get(id, "")
-		if err != nil {
-			app.lo.Error("error fetching media", "error", err)
-			return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, app.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.media}"), nil, envelope.GeneralError)
-		}
-		media = append(media, m)
+	media, err := getMessageMedia(app, req.Attachments)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
 	}
 
 	if req.Private {
@@ -165,7 +311,20 @@ func handleSendMessage(r *fastglue.Request) error {
 			return sendErrorEnvelope(r, err)
 		}
 	} else {
-		if err := app.conversation.SendReply(media, conv.InboxID, user.ID, cuuid, req.Message, req.CC, req.BCC, map[string]any{} /**meta**/); err != nil {
+		var meta = map[string]any{}
+		if req.ReplyAll {
+			meta["reply_all"] = true
+		}
+		if req.TranslateTo != "" {
+			translated, err := app.conversation.TranslateText(req.Message, req.TranslateTo)
+			if err != nil {
+				return sendErrorEnvelope(r, err)
+			}
+			meta["original_content"] = req.Message
+			meta["translated_to"] = req.TranslateTo
+			req.Message = translated
+		}
+		if err := app.conversation.SendReply(media, conv.InboxID, user.ID, cuuid, req.Message, req.CC, req.BCC, meta); err != nil {
 			return sendErrorEnvelope(r, err)
 		}
 		// Evaluate automation rules.
@@ -174,3 +333,56 @@ func handleSendMessage(r *fastglue.Request) error {
 
 	return r.SendEnvelope(true)
 }
+
+// handleValidateMessage checks a message being composed without sending it, returning the reasons it can't be sent.
+func handleValidateMessage(r *fastglue.Request) error {
+	var (
+		app   = r.Context.(*App)
+		auser = r.RequestCtx.UserValue("user").(amodels.User)
+		cuuid = r.RequestCtx.UserValue("cuuid").(string)
+		req   = messageReq{}
+	)
+
+	user, err := app.user.GetAgent(auser.ID, "")
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	conv, err := enforceConversationAccess(app, cuuid, user)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+
+	if err := r.Decode(&req, "json"); err != nil {
+		app.lo.Error("error unmarshalling message request", "error", err)
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.errorParsing", "name", "{globals.terms.request}"), nil, envelope.InputError)
+	}
+
+	media, err := getMessageMedia(app, req.Attachments)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if err := app.conversation.ValidateOutgoing(cmodels.Message{
+		ConversationID:   conv.ID,
+		ConversationUUID: cuuid,
+		Content:          req.Message,
+		Private:          req.Private,
+		Media:            media,
+	}); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(true)
+}
+
+// getMessageMedia fetches the uploaded media to attach to a message.
+func getMessageMedia(app *App, ids []int) ([]medModels.Media, error) {
+	var media = make([]medModels.Media, 0, len(ids))
+	for _, id := range ids {
+		m, err := app.media.Get(id, "")
+		if err != nil {
+			app.lo.Error("error fetching media", "error", err)
+			return nil, envelope.NewError(envelope.GeneralError, app.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.media}"), nil)
+		}
+		media = append(media, m)
+	}
+	return media, nil
+}
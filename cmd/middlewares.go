@@ -6,6 +6,7 @@ import (
 
 	amodels "github.com/abhinavxd/libredesk/internal/auth/models"
 	"github.com/abhinavxd/libredesk/internal/envelope"
+	umodels "github.com/abhinavxd/libredesk/internal/user/models"
 	"github.com/valyala/fasthttp"
 	"github.com/zerodha/fastglue"
 	"github.com/zerodha/simplesessions/v3"
@@ -31,21 +32,38 @@ func tryAuth(handler fastglue.FastRequestHandler) fastglue.FastRequestHandler {
 
 		// Set user in context if found.
 		r.RequestCtx.SetUserValue("user", amodels.User{
-			ID:        user.ID,
-			Email:     user.Email.String,
-			FirstName: user.FirstName,
-			LastName:  user.LastName,
+			ID:          user.ID,
+			Email:       user.Email.String,
+			FirstName:   user.FirstName,
+			LastName:    user.LastName,
+			Permissions: user.Permissions,
 		})
 
 		return handler(r)
 	}
 }
 
-// auth validates the session and adds the user to the request context.
+// auth validates the session and adds the user to the request context. Requests bearing an API token are
+// authenticated with the token instead.
 func auth(handler fastglue.FastRequestHandler) fastglue.FastRequestHandler {
 	return func(r *fastglue.Request) error {
 		var app = r.Context.(*App)
 
+		if apiToken, ok := bearerToken(r); ok {
+			token, user, err := app.user.ValidateAPIToken(apiToken)
+			if err != nil {
+				return sendErrorEnvelope(r, err)
+			}
+			r.RequestCtx.SetUserValue("user", amodels.User{
+				ID:          user.ID,
+				Email:       user.Email.String,
+				FirstName:   user.FirstName,
+				LastName:    user.LastName,
+				Permissions: tokenPermissions(token, user),
+			})
+			return handler(r)
+		}
+
 		// Validate session and fetch user.
 		userSession, err := app.auth.ValidateSession(r)
 		if err != nil || userSession.ID <= 0 {
@@ -59,18 +77,30 @@ func auth(handler fastglue.FastRequestHandler) fastglue.FastRequestHandler {
 			return sendErrorEnvelope(r, err)
 		}
 		r.RequestCtx.SetUserValue("user", amodels.User{
-			ID:        user.ID,
-			Email:     user.Email.String,
-			FirstName: user.FirstName,
-			LastName:  user.LastName,
+			ID:          user.ID,
+			Email:       user.Email.String,
+			FirstName:   user.FirstName,
+			LastName:    user.LastName,
+			Permissions: user.Permissions,
 		})
 
 		return handler(r)
 	}
 }
 
+// sessionAuth is auth for the routes that only accept a session, requests bearing an API token are rejected.
+func sessionAuth(handler fastglue.FastRequestHandler) fastglue.FastRequestHandler {
+	return func(r *fastglue.Request) error {
+		var app = r.Context.(*App)
+		if _, ok := bearerToken(r); ok {
+			return r.SendErrorEnvelope(http.StatusForbidden, app.i18n.T("auth.sessionRequired"), nil, envelope.PermissionError)
+		}
+		return auth(handler)(r)
+	}
+}
+
 // perm matches the CSRF token and checks if the user has the required permission to access the endpoint.
-// and sets the user in the request context.
+// and sets the user in the request context. Requests bearing an API token are authenticated with the token instead.
 func perm(handler fastglue.FastRequestHandler, perm string) fastglue.FastRequestHandler {
 	return func(r *fastglue.Request) error {
 		var (
@@ -79,6 +109,10 @@ func perm(handler fastglue.FastRequestHandler, perm string) fastglue.FastRequest
 			hdrToken    = string(r.RequestCtx.Request.Header.Peek("X-CSRFTOKEN"))
 		)
 
+		if apiToken, ok := bearerToken(r); ok {
+			return tokenPerm(r, handler, apiToken, perm)
+		}
+
 		// Match CSRF token from cookie and header.
 		if cookieToken == "" || hdrToken == "" || cookieToken != hdrToken {
 			app.lo.Error("csrf token mismatch", "cookie_token", cookieToken, "header_token", hdrToken)
@@ -106,30 +140,88 @@ func perm(handler fastglue.FastRequestHandler, perm string) fastglue.FastRequest
 			return r.SendErrorEnvelope(http.StatusUnauthorized, app.i18n.T("user.accountDisabled"), nil, envelope.PermissionError)
 		}
 
-		// Split the permission string into object and action and enforce it.
-		parts := strings.Split(perm, ":")
-		if len(parts) != 2 {
-			return r.SendErrorEnvelope(http.StatusInternalServerError, app.i18n.Ts("globals.messages.invalid", "name", "{globals.terms.permission}"), nil, envelope.GeneralError)
-		}
-		object, action := parts[0], parts[1]
-		ok, err := app.authz.Enforce(user, object, action)
-		if err != nil {
-			return r.SendErrorEnvelope(http.StatusInternalServerError, app.i18n.Ts("globals.messages.errorChecking", "name", "{globals.terms.permission}"), nil, envelope.GeneralError)
-		}
-		if !ok {
-			return r.SendErrorEnvelope(http.StatusForbidden, app.i18n.Ts("globals.messages.denied", "name", "{globals.terms.permission}"), nil, envelope.PermissionError)
+		return enforcePerm(r, handler, user, user.Permissions, perm)
+	}
+}
+
+// tokenPerm authenticates the request with an API token, checks that the token's scopes and its user allow
+// the permission and sets the user in the request context. CSRF checks don't apply as no cookies are involved.
+func tokenPerm(r *fastglue.Request, handler fastglue.FastRequestHandler, apiToken, perm string) error {
+	var app = r.Context.(*App)
+	token, user, err := app.user.ValidateAPIToken(apiToken)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if !token.HasScope(perm) {
+		return r.SendErrorEnvelope(http.StatusForbidden, app.i18n.Ts("globals.messages.denied", "name", "{globals.terms.permission}"), nil, envelope.PermissionError)
+	}
+
+	return enforcePerm(r, handler, user, tokenPermissions(token, user), perm)
+}
+
+// tokenPermissions narrows the permissions of the token's user to the token's scopes, they're the permissions
+// handlers see for requests authenticated with the token.
+func tokenPermissions(token umodels.APIToken, user umodels.User) []string {
+	permissions := make([]string, 0, len(user.Permissions))
+	for _, p := range user.Permissions {
+		if token.HasScope(p) {
+			permissions = append(permissions, p)
 		}
+	}
+	return permissions
+}
 
-		// Set user in the request context.
-		r.RequestCtx.SetUserValue("user", amodels.User{
-			ID:        user.ID,
-			Email:     user.Email.String,
-			FirstName: user.FirstName,
-			LastName:  user.LastName,
-		})
+// enforcePerm checks if the user has the permission and sets the user along with the passed permissions
+// in the request context.
+func enforcePerm(r *fastglue.Request, handler fastglue.FastRequestHandler, user umodels.User, permissions []string, perm string) error {
+	var app = r.Context.(*App)
 
-		return handler(r)
+	// Split the permission string into object and action and enforce it.
+	parts := strings.Split(perm, ":")
+	if len(parts) != 2 {
+		return r.SendErrorEnvelope(http.StatusInternalServerError, app.i18n.Ts("globals.messages.invalid", "name", "{globals.terms.permission}"), nil, envelope.GeneralError)
 	}
+	object, action := parts[0], parts[1]
+	ok, err := app.authz.Enforce(user, object, action)
+	if err != nil {
+		return r.SendErrorEnvelope(http.StatusInternalServerError, app.i18n.Ts("globals.messages.errorChecking", "name", "{globals.terms.permission}"), nil, envelope.GeneralError)
+	}
+	if !ok {
+		return r.SendErrorEnvelope(http.StatusForbidden, app.i18n.Ts("globals.messages.denied", "name", "{globals.terms.permission}"), nil, envelope.PermissionError)
+	}
+
+	// Set user in the request context.
+	r.RequestCtx.SetUserValue("user", amodels.User{
+		ID:          user.ID,
+		Email:       user.Email.String,
+		FirstName:   user.FirstName,
+		LastName:    user.LastName,
+		Permissions: permissions,
+	})
+
+	return handler(r)
+}
+
+// requirePermission returns a permission error if the user in the request context lacks the permission.
+// For checks inside handlers whose route can't be guarded by a single permission.
+func requirePermission(r *fastglue.Request, perm string) error {
+	var (
+		app      = r.Context.(*App)
+		auser, _ = r.RequestCtx.UserValue("user").(amodels.User)
+	)
+	if !auser.HasPermission(perm) {
+		app.lo.Warn("permission denied", "user_id", auser.ID, "permission", perm)
+		return envelope.NewError(envelope.PermissionError, app.i18n.Ts("globals.messages.denied", "name", "{globals.terms.permission}"), nil)
+	}
+	return nil
+}
+
+// bearerToken returns the token from the `Authorization: Bearer <token>` header.
+func bearerToken(r *fastglue.Request) (string, bool) {
+	hdr := string(r.RequestCtx.Request.Header.Peek("Authorization"))
+	token, ok := strings.CutPrefix(hdr, "Bearer ")
+	token = strings.TrimSpace(token)
+	return token, ok && token != ""
 }
 
 // authPage ensures the user is logged in; otherwise, redirects to the login page.
@@ -0,0 +1,83 @@
+package main
+
+import (
+	amodels "github.com/abhinavxd/libredesk/internal/auth/models"
+	"github.com/abhinavxd/libredesk/internal/envelope"
+	pmodels "github.com/abhinavxd/libredesk/internal/notification/preference/models"
+	"github.com/valyala/fasthttp"
+	"github.com/zerodha/fastglue"
+)
+
+// handleGetNotificationPreferences returns the notification preferences of the current agent.
+func handleGetNotificationPreferences(r *fastglue.Request) error {
+	var (
+		app   = r.Context.(*App)
+		auser = r.RequestCtx.UserValue("user").(amodels.User)
+	)
+	prefs, err := app.notifPref.GetAll(auser.ID)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(prefs)
+}
+
+// handleUpdateNotificationPreferences saves the passed notification preferences of the current agent.
+func handleUpdateNotificationPreferences(r *fastglue.Request) error {
+	var (
+		app   = r.Context.(*App)
+		auser = r.RequestCtx.UserValue("user").(amodels.User)
+		req   = []pmodels.Preference{}
+	)
+	if err := r.Decode(&req, "json"); err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.errorParsing", "name", "{globals.terms.request}"), err.Error(), envelope.InputError)
+	}
+	if err := app.notifPref.Update(auser.ID, req); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	prefs, err := app.notifPref.GetAll(auser.ID)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(prefs)
+}
+
+// handleResetNotificationPreferences resets the notification preferences of the current agent to the defaults.
+func handleResetNotificationPreferences(r *fastglue.Request) error {
+	var (
+		app   = r.Context.(*App)
+		auser = r.RequestCtx.UserValue("user").(amodels.User)
+	)
+	if err := app.notifPref.Reset(auser.ID); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(true)
+}
+
+// handleGetQuietHours returns the quiet hours of the current agent.
+func handleGetQuietHours(r *fastglue.Request) error {
+	var (
+		app   = r.Context.(*App)
+		auser = r.RequestCtx.UserValue("user").(amodels.User)
+	)
+	qh, err := app.notifPref.GetQuietHours(auser.ID)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(qh)
+}
+
+// handleUpdateQuietHours saves the quiet hours of the current agent.
+func handleUpdateQuietHours(r *fastglue.Request) error {
+	var (
+		app   = r.Context.(*App)
+		auser = r.RequestCtx.UserValue("user").(amodels.User)
+		req   = pmodels.QuietHours{}
+	)
+	if err := r.Decode(&req, "json"); err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.errorParsing", "name", "{globals.terms.request}"), err.Error(), envelope.InputError)
+	}
+	if err := app.notifPref.UpdateQuietHours(auser.ID, req); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(req)
+}
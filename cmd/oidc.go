@@ -4,6 +4,7 @@ import (
 	"strconv"
 	"strings"
 
+	almodels "github.com/abhinavxd/libredesk/internal/auditlog/models"
 	"github.com/abhinavxd/libredesk/internal/envelope"
 	"github.com/abhinavxd/libredesk/internal/oidc/models"
 	"github.com/abhinavxd/libredesk/internal/stringutil"
@@ -72,9 +73,11 @@ func handleCreateOIDC(r *fastglue.Request) error {
 		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.errorParsing", "name", "{globals.terms.request}"), nil, envelope.GeneralError)
 	}
 
-	if err := app.oidc.Create(req); err != nil {
+	created, err := app.oidc.Create(req)
+	if err != nil {
 		return sendErrorEnvelope(r, err)
 	}
+	recordAudit(r, almodels.ActionCreate, almodels.TargetOIDC, created.ID, nil, created)
 
 	// Reload the auth manager to update the OIDC providers.
 	if err := reloadAuth(app); err != nil {
@@ -98,9 +101,18 @@ func handleUpdateOIDC(r *fastglue.Request) error {
 		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.errorParsing", "name", "{globals.terms.request}"), nil, envelope.GeneralError)
 	}
 
+	before, err := app.oidc.Get(id, true)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
 	if err = app.oidc.Update(id, req); err != nil {
 		return sendErrorEnvelope(r, err)
 	}
+	after, err := app.oidc.Get(id, true)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	recordAudit(r, almodels.ActionUpdate, almodels.TargetOIDC, id, before, after)
 
 	// Reload the auth manager to update the OIDC providers.
 	if err := reloadAuth(app); err != nil {
@@ -116,8 +128,13 @@ func handleDeleteOIDC(r *fastglue.Request) error {
 	if err != nil || id == 0 {
 		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.invalid", "name", "OIDC `id`"), nil, envelope.InputError)
 	}
+	before, err := app.oidc.Get(id, true)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
 	if err = app.oidc.Delete(id); err != nil {
 		return sendErrorEnvelope(r, err)
 	}
+	recordAudit(r, almodels.ActionDelete, almodels.TargetOIDC, id, before, nil)
 	return r.SendEnvelope(true)
 }
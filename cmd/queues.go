@@ -0,0 +1,194 @@
+package main
+
+import (
+	"strconv"
+
+	amodels "github.com/abhinavxd/libredesk/internal/auth/models"
+	authzModels "github.com/abhinavxd/libredesk/internal/authz/models"
+	"github.com/abhinavxd/libredesk/internal/envelope"
+	qmodels "github.com/abhinavxd/libredesk/internal/queue/models"
+	"github.com/valyala/fasthttp"
+	"github.com/volatiletech/null/v9"
+	"github.com/zerodha/fastglue"
+)
+
+// queueReq is the request body to create or update a queue.
+type queueReq struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	TeamID      int    `json:"team_id"`
+}
+
+// handleGetQueues returns all queues.
+func handleGetQueues(r *fastglue.Request) error {
+	var app = r.Context.(*App)
+	queues, err := app.queue.GetAll()
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(queues)
+}
+
+// handleCreateQueue creates a new queue.
+func handleCreateQueue(r *fastglue.Request) error {
+	var (
+		app = r.Context.(*App)
+		req = queueReq{}
+	)
+	if err := r.Decode(&req, "json"); err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.errorParsing", "name", "{globals.terms.request}"), err.Error(), envelope.InputError)
+	}
+	if req.Name == "" {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.empty", "name", "`name`"), nil, envelope.InputError)
+	}
+	queue, err := app.queue.Create(req.Name, req.Description, null.NewInt(req.TeamID, req.TeamID > 0))
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(queue)
+}
+
+// handleUpdateQueue updates an existing queue.
+func handleUpdateQueue(r *fastglue.Request) error {
+	var (
+		app = r.Context.(*App)
+		req = queueReq{}
+	)
+	id, err := strconv.Atoi(r.RequestCtx.UserValue("id").(string))
+	if err != nil || id <= 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.invalid", "name", "`id`"), nil, envelope.InputError)
+	}
+	if err := r.Decode(&req, "json"); err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.errorParsing", "name", "{globals.terms.request}"), err.Error(), envelope.InputError)
+	}
+	if req.Name == "" {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.empty", "name", "`name`"), nil, envelope.InputError)
+	}
+	queue, err := app.queue.Update(id, req.Name, req.Description, null.NewInt(req.TeamID, req.TeamID > 0))
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(queue)
+}
+
+// handleDeleteQueue deletes a queue.
+func handleDeleteQueue(r *fastglue.Request) error {
+	var app = r.Context.(*App)
+	id, err := strconv.Atoi(r.RequestCtx.UserValue("id").(string))
+	if err != nil || id <= 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.invalid", "name", "`id`"), nil, envelope.InputError)
+	}
+	if err := app.queue.Delete(id); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(true)
+}
+
+// handleGetQueueConversations returns the conversations waiting in a queue.
+func handleGetQueueConversations(r *fastglue.Request) error {
+	var (
+		app         = r.Context.(*App)
+		order       = string(r.RequestCtx.QueryArgs().Peek("order"))
+		orderBy     = string(r.RequestCtx.QueryArgs().Peek("order_by"))
+		filters     = string(r.RequestCtx.QueryArgs().Peek("filters"))
+		page, _     = strconv.Atoi(string(r.RequestCtx.QueryArgs().Peek("page")))
+		pageSize, _ = strconv.Atoi(string(r.RequestCtx.QueryArgs().Peek("page_size")))
+		total       = 0
+	)
+	queue, err := enforceQueueAccess(r)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+
+	conversations, err := app.conversation.GetQueueConversationsList(queue.ID, order, orderBy, filters, page, pageSize)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if len(conversations) > 0 {
+		total = conversations[0].Total
+	}
+
+	return r.SendEnvelope(envelope.PageResults{
+		Results:    conversations,
+		Total:      total,
+		PerPage:    pageSize,
+		TotalPages: (total + pageSize - 1) / pageSize,
+		Page:       page,
+	})
+}
+
+// handlePullQueueConversation assigns the longest waiting conversation of a queue to the user and returns it.
+func handlePullQueueConversation(r *fastglue.Request) error {
+	var (
+		app   = r.Context.(*App)
+		auser = r.RequestCtx.UserValue("user").(amodels.User)
+	)
+	queue, err := enforceQueueAccess(r)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+
+	user, err := app.user.GetAgent(auser.ID, "")
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	conversation, err := app.conversation.PullFromQueue(queue.ID, user)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(conversation)
+}
+
+// handleRouteConversationToQueue moves a conversation to a queue.
+func handleRouteConversationToQueue(r *fastglue.Request) error {
+	var (
+		app   = r.Context.(*App)
+		uuid  = r.RequestCtx.UserValue("uuid").(string)
+		auser = r.RequestCtx.UserValue("user").(amodels.User)
+	)
+	queueID, err := r.RequestCtx.PostArgs().GetUint("queue_id")
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.invalid", "name", "`queue_id`"), nil, envelope.InputError)
+	}
+
+	user, err := app.user.GetAgent(auser.ID, "")
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if _, err := enforceConversationAccess(app, uuid, user); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if err := app.conversation.RouteToQueue(uuid, queueID, user); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(true)
+}
+
+// enforceQueueAccess returns the queue of the request if the user can pull from it. Team queues are open to the
+// members of the team, other queues to users who can read unassigned conversations.
+func enforceQueueAccess(r *fastglue.Request) (qmodels.Queue, error) {
+	var (
+		app   = r.Context.(*App)
+		auser = r.RequestCtx.UserValue("user").(amodels.User)
+	)
+	id, _ := strconv.Atoi(r.RequestCtx.UserValue("id").(string))
+	if id <= 0 {
+		return qmodels.Queue{}, envelope.NewError(envelope.InputError, app.i18n.Ts("globals.messages.invalid", "name", "`id`"), nil)
+	}
+	queue, err := app.queue.Get(id)
+	if err != nil {
+		return queue, err
+	}
+
+	if !queue.TeamID.Valid {
+		return queue, requirePermission(r, authzModels.PermConversationsReadUnassigned)
+	}
+	member, err := app.team.UserBelongsToTeam(queue.TeamID.Int, auser.ID)
+	if err != nil {
+		return queue, err
+	}
+	if !member {
+		return queue, envelope.NewError(envelope.PermissionError, app.i18n.T("conversation.notMemberOfTeam"), nil)
+	}
+	return queue, nil
+}
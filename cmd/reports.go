@@ -0,0 +1,104 @@
+package main
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/abhinavxd/libredesk/internal/envelope"
+	rmodels "github.com/abhinavxd/libredesk/internal/report/models"
+	"github.com/valyala/fasthttp"
+	"github.com/zerodha/fastglue"
+)
+
+const (
+	// reportDateLayout is the layout of the `from` and `to` dates of report requests.
+	reportDateLayout = "2006-01-02"
+
+	// defaultReportRange is the date range of reports when no `from` date is passed.
+	defaultReportRange = 30 * 24 * time.Hour
+)
+
+// handleGetAgentStats returns the performance metrics of agents.
+// `from` and `to` are inclusive dates, defaulting to the last 30 days, `team_id` restricts the stats to the team's agents.
+func handleGetAgentStats(r *fastglue.Request) error {
+	var (
+		app       = r.Context.(*App)
+		args      = r.RequestCtx.QueryArgs()
+		teamID, _ = strconv.Atoi(string(args.Peek("team_id")))
+		to        = time.Now().Truncate(24 * time.Hour).Add(24 * time.Hour)
+		from      = to.Add(-defaultReportRange)
+	)
+
+	if v := string(args.Peek("to")); v != "" {
+		t, err := time.Parse(reportDateLayout, v)
+		if err != nil {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.invalid", "name", "`to`"), nil, envelope.InputError)
+		}
+		to = t.Add(24 * time.Hour)
+	}
+	if v := string(args.Peek("from")); v != "" {
+		t, err := time.Parse(reportDateLayout, v)
+		if err != nil {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.invalid", "name", "`from`"), nil, envelope.InputError)
+		}
+		from = t
+	}
+
+	stats, err := app.report.AgentStats(rmodels.AgentStatsFilter{From: from, To: to, TeamID: teamID})
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(stats)
+}
+
+// handleGetTeamWorkload returns the current workload of a team.
+func handleGetTeamWorkload(r *fastglue.Request) error {
+	var (
+		app   = r.Context.(*App)
+		id, _ = strconv.Atoi(r.RequestCtx.UserValue("id").(string))
+	)
+	if id <= 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.invalid", "name", "`id`"), nil, envelope.InputError)
+	}
+	workload, err := app.report.TeamWorkload(id)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(workload)
+}
+
+// handleGetCSATResponses returns the submitted CSAT responses, `flagged=true` returns only the ones flagged by moderation.
+func handleGetCSATResponses(r *fastglue.Request) error {
+	var (
+		app         = r.Context.(*App)
+		args        = r.RequestCtx.QueryArgs()
+		page, _     = strconv.Atoi(string(args.Peek("page")))
+		pageSize, _ = strconv.Atoi(string(args.Peek("page_size")))
+		flagged     = args.GetBool("flagged")
+		total       = 0
+	)
+	responses, err := app.csat.GetResponses(flagged, page, pageSize)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if len(responses) > 0 {
+		total = responses[0].Total
+	}
+	return r.SendEnvelope(envelope.PageResults{
+		Results:    responses,
+		Total:      total,
+		PerPage:    pageSize,
+		TotalPages: (total + max(pageSize, 1) - 1) / max(pageSize, 1),
+		Page:       page,
+	})
+}
+
+// handleGetCSATStats returns the number of responses and the average score of each CSAT survey question.
+func handleGetCSATStats(r *fastglue.Request) error {
+	app := r.Context.(*App)
+	stats, err := app.csat.Stats()
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(stats)
+}
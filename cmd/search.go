@@ -2,8 +2,14 @@ package main
 
 import (
 	"fmt"
+	"slices"
+	"strconv"
 
+	amodels "github.com/abhinavxd/libredesk/internal/auth/models"
+	authzModels "github.com/abhinavxd/libredesk/internal/authz/models"
 	"github.com/abhinavxd/libredesk/internal/envelope"
+	smodels "github.com/abhinavxd/libredesk/internal/search/models"
+	"github.com/valyala/fasthttp"
 	"github.com/zerodha/fastglue"
 )
 
@@ -38,6 +44,59 @@ func handleSearchContacts(r *fastglue.Request) error {
 	return handleSearch(r, wrapper)
 }
 
+// handleSearchAllConversations runs a full-text search across the conversations the user can read.
+func handleSearchAllConversations(r *fastglue.Request) error {
+	var (
+		app         = r.Context.(*App)
+		auser       = r.RequestCtx.UserValue("user").(amodels.User)
+		args        = r.RequestCtx.QueryArgs()
+		q           = string(args.Peek("query"))
+		page, _     = strconv.Atoi(string(args.Peek("page")))
+		pageSize, _ = strconv.Atoi(string(args.Peek("page_size")))
+		total       = 0
+	)
+
+	if len(q) < minSearchQueryLength {
+		return sendErrorEnvelope(r, envelope.NewError(envelope.InputError, app.i18n.Ts("search.minQueryLength", "length", fmt.Sprintf("%d", minSearchQueryLength)), nil))
+	}
+
+	var filter = smodels.SearchFilter{Status: string(args.Peek("status"))}
+	filter.InboxID, _ = strconv.Atoi(string(args.Peek("inbox_id")))
+	filter.TeamID, _ = strconv.Atoi(string(args.Peek("team_id")))
+	filter.AssignedUserID, _ = strconv.Atoi(string(args.Peek("assigned_user_id")))
+
+	user, err := app.user.GetAgent(auser.ID, "")
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	var scope = smodels.SearchScope{
+		UserID:         user.ID,
+		TeamIDs:        user.Teams.IDs(),
+		ReadAll:        slices.Contains(user.Permissions, authzModels.PermConversationsReadAll),
+		ReadAssigned:   slices.Contains(user.Permissions, authzModels.PermConversationsReadAssigned),
+		ReadUnassigned: slices.Contains(user.Permissions, authzModels.PermConversationsReadUnassigned),
+		ReadTeamInbox:  slices.Contains(user.Permissions, authzModels.PermConversationsReadTeamInbox),
+	}
+	if !scope.ReadAll && !scope.ReadAssigned && !scope.ReadUnassigned && !scope.ReadTeamInbox {
+		return r.SendErrorEnvelope(fasthttp.StatusForbidden, app.i18n.Ts("globals.messages.denied", "name", "{globals.terms.permission}"), nil, envelope.PermissionError)
+	}
+
+	results, err := app.search.SearchConversations(q, filter, scope, page, pageSize)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if len(results) > 0 {
+		total = results[0].Total
+	}
+	return r.SendEnvelope(envelope.PageResults{
+		Results:    results,
+		Total:      total,
+		PerPage:    pageSize,
+		TotalPages: (total + max(pageSize, 1) - 1) / max(pageSize, 1),
+		Page:       page,
+	})
+}
+
 // handleSearch searches for the given query using the provided search function.
 func handleSearch(r *fastglue.Request, searchFunc func(string) (interface{}, error)) error {
 	var (
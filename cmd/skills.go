@@ -0,0 +1,98 @@
+package main
+
+import (
+	"strconv"
+
+	"github.com/abhinavxd/libredesk/internal/envelope"
+	"github.com/valyala/fasthttp"
+	"github.com/zerodha/fastglue"
+)
+
+type skillsReq struct {
+	Skills []string `json:"skills"`
+}
+
+type skillReq struct {
+	Skill string `json:"skill"`
+}
+
+// handleGetAgentSkills returns the skills of an agent.
+func handleGetAgentSkills(r *fastglue.Request) error {
+	var app = r.Context.(*App)
+	id, err := strconv.Atoi(r.RequestCtx.UserValue("id").(string))
+	if err != nil || id <= 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.invalid", "name", "`id`"), nil, envelope.InputError)
+	}
+	if _, err := app.user.GetAgent(id, ""); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	skills, err := app.user.GetSkills(id)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(skills)
+}
+
+// handleSetAgentSkills replaces the skills of an agent.
+func handleSetAgentSkills(r *fastglue.Request) error {
+	var (
+		app = r.Context.(*App)
+		req skillsReq
+	)
+	id, err := strconv.Atoi(r.RequestCtx.UserValue("id").(string))
+	if err != nil || id <= 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.invalid", "name", "`id`"), nil, envelope.InputError)
+	}
+	if err := r.Decode(&req, "json"); err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.errorParsing", "name", "{globals.terms.request}"), nil, envelope.InputError)
+	}
+	if _, err := app.user.GetAgent(id, ""); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	skills, err := app.user.SetSkills(id, req.Skills)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(skills)
+}
+
+// handleAddAgentSkill adds a skill to an agent.
+func handleAddAgentSkill(r *fastglue.Request) error {
+	var (
+		app = r.Context.(*App)
+		req skillReq
+	)
+	id, err := strconv.Atoi(r.RequestCtx.UserValue("id").(string))
+	if err != nil || id <= 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.invalid", "name", "`id`"), nil, envelope.InputError)
+	}
+	if err := r.Decode(&req, "json"); err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.errorParsing", "name", "{globals.terms.request}"), nil, envelope.InputError)
+	}
+	if _, err := app.user.GetAgent(id, ""); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if err := app.user.AddSkill(id, req.Skill); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(true)
+}
+
+// handleRemoveAgentSkill removes a skill from an agent.
+func handleRemoveAgentSkill(r *fastglue.Request) error {
+	var (
+		app   = r.Context.(*App)
+		skill = r.RequestCtx.UserValue("skill").(string)
+	)
+	id, err := strconv.Atoi(r.RequestCtx.UserValue("id").(string))
+	if err != nil || id <= 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.invalid", "name", "`id`"), nil, envelope.InputError)
+	}
+	if _, err := app.user.GetAgent(id, ""); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if err := app.user.RemoveSkill(id, skill); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(true)
+}
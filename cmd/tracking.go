@@ -0,0 +1,77 @@
+package main
+
+import (
+	amodels "github.com/abhinavxd/libredesk/internal/auth/models"
+	"github.com/abhinavxd/libredesk/internal/envelope"
+	"github.com/valyala/fasthttp"
+	"github.com/zerodha/fastglue"
+)
+
+// trackingPixel is a 1x1 transparent GIF.
+var trackingPixel = []byte{
+	0x47, 0x49, 0x46, 0x38, 0x39, 0x61, 0x01, 0x00, 0x01, 0x00, 0x80, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0xff, 0xff, 0xff, 0x21, 0xf9, 0x04, 0x01, 0x00, 0x00, 0x00, 0x00, 0x2c, 0x00, 0x00, 0x00, 0x00,
+	0x01, 0x00, 0x01, 0x00, 0x00, 0x02, 0x02, 0x44, 0x01, 0x00, 0x3b,
+}
+
+// handleTrackMessageOpen records an open of an outgoing email and responds with the tracking pixel.
+func handleTrackMessageOpen(r *fastglue.Request) error {
+	var (
+		app  = r.Context.(*App)
+		uuid = r.RequestCtx.UserValue("uuid").(string)
+	)
+	// The pixel is always served so email clients don't show a broken image.
+	app.conversation.RecordMessageOpen(uuid)
+
+	r.RequestCtx.Response.Header.Set("Cache-Control", "no-store, no-cache, must-revalidate, max-age=0")
+	r.RequestCtx.SetContentType("image/gif")
+	r.RequestCtx.SetBody(trackingPixel)
+	return nil
+}
+
+// handleTrackMessageClick records a click of a tracked link in an outgoing email and redirects to the link.
+func handleTrackMessageClick(r *fastglue.Request) error {
+	var (
+		app  = r.Context.(*App)
+		uuid = r.RequestCtx.UserValue("uuid").(string)
+	)
+	url, err := app.conversation.RecordMessageClick(uuid)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	r.RequestCtx.Response.Header.Set("Cache-Control", "no-store")
+	r.RequestCtx.Redirect(url, fasthttp.StatusFound)
+	return nil
+}
+
+// handleGetMessageTrackingEvents returns the opens and clicks of an outgoing message.
+func handleGetMessageTrackingEvents(r *fastglue.Request) error {
+	var (
+		app   = r.Context.(*App)
+		uuid  = r.RequestCtx.UserValue("uuid").(string)
+		cuuid = r.RequestCtx.UserValue("cuuid").(string)
+		auser = r.RequestCtx.UserValue("user").(amodels.User)
+	)
+	user, err := app.user.GetAgent(auser.ID, "")
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if _, err = enforceConversationAccess(app, cuuid, user); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+
+	// Make sure the message belongs to the conversation.
+	msgConvUUID, err := app.conversation.GetMessageConversationUUID(uuid)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if msgConvUUID != cuuid {
+		return r.SendErrorEnvelope(fasthttp.StatusNotFound, app.i18n.Ts("globals.messages.notFound", "name", "{globals.terms.message}"), nil, envelope.NotFoundError)
+	}
+
+	events, err := app.conversation.GetMessageTrackingEvents(uuid)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(events)
+}
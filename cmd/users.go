@@ -8,6 +8,7 @@ import (
 	"strconv"
 	"strings"
 
+	almodels "github.com/abhinavxd/libredesk/internal/auditlog/models"
 	amodels "github.com/abhinavxd/libredesk/internal/auth/models"
 	"github.com/abhinavxd/libredesk/internal/envelope"
 	"github.com/abhinavxd/libredesk/internal/image"
@@ -119,6 +120,13 @@ func handleUpdateCurrentAgent(r *fastglue.Request) error {
 			return sendErrorEnvelope(r, err)
 		}
 	}
+
+	// Update timezone?
+	if tz, ok := form.Value["timezone"]; ok && len(tz) > 0 {
+		if err := app.user.UpdateTimezone(agent.ID, strings.TrimSpace(tz[0])); err != nil {
+			return sendErrorEnvelope(r, err)
+		}
+	}
 	return r.SendEnvelope(true)
 }
 
@@ -155,6 +163,9 @@ func handleCreateAgent(r *fastglue.Request) error {
 			return sendErrorEnvelope(r, err)
 		}
 	}
+	if created, err := app.user.GetAgent(user.ID, ""); err == nil {
+		recordAudit(r, almodels.ActionCreate, almodels.TargetUser, user.ID, nil, created)
+	}
 
 	if user.SendWelcomeEmail {
 		// Generate reset token.
@@ -164,7 +175,7 @@ func handleCreateAgent(r *fastglue.Request) error {
 		}
 
 		// Render template and send email.
-		content, err := app.tmpl.RenderInMemoryTemplate(tmpl.TmplWelcome, map[string]any{
+		content, err := app.tmpl.RenderInMemoryTemplate(tmpl.TmplWelcome, user.Locale.String, map[string]any{
 			"ResetToken": resetToken,
 			"Email":      user.Email.String,
 		})
@@ -213,6 +224,11 @@ func handleUpdateAgent(r *fastglue.Request) error {
 		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.empty", "name", "`first_name`"), nil, envelope.InputError)
 	}
 
+	before, err := app.user.GetAgent(id, "")
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+
 	// Update agent.
 	if err = app.user.UpdateAgent(id, user); err != nil {
 		return sendErrorEnvelope(r, err)
@@ -222,6 +238,9 @@ func handleUpdateAgent(r *fastglue.Request) error {
 	if err := app.team.UpsertUserTeams(id, user.Teams.Names()); err != nil {
 		return sendErrorEnvelope(r, err)
 	}
+	if after, err := app.user.GetAgent(id, ""); err == nil {
+		recordAudit(r, almodels.ActionUpdate, almodels.TargetUser, id, before, after)
+	}
 
 	return r.SendEnvelope(true)
 }
@@ -242,10 +261,16 @@ func handleDeleteAgent(r *fastglue.Request) error {
 		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.T("user.userCannotDeleteSelf"), nil, envelope.InputError)
 	}
 
+	before, err := app.user.GetAgent(id, "")
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+
 	// Soft delete user.
 	if err = app.user.SoftDeleteAgent(id); err != nil {
 		return sendErrorEnvelope(r, err)
 	}
+	recordAudit(r, almodels.ActionDelete, almodels.TargetUser, id, before, nil)
 
 	// Unassign all open conversations assigned to the user.
 	if err := app.conversation.UnassignOpen(id); err != nil {
@@ -327,7 +352,7 @@ func handleResetPassword(r *fastglue.Request) error {
 	}
 
 	// Send email.
-	content, err := app.tmpl.RenderInMemoryTemplate(tmpl.TmplResetPassword, map[string]string{
+	content, err := app.tmpl.RenderInMemoryTemplate(tmpl.TmplResetPassword, agent.Locale.String, map[string]string{
 		"ResetToken": token,
 	})
 	if err != nil {
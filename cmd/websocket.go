@@ -2,8 +2,12 @@ package main
 
 import (
 	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
 
 	amodels "github.com/abhinavxd/libredesk/internal/auth/models"
+	"github.com/abhinavxd/libredesk/internal/envelope"
 	"github.com/abhinavxd/libredesk/internal/ws"
 	wsmodels "github.com/abhinavxd/libredesk/internal/ws/models"
 	"github.com/fasthttp/websocket"
@@ -20,19 +24,34 @@ func ErrHandler(ctx *fasthttp.RequestCtx, status int, reason error) {
 var upgrader = websocket.FastHTTPUpgrader{
 	ReadBufferSize:  8192,
 	WriteBufferSize: 8192,
+	// The origin is checked in handleWS before upgrading as it needs the app's root URL.
 	CheckOrigin: func(ctx *fasthttp.RequestCtx) bool {
 		return true
 	},
 	Error: ErrHandler,
 }
 
-// handleWS handles the websocket connection.
+// handleWS handles the websocket connection. The session is validated by the auth middleware, the connection is
+// only upgraded for enabled agents and requests from the app's own origin as the session cookie is sent
+// along with cross-site websocket requests.
 func handleWS(r *fastglue.Request, hub *ws.Hub) error {
 	var (
 		auser = r.RequestCtx.UserValue("user").(amodels.User)
 		app   = r.Context.(*App)
 	)
-	err := upgrader.Upgrade(r.RequestCtx, func(conn *websocket.Conn) {
+	if !wsOriginAllowed(r.RequestCtx, app.consts.Load().(*constants).AppBaseURL) {
+		app.lo.Warn("rejecting websocket connection from foreign origin", "user_id", auser.ID, "origin", string(r.RequestCtx.Request.Header.Peek("Origin")))
+		return r.SendErrorEnvelope(http.StatusForbidden, app.i18n.Ts("globals.messages.denied", "name", "{globals.terms.permission}"), nil, envelope.PermissionError)
+	}
+	user, err := app.user.GetAgent(auser.ID, "")
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if !user.Enabled {
+		return r.SendErrorEnvelope(http.StatusUnauthorized, app.i18n.T("user.accountDisabled"), nil, envelope.PermissionError)
+	}
+
+	err = upgrader.Upgrade(r.RequestCtx, func(conn *websocket.Conn) {
 		c := ws.Client{
 			ID:   auser.ID,
 			Hub:  hub,
@@ -48,3 +67,73 @@ func handleWS(r *fastglue.Request, hub *ws.Hub) error {
 	}
 	return nil
 }
+
+// wsOriginAllowed returns true if the websocket request has no origin, e.g. non-browser clients, or comes from
+// the host it's sent to or the host of the app's root URL.
+func wsOriginAllowed(ctx *fasthttp.RequestCtx, rootURL string) bool {
+	origin := string(ctx.Request.Header.Peek("Origin"))
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	if strings.EqualFold(u.Host, string(ctx.Host())) {
+		return true
+	}
+	root, err := url.Parse(rootURL)
+	return err == nil && root.Host != "" && strings.EqualFold(u.Host, root.Host)
+}
+
+// handleGetConversationViewers returns the agents currently viewing a conversation.
+func handleGetConversationViewers(r *fastglue.Request, hub *ws.Hub) error {
+	var (
+		app   = r.Context.(*App)
+		uuid  = r.RequestCtx.UserValue("uuid").(string)
+		auser = r.RequestCtx.UserValue("user").(amodels.User)
+	)
+	user, err := app.user.GetAgent(auser.ID, "")
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if _, err := enforceConversationAccess(app, uuid, user); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+
+	var viewers = make([]conversationViewer, 0)
+	for _, id := range hub.GetConversationViewers(uuid) {
+		agent, err := app.user.GetAgent(id, "")
+		if err != nil {
+			continue
+		}
+		viewers = append(viewers, conversationViewer{
+			ID:        agent.ID,
+			FirstName: agent.FirstName,
+			LastName:  agent.LastName,
+			AvatarURL: agent.AvatarURL.String,
+		})
+	}
+	return r.SendEnvelope(viewers)
+}
+
+// conversationViewer is an agent viewing a conversation.
+type conversationViewer struct {
+	ID        int    `json:"id"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	AvatarURL string `json:"avatar_url"`
+}
+
+// conversationViewAuthorizer returns the function checking if an agent can view a conversation, agents are only
+// added as viewers of the conversations they have access to.
+func conversationViewAuthorizer(app *App) func(userID int, conversationUUID string) bool {
+	return func(userID int, conversationUUID string) bool {
+		user, err := app.user.GetAgent(userID, "")
+		if err != nil {
+			return false
+		}
+		_, err = enforceConversationAccess(app, conversationUUID, user)
+		return err == nil
+	}
+}
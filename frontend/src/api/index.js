@@ -53,6 +53,7 @@ const deleteCustomAttribute = (id) => http.delete(`/api/v1/custom-attributes/${i
 const searchConversations = (params) => http.get('/api/v1/conversations/search', { params })
 const searchMessages = (params) => http.get('/api/v1/messages/search', { params })
 const searchContacts = (params) => http.get('/api/v1/contacts/search', { params })
+const searchAllConversations = (params) => http.get('/api/v1/search/conversations', { params })
 const getEmailNotificationSettings = () => http.get('/api/v1/settings/notifications/email')
 const updateEmailNotificationSettings = (data) => http.put('/api/v1/settings/notifications/email', data)
 const getPriorities = () => http.get('/api/v1/priorities')
@@ -63,6 +64,22 @@ const deleteStatus = (id) => http.delete(`/api/v1/statuses/${id}`)
 const createTag = (data) => http.post('/api/v1/tags', data)
 const updateTag = (id, data) => http.put(`/api/v1/tags/${id}`, data)
 const deleteTag = (id) => http.delete(`/api/v1/tags/${id}`)
+const getQueues = () => http.get('/api/v1/queues')
+const createQueue = (data) =>
+  http.post('/api/v1/queues', data, {
+    headers: {
+      'Content-Type': 'application/json'
+    }
+  })
+const updateQueue = (id, data) =>
+  http.put(`/api/v1/queues/${id}`, data, {
+    headers: {
+      'Content-Type': 'application/json'
+    }
+  })
+const deleteQueue = (id) => http.delete(`/api/v1/queues/${id}`)
+const getQueueConversations = (id, params) => http.get(`/api/v1/queues/${id}/conversations`, { params })
+const pullQueueConversation = (id) => http.post(`/api/v1/queues/${id}/pull`)
 const getTemplate = (id) => http.get(`/api/v1/templates/${id}`)
 const getTemplates = (type) => http.get('/api/v1/templates', { params: { type: type } })
 const createTemplate = (data) =>
@@ -151,6 +168,12 @@ const createAutomationRule = (data) =>
     }
   })
 const deleteAutomationRule = (id) => http.delete(`/api/v1/automations/rules/${id}`)
+const getFailedAutomationActions = (params) =>
+  http.get(`/api/v1/automations/failed-actions`, { params })
+const retryFailedAutomationAction = (id) =>
+  http.post(`/api/v1/automations/failed-actions/${id}/retry`)
+const deleteFailedAutomationAction = (id) =>
+  http.delete(`/api/v1/automations/failed-actions/${id}`)
 const updateAutomationRuleWeights = (data) =>
   http.put(`/api/v1/automations/rules/weights`, data, {
     headers: {
@@ -181,6 +204,9 @@ const updateContact = (id, data) => http.put(`/api/v1/contacts/${id}`, data, {
   }
 })
 const blockContact = (id, data) => http.put(`/api/v1/contacts/${id}/block`, data)
+const exportContactData = (id) =>
+  http.get(`/api/v1/contacts/${id}/export`, { responseType: 'blob' })
+const eraseContactData = (id) => http.post(`/api/v1/contacts/${id}/erase`)
 const getTeam = (id) => http.get(`/api/v1/teams/${id}`)
 const getTeams = () => http.get('/api/v1/teams')
 const updateTeam = (id, data) => http.put(`/api/v1/teams/${id}`, data)
@@ -205,10 +231,38 @@ const getUser = (id) => http.get(`/api/v1/agents/${id}`)
 const deleteUserAvatar = () => http.delete('/api/v1/agents/me/avatar')
 const getCurrentUser = () => http.get('/api/v1/agents/me')
 const getCurrentUserTeams = () => http.get('/api/v1/agents/me/teams')
+const getAPITokens = () => http.get('/api/v1/agents/me/api-tokens')
+const createAPIToken = (data) =>
+  http.post('/api/v1/agents/me/api-tokens', data, {
+    headers: {
+      'Content-Type': 'application/json'
+    }
+  })
+const revokeAPIToken = (id) => http.delete(`/api/v1/agents/me/api-tokens/${id}`)
 const updateCurrentUserAvailability = (data) => http.put('/api/v1/agents/me/availability', data)
+const getNotificationPreferences = () => http.get('/api/v1/agents/me/notification-preferences')
+const updateNotificationPreferences = (data) =>
+  http.put('/api/v1/agents/me/notification-preferences', data, {
+    headers: {
+      'Content-Type': 'application/json'
+    }
+  })
+const resetNotificationPreferences = () => http.delete('/api/v1/agents/me/notification-preferences')
+const getQuietHours = () => http.get('/api/v1/agents/me/quiet-hours')
+const updateQuietHours = (data) =>
+  http.put('/api/v1/agents/me/quiet-hours', data, {
+    headers: {
+      'Content-Type': 'application/json'
+    }
+  })
 const resetPassword = (data) => http.post('/api/v1/agents/reset-password', data)
 const setPassword = (data) => http.post('/api/v1/agents/set-password', data)
 const deleteUser = (id) => http.delete(`/api/v1/agents/${id}`)
+const getAgentSkills = (id) => http.get(`/api/v1/agents/${id}/skills`)
+const setAgentSkills = (id, skills) => http.put(`/api/v1/agents/${id}/skills`, { skills })
+const addAgentSkill = (id, skill) => http.post(`/api/v1/agents/${id}/skills`, { skill })
+const removeAgentSkill = (id, skill) =>
+  http.delete(`/api/v1/agents/${id}/skills/${encodeURIComponent(skill)}`)
 const createUser = (data) =>
   http.post('/api/v1/agents', data, {
     headers: {
@@ -219,6 +273,7 @@ const getTags = () => http.get('/api/v1/tags')
 const upsertTags = (uuid, data) => http.post(`/api/v1/conversations/${uuid}/tags`, data)
 const updateAssignee = (uuid, assignee_type, data) => http.put(`/api/v1/conversations/${uuid}/assignee/${assignee_type}`, data)
 const removeAssignee = (uuid, assignee_type) => http.put(`/api/v1/conversations/${uuid}/assignee/${assignee_type}/remove`)
+const routeConversationToQueue = (uuid, data) => http.put(`/api/v1/conversations/${uuid}/queue`, data)
 const updateContactCustomAttribute = (uuid, data) => http.put(`/api/v1/conversations/${uuid}/contacts/custom-attributes`, data,
   {
     headers: {
@@ -234,9 +289,48 @@ const updateConversationCustomAttribute = (uuid, data) => http.put(`/api/v1/conv
 const createConversation = (data) => http.post('/api/v1/conversations', data)
 const updateConversationStatus = (uuid, data) => http.put(`/api/v1/conversations/${uuid}/status`, data)
 const updateConversationPriority = (uuid, data) => http.put(`/api/v1/conversations/${uuid}/priority`, data)
+const updateConversationLocale = (uuid, data) => http.put(`/api/v1/conversations/${uuid}/locale`, data)
+const updateConversationSubject = (uuid, data) =>
+  http.put(`/api/v1/conversations/${uuid}/subject`, data)
+const deleteConversation = (uuid) => http.delete(`/api/v1/conversations/${uuid}`)
+const restoreConversation = (uuid) => http.put(`/api/v1/conversations/${uuid}/restore`)
+const assignToSelf = (uuid) => http.put(`/api/v1/conversations/${uuid}/assignee/me`)
 const updateAssigneeLastSeen = (uuid) => http.put(`/api/v1/conversations/${uuid}/last-seen`)
+const setConversationsReadState = (data) =>
+  http.put('/api/v1/conversations/read-state', data, {
+    headers: {
+      'Content-Type': 'application/json'
+    }
+  })
 const getConversationMessage = (cuuid, uuid) => http.get(`/api/v1/conversations/${cuuid}/messages/${uuid}`)
+const getAssigneeSuggestions = (uuid) => http.get(`/api/v1/conversations/${uuid}/assignee/suggestions`)
+const getMergeSuggestions = (uuid) => http.get(`/api/v1/conversations/${uuid}/merge/suggestions`)
+const getDraft = (uuid) => http.get(`/api/v1/conversations/${uuid}/draft`)
+const saveDraft = (uuid, data) =>
+  http.put(`/api/v1/conversations/${uuid}/draft`, data, {
+    headers: {
+      'Content-Type': 'application/json'
+    }
+  })
+const deleteDraft = (uuid) => http.delete(`/api/v1/conversations/${uuid}/draft`)
 const retryMessage = (cuuid, uuid) => http.put(`/api/v1/conversations/${cuuid}/messages/${uuid}/retry`)
+const retryFailedMessages = (cuuid) => http.put(`/api/v1/conversations/${cuuid}/messages/retry-failed`)
+const attachToMessage = (cuuid, uuid, data) =>
+  http.post(`/api/v1/conversations/${cuuid}/messages/${uuid}/attachments`, data, {
+    headers: {
+      'Content-Type': 'application/json'
+    }
+  })
+const getMessageTrackingEvents = (cuuid, uuid) =>
+  http.get(`/api/v1/conversations/${cuuid}/messages/${uuid}/tracking`)
+const getMessageStatusHistory = (cuuid, uuid) =>
+  http.get(`/api/v1/conversations/${cuuid}/messages/${uuid}/status-history`)
+const translateMessage = (cuuid, uuid, data) =>
+  http.post(`/api/v1/conversations/${cuuid}/messages/${uuid}/translate`, data, {
+    headers: {
+      'Content-Type': 'application/json'
+    }
+  })
 const getConversationMessages = (uuid, params) => http.get(`/api/v1/conversations/${uuid}/messages`, { params })
 const sendMessage = (uuid, data) =>
   http.post(`/api/v1/conversations/${uuid}/messages`, data, {
@@ -244,10 +338,32 @@ const sendMessage = (uuid, data) =>
       'Content-Type': 'application/json'
     }
   })
+const validateMessage = (uuid, data) =>
+  http.post(`/api/v1/conversations/${uuid}/messages/validate`, data, {
+    headers: {
+      'Content-Type': 'application/json'
+    }
+  })
 const getConversation = (uuid) => http.get(`/api/v1/conversations/${uuid}`)
 const getConversationParticipants = (uuid) => http.get(`/api/v1/conversations/${uuid}/participants`)
+const addConversationParticipant = (uuid, data) =>
+  http.post(`/api/v1/conversations/${uuid}/participants`, data)
+const removeConversationParticipant = (uuid, userID) =>
+  http.delete(`/api/v1/conversations/${uuid}/participants/${userID}`)
+const getConversationWatchers = (uuid) => http.get(`/api/v1/conversations/${uuid}/watchers`)
+const watchConversation = (uuid) => http.put(`/api/v1/conversations/${uuid}/watch`)
+const unwatchConversation = (uuid) => http.delete(`/api/v1/conversations/${uuid}/watch`)
+const setConversationReminder = (uuid, data) =>
+  http.post(`/api/v1/conversations/${uuid}/reminders`, data, {
+    headers: {
+      'Content-Type': 'application/json'
+    }
+  })
+const getReminders = () => http.get('/api/v1/agents/me/reminders')
+const cancelReminder = (id) => http.delete(`/api/v1/agents/me/reminders/${id}`)
 const getAllMacros = () => http.get('/api/v1/macros')
 const getMacro = (id) => http.get(`/api/v1/macros/${id}`)
+const suggestMacros = (params) => http.get('/api/v1/macros/suggestions', { params })
 const createMacro = (data) => http.post('/api/v1/macros', data, {
   headers: {
     'Content-Type': 'application/json'
@@ -264,9 +380,13 @@ const applyMacro = (uuid, id, data) => http.post(`/api/v1/conversations/${uuid}/
     'Content-Type': 'application/json'
   }
 })
+const executeMacro = (uuid, id) => http.post(`/api/v1/conversations/${uuid}/macros/${id}/execute`)
 const getTeamUnassignedConversations = (teamID, params) =>
   http.get(`/api/v1/teams/${teamID}/conversations/unassigned`, { params })
 const getAssignedConversations = (params) => http.get('/api/v1/conversations/assigned', { params })
+const getNextConversation = (params) => http.get('/api/v1/conversations/next', { params })
+const getConversationStatusCounts = (params) =>
+  http.get('/api/v1/conversations/status-counts', { params })
 const getUnassignedConversations = (params) => http.get('/api/v1/conversations/unassigned', { params })
 const getAllConversations = (params) => http.get('/api/v1/conversations/all', { params })
 const getViewConversations = (id, params) => http.get(`/api/v1/views/${id}/conversations`, { params })
@@ -278,6 +398,11 @@ const uploadMedia = (data) =>
   })
 const getOverviewCounts = () => http.get('/api/v1/reports/overview/counts')
 const getOverviewCharts = () => http.get('/api/v1/reports/overview/charts')
+const getAgentStats = (params) => http.get('/api/v1/reports/agents', { params })
+const getTeamWorkload = (id) => http.get(`/api/v1/reports/teams/${id}/workload`)
+const getCSATResponses = (params) => http.get('/api/v1/reports/csat/responses', { params })
+const getCSATStats = () => http.get('/api/v1/reports/csat/stats')
+const getAuditLogs = (params) => http.get('/api/v1/audit-logs', { params })
 const getLanguage = (lang) => http.get(`/api/v1/lang/${lang}`)
 const createInbox = (data) =>
   http.post('/api/v1/inboxes', data, {
@@ -287,7 +412,15 @@ const createInbox = (data) =>
   })
 const getInboxes = () => http.get('/api/v1/inboxes')
 const getInbox = (id) => http.get(`/api/v1/inboxes/${id}`)
+const getInboxesHealth = () => http.get('/api/v1/inboxes/health')
+const getInboxesStats = (params) => http.get('/api/v1/inboxes/stats', { params })
+const getBlockedIncomingMessages = (params) => http.get('/api/v1/inboxes/blocked-messages', { params })
+const deleteBlockedIncomingMessage = (id) => http.delete(`/api/v1/inboxes/blocked-messages/${id}`)
+const releaseBlockedIncomingMessage = (id) => http.post(`/api/v1/inboxes/blocked-messages/${id}/release`)
 const toggleInbox = (id) => http.put(`/api/v1/inboxes/${id}/toggle`)
+const getPausedInboxes = () => http.get('/api/v1/inboxes/paused')
+const pauseInbox = (id) => http.put(`/api/v1/inboxes/${id}/pause`)
+const resumeInbox = (id) => http.put(`/api/v1/inboxes/${id}/resume`)
 const updateInbox = (id, data) =>
   http.put(`/api/v1/inboxes/${id}`, data, {
     headers: {
@@ -314,11 +447,17 @@ const aiCompletion = (data) => http.post('/api/v1/ai/completion', data)
 const updateAIProvider = (data) => http.put('/api/v1/ai/provider', data)
 const getContactNotes = (id) => http.get(`/api/v1/contacts/${id}/notes`)
 const createContactNote = (id, data) => http.post(`/api/v1/contacts/${id}/notes`, data)
+const updateContactNote = (id, noteId, data) =>
+  http.put(`/api/v1/contacts/${id}/notes/${noteId}`, data)
 const deleteContactNote = (id, noteId) => http.delete(`/api/v1/contacts/${id}/notes/${noteId}`)
 
 export default {
   login,
   deleteUser,
+  getAgentSkills,
+  setAgentSkills,
+  addAgentSkill,
+  removeAgentSkill,
   resetPassword,
   setPassword,
   getTags,
@@ -334,6 +473,11 @@ export default {
   getUsers,
   getInbox,
   getInboxes,
+  getInboxesHealth,
+  getInboxesStats,
+  getBlockedIncomingMessages,
+  deleteBlockedIncomingMessage,
+  releaseBlockedIncomingMessage,
   getLanguage,
   getConversation,
   getAutomationRule,
@@ -349,34 +493,70 @@ export default {
   updateSLA,
   deleteSLA,
   getAssignedConversations,
+  getNextConversation,
+  getConversationStatusCounts,
   getUnassignedConversations,
   getAllConversations,
   getTeamUnassignedConversations,
   getViewConversations,
   getOverviewCharts,
+  getAgentStats,
+  getCSATResponses,
+  getCSATStats,
+  getTeamWorkload,
+  getAuditLogs,
   getOverviewCounts,
   getConversationParticipants,
+  addConversationParticipant,
+  removeConversationParticipant,
+  getConversationWatchers,
+  watchConversation,
+  unwatchConversation,
+  setConversationReminder,
+  getReminders,
+  cancelReminder,
   getConversationMessage,
   getConversationMessages,
   getCurrentUser,
   getCurrentUserTeams,
+  getAPITokens,
+  createAPIToken,
+  revokeAPIToken,
   getAllMacros,
   getMacro,
+  suggestMacros,
   createMacro,
   updateMacro,
   deleteMacro,
   applyMacro,
+  executeMacro,
   updateCurrentUser,
   updateAssignee,
+  assignToSelf,
   updateConversationStatus,
   updateConversationPriority,
+  updateConversationLocale,
+  updateConversationSubject,
+  deleteConversation,
+  restoreConversation,
   upsertTags,
   updateConversationCustomAttribute,
+  getAssigneeSuggestions,
+  getMergeSuggestions,
+  getDraft,
+  saveDraft,
+  deleteDraft,
   updateContactCustomAttribute,
   uploadMedia,
   updateAssigneeLastSeen,
+  setConversationsReadState,
   updateUser,
   updateCurrentUserAvailability,
+  getNotificationPreferences,
+  updateNotificationPreferences,
+  resetNotificationPreferences,
+  getQuietHours,
+  updateQuietHours,
   updateAutomationRule,
   updateAutomationRuleWeights,
   updateAutomationRulesExecutionMode,
@@ -384,14 +564,26 @@ export default {
   createAutomationRule,
   toggleAutomationRule,
   deleteAutomationRule,
+  getFailedAutomationActions,
+  retryFailedAutomationAction,
+  deleteFailedAutomationAction,
   createConversation,
   sendMessage,
+  validateMessage,
   retryMessage,
+  retryFailedMessages,
+  translateMessage,
+  getMessageTrackingEvents,
+  getMessageStatusHistory,
+  attachToMessage,
   createUser,
   createInbox,
   updateInbox,
   deleteInbox,
   toggleInbox,
+  getPausedInboxes,
+  pauseInbox,
+  resumeInbox,
   createTeam,
   updateTeam,
   getSettings,
@@ -412,6 +604,13 @@ export default {
   createTag,
   updateTag,
   deleteTag,
+  getQueues,
+  createQueue,
+  updateQueue,
+  deleteQueue,
+  getQueueConversations,
+  pullQueueConversation,
+  routeConversationToQueue,
   getStatuses,
   getPriorities,
   createStatus,
@@ -430,11 +629,14 @@ export default {
   searchConversations,
   searchMessages,
   searchContacts,
+  searchAllConversations,
   removeAssignee,
   getContacts,
   getContact,
   updateContact,
   blockContact,
+  exportContactData,
+  eraseContactData,
   getCustomAttributes,
   createCustomAttribute,
   updateCustomAttribute,
@@ -442,5 +644,6 @@ export default {
   getCustomAttribute,
   getContactNotes,
   createContactNote,
+  updateContactNote,
   deleteContactNote
 }
@@ -56,7 +56,8 @@ export function useConversationFilters () {
             label: 'Assigned user',
             type: FIELD_TYPE.SELECT,
             operators: FIELD_OPERATORS.SELECT,
-            options: uStore.options
+            // `@me` resolves to the agent viewing the list.
+            options: [{ label: 'Me', value: '@me' }, ...uStore.options]
         },
         inbox_id: {
             label: 'Inbox',
@@ -66,21 +67,29 @@ export function useConversationFilters () {
         }
     }))
 
+    const toCustomAttributeFields = (attributes) => {
+        return attributes.reduce((acc, attribute) => {
+            acc[attribute.key] = {
+                label: attribute.label,
+                type: customAttributeDataTypeToFieldType[attribute.data_type] || FIELD_TYPE.TEXT,
+                operators: customAttributeDataTypeToFieldOperators[attribute.data_type] || FIELD_OPERATORS.TEXT,
+                options: attribute.values.map(value => ({
+                    label: value,
+                    value: value
+                })) || [],
+            }
+            return acc
+        }, {})
+    }
+
     const contactCustomAttributes = computed(() => {
-        return customAttributeStore.contactAttributeOptions
-            .filter(attribute => attribute.applies_to === 'contact')
-            .reduce((acc, attribute) => {
-                acc[attribute.key] = {
-                    label: attribute.label,
-                    type: customAttributeDataTypeToFieldType[attribute.data_type] || FIELD_TYPE.TEXT,
-                    operators: customAttributeDataTypeToFieldOperators[attribute.data_type] || FIELD_OPERATORS.TEXT,
-                    options: attribute.values.map(value => ({
-                        label: value,
-                        value: value
-                    })) || [],
-                }
-                return acc
-            }, {})
+        return toCustomAttributeFields(customAttributeStore.contactAttributeOptions
+            .filter(attribute => attribute.applies_to === 'contact'))
+    })
+
+    const conversationCustomAttributes = computed(() => {
+        return toCustomAttributeFields(customAttributeStore.conversationAttributeOptions
+            .filter(attribute => attribute.applies_to === 'conversation'))
     })
 
     const newConversationFilters = computed(() => ({
@@ -89,6 +98,21 @@ export function useConversationFilters () {
             type: FIELD_TYPE.TEXT,
             operators: FIELD_OPERATORS.TEXT
         },
+        contact_email_domain: {
+            label: 'Email domain',
+            type: FIELD_TYPE.TEXT,
+            operators: FIELD_OPERATORS.TEXT
+        },
+        contact_vip: {
+            label: 'VIP contact',
+            type: FIELD_TYPE.BOOLEAN,
+            operators: FIELD_OPERATORS.BOOLEAN
+        },
+        contact_past_conversations: {
+            label: 'Past conversations of contact',
+            type: FIELD_TYPE.NUMBER,
+            operators: FIELD_OPERATORS.NUMBER
+        },
         content: {
             label: 'Content',
             type: FIELD_TYPE.TEXT,
@@ -128,10 +152,30 @@ export function useConversationFilters () {
             type: FIELD_TYPE.SELECT,
             operators: FIELD_OPERATORS.SELECT,
             options: iStore.options
+        },
+        tags: {
+            label: 'Tags',
+            type: FIELD_TYPE.TAG,
+            operators: FIELD_OPERATORS.TAGS
         }
     }))
 
     const conversationFilters = computed(() => ({
+        contact_email_domain: {
+            label: 'Email domain',
+            type: FIELD_TYPE.TEXT,
+            operators: FIELD_OPERATORS.TEXT
+        },
+        contact_vip: {
+            label: 'VIP contact',
+            type: FIELD_TYPE.BOOLEAN,
+            operators: FIELD_OPERATORS.BOOLEAN
+        },
+        contact_past_conversations: {
+            label: 'Past conversations of contact',
+            type: FIELD_TYPE.NUMBER,
+            operators: FIELD_OPERATORS.NUMBER
+        },
         status: {
             label: 'Status',
             type: FIELD_TYPE.SELECT,
@@ -176,11 +220,41 @@ export function useConversationFilters () {
             type: FIELD_TYPE.NUMBER,
             operators: FIELD_OPERATORS.NUMBER
         },
+        hours_since_unanswered: {
+            label: 'Hours since unanswered customer message',
+            type: FIELD_TYPE.NUMBER,
+            operators: FIELD_OPERATORS.NUMBER
+        },
+        business_hours_since_unanswered: {
+            label: 'Business hours since unanswered customer message',
+            type: FIELD_TYPE.NUMBER,
+            operators: FIELD_OPERATORS.NUMBER
+        },
+        last_incoming_message: {
+            label: 'Last incoming message',
+            type: FIELD_TYPE.TEXT,
+            operators: FIELD_OPERATORS.MESSAGE
+        },
+        minutes_unassigned: {
+            label: 'Minutes unassigned',
+            type: FIELD_TYPE.NUMBER,
+            operators: FIELD_OPERATORS.NUMBER
+        },
+        language: {
+            label: 'Language (ISO 639-1 code, e.g. de)',
+            type: FIELD_TYPE.TEXT,
+            operators: FIELD_OPERATORS.TEXT
+        },
         inbox: {
             label: 'Inbox',
             type: FIELD_TYPE.SELECT,
             operators: FIELD_OPERATORS.SELECT,
             options: iStore.options
+        },
+        tags: {
+            label: 'Tags',
+            type: FIELD_TYPE.TAG,
+            operators: FIELD_OPERATORS.TAGS
         }
     }))
 
@@ -195,6 +269,11 @@ export function useConversationFilters () {
             type: FIELD_TYPE.SELECT,
             options: uStore.options
         },
+        assign_team_agent: {
+            label: 'Assign to team agent (round robin)',
+            type: FIELD_TYPE.SELECT,
+            options: tStore.options
+        },
         set_status: {
             label: 'Set status',
             type: FIELD_TYPE.SELECT,
@@ -278,5 +357,6 @@ export function useConversationFilters () {
         conversationActions,
         macroActions,
         contactCustomAttributes,
+        conversationCustomAttributes,
     }
 }
\ No newline at end of file
@@ -16,7 +16,8 @@ export const OPERATOR = {
     CONTAINS: 'contains',
     NOT_CONTAINS: 'not contains',
     GREATER_THAN: 'greater than',
-    LESS_THAN: 'less than'
+    LESS_THAN: 'less than',
+    MATCHES: 'matches'
 }
 
 export const FIELD_OPERATORS = {
@@ -39,4 +40,12 @@ export const FIELD_OPERATORS = {
         OPERATOR.LESS_THAN
     ],
     NUMBER: [OPERATOR.EQUALS, OPERATOR.NOT_EQUALS, OPERATOR.GREATER_THAN, OPERATOR.LESS_THAN],
+    TAGS: [OPERATOR.CONTAINS, OPERATOR.NOT_CONTAINS, OPERATOR.SET, OPERATOR.NOT_SET],
+    MESSAGE: [
+        OPERATOR.SET,
+        OPERATOR.NOT_SET,
+        OPERATOR.CONTAINS,
+        OPERATOR.NOT_CONTAINS,
+        OPERATOR.MATCHES
+    ],
 }
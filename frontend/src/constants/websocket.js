@@ -2,4 +2,10 @@ export const WS_EVENT = {
     NEW_MESSAGE: 'new_message',
     MESSAGE_PROP_UPDATE: 'message_prop_update',
     CONVERSATION_PROP_UPDATE: 'conversation_prop_update',
+    INBOX_PROP_UPDATE: 'inbox_prop_update',
+    CONVERSATION_VIEWERS: 'conversation_viewers',
+    CONVERSATIONS_READ_STATE: 'conversations_read_state',
+    CONVERSATION_REMINDER: 'conversation_reminder',
+    CONVERSATION_VIEW: 'conversation_view',
+    CONVERSATION_LEAVE: 'conversation_leave',
 }
\ No newline at end of file
@@ -47,4 +47,5 @@ export const createFormSchema = (t) => z.object({
     .optional(),
   enabled: z.boolean().optional().default(true),
   availability_status: z.string().optional().default('offline'),
+  max_open_conversations: z.coerce.number().int().min(0).optional().default(0),
 })
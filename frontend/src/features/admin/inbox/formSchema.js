@@ -4,8 +4,12 @@ import { isGoDuration } from '@/utils/strings'
 export const createFormSchema = (t) => z.object({
   name: z.string().min(1, t('globals.messages.required')),
   from: z.string().min(1, t('globals.messages.required')),
+  aliases: z.string().optional(),
   enabled: z.boolean().optional(),
   csat_enabled: z.boolean().optional(),
+  tracking_enabled: z.boolean().optional(),
+  plain_text_only: z.boolean().optional(),
+  delivery_reports: z.boolean().optional(),
   imap: z.object({
     host: z.string().min(1, t('globals.messages.required')),
     port: z.number().min(1).max(65535),
@@ -39,5 +43,83 @@ export const createFormSchema = (t) => z.object({
     tls_skip_verify: z.boolean().optional(),
     hello_hostname: z.string().optional(),
     auth_protocol: z.enum(['login', 'cram', 'plain', 'none'])
-  })
+  }),
+
+  rate_limit: z
+    .object({
+      per_second: z.number().min(0),
+      burst: z.number().int().min(0)
+    })
+    .optional(),
+  unassigned_escalation: z
+    .object({
+      minutes: z.number().int().min(0),
+      // Team and user options have string values, converted to IDs on submit.
+      fallback_team_id: z.string().optional(),
+      notify_user_id: z.string().optional()
+    })
+    .optional(),
+  default_assignee: z
+    .object({
+      // Team and user options have string values, converted to IDs on submit.
+      team_id: z.string().optional(),
+      user_id: z.string().optional()
+    })
+    .optional(),
+  priority_defaults: z
+    .object({
+      // Priority options have string values, converted to IDs on submit.
+      default_priority_id: z.string().optional(),
+      vip_priority_id: z.string().optional()
+    })
+    .optional(),
+  footer: z
+    .object({
+      html: z.string().optional(),
+      text: z.string().optional(),
+      unsubscribe_url: z.string().optional()
+    })
+    .optional()
+})
+
+// createWebhookFormSchema is the schema of the webhook inbox form, the secret is masked when the inbox is fetched.
+export const createWebhookFormSchema = (t) => z.object({
+  name: z.string().min(1, t('globals.messages.required')),
+  from: z.string().min(1, t('globals.messages.required')),
+  enabled: z.boolean().optional(),
+  csat_enabled: z.boolean().optional(),
+  secret: z.string().min(1, t('globals.messages.required'))
+})
+
+// toUnassignedEscalationConfig converts the unassigned escalation form values to the inbox config.
+export const toUnassignedEscalationConfig = (values) => ({
+  minutes: values?.minutes || 0,
+  fallback_team_id: Number(values?.fallback_team_id) || 0,
+  notify_user_id: Number(values?.notify_user_id) || 0
+})
+
+// toPriorityDefaultsConfig converts the priority defaults form values to the inbox config.
+export const toPriorityDefaultsConfig = (values) => ({
+  default_priority_id: Number(values?.default_priority_id) || 0,
+  vip_priority_id: Number(values?.vip_priority_id) || 0
 })
+
+// toDefaultAssigneeConfig converts the default assignee form values to the inbox config.
+export const toDefaultAssigneeConfig = (values) => ({
+  team_id: Number(values?.team_id) || 0,
+  user_id: Number(values?.user_id) || 0
+})
+
+// toFooterConfig converts the footer form values to the inbox config.
+export const toFooterConfig = (values) => ({
+  html: values?.html || '',
+  text: values?.text || '',
+  unsubscribe_url: values?.unsubscribe_url || ''
+})
+
+// toAliasesConfig converts the comma separated aliases of the form to the inbox config.
+export const toAliasesConfig = (value) =>
+  (value || '')
+    .split(',')
+    .map((a) => a.trim())
+    .filter(Boolean)
@@ -14,4 +14,5 @@ export const teamFormSchema = z.object({
   timezone: z.string({ required_error: 'Timezone is required.' }),
   business_hours_id: z.number().optional().nullable(),
   sla_policy_id: z.number().optional().nullable(),
+  csat_enabled: z.boolean().optional().default(true),
 })
@@ -12,6 +12,11 @@ export const useConversationStore = defineStore('conversation', () => {
   const MESSAGE_LIST_PAGE_SIZE = 30
   const priorities = ref([])
   const statuses = ref([])
+  // Conversation counts by status name, keyed by list type.
+  const statusCounts = reactive({})
+  let statusCountsTimer = null
+  // Conversation properties that change the status counts.
+  const STATUS_COUNT_PROPS = ['status', 'assigned_user_id', 'assigned_team_id', 'assigned_queue_id', 'deleted_at']
 
   // Options for select fields
   const priorityOptions = computed(() => {
@@ -95,6 +100,7 @@ export const useConversationStore = defineStore('conversation', () => {
   const conversation = reactive({
     data: null,
     participants: {},
+    viewers: [],
     mediaFiles: [],
     macro: {},
     loading: false,
@@ -475,6 +481,18 @@ export const useConversationStore = defineStore('conversation', () => {
     }
   }
 
+  async function updateLocale (v) {
+    try {
+      await api.updateConversationLocale(conversation.data.uuid, { locale: v })
+    } catch (error) {
+      emitter.emit(EMITTER_EVENTS.SHOW_TOAST, {
+        title: 'Error',
+        variant: 'destructive',
+        description: handleHTTPError(error).message
+      })
+    }
+  }
+
   async function updateStatus (v) {
     try {
       await api.updateConversationStatus(conversation.data.uuid, { status: v })
@@ -617,12 +635,92 @@ export const useConversationStore = defineStore('conversation', () => {
     if (existingConversation) {
       existingConversation[update.prop] = update.value
     }
+    if (STATUS_COUNT_PROPS.includes(update.prop)) {
+      refreshStatusCounts()
+    }
+  }
+
+  /**
+   * Fetch the number of conversations by status in a conversations list, for the sidebar badges.
+   *
+   * @param {string} listType - Conversation list type
+   */
+  async function fetchStatusCounts (listType) {
+    try {
+      const resp = await api.getConversationStatusCounts({ list_type: listType })
+      statusCounts[listType] = resp.data.data
+    } catch (error) {
+      // Badges are not essential, don't bother the user with a toast.
+      console.error('Error fetching conversation status counts', error)
+    }
+  }
+
+  // Refetch the fetched status counts once a burst of updates settles.
+  function refreshStatusCounts () {
+    clearTimeout(statusCountsTimer)
+    statusCountsTimer = setTimeout(() => {
+      Object.keys(statusCounts).forEach(listType => fetchStatusCounts(listType))
+    }, 2000)
+  }
+
+  /**
+   * Apply the unread message counts of conversations marked read or unread by the user.
+   *
+   * @param {Object} update - Read state and the conversations with their unread message count
+   */
+  function setConversationsReadState (update) {
+    for (const c of update.conversations || []) {
+      updateConversationProp({ uuid: c.uuid, prop: 'unread_message_count', value: c.unread_message_count })
+    }
+  }
+
+  /**
+   * Show a due reminder of the current user.
+   *
+   * @param {Object} reminder - Reminder with the conversation reference number, subject and note
+   */
+  function showReminder (reminder) {
+    const conversation = `#${reminder.reference_number} ${reminder.subject || ''}`.trim()
+    emitter.emit(EMITTER_EVENTS.SHOW_TOAST, {
+      title: 'Reminder',
+      description: reminder.note ? `${conversation}: ${reminder.note}` : conversation
+    })
+  }
+
+  /**
+   * Mark conversations read or unread for the current user in a single request.
+   *
+   * @param {string[]} uuids - Conversation UUIDs
+   * @param {boolean} read - Mark read if true, unread otherwise
+   */
+  async function markConversationsRead (uuids, read) {
+    try {
+      await api.setConversationsReadState({ uuids, read })
+    } catch (error) {
+      emitter.emit(EMITTER_EVENTS.SHOW_TOAST, {
+        title: 'Error',
+        variant: 'destructive',
+        description: handleHTTPError(error).message
+      })
+    }
+  }
+
+  /**
+   * Set the IDs of the agents viewing the current conversation.
+   *
+   * @param {Object} update - Conversation UUID and viewer user IDs
+   */
+  function setConversationViewers (update) {
+    if (conversation.data?.uuid === update.uuid) {
+      conversation.viewers = update.viewers || []
+    }
   }
 
   function resetCurrentConversation () {
     Object.assign(conversation, {
       data: null,
       participants: {},
+      viewers: [],
       mediaFiles: [],
       macro: {},
       loading: false,
@@ -652,6 +750,12 @@ export const useConversationStore = defineStore('conversation', () => {
     clearListReRenderInterval,
     conversationUUIDExists,
     updateConversationProp,
+    setConversationViewers,
+    setConversationsReadState,
+    showReminder,
+    markConversationsRead,
+    fetchStatusCounts,
+    statusCounts,
     addNewConversation,
     getContactFullName,
     fetchParticipants,
@@ -667,6 +771,7 @@ export const useConversationStore = defineStore('conversation', () => {
     upsertTags,
     updateAssignee,
     updatePriority,
+    updateLocale,
     updateStatus,
     updateConversationList,
     resetCurrentConversation,
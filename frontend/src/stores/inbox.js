@@ -25,9 +25,17 @@ export const useInboxStore = defineStore('inbox', () => {
       })
     }
   }
+  // Update a property of an inbox, e.g. `paused` when dispatching for the inbox is paused or resumed.
+  const updateInboxProp = (update) => {
+    const inbox = inboxes.value.find(inb => inb.id === update.id)
+    if (inbox) {
+      inbox[update.prop] = update.value
+    }
+  }
   return {
     inboxes,
     options,
     fetchInboxes,
+    updateInboxProp,
   }
 })
\ No newline at end of file
@@ -1,6 +1,19 @@
 import { format, differenceInMinutes, differenceInHours, differenceInDays } from 'date-fns'
 
-export function formatTime(t) {
+// formatDateTime formats a timestamp as a date and time in the passed IANA timezone, the browser's timezone is used when not set.
+export function formatDateTime(t, timeZone) {
+  if (!timeZone) return format(t, 'MMMM d, yyyy h:mm a')
+  return new Intl.DateTimeFormat('en-US', {
+    timeZone,
+    month: 'long',
+    day: 'numeric',
+    year: 'numeric',
+    hour: 'numeric',
+    minute: '2-digit'
+  }).format(new Date(t))
+}
+
+export function formatTime(t, timeZone) {
   try {
     const now = new Date()
     const minutesDifference = differenceInMinutes(now, t)
@@ -16,7 +29,7 @@ export function formatTime(t) {
     } else if (daysDifference < 7) {
       return `${daysDifference} days ago`
     } else {
-      return format(t, 'MMMM d, yyyy h:mm a')
+      return formatDateTime(t, timeZone)
     }
   } catch (error) {
     console.error('error parsing time', error, 'time', t)
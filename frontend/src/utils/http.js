@@ -8,6 +8,7 @@ function handleHTTPError (error) {
     status: 'error',
     message: 'Unknown error',
     error_type: 'GeneralException',
+    error_code: null,
     data: null,
     status_code: null
   }
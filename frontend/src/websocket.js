@@ -1,4 +1,5 @@
 import { useConversationStore } from './stores/conversation'
+import { useInboxStore } from './stores/inbox'
 import { WS_EVENT } from './constants/websocket'
 
 export class WebSocketClient {
@@ -13,6 +14,8 @@ export class WebSocketClient {
     this.pingInterval = null
     this.lastPong = Date.now()
     this.convStore = useConversationStore()
+    this.inboxStore = useInboxStore()
+    this.viewingConversationUUID = null
   }
 
   init () {
@@ -42,6 +45,10 @@ export class WebSocketClient {
     this.isReconnecting = false
     this.lastPong = Date.now()
     this.setupPing()
+    // Viewers are tracked per connection, so view the open conversation again after reconnecting.
+    if (this.viewingConversationUUID) {
+      this.send({ type: WS_EVENT.CONVERSATION_VIEW, uuid: this.viewingConversationUUID })
+    }
   }
 
   handleMessage (event) {
@@ -61,7 +68,11 @@ export class WebSocketClient {
           this.convStore.updateConversationMessage(data.data)
         },
         [WS_EVENT.MESSAGE_PROP_UPDATE]: () => this.convStore.updateMessageProp(data.data),
-        [WS_EVENT.CONVERSATION_PROP_UPDATE]: () => this.convStore.updateConversationProp(data.data)
+        [WS_EVENT.CONVERSATION_PROP_UPDATE]: () => this.convStore.updateConversationProp(data.data),
+        [WS_EVENT.INBOX_PROP_UPDATE]: () => this.inboxStore.updateInboxProp(data.data),
+        [WS_EVENT.CONVERSATION_VIEWERS]: () => this.convStore.setConversationViewers(data.data),
+        [WS_EVENT.CONVERSATIONS_READ_STATE]: () => this.convStore.setConversationsReadState(data.data),
+        [WS_EVENT.CONVERSATION_REMINDER]: () => this.convStore.showReminder(data.data)
       }
 
       const handler = handlers[data.type]
@@ -148,6 +159,17 @@ export class WebSocketClient {
     }
   }
 
+  viewConversation (uuid) {
+    this.viewingConversationUUID = uuid
+    this.send({ type: WS_EVENT.CONVERSATION_VIEW, uuid })
+  }
+
+  leaveConversation () {
+    if (!this.viewingConversationUUID) return
+    this.viewingConversationUUID = null
+    this.send({ type: WS_EVENT.CONVERSATION_LEAVE })
+  }
+
   close () {
     this.manualClose = true
     this.clearPing()
@@ -168,4 +190,6 @@ export function initWS () {
 }
 
 export const sendMessage = message => wsClient?.send(message)
+export const viewConversation = uuid => wsClient?.viewConversation(uuid)
+export const leaveConversation = () => wsClient?.leaveConversation()
 export const closeWebSocket = () => wsClient?.close()
\ No newline at end of file
@@ -64,18 +64,20 @@ func (m *Manager) Completion(k string, prompt string) (string, error) {
 	if err != nil {
 		return "", err
 	}
+	return m.sendPrompt(PromptPayload{
+		SystemPrompt: systemPrompt,
+		UserPrompt:   prompt,
+	})
+}
 
+// sendPrompt sends the payload to the default provider and returns the response.
+func (m *Manager) sendPrompt(payload PromptPayload) (string, error) {
 	client, err := m.getDefaultProviderClient()
 	if err != nil {
 		m.lo.Error("error getting provider client", "error", err)
 		return "", envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", m.i18n.Ts("globals.terms.provider")), nil)
 	}
 
-	payload := PromptPayload{
-		SystemPrompt: systemPrompt,
-		UserPrompt:   prompt,
-	}
-
 	response, err := client.SendPrompt(payload)
 	if err != nil {
 		if errors.Is(err, ErrInvalidAPIKey) {
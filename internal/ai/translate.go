@@ -0,0 +1,45 @@
+package ai
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// translatePrompt is the system prompt for translations, the provider replies with JSON so the detected source
+// language can be returned along with the translation.
+const translatePrompt = `Translate the text to the language with the BCP 47 code %q. Keep the formatting, names, ` +
+	`links and numbers as is. Reply only with a JSON object of the form {"source_lang": "<BCP 47 code of the ` +
+	`language of the text>", "text": "<translated text>"}.`
+
+// Translate translates the text to the target language with the default provider, returning the translation
+// and the detected language of the text.
+func (m *Manager) Translate(text, targetLang string) (string, string, error) {
+	response, err := m.sendPrompt(PromptPayload{
+		SystemPrompt: fmt.Sprintf(translatePrompt, targetLang),
+		UserPrompt:   text,
+	})
+	if err != nil {
+		return "", "", err
+	}
+	translated, sourceLang := parseTranslation(response)
+	return translated, sourceLang, nil
+}
+
+// parseTranslation parses the JSON reply of the provider, replies that aren't JSON are treated as the translated
+// text with an unknown source language.
+func parseTranslation(response string) (string, string) {
+	var out struct {
+		SourceLang string `json:"source_lang"`
+		Text       string `json:"text"`
+	}
+	// Providers sometimes wrap JSON replies in a markdown code block.
+	trimmed := strings.TrimSpace(response)
+	trimmed = strings.TrimPrefix(trimmed, "```json")
+	trimmed = strings.TrimPrefix(trimmed, "```")
+	trimmed = strings.TrimSuffix(trimmed, "```")
+	if err := json.Unmarshal([]byte(strings.TrimSpace(trimmed)), &out); err != nil || out.Text == "" {
+		return strings.TrimSpace(response), ""
+	}
+	return out.Text, strings.TrimSpace(out.SourceLang)
+}
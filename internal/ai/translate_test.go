@@ -0,0 +1,25 @@
+package ai
+
+import "testing"
+
+func TestParseTranslation(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		wantText string
+		wantLang string
+	}{
+		{name: "json", response: `{"source_lang": "de", "text": "Hello"}`, wantText: "Hello", wantLang: "de"},
+		{name: "json in code block", response: "```json\n{\"source_lang\": \"fr\", \"text\": \"Thanks\"}\n```", wantText: "Thanks", wantLang: "fr"},
+		{name: "plain text", response: " Hello there ", wantText: "Hello there", wantLang: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text, lang := parseTranslation(tt.response)
+			if text != tt.wantText || lang != tt.wantLang {
+				t.Errorf("got (%q, %q), want (%q, %q)", text, lang, tt.wantText, tt.wantLang)
+			}
+		})
+	}
+}
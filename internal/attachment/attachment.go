@@ -1,9 +1,12 @@
 package attachment
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/textproto"
+	"os"
 )
 
 const (
@@ -21,7 +24,12 @@ type Attachment struct {
 	Disposition string               `json:"disposition"`
 	UUID        string               `json:"uuid"`
 	URL         string               `json:"url"`
+	BlobName    string               `json:"blob_name,omitempty"`
 	Header      textproto.MIMEHeader `json:"-"`
+
+	// Open opens the content for reading when it's not loaded in `Content`, so that stored blobs
+	// are only read when the attachment is sent and received parts aren't copied before they're uploaded.
+	Open func() (io.ReadCloser, error) `json:"-"`
 }
 
 type Attachments []Attachment
@@ -38,6 +46,81 @@ func (a *Attachments) Scan(value interface{}) error {
 	return json.Unmarshal(bytes, a)
 }
 
+// ReadContent returns the attachment content, reading it with `Open` if it's not loaded.
+func (a Attachment) ReadContent() ([]byte, error) {
+	if a.Content != nil || a.Open == nil {
+		return a.Content, nil
+	}
+	r, err := a.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+// Reader opens the attachment content for reading, with `Open` or from `Content` for attachments loaded in memory.
+func (a Attachment) Reader() (io.ReadCloser, error) {
+	if a.Content != nil || a.Open == nil {
+		return readSeekNopCloser{bytes.NewReader(a.Content)}, nil
+	}
+	return a.Open()
+}
+
+// SeekableReader opens the attachment content like Reader for consumers that read it more than once. Contents that
+// can't be rewound, like remote blobs, are spooled to a temporary file removed on close.
+func (a Attachment) SeekableReader() (io.ReadSeekCloser, error) {
+	r, err := a.Reader()
+	if err != nil {
+		return nil, err
+	}
+	if rs, ok := r.(io.ReadSeekCloser); ok {
+		return rs, nil
+	}
+	defer r.Close()
+
+	f, err := os.CreateTemp("", "attachment-*")
+	if err != nil {
+		return nil, err
+	}
+	spooled := tempFile{f}
+	if _, err := io.Copy(f, r); err != nil {
+		spooled.Close()
+		return nil, err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		spooled.Close()
+		return nil, err
+	}
+	return spooled, nil
+}
+
+// BytesOpener returns an `Open` function for content already in memory, e.g. a parsed MIME part, so it's read
+// without copying it to `Content`.
+func BytesOpener(b []byte) func() (io.ReadCloser, error) {
+	return func() (io.ReadCloser, error) {
+		return readSeekNopCloser{bytes.NewReader(b)}, nil
+	}
+}
+
+// readSeekNopCloser is a seekable reader with a no-op Close.
+type readSeekNopCloser struct {
+	io.ReadSeeker
+}
+
+func (readSeekNopCloser) Close() error { return nil }
+
+// tempFile is a temporary file removed on close.
+type tempFile struct {
+	*os.File
+}
+
+func (f tempFile) Close() error {
+	err := f.File.Close()
+	os.Remove(f.Name())
+	return err
+}
+
 // MakeHeader creates a MIME header for email attachments or inline content.
 func MakeHeader(contentType, contentID, fileName, encoding, disposition string) textproto.MIMEHeader {
 	if encoding == "" {
@@ -1,8 +1,10 @@
 package attachment
 
 import (
+	"io"
 	"net/textproto"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -116,3 +118,40 @@ func TestAttachments_Scan(t *testing.T) {
 		})
 	}
 }
+
+func TestSeekableReader(t *testing.T) {
+	tests := []struct {
+		name string
+		att  Attachment
+	}{
+		{name: "content", att: Attachment{Content: []byte("hello")}},
+		{name: "seekable opener", att: Attachment{Open: BytesOpener([]byte("hello"))}},
+		{name: "stream", att: Attachment{Open: func() (io.ReadCloser, error) {
+			return io.NopCloser(strings.NewReader("hello")), nil
+		}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := tt.att.SeekableReader()
+			if err != nil {
+				t.Fatalf("SeekableReader() error = %v", err)
+			}
+			defer r.Close()
+
+			// Read twice, as when hashing the content before uploading it.
+			for i := 0; i < 2; i++ {
+				b, err := io.ReadAll(r)
+				if err != nil {
+					t.Fatalf("reading: %v", err)
+				}
+				if string(b) != "hello" {
+					t.Errorf("read %q, want %q", b, "hello")
+				}
+				if _, err := r.Seek(0, io.SeekStart); err != nil {
+					t.Fatalf("seeking: %v", err)
+				}
+			}
+		})
+	}
+}
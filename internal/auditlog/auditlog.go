@@ -0,0 +1,185 @@
+// Package auditlog records administrative changes along with who made them.
+package auditlog
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/abhinavxd/libredesk/internal/auditlog/models"
+	"github.com/abhinavxd/libredesk/internal/dbutil"
+	"github.com/abhinavxd/libredesk/internal/envelope"
+	"github.com/jmoiron/sqlx"
+	"github.com/knadh/go-i18n"
+	"github.com/volatiletech/null/v9"
+	"github.com/zerodha/logf"
+)
+
+var (
+	//go:embed queries.sql
+	efs embed.FS
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 100
+
+	redacted = "[redacted]"
+)
+
+// ignoredFields are bookkeeping fields that change on every update and would only add noise to the diff.
+var ignoredFields = map[string]struct{}{
+	"created_at": {},
+	"updated_at": {},
+}
+
+// sensitiveFields are fields whose values are never stored, only the fact that they changed.
+var sensitiveFields = []string{"password", "secret", "token", "api_key"}
+
+// Manager manages the audit log.
+type Manager struct {
+	q    queries
+	lo   *logf.Logger
+	i18n *i18n.I18n
+}
+
+// Opts contains options for initializing the Manager.
+type Opts struct {
+	DB   *sqlx.DB
+	Lo   *logf.Logger
+	I18n *i18n.I18n
+}
+
+// queries contains prepared SQL queries.
+type queries struct {
+	InsertLog *sqlx.Stmt `query:"insert-log"`
+	GetLogs   *sqlx.Stmt `query:"get-logs"`
+}
+
+// New creates and returns a new instance of the Manager.
+func New(opts Opts) (*Manager, error) {
+	var q queries
+	if err := dbutil.ScanSQLFile("queries.sql", &q, opts.DB, efs); err != nil {
+		return nil, err
+	}
+	return &Manager{
+		q:    q,
+		lo:   opts.Lo,
+		i18n: opts.I18n,
+	}, nil
+}
+
+// Record records an action by the actor on a target. Before and after are the target's state around the change,
+// nil for the side that doesn't exist, and only the fields that differ are stored.
+// The change has already happened when this is called so errors are logged and not returned.
+func (m *Manager) Record(actorID int, actorName, action, targetType string, targetID int, before, after any) {
+	changes, err := Diff(before, after)
+	if err != nil {
+		m.lo.Error("error computing audit log diff", "action", action, "target_type", targetType, "target_id", targetID, "error", err)
+		changes = []byte("{}")
+	}
+	if _, err := m.q.InsertLog.Exec(actorID, actorName, action, targetType, targetID, changes); err != nil {
+		m.lo.Error("error inserting audit log", "action", action, "target_type", targetType, "target_id", targetID, "error", err)
+	}
+}
+
+// GetLogs returns the audit logs matching the filter, newest first.
+func (m *Manager) GetLogs(filter models.Filter, page, pageSize int) ([]models.Log, error) {
+	if pageSize > maxPageSize {
+		return nil, envelope.NewError(envelope.InputError, m.i18n.Ts("globals.messages.pageTooLarge", "max", fmt.Sprintf("%d", maxPageSize)), nil)
+	}
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = defaultPageSize
+	}
+
+	var (
+		logs     = make([]models.Log, 0)
+		from, to null.Time
+	)
+	if !filter.From.IsZero() {
+		from = null.TimeFrom(filter.From)
+	}
+	if !filter.To.IsZero() {
+		to = null.TimeFrom(filter.To)
+	}
+	if err := m.q.GetLogs.Select(&logs, filter.ActorID, filter.Action, filter.TargetType, filter.TargetID, from, to, pageSize, (page-1)*pageSize); err != nil {
+		m.lo.Error("error fetching audit logs", "error", err)
+		return nil, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.auditLog}"), nil)
+	}
+	return logs, nil
+}
+
+// Diff returns the JSON encoded fields that differ between the JSON representations of before and after
+// as a map of field name to models.Change. Values of sensitive fields are redacted.
+func Diff(before, after any) (json.RawMessage, error) {
+	old, err := toMap(before)
+	if err != nil {
+		return nil, err
+	}
+	updated, err := toMap(after)
+	if err != nil {
+		return nil, err
+	}
+
+	// A field missing on one side is compared as nil.
+	keys := make(map[string]struct{}, len(old)+len(updated))
+	for key := range old {
+		keys[key] = struct{}{}
+	}
+	for key := range updated {
+		keys[key] = struct{}{}
+	}
+
+	changes := make(map[string]models.Change)
+	for key := range keys {
+		if _, ok := ignoredFields[key]; ok {
+			continue
+		}
+		change := models.Change{Old: old[key], New: updated[key]}
+		if reflect.DeepEqual(change.Old, change.New) {
+			continue
+		}
+		if isSensitive(key) {
+			if change.Old != nil {
+				change.Old = redacted
+			}
+			if change.New != nil {
+				change.New = redacted
+			}
+		}
+		changes[key] = change
+	}
+	return json.Marshal(changes)
+}
+
+// toMap converts a value to a map of its JSON fields, nil is an empty map.
+func toMap(v any) (map[string]any, error) {
+	out := make(map[string]any)
+	if v == nil {
+		return out, nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// isSensitive returns true if the field name looks like it holds a credential.
+func isSensitive(key string) bool {
+	key = strings.ToLower(key)
+	for _, s := range sensitiveFields {
+		if strings.Contains(key, s) {
+			return true
+		}
+	}
+	return false
+}
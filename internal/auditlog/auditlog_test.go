@@ -0,0 +1,82 @@
+package auditlog
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	type record struct {
+		Name         string   `json:"name"`
+		Enabled      bool     `json:"enabled"`
+		Roles        []string `json:"roles"`
+		ClientSecret string   `json:"client_secret,omitempty"`
+		UpdatedAt    string   `json:"updated_at"`
+	}
+
+	tests := []struct {
+		name     string
+		before   any
+		after    any
+		expected map[string]any
+	}{
+		{
+			name:     "no changes",
+			before:   record{Name: "a", UpdatedAt: "1"},
+			after:    record{Name: "a", UpdatedAt: "2"},
+			expected: map[string]any{},
+		},
+		{
+			name:   "changed fields",
+			before: record{Name: "a", Roles: []string{"Agent"}},
+			after:  record{Name: "b", Roles: []string{"Admin"}},
+			expected: map[string]any{
+				"name":  map[string]any{"old": "a", "new": "b"},
+				"roles": map[string]any{"old": []any{"Agent"}, "new": []any{"Admin"}},
+			},
+		},
+		{
+			name:   "create",
+			before: nil,
+			after:  record{Name: "a", Enabled: true},
+			expected: map[string]any{
+				"name":    map[string]any{"old": nil, "new": "a"},
+				"enabled": map[string]any{"old": nil, "new": true},
+			},
+		},
+		{
+			name:   "delete",
+			before: record{Name: "a"},
+			after:  nil,
+			expected: map[string]any{
+				"name":    map[string]any{"old": "a", "new": nil},
+				"enabled": map[string]any{"old": false, "new": nil},
+			},
+		},
+		{
+			name:   "sensitive field is redacted",
+			before: record{Name: "a", ClientSecret: "old"},
+			after:  record{Name: "a", ClientSecret: "new"},
+			expected: map[string]any{
+				"client_secret": map[string]any{"old": redacted, "new": redacted},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := Diff(tt.before, tt.after)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var got map[string]any
+			if err := json.Unmarshal(b, &got); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("got %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/volatiletech/null/v9"
+)
+
+// Actions.
+const (
+	ActionCreate   = "create"
+	ActionUpdate   = "update"
+	ActionDelete   = "delete"
+	ActionRestore  = "restore"
+	ActionExport   = "export"
+	ActionErase    = "erase"
+	ActionDownload = "download"
+)
+
+// Target types.
+const (
+	TargetUser           = "user"
+	TargetOIDC           = "oidc"
+	TargetAutomationRule = "automation_rule"
+	TargetConversation   = "conversation"
+	TargetContact        = "contact"
+	TargetMedia          = "media"
+)
+
+// Log is a recorded administrative action.
+type Log struct {
+	ID         int64           `db:"id" json:"id"`
+	CreatedAt  time.Time       `db:"created_at" json:"created_at"`
+	ActorID    null.Int        `db:"actor_id" json:"actor_id"`
+	ActorName  string          `db:"actor_name" json:"actor_name"`
+	Action     string          `db:"action" json:"action"`
+	TargetType string          `db:"target_type" json:"target_type"`
+	TargetID   null.Int        `db:"target_id" json:"target_id"`
+	Changes    json.RawMessage `db:"changes" json:"changes"`
+
+	Total int `db:"total" json:"-"`
+}
+
+// Filter narrows down the logs returned, zero values match everything.
+type Filter struct {
+	ActorID    int
+	Action     string
+	TargetType string
+	TargetID   int
+	From       time.Time
+	To         time.Time
+}
+
+// Change is the old and new value of a changed field.
+type Change struct {
+	Old any `json:"old"`
+	New any `json:"new"`
+}
@@ -0,0 +1,24 @@
+-- name: insert-log
+INSERT INTO audit_logs (actor_id, actor_name, action, target_type, target_id, changes)
+VALUES (NULLIF($1, 0), $2, $3, $4, NULLIF($5, 0), $6);
+
+-- name: get-logs
+SELECT
+    id,
+    created_at,
+    actor_id,
+    actor_name,
+    action,
+    target_type,
+    target_id,
+    changes,
+    COUNT(*) OVER() AS total
+FROM audit_logs
+WHERE ($1 = 0 OR actor_id = $1)
+    AND ($2 = '' OR action = $2)
+    AND ($3 = '' OR target_type = $3)
+    AND ($4 = 0 OR target_id = $4)
+    AND ($5::TIMESTAMPTZ IS NULL OR created_at >= $5)
+    AND ($6::TIMESTAMPTZ IS NULL OR created_at < $6)
+ORDER BY created_at DESC, id DESC
+LIMIT $7 OFFSET $8;
@@ -1,9 +1,19 @@
 package models
 
+import "slices"
+
 // User represents an authenticated user.
 type User struct {
 	ID        int    `json:"id"`
 	FirstName string `json:"first_name"`
 	LastName  string `json:"last_name"`
 	Email     string `json:"email,omitempty"`
+	// Permissions are the resolved permissions of the user's roles for the request, narrowed to the
+	// token's scopes for requests authenticated with an API token. Not stored in the session.
+	Permissions []string `json:"-"`
+}
+
+// HasPermission returns true if the user has the permission.
+func (u User) HasPermission(perm string) bool {
+	return slices.Contains(u.Permissions, perm)
 }
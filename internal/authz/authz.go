@@ -154,7 +154,7 @@ func (e *Enforcer) EnforceMediaAccess(user umodels.User, model string) (bool, er
 			return false, envelope.NewError(envelope.GeneralError, e.i18n.Ts("globals.messages.errorChecking", "name", "{globals.terms.permission}"), nil)
 		}
 		if !allowed {
-			return false, envelope.NewError(envelope.UnauthorizedError, e.i18n.Ts("globals.messages.denied", "name", "{globals.terms.permission}"), nil)
+			return false, envelope.NewError(envelope.PermissionError, e.i18n.Ts("globals.messages.denied", "name", "{globals.terms.permission}"), nil)
 		}
 	default:
 		return true, nil
@@ -12,7 +12,10 @@ const (
 	PermConversationsUpdatePriority     = "conversations:update_priority"
 	PermConversationsUpdateStatus       = "conversations:update_status"
 	PermConversationsUpdateTags         = "conversations:update_tags"
+	PermConversationsUpdateCustomAttrs  = "conversations:update_custom_attributes"
+	PermConversationsUpdateSubject      = "conversations:update_subject"
 	PermConversationWrite               = "conversations:write"
+	PermConversationsDelete             = "conversations:delete"
 	PermMessagesRead                    = "messages:read"
 	PermMessagesWrite                   = "messages:write"
 
@@ -72,6 +75,8 @@ const (
 	PermContactsRead    = "contacts:read"
 	PermContactsWrite   = "contacts:write"
 	PermContactsBlock   = "contacts:block"
+	PermContactsExport  = "contacts:export"
+	PermContactsErase   = "contacts:erase"
 
 	// Contact Notes
 	PermContactNotesRead   = "contact_notes:read"
@@ -80,6 +85,12 @@ const (
 
 	// Custom attributes
 	PermCustomAttributesManage = "custom_attributes:manage"
+
+	// Audit logs
+	PermAuditLogsRead = "audit_logs:read"
+
+	// Queues
+	PermQueuesManage = "queues:manage"
 )
 
 var validPermissions = map[string]struct{}{
@@ -93,7 +104,10 @@ var validPermissions = map[string]struct{}{
 	PermConversationsUpdatePriority:     {},
 	PermConversationsUpdateStatus:       {},
 	PermConversationsUpdateTags:         {},
+	PermConversationsUpdateCustomAttrs:  {},
+	PermConversationsUpdateSubject:      {},
 	PermConversationWrite:               {},
+	PermConversationsDelete:             {},
 	PermMessagesRead:                    {},
 	PermMessagesWrite:                   {},
 	PermViewManage:                      {},
@@ -118,9 +132,13 @@ var validPermissions = map[string]struct{}{
 	PermContactsRead:                    {},
 	PermContactsWrite:                   {},
 	PermContactsBlock:                   {},
+	PermContactsExport:                  {},
+	PermContactsErase:                   {},
 	PermContactNotesRead:                {},
 	PermContactNotesWrite:               {},
 	PermContactNotesDelete:              {},
+	PermAuditLogsRead:                   {},
+	PermQueuesManage:                    {},
 }
 
 // IsValidPermission returns true if it's a valid permission.
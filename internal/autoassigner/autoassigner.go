@@ -28,7 +28,7 @@ const (
 type conversationStore interface {
 	GetUnassignedConversations() ([]models.Conversation, error)
 	UpdateConversationUserAssignee(conversationUUID string, userID int, user umodels.User) error
-	ActiveUserConversationsCount(userID int) (int, error)
+	GetAgentWorkload(userID int, excludeUUID string) (models.AgentWorkload, error)
 }
 
 type teamStore interface {
@@ -204,13 +204,34 @@ func (e *Engine) assignConversations() error {
 	}
 
 	for _, conversation := range unassignedConversations {
+		userID, ok := e.pickPoolUser(conversation)
+		if !ok {
+			continue
+		}
+
+		// Assign conversation to user.
+		if err := e.conversationStore.UpdateConversationUserAssignee(conversation.UUID, userID, e.systemUser); err != nil {
+			e.lo.Error("error assigning conversation", "conversation_uuid", conversation.UUID, "error", err)
+			continue
+		}
+	}
+	return nil
+}
+
+// pickPoolUser returns the next user of the team balancer pool that can take the conversation, users who have
+// reached the team's max auto assigned conversations or their own max open conversations are passed over for the
+// next one in the pool.
+func (e *Engine) pickPoolUser(conversation models.Conversation) (int, bool) {
+	teamID := conversation.AssignedTeamID.Int
+	poolSize := e.poolSize(teamID)
+	for i := 0; i < poolSize; i++ {
 		// Get user from the pool.
-		userIDStr, err := e.getUserFromPool(conversation.AssignedTeamID.Int)
+		userIDStr, err := e.getUserFromPool(teamID)
 		if err != nil {
 			if err != ErrTeamNotFound {
 				e.lo.Error("error fetching user from balancer pool", "conversation_uuid", conversation.UUID, "error", err)
 			}
-			continue
+			return 0, false
 		}
 
 		// Convert to int.
@@ -220,31 +241,41 @@ func (e *Engine) assignConversations() error {
 			continue
 		}
 
-		// Get active conversations count for the user.
-		activeConversationsCount, err := e.conversationStore.ActiveUserConversationsCount(userID)
+		// Get active conversations count and cap of the user.
+		workload, err := e.conversationStore.GetAgentWorkload(userID, "")
 		if err != nil {
 			e.lo.Error("error fetching active conversations count for user", "user_id", userID, "error", err)
 			continue
 		}
 
-		teamMaxAutoAssignments := e.teamMaxAutoAssignments[conversation.AssignedTeamID.Int]
+		teamMaxAutoAssignments := e.teamMaxAutoAssignments[teamID]
 		// Check if user has reached the max auto assigned conversations limit,
 		// 0 is unlimited.
-		if teamMaxAutoAssignments != 0 {
-			if activeConversationsCount >= teamMaxAutoAssignments {
-				e.lo.Debug("user has reached max auto assigned conversations limit, skipping auto assignment", "user_id", userID,
-					"user_active_conversations_count", activeConversationsCount, "max_auto_assigned_conversations", teamMaxAutoAssignments)
-				continue
-			}
+		if teamMaxAutoAssignments != 0 && workload.ActiveConversationsCount >= teamMaxAutoAssignments {
+			e.lo.Debug("user has reached max auto assigned conversations limit, trying next user", "user_id", userID,
+				"user_active_conversations_count", workload.ActiveConversationsCount, "max_auto_assigned_conversations", teamMaxAutoAssignments)
+			continue
 		}
-
-		// Assign conversation to user.
-		if err := e.conversationStore.UpdateConversationUserAssignee(conversation.UUID, userID, e.systemUser); err != nil {
-			e.lo.Error("error assigning conversation", "conversation_uuid", conversation.UUID, "error", err)
+		if workload.AtOpenConversationsCap() {
+			e.lo.Debug("user has reached max open conversations, trying next user", "user_id", userID,
+				"user_active_conversations_count", workload.ActiveConversationsCount, "max_open_conversations", workload.MaxOpenConversations)
 			continue
 		}
+		return userID, true
 	}
-	return nil
+	return 0, false
+}
+
+// poolSize returns the number of users in the team balancer pool.
+func (e *Engine) poolSize(teamID int) int {
+	e.balanceMu.Lock()
+	defer e.balanceMu.Unlock()
+
+	pool, ok := e.roundRobinBalancer[teamID]
+	if !ok {
+		return 0
+	}
+	return len(pool.ItemIDs())
 }
 
 // getUserFromPool returns user ID from the team balancer pool.
@@ -0,0 +1,164 @@
+package automation
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strconv"
+
+	"github.com/abhinavxd/libredesk/internal/automation/models"
+	cmodels "github.com/abhinavxd/libredesk/internal/conversation/models"
+	umodels "github.com/abhinavxd/libredesk/internal/user/models"
+)
+
+var errNoAgentAvailable = errors.New("no agent available for assignment")
+
+// assignTeamAgent assigns the conversation to the team in the action value and then to an agent of that team
+// picked using the assignment strategy.
+//
+// Action value is [team_id, strategy, respect_availability, match_skills], strategy defaults to round robin,
+// respect_availability restricts the candidates to online agents and match_skills to the agents having all the skills
+// the conversation requires when set to `true`.
+func (e *Engine) assignTeamAgent(action models.RuleAction, conversation cmodels.Conversation) error {
+	if len(action.Value) == 0 {
+		return fmt.Errorf("%w: empty value for action %s", errInvalidAction, action.Type)
+	}
+	teamID, err := strconv.Atoi(action.Value[0])
+	if err != nil || teamID <= 0 {
+		return fmt.Errorf("%w: invalid team id %q for action %s", errInvalidAction, action.Value[0], action.Type)
+	}
+
+	strategy := models.AssignmentStrategyRoundRobin
+	if len(action.Value) > 1 && action.Value[1] != "" {
+		strategy = action.Value[1]
+	}
+	onlineOnly := len(action.Value) > 2 && action.Value[2] == "true"
+
+	var skills []string
+	if len(action.Value) > 3 && action.Value[3] == "true" {
+		if skills, err = requiredSkills(conversation); err != nil {
+			return fmt.Errorf("reading required skills of conversation %s: %w", conversation.UUID, err)
+		}
+	}
+
+	// Assignments are serialized from the pick to the cursor update, so concurrent workers don't pick the same agent.
+	e.assignMu.Lock()
+	defer e.assignMu.Unlock()
+
+	agentID, err := e.pickTeamAgent(teamID, strategy, onlineOnly, skills)
+	if err != nil {
+		return err
+	}
+
+	// Move the conversation to the team first, so the SLA policy of the team gets applied.
+	if conversation.AssignedTeamID.Int != teamID {
+		if err := e.conversationStore.ApplyAction(models.RuleAction{
+			Type:  models.ActionAssignTeam,
+			Value: []string{strconv.Itoa(teamID)},
+		}, conversation, e.systemUser); err != nil {
+			return err
+		}
+	}
+
+	e.lo.Debug("assigning conversation to team agent", "conversation_uuid", conversation.UUID, "team_id", teamID, "user_id", agentID, "strategy", strategy, "skills", skills)
+	if err := e.conversationStore.ApplyAction(models.RuleAction{
+		Type:  models.ActionAssignUser,
+		Value: []string{strconv.Itoa(agentID)},
+	}, conversation, e.systemUser); err != nil {
+		return err
+	}
+
+	// Advance the round robin cursor only once the agent got the conversation, so a failed assignment doesn't skip them.
+	if strategy == models.AssignmentStrategyRoundRobin {
+		if _, err := e.q.UpsertAssignmentCursor.Exec(teamID, agentID); err != nil {
+			e.lo.Error("error saving assignment cursor", "team_id", teamID, "error", err)
+			return err
+		}
+	}
+	return nil
+}
+
+// pickTeamAgent picks an agent from the team using the passed strategy, among the agents having all the skills.
+// The caller holds assignMu.
+func (e *Engine) pickTeamAgent(teamID int, strategy string, onlineOnly bool, skills []string) (int, error) {
+	workload, err := e.conversationStore.GetTeamAgentsWorkload(teamID)
+	if err != nil {
+		return 0, err
+	}
+	candidates := filterAssignableAgents(workload, onlineOnly)
+	if len(skills) > 0 && len(candidates) > 0 {
+		if e.userStore == nil {
+			return 0, errors.New("no user store set for skill aware assignment")
+		}
+		skilledIDs, err := e.userStore.MatchAgentsBySkill(skills, teamID)
+		if err != nil {
+			return 0, err
+		}
+		candidates = filterSkilledAgents(candidates, skilledIDs)
+	}
+	if len(candidates) == 0 {
+		return 0, fmt.Errorf("team %d: %w", teamID, errNoAgentAvailable)
+	}
+
+	switch strategy {
+	case models.AssignmentStrategyRoundRobin:
+		var lastAssignedUserID int
+		if err := e.q.GetAssignmentCursor.Get(&lastAssignedUserID, teamID); err != nil && err != sql.ErrNoRows {
+			e.lo.Error("error fetching assignment cursor", "team_id", teamID, "error", err)
+			return 0, err
+		}
+		return nextRoundRobinAgent(candidates, lastAssignedUserID), nil
+	case models.AssignmentStrategyLeastBusy:
+		return leastBusyAgent(candidates), nil
+	case models.AssignmentStrategyRandom:
+		return candidates[rand.Intn(len(candidates))].UserID, nil
+	default:
+		return 0, fmt.Errorf("unknown assignment strategy: %s", strategy)
+	}
+}
+
+// filterAssignableAgents returns the agents that can take a new conversation, agents who are away are skipped
+// and so are the ones who have reached the team's max auto assigned conversations or their own max open
+// conversations, 0 is unlimited.
+func filterAssignableAgents(workload []cmodels.AgentWorkload, onlineOnly bool) []cmodels.AgentWorkload {
+	var out = make([]cmodels.AgentWorkload, 0, len(workload))
+	for _, agent := range workload {
+		if agent.AvailabilityStatus == umodels.AwayManual || agent.AvailabilityStatus == umodels.AwayAndReassigning {
+			continue
+		}
+		if onlineOnly && agent.AvailabilityStatus != umodels.Online {
+			continue
+		}
+		if agent.MaxAutoAssignedConversations > 0 && agent.ActiveConversationsCount >= agent.MaxAutoAssignedConversations {
+			continue
+		}
+		if agent.AtOpenConversationsCap() {
+			continue
+		}
+		out = append(out, agent)
+	}
+	return out
+}
+
+// nextRoundRobinAgent returns the agent after the last assigned one, wrapping around to the first agent.
+// Candidates are expected to be sorted by user ID.
+func nextRoundRobinAgent(candidates []cmodels.AgentWorkload, lastAssignedUserID int) int {
+	for _, agent := range candidates {
+		if agent.UserID > lastAssignedUserID {
+			return agent.UserID
+		}
+	}
+	return candidates[0].UserID
+}
+
+// leastBusyAgent returns the agent with the fewest active conversations, ties go to the lowest user ID.
+func leastBusyAgent(candidates []cmodels.AgentWorkload) int {
+	best := candidates[0]
+	for _, agent := range candidates[1:] {
+		if agent.ActiveConversationsCount < best.ActiveConversationsCount {
+			best = agent
+		}
+	}
+	return best.UserID
+}
@@ -0,0 +1,77 @@
+package automation
+
+import (
+	"testing"
+
+	cmodels "github.com/abhinavxd/libredesk/internal/conversation/models"
+	umodels "github.com/abhinavxd/libredesk/internal/user/models"
+)
+
+func TestFilterAssignableAgents(t *testing.T) {
+	workload := []cmodels.AgentWorkload{
+		{UserID: 1, AvailabilityStatus: umodels.Online, ActiveConversationsCount: 2, MaxAutoAssignedConversations: 5},
+		{UserID: 2, AvailabilityStatus: umodels.AwayManual},
+		{UserID: 3, AvailabilityStatus: umodels.Offline, ActiveConversationsCount: 1, MaxAutoAssignedConversations: 5},
+		{UserID: 4, AvailabilityStatus: umodels.Online, ActiveConversationsCount: 5, MaxAutoAssignedConversations: 5},
+		{UserID: 5, AvailabilityStatus: umodels.AwayAndReassigning},
+		{UserID: 6, AvailabilityStatus: umodels.Online, ActiveConversationsCount: 3, MaxOpenConversations: 3},
+		{UserID: 7, AvailabilityStatus: umodels.Online, ActiveConversationsCount: 2, MaxOpenConversations: 3},
+	}
+
+	tests := []struct {
+		name       string
+		onlineOnly bool
+		expected   []int
+	}{
+		{name: "skip away and capped agents", expected: []int{1, 3, 7}},
+		{name: "online agents only", onlineOnly: true, expected: []int{1, 7}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := filterAssignableAgents(workload, tt.onlineOnly)
+			if len(got) != len(tt.expected) {
+				t.Fatalf("got %d agents, want %d", len(got), len(tt.expected))
+			}
+			for i := range got {
+				if got[i].UserID != tt.expected[i] {
+					t.Errorf("at index %d got user %d, want %d", i, got[i].UserID, tt.expected[i])
+				}
+			}
+		})
+	}
+}
+
+func TestNextRoundRobinAgent(t *testing.T) {
+	candidates := []cmodels.AgentWorkload{{UserID: 3}, {UserID: 7}, {UserID: 9}}
+
+	tests := []struct {
+		name         string
+		lastAssigned int
+		expected     int
+	}{
+		{name: "no cursor", lastAssigned: 0, expected: 3},
+		{name: "next agent", lastAssigned: 3, expected: 7},
+		{name: "cursor agent left the team", lastAssigned: 8, expected: 9},
+		{name: "wrap around", lastAssigned: 9, expected: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nextRoundRobinAgent(candidates, tt.lastAssigned); got != tt.expected {
+				t.Errorf("got %d, want %d", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestLeastBusyAgent(t *testing.T) {
+	candidates := []cmodels.AgentWorkload{
+		{UserID: 1, ActiveConversationsCount: 4},
+		{UserID: 2, ActiveConversationsCount: 1},
+		{UserID: 3, ActiveConversationsCount: 1},
+	}
+	if got := leastBusyAgent(candidates); got != 2 {
+		t.Errorf("got %d, want 2", got)
+	}
+}
@@ -0,0 +1,40 @@
+package automation
+
+import (
+	"time"
+)
+
+// AutoCloseOpts configures the automatic closing of conversations that had no activity for a while.
+type AutoCloseOpts struct {
+	// After is the inactivity duration after which conversations are closed, 0 disables auto-close.
+	After time.Duration
+	// FromStatuses are the statuses of conversations eligible for auto-close, e.g. Resolved.
+	FromStatuses []string
+	// ToStatus is the status the conversations are moved to, e.g. Closed.
+	ToStatus string
+}
+
+// autoCloseConversations moves conversations that have been in one of the auto-close statuses without any activity
+// for the configured duration to the target status. A new incoming message reopens the conversation, which takes
+// it out of the auto-close statuses.
+func (e *Engine) autoCloseConversations() {
+	if e.autoClose.After <= 0 || len(e.autoClose.FromStatuses) == 0 || e.autoClose.ToStatus == "" {
+		return
+	}
+
+	inactiveSince := time.Now().Add(-e.autoClose.After)
+	conversations, err := e.conversationStore.GetConversationsToAutoClose(e.autoClose.FromStatuses, inactiveSince)
+	if err != nil {
+		e.lo.Error("error fetching conversations to auto-close", "error", err)
+		return
+	}
+
+	for _, c := range conversations {
+		// The status change is made, and recorded in the activity, as the user automation rules act as.
+		if err := e.conversationStore.UpdateConversationStatus(c.UUID, 0, e.autoClose.ToStatus, "", e.systemUser); err != nil {
+			e.lo.Error("error auto-closing conversation", "uuid", c.UUID, "error", err)
+			continue
+		}
+		e.lo.Debug("auto-closed conversation", "uuid", c.UUID, "status", e.autoClose.ToStatus)
+	}
+}
@@ -36,6 +36,8 @@ const (
 	NewConversation    TaskType = "new"
 	UpdateConversation TaskType = "update"
 	TimeTrigger        TaskType = "time-trigger"
+	UnassignedTrigger  TaskType = "unassigned-trigger"
+	RetryTrigger       TaskType = "retry-trigger"
 )
 
 // ConversationTask represents a unit of work for processing conversations.
@@ -52,22 +54,68 @@ type Engine struct {
 	lo                *logf.Logger
 	i18n              *i18n.I18n
 	conversationStore conversationStore
+	userStore         userStore
 	taskQueue         chan ConversationTask
 	closed            bool
 	closedMu          sync.RWMutex
 	wg                sync.WaitGroup
+
+	// loopGuard suppresses conversation update rules that keep re-triggering themselves.
+	loopGuard *loopGuard
+
+	// assignMu serializes agent assignments so that concurrent workers do not pick the same round robin cursor.
+	assignMu sync.Mutex
+
+	autoClose AutoCloseOpts
+
+	// reassignmentCooldown is how long after an agent assigns a conversation automation assignment actions are suppressed.
+	reassignmentCooldown time.Duration
+
+	// systemUser is the actor of every change and message made by the rules.
+	systemUser umodels.User
+
+	// router is the external router new conversations are assigned by, nil if not configured.
+	router *externalRouter
 }
 
 type Opts struct {
-	DB   *sqlx.DB
-	Lo   *logf.Logger
-	I18n *i18n.I18n
+	DB        *sqlx.DB
+	Lo        *logf.Logger
+	I18n      *i18n.I18n
+	AutoClose AutoCloseOpts
+	// ReassignmentCooldown suppresses automation assignment actions for this long after an agent assigns a conversation, 0 disables it.
+	ReassignmentCooldown time.Duration
+	// SystemUser is the user rules act as, the conversation's system user is used when unset.
+	SystemUser umodels.User
+	// ExternalRouter posts new conversations to an external system for an assignment decision before the rules run.
+	ExternalRouter ExternalRouterOpts
+	// UserStore matches agents by skill for skill aware assignment.
+	UserStore userStore
 }
 
 type conversationStore interface {
 	ApplyAction(action models.RuleAction, conversation cmodels.Conversation, user umodels.User) error
 	GetConversation(teamID int, uuid string) (cmodels.Conversation, error)
 	GetConversationsCreatedAfter(time.Time) ([]cmodels.Conversation, error)
+	GetConversationsToAutoClose(statuses []string, inactiveSince time.Time) ([]cmodels.Conversation, error)
+	UpdateConversationStatus(uuid string, statusID int, status, snoozeDur string, actor umodels.User) error
+	GetLatestIncomingMessage(conversationID int) (cmodels.Message, error)
+	GetContactPastConversationCount(contactID, conversationID int) (int, error)
+	GetUnansweredSince(conversationID int) (time.Time, error)
+	BusinessMinutesSince(start time.Time, assignedTeamID int) (int, error)
+	AddTags(uuid string, tagNames []string, actor umodels.User) error
+	RemoveTags(uuid string, tagNames []string, actor umodels.User) error
+	GetTeamAgentsWorkload(teamID int) ([]cmodels.AgentWorkload, error)
+	SetConversationCustomAttribute(uuid, key, value string) error
+	GetUnassignedConversationsToEscalate() ([]cmodels.UnassignedEscalation, error)
+	MarkUnassignedEscalated(conversationID int) error
+	SendUnassignedConversationAlert(conversationUUID string, userID, minutes int) error
+	SendDueReminders() error
+}
+
+// userStore matches agents by their skills.
+type userStore interface {
+	MatchAgentsBySkill(skills []string, teamID int) ([]int, error)
 }
 
 type queries struct {
@@ -80,6 +128,14 @@ type queries struct {
 	GetEnabledRules         *sqlx.Stmt `query:"get-enabled-rules"`
 	UpdateRuleWeight        *sqlx.Stmt `query:"update-rule-weight"`
 	UpdateRuleExecutionMode *sqlx.Stmt `query:"update-rule-execution-mode"`
+	GetAssignmentCursor     *sqlx.Stmt `query:"get-assignment-cursor"`
+	UpsertAssignmentCursor  *sqlx.Stmt `query:"upsert-assignment-cursor"`
+	InsertFailedAction      *sqlx.Stmt `query:"insert-failed-action"`
+	ClaimDueFailedActions   *sqlx.Stmt `query:"claim-due-failed-actions"`
+	UpdateFailedAttempt     *sqlx.Stmt `query:"update-failed-action-attempt"`
+	GetFailedActions        *sqlx.Stmt `query:"get-failed-actions"`
+	RetryFailedAction       *sqlx.Stmt `query:"retry-failed-action"`
+	DeleteFailedAction      *sqlx.Stmt `query:"delete-failed-action"`
 }
 
 // New initializes a new Engine.
@@ -87,9 +143,16 @@ func New(opt Opts) (*Engine, error) {
 	var (
 		q queries
 		e = &Engine{
-			lo:        opt.Lo,
-			i18n:      opt.I18n,
-			taskQueue: make(chan ConversationTask, MaxQueueSize),
+			lo:         opt.Lo,
+			i18n:       opt.I18n,
+			taskQueue:  make(chan ConversationTask, MaxQueueSize),
+			loopGuard:  newLoopGuard(ruleLoopWindow, maxRuleLoopDepth),
+			autoClose:  opt.AutoClose,
+			systemUser: opt.SystemUser,
+			router:     newExternalRouter(opt.ExternalRouter),
+			userStore:  opt.UserStore,
+
+			reassignmentCooldown: opt.ReassignmentCooldown,
 		}
 	)
 	if err := dbutil.ScanSQLFile("queries.sql", &q, opt.DB, efs); err != nil {
@@ -127,13 +190,25 @@ func (e *Engine) Run(ctx context.Context, workerCount int) {
 		ticker.Stop()
 	}()
 
+	// Unassigned conversations are checked more often as the escalation threshold is in minutes.
+	unassignedTicker := time.NewTicker(unassignedCheckInterval)
+	defer unassignedTicker.Stop()
+
+	retryTicker := time.NewTicker(failedActionRetryInterval)
+	defer retryTicker.Stop()
+
 	for {
 		select {
 		case <-ctx.Done():
 			return
 		case <-ticker.C:
+			e.loopGuard.prune(time.Now())
 			e.lo.Info("queuing time triggers")
 			e.taskQueue <- ConversationTask{taskType: TimeTrigger}
+		case <-unassignedTicker.C:
+			e.taskQueue <- ConversationTask{taskType: UnassignedTrigger}
+		case <-retryTicker.C:
+			e.taskQueue <- ConversationTask{taskType: RetryTrigger}
 		}
 	}
 }
@@ -156,6 +231,10 @@ func (e *Engine) worker(ctx context.Context) {
 				e.handleUpdateConversation(task.conversationUUID, task.eventType)
 			case TimeTrigger:
 				e.handleTimeTrigger()
+			case UnassignedTrigger:
+				e.escalateUnassignedConversations()
+			case RetryTrigger:
+				e.retryFailedActions()
 			}
 		}
 	}
@@ -213,6 +292,9 @@ func (e *Engine) UpdateRule(id int, rule models.RuleRecord) error {
 	if rule.Events == nil {
 		rule.Events = pq.StringArray{}
 	}
+	if err := e.validateRuleRegexes(rule.Rules); err != nil {
+		return err
+	}
 	if _, err := e.q.UpdateRule.Exec(id, rule.Name, rule.Description, rule.Type, rule.Events, rule.Rules, rule.Enabled); err != nil {
 		e.lo.Error("error updating rule", "error", err)
 		return envelope.NewError(envelope.GeneralError, e.i18n.Ts("globals.messages.errorUpdating", "name", e.i18n.Ts("globals.terms.rule")), nil)
@@ -222,17 +304,32 @@ func (e *Engine) UpdateRule(id int, rule models.RuleRecord) error {
 	return nil
 }
 
-// CreateRule creates a new rule.
-func (e *Engine) CreateRule(rule models.RuleRecord) error {
+// CreateRule creates a new rule and returns it.
+func (e *Engine) CreateRule(rule models.RuleRecord) (models.RuleRecord, error) {
+	var created models.RuleRecord
 	if rule.Events == nil {
 		rule.Events = pq.StringArray{}
 	}
-	if _, err := e.q.InsertRule.Exec(rule.Name, rule.Description, rule.Type, rule.Events, rule.Rules); err != nil {
+	if err := e.validateRuleRegexes(rule.Rules); err != nil {
+		return created, err
+	}
+	if err := e.q.InsertRule.Get(&created, rule.Name, rule.Description, rule.Type, rule.Events, rule.Rules); err != nil {
 		e.lo.Error("error creating rule", "error", err)
-		return envelope.NewError(envelope.GeneralError, e.i18n.Ts("globals.messages.errorCreating", "name", e.i18n.Ts("globals.terms.rule")), nil)
+		return created, envelope.NewError(envelope.GeneralError, e.i18n.Ts("globals.messages.errorCreating", "name", e.i18n.Ts("globals.terms.rule")), nil)
 	}
 	// Reload rules.
 	e.ReloadRules()
+	return created, nil
+}
+
+// validateRuleRegexes checks the regex patterns of the rule conditions compile, so broken patterns are rejected when
+// the rule is saved instead of failing every evaluation.
+func (e *Engine) validateRuleRegexes(rules json.RawMessage) error {
+	pattern, err := invalidRuleRegex(rules)
+	if err != nil {
+		e.lo.Warn("invalid rule regex", "pattern", pattern, "error", err)
+		return envelope.NewError(envelope.InputError, e.i18n.Ts("admin.automation.invalidRegex", "pattern", pattern), nil)
+	}
 	return nil
 }
 
@@ -320,6 +417,9 @@ func (e *Engine) handleNewConversation(conversationUUID string) {
 		e.lo.Error("error fetching conversation for new event", "uuid", conversationUUID, "error", err)
 		return
 	}
+	if e.routeExternally(conversation) {
+		return
+	}
 	rules := e.filterRulesByType(models.RuleTypeNewConversation, "")
 	if len(rules) == 0 {
 		e.lo.Warn("no rules to evaluate for new conversation", "uuid", conversationUUID)
@@ -347,6 +447,11 @@ func (e *Engine) handleUpdateConversation(conversationUUID, eventType string) {
 // handleTimeTrigger handles time trigger events.
 func (e *Engine) handleTimeTrigger() {
 	e.lo.Debug("handling time triggers")
+	e.autoCloseConversations()
+	if err := e.conversationStore.SendDueReminders(); err != nil {
+		e.lo.Error("error sending due reminders", "error", err)
+	}
+
 	thirtyDaysAgo := time.Now().Add(-30 * 24 * time.Hour)
 	conversations, err := e.conversationStore.GetConversationsCreatedAfter(thirtyDaysAgo)
 	if err != nil {
@@ -390,6 +495,8 @@ func (e *Engine) queryRules() []models.Rule {
 		}
 		// Set values from DB.
 		for i := range rulesBatch {
+			rulesBatch[i].ID = rule.ID
+			rulesBatch[i].Name = rule.Name
 			rulesBatch[i].Type = rule.Type
 			rulesBatch[i].Events = rule.Events
 			rulesBatch[i].ExecutionMode = rule.ExecutionMode
@@ -0,0 +1,34 @@
+package automation
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// emailDomain returns the lower cased domain of the email address, empty if it has none.
+func emailDomain(email string) string {
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return ""
+	}
+	return strings.ToLower(strings.TrimSpace(email[at+1:]))
+}
+
+// isVIPContact returns true if the contact's `vip` custom attribute is truthy, i.e. true, yes or 1, the same values
+// that give new conversations the VIP priority of their inbox.
+func isVIPContact(customAttributes json.RawMessage) bool {
+	var attrs map[string]any
+	if len(customAttributes) == 0 || json.Unmarshal(customAttributes, &attrs) != nil {
+		return false
+	}
+	vip, ok := attrs["vip"]
+	if !ok || vip == nil {
+		return false
+	}
+	switch strings.ToLower(fmt.Sprintf("%v", vip)) {
+	case "true", "yes", "1":
+		return true
+	}
+	return false
+}
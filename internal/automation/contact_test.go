@@ -0,0 +1,43 @@
+package automation
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestEmailDomain(t *testing.T) {
+	tests := map[string]string{
+		"alice@Example.COM":   "example.com",
+		"a@b@vip.example.org": "vip.example.org",
+		"no-at-sign":          "",
+		"":                    "",
+		"trailing@":           "",
+	}
+	for email, want := range tests {
+		if got := emailDomain(email); got != want {
+			t.Errorf("emailDomain(%q) = %q, want %q", email, got, want)
+		}
+	}
+}
+
+func TestIsVIPContact(t *testing.T) {
+	tests := []struct {
+		attrs string
+		want  bool
+	}{
+		{attrs: `{"vip": true}`, want: true},
+		{attrs: `{"vip": "Yes"}`, want: true},
+		{attrs: `{"vip": 1}`, want: true},
+		{attrs: `{"vip": false}`, want: false},
+		{attrs: `{"vip": "no"}`, want: false},
+		{attrs: `{"vip": null}`, want: false},
+		{attrs: `{"plan": "enterprise"}`, want: false},
+		{attrs: ``, want: false},
+		{attrs: `not json`, want: false},
+	}
+	for _, tt := range tests {
+		if got := isVIPContact(json.RawMessage(tt.attrs)); got != tt.want {
+			t.Errorf("isVIPContact(%s) = %v, want %v", tt.attrs, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package automation
+
+import (
+	"time"
+
+	"github.com/abhinavxd/libredesk/internal/automation/models"
+	cmodels "github.com/abhinavxd/libredesk/internal/conversation/models"
+	"github.com/volatiletech/null/v9"
+)
+
+// isAssignmentAction returns true if the action changes the assignee of a conversation.
+func isAssignmentAction(actionType string) bool {
+	switch actionType {
+	case models.ActionAssignTeam, models.ActionAssignUser, models.ActionAssignTeamAgent, models.ActionRouteToQueue:
+		return true
+	}
+	return false
+}
+
+// cooldownEnd returns the end of the reassignment cooldown started by a manual assignment at `assignedAt` and
+// whether it's still active at `now`, a non-positive cooldown disables it.
+func cooldownEnd(assignedAt null.Time, cooldown time.Duration, now time.Time) (time.Time, bool) {
+	if cooldown <= 0 || !assignedAt.Valid {
+		return time.Time{}, false
+	}
+	end := assignedAt.Time.Add(cooldown)
+	return end, now.Before(end)
+}
+
+// reassignmentCooldownUntil returns the end of the reassignment cooldown of the conversation if the action is an
+// assignment action that must be suppressed because an agent assigned the conversation recently.
+func (e *Engine) reassignmentCooldownUntil(action models.RuleAction, conversation cmodels.Conversation, now time.Time) (time.Time, bool) {
+	if !isAssignmentAction(action.Type) {
+		return time.Time{}, false
+	}
+	return cooldownEnd(conversation.ManuallyAssignedAt, e.reassignmentCooldown, now)
+}
+
+// cooldownReason returns the reason recorded for an action suppressed by the reassignment cooldown.
+func cooldownReason(until time.Time) string {
+	return "suppressed by reassignment cooldown until " + until.UTC().Format(time.RFC3339)
+}
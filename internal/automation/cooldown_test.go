@@ -0,0 +1,38 @@
+package automation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/volatiletech/null/v9"
+)
+
+func TestCooldownEnd(t *testing.T) {
+	var (
+		now        = time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+		assignedAt = null.TimeFrom(now.Add(-10 * time.Minute))
+	)
+	tests := []struct {
+		name       string
+		assignedAt null.Time
+		cooldown   time.Duration
+		active     bool
+	}{
+		{name: "disabled", assignedAt: assignedAt, cooldown: 0},
+		{name: "never assigned manually", assignedAt: null.Time{}, cooldown: time.Hour},
+		{name: "within cooldown", assignedAt: assignedAt, cooldown: 30 * time.Minute, active: true},
+		{name: "cooldown elapsed", assignedAt: assignedAt, cooldown: 5 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			end, active := cooldownEnd(tt.assignedAt, tt.cooldown, now)
+			if active != tt.active {
+				t.Fatalf("got active %v, want %v", active, tt.active)
+			}
+			if active && !end.Equal(tt.assignedAt.Time.Add(tt.cooldown)) {
+				t.Errorf("got end %v, want %v", end, tt.assignedAt.Time.Add(tt.cooldown))
+			}
+		})
+	}
+}
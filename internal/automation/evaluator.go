@@ -3,13 +3,13 @@ package automation
 import (
 	"encoding/json"
 	"fmt"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/abhinavxd/libredesk/internal/automation/models"
 	cmodels "github.com/abhinavxd/libredesk/internal/conversation/models"
-	umodels "github.com/abhinavxd/libredesk/internal/user/models"
 )
 
 // evalConversationRules evaluates a list of rules against a given conversation.
@@ -19,40 +19,109 @@ func (e *Engine) evalConversationRules(rules []models.Rule, conversation cmodels
 	for _, rule := range rules {
 		e.lo.Debug("evaluating rules for conversation", "rule", rule, "conversation_id", conversation.ID)
 
-		// At max there can be only 2 groups.
-		if len(rule.Groups) > 2 {
-			e.lo.Warn("WARNING: more than 2 groups found for rules skipping evaluation")
+		// Actions of update rules trigger update events themselves, skip rules that look like they are looping.
+		isUpdateRule := rule.Type == models.RuleTypeConversationUpdate
+		if isUpdateRule {
+			if ok, reason := e.loopGuard.allow(conversation.UUID, rule.ID, time.Now()); !ok {
+				e.lo.Warn("automation rule loop detected, skipping rule", "rule_id", rule.ID, "rule_name", rule.Name, "conversation_uuid", conversation.UUID, "reason", reason)
+				continue
+			}
+		}
+
+		if !e.ruleMatches(rule, conversation) {
 			continue
 		}
 
-		var groupEvalResults []bool
-		for idx, group := range rule.Groups {
-			if len(group.Rules) == 0 {
-				e.lo.Debug("no rules found in group, skipping rule group evaluation", "group_num", idx+1, "conversation_uuid", conversation.UUID)
+		e.lo.Debug("all rules within groups evaluated successfully, executing actions", "conversation_uuid", conversation.UUID)
+		if isUpdateRule {
+			e.loopGuard.record(conversation.UUID, rule.ID, time.Now())
+		}
+		for _, action := range rule.Actions {
+			if until, ok := e.reassignmentCooldownUntil(action, conversation, time.Now()); ok {
+				e.lo.Info("suppressing automation assignment action in reassignment cooldown", "rule_id", rule.ID, "action", action.Type, "conversation_uuid", conversation.UUID, "until", until)
+				e.insertFailedAction(rule, action, conversation, cooldownReason(until), models.FailedActionStatusSuppressed)
 				continue
 			}
-			result := e.evaluateGroup(group.Rules, group.LogicalOp, conversation)
-			e.lo.Debug("group rule evaluation complete", "logical_op", group.LogicalOp, "result", result, "conversation_uuid", conversation.UUID)
-			groupEvalResults = append(groupEvalResults, result)
+			if err := e.applyAction(action, conversation); err != nil {
+				e.lo.Error("error applying action on conversation", "action", action, "conversation_uuid", conversation.UUID, "error", err)
+				e.queueFailedAction(rule, action, conversation, err)
+			}
+		}
+		if rule.ExecutionMode == models.ExecutionModeFirstMatch {
+			e.lo.Debug("automation is first match rule execution mode, breaking out of rule evaluation", "conversation_uuid", conversation.UUID)
+			break
 		}
+	}
+}
 
-		if evaluateFinalResult(groupEvalResults, rule.GroupOperator) {
-			e.lo.Debug("all rules within groups evaluated successfully, executing actions", "conversation_uuid", conversation.UUID)
-			for _, action := range rule.Actions {
-				if err := e.conversationStore.ApplyAction(action, conversation, umodels.User{}); err != nil {
-					e.lo.Error("error applying action on conversation", "action", action, "conversation_uuid", conversation.UUID, "error", err)
-				}
-			}
-			if rule.ExecutionMode == models.ExecutionModeFirstMatch {
-				e.lo.Debug("automation is first match rule execution mode, breaking out of rule evaluation", "conversation_uuid", conversation.UUID)
-				break
-			}
-		} else {
-			e.lo.Debug("rule evaluation failed, skipping actions", "group_eval_results", groupEvalResults, "conversation_uuid", conversation.UUID)
+// ruleMatches reports whether the groups of the rule pass their evaluations against the conversation.
+func (e *Engine) ruleMatches(rule models.Rule, conversation cmodels.Conversation) bool {
+	// At max there can be only 2 groups.
+	if len(rule.Groups) > 2 {
+		e.lo.Warn("WARNING: more than 2 groups found for rules skipping evaluation")
+		return false
+	}
+
+	var groupEvalResults []bool
+	for idx, group := range rule.Groups {
+		if len(group.Rules) == 0 {
+			e.lo.Debug("no rules found in group, skipping rule group evaluation", "group_num", idx+1, "conversation_uuid", conversation.UUID)
+			continue
+		}
+		result := e.evaluateGroup(group.Rules, group.LogicalOp, conversation)
+		e.lo.Debug("group rule evaluation complete", "logical_op", group.LogicalOp, "result", result, "conversation_uuid", conversation.UUID)
+		groupEvalResults = append(groupEvalResults, result)
+	}
+
+	if !evaluateFinalResult(groupEvalResults, rule.GroupOperator) {
+		e.lo.Debug("rule evaluation failed, skipping actions", "group_eval_results", groupEvalResults, "conversation_uuid", conversation.UUID)
+		return false
+	}
+	return true
+}
+
+// applyAction executes a single rule action on the conversation, actions are performed on behalf of the system user.
+func (e *Engine) applyAction(action models.RuleAction, conversation cmodels.Conversation) error {
+	switch action.Type {
+	case models.ActionAddTags, models.ActionRemoveTags:
+		tagNames := normalizeTagNames(action.Value)
+		if len(tagNames) == 0 {
+			return fmt.Errorf("%w: empty value for action %s", errInvalidAction, action.Type)
+		}
+		if action.Type == models.ActionAddTags {
+			return e.conversationStore.AddTags(conversation.UUID, tagNames, e.systemUser)
+		}
+		return e.conversationStore.RemoveTags(conversation.UUID, tagNames, e.systemUser)
+	case models.ActionAssignTeamAgent:
+		return e.assignTeamAgent(action, conversation)
+	case models.ActionSetCustomAttribute:
+		// Value is [key, value], an empty or missing value removes the attribute.
+		if len(action.Value) == 0 || action.Value[0] == "" {
+			return fmt.Errorf("%w: empty value for action %s", errInvalidAction, action.Type)
+		}
+		var value string
+		if len(action.Value) > 1 {
+			value = action.Value[1]
 		}
+		return e.conversationStore.SetConversationCustomAttribute(conversation.UUID, action.Value[0], value)
+	default:
+		return e.conversationStore.ApplyAction(action, conversation, e.systemUser)
 	}
 }
 
+// normalizeTagNames trims the tag names and drops empty and duplicate ones.
+func normalizeTagNames(tags []string) []string {
+	var out = make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || slices.Contains(out, tag) {
+			continue
+		}
+		out = append(out, tag)
+	}
+	return out
+}
+
 // evaluateFinalResult computes the final result of multiple group evaluations
 // based on the specified logical operator (AND/OR).
 func evaluateFinalResult(results []bool, operator string) bool {
@@ -124,6 +193,17 @@ func (e *Engine) evaluateRule(rule models.RuleDetail, conversation cmodels.Conve
 		switch rule.Field {
 		case models.ContactEmail:
 			valueToCompare = conversation.Contact.Email.String
+		case models.ContactEmailDomain:
+			valueToCompare = emailDomain(conversation.Contact.Email.String)
+		case models.ContactVIP:
+			valueToCompare = strconv.FormatBool(isVIPContact(conversation.Contact.CustomAttributes))
+		case models.ContactPastConversations:
+			count, err := e.conversationStore.GetContactPastConversationCount(conversation.ContactID, conversation.ID)
+			if err != nil {
+				e.lo.Error("error fetching contact past conversation count", "conversation_uuid", conversation.UUID, "error", err)
+				return false
+			}
+			valueToCompare = strconv.Itoa(count)
 		case models.ConversationSubject:
 			valueToCompare = conversation.Subject.String
 		case models.ConversationContent:
@@ -154,15 +234,66 @@ func (e *Engine) evaluateRule(rule models.RuleDetail, conversation cmodels.Conve
 			if !conversation.ResolvedAt.IsZero() {
 				valueToCompare = fmt.Sprintf("%.0f", (time.Since(conversation.ResolvedAt.Time).Hours()))
 			}
+		case models.ConversationHoursSinceUnanswered, models.ConversationBusinessHoursSinceUnanswered:
+			// Left empty when the contact is not waiting for a reply.
+			since, err := e.conversationStore.GetUnansweredSince(conversation.ID)
+			if err != nil {
+				e.lo.Error("error fetching unanswered since", "conversation_uuid", conversation.UUID, "error", err)
+				return false
+			}
+			if since.IsZero() {
+				break
+			}
+			if rule.Field == models.ConversationHoursSinceUnanswered {
+				valueToCompare = fmt.Sprintf("%.0f", time.Since(since).Hours())
+				break
+			}
+			minutes, err := e.conversationStore.BusinessMinutesSince(since, conversation.AssignedTeamID.Int)
+			if err != nil {
+				e.lo.Error("error calculating business hours since unanswered", "conversation_uuid", conversation.UUID, "error", err)
+				return false
+			}
+			valueToCompare = strconv.Itoa(minutes / 60)
 		case models.ConversationInbox:
 			valueToCompare = strconv.Itoa(conversation.InboxID)
+		case models.ConversationLanguage:
+			valueToCompare = conversation.Language.String
+		case models.ConversationMinutesUnassigned:
+			// Left empty when the conversation is assigned to an agent.
+			if !conversation.AssignedUserID.Valid {
+				valueToCompare = fmt.Sprintf("%.0f", time.Since(conversation.CreatedAt).Minutes())
+			}
+		case models.ConversationTags:
+			// Tags are a list, they are matched whole instead of as a string.
+			tags, err := conversationTagNames(conversation.Tags.JSON)
+			if err != nil {
+				e.lo.Error("error unmarshalling conversation tags", "conversation_uuid", conversation.UUID, "error", err)
+				return false
+			}
+			matched, err := matchTags(rule, tags)
+			if err != nil {
+				e.lo.Error("error matching conversation tags", "conversation_uuid", conversation.UUID, "error", err)
+				return false
+			}
+			e.lo.Debug("conversation automation rule status", "has_met", matched, "conversation_uuid", conversation.UUID)
+			return matched
+		case models.ConversationLastIncomingMessage:
+			message, err := e.conversationStore.GetLatestIncomingMessage(conversation.ID)
+			if err != nil {
+				e.lo.Error("error fetching latest incoming message", "conversation_uuid", conversation.UUID, "error", err)
+				return false
+			}
+			valueToCompare = message.TextContent
 		default:
 			e.lo.Error("error unrecognized conversation field", "field", rule.Field, "field_type", rule.FieldType, "conversation_uuid", conversation.UUID)
 			return false
 		}
-	} else if rule.FieldType == models.FieldTypeContactCustomAttribute {
+	} else if rule.FieldType == models.FieldTypeContactCustomAttribute || rule.FieldType == models.FieldTypeConversationCustomAttribute {
 		// If the field type is custom attribute, need to extract the value from the custom attributes
 		var attributes json.RawMessage = conversation.Contact.CustomAttributes
+		if rule.FieldType == models.FieldTypeConversationCustomAttribute {
+			attributes = conversation.CustomAttributes
+		}
 
 		// Unmarshal the custom attributes
 		if err := json.Unmarshal(attributes, &customAttributes); err != nil {
@@ -196,6 +327,9 @@ func (e *Engine) evaluateRule(rule models.RuleDetail, conversation cmodels.Conve
 		return false
 	}
 
+	// Keep the regex pattern as is, lower casing it would change the meaning of escapes like `\D`.
+	pattern := rule.Value
+
 	// Case sensitive match?
 	if !rule.CaseSensitiveMatch {
 		valueToCompare = strings.ToLower(valueToCompare)
@@ -257,6 +391,13 @@ func (e *Engine) evaluateRule(rule models.RuleDetail, conversation cmodels.Conve
 				break
 			}
 		}
+	case models.RuleOperatorMatches:
+		matched, err := matchRegex(pattern, valueToCompare, rule.CaseSensitiveMatch)
+		if err != nil {
+			e.lo.Error("error matching rule regex", "pattern", pattern, "conversation_uuid", conversation.UUID, "error", err)
+			return false
+		}
+		conditionMet = matched
 	case models.RuleOperatorSet:
 		conditionMet = len(valueToCompare) > 0
 	case models.RuleOperatorNotSet:
@@ -0,0 +1,86 @@
+package automation
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	// ruleLoopWindow is the window within which consecutive rule executions on a conversation are considered
+	// part of the same logical update.
+	ruleLoopWindow = 10 * time.Second
+	// maxRuleLoopDepth is the maximum number of update rules that can be executed on a conversation within a
+	// single logical update.
+	maxRuleLoopDepth = 5
+)
+
+// loopGuard tracks update rule executions per conversation to detect rules that re-trigger each other,
+// e.g. a rule that changes the priority on `conversation.priority.change` event.
+type loopGuard struct {
+	mu       sync.Mutex
+	window   time.Duration
+	maxDepth int
+	chains   map[string]*ruleChain
+}
+
+// ruleChain is the chain of rule executions on a conversation.
+type ruleChain struct {
+	depth     int
+	lastFired time.Time
+	rules     map[int]time.Time
+}
+
+func newLoopGuard(window time.Duration, maxDepth int) *loopGuard {
+	return &loopGuard{
+		window:   window,
+		maxDepth: maxDepth,
+		chains:   make(map[string]*ruleChain),
+	}
+}
+
+// allow reports whether the rule can be executed on the conversation, along with the reason when it cannot.
+func (g *loopGuard) allow(conversationUUID string, ruleID int, now time.Time) (bool, string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	chain, ok := g.chains[conversationUUID]
+	if !ok || now.Sub(chain.lastFired) > g.window {
+		return true, ""
+	}
+	if firedAt, ok := chain.rules[ruleID]; ok && now.Sub(firedAt) <= g.window {
+		return false, fmt.Sprintf("rule already executed %s ago", now.Sub(firedAt).Round(time.Millisecond))
+	}
+	if chain.depth >= g.maxDepth {
+		return false, fmt.Sprintf("max depth of %d rule executions reached", g.maxDepth)
+	}
+	return true, ""
+}
+
+// record records the execution of the rule on the conversation.
+func (g *loopGuard) record(conversationUUID string, ruleID int, now time.Time) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	chain, ok := g.chains[conversationUUID]
+	if !ok || now.Sub(chain.lastFired) > g.window {
+		// Start of a new logical update.
+		chain = &ruleChain{rules: make(map[int]time.Time)}
+		g.chains[conversationUUID] = chain
+	}
+	chain.depth++
+	chain.lastFired = now
+	chain.rules[ruleID] = now
+}
+
+// prune removes chains that are older than the window.
+func (g *loopGuard) prune(now time.Time) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	for uuid, chain := range g.chains {
+		if now.Sub(chain.lastFired) > g.window {
+			delete(g.chains, uuid)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package automation
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLoopGuard(t *testing.T) {
+	now := time.Now()
+
+	t.Run("same rule within window", func(t *testing.T) {
+		g := newLoopGuard(10*time.Second, 5)
+		g.record("c1", 1, now)
+		if ok, _ := g.allow("c1", 1, now.Add(time.Second)); ok {
+			t.Error("expected rule to be suppressed")
+		}
+		if ok, _ := g.allow("c1", 2, now.Add(time.Second)); !ok {
+			t.Error("expected other rule to be allowed")
+		}
+		if ok, _ := g.allow("c2", 1, now.Add(time.Second)); !ok {
+			t.Error("expected rule on other conversation to be allowed")
+		}
+	})
+
+	t.Run("same rule after window", func(t *testing.T) {
+		g := newLoopGuard(10*time.Second, 5)
+		g.record("c1", 1, now)
+		if ok, _ := g.allow("c1", 1, now.Add(11*time.Second)); !ok {
+			t.Error("expected rule to be allowed")
+		}
+	})
+
+	t.Run("max depth", func(t *testing.T) {
+		g := newLoopGuard(10*time.Second, 3)
+		for i := 1; i <= 3; i++ {
+			g.record("c1", i, now.Add(time.Duration(i)*time.Second))
+		}
+		if ok, _ := g.allow("c1", 4, now.Add(4*time.Second)); ok {
+			t.Error("expected rule to be suppressed at max depth")
+		}
+		if ok, _ := g.allow("c1", 4, now.Add(14*time.Second)); !ok {
+			t.Error("expected rule to be allowed once the chain expired")
+		}
+	})
+
+	t.Run("prune", func(t *testing.T) {
+		g := newLoopGuard(10*time.Second, 5)
+		g.record("c1", 1, now)
+		g.record("c2", 1, now.Add(5*time.Second))
+		g.prune(now.Add(12 * time.Second))
+		if _, ok := g.chains["c1"]; ok {
+			t.Error("expected c1 to be pruned")
+		}
+		if _, ok := g.chains["c2"]; !ok {
+			t.Error("expected c2 to be kept")
+		}
+	})
+}
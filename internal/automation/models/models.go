@@ -6,20 +6,28 @@ import (
 
 	authzModels "github.com/abhinavxd/libredesk/internal/authz/models"
 	"github.com/lib/pq"
+	"github.com/volatiletech/null/v9"
 )
 
 const (
-	ActionAssignTeam      = "assign_team"
-	ActionAssignUser      = "assign_user"
-	ActionSetStatus       = "set_status"
-	ActionSetPriority     = "set_priority"
-	ActionSendPrivateNote = "send_private_note"
-	ActionReply           = "send_reply"
-	ActionSetSLA          = "set_sla"
-	ActionAddTags         = "add_tags"
-	ActionSetTags         = "set_tags"
-	ActionRemoveTags      = "remove_tags"
-	ActionSendCSAT        = "send_csat"
+	ActionAssignTeam         = "assign_team"
+	ActionAssignUser         = "assign_user"
+	ActionSetStatus          = "set_status"
+	ActionSetPriority        = "set_priority"
+	ActionSendPrivateNote    = "send_private_note"
+	ActionReply              = "send_reply"
+	ActionSetSLA             = "set_sla"
+	ActionAddTags            = "add_tags"
+	ActionSetTags            = "set_tags"
+	ActionRemoveTags         = "remove_tags"
+	ActionSendCSAT           = "send_csat"
+	ActionAssignTeamAgent    = "assign_team_agent"
+	ActionSetCustomAttribute = "set_custom_attribute"
+	ActionRouteToQueue       = "route_to_queue"
+
+	AssignmentStrategyRoundRobin = "round_robin"
+	AssignmentStrategyLeastBusy  = "least_busy"
+	AssignmentStrategyRandom     = "random"
 
 	OperatorAnd = "AND"
 	OperatorOR  = "OR"
@@ -32,23 +40,33 @@ const (
 	RuleOperatorNotSet      = "not set"
 	RuleOperatorGreaterThan = "greater than"
 	RuleOperatorLessThan    = "less than"
+	RuleOperatorMatches     = "matches"
 
 	RuleTypeNewConversation    = "new_conversation"
 	RuleTypeConversationUpdate = "conversation_update"
 	RuleTypeTimeTrigger        = "time_trigger"
 
-	ConversationSubject              = "subject"
-	ConversationContent              = "content"
-	ConversationStatus               = "status"
-	ConversationPriority             = "priority"
-	ConversationAssignedUser         = "assigned_user"
-	ConversationAssignedTeam         = "assigned_team"
-	ConversationHoursSinceCreated    = "hours_since_created"
-	ConversationHoursSinceFirstReply = "hours_since_first_reply"
-	ConversationHoursSinceLastReply  = "hours_since_last_reply"
-	ConversationHoursSinceResolved   = "hours_since_resolved"
-	ConversationInbox                = "inbox"
-	ContactEmail                     = "contact_email"
+	ConversationSubject                      = "subject"
+	ConversationContent                      = "content"
+	ConversationStatus                       = "status"
+	ConversationPriority                     = "priority"
+	ConversationAssignedUser                 = "assigned_user"
+	ConversationAssignedTeam                 = "assigned_team"
+	ConversationHoursSinceCreated            = "hours_since_created"
+	ConversationHoursSinceFirstReply         = "hours_since_first_reply"
+	ConversationHoursSinceLastReply          = "hours_since_last_reply"
+	ConversationHoursSinceResolved           = "hours_since_resolved"
+	ConversationInbox                        = "inbox"
+	ConversationLastIncomingMessage          = "last_incoming_message"
+	ConversationHoursSinceUnanswered         = "hours_since_unanswered"
+	ConversationBusinessHoursSinceUnanswered = "business_hours_since_unanswered"
+	ConversationLanguage                     = "language"
+	ConversationMinutesUnassigned            = "minutes_unassigned"
+	ConversationTags                         = "tags"
+	ContactEmail                             = "contact_email"
+	ContactEmailDomain                       = "contact_email_domain"
+	ContactVIP                               = "contact_vip"
+	ContactPastConversations                 = "contact_past_conversations"
 
 	EventConversationUserAssigned    = "conversation.user.assigned"
 	EventConversationTeamAssigned    = "conversation.team.assigned"
@@ -56,12 +74,22 @@ const (
 	EventConversationPriorityChange  = "conversation.priority.change"
 	EventConversationMessageOutgoing = "conversation.message.outgoing"
 	EventConversationMessageIncoming = "conversation.message.incoming"
+	EventConversationTagsChange      = "conversation.tags.change"
 
 	ExecutionModeAll        = "all"
 	ExecutionModeFirstMatch = "first_match"
 
 	FieldTypeContactCustomAttribute      = "contact_custom_attribute"
 	FieldTypeConversationField           = "conversation"
+	FieldTypeConversationCustomAttribute = "conversation_custom_attribute"
+
+	FailedActionStatusPending   = "pending"
+	FailedActionStatusExhausted = "exhausted"
+	// FailedActionStatusSuppressed is recorded for assignment actions skipped due to the reassignment cooldown, they are not retried.
+	FailedActionStatusSuppressed = "suppressed"
+	// FailedActionStatusFailed is recorded for actions that failed with an error a retry won't fix or that aren't safe
+	// to repeat, they are not retried.
+	FailedActionStatusFailed = "failed"
 )
 
 // ActionPermissions maps actions to permissions
@@ -93,6 +121,8 @@ type RuleRecord struct {
 }
 
 type Rule struct {
+	ID            int          `json:"-"`
+	Name          string       `json:"-"`
 	Type          string       `json:"type"`
 	ExecutionMode string       `json:"execution_mode"`
 	Events        []string     `json:"event"`
@@ -119,3 +149,19 @@ type RuleAction struct {
 	Value        []string `json:"value" db:"value"`
 	DisplayValue []string `json:"display_value" db:"-"`
 }
+
+// FailedAction is a rule action that failed to apply and is queued for retry.
+type FailedAction struct {
+	ID               int             `db:"id" json:"id"`
+	CreatedAt        time.Time       `db:"created_at" json:"created_at"`
+	UpdatedAt        time.Time       `db:"updated_at" json:"updated_at"`
+	RuleID           null.Int        `db:"rule_id" json:"rule_id"`
+	RuleName         string          `db:"rule_name" json:"rule_name"`
+	ConversationUUID string          `db:"conversation_uuid" json:"conversation_uuid"`
+	Action           json.RawMessage `db:"action" json:"action"`
+	Attempts         int             `db:"attempts" json:"attempts"`
+	LastError        string          `db:"last_error" json:"last_error"`
+	Status           string          `db:"status" json:"status"`
+	NextAttemptAt    time.Time       `db:"next_attempt_at" json:"next_attempt_at"`
+	Total            int             `db:"total" json:"-"`
+}
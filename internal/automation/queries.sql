@@ -1,5 +1,7 @@
 -- name: get-enabled-rules
 select
+    id,
+    name,
     type,
     events,
     rules,
@@ -27,7 +29,8 @@ DO UPDATE SET
 WHERE $1 > 0;
 
 -- name: insert-rule
-INSERT into automation_rules (name, description, type, events, rules) values ($1, $2, $3, $4, $5);
+INSERT into automation_rules (name, description, type, events, rules) values ($1, $2, $3, $4, $5)
+RETURNING id, created_at, updated_at, enabled, name, description, type, events, rules, execution_mode;
 
 -- name: delete-rule
 delete from automation_rules where id = $1;
@@ -45,4 +48,52 @@ WHERE id = $1;
 -- name: update-rule-execution-mode
 UPDATE automation_rules
 SET execution_mode = $2, updated_at = NOW()
-WHERE type = $1;
\ No newline at end of file
+WHERE type = $1;
+
+-- name: get-assignment-cursor
+SELECT last_assigned_user_id FROM automation_assignment_cursors WHERE team_id = $1;
+
+-- name: upsert-assignment-cursor
+INSERT INTO automation_assignment_cursors (team_id, last_assigned_user_id)
+VALUES ($1, $2)
+ON CONFLICT (team_id) DO UPDATE SET
+    last_assigned_user_id = EXCLUDED.last_assigned_user_id,
+    updated_at = NOW();
+
+-- name: insert-failed-action
+INSERT INTO automation_failed_actions (rule_id, rule_name, conversation_id, action, last_error, next_attempt_at, status)
+VALUES (NULLIF($1, 0), $2, $3, $4, $5, $6, $7);
+
+-- name: claim-due-failed-actions
+-- Pushes the next attempt of the claimed actions forward so that overlapping retry runs skip them.
+UPDATE automation_failed_actions
+SET next_attempt_at = NOW() + INTERVAL '10 minutes', updated_at = NOW()
+WHERE id IN (
+    SELECT id FROM automation_failed_actions
+    WHERE status = 'pending' AND next_attempt_at <= NOW()
+    ORDER BY next_attempt_at ASC
+    LIMIT $1
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING id, created_at, updated_at, rule_id, rule_name, (SELECT uuid FROM conversations WHERE id = conversation_id) AS conversation_uuid, action, attempts, last_error, status, next_attempt_at;
+
+-- name: update-failed-action-attempt
+UPDATE automation_failed_actions
+SET attempts = attempts + 1, last_error = $2, status = $3, next_attempt_at = $4, updated_at = NOW()
+WHERE id = $1;
+
+-- name: get-failed-actions
+SELECT COUNT(*) OVER() AS total, fa.id, fa.created_at, fa.updated_at, fa.rule_id, fa.rule_name, c.uuid AS conversation_uuid, fa.action, fa.attempts, fa.last_error, fa.status, fa.next_attempt_at
+FROM automation_failed_actions fa
+INNER JOIN conversations c ON c.id = fa.conversation_id
+WHERE ($1 = '' OR fa.status = $1)
+ORDER BY fa.updated_at DESC
+LIMIT $2 OFFSET $3;
+
+-- name: retry-failed-action
+UPDATE automation_failed_actions
+SET attempts = 0, status = 'pending', next_attempt_at = NOW(), updated_at = NOW()
+WHERE id = $1;
+
+-- name: delete-failed-action
+DELETE FROM automation_failed_actions WHERE id = $1;
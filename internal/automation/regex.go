@@ -0,0 +1,104 @@
+package automation
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sync"
+
+	"github.com/abhinavxd/libredesk/internal/automation/models"
+)
+
+const (
+	// maxRegexPatternLen is the maximum length of a rule regex pattern.
+	maxRegexPatternLen = 1000
+	// maxRegexInputLen is the maximum number of bytes of the input matched against a rule regex.
+	maxRegexInputLen = 64 * 1024
+	// maxCachedRegexes is the maximum number of compiled patterns kept in the cache.
+	maxCachedRegexes = 500
+)
+
+var (
+	errRegexTooLong   = fmt.Errorf("regex pattern exceeds %d characters", maxRegexPatternLen)
+	compiledRegexes   = map[string]*regexp.Regexp{}
+	compiledRegexesMu sync.RWMutex
+)
+
+// compileRegex compiles the pattern and caches it, as the same rule patterns are evaluated repeatedly.
+// Once the cache is full an arbitrary pattern is evicted, so patterns of edited or deleted rules don't pile up.
+func compileRegex(pattern string) (*regexp.Regexp, error) {
+	compiledRegexesMu.RLock()
+	re, ok := compiledRegexes[pattern]
+	compiledRegexesMu.RUnlock()
+	if ok {
+		return re, nil
+	}
+
+	re, err := parseRegex(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	compiledRegexesMu.Lock()
+	if len(compiledRegexes) >= maxCachedRegexes {
+		for k := range compiledRegexes {
+			delete(compiledRegexes, k)
+			break
+		}
+	}
+	compiledRegexes[pattern] = re
+	compiledRegexesMu.Unlock()
+	return re, nil
+}
+
+// parseRegex compiles the pattern, rejecting patterns longer than maxRegexPatternLen.
+func parseRegex(pattern string) (*regexp.Regexp, error) {
+	if len(pattern) > maxRegexPatternLen {
+		return nil, errRegexTooLong
+	}
+	return regexp.Compile(pattern)
+}
+
+// regexPattern returns the pattern matched for a rule regex, case insensitive unless the rule is case sensitive.
+func regexPattern(pattern string, caseSensitive bool) string {
+	if !caseSensitive {
+		return "(?i)" + pattern
+	}
+	return pattern
+}
+
+// matchRegex reports whether the input matches the pattern.
+// Go's RE2 based engine runs in linear time of the input so it is not prone to catastrophic backtracking, the input
+// is capped so a single rule cannot stall the automation workers on huge messages.
+func matchRegex(pattern, input string, caseSensitive bool) (bool, error) {
+	re, err := compileRegex(regexPattern(pattern, caseSensitive))
+	if err != nil {
+		return false, err
+	}
+
+	if len(input) > maxRegexInputLen {
+		input = input[:maxRegexInputLen]
+	}
+	return re.MatchString(input), nil
+}
+
+// invalidRuleRegex returns the first regex pattern of the rule conditions that doesn't compile, with its error.
+func invalidRuleRegex(rules json.RawMessage) (string, error) {
+	var batch []models.Rule
+	if err := json.Unmarshal(rules, &batch); err != nil {
+		return "", nil
+	}
+	for _, rule := range batch {
+		for _, group := range rule.Groups {
+			for _, cond := range group.Rules {
+				if cond.Operator != models.RuleOperatorMatches {
+					continue
+				}
+				if _, err := parseRegex(regexPattern(cond.Value, cond.CaseSensitiveMatch)); err != nil {
+					return cond.Value, err
+				}
+			}
+		}
+	}
+	return "", nil
+}
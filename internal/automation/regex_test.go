@@ -0,0 +1,122 @@
+package automation
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestMatchRegex(t *testing.T) {
+	tests := []struct {
+		name          string
+		pattern       string
+		input         string
+		caseSensitive bool
+		expected      bool
+		wantErr       bool
+	}{
+		{
+			name:     "simple keyword",
+			pattern:  `refund`,
+			input:    "I would like a refund please",
+			expected: true,
+		},
+		{
+			name:     "case insensitive match",
+			pattern:  `REFUND`,
+			input:    "I would like a refund please",
+			expected: true,
+		},
+		{
+			name:          "case sensitive mismatch",
+			pattern:       `REFUND`,
+			input:         "I would like a refund please",
+			caseSensitive: true,
+			expected:      false,
+		},
+		{
+			name:     "escape sequences are preserved",
+			pattern:  `order #\d{5}`,
+			input:    "Where is order #12345?",
+			expected: true,
+		},
+		{
+			name:     "alternation",
+			pattern:  `\b(cancel|refund)\b`,
+			input:    "please cancel my subscription",
+			expected: true,
+		},
+		{
+			name:    "invalid pattern",
+			pattern: `(unclosed`,
+			input:   "anything",
+			wantErr: true,
+		},
+		{
+			name:    "pattern too long",
+			pattern: strings.Repeat("a", maxRegexPatternLen+1),
+			input:   "a",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := matchRegex(tt.pattern, tt.input, tt.caseSensitive)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.expected {
+				t.Errorf("got %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCompileRegexCacheLimit(t *testing.T) {
+	for i := 0; i < maxCachedRegexes+10; i++ {
+		if _, err := compileRegex(fmt.Sprintf("pattern-%d", i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	compiledRegexesMu.RLock()
+	defer compiledRegexesMu.RUnlock()
+	if len(compiledRegexes) > maxCachedRegexes {
+		t.Errorf("got %d cached patterns, want at most %d", len(compiledRegexes), maxCachedRegexes)
+	}
+}
+
+func TestInvalidRuleRegex(t *testing.T) {
+	tests := []struct {
+		name    string
+		rules   string
+		pattern string
+	}{
+		{
+			name:  "valid pattern",
+			rules: `[{"groups": [{"rules": [{"field": "subject", "operator": "matches", "value": "order #\\d{5}"}]}]}]`,
+		},
+		{
+			name:    "invalid pattern",
+			rules:   `[{"groups": [{"rules": [{"field": "subject", "operator": "contains", "value": "(a"}, {"field": "content", "operator": "matches", "value": "(unclosed"}]}]}]`,
+			pattern: "(unclosed",
+		},
+		{
+			name:  "other operators aren't checked",
+			rules: `[{"groups": [{"rules": [{"field": "subject", "operator": "contains", "value": "(unclosed"}]}]}]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pattern, err := invalidRuleRegex(json.RawMessage(tt.rules))
+			if (err != nil) != (tt.pattern != "") {
+				t.Fatalf("got error %v, want an error %v", err, tt.pattern != "")
+			}
+			if pattern != tt.pattern {
+				t.Errorf("got pattern %q, want %q", pattern, tt.pattern)
+			}
+		})
+	}
+}
@@ -0,0 +1,228 @@
+package automation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/abhinavxd/libredesk/internal/automation/models"
+	cmodels "github.com/abhinavxd/libredesk/internal/conversation/models"
+	"github.com/abhinavxd/libredesk/internal/envelope"
+)
+
+const (
+	// failedActionRetryInterval is how often failed rule actions that are due are retried.
+	failedActionRetryInterval = time.Minute
+
+	// maxActionAttempts is the number of attempts, including the first one, after which a failed action is given up on.
+	maxActionAttempts = 5
+
+	// failedActionBaseBackoff and failedActionMaxBackoff bound the exponential delay between attempts.
+	failedActionBaseBackoff = time.Minute
+	failedActionMaxBackoff  = time.Hour
+
+	// failedActionBatchSize is the maximum number of failed actions retried in a single run.
+	failedActionBatchSize = 100
+
+	maxFailedActionsPageSize     = 100
+	defaultFailedActionsPageSize = 20
+)
+
+// errInvalidAction is returned for actions whose value can't be applied, retrying them doesn't help.
+var errInvalidAction = errors.New("invalid action")
+
+// nonIdempotentActions are the actions that aren't retried, a failed attempt may have partly applied them and
+// repeating it could send the message twice.
+var nonIdempotentActions = []string{
+	models.ActionReply,
+	models.ActionSendPrivateNote,
+	models.ActionSendCSAT,
+}
+
+// isRetryable reports whether a failed action is worth retrying, that is it's safe to repeat and it failed with an
+// error that may go away, such as a database error. Invalid actions and input, not found and permission errors are
+// not retried.
+func isRetryable(action models.RuleAction, err error) bool {
+	if slices.Contains(nonIdempotentActions, action.Type) || errors.Is(err, errInvalidAction) {
+		return false
+	}
+	var envErr envelope.Error
+	if errors.As(err, &envErr) {
+		switch envErr.ErrorType {
+		case envelope.GeneralError, envelope.DataError, envelope.NetworkError:
+			return true
+		}
+		return false
+	}
+	return true
+}
+
+// retryBackoff returns the delay before the next attempt of an action that has failed `attempts` times.
+func retryBackoff(attempts int) time.Duration {
+	if attempts < 1 {
+		attempts = 1
+	}
+	d := failedActionBaseBackoff
+	for i := 1; i < attempts; i++ {
+		d *= 2
+		if d >= failedActionMaxBackoff {
+			return failedActionMaxBackoff
+		}
+	}
+	return d
+}
+
+// queueFailedAction persists an action that failed to apply so it's retried later, surviving restarts. Actions that
+// aren't retryable are only recorded for inspection.
+func (e *Engine) queueFailedAction(rule models.Rule, action models.RuleAction, conversation cmodels.Conversation, applyErr error) {
+	status := models.FailedActionStatusPending
+	if !isRetryable(action, applyErr) {
+		status = models.FailedActionStatusFailed
+	}
+	e.insertFailedAction(rule, action, conversation, applyErr.Error(), status)
+}
+
+// insertFailedAction persists an action that was not applied with the passed status.
+func (e *Engine) insertFailedAction(rule models.Rule, action models.RuleAction, conversation cmodels.Conversation, reason, status string) {
+	b, err := json.Marshal(action)
+	if err != nil {
+		e.lo.Error("error marshalling failed action", "action", action, "error", err)
+		return
+	}
+	if _, err := e.q.InsertFailedAction.Exec(rule.ID, rule.Name, conversation.ID, b, reason, time.Now().Add(retryBackoff(1)), status); err != nil {
+		e.lo.Error("error inserting failed action", "rule_id", rule.ID, "conversation_uuid", conversation.UUID, "status", status, "error", err)
+	}
+}
+
+// retryFailedActions retries the failed actions that are due, actions that keep failing are marked exhausted
+// after `maxActionAttempts` attempts and are left for inspection.
+func (e *Engine) retryFailedActions() {
+	var actions []models.FailedAction
+	if err := e.q.ClaimDueFailedActions.Select(&actions, failedActionBatchSize); err != nil {
+		e.lo.Error("error fetching failed actions to retry", "error", err)
+		return
+	}
+	for _, fa := range actions {
+		e.retryFailedAction(fa)
+	}
+}
+
+// retryFailedAction applies a failed action again on the current state of its conversation, if the rule it came from
+// still applies to the conversation. Actions of rules that no longer apply are dropped.
+func (e *Engine) retryFailedAction(fa models.FailedAction) {
+	var action models.RuleAction
+	err := json.Unmarshal(fa.Action, &action)
+	if err != nil {
+		err = fmt.Errorf("%w: %w", errInvalidAction, err)
+	} else if slices.Contains(nonIdempotentActions, action.Type) {
+		// Actions queued before they were excluded or requeued by hand.
+		err = fmt.Errorf("%w: %s actions are not retried", errInvalidAction, action.Type)
+	} else {
+		var conversation cmodels.Conversation
+		if conversation, err = e.conversationStore.GetConversation(0, fa.ConversationUUID); err == nil {
+			if !e.ruleStillApplies(fa.RuleID.Int, action, conversation) {
+				e.lo.Info("dropping failed automation action of rule that no longer applies", "id", fa.ID, "rule_id", fa.RuleID.Int, "conversation_uuid", fa.ConversationUUID)
+				if _, err := e.q.DeleteFailedAction.Exec(fa.ID); err != nil {
+					e.lo.Error("error deleting failed action", "id", fa.ID, "error", err)
+				}
+				return
+			}
+			if until, ok := e.reassignmentCooldownUntil(action, conversation, time.Now()); ok {
+				e.lo.Info("suppressing retry of automation assignment action in reassignment cooldown", "id", fa.ID, "conversation_uuid", fa.ConversationUUID, "until", until)
+				if _, err := e.q.UpdateFailedAttempt.Exec(fa.ID, cooldownReason(until), models.FailedActionStatusSuppressed, time.Now()); err != nil {
+					e.lo.Error("error updating failed action attempt", "id", fa.ID, "error", err)
+				}
+				return
+			}
+			err = e.applyAction(action, conversation)
+		}
+	}
+
+	if err == nil {
+		e.lo.Info("retried failed automation action", "id", fa.ID, "rule_id", fa.RuleID.Int, "conversation_uuid", fa.ConversationUUID, "attempts", fa.Attempts+1)
+		if _, err := e.q.DeleteFailedAction.Exec(fa.ID); err != nil {
+			e.lo.Error("error deleting retried failed action", "id", fa.ID, "error", err)
+		}
+		return
+	}
+
+	var (
+		attempts  = fa.Attempts + 1
+		status    = models.FailedActionStatusPending
+		lastError = err.Error()
+	)
+	if !isRetryable(action, err) {
+		status = models.FailedActionStatusFailed
+		e.lo.Error("error retrying failed automation action, not retrying", "id", fa.ID, "rule_id", fa.RuleID.Int, "conversation_uuid", fa.ConversationUUID, "attempts", attempts, "error", err)
+	} else if attempts >= maxActionAttempts {
+		status = models.FailedActionStatusExhausted
+		e.lo.Error("giving up on failed automation action", "id", fa.ID, "rule_id", fa.RuleID.Int, "conversation_uuid", fa.ConversationUUID, "attempts", attempts, "error", err)
+	} else {
+		e.lo.Warn("error retrying failed automation action", "id", fa.ID, "rule_id", fa.RuleID.Int, "conversation_uuid", fa.ConversationUUID, "attempts", attempts, "error", err)
+	}
+	if _, err := e.q.UpdateFailedAttempt.Exec(fa.ID, lastError, status, time.Now().Add(retryBackoff(attempts))); err != nil {
+		e.lo.Error("error updating failed action attempt", "id", fa.ID, "error", err)
+	}
+}
+
+// ruleStillApplies reports whether an enabled rule with the ID and the action still matches the conversation.
+func (e *Engine) ruleStillApplies(ruleID int, action models.RuleAction, conversation cmodels.Conversation) bool {
+	e.rulesMu.RLock()
+	rules := e.rules
+	e.rulesMu.RUnlock()
+
+	for _, rule := range rules {
+		if rule.ID != ruleID || !slices.ContainsFunc(rule.Actions, func(a models.RuleAction) bool {
+			return a.Type == action.Type && slices.Equal(a.Value, action.Value)
+		}) {
+			continue
+		}
+		if e.ruleMatches(rule, conversation) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetFailedActions returns the queued failed actions, optionally filtered by status, most recently updated first.
+func (e *Engine) GetFailedActions(status string, page, pageSize int) ([]models.FailedAction, error) {
+	if pageSize > maxFailedActionsPageSize {
+		return nil, envelope.NewError(envelope.InputError, e.i18n.Ts("globals.messages.pageTooLarge", "max", fmt.Sprintf("%d", maxFailedActionsPageSize)), nil)
+	}
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = defaultFailedActionsPageSize
+	}
+	var actions = make([]models.FailedAction, 0)
+	if err := e.q.GetFailedActions.Select(&actions, status, pageSize, (page-1)*pageSize); err != nil {
+		e.lo.Error("error fetching failed actions", "error", err)
+		return nil, envelope.NewError(envelope.GeneralError, e.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.failedAction}"), nil)
+	}
+	return actions, nil
+}
+
+// RetryFailedAction resets the attempts of a failed action and queues it for an immediate retry.
+func (e *Engine) RetryFailedAction(id int) error {
+	res, err := e.q.RetryFailedAction.Exec(id)
+	if err != nil {
+		e.lo.Error("error queuing failed action for retry", "id", id, "error", err)
+		return envelope.NewError(envelope.GeneralError, e.i18n.Ts("globals.messages.errorUpdating", "name", "{globals.terms.failedAction}"), nil)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return envelope.NewError(envelope.NotFoundError, e.i18n.Ts("globals.messages.notFound", "name", "{globals.terms.failedAction}"), nil)
+	}
+	return nil
+}
+
+// DeleteFailedAction removes a failed action from the retry queue.
+func (e *Engine) DeleteFailedAction(id int) error {
+	if _, err := e.q.DeleteFailedAction.Exec(id); err != nil {
+		e.lo.Error("error deleting failed action", "id", id, "error", err)
+		return envelope.NewError(envelope.GeneralError, e.i18n.Ts("globals.messages.errorDeleting", "name", "{globals.terms.failedAction}"), nil)
+	}
+	return nil
+}
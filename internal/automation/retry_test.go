@@ -0,0 +1,106 @@
+package automation
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/abhinavxd/libredesk/internal/automation/models"
+	cmodels "github.com/abhinavxd/libredesk/internal/conversation/models"
+	"github.com/abhinavxd/libredesk/internal/envelope"
+	"github.com/volatiletech/null/v9"
+	"github.com/zerodha/logf"
+)
+
+func TestRetryBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		expected time.Duration
+	}{
+		{attempts: 0, expected: time.Minute},
+		{attempts: 1, expected: time.Minute},
+		{attempts: 2, expected: 2 * time.Minute},
+		{attempts: 4, expected: 8 * time.Minute},
+		{attempts: 7, expected: time.Hour},
+		{attempts: 50, expected: time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expected.String(), func(t *testing.T) {
+			if got := retryBackoff(tt.attempts); got != tt.expected {
+				t.Errorf("retryBackoff(%d) = %v, want %v", tt.attempts, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	var (
+		tags  = models.RuleAction{Type: models.ActionAddTags, Value: []string{"vip"}}
+		reply = models.RuleAction{Type: models.ActionReply, Value: []string{"Hello"}}
+	)
+	tests := []struct {
+		name     string
+		action   models.RuleAction
+		err      error
+		expected bool
+	}{
+		{name: "database error", action: tags, err: errors.New("connection refused"), expected: true},
+		{name: "general error", action: tags, err: envelope.NewError(envelope.GeneralError, "error updating", nil), expected: true},
+		{name: "wrapped general error", action: tags, err: fmt.Errorf("updating: %w", envelope.NewError(envelope.GeneralError, "error updating", nil)), expected: true},
+		{name: "input error", action: tags, err: envelope.NewError(envelope.InputError, "invalid", nil)},
+		{name: "not found error", action: tags, err: envelope.NewError(envelope.NotFoundError, "not found", nil)},
+		{name: "invalid action", action: tags, err: fmt.Errorf("%w: empty value", errInvalidAction)},
+		{name: "reply", action: reply, err: errors.New("connection refused")},
+		{name: "private note", action: models.RuleAction{Type: models.ActionSendPrivateNote}, err: errors.New("connection refused")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isRetryable(tt.action, tt.err); got != tt.expected {
+				t.Errorf("isRetryable() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRuleStillApplies(t *testing.T) {
+	lo := logf.New(logf.Opts{Writer: io.Discard})
+	var (
+		action = models.RuleAction{Type: models.ActionAddTags, Value: []string{"billing"}}
+		rule   = models.Rule{
+			ID:            1,
+			GroupOperator: models.OperatorAnd,
+			Groups: []models.RuleGroup{{
+				LogicalOp: models.OperatorAnd,
+				Rules:     []models.RuleDetail{{Field: models.ConversationSubject, Operator: models.RuleOperatorEquals, Value: "Invoice"}},
+			}},
+			Actions: []models.RuleAction{action},
+		}
+		e = &Engine{lo: &lo, rules: []models.Rule{rule}}
+	)
+
+	tests := []struct {
+		name     string
+		ruleID   int
+		action   models.RuleAction
+		subject  string
+		expected bool
+	}{
+		{name: "conditions still pass", ruleID: 1, action: action, subject: "Invoice", expected: true},
+		{name: "conditions no longer pass", ruleID: 1, action: action, subject: "Hello"},
+		{name: "rule deleted or disabled", ruleID: 2, action: action, subject: "Invoice"},
+		{name: "action removed from rule", ruleID: 1, action: models.RuleAction{Type: models.ActionAddTags, Value: []string{"vip"}}, subject: "Invoice"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conversation := cmodels.Conversation{Subject: null.StringFrom(tt.subject)}
+			if got := e.ruleStillApplies(tt.ruleID, tt.action, conversation); got != tt.expected {
+				t.Errorf("ruleStillApplies() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package automation
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/abhinavxd/libredesk/internal/automation/models"
+	cmodels "github.com/abhinavxd/libredesk/internal/conversation/models"
+)
+
+const (
+	// defaultRouterTimeout is the timeout of external router requests if none is configured.
+	defaultRouterTimeout = 5 * time.Second
+	// maxRouterResponseSize is the maximum size of an external router response body.
+	maxRouterResponseSize = 64 * 1024
+)
+
+// ExternalRouterOpts configures the external router new conversations are posted to for an assignment decision.
+// The router is disabled if the URL is empty.
+type ExternalRouterOpts struct {
+	URL string
+	// Token is sent as a bearer token in the `Authorization` header if set.
+	Token   string
+	Timeout time.Duration
+}
+
+// routerDecision is the assignment decision of the external router, zero IDs leave the assignee unchanged.
+type routerDecision struct {
+	TeamID  int `json:"team_id"`
+	AgentID int `json:"agent_id"`
+}
+
+// empty returns true if the decision doesn't assign the conversation.
+func (d routerDecision) empty() bool {
+	return d.TeamID <= 0 && d.AgentID <= 0
+}
+
+// externalRouter posts new conversations to an external system that decides their assignment.
+type externalRouter struct {
+	opts   ExternalRouterOpts
+	client *http.Client
+}
+
+// newExternalRouter returns an external router, or nil if it's not configured.
+func newExternalRouter(opts ExternalRouterOpts) *externalRouter {
+	if opts.URL == "" {
+		return nil
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultRouterTimeout
+	}
+	return &externalRouter{
+		opts:   opts,
+		client: &http.Client{Timeout: opts.Timeout},
+	}
+}
+
+// route posts the conversation to the router and returns its decision. A 204 response is an empty decision.
+func (r *externalRouter) route(conversation cmodels.Conversation) (routerDecision, error) {
+	var decision routerDecision
+
+	body, err := json.Marshal(map[string]any{"conversation": conversation})
+	if err != nil {
+		return decision, fmt.Errorf("marshalling conversation: %w", err)
+	}
+	req, err := http.NewRequest(http.MethodPost, r.opts.URL, bytes.NewReader(body))
+	if err != nil {
+		return decision, fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if r.opts.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+r.opts.Token)
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return decision, fmt.Errorf("posting conversation: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNoContent {
+		return decision, nil
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return decision, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxRouterResponseSize)).Decode(&decision); err != nil {
+		return decision, fmt.Errorf("decoding decision: %w", err)
+	}
+	return decision, nil
+}
+
+// routeExternally asks the external router for the assignment of a new conversation and applies it. It returns true
+// if the conversation was assigned, in which case the local new conversation rules are skipped. Local rules are
+// evaluated as usual if the router is not configured, fails, or doesn't assign the conversation.
+func (e *Engine) routeExternally(conversation cmodels.Conversation) bool {
+	if e.router == nil {
+		return false
+	}
+	decision, err := e.router.route(conversation)
+	if err != nil {
+		e.lo.Error("error routing conversation externally, falling back to automation rules", "conversation_uuid", conversation.UUID, "error", err)
+		return false
+	}
+	if decision.empty() {
+		e.lo.Debug("external router made no assignment decision", "conversation_uuid", conversation.UUID)
+		return false
+	}
+
+	var actions []models.RuleAction
+	if decision.TeamID > 0 {
+		actions = append(actions, models.RuleAction{Type: models.ActionAssignTeam, Value: []string{strconv.Itoa(decision.TeamID)}})
+	}
+	if decision.AgentID > 0 {
+		actions = append(actions, models.RuleAction{Type: models.ActionAssignUser, Value: []string{strconv.Itoa(decision.AgentID)}})
+	}
+	for _, action := range actions {
+		if err := e.conversationStore.ApplyAction(action, conversation, e.systemUser); err != nil {
+			e.lo.Error("error applying external router decision, falling back to automation rules", "action", action.Type, "conversation_uuid", conversation.UUID, "error", err)
+			return false
+		}
+	}
+	e.lo.Info("conversation routed externally", "conversation_uuid", conversation.UUID, "team_id", decision.TeamID, "agent_id", decision.AgentID)
+	return true
+}
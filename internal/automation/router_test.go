@@ -0,0 +1,90 @@
+package automation
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	cmodels "github.com/abhinavxd/libredesk/internal/conversation/models"
+)
+
+func TestNewExternalRouterDisabled(t *testing.T) {
+	if r := newExternalRouter(ExternalRouterOpts{}); r != nil {
+		t.Errorf("newExternalRouter() = %v, want nil without a URL", r)
+	}
+}
+
+func TestExternalRouterRoute(t *testing.T) {
+	tests := []struct {
+		name     string
+		handler  http.HandlerFunc
+		timeout  time.Duration
+		expected routerDecision
+		wantErr  bool
+	}{
+		{
+			name: "assignment decision",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("Authorization") != "Bearer secret" {
+					t.Errorf("Authorization = %q", r.Header.Get("Authorization"))
+				}
+				var body struct {
+					Conversation cmodels.Conversation `json:"conversation"`
+				}
+				if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Conversation.UUID != "conv-uuid" {
+					t.Errorf("unexpected request body %+v, error %v", body, err)
+				}
+				w.Write([]byte(`{"team_id": 2, "agent_id": 5}`))
+			},
+			expected: routerDecision{TeamID: 2, AgentID: 5},
+		},
+		{
+			name: "no content",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNoContent)
+			},
+			expected: routerDecision{},
+		},
+		{
+			name: "error status",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusInternalServerError)
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid body",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(`not json`))
+			},
+			wantErr: true,
+		},
+		{
+			name: "timeout",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				time.Sleep(200 * time.Millisecond)
+				w.Write([]byte(`{"team_id": 2}`))
+			},
+			timeout: 50 * time.Millisecond,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(tt.handler)
+			defer srv.Close()
+
+			r := newExternalRouter(ExternalRouterOpts{URL: srv.URL, Token: "secret", Timeout: tt.timeout})
+			got, err := r.route(cmodels.Conversation{UUID: "conv-uuid"})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("route() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.expected {
+				t.Errorf("route() = %+v, want %+v", got, tt.expected)
+			}
+		})
+	}
+}
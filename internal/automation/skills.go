@@ -0,0 +1,72 @@
+package automation
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+
+	cmodels "github.com/abhinavxd/libredesk/internal/conversation/models"
+)
+
+const (
+	// skillTagPrefix marks the conversation tags naming a required skill, e.g. `skill:billing`.
+	skillTagPrefix = "skill:"
+
+	// requiredSkillsAttribute is the conversation custom attribute listing required skills, comma separated or as an
+	// array.
+	requiredSkillsAttribute = "required_skills"
+)
+
+// requiredSkills returns the skills an agent needs to be assigned the conversation, taken from its `skill:` tags
+// and its `required_skills` custom attribute. Skills are lowercased, duplicates are dropped.
+func requiredSkills(conversation cmodels.Conversation) ([]string, error) {
+	var skills []string
+
+	tags, err := conversationTagNames(conversation.Tags.JSON)
+	if err != nil {
+		return nil, err
+	}
+	for _, tag := range tags {
+		if skill, ok := strings.CutPrefix(strings.ToLower(strings.TrimSpace(tag)), skillTagPrefix); ok {
+			skills = append(skills, skill)
+		}
+	}
+
+	if len(conversation.CustomAttributes) > 0 {
+		var attributes map[string]any
+		if err := json.Unmarshal(conversation.CustomAttributes, &attributes); err != nil {
+			return nil, err
+		}
+		switch v := attributes[requiredSkillsAttribute].(type) {
+		case string:
+			skills = append(skills, strings.Split(v, ",")...)
+		case []any:
+			for _, s := range v {
+				if s, ok := s.(string); ok {
+					skills = append(skills, s)
+				}
+			}
+		}
+	}
+
+	var out = make([]string, 0, len(skills))
+	for _, s := range skills {
+		s = strings.ToLower(strings.TrimSpace(s))
+		if s == "" || slices.Contains(out, s) {
+			continue
+		}
+		out = append(out, s)
+	}
+	return out, nil
+}
+
+// filterSkilledAgents returns the candidates whose user ID is in the skilled agent IDs.
+func filterSkilledAgents(candidates []cmodels.AgentWorkload, skilledIDs []int) []cmodels.AgentWorkload {
+	var out = make([]cmodels.AgentWorkload, 0, len(candidates))
+	for _, agent := range candidates {
+		if slices.Contains(skilledIDs, agent.UserID) {
+			out = append(out, agent)
+		}
+	}
+	return out
+}
@@ -0,0 +1,54 @@
+package automation
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+
+	cmodels "github.com/abhinavxd/libredesk/internal/conversation/models"
+	"github.com/volatiletech/null/v9"
+)
+
+func TestRequiredSkills(t *testing.T) {
+	tests := []struct {
+		name       string
+		tags       string
+		attributes string
+		expected   []string
+	}{
+		{name: "no tags or attributes", expected: []string{}},
+		{name: "skill tags", tags: `["billing", "Skill:Spanish", "skill: refunds "]`, expected: []string{"spanish", "refunds"}},
+		{name: "comma separated attribute", attributes: `{"required_skills": "Billing, spanish,,"}`, expected: []string{"billing", "spanish"}},
+		{name: "array attribute", attributes: `{"required_skills": ["billing", 3, "vip"]}`, expected: []string{"billing", "vip"}},
+		{name: "tags and attribute deduped", tags: `["skill:billing"]`, attributes: `{"required_skills": "BILLING,vip"}`, expected: []string{"billing", "vip"}},
+		{name: "other attributes ignored", attributes: `{"plan": "pro"}`, expected: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conversation := cmodels.Conversation{CustomAttributes: json.RawMessage(tt.attributes)}
+			if tt.tags != "" {
+				conversation.Tags = null.JSONFrom([]byte(tt.tags))
+			}
+			got, err := requiredSkills(conversation)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !slices.Equal(got, tt.expected) {
+				t.Errorf("got %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFilterSkilledAgents(t *testing.T) {
+	candidates := []cmodels.AgentWorkload{{UserID: 1}, {UserID: 2}, {UserID: 3}}
+
+	got := filterSkilledAgents(candidates, []int{3, 1, 9})
+	if len(got) != 2 || got[0].UserID != 1 || got[1].UserID != 3 {
+		t.Errorf("got %+v, want users 1 and 3", got)
+	}
+	if got := filterSkilledAgents(candidates, nil); len(got) != 0 {
+		t.Errorf("got %+v, want no agents", got)
+	}
+}
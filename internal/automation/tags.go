@@ -0,0 +1,60 @@
+package automation
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/abhinavxd/libredesk/internal/automation/models"
+)
+
+// conversationTagNames returns the tag names of a conversation from its `tags` JSON array.
+func conversationTagNames(tags []byte) ([]string, error) {
+	var names []string
+	if len(tags) == 0 {
+		return names, nil
+	}
+	if err := json.Unmarshal(tags, &names); err != nil {
+		return nil, err
+	}
+	return names, nil
+}
+
+// matchTags evaluates a tags condition. Contains matches when the conversation has any of the comma separated tags of
+// the rule and not contains when it has none of them, tags are compared whole so `bill` doesn't match `billing`.
+// Set and not set check whether the conversation has any tags.
+func matchTags(rule models.RuleDetail, tags []string) (bool, error) {
+	var (
+		has  = make([]string, 0, len(tags))
+		want = make([]string, 0)
+	)
+	normalize := func(tag string) string {
+		tag = strings.TrimSpace(tag)
+		if !rule.CaseSensitiveMatch {
+			tag = strings.ToLower(tag)
+		}
+		return tag
+	}
+	for _, tag := range tags {
+		has = append(has, normalize(tag))
+	}
+	for _, tag := range strings.Split(rule.Value, ",") {
+		if tag = normalize(tag); tag != "" {
+			want = append(want, tag)
+		}
+	}
+
+	hasAny := slices.ContainsFunc(want, func(tag string) bool { return slices.Contains(has, tag) })
+	switch rule.Operator {
+	case models.RuleOperatorContains:
+		return hasAny, nil
+	case models.RuleOperatorNotContains:
+		return !hasAny, nil
+	case models.RuleOperatorSet:
+		return len(has) > 0, nil
+	case models.RuleOperatorNotSet:
+		return len(has) == 0, nil
+	}
+	return false, fmt.Errorf("operator %q is not supported for tags", rule.Operator)
+}
@@ -0,0 +1,97 @@
+package automation
+
+import (
+	"testing"
+
+	"github.com/abhinavxd/libredesk/internal/automation/models"
+)
+
+func TestMatchTags(t *testing.T) {
+	tests := []struct {
+		name     string
+		rule     models.RuleDetail
+		tags     []string
+		expected bool
+		wantErr  bool
+	}{
+		{
+			name:     "contains any of the tags",
+			rule:     models.RuleDetail{Operator: models.RuleOperatorContains, Value: "vip, billing"},
+			tags:     []string{"billing"},
+			expected: true,
+		},
+		{
+			name:     "contains compares whole tags",
+			rule:     models.RuleDetail{Operator: models.RuleOperatorContains, Value: "bill"},
+			tags:     []string{"billing"},
+			expected: false,
+		},
+		{
+			name:     "contains is case insensitive by default",
+			rule:     models.RuleDetail{Operator: models.RuleOperatorContains, Value: "VIP"},
+			tags:     []string{"vip"},
+			expected: true,
+		},
+		{
+			name:     "contains case sensitive",
+			rule:     models.RuleDetail{Operator: models.RuleOperatorContains, Value: "VIP", CaseSensitiveMatch: true},
+			tags:     []string{"vip"},
+			expected: false,
+		},
+		{
+			name:     "not contains",
+			rule:     models.RuleDetail{Operator: models.RuleOperatorNotContains, Value: "spam"},
+			tags:     []string{"billing"},
+			expected: true,
+		},
+		{
+			name:     "not contains with a matching tag",
+			rule:     models.RuleDetail{Operator: models.RuleOperatorNotContains, Value: "spam,billing"},
+			tags:     []string{"billing"},
+			expected: false,
+		},
+		{
+			name:     "set",
+			rule:     models.RuleDetail{Operator: models.RuleOperatorSet},
+			tags:     []string{"billing"},
+			expected: true,
+		},
+		{
+			name:     "not set",
+			rule:     models.RuleDetail{Operator: models.RuleOperatorNotSet},
+			tags:     nil,
+			expected: true,
+		},
+		{
+			name:    "unsupported operator",
+			rule:    models.RuleDetail{Operator: models.RuleOperatorGreaterThan, Value: "1"},
+			tags:    []string{"billing"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := matchTags(tt.rule, tt.tags)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("matchTags() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.expected {
+				t.Errorf("matchTags() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestConversationTagNames(t *testing.T) {
+	names, err := conversationTagNames([]byte(`["vip","billing"]`))
+	if err != nil {
+		t.Fatalf("conversationTagNames() error = %v", err)
+	}
+	if len(names) != 2 || names[0] != "vip" || names[1] != "billing" {
+		t.Errorf("conversationTagNames() = %v", names)
+	}
+	if names, err := conversationTagNames(nil); err != nil || len(names) != 0 {
+		t.Errorf("conversationTagNames(nil) = %v, %v", names, err)
+	}
+}
@@ -0,0 +1,54 @@
+package automation
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/abhinavxd/libredesk/internal/automation/models"
+	cmodels "github.com/abhinavxd/libredesk/internal/conversation/models"
+)
+
+// unassignedCheckInterval is how often conversations left unassigned are checked for escalation.
+const unassignedCheckInterval = time.Minute
+
+// escalateUnassignedConversations escalates the conversations left without an assigned agent past the unassigned
+// escalation minutes of their inbox, moving them to the inbox's fallback team and alerting its notify user.
+// Each conversation is escalated once.
+func (e *Engine) escalateUnassignedConversations() {
+	conversations, err := e.conversationStore.GetUnassignedConversationsToEscalate()
+	if err != nil {
+		e.lo.Error("error fetching unassigned conversations to escalate", "error", err)
+		return
+	}
+	for _, c := range conversations {
+		e.escalateUnassigned(c)
+	}
+}
+
+// escalateUnassigned escalates a single unassigned conversation, it's marked as escalated even if an action fails
+// so a broken fallback team or user doesn't get retried every minute.
+func (e *Engine) escalateUnassigned(c cmodels.UnassignedEscalation) {
+	if c.FallbackTeamID > 0 && c.AssignedTeamID.Int != c.FallbackTeamID {
+		conversation, err := e.conversationStore.GetConversation(0, c.UUID)
+		if err != nil {
+			e.lo.Error("error fetching conversation to escalate", "uuid", c.UUID, "error", err)
+			return
+		}
+		if err := e.conversationStore.ApplyAction(models.RuleAction{
+			Type:  models.ActionAssignTeam,
+			Value: []string{strconv.Itoa(c.FallbackTeamID)},
+		}, conversation, e.systemUser); err != nil {
+			e.lo.Error("error assigning unassigned conversation to fallback team", "uuid", c.UUID, "team_id", c.FallbackTeamID, "error", err)
+		}
+	}
+	if c.NotifyUserID > 0 {
+		if err := e.conversationStore.SendUnassignedConversationAlert(c.UUID, c.NotifyUserID, c.Minutes); err != nil {
+			e.lo.Error("error alerting about unassigned conversation", "uuid", c.UUID, "user_id", c.NotifyUserID, "error", err)
+		}
+	}
+	if err := e.conversationStore.MarkUnassignedEscalated(c.ID); err != nil {
+		e.lo.Error("error marking conversation as escalated", "uuid", c.UUID, "error", err)
+		return
+	}
+	e.lo.Info("escalated unassigned conversation", "uuid", c.UUID, "minutes", c.Minutes, "fallback_team_id", c.FallbackTeamID, "notify_user_id", c.NotifyUserID)
+}
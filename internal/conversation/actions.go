@@ -0,0 +1,194 @@
+package conversation
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	amodels "github.com/abhinavxd/libredesk/internal/automation/models"
+	"github.com/abhinavxd/libredesk/internal/conversation/models"
+	"github.com/abhinavxd/libredesk/internal/envelope"
+	umodels "github.com/abhinavxd/libredesk/internal/user/models"
+	"github.com/lib/pq"
+)
+
+// resolvedAction is an action with its value resolved and validated, ready to be applied.
+type resolvedAction struct {
+	typ  string
+	id   int
+	name string
+	tags []string
+}
+
+// ApplyActions applies the actions to the conversation in order and in a single transaction, so either all of them
+// are applied or none is. Every action is validated before anything is changed, and the activities, broadcasts and
+// emails of the actions are only done once the changes are committed. Only the actions that change the conversation
+// itself are supported, messages are sent with ApplyAction once the actions are applied.
+func (c *Manager) ApplyActions(uuid string, actions []amodels.RuleAction, actor umodels.User) error {
+	actor, err := c.actorOrSystemUser(actor)
+	if err != nil {
+		return envelope.NewError(envelope.GeneralError, c.i18n.Ts("globals.messages.errorUpdating", "name", "{globals.terms.conversation}"), nil)
+	}
+	conversation, err := c.GetConversation(0, uuid)
+	if err != nil {
+		return err
+	}
+
+	resolved := make([]resolvedAction, 0, len(actions))
+	for _, action := range actions {
+		r, err := c.resolveAction(uuid, action)
+		if err != nil {
+			return err
+		}
+		resolved = append(resolved, r)
+	}
+
+	prevTags, err := c.getConversationTags(uuid)
+	if err != nil {
+		return err
+	}
+
+	if err := c.execActions(uuid, resolved, actor); err != nil {
+		return err
+	}
+
+	// The changes are committed, the follow-ups of each action don't fail the others.
+	var (
+		previousTeamID = conversation.AssignedTeamID.Int
+		tagsChanged    bool
+	)
+	for _, r := range resolved {
+		var err error
+		switch r.typ {
+		case amodels.ActionAssignTeam:
+			c.BroadcastConversationUpdate(uuid, "assigned_team_id", r.id)
+			c.teamAssigned(uuid, previousTeamID, r.id, actor)
+			previousTeamID = r.id
+		case amodels.ActionAssignUser:
+			c.BroadcastConversationUpdate(uuid, "assigned_user_id", r.id)
+			err = c.userAssigned(uuid, r.id, actor)
+		case amodels.ActionSetPriority:
+			err = c.priorityChanged(uuid, r.name, actor)
+		case amodels.ActionSetStatus:
+			err = c.statusChanged(uuid, r.name, actor)
+		case amodels.ActionAddTags, amodels.ActionRemoveTags, amodels.ActionSetTags:
+			tagsChanged = true
+		}
+		if err != nil {
+			c.lo.Error("error recording applied action", "type", r.typ, "uuid", uuid, "error", err)
+		}
+	}
+	if tagsChanged {
+		if err := c.tagsChanged(uuid, prevTags, actor); err != nil {
+			c.lo.Error("error recording applied tags", "uuid", uuid, "error", err)
+		}
+	}
+	return nil
+}
+
+// resolveAction validates the value of an action and resolves it to the IDs and names the conversation is updated
+// with.
+func (c *Manager) resolveAction(uuid string, action amodels.RuleAction) (resolvedAction, error) {
+	r := resolvedAction{typ: action.Type}
+	if len(action.Value) == 0 {
+		return r, envelope.NewError(envelope.InputError, c.i18n.Ts("globals.messages.empty", "name", action.Type), nil)
+	}
+
+	switch action.Type {
+	case amodels.ActionAssignTeam:
+		r.id, _ = strconv.Atoi(action.Value[0])
+		if r.id <= 0 {
+			return r, envelope.NewError(envelope.InputError, c.i18n.Ts("globals.messages.invalid", "name", "{globals.terms.team}"), nil)
+		}
+		if _, err := c.teamStore.Get(r.id); err != nil {
+			return r, err
+		}
+	case amodels.ActionAssignUser:
+		r.id, _ = strconv.Atoi(action.Value[0])
+		if r.id <= 0 {
+			return r, envelope.NewError(envelope.InputError, c.i18n.Ts("globals.messages.invalid", "name", "{globals.terms.agent}"), nil)
+		}
+		if err := c.checkOpenConversationsCap(uuid, r.id); err != nil {
+			return r, err
+		}
+	case amodels.ActionSetPriority:
+		id, _ := strconv.Atoi(action.Value[0])
+		p, err := c.resolvePriority(id, "")
+		if err != nil {
+			return r, err
+		}
+		r.name = p.Name
+	case amodels.ActionSetStatus:
+		id, _ := strconv.Atoi(action.Value[0])
+		s, err := c.resolveStatus(id, "")
+		if err != nil {
+			return r, err
+		}
+		// Actions have no snooze duration.
+		if s.Name == models.StatusSnoozed {
+			return r, envelope.NewError(envelope.InputError, c.i18n.T("conversation.invalidSnoozeDuration"), nil)
+		}
+		r.name = s.Name
+	case amodels.ActionAddTags, amodels.ActionRemoveTags, amodels.ActionSetTags:
+		r.tags = action.Value
+	default:
+		return r, envelope.NewError(envelope.InputError, c.i18n.Ts("globals.messages.invalid", "name", action.Type), nil)
+	}
+	return r, nil
+}
+
+// execActions updates the conversation with the resolved actions in a single transaction.
+func (c *Manager) execActions(uuid string, actions []resolvedAction, actor umodels.User) error {
+	errUpdating := envelope.NewError(envelope.GeneralError, c.i18n.Ts("globals.messages.errorUpdating", "name", "{globals.terms.conversation}"), nil)
+
+	tx, err := c.db.BeginTxx(context.Background(), nil)
+	if err != nil {
+		c.lo.Error("error starting db txn", "error", err)
+		return errUpdating
+	}
+	defer tx.Rollback()
+
+	for _, r := range actions {
+		switch r.typ {
+		case amodels.ActionAssignTeam:
+			_, err = tx.Stmtx(c.q.UpdateConversationAssignedTeam).Exec(uuid, r.id)
+		case amodels.ActionAssignUser:
+			if err := c.assignUserTx(tx, uuid, r.id); err != nil {
+				return err
+			}
+		case amodels.ActionSetPriority:
+			_, err = tx.Stmtx(c.q.UpdateConversationPriority).Exec(uuid, r.name)
+		case amodels.ActionSetStatus:
+			_, err = tx.Stmtx(c.q.UpdateConversationStatus).Exec(uuid, r.name, time.Time{})
+		case amodels.ActionAddTags:
+			_, err = tx.Stmtx(c.q.AddConversationTags).Exec(uuid, pq.Array(r.tags))
+		case amodels.ActionRemoveTags:
+			_, err = tx.Stmtx(c.q.RemoveConversationTags).Exec(uuid, pq.Array(r.tags))
+		case amodels.ActionSetTags:
+			_, err = tx.Stmtx(c.q.SetConversationTags).Exec(uuid, pq.Array(r.tags))
+		}
+		if err != nil {
+			c.lo.Error("error applying action", "type", r.typ, "uuid", uuid, "error", err)
+			return errUpdating
+		}
+		if r.typ != amodels.ActionAssignTeam && r.typ != amodels.ActionAssignUser {
+			continue
+		}
+		if _, err := tx.Stmtx(c.q.InsertConversationAssignment).Exec(uuid, c.resetFirstReplyOnReassign); err != nil {
+			c.lo.Error("error recording conversation assignment", "uuid", uuid, "error", err)
+			return errUpdating
+		}
+		if isAgentActor(actor) {
+			if _, err := tx.Stmtx(c.q.SetManuallyAssigned).Exec(uuid); err != nil {
+				c.lo.Error("error recording manual conversation assignment", "uuid", uuid, "error", err)
+				return errUpdating
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.lo.Error("error committing applied actions", "uuid", uuid, "error", err)
+		return errUpdating
+	}
+	return nil
+}
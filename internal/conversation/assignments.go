@@ -0,0 +1,99 @@
+package conversation
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"time"
+
+	"github.com/abhinavxd/libredesk/internal/envelope"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	// First response time semantics on reassignment, see Opts.FirstReplyOnReassign.
+	FirstReplyOnReassignKeep  = "keep"
+	FirstReplyOnReassignReset = "reset"
+)
+
+// recordAssignment records the current assignee of the conversation so response times can be attributed to the
+// team and agent it was assigned to. Errors are logged as the assignment itself already succeeded.
+func (c *Manager) recordAssignment(uuid string) {
+	if _, err := c.q.InsertConversationAssignment.Exec(uuid, c.resetFirstReplyOnReassign); err != nil {
+		c.lo.Error("error recording conversation assignment", "uuid", uuid, "error", err)
+	}
+}
+
+// recordAssignmentReply sets the first reply time of the current assignment of the conversation.
+func (c *Manager) recordAssignmentReply(conversationID int, at time.Time) {
+	if _, err := c.q.UpdateAssignmentFirstReplyAt.Exec(conversationID, at); err != nil {
+		c.lo.Error("error recording assignment first reply", "conversation_id", conversationID, "error", err)
+	}
+}
+
+// checkOpenConversationsCap returns an input error if assigning the conversation would take the agent over their
+// maximum open conversations.
+func (c *Manager) checkOpenConversationsCap(uuid string, userID int) error {
+	workload, err := c.GetAgentWorkload(userID, uuid)
+	if err != nil {
+		return err
+	}
+	if workload.AtOpenConversationsCap() {
+		return envelope.NewError(envelope.InputError, c.i18n.Ts("conversation.agentAtOpenConversationsCap", "max", strconv.Itoa(workload.MaxOpenConversations)), nil)
+	}
+	return nil
+}
+
+// assignUser assigns the conversation to the agent, see assignUserTx.
+func (c *Manager) assignUser(uuid string, userID int) error {
+	tx, err := c.db.BeginTxx(context.Background(), nil)
+	if err != nil {
+		c.lo.Error("error starting db txn", "error", err)
+		return envelope.NewError(envelope.GeneralError, c.i18n.Ts("globals.messages.errorUpdating", "name", "{globals.terms.conversation}"), nil)
+	}
+	defer tx.Rollback()
+
+	if err := c.assignUserTx(tx, uuid, userID); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		c.lo.Error("error committing conversation assignee", "uuid", uuid, "error", err)
+		return envelope.NewError(envelope.GeneralError, c.i18n.Ts("globals.messages.errorUpdating", "name", "{globals.terms.conversation}"), nil)
+	}
+	return nil
+}
+
+// assignUserTx assigns the conversation to the agent in the transaction, unless that takes the agent over their
+// maximum open conversations. The cap is checked by the update itself after locking the agent, so concurrent
+// assignments to the same agent can't go over it.
+func (c *Manager) assignUserTx(tx *sqlx.Tx, uuid string, userID int) error {
+	if err := c.lockAgentForAssignment(tx, userID); err != nil {
+		return err
+	}
+	res, err := tx.Stmtx(c.q.UpdateConversationAssignedUser).Exec(uuid, userID)
+	if err != nil {
+		c.lo.Error("error updating conversation assignee", "uuid", uuid, "user_id", userID, "error", err)
+		return envelope.NewError(envelope.GeneralError, c.i18n.Ts("globals.messages.errorUpdating", "name", "{globals.terms.conversation}"), nil)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		if err := c.checkOpenConversationsCap(uuid, userID); err != nil {
+			return err
+		}
+		return envelope.NewError(envelope.NotFoundError, c.i18n.Ts("globals.messages.notFound", "name", "{globals.terms.conversation}"), nil)
+	}
+	return nil
+}
+
+// lockAgentForAssignment locks the agent until the end of the transaction, returning a not found error if there's
+// no such agent.
+func (c *Manager) lockAgentForAssignment(tx *sqlx.Tx, userID int) error {
+	var id int
+	if err := tx.Stmtx(c.q.LockAgentForAssignment).Get(&id, userID); err != nil {
+		if err == sql.ErrNoRows {
+			return envelope.NewError(envelope.NotFoundError, c.i18n.Ts("globals.messages.notFound", "name", "{globals.terms.agent}"), nil)
+		}
+		c.lo.Error("error locking agent for assignment", "user_id", userID, "error", err)
+		return envelope.NewError(envelope.GeneralError, c.i18n.Ts("globals.messages.errorUpdating", "name", "{globals.terms.conversation}"), nil)
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+package conversation
+
+import (
+	"io"
+	"testing"
+	"time"
+
+	"github.com/abhinavxd/libredesk/internal/conversation/models"
+	"github.com/abhinavxd/libredesk/internal/dbutil"
+	"github.com/abhinavxd/libredesk/internal/ws"
+	"github.com/volatiletech/null/v9"
+	"github.com/zerodha/logf"
+)
+
+// testAssignment is a row of conversation_assignments.
+type testAssignment struct {
+	UserID       null.Int  `db:"assigned_user_id"`
+	TeamID       null.Int  `db:"assigned_team_id"`
+	FirstReplyAt null.Time `db:"first_reply_at"`
+}
+
+func TestAssignmentHistory(t *testing.T) {
+	db := newTestDB(t)
+
+	var q queries
+	if err := dbutil.ScanSQLFile("queries.sql", &q, db, efs); err != nil {
+		t.Fatalf("preparing queries: %v", err)
+	}
+	lo := logf.New(logf.Opts{Writer: io.Discard})
+	m := &Manager{q: q, db: db, lo: &lo, wsHub: ws.NewHub(nil), resetFirstReplyOnReassign: true}
+
+	var ids struct {
+		ConversationID   int    `db:"conversation_id"`
+		ConversationUUID string `db:"conversation_uuid"`
+		AgentA           int    `db:"agent_a"`
+		AgentB           int    `db:"agent_b"`
+		TeamID           int    `db:"team_id"`
+		QueueID          int    `db:"queue_id"`
+	}
+	err := db.QueryRowx(`
+		WITH contact AS (
+			INSERT INTO users (type, first_name, email) VALUES ('contact', 'Test', 'contact@example.com') RETURNING id
+		),
+		agent_a AS (
+			INSERT INTO users (type, first_name, email) VALUES ('agent', 'A', 'a@example.com') RETURNING id
+		),
+		agent_b AS (
+			INSERT INTO users (type, first_name, email) VALUES ('agent', 'B', 'b@example.com') RETURNING id
+		),
+		team AS (
+			INSERT INTO teams (name, conversation_assignment_type) VALUES ('Support', 'Manual') RETURNING id
+		),
+		queue AS (
+			INSERT INTO queues (name) VALUES ('Support') RETURNING id
+		),
+		inbox AS (
+			INSERT INTO inboxes (name, channel) VALUES ('Support', 'email') RETURNING id
+		),
+		channel AS (
+			INSERT INTO contact_channels (contact_id, inbox_id, identifier)
+			SELECT contact.id, inbox.id, 'contact@example.com' FROM contact, inbox RETURNING id, contact_id, inbox_id
+		),
+		conversation AS (
+			INSERT INTO conversations (contact_id, inbox_id, contact_channel_id, status_id)
+			SELECT channel.contact_id, channel.inbox_id, channel.id, (SELECT id FROM conversation_statuses WHERE name = 'Open')
+			FROM channel RETURNING id, uuid
+		)
+		SELECT conversation.id AS conversation_id, conversation.uuid AS conversation_uuid, agent_a.id AS agent_a,
+			agent_b.id AS agent_b, team.id AS team_id, queue.id AS queue_id
+		FROM conversation, agent_a, agent_b, team, queue`).StructScan(&ids)
+	if err != nil {
+		t.Fatalf("inserting conversation: %v", err)
+	}
+	uuid := ids.ConversationUUID
+
+	getAssignments := func() []testAssignment {
+		t.Helper()
+		var a []testAssignment
+		if err := db.Select(&a, `SELECT assigned_user_id, assigned_team_id, first_reply_at FROM conversation_assignments
+			WHERE conversation_id = $1 ORDER BY id`, ids.ConversationID); err != nil {
+			t.Fatalf("fetching assignments: %v", err)
+		}
+		return a
+	}
+	checkLast := func(step string, n, userID, teamID int) {
+		t.Helper()
+		a := getAssignments()
+		if len(a) != n {
+			t.Fatalf("%s: got %d assignments, want %d", step, len(a), n)
+		}
+		last := a[n-1]
+		if last.UserID.Int != userID || last.TeamID.Int != teamID {
+			t.Errorf("%s: got assignment user %v team %v, want user %d team %d", step, last.UserID, last.TeamID, userID, teamID)
+		}
+	}
+
+	if err := m.UpdateAssignee(uuid, ids.AgentA, models.AssigneeTypeUser); err != nil {
+		t.Fatalf("UpdateAssignee() error = %v", err)
+	}
+	checkLast("assign agent", 1, ids.AgentA, 0)
+
+	// Reassigning the same agent isn't recorded again.
+	if err := m.UpdateAssignee(uuid, ids.AgentA, models.AssigneeTypeUser); err != nil {
+		t.Fatalf("UpdateAssignee() error = %v", err)
+	}
+	checkLast("same agent", 1, ids.AgentA, 0)
+
+	if err := m.UpdateAssignee(uuid, ids.TeamID, models.AssigneeTypeTeam); err != nil {
+		t.Fatalf("UpdateAssignee() error = %v", err)
+	}
+	checkLast("assign team", 2, ids.AgentA, ids.TeamID)
+
+	// The first reply is set on the current assignment only, and only once.
+	m.recordAssignmentReply(ids.ConversationID, time.Now())
+	m.recordAssignmentReply(ids.ConversationID, time.Now().Add(time.Hour))
+	a := getAssignments()
+	if a[0].FirstReplyAt.Valid || !a[1].FirstReplyAt.Valid || a[1].FirstReplyAt.Time.After(time.Now()) {
+		t.Errorf("got first replies %v and %v, want only the current assignment's first one", a[0].FirstReplyAt, a[1].FirstReplyAt)
+	}
+
+	if err := m.RemoveConversationAssignee(uuid, models.AssigneeTypeUser); err != nil {
+		t.Fatalf("RemoveConversationAssignee() error = %v", err)
+	}
+	checkLast("unassign agent", 3, 0, ids.TeamID)
+
+	if err := m.UpdateAssignee(uuid, ids.AgentB, models.AssigneeTypeUser); err != nil {
+		t.Fatalf("UpdateAssignee() error = %v", err)
+	}
+	checkLast("assign other agent", 4, ids.AgentB, ids.TeamID)
+	if err := m.UnassignOpen(ids.AgentB); err != nil {
+		t.Fatalf("UnassignOpen() error = %v", err)
+	}
+	checkLast("unassign open", 5, 0, ids.TeamID)
+
+	if _, err := db.Exec(`UPDATE conversations SET assigned_queue_id = $2 WHERE uuid = $1`, uuid, ids.QueueID); err != nil {
+		t.Fatalf("queueing conversation: %v", err)
+	}
+	pulled, err := m.pullQueueConversation(ids.QueueID, ids.AgentA)
+	if err != nil {
+		t.Fatalf("pullQueueConversation() error = %v", err)
+	}
+	if pulled != uuid {
+		t.Fatalf("pulled %s, want %s", pulled, uuid)
+	}
+	checkLast("queue pull", 6, ids.AgentA, ids.TeamID)
+}
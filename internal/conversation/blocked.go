@@ -0,0 +1,103 @@
+package conversation
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/abhinavxd/libredesk/internal/conversation/models"
+	"github.com/abhinavxd/libredesk/internal/envelope"
+	"github.com/abhinavxd/libredesk/internal/stringutil"
+)
+
+const (
+	// maxBlockedContentLen is the number of characters of a blocked message's text content kept for review.
+	maxBlockedContentLen = 2000
+	maxBlockedPageSize   = 100
+)
+
+// incomingBlockReason returns the reason the incoming message has to be blocked, empty if it's within the rate limits.
+func (m *Manager) incomingBlockReason(message models.IncomingMessage) string {
+	now := time.Now()
+	sender := strings.ToLower(message.Contact.Email.String)
+	if sender != "" && !m.contactIncomingLimiter.allow(strconv.Itoa(message.InboxID)+":"+sender, now) {
+		return models.BlockedReasonContactRateLimit
+	}
+	if !m.inboxIncomingLimiter.allow(strconv.Itoa(message.InboxID), now) {
+		return models.BlockedReasonInboxRateLimit
+	}
+	return ""
+}
+
+// recordBlockedIncoming saves a blocked incoming message for review instead of processing it. The whole message is
+// kept, attachments included, so it can be released if it was blocked by mistake.
+func (m *Manager) recordBlockedIncoming(message models.IncomingMessage, reason string) error {
+	m.lo.Warn("blocking incoming message over rate limit", "inbox_id", message.InboxID, "sender", message.Contact.Email.String, "source_id", message.Message.SourceID.String, "reason", reason)
+	payload, err := encodeIncoming(message)
+	if err != nil {
+		m.lo.Error("error encoding blocked incoming message", "inbox_id", message.InboxID, "error", err)
+		return fmt.Errorf("encoding blocked incoming message: %w", err)
+	}
+	content := stringutil.TruncateRunes(stringutil.HTML2Text(message.Message.Content), maxBlockedContentLen)
+	if _, err := m.q.InsertBlockedIncomingMessage.Exec(message.InboxID, message.Contact.Email.String, message.Message.SourceID, message.Message.Subject, content, reason, payload); err != nil {
+		m.lo.Error("error inserting blocked incoming message", "inbox_id", message.InboxID, "error", err)
+		return fmt.Errorf("inserting blocked incoming message: %w", err)
+	}
+	return nil
+}
+
+// GetBlockedIncomingMessages returns the incoming messages blocked by the rate limits, newest first.
+func (m *Manager) GetBlockedIncomingMessages(page, pageSize int) ([]models.BlockedIncomingMessage, error) {
+	if pageSize > maxBlockedPageSize {
+		return nil, envelope.NewError(envelope.InputError, m.i18n.Ts("globals.messages.pageTooLarge", "max", strconv.Itoa(maxBlockedPageSize)), nil)
+	}
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 20
+	}
+	var messages = make([]models.BlockedIncomingMessage, 0)
+	if err := m.q.GetBlockedIncomingMessages.Select(&messages, pageSize, (page-1)*pageSize); err != nil {
+		m.lo.Error("error fetching blocked incoming messages", "error", err)
+		return nil, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.message}"), nil)
+	}
+	return messages, nil
+}
+
+// DeleteBlockedIncomingMessage deletes a reviewed blocked incoming message.
+func (m *Manager) DeleteBlockedIncomingMessage(id int) error {
+	if _, err := m.q.DeleteBlockedIncomingMessage.Exec(id); err != nil {
+		m.lo.Error("error deleting blocked incoming message", "id", id, "error", err)
+		return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorDeleting", "name", "{globals.terms.message}"), nil)
+	}
+	return nil
+}
+
+// ReleaseBlockedIncomingMessage processes a blocked incoming message as if it had not been blocked, bypassing the
+// rate limits, and deletes it from the blocked messages.
+func (m *Manager) ReleaseBlockedIncomingMessage(id int) error {
+	var payload []byte
+	if err := m.q.GetBlockedIncomingPayload.Get(&payload, id); err != nil {
+		if err == sql.ErrNoRows {
+			return envelope.NewError(envelope.NotFoundError, m.i18n.Ts("globals.messages.notFound", "name", "{globals.terms.message}"), nil)
+		}
+		m.lo.Error("error fetching blocked incoming message", "id", id, "error", err)
+		return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.message}"), nil)
+	}
+	if len(payload) == 0 {
+		return envelope.NewError(envelope.InputError, m.i18n.T("conversation.blockedMessageNotReleasable"), nil)
+	}
+	message, err := decodeIncoming(payload)
+	if err != nil {
+		m.lo.Error("error decoding blocked incoming message", "id", id, "error", err)
+		return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorParsing", "name", "{globals.terms.message}"), nil)
+	}
+	if err := m.enqueueIncoming(message); err != nil {
+		m.lo.Error("error enqueuing released incoming message", "id", id, "error", err)
+		return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorCreating", "name", "{globals.terms.message}"), nil)
+	}
+	return m.DeleteBlockedIncomingMessage(id)
+}
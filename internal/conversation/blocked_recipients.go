@@ -0,0 +1,54 @@
+package conversation
+
+import (
+	"path"
+
+	"github.com/abhinavxd/libredesk/internal/conversation/models"
+	"github.com/abhinavxd/libredesk/internal/inbox"
+)
+
+// blockedRecipient returns the first address of `to` that's one of the inbox's own addresses, sending to it would
+// loop back as a new incoming message, or that matches a pattern of the blocklist, e.g. `noreply@*`.
+func blockedRecipient(to, inboxAddresses, blocklist []string) (string, bool) {
+	own := make(map[string]struct{}, len(inboxAddresses))
+	for _, addr := range inboxAddresses {
+		own[normalizeAddress(addr)] = struct{}{}
+	}
+	for _, addr := range to {
+		key := normalizeAddress(addr)
+		if key == "" {
+			continue
+		}
+		if _, ok := own[key]; ok {
+			return addr, true
+		}
+		for _, pattern := range blocklist {
+			if ok, _ := path.Match(normalizeAddress(pattern), key); ok {
+				return addr, true
+			}
+		}
+	}
+	return "", false
+}
+
+// checkBlockedRecipient returns the contact address of the conversation that the message can't be sent to, recording
+// the blocked attempt as an activity on the conversation.
+func (m *Manager) checkBlockedRecipient(inb inbox.Inbox, message models.Message) (string, bool) {
+	to, err := m.GetToAddress(message.ConversationID)
+	if err != nil {
+		return "", false
+	}
+	addr, blocked := blockedRecipient(to, append([]string{inb.FromAddress()}, inb.Aliases()...), m.blockedRecipients)
+	if !blocked {
+		return "", false
+	}
+	systemUser, err := m.userStore.GetSystemUser()
+	if err != nil {
+		m.lo.Error("error fetching system user for recording blocked recipient", "error", err)
+		return addr, true
+	}
+	if err := m.InsertConversationActivity(models.ActivityRecipientBlocked, message.ConversationUUID, addr, systemUser); err != nil {
+		m.lo.Error("error recording blocked recipient activity", "conversation_uuid", message.ConversationUUID, "error", err)
+	}
+	return addr, true
+}
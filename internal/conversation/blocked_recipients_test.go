@@ -0,0 +1,31 @@
+package conversation
+
+import "testing"
+
+func TestBlockedRecipient(t *testing.T) {
+	inboxAddresses := []string{"Support <support@example.com>", "help@example.com"}
+	blocklist := []string{"noreply@*", "*@internal.example.com"}
+	tests := []struct {
+		name    string
+		to      []string
+		want    string
+		blocked bool
+	}{
+		{name: "contact address", to: []string{"alice@example.com"}},
+		{name: "inbox from address", to: []string{"SUPPORT@example.com"}, want: "SUPPORT@example.com", blocked: true},
+		{name: "inbox alias", to: []string{"alice@example.com", "Help <help@example.com>"}, want: "Help <help@example.com>", blocked: true},
+		{name: "blocklist prefix", to: []string{"NoReply@vendor.com"}, want: "NoReply@vendor.com", blocked: true},
+		{name: "blocklist domain", to: []string{"ops@internal.example.com"}, want: "ops@internal.example.com", blocked: true},
+		{name: "similar address", to: []string{"noreply-team@vendor.com"}},
+		{name: "empty", to: []string{""}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, blocked := blockedRecipient(tt.to, inboxAddresses, blocklist)
+			if got != tt.want || blocked != tt.blocked {
+				t.Errorf("got %q, %v, want %q, %v", got, blocked, tt.want, tt.blocked)
+			}
+		})
+	}
+}
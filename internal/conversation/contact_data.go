@@ -0,0 +1,150 @@
+package conversation
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/abhinavxd/libredesk/internal/conversation/models"
+	"github.com/abhinavxd/libredesk/internal/envelope"
+)
+
+// ExportContactData returns a JSON bundle of the personal data of a contact: the profile, and the conversations
+// with their messages and attachments. Private notes and activity messages aren't included.
+func (m *Manager) ExportContactData(contactID int) ([]byte, error) {
+	contact, err := m.userStore.GetContact(contactID, "")
+	if err != nil {
+		return nil, err
+	}
+
+	export := models.ContactExport{
+		ExportedAt: time.Now(),
+		Contact: models.ContactExportProfile{
+			ID:                     contact.ID,
+			CreatedAt:              contact.CreatedAt,
+			FirstName:              contact.FirstName,
+			LastName:               contact.LastName,
+			Email:                  contact.Email,
+			PhoneNumber:            contact.PhoneNumber,
+			PhoneNumberCallingCode: contact.PhoneNumberCallingCode,
+			AvatarURL:              contact.AvatarURL,
+			CustomAttributes:       contact.CustomAttributes,
+		},
+		Conversations: []models.ContactExportConversation{},
+	}
+
+	var (
+		messages    []models.ContactExportMessage
+		attachments []models.ContactExportAttachment
+	)
+	if err := m.q.GetContactExportConversations.Select(&export.Conversations, contactID); err != nil {
+		m.lo.Error("error fetching contact conversations for export", "contact_id", contactID, "error", err)
+		return nil, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.conversation}"), nil)
+	}
+	if err := m.q.GetContactExportMessages.Select(&messages, contactID); err != nil {
+		m.lo.Error("error fetching contact messages for export", "contact_id", contactID, "error", err)
+		return nil, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.message}"), nil)
+	}
+	if err := m.q.GetContactExportMedia.Select(&attachments, contactID); err != nil {
+		m.lo.Error("error fetching contact media for export", "contact_id", contactID, "error", err)
+		return nil, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.media}"), nil)
+	}
+
+	// Group the attachments by message and the messages by conversation.
+	msgAttachments := make(map[int][]models.ContactExportAttachment)
+	for _, a := range attachments {
+		content, err := m.readBlob(a.BlobName)
+		if err != nil {
+			m.lo.Error("error reading attachment for export", "contact_id", contactID, "media_uuid", a.UUID, "error", err)
+			return nil, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.media}"), nil)
+		}
+		a.Content = content
+		msgAttachments[a.MessageID] = append(msgAttachments[a.MessageID], a)
+	}
+	convMessages := make(map[int][]models.ContactExportMessage)
+	for _, msg := range messages {
+		msg.Attachments = msgAttachments[msg.ID]
+		if msg.Attachments == nil {
+			msg.Attachments = []models.ContactExportAttachment{}
+		}
+		convMessages[msg.ConversationID] = append(convMessages[msg.ConversationID], msg)
+	}
+	for i := range export.Conversations {
+		export.Conversations[i].Messages = convMessages[export.Conversations[i].ID]
+		if export.Conversations[i].Messages == nil {
+			export.Conversations[i].Messages = []models.ContactExportMessage{}
+		}
+	}
+
+	b, err := json.Marshal(export)
+	if err != nil {
+		m.lo.Error("error marshalling contact export", "contact_id", contactID, "error", err)
+		return nil, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.contact}"), nil)
+	}
+	return b, nil
+}
+
+// EraseContactData anonymizes a contact and erases the personal data in their conversations. The content of the
+// messages is blanked and their attachments are deleted from the media store, the conversations and messages are
+// kept so reports and SLA metrics are unchanged.
+func (m *Manager) EraseContactData(contactID int) error {
+	if _, err := m.userStore.GetContact(contactID, ""); err != nil {
+		return err
+	}
+
+	var mediaUUIDs []string
+	if err := m.q.GetContactMediaUUIDs.Select(&mediaUUIDs, contactID); err != nil {
+		m.lo.Error("error fetching contact media", "contact_id", contactID, "error", err)
+		return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorDeleting", "name", "{globals.terms.contact}"), nil)
+	}
+
+	tx, err := m.db.BeginTxx(context.Background(), nil)
+	if err != nil {
+		m.lo.Error("error starting transaction", "error", err)
+		return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorDeleting", "name", "{globals.terms.contact}"), nil)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Stmtx(m.q.EraseContactMessages).Exec(contactID); err != nil {
+		m.lo.Error("error erasing contact messages", "contact_id", contactID, "error", err)
+		return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorDeleting", "name", "{globals.terms.contact}"), nil)
+	}
+	if _, err := tx.Stmtx(m.q.EraseContactConversations).Exec(contactID); err != nil {
+		m.lo.Error("error erasing contact conversations", "contact_id", contactID, "error", err)
+		return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorDeleting", "name", "{globals.terms.contact}"), nil)
+	}
+	if _, err := tx.Stmtx(m.q.EraseContact).Exec(contactID, m.i18n.T("contact.erasedName")); err != nil {
+		m.lo.Error("error erasing contact", "contact_id", contactID, "error", err)
+		return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorDeleting", "name", "{globals.terms.contact}"), nil)
+	}
+	if err := tx.Commit(); err != nil {
+		m.lo.Error("error committing contact erasure", "contact_id", contactID, "error", err)
+		return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorDeleting", "name", "{globals.terms.contact}"), nil)
+	}
+
+	// Delete the attachments once the erasure is committed, a failed delete leaves the file
+	// behind but the erasure can be run again to retry.
+	var failed int
+	for _, mediaUUID := range mediaUUIDs {
+		if err := m.mediaStore.Delete(mediaUUID); err != nil {
+			m.lo.Error("error deleting contact media", "contact_id", contactID, "media_uuid", mediaUUID, "error", err)
+			failed++
+		}
+	}
+	if failed > 0 {
+		return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorDeleting", "name", "{globals.terms.media}"), nil)
+	}
+	m.lo.Info("contact data erased", "contact_id", contactID, "media", len(mediaUUIDs))
+	return nil
+}
+
+// readBlob returns the content of a file in the media store.
+func (m *Manager) readBlob(name string) ([]byte, error) {
+	rd, err := m.mediaStore.GetReader(name)
+	if err != nil {
+		return nil, err
+	}
+	defer rd.Close()
+	return io.ReadAll(rd)
+}
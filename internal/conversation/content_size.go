@@ -0,0 +1,171 @@
+package conversation
+
+import (
+	"html"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/abhinavxd/libredesk/internal/attachment"
+	"github.com/abhinavxd/libredesk/internal/conversation/models"
+	"github.com/abhinavxd/libredesk/internal/envelope"
+)
+
+const (
+	// Handling of message content over the maximum size, see Opts.OversizedContent.
+	OversizedContentTruncate = "truncate"
+	OversizedContentReject   = "reject"
+)
+
+// enforceContentSize truncates the content of a message over the maximum content size with a marker, or rejects the
+// message if oversized content is configured to be rejected.
+func (m *Manager) enforceContentSize(message *models.Message) error {
+	if m.maxContentSize <= 0 || len(message.Content) <= m.maxContentSize {
+		return nil
+	}
+	if m.rejectOversizedContent {
+		m.lo.Warn("rejecting message over the maximum content size", "size", len(message.Content), "max_size", m.maxContentSize,
+			"conversation_uuid", message.ConversationUUID)
+		return envelope.NewError(envelope.InputError, m.i18n.Ts("conversation.contentTooLarge", "max", strconv.Itoa(m.maxContentSize)), nil)
+	}
+	m.lo.Warn("truncating message over the maximum content size", "size", len(message.Content), "max_size", m.maxContentSize,
+		"conversation_uuid", message.ConversationUUID)
+	message.Content = truncateContent(message.Content, message.ContentType, m.maxContentSize, m.i18n.T("conversation.contentTruncated"))
+	return nil
+}
+
+// offloadOversizedContent moves the content of an incoming message over the maximum content size to an attachment,
+// the message keeps the start of the content with a marker pointing to the attachment.
+func (m *Manager) offloadOversizedContent(message *models.Message) {
+	if m.maxContentSize <= 0 || len(message.Content) <= m.maxContentSize {
+		return
+	}
+	m.lo.Warn("moving incoming message content over the maximum content size to an attachment", "size", len(message.Content),
+		"max_size", m.maxContentSize, "message_source_id", message.SourceID.String)
+
+	var file = attachment.Attachment{
+		Name:        "message.html",
+		Content:     []byte(message.Content),
+		ContentType: "text/html",
+		Size:        len(message.Content),
+		Disposition: attachment.DispositionAttachment,
+	}
+	if message.ContentType == models.ContentTypeText {
+		file.Name = "message.txt"
+		file.ContentType = "text/plain"
+	}
+	message.Attachments = append(message.Attachments, file)
+	message.Content = truncateContent(message.Content, message.ContentType, m.maxContentSize, m.i18n.Ts("conversation.contentOffloaded", "name", file.Name))
+}
+
+// truncateContent cuts the content so that it fits in maxSize bytes along with the marker, on a character boundary.
+// HTML content isn't cut inside a tag or a character reference and the elements left open are closed. The marker is
+// appended as a paragraph to HTML content and on its own line to text content, it's left out if it doesn't fit.
+func truncateContent(content, contentType string, maxSize int, marker string) string {
+	if len(content) <= maxSize {
+		return content
+	}
+	if contentType == models.ContentTypeText {
+		marker = "\n\n" + marker
+	} else {
+		marker = "<p><em>" + html.EscapeString(marker) + "</em></p>"
+	}
+	if len(marker) >= maxSize {
+		marker = ""
+	}
+
+	n := maxSize - len(marker)
+	for {
+		for n > 0 && !utf8.RuneStart(content[n]) {
+			n--
+		}
+		if contentType == models.ContentTypeText {
+			return content[:n] + marker
+		}
+
+		// Closing the open elements takes room too, cut further back until they fit.
+		n = htmlCutPoint(content, n)
+		closing := closingTags(content[:n])
+		if over := n + len(closing) + len(marker) - maxSize; over > 0 {
+			n = max(n-over, 0)
+			continue
+		}
+		return content[:n] + closing + marker
+	}
+}
+
+// htmlCutPoint moves a cut point of HTML content back to the start of the tag or character reference it falls in.
+func htmlCutPoint(content string, n int) int {
+	if i := strings.LastIndexByte(content[:n], '<'); i >= 0 && !strings.Contains(content[i:n], ">") {
+		n = i
+	}
+	if i := strings.LastIndexByte(content[:n], '&'); i >= 0 && isCharRefPrefix(content[i+1:n]) {
+		n = i
+	}
+	return n
+}
+
+// isCharRefPrefix reports whether s can be the start of a character reference after its ampersand, e.g. `amp` or `#3`.
+func isCharRefPrefix(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '#' && i == 0) {
+			return false
+		}
+	}
+	return true
+}
+
+// voidElements are the HTML elements without a closing tag.
+var voidElements = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true, "hr": true, "img": true, "input": true,
+	"link": true, "meta": true, "source": true, "track": true, "wbr": true,
+}
+
+// closingTags returns the closing tags of the elements left open in an HTML fragment, innermost first.
+func closingTags(fragment string) string {
+	var open []string
+	for rest := fragment; ; {
+		i := strings.IndexByte(rest, '<')
+		if i < 0 {
+			break
+		}
+		j := strings.IndexByte(rest[i:], '>')
+		if j < 0 {
+			break
+		}
+		tag := rest[i+1 : i+j]
+		rest = rest[i+j+1:]
+
+		// Comments, doctypes and self-closing tags don't open elements.
+		if strings.HasPrefix(tag, "!") || strings.HasSuffix(tag, "/") {
+			continue
+		}
+		isClosing := strings.HasPrefix(tag, "/")
+		fields := strings.Fields(strings.TrimPrefix(tag, "/"))
+		if len(fields) == 0 {
+			continue
+		}
+		name := strings.ToLower(fields[0])
+		if voidElements[name] {
+			continue
+		}
+		if !isClosing {
+			open = append(open, name)
+			continue
+		}
+		// Close the element along with the ones left open inside it.
+		for k := len(open) - 1; k >= 0; k-- {
+			if open[k] == name {
+				open = open[:k]
+				break
+			}
+		}
+	}
+
+	var b strings.Builder
+	for i := len(open) - 1; i >= 0; i-- {
+		b.WriteString("</" + open[i] + ">")
+	}
+	return b.String()
+}
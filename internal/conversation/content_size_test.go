@@ -0,0 +1,40 @@
+package conversation
+
+import (
+	"testing"
+
+	"github.com/abhinavxd/libredesk/internal/conversation/models"
+)
+
+func TestTruncateContent(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		contentType string
+		maxSize     int
+		marker      string
+		expected    string
+	}{
+		{name: "under the limit", content: "Hello", contentType: models.ContentTypeText, maxSize: 10, marker: "[cut]", expected: "Hello"},
+		{name: "text", content: "Hello world, how are you?", contentType: models.ContentTypeText, maxSize: 16, marker: "[cut]", expected: "Hello wor\n\n[cut]"},
+		{name: "html", content: "<p>Hello world, how are you doing today?</p>", contentType: models.ContentTypeHTML, maxSize: 40, marker: "a & b", expected: "<p>Hello wo</p><p><em>a &amp; b</em></p>"},
+		{name: "html cut inside a tag", content: "<p>Hello</p><p><a href=\"https://example.com\">link</a></p>", contentType: models.ContentTypeHTML, maxSize: 40, marker: "[cut]", expected: "<p>Hello</p><p></p><p><em>[cut]</em></p>"},
+		{name: "html cut inside a character reference", content: "<p>Fish &amp; chips, and a lot more text after it</p>", contentType: models.ContentTypeHTML, maxSize: 36, marker: "[cut]", expected: "<p>Fish </p><p><em>[cut]</em></p>"},
+		{name: "html ampersand", content: "<div>Q & A session, with more text here</div>", contentType: models.ContentTypeHTML, maxSize: 37, marker: "[cut]", expected: "<div>Q & A</div><p><em>[cut]</em></p>"},
+		{name: "html nested and void elements", content: "<div><p>Hi<br><b>there</b> and <i>more text that goes on and on</i></p></div>", contentType: models.ContentTypeHTML, maxSize: 71, marker: "[cut]", expected: "<div><p>Hi<br><b>there</b> and <i>mo</i></p></div><p><em>[cut]</em></p>"},
+		{name: "cut inside a character", content: "héllo", contentType: models.ContentTypeText, maxSize: 4, marker: "", expected: "h\n\n"},
+		{name: "marker doesn't fit", content: "Hello world", contentType: models.ContentTypeText, maxSize: 5, marker: "[truncated]", expected: "Hello"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := truncateContent(tt.content, tt.contentType, tt.maxSize, tt.marker)
+			if got != tt.expected {
+				t.Errorf("got %q, want %q", got, tt.expected)
+			}
+			if len(got) > tt.maxSize {
+				t.Errorf("got %d bytes, over the maximum of %d", len(got), tt.maxSize)
+			}
+		})
+	}
+}
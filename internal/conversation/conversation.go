@@ -13,6 +13,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/abhinavxd/libredesk/internal/automation"
@@ -21,13 +22,16 @@ import (
 	pmodels "github.com/abhinavxd/libredesk/internal/conversation/priority/models"
 	smodels "github.com/abhinavxd/libredesk/internal/conversation/status/models"
 	csatModels "github.com/abhinavxd/libredesk/internal/csat/models"
+	caModels "github.com/abhinavxd/libredesk/internal/custom_attribute/models"
 	"github.com/abhinavxd/libredesk/internal/dbutil"
 	"github.com/abhinavxd/libredesk/internal/envelope"
 	"github.com/abhinavxd/libredesk/internal/inbox"
 	imodels "github.com/abhinavxd/libredesk/internal/inbox/models"
 	mmodels "github.com/abhinavxd/libredesk/internal/media/models"
 	notifier "github.com/abhinavxd/libredesk/internal/notification"
+	npmodels "github.com/abhinavxd/libredesk/internal/notification/preference/models"
 	slaModels "github.com/abhinavxd/libredesk/internal/sla/models"
+	"github.com/abhinavxd/libredesk/internal/stringutil"
 	tmodels "github.com/abhinavxd/libredesk/internal/team/models"
 	"github.com/abhinavxd/libredesk/internal/template"
 	umodels "github.com/abhinavxd/libredesk/internal/user/models"
@@ -43,13 +47,18 @@ var (
 	//go:embed queries.sql
 	efs                                  embed.FS
 	errConversationNotFound              = errors.New("conversation not found")
-	conversationsAllowedFields = []string{"status_id", "priority_id", "assigned_team_id", "assigned_user_id", "inbox_id", "last_message_at", "created_at", "waiting_since", "next_sla_deadline_at", "priority_id"}
+	conversationsAllowedFields = []string{"status_id", "priority_id", "assigned_team_id", "assigned_user_id", "assigned_queue_id", "inbox_id", "last_message_at", "created_at", "waiting_since", "next_sla_deadline_at", "priority_id"}
 	conversationStatusAllowedFields     = []string{"id", "name"}
+	conversationsListAllowedFields       = dbutil.AllowedFields{
+		"conversations":         conversationsAllowedFields,
+		"conversation_statuses": conversationStatusAllowedFields,
+	}
 	csatReplyMessage                     = "Please rate your experience with us: <a href=\"%s\">Rate now</a>"
 )
 
 const (
 	conversationsListMaxPageSize = 100
+	maxSubjectLen                = 998
 )
 
 // Manager handles the operations related to conversations
@@ -64,6 +73,7 @@ type Manager struct {
 	slaStore                   slaStore
 	settingsStore              settingsStore
 	csatStore                  csatStore
+	customAttributeStore       customAttributeStore
 	notifier                   *notifier.Service
 	lo                         *logf.Logger
 	db                         *sqlx.DB
@@ -71,9 +81,28 @@ type Manager struct {
 	automation                 *automation.Engine
 	wsHub                      *ws.Hub
 	template                   *template.Manager
+	translator                 translator
 	incomingMessageQueue       chan models.IncomingMessage
 	outgoingMessageQueue       chan models.Message
 	outgoingProcessingMessages sync.Map
+	statusCounts               statusCountsCache
+	failedRetries              sync.Map
+	sendLimiter                *inboxSendLimiter
+	outgoingScaler             outgoingWorkerScaler
+	extraSenderWorkers         atomic.Int32
+	contactIncomingLimiter     *incomingLimiter
+	inboxIncomingLimiter       *incomingLimiter
+	spilling                   atomic.Bool
+	lastMessagePreviewLen      int
+	disableEmailTracking       bool
+	messageRetryMaxAge         time.Duration
+	blockedRecipients          []string
+	resetFirstReplyOnReassign  bool
+	processingTimeout          time.Duration
+	outgoingPriorityOrder      bool
+	outgoingPriorityAgeBoost   time.Duration
+	maxContentSize             int
+	rejectOversizedContent     bool
 	closed                     bool
 	closedMu                   sync.RWMutex
 	wg                         sync.WaitGroup
@@ -81,39 +110,46 @@ type Manager struct {
 
 type slaStore interface {
 	ApplySLA(startTime time.Time, conversationID, assignedTeamID, slaID int) (slaModels.SLAPolicy, error)
+	BusinessMinutesSince(start time.Time, assignedTeamID int) (int, error)
 }
 
 type statusStore interface {
 	Get(int) (smodels.Status, error)
+	GetByName(string) (smodels.Status, error)
 }
 
 type priorityStore interface {
 	Get(int) (pmodels.Priority, error)
+	GetByName(string) (pmodels.Priority, error)
 }
 
 type teamStore interface {
 	Get(int) (tmodels.Team, error)
-	UserBelongsToTeam(userID, teamID int) (bool, error)
+	UserBelongsToTeam(teamID, userID int) (bool, error)
 }
 
 type userStore interface {
 	GetAgent(int, string) (umodels.User, error)
 	GetSystemUser() (umodels.User, error)
+	GetContact(int, string) (umodels.User, error)
 	CreateContact(user *umodels.User) error
 }
 
 type mediaStore interface {
-	GetBlob(name string) ([]byte, error)
-	Attach(id int, model string, modelID int) error
+	GetReader(name string) (io.ReadCloser, error)
 	GetByModel(id int, model string) ([]mmodels.Media, error)
+	UpdateDisposition(id int, disposition string) error
 	ContentIDExists(contentID string) (bool, string, error)
 	Upload(fileName, contentType string, content io.ReadSeeker) (string, error)
 	UploadAndInsert(fileName, contentType, contentID string, modelType null.String, modelID null.Int, content io.ReadSeeker, fileSize int, disposition null.String, meta []byte) (mmodels.Media, error)
+	Delete(name string) error
 }
 
 type inboxStore interface {
 	Get(int) (inbox.Inbox, error)
 	GetDBRecord(int) (imodels.Inbox, error)
+	RecordSend(inboxID int, err error)
+	AllowSend(inboxID int) bool
 }
 
 type settingsStore interface {
@@ -122,7 +158,13 @@ type settingsStore interface {
 
 type csatStore interface {
 	Create(conversationID int) (csatModels.CSATResponse, error)
-	MakePublicURL(appBaseURL, uuid string) string
+	MakePublicURL(appBaseURL string, csat csatModels.CSATResponse) string
+	ShouldSend(conversation models.Conversation, status string) (bool, error)
+}
+
+type customAttributeStore interface {
+	ValidateValues(appliesTo string, values map[string]any) error
+	ParseValue(appliesTo, key, value string) (any, error)
 }
 
 // Opts holds the options for creating a new Manager.
@@ -131,6 +173,44 @@ type Opts struct {
 	Lo                       *logf.Logger
 	OutgoingMessageQueueSize int
 	IncomingMessageQueueSize int
+	// MaxConcurrentSendsPerInbox caps the number of sender workers sending through a single inbox at once, 0 is unlimited.
+	MaxConcurrentSendsPerInbox int
+	// OutgoingWorkersMax is the maximum number of sender workers, extra workers on top of the ones passed to Run are
+	// spawned while the outgoing queue has more than OutgoingQueueTargetDepth messages and exit after being idle for
+	// OutgoingWorkerIdleTimeout. Scaling is disabled if it's not above the number of workers passed to Run.
+	OutgoingWorkersMax        int
+	OutgoingQueueTargetDepth  int
+	OutgoingWorkerIdleTimeout time.Duration
+	// LastMessagePreviewLen is the number of characters of the last message stored on the conversation for previews.
+	LastMessagePreviewLen int
+	// IncomingContactRateLimit and IncomingInboxRateLimit cap the incoming messages per contact and per inbox every
+	// IncomingRateWindow, senders over the limit are blocked for IncomingBlockDuration. 0 disables the limit.
+	IncomingContactRateLimit int
+	IncomingInboxRateLimit   int
+	IncomingRateWindow       time.Duration
+	IncomingBlockDuration    time.Duration
+	// DisableEmailTracking turns off open and click tracking of outgoing emails for all inboxes.
+	DisableEmailTracking bool
+	// MessageRetryMaxAge is the age after which failed messages are no longer requeued by RetryFailedMessages.
+	MessageRetryMaxAge time.Duration
+	// BlockedRecipients are address patterns outgoing messages are never sent to, e.g. `noreply@*`.
+	BlockedRecipients []string
+	// FirstReplyOnReassign is `keep` to measure the first response time from the creation of the conversation, or
+	// `reset` to restart it when the conversation is reassigned to another team or agent before the first reply.
+	FirstReplyOnReassign string
+	// ProcessingTimeout is the time after which an outgoing message still being sent is considered stuck and released
+	// to be sent again. 0 disables the periodic sweep, sends interrupted by a restart are always reconciled on startup.
+	ProcessingTimeout time.Duration
+	// OutgoingPriorityOrder sends the pending outgoing messages of high priority conversations first instead of in
+	// the order they were created. Messages waiting for OutgoingPriorityAgeBoost are raised by one priority level, so
+	// the ones of low priority conversations aren't held back for long, 0 disables the boost.
+	OutgoingPriorityOrder    bool
+	OutgoingPriorityAgeBoost time.Duration
+	// MaxContentSize is the maximum size in bytes of the content of a message, 0 is unlimited. Content over it is
+	// truncated with a marker, or the message is rejected if OversizedContent is `reject`. The content of incoming
+	// messages over it is moved to an attachment.
+	MaxContentSize   int
+	OversizedContent string
 }
 
 // New initializes a new conversation Manager.
@@ -147,6 +227,7 @@ func New(
 	mediaStore mediaStore,
 	settingsStore settingsStore,
 	csatStore csatStore,
+	customAttributeStore customAttributeStore,
 	automation *automation.Engine,
 	template *template.Manager,
 	opts Opts) (*Manager, error) {
@@ -156,6 +237,18 @@ func New(
 		return nil, err
 	}
 
+	if opts.LastMessagePreviewLen <= 0 {
+		opts.LastMessagePreviewLen = defaultLastMessagePreviewLen
+	}
+
+	// Content over the maximum size is truncated unless set to be rejected.
+	switch opts.OversizedContent {
+	case "", OversizedContentTruncate, OversizedContentReject:
+	default:
+		return nil, fmt.Errorf("invalid oversized content handling %q, expected %q or %q", opts.OversizedContent,
+			OversizedContentTruncate, OversizedContentReject)
+	}
+
 	c := &Manager{
 		q:                          q,
 		wsHub:                      wsHub,
@@ -167,6 +260,7 @@ func New(
 		mediaStore:                 mediaStore,
 		settingsStore:              settingsStore,
 		csatStore:                  csatStore,
+		customAttributeStore:       customAttributeStore,
 		slaStore:                   slaStore,
 		statusStore:                statusStore,
 		priorityStore:              priorityStore,
@@ -177,7 +271,24 @@ func New(
 		incomingMessageQueue:       make(chan models.IncomingMessage, opts.IncomingMessageQueueSize),
 		outgoingMessageQueue:       make(chan models.Message, opts.OutgoingMessageQueueSize),
 		outgoingProcessingMessages: sync.Map{},
-	}
+		sendLimiter:                newInboxSendLimiter(opts.MaxConcurrentSendsPerInbox),
+		outgoingScaler:             newOutgoingWorkerScaler(opts.OutgoingWorkersMax, opts.OutgoingQueueTargetDepth, opts.OutgoingWorkerIdleTimeout),
+		contactIncomingLimiter:     newIncomingLimiter(opts.IncomingContactRateLimit, opts.IncomingRateWindow, opts.IncomingBlockDuration),
+		inboxIncomingLimiter:       newIncomingLimiter(opts.IncomingInboxRateLimit, opts.IncomingRateWindow, opts.IncomingBlockDuration),
+		lastMessagePreviewLen:      opts.LastMessagePreviewLen,
+		disableEmailTracking:       opts.DisableEmailTracking,
+		messageRetryMaxAge:         opts.MessageRetryMaxAge,
+		blockedRecipients:          opts.BlockedRecipients,
+		resetFirstReplyOnReassign:  opts.FirstReplyOnReassign == FirstReplyOnReassignReset,
+		processingTimeout:          opts.ProcessingTimeout,
+		outgoingPriorityOrder:      opts.OutgoingPriorityOrder,
+		outgoingPriorityAgeBoost:   opts.OutgoingPriorityAgeBoost,
+		maxContentSize:             opts.MaxContentSize,
+		rejectOversizedContent:     opts.OversizedContent == OversizedContentReject,
+	}
+
+	// Spilled over messages from a previous run are drained before new messages are queued.
+	c.spilling.Store(true)
 
 	return c, nil
 }
@@ -185,24 +296,65 @@ func New(
 type queries struct {
 	// Conversation queries.
 	GetToAddress                       *sqlx.Stmt `query:"get-to-address"`
+	GetLatestIncomingRecipients        *sqlx.Stmt `query:"get-latest-incoming-recipients"`
+	SetMessageTranslation              *sqlx.Stmt `query:"set-message-translation"`
 	GetConversationUUID                *sqlx.Stmt `query:"get-conversation-uuid"`
 	GetConversation                    *sqlx.Stmt `query:"get-conversation"`
 	GetConversationsCreatedAfter       *sqlx.Stmt `query:"get-conversations-created-after"`
+	GetConversationsToAutoClose        *sqlx.Stmt `query:"get-conversations-to-auto-close"`
 	GetUnassignedConversations         *sqlx.Stmt `query:"get-unassigned-conversations"`
 	GetConversations                   string     `query:"get-conversations"`
+	GetConversationStatusCounts        string     `query:"get-conversation-status-counts"`
+	GetConversationInboxCounts         string     `query:"get-conversation-inbox-counts"`
 	GetContactConversations            *sqlx.Stmt `query:"get-contact-conversations"`
+	GetContactPastConversationCount    *sqlx.Stmt `query:"get-contact-past-conversation-count"`
 	GetConversationParticipants        *sqlx.Stmt `query:"get-conversation-participants"`
 	GetUserActiveConversationsCount    *sqlx.Stmt `query:"get-user-active-conversations-count"`
+	GetTeamAgentsWorkload              *sqlx.Stmt `query:"get-team-agents-workload"`
+	GetAgentWorkload                   *sqlx.Stmt `query:"get-agent-workload"`
+	GetAssigneeSuggestions             *sqlx.Stmt `query:"get-assignee-suggestions"`
 	UpdateConversationFirstReplyAt     *sqlx.Stmt `query:"update-conversation-first-reply-at"`
+	InsertConversationAssignment       *sqlx.Stmt `query:"insert-conversation-assignment"`
+	UpdateAssignmentFirstReplyAt       *sqlx.Stmt `query:"update-assignment-first-reply-at"`
 	UpdateConversationLastReplyAt      *sqlx.Stmt `query:"update-conversation-last-reply-at"`
 	UpdateConversationAssigneeLastSeen *sqlx.Stmt `query:"update-conversation-assignee-last-seen"`
+	LockAgentForAssignment             *sqlx.Stmt `query:"lock-agent-for-assignment"`
 	UpdateConversationAssignedUser     *sqlx.Stmt `query:"update-conversation-assigned-user"`
 	UpdateConversationAssignedTeam     *sqlx.Stmt `query:"update-conversation-assigned-team"`
+	RouteConversationToQueue           *sqlx.Stmt `query:"route-conversation-to-queue"`
+	PullQueueConversation              *sqlx.Stmt `query:"pull-queue-conversation"`
 	UpdateConversationCustomAttributes *sqlx.Stmt `query:"update-conversation-custom-attributes"`
+	SetConversationCustomAttribute     *sqlx.Stmt `query:"set-conversation-custom-attribute"`
 	UpdateConversationPriority         *sqlx.Stmt `query:"update-conversation-priority"`
 	UpdateConversationStatus           *sqlx.Stmt `query:"update-conversation-status"`
 	UpdateConversationLastMessage      *sqlx.Stmt `query:"update-conversation-last-message"`
 	InsertConversationParticipant      *sqlx.Stmt `query:"insert-conversation-participant"`
+	DeleteConversationParticipant      *sqlx.Stmt `query:"delete-conversation-participant"`
+	InsertConversationWatcher          *sqlx.Stmt `query:"insert-conversation-watcher"`
+	DeleteConversationWatcher          *sqlx.Stmt `query:"delete-conversation-watcher"`
+	GetConversationWatchers            *sqlx.Stmt `query:"get-conversation-watchers"`
+	GetWatchDigestActivity             *sqlx.Stmt `query:"get-watch-digest-activity"`
+	UpsertWatchDigestSentAt            *sqlx.Stmt `query:"upsert-watch-digest-sent-at"`
+	InsertConversationReminder         *sqlx.Stmt `query:"insert-conversation-reminder"`
+	GetUserReminders                   *sqlx.Stmt `query:"get-user-reminders"`
+	DeleteConversationReminder         *sqlx.Stmt `query:"delete-conversation-reminder"`
+	GetDueReminders                    *sqlx.Stmt `query:"get-due-reminders"`
+	MarkReminderNotified               *sqlx.Stmt `query:"mark-reminder-notified"`
+	UpsertMessageTrackingLink          *sqlx.Stmt `query:"upsert-message-tracking-link"`
+	GetMessageTrackingLink             *sqlx.Stmt `query:"get-message-tracking-link"`
+	InsertMessageOpenEvent             *sqlx.Stmt `query:"insert-message-open-event"`
+	InsertMessageClickEvent            *sqlx.Stmt `query:"insert-message-click-event"`
+	GetMessageTrackingEvents           *sqlx.Stmt `query:"get-message-tracking-events"`
+	GetUnassignedToEscalate            *sqlx.Stmt `query:"get-unassigned-conversations-to-escalate"`
+	SetUnassignedEscalated             *sqlx.Stmt `query:"set-unassigned-escalated"`
+	GetInboxDefaultAssignee            *sqlx.Stmt `query:"get-inbox-default-assignee"`
+	SetManuallyAssigned                *sqlx.Stmt `query:"set-conversation-manually-assigned"`
+	UpdateConversationLocale           *sqlx.Stmt `query:"update-conversation-locale"`
+	UpdateConversationSubject          *sqlx.Stmt `query:"update-conversation-subject"`
+	SetConversationsReadState          *sqlx.Stmt `query:"set-conversations-read-state"`
+	GetMessageStatusHistory            *sqlx.Stmt `query:"get-message-status-history"`
+	GetConversationFailedMessages      *sqlx.Stmt `query:"get-conversation-failed-messages"`
+	GetMergeCandidates                 *sqlx.Stmt `query:"get-merge-candidates"`
 	InsertConversation                 *sqlx.Stmt `query:"insert-conversation"`
 	AddConversationTags                *sqlx.Stmt `query:"add-conversation-tags"`
 	SetConversationTags                *sqlx.Stmt `query:"set-conversation-tags"`
@@ -212,6 +364,19 @@ type queries struct {
 	ReOpenConversation                 *sqlx.Stmt `query:"re-open-conversation"`
 	UnsnoozeAll                        *sqlx.Stmt `query:"unsnooze-all"`
 	DeleteConversation                 *sqlx.Stmt `query:"delete-conversation"`
+	DeleteConversationCSATResponses    *sqlx.Stmt `query:"delete-conversation-csat-responses"`
+	DeleteConversationAppliedSLAs      *sqlx.Stmt `query:"delete-conversation-applied-slas"`
+	SoftDeleteConversation             *sqlx.Stmt `query:"soft-delete-conversation"`
+	RestoreConversation                *sqlx.Stmt `query:"restore-conversation"`
+	GetConversationsToPurge            *sqlx.Stmt `query:"get-conversations-to-purge"`
+	GetConversationMediaUUIDs          *sqlx.Stmt `query:"get-conversation-media-uuids"`
+	GetContactExportConversations      *sqlx.Stmt `query:"get-contact-export-conversations"`
+	GetContactExportMessages           *sqlx.Stmt `query:"get-contact-export-messages"`
+	GetContactExportMedia              *sqlx.Stmt `query:"get-contact-export-media"`
+	GetContactMediaUUIDs               *sqlx.Stmt `query:"get-contact-media-uuids"`
+	EraseContactMessages               *sqlx.Stmt `query:"erase-contact-messages"`
+	EraseContactConversations          *sqlx.Stmt `query:"erase-contact-conversations"`
+	EraseContact                       *sqlx.Stmt `query:"erase-contact"`
 	RemoveConversationAssignee         *sqlx.Stmt `query:"remove-conversation-assignee"`
 
 	// Dashboard queries.
@@ -220,27 +385,69 @@ type queries struct {
 
 	// Message queries.
 	GetMessage                         *sqlx.Stmt `query:"get-message"`
+	GetLatestIncomingMessage           *sqlx.Stmt `query:"get-latest-incoming-message"`
+	GetUnansweredSince                 *sqlx.Stmt `query:"get-unanswered-since"`
 	GetMessages                        string     `query:"get-messages"`
 	GetPendingMessages                 *sqlx.Stmt `query:"get-pending-messages"`
 	GetMessageSourceIDs                *sqlx.Stmt `query:"get-message-source-ids"`
 	GetConversationUUIDFromMessageUUID *sqlx.Stmt `query:"get-conversation-uuid-from-message-uuid"`
 	InsertMessage                      *sqlx.Stmt `query:"insert-message"`
+	AttachUnlinkedMedia                *sqlx.Stmt `query:"attach-unlinked-media"`
+	LockMessage                        *sqlx.Stmt `query:"lock-message"`
+	SetInboxDispatchPaused             *sqlx.Stmt `query:"set-inbox-dispatch-paused"`
+	IsInboxDispatchPaused              *sqlx.Stmt `query:"is-inbox-dispatch-paused"`
+	GetDispatchPausedInboxIDs          *sqlx.Stmt `query:"get-dispatch-paused-inbox-ids"`
 	UpdateMessageStatus                *sqlx.Stmt `query:"update-message-status"`
+	GetOutgoingMessageBySourceID       *sqlx.Stmt `query:"get-outgoing-message-by-source-id"`
+	InsertDeliveryReport               *sqlx.Stmt `query:"insert-delivery-report"`
+	DeliveryReportExists               *sqlx.Stmt `query:"delivery-report-exists"`
+	MarkMessageSending                 *sqlx.Stmt `query:"mark-message-sending"`
+	ReleaseInterruptedMessages         *sqlx.Stmt `query:"release-interrupted-messages"`
 	MessageExistsBySourceID            *sqlx.Stmt `query:"message-exists-by-source-id"`
+	GetConversationIDByReferenceNumber *sqlx.Stmt `query:"get-conversation-id-by-reference-number"`
 	GetConversationByMessageID         *sqlx.Stmt `query:"get-conversation-by-message-id"`
+
+	// Draft queries.
+	UpsertDraft       *sqlx.Stmt `query:"upsert-draft"`
+	GetDraft          *sqlx.Stmt `query:"get-draft"`
+	DeleteDraft       *sqlx.Stmt `query:"delete-draft"`
+	DeleteStaleDrafts *sqlx.Stmt `query:"delete-stale-drafts"`
+
+	// Blocked incoming message queries.
+	InsertBlockedIncomingMessage *sqlx.Stmt `query:"insert-blocked-incoming-message"`
+	BlockedIncomingMessageExists *sqlx.Stmt `query:"blocked-incoming-message-exists"`
+	GetBlockedIncomingPayload    *sqlx.Stmt `query:"get-blocked-incoming-message-payload"`
+	GetBlockedIncomingMessages   *sqlx.Stmt `query:"get-blocked-incoming-messages"`
+	DeleteBlockedIncomingMessage *sqlx.Stmt `query:"delete-blocked-incoming-message"`
+
+	// Incoming message spillover queries.
+	InsertIncomingSpillover *sqlx.Stmt `query:"insert-incoming-spillover"`
+	GetIncomingSpillover    *sqlx.Stmt `query:"get-incoming-spillover"`
+	DeleteIncomingSpillover *sqlx.Stmt `query:"delete-incoming-spillover"`
 }
 
 // CreateConversation creates a new conversation and returns its ID and UUID.
 func (c *Manager) CreateConversation(contactID, contactChannelID, inboxID int, lastMessage string, lastMessageAt time.Time, subject string, appendRefNumToSubject bool) (int, string, error) {
 	var (
-		id     int
-		uuid   string
-		prefix string
+		id       int
+		uuid     string
+		prefix   string
+		priority string
 	)
-	if err := c.q.InsertConversation.QueryRow(contactID, contactChannelID, models.StatusOpen, inboxID, lastMessage, lastMessageAt, subject, prefix, appendRefNumToSubject).Scan(&id, &uuid); err != nil {
+	if err := c.q.InsertConversation.QueryRow(contactID, contactChannelID, models.StatusOpen, inboxID, lastMessage, lastMessageAt, subject, prefix, appendRefNumToSubject).Scan(&id, &uuid, &priority); err != nil {
 		c.lo.Error("error inserting new conversation into the DB", "error", err)
 		return id, uuid, err
 	}
+
+	// Conversations have no priority by default, record the one set from the inbox priority defaults.
+	if priority != "" {
+		systemUser, err := c.userStore.GetSystemUser()
+		if err != nil {
+			c.lo.Error("error fetching system user", "error", err)
+		} else if err := c.RecordPriorityChange(priority, uuid, systemUser); err != nil {
+			c.lo.Error("error recording initial conversation priority", "conversation_uuid", uuid, "error", err)
+		}
+	}
 	return id, uuid, nil
 }
 
@@ -249,7 +456,7 @@ func (c *Manager) GetConversation(id int, uuid string) (models.Conversation, err
 	var conversation models.Conversation
 	if err := c.q.GetConversation.Get(&conversation, id, uuid); err != nil {
 		if err == sql.ErrNoRows {
-			return conversation, envelope.NewError(envelope.InputError, c.i18n.Ts("globals.messages.notFound", "name", "{globals.terms.conversation}"), nil)
+			return conversation, envelope.NewCodedError(envelope.InputError, envelope.ErrCodeConversationNotFound, c.i18n.Ts("globals.messages.notFound", "name", "{globals.terms.conversation}"), nil)
 		}
 		c.lo.Error("error fetching conversation", "error", err)
 		return conversation, envelope.NewError(envelope.GeneralError, c.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.conversation}"), nil)
@@ -267,6 +474,16 @@ func (c *Manager) GetContactConversations(contactID int) ([]models.Conversation,
 	return conversations, nil
 }
 
+// GetContactPastConversationCount returns the number of conversations of the contact created before the conversation.
+func (c *Manager) GetContactPastConversationCount(contactID, conversationID int) (int, error) {
+	var count int
+	if err := c.q.GetContactPastConversationCount.Get(&count, contactID, conversationID); err != nil {
+		c.lo.Error("error fetching contact past conversation count", "contact_id", contactID, "error", err)
+		return 0, envelope.NewError(envelope.GeneralError, c.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.conversation}"), nil)
+	}
+	return count, nil
+}
+
 // GetConversationsCreatedAfter retrieves conversations created after the specified time.
 func (c *Manager) GetConversationsCreatedAfter(time time.Time) ([]models.Conversation, error) {
 	var conversations = make([]models.Conversation, 0)
@@ -277,6 +494,16 @@ func (c *Manager) GetConversationsCreatedAfter(time time.Time) ([]models.Convers
 	return conversations, nil
 }
 
+// GetConversationsToAutoClose returns conversations in one of the passed statuses that have not been updated since `inactiveSince`.
+func (c *Manager) GetConversationsToAutoClose(statuses []string, inactiveSince time.Time) ([]models.Conversation, error) {
+	var conversations = make([]models.Conversation, 0)
+	if err := c.q.GetConversationsToAutoClose.Select(&conversations, pq.Array(statuses), inactiveSince); err != nil {
+		c.lo.Error("error fetching conversations to auto-close", "error", err)
+		return conversations, err
+	}
+	return conversations, nil
+}
+
 // UpdateConversationAssigneeLastSeen updates the last seen timestamp of assignee.
 func (c *Manager) UpdateConversationAssigneeLastSeen(uuid string) error {
 	if _, err := c.q.UpdateConversationAssigneeLastSeen.Exec(uuid); err != nil {
@@ -285,11 +512,11 @@ func (c *Manager) UpdateConversationAssigneeLastSeen(uuid string) error {
 	}
 
 	// Broadcast the property update to all subscribers.
-	c.BroadcastConversationUpdate(uuid, "assignee_last_seen_at", time.Now().Format(time.RFC3339))
+	c.BroadcastConversationUpdate(uuid, "assignee_last_seen_at", stringutil.FormatTimestamp(time.Now()))
 	return nil
 }
 
-// GetConversationParticipants retrieves the participants of a conversation.
+// GetConversationParticipants retrieves the participants of a conversation with their role, each agent is listed once.
 func (c *Manager) GetConversationParticipants(uuid string) ([]models.ConversationParticipant, error) {
 	conv := make([]models.ConversationParticipant, 0)
 	if err := c.q.GetConversationParticipants.Select(&conv, uuid); err != nil {
@@ -299,6 +526,29 @@ func (c *Manager) GetConversationParticipants(uuid string) ([]models.Conversatio
 	return conv, nil
 }
 
+// AddConversationParticipant adds the user as a participant of the conversation with the role, which is one of
+// collaborator, follower or requester. The role of an existing participant is replaced. The assignee isn't a stored
+// role, it's the assigned user of the conversation.
+func (c *Manager) AddConversationParticipant(userID int, conversationUUID, role string) error {
+	if !slices.Contains(models.ParticipantRoles, role) {
+		return envelope.NewError(envelope.InputError, c.i18n.Ts("globals.messages.invalid", "name", "`role`"), nil)
+	}
+	if _, err := c.q.InsertConversationParticipant.Exec(userID, conversationUUID, role, true); err != nil {
+		c.lo.Error("error adding conversation participant", "user_id", userID, "conversation_uuid", conversationUUID, "error", err)
+		return envelope.NewError(envelope.GeneralError, c.i18n.Ts("globals.messages.errorCreating", "name", "{globals.terms.conversationParticipant}"), nil)
+	}
+	return nil
+}
+
+// RemoveConversationParticipant removes the user from the participants of the conversation.
+func (c *Manager) RemoveConversationParticipant(userID int, conversationUUID string) error {
+	if _, err := c.q.DeleteConversationParticipant.Exec(userID, conversationUUID); err != nil {
+		c.lo.Error("error removing conversation participant", "user_id", userID, "conversation_uuid", conversationUUID, "error", err)
+		return envelope.NewError(envelope.GeneralError, c.i18n.Ts("globals.messages.errorDeleting", "name", "{globals.terms.conversationParticipant}"), nil)
+	}
+	return nil
+}
+
 // GetUnassignedConversations retrieves unassigned conversations.
 func (c *Manager) GetUnassignedConversations() ([]models.Conversation, error) {
 	var conv []models.Conversation
@@ -325,8 +575,8 @@ func (c *Manager) GetConversationUUID(id int) (string, error) {
 }
 
 // GetAllConversationsList retrieves all conversations with optional filtering, ordering, and pagination.
-func (c *Manager) GetAllConversationsList(order, orderBy, filters string, page, pageSize int) ([]models.Conversation, error) {
-	return c.GetConversations(0, []int{}, []string{models.AllConversations}, order, orderBy, filters, page, pageSize)
+func (c *Manager) GetAllConversationsList(userID int, order, orderBy, filters string, page, pageSize int) ([]models.Conversation, error) {
+	return c.GetConversations(userID, []int{}, []string{models.AllConversations}, order, orderBy, filters, page, pageSize)
 }
 
 // GetAssignedConversationsList retrieves conversations assigned to a specific user with optional filtering, ordering, and pagination.
@@ -335,13 +585,13 @@ func (c *Manager) GetAssignedConversationsList(userID int, order, orderBy, filte
 }
 
 // GetUnassignedConversationsList retrieves conversations assigned to a team the user is part of with optional filtering, ordering, and pagination.
-func (c *Manager) GetUnassignedConversationsList(order, orderBy, filters string, page, pageSize int) ([]models.Conversation, error) {
-	return c.GetConversations(0, []int{}, []string{models.UnassignedConversations}, order, orderBy, filters, page, pageSize)
+func (c *Manager) GetUnassignedConversationsList(userID int, order, orderBy, filters string, page, pageSize int) ([]models.Conversation, error) {
+	return c.GetConversations(userID, []int{}, []string{models.UnassignedConversations}, order, orderBy, filters, page, pageSize)
 }
 
 // GetTeamUnassignedConversationsList retrieves conversations assigned to a team with optional filtering, ordering, and pagination.
-func (c *Manager) GetTeamUnassignedConversationsList(teamID int, order, orderBy, filters string, page, pageSize int) ([]models.Conversation, error) {
-	return c.GetConversations(0, []int{teamID}, []string{models.TeamUnassignedConversations}, order, orderBy, filters, page, pageSize)
+func (c *Manager) GetTeamUnassignedConversationsList(userID, teamID int, order, orderBy, filters string, page, pageSize int) ([]models.Conversation, error) {
+	return c.GetConversations(userID, []int{teamID}, []string{models.TeamUnassignedConversations}, order, orderBy, filters, page, pageSize)
 }
 
 func (c *Manager) GetViewConversationsList(userID int, teamIDs []int, listType []string, order, orderBy, filters string, page, pageSize int) ([]models.Conversation, error) {
@@ -349,6 +599,7 @@ func (c *Manager) GetViewConversationsList(userID int, teamIDs []int, listType [
 }
 
 // GetConversations retrieves conversations list based on user ID, type, and optional filtering, ordering, and pagination.
+// The user ID is the requesting user, `@me` filter values resolve to it.
 func (c *Manager) GetConversations(userID int, teamIDs []int, listTypes []string, order, orderBy, filters string, page, pageSize int) ([]models.Conversation, error) {
 	var conversations = make([]models.Conversation, 0)
 
@@ -375,26 +626,26 @@ func (c *Manager) GetConversations(userID int, teamIDs []int, listTypes []string
 	return conversations, nil
 }
 
-// ReOpenConversation reopens a conversation if it's snoozed, resolved or closed.
+// ReOpenConversation reopens a conversation if it's snoozed, resolved or closed and records a reopened activity
+// with the status it was reopened from.
 func (c *Manager) ReOpenConversation(conversationUUID string, actor umodels.User) error {
-	rows, err := c.q.ReOpenConversation.Exec(conversationUUID)
-	if err != nil {
+	var prevStatus string
+	if err := c.q.ReOpenConversation.Get(&prevStatus, conversationUUID); err != nil {
+		// Conversation is already open.
+		if err == sql.ErrNoRows {
+			return nil
+		}
 		c.lo.Error("error reopening conversation", "uuid", conversationUUID, "error", err)
 		return envelope.NewError(envelope.GeneralError, c.i18n.Ts("globals.messages.errorUpdating", "name", "{globals.terms.conversation}"), nil)
 	}
+	// The agent is unassigned if they're away and reassigning.
+	c.recordAssignment(conversationUUID)
 
-	// Record the status change as an activity if the conversation was reopened.
-	count, _ := rows.RowsAffected()
-	if count > 0 {
-		// Broadcast update using WS
-		c.BroadcastConversationUpdate(conversationUUID, "status", models.StatusOpen)
+	// Broadcast update using WS
+	c.BroadcastConversationUpdate(conversationUUID, "status", models.StatusOpen)
 
-		// Record the status change as an activity.
-		if err := c.RecordStatusChange(models.StatusOpen, conversationUUID, actor); err != nil {
-			return err
-		}
-	}
-	return nil
+	// Record the reopen as an activity.
+	return c.RecordReopen(prevStatus, conversationUUID, actor)
 }
 
 // ActiveUserConversationsCount returns the count of active conversations for a user. i.e. conversations not closed or resolved status.
@@ -407,13 +658,28 @@ func (c *Manager) ActiveUserConversationsCount(userID int) (int, error) {
 	return count, nil
 }
 
-// UpdateConversationLastMessage updates the last message details for a conversation.
-func (c *Manager) UpdateConversationLastMessage(conversation int, conversationUUID, lastMessage, lastMessageSenderType string, lastMessageAt time.Time) error {
-	if _, err := c.q.UpdateConversationLastMessage.Exec(conversation, conversationUUID, lastMessage, lastMessageSenderType, lastMessageAt); err != nil {
-		c.lo.Error("error updating conversation last message", "error", err)
-		return err
+// GetTeamAgentsWorkload returns the availability and active conversations count of all agents of a team.
+func (c *Manager) GetTeamAgentsWorkload(teamID int) ([]models.AgentWorkload, error) {
+	var workload = make([]models.AgentWorkload, 0)
+	if err := c.q.GetTeamAgentsWorkload.Select(&workload, teamID); err != nil {
+		c.lo.Error("error fetching team agents workload", "team_id", teamID, "error", err)
+		return workload, envelope.NewError(envelope.GeneralError, c.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.conversation}"), nil)
 	}
-	return nil
+	return workload, nil
+}
+
+// GetAgentWorkload returns the availability, open conversations count and open conversations cap of an agent.
+// The conversation with the passed uuid isn't counted, pass an empty uuid to count all the open conversations.
+func (c *Manager) GetAgentWorkload(userID int, excludeUUID string) (models.AgentWorkload, error) {
+	var workload models.AgentWorkload
+	if err := c.q.GetAgentWorkload.Get(&workload, userID, excludeUUID); err != nil {
+		if err == sql.ErrNoRows {
+			return workload, envelope.NewError(envelope.NotFoundError, c.i18n.Ts("globals.messages.notFound", "name", "{globals.terms.agent}"), nil)
+		}
+		c.lo.Error("error fetching agent workload", "user_id", userID, "error", err)
+		return workload, envelope.NewError(envelope.GeneralError, c.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.conversation}"), nil)
+	}
+	return workload, nil
 }
 
 // UpdateConversationFirstReplyAt updates the first reply timestamp for a conversation.
@@ -426,7 +692,7 @@ func (c *Manager) UpdateConversationFirstReplyAt(conversationUUID string, conver
 
 	rows, _ := res.RowsAffected()
 	if rows > 0 {
-		c.BroadcastConversationUpdate(conversationUUID, "first_reply_at", at.Format(time.RFC3339))
+		c.BroadcastConversationUpdate(conversationUUID, "first_reply_at", stringutil.FormatTimestamp(at))
 	}
 	return nil
 }
@@ -441,17 +707,23 @@ func (c *Manager) UpdateConversationLastReplyAt(conversationUUID string, convers
 
 	rows, _ := res.RowsAffected()
 	if rows > 0 {
-		c.BroadcastConversationUpdate(conversationUUID, "last_reply_at", at.Format(time.RFC3339))
+		c.BroadcastConversationUpdate(conversationUUID, "last_reply_at", stringutil.FormatTimestamp(at))
 	}
 	return nil
 }
 
 // UpdateConversationUserAssignee sets the assignee of a conversation to a specifc user.
 func (c *Manager) UpdateConversationUserAssignee(uuid string, assigneeID int, actor umodels.User) error {
+	// Errors of user assignments are already envelope errors, e.g. when the agent is at their open conversations cap.
 	if err := c.UpdateAssignee(uuid, assigneeID, models.AssigneeTypeUser); err != nil {
-		return envelope.NewError(envelope.GeneralError, c.i18n.Ts("globals.messages.errorUpdating", "name", "{globals.terms.conversation}"), nil)
+		return err
 	}
+	c.recordManualAssignment(uuid, actor)
+	return c.userAssigned(uuid, assigneeID, actor)
+}
 
+// userAssigned emails the new assignee of the conversation and records the assignment activity.
+func (c *Manager) userAssigned(uuid string, assigneeID int, actor umodels.User) error {
 	conversation, err := c.GetConversation(0, uuid)
 	if err != nil {
 		return err
@@ -477,38 +749,67 @@ func (c *Manager) UpdateConversationTeamAssignee(uuid string, teamID int, actor
 	}
 	previousAssignedTeamID := conversation.AssignedTeamID.Int
 
+	// Reject unknown teams before assigning so no bogus activity is recorded.
+	if teamID <= 0 {
+		return envelope.NewError(envelope.InputError, c.i18n.Ts("globals.messages.invalid", "name", "{globals.terms.team}"), nil)
+	}
+	if _, err := c.teamStore.Get(teamID); err != nil {
+		return err
+	}
+
 	if err := c.UpdateAssignee(uuid, teamID, models.AssigneeTypeTeam); err != nil {
 		return envelope.NewError(envelope.GeneralError, c.i18n.Ts("globals.messages.errorUpdating", "name", "{globals.terms.conversation}"), nil)
 	}
+	c.recordManualAssignment(uuid, actor)
+	c.teamAssigned(uuid, previousAssignedTeamID, teamID, actor)
+	return nil
+}
 
-	// Assignment successful, any errors now are non-critical and can be ignored by returning nil.
+// teamAssigned records the team assignment activity and applies the SLA policy of the team if the team changed.
+// The assignment is done, so errors here do not fail it.
+func (c *Manager) teamAssigned(uuid string, previousAssignedTeamID, teamID int, actor umodels.User) {
 	if err := c.RecordAssigneeTeamChange(uuid, teamID, actor); err != nil {
-		return nil
+		return
 	}
 
 	// Apply SLA policy if team has changed and the new team has an SLA policy.
 	if previousAssignedTeamID != teamID && teamID > 0 {
 		team, err := c.teamStore.Get(teamID)
 		if err != nil {
-			return nil
+			return
 		}
 		if team.SLAPolicyID.Int > 0 {
 			systemUser, err := c.userStore.GetSystemUser()
 			if err != nil {
-				return nil
+				return
 			}
 
 			// Fetch the conversation again to get the updated assignee details.
 			conversation, err := c.GetConversation(0, uuid)
 			if err != nil {
-				return nil
+				return
 			}
 			if err := c.ApplySLA(conversation, team.SLAPolicyID.Int, systemUser); err != nil {
-				return nil
+				c.lo.Error("error applying team SLA policy", "uuid", uuid, "team_id", teamID, "error", err)
 			}
 		}
 	}
-	return nil
+}
+
+// recordManualAssignment records the assignment time of a conversation if it was assigned by an agent, which starts
+// the reassignment cooldown during which automation rules do not reassign the conversation.
+func (c *Manager) recordManualAssignment(uuid string, actor umodels.User) {
+	if !isAgentActor(actor) {
+		return
+	}
+	if _, err := c.q.SetManuallyAssigned.Exec(uuid); err != nil {
+		c.lo.Error("error recording manual conversation assignment", "uuid", uuid, "error", err)
+	}
+}
+
+// isAgentActor returns true if the actor is an agent, not the system or automation user.
+func isAgentActor(actor umodels.User) bool {
+	return actor.ID > 0 && actor.Type == umodels.UserTypeAgent && actor.Email.String != umodels.SystemUserEmail && actor.Email.String != umodels.AutomationUserEmail
 }
 
 // UpdateAssignee updates the assignee of a conversation.
@@ -517,9 +818,8 @@ func (c *Manager) UpdateAssignee(uuid string, assigneeID int, assigneeType strin
 	switch assigneeType {
 	case models.AssigneeTypeUser:
 		prop = "assigned_user_id"
-		if _, err := c.q.UpdateConversationAssignedUser.Exec(uuid, assigneeID); err != nil {
-			c.lo.Error("error updating conversation assignee", "error", err)
-			return fmt.Errorf("updating assignee: %w", err)
+		if err := c.assignUser(uuid, assigneeID); err != nil {
+			return err
 		}
 	case models.AssigneeTypeTeam:
 		prop = "assigned_team_id"
@@ -530,6 +830,8 @@ func (c *Manager) UpdateAssignee(uuid string, assigneeID int, assigneeType strin
 	default:
 		return fmt.Errorf("invalid assignee type: %s", assigneeType)
 	}
+	c.recordAssignment(uuid)
+
 	// Broadcast update to all subscribers.
 	c.BroadcastConversationUpdate(uuid, prop, assigneeID)
 	return nil
@@ -537,18 +839,21 @@ func (c *Manager) UpdateAssignee(uuid string, assigneeID int, assigneeType strin
 
 // UpdateConversationPriority updates the priority of a conversation.
 func (c *Manager) UpdateConversationPriority(uuid string, priorityID int, priority string, actor umodels.User) error {
-	// Fetch the priority name if priority ID is provided.
-	if priorityID > 0 {
-		p, err := c.priorityStore.Get(priorityID)
-		if err != nil {
-			return envelope.NewError(envelope.InputError, err.Error(), nil)
-		}
-		priority = p.Name
+	// Resolve the priority by ID or name, unknown priorities are rejected before anything is recorded.
+	p, err := c.resolvePriority(priorityID, priority)
+	if err != nil {
+		return err
 	}
+	priority = p.Name
 	if _, err := c.q.UpdateConversationPriority.Exec(uuid, priority); err != nil {
 		c.lo.Error("error updating conversation priority", "error", err)
 		return envelope.NewError(envelope.GeneralError, c.i18n.Ts("globals.messages.errorUpdating", "name", "{globals.terms.conversation}"), nil)
 	}
+	return c.priorityChanged(uuid, priority, actor)
+}
+
+// priorityChanged records the priority change activity and broadcasts the new priority.
+func (c *Manager) priorityChanged(uuid, priority string, actor umodels.User) error {
 	if err := c.RecordPriorityChange(priority, uuid, actor); err != nil {
 		return envelope.NewError(envelope.GeneralError, c.i18n.Ts("globals.messages.errorUpdating", "name", "{globals.terms.conversation}"), nil)
 	}
@@ -556,16 +861,68 @@ func (c *Manager) UpdateConversationPriority(uuid string, priorityID int, priori
 	return nil
 }
 
+// UpdateConversationSubject changes the subject of a conversation, which is used by the replies sent after it. The
+// reference number is appended to the subject if it's not in it, so contact replies are still threaded by subject.
+func (c *Manager) UpdateConversationSubject(uuid, subject string, actor umodels.User) error {
+	subject = strings.TrimSpace(subject)
+	if subject == "" {
+		return envelope.NewError(envelope.InputError, c.i18n.Ts("globals.messages.empty", "name", "`subject`"), nil)
+	}
+	if len(subject) > maxSubjectLen {
+		return envelope.NewError(envelope.InputError, c.i18n.Ts("globals.messages.invalid", "name", "`subject`"), nil)
+	}
+
+	conversation, err := c.GetConversation(0, uuid)
+	if err != nil {
+		return err
+	}
+	subject = subjectWithReferenceNumber(subject, conversation.ReferenceNumber)
+	if subject == conversation.Subject.String {
+		return nil
+	}
+
+	if _, err := c.q.UpdateConversationSubject.Exec(uuid, subject); err != nil {
+		c.lo.Error("error updating conversation subject", "uuid", uuid, "error", err)
+		return envelope.NewError(envelope.GeneralError, c.i18n.Ts("globals.messages.errorUpdating", "name", "{globals.terms.conversation}"), nil)
+	}
+	if err := c.InsertConversationActivity(models.ActivitySubjectChange, uuid, subject, actor); err != nil {
+		c.lo.Error("error recording subject change activity", "uuid", uuid, "error", err)
+	}
+	c.BroadcastConversationUpdate(uuid, "subject", subject)
+	return nil
+}
+
+// subjectWithReferenceNumber appends the reference number to the subject in square brackets, unless it's already in it.
+func subjectWithReferenceNumber(subject, referenceNumber string) string {
+	if referenceNumber == "" || slices.Contains(parseSubjectReferenceNumbers(subject), referenceNumber) {
+		return subject
+	}
+	return subject + " [" + referenceNumber + "]"
+}
+
+// UpdateConversationLocale sets the locale CSAT surveys and auto-sent templates are rendered in for a conversation,
+// overriding the detected language of the contact. An empty locale removes the override.
+func (c *Manager) UpdateConversationLocale(uuid, locale string) error {
+	locale = strings.TrimSpace(locale)
+	if locale != "" && !stringutil.ValidLocale(locale) {
+		return envelope.NewError(envelope.InputError, c.i18n.Ts("globals.messages.invalid", "name", "`locale`"), nil)
+	}
+	if _, err := c.q.UpdateConversationLocale.Exec(uuid, locale); err != nil {
+		c.lo.Error("error updating conversation locale", "uuid", uuid, "error", err)
+		return envelope.NewError(envelope.GeneralError, c.i18n.Ts("globals.messages.errorUpdating", "name", "{globals.terms.conversation}"), nil)
+	}
+	c.BroadcastConversationUpdate(uuid, "locale", locale)
+	return nil
+}
+
 // UpdateConversationStatus updates the status of a conversation.
 func (c *Manager) UpdateConversationStatus(uuid string, statusID int, status, snoozeDur string, actor umodels.User) error {
-	// Fetch the status name if status ID is provided.
-	if statusID > 0 {
-		s, err := c.statusStore.Get(statusID)
-		if err != nil {
-			return envelope.NewError(envelope.InputError, err.Error(), nil)
-		}
-		status = s.Name
+	// Resolve the status by ID or name, unknown statuses are rejected before anything is recorded.
+	s, err := c.resolveStatus(statusID, status)
+	if err != nil {
+		return err
 	}
+	status = s.Name
 
 	if status == models.StatusSnoozed && snoozeDur == "" {
 		return envelope.NewError(envelope.InputError, c.i18n.T("conversation.invalidSnoozeDuration"), nil)
@@ -587,7 +944,12 @@ func (c *Manager) UpdateConversationStatus(uuid string, statusID int, status, sn
 		c.lo.Error("error updating conversation status", "error", err)
 		return envelope.NewError(envelope.GeneralError, c.i18n.Ts("globals.messages.errorUpdating", "name", "{globals.terms.conversation}"), nil)
 	}
+	return c.statusChanged(uuid, status, actor)
+}
 
+// statusChanged records the status change activity, broadcasts the new status and sends the CSAT survey if the
+// status triggers one.
+func (c *Manager) statusChanged(uuid, status string, actor umodels.User) error {
 	// Record the status change as an activity.
 	if err := c.RecordStatusChange(status, uuid, actor); err != nil {
 		return envelope.NewError(envelope.GeneralError, c.i18n.Ts("globals.messages.errorUpdating", "name", "{globals.terms.conversation}"), nil)
@@ -595,9 +957,31 @@ func (c *Manager) UpdateConversationStatus(uuid string, statusID int, status, sn
 
 	// Broadcast updates using websocket.
 	c.BroadcastConversationUpdate(uuid, "status", status)
+
+	// The status change is done, a failed survey shouldn't fail it.
+	if err := c.sendCSATOnStatusChange(uuid, status, actor); err != nil {
+		c.lo.Error("error sending CSAT survey on status change", "conversation_uuid", uuid, "status", status, "error", err)
+	}
 	return nil
 }
 
+// sendCSATOnStatusChange sends a CSAT survey if the status change triggers one for the conversation.
+func (c *Manager) sendCSATOnStatusChange(uuid, status string, actor umodels.User) error {
+	conversation, err := c.GetConversation(0, uuid)
+	if err != nil {
+		return err
+	}
+	send, err := c.csatStore.ShouldSend(conversation, status)
+	if err != nil || !send {
+		return err
+	}
+	actor, err = c.actorOrSystemUser(actor)
+	if err != nil {
+		return err
+	}
+	return c.SendCSATReply(actor.ID, conversation)
+}
+
 // GetDashboardCounts returns dashboard counts
 // TODO: Rename to overview [reports/overview].
 func (c *Manager) GetDashboardCounts(userID, teamID int) (json.RawMessage, error) {
@@ -677,65 +1061,27 @@ func (c *Manager) GetDashboardChart(userID, teamID int) (json.RawMessage, error)
 
 // SetConversationTags sets the tags associated with a conversation.
 func (c *Manager) SetConversationTags(uuid string, action string, tagNames []string, actor umodels.User) error {
-	// Get current tags list.
-	prevTags, err := c.getConversationTags(uuid)
-	if err != nil {
-		return envelope.NewError(envelope.GeneralError, c.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.tag}"), nil)
-	}
-
-	// Add specified tags, ignore existing ones.
-	if action == amodels.ActionAddTags {
-		if _, err := c.q.AddConversationTags.Exec(uuid, pq.Array(tagNames)); err != nil {
-			c.lo.Error("error adding conversation tags", "error", err)
-			return envelope.NewError(envelope.GeneralError, c.i18n.Ts("globals.messages.errorUpdating", "name", "{globals.terms.tag}"), nil)
-		}
-	}
-
-	// Set specified tags and remove all other existing ones.
-	if action == amodels.ActionSetTags {
-		if _, err := c.q.SetConversationTags.Exec(uuid, pq.Array(tagNames)); err != nil {
-			c.lo.Error("error setting conversation tags", "error", err)
-			return envelope.NewError(envelope.GeneralError, c.i18n.Ts("globals.messages.errorUpdating", "name", "{globals.terms.tag}"), nil)
-		}
-	}
-
-	// Delete specified tags, ignore all others.
-	if action == amodels.ActionRemoveTags {
-		if _, err := c.q.RemoveConversationTags.Exec(uuid, pq.Array(tagNames)); err != nil {
-			c.lo.Error("error removing conversation tags", "error", err)
-			return envelope.NewError(envelope.GeneralError, c.i18n.Ts("globals.messages.errorUpdating", "name", "{globals.terms.tag}"), nil)
-		}
-	}
-
-	// Get updated tags list.
-	newTags, err := c.getConversationTags(uuid)
-	if err != nil {
-		return envelope.NewError(envelope.GeneralError, c.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.tag}"), nil)
-	}
-
-	// Find actually removed tags.
-	for _, tag := range prevTags {
-		if slices.Contains(newTags, tag) {
-			continue
-		}
-		// Record the removed tags as activities.
-		if err := c.RecordTagRemoval(uuid, tag, actor); err != nil {
-			return envelope.NewError(envelope.GeneralError, c.i18n.Ts("globals.messages.errorUpdating", "name", "{globals.terms.tag}"), nil)
-		}
+	switch action {
+	case amodels.ActionAddTags:
+		return c.AddTags(uuid, tagNames, actor)
+	case amodels.ActionRemoveTags:
+		return c.RemoveTags(uuid, tagNames, actor)
+	case amodels.ActionSetTags:
+		// Set specified tags and remove all other existing ones.
+		return c.updateConversationTags(uuid, c.q.SetConversationTags, tagNames, actor)
+	default:
+		return envelope.NewError(envelope.InputError, c.i18n.Ts("globals.messages.invalid", "name", "`action`"), nil)
 	}
+}
 
-	// Find actually added tags.
-	for _, tag := range newTags {
-		if slices.Contains(prevTags, tag) {
-			continue
-		}
-		// Record the added tags as activities.
-		if err := c.RecordTagAddition(uuid, tag, actor); err != nil {
-			return envelope.NewError(envelope.GeneralError, c.i18n.Ts("globals.messages.errorUpdating", "name", "{globals.terms.tag}"), nil)
-		}
-	}
+// AddTags adds the specified tags to a conversation, existing tags are ignored.
+func (c *Manager) AddTags(uuid string, tagNames []string, actor umodels.User) error {
+	return c.updateConversationTags(uuid, c.q.AddConversationTags, tagNames, actor)
+}
 
-	return nil
+// RemoveTags removes the specified tags from a conversation, all other tags are left as is.
+func (c *Manager) RemoveTags(uuid string, tagNames []string, actor umodels.User) error {
+	return c.updateConversationTags(uuid, c.q.RemoveConversationTags, tagNames, actor)
 }
 
 // GetToAddress retrieves the recipient addresses for a conversation and channel.
@@ -767,7 +1113,7 @@ func (m *Manager) SendAssignedConversationEmail(userIDs []int, conversation mode
 		return fmt.Errorf("fetching agent: %w", err)
 	}
 
-	content, subject, err := m.template.RenderStoredEmailTemplate(template.TmplConversationAssigned,
+	content, subject, err := m.template.RenderStoredEmailTemplate(template.TmplConversationAssigned, agent.Locale.String,
 		map[string]any{
 			// Kept these lower case keys for backward compatibility.
 			"conversation": map[string]string{
@@ -810,10 +1156,12 @@ func (m *Manager) SendAssignedConversationEmail(userIDs []int, conversation mode
 		return fmt.Errorf("rendering template: %w", err)
 	}
 	nm := notifier.Message{
+		UserIDs:         []int{agent.ID},
 		RecipientEmails: []string{agent.Email.String},
 		Subject:         subject,
 		Content:         content,
 		Provider:        notifier.ProviderEmail,
+		EventType:       npmodels.EventAssignment,
 	}
 	if err := m.notifier.Send(nm); err != nil {
 		m.lo.Error("error sending notification message", "error", err)
@@ -856,11 +1204,9 @@ func (m *Manager) ApplyAction(action amodels.RuleAction, conv models.Conversatio
 	}
 
 	// Fall back to system user if user is not provided.
-	if user.ID == 0 {
-		var err error
-		if user, err = m.userStore.GetSystemUser(); err != nil {
-			return fmt.Errorf("get system user: %w", err)
-		}
+	user, err := m.actorOrSystemUser(user)
+	if err != nil {
+		return err
 	}
 
 	m.lo.Debug("executing action",
@@ -890,10 +1236,17 @@ func (m *Manager) ApplyAction(action amodels.RuleAction, conv models.Conversatio
 	case amodels.ActionSetSLA:
 		slaID, _ := strconv.Atoi(action.Value[0])
 		return m.ApplySLA(conv, slaID, user)
-	case amodels.ActionAddTags, amodels.ActionSetTags, amodels.ActionRemoveTags:
+	case amodels.ActionAddTags:
+		return m.AddTags(conv.UUID, action.Value, user)
+	case amodels.ActionRemoveTags:
+		return m.RemoveTags(conv.UUID, action.Value, user)
+	case amodels.ActionSetTags:
 		return m.SetConversationTags(conv.UUID, action.Type, action.Value, user)
 	case amodels.ActionSendCSAT:
 		return m.SendCSATReply(user.ID, conv)
+	case amodels.ActionRouteToQueue:
+		queueID, _ := strconv.Atoi(action.Value[0])
+		return m.RouteToQueue(conv.UUID, queueID, user)
 	default:
 		return fmt.Errorf("unknown action: %s", action.Type)
 	}
@@ -905,6 +1258,7 @@ func (m *Manager) RemoveConversationAssignee(uuid, typ string) error {
 		m.lo.Error("error removing conversation assignee", "error", err)
 		return envelope.NewError(envelope.GeneralError, m.i18n.T("conversation.errorRemovingConversationAssignee"), nil)
 	}
+	m.recordAssignment(uuid)
 	return nil
 }
 
@@ -916,10 +1270,24 @@ func (m *Manager) SendCSATReply(actorUserID int, conversation models.Conversatio
 	}
 	csat, err := m.csatStore.Create(conversation.ID)
 	if err != nil {
+		if envErr, ok := err.(envelope.Error); ok && envErr.ErrorCode == envelope.ErrCodeDuplicate {
+			m.lo.Info("CSAT survey already sent for conversation, skipping", "conversation_uuid", conversation.UUID)
+			return nil
+		}
 		return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorCreating", "name", "{globals.terms.csat}"), nil)
 	}
-	csatPublicURL := m.csatStore.MakePublicURL(appRootURL, csat.UUID)
-	message := fmt.Sprintf(csatReplyMessage, csatPublicURL)
+	csatPublicURL := m.csatStore.MakePublicURL(appRootURL, csat)
+
+	// Render the survey message in the conversation's locale, falling back to the built-in English message.
+	message, err := m.template.RenderInMemoryTemplate(template.TmplCSATReply, conversation.EffectiveLocale(), map[string]any{
+		"URL": csatPublicURL,
+	})
+	if err != nil || strings.TrimSpace(message) == "" {
+		if err != nil {
+			m.lo.Error("error rendering CSAT reply template", "conversation_uuid", conversation.UUID, "error", err)
+		}
+		message = fmt.Sprintf(csatReplyMessage, csatPublicURL)
+	}
 	// Store `is_csat` meta to identify and filter CSAT public url from the message.
 	meta := map[string]interface{}{
 		"is_csat": true,
@@ -927,17 +1295,11 @@ func (m *Manager) SendCSATReply(actorUserID int, conversation models.Conversatio
 	return m.SendReply([]mmodels.Media{}, conversation.InboxID, actorUserID, conversation.UUID, message, nil, nil, meta)
 }
 
-// DeleteConversation deletes a conversation.
-func (m *Manager) DeleteConversation(uuid string) error {
-	if _, err := m.q.DeleteConversation.Exec(uuid); err != nil {
-		m.lo.Error("error deleting conversation", "error", err)
-		return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorDeleting", "name", m.i18n.Ts("globals.terms.conversation")), nil)
-	}
-	return nil
-}
-
-// UpdateConversationCustomAttributes updates the custom attributes of a conversation.
+// UpdateConversationCustomAttributes validates and replaces the custom attributes of a conversation.
 func (c *Manager) UpdateConversationCustomAttributes(uuid string, customAttributes map[string]any) error {
+	if err := c.customAttributeStore.ValidateValues(caModels.AppliesToConversation, customAttributes); err != nil {
+		return err
+	}
 	jsonb, err := json.Marshal(customAttributes)
 	if err != nil {
 		c.lo.Error("error marshalling custom attributes", "error", err)
@@ -947,18 +1309,100 @@ func (c *Manager) UpdateConversationCustomAttributes(uuid string, customAttribut
 		c.lo.Error("error updating conversation custom attributes", "error", err)
 		return envelope.NewError(envelope.GeneralError, c.i18n.Ts("globals.messages.errorUpdating", "name", "{globals.terms.conversation}"), nil)
 	}
+	c.BroadcastConversationUpdate(uuid, "custom_attributes", customAttributes)
 	return nil
 }
 
-// addConversationParticipant adds a user as participant to a conversation.
-func (c *Manager) addConversationParticipant(userID int, conversationUUID string) error {
-	if _, err := c.q.InsertConversationParticipant.Exec(userID, conversationUUID); err != nil && !dbutil.IsUniqueViolationError(err) {
-		c.lo.Error("error adding conversation participant", "user_id", userID, "conversation_uuid", conversationUUID, "error", err)
-		return envelope.NewError(envelope.GeneralError, c.i18n.Ts("globals.messages.errorCreating", "name", "{globals.terms.conversationParticipant}"), nil)
+// SetConversationCustomAttribute sets a single custom attribute of a conversation, the string value is converted to the
+// type of the attribute definition. An empty value removes the attribute.
+func (c *Manager) SetConversationCustomAttribute(uuid, key, value string) error {
+	parsed, err := c.customAttributeStore.ParseValue(caModels.AppliesToConversation, key, value)
+	if err != nil {
+		return err
+	}
+	jsonb, err := json.Marshal(parsed)
+	if err != nil {
+		c.lo.Error("error marshalling custom attribute value", "key", key, "error", err)
+		return envelope.NewError(envelope.GeneralError, c.i18n.Ts("globals.messages.errorUpdating", "name", "{globals.terms.conversation}"), nil)
+	}
+	var customAttributes json.RawMessage
+	if err := c.q.SetConversationCustomAttribute.Get(&customAttributes, uuid, key, jsonb); err != nil {
+		c.lo.Error("error setting conversation custom attribute", "key", key, "error", err)
+		return envelope.NewError(envelope.GeneralError, c.i18n.Ts("globals.messages.errorUpdating", "name", "{globals.terms.conversation}"), nil)
+	}
+	c.BroadcastConversationUpdate(uuid, "custom_attributes", customAttributes)
+	return nil
+}
+
+// updateConversationTags executes the passed tags statement on a conversation, records the added and removed tags as activities
+// and broadcasts the updated tags list.
+func (c *Manager) updateConversationTags(uuid string, stmt *sqlx.Stmt, tagNames []string, actor umodels.User) error {
+	actor, err := c.actorOrSystemUser(actor)
+	if err != nil {
+		return envelope.NewError(envelope.GeneralError, c.i18n.Ts("globals.messages.errorUpdating", "name", "{globals.terms.tag}"), nil)
+	}
+
+	// Get current tags list.
+	prevTags, err := c.getConversationTags(uuid)
+	if err != nil {
+		return err
+	}
+
+	if _, err := stmt.Exec(uuid, pq.Array(tagNames)); err != nil {
+		c.lo.Error("error updating conversation tags", "uuid", uuid, "error", err)
+		return envelope.NewError(envelope.GeneralError, c.i18n.Ts("globals.messages.errorUpdating", "name", "{globals.terms.tag}"), nil)
+	}
+
+	return c.tagsChanged(uuid, prevTags, actor)
+}
+
+// tagsChanged records the tag change activity and broadcasts the new tags list if it differs from prevTags.
+func (c *Manager) tagsChanged(uuid string, prevTags []string, actor umodels.User) error {
+	// Get updated tags list.
+	newTags, err := c.getConversationTags(uuid)
+	if err != nil {
+		return err
+	}
+
+	if err := c.RecordTagChange(uuid, prevTags, newTags, actor); err != nil {
+		return envelope.NewError(envelope.GeneralError, c.i18n.Ts("globals.messages.errorUpdating", "name", "{globals.terms.tag}"), nil)
+	}
+
+	// Broadcast the new tags list to all subscribers.
+	if !slices.Equal(prevTags, newTags) {
+		c.BroadcastConversationUpdate(uuid, "tags", newTags)
 	}
 	return nil
 }
 
+// resolveStatus returns the status with the ID, or with the name if the ID is 0.
+func (c *Manager) resolveStatus(id int, name string) (smodels.Status, error) {
+	if id > 0 {
+		return c.statusStore.Get(id)
+	}
+	return c.statusStore.GetByName(name)
+}
+
+// resolvePriority returns the priority with the ID, or with the name if the ID is 0.
+func (c *Manager) resolvePriority(id int, name string) (pmodels.Priority, error) {
+	if id > 0 {
+		return c.priorityStore.Get(id)
+	}
+	return c.priorityStore.GetByName(name)
+}
+
+// actorOrSystemUser returns the passed actor, falling back to the system user if the actor is not set.
+func (c *Manager) actorOrSystemUser(actor umodels.User) (umodels.User, error) {
+	if actor.ID > 0 {
+		return actor, nil
+	}
+	systemUser, err := c.userStore.GetSystemUser()
+	if err != nil {
+		return actor, fmt.Errorf("get system user: %w", err)
+	}
+	return systemUser, nil
+}
+
 // getConversationTags retrieves the tags associated with a conversation.
 func (c *Manager) getConversationTags(uuid string) ([]string, error) {
 	var tags []string
@@ -971,8 +1415,6 @@ func (c *Manager) getConversationTags(uuid string) ([]string, error) {
 
 // makeConversationsListQuery prepares a SQL query string for conversations list
 func (c *Manager) makeConversationsListQuery(userID int, teamIDs []int, listTypes []string, baseQuery, order, orderBy string, page, pageSize int, filtersJSON string) (string, []interface{}, error) {
-	var qArgs []interface{}
-
 	// Set defaults
 	if orderBy == "" {
 		orderBy = "conversations.last_message_at"
@@ -980,9 +1422,6 @@ func (c *Manager) makeConversationsListQuery(userID int, teamIDs []int, listType
 	if order == "" {
 		order = "DESC"
 	}
-	if filtersJSON == "" {
-		filtersJSON = "[]"
-	}
 
 	// Validate inputs
 	if pageSize > conversationsListMaxPageSize || pageSize < 1 {
@@ -992,8 +1431,37 @@ func (c *Manager) makeConversationsListQuery(userID int, teamIDs []int, listType
 		return "", nil, fmt.Errorf("page must be greater than 0")
 	}
 
+	baseQuery, qArgs, filtersJSON, err := makeConversationsScopeQuery(userID, teamIDs, listTypes, baseQuery, filtersJSON)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return dbutil.BuildPaginatedQuery(baseQuery, qArgs, dbutil.PaginationOptions{
+		Order:    order,
+		OrderBy:  orderBy,
+		Page:     page,
+		PageSize: pageSize,
+	}, filtersJSON, conversationsListAllowedFields)
+}
+
+// makeConversationsScopeQuery sets the conditions of the list types in the base query and resolves the `@me` values
+// of the filters, it returns the query, its arguments and the resolved filters JSON.
+func makeConversationsScopeQuery(userID int, teamIDs []int, listTypes []string, baseQuery, filtersJSON string) (string, []interface{}, string, error) {
+	// The requesting user is always the first argument, the base query uses it for the user's unread count.
+	var qArgs = []interface{}{userID}
+
+	if filtersJSON == "" {
+		filtersJSON = "[]"
+	}
+
+	// Resolve `@me` filter values to the requesting user.
+	filtersJSON, err := resolveCurrentUserFilters(filtersJSON, userID)
+	if err != nil {
+		return "", nil, "", err
+	}
+
 	if len(listTypes) == 0 {
-		return "", nil, fmt.Errorf("no conversation list types specified")
+		return "", nil, "", fmt.Errorf("no conversation list types specified")
 	}
 
 	// Prepare the conditions based on the list types.
@@ -1001,8 +1469,7 @@ func (c *Manager) makeConversationsListQuery(userID int, teamIDs []int, listType
 	for _, lt := range listTypes {
 		switch lt {
 		case models.AssignedConversations:
-			conditions = append(conditions, fmt.Sprintf("conversations.assigned_user_id = $%d", len(qArgs)+1))
-			qArgs = append(qArgs, userID)
+			conditions = append(conditions, "conversations.assigned_user_id = $1")
 		case models.UnassignedConversations:
 			conditions = append(conditions, "conversations.assigned_user_id IS NULL AND conversations.assigned_team_id IS NULL")
 		case models.TeamUnassignedConversations:
@@ -1017,7 +1484,7 @@ func (c *Manager) makeConversationsListQuery(userID int, teamIDs []int, listType
 		case models.AllConversations:
 			// No conditions needed for all conversations.
 		default:
-			return "", nil, fmt.Errorf("unknown conversation type: %s", lt)
+			return "", nil, "", fmt.Errorf("unknown conversation type: %s", lt)
 		}
 	}
 
@@ -1027,14 +1494,5 @@ func (c *Manager) makeConversationsListQuery(userID int, teamIDs []int, listType
 		// Replace the `%s` in the base query with an empty string.
 		baseQuery = fmt.Sprintf(baseQuery, "")
 	}
-
-	return dbutil.BuildPaginatedQuery(baseQuery, qArgs, dbutil.PaginationOptions{
-		Order:    order,
-		OrderBy:  orderBy,
-		Page:     page,
-		PageSize: pageSize,
-	}, filtersJSON, dbutil.AllowedFields{
-		"conversations":         conversationsAllowedFields,
-		"conversation_statuses": conversationStatusAllowedFields,
-	})
+	return baseQuery, qArgs, filtersJSON, nil
 }
@@ -0,0 +1,62 @@
+package conversation
+
+// inboxDefaultAssignee is the default team and agent of an inbox, with whether they still exist.
+type inboxDefaultAssignee struct {
+	TeamID     int  `db:"team_id"`
+	TeamExists bool `db:"team_exists"`
+	UserID     int  `db:"user_id"`
+	UserExists bool `db:"user_exists"`
+}
+
+// assignees returns the team and agent new conversations are assigned to, 0 for the ones not set or that no longer
+// exist.
+func (d inboxDefaultAssignee) assignees() (int, int) {
+	var teamID, userID int
+	if d.TeamID > 0 && d.TeamExists {
+		teamID = d.TeamID
+	}
+	if d.UserID > 0 && d.UserExists {
+		userID = d.UserID
+	}
+	return teamID, userID
+}
+
+// applyDefaultAssignee assigns a new conversation to the default team and agent of its inbox, so it's covered even
+// without automation rules. The system user assigns it, so automation rules can still reassign the conversation.
+// Defaults that no longer exist are skipped, failures are logged as the conversation is created regardless.
+func (m *Manager) applyDefaultAssignee(uuid string, inboxID int) {
+	var defaults inboxDefaultAssignee
+	if err := m.q.GetInboxDefaultAssignee.Get(&defaults, inboxID); err != nil {
+		m.lo.Error("error fetching inbox default assignee", "inbox_id", inboxID, "error", err)
+		return
+	}
+
+	teamID, userID := defaults.assignees()
+	if defaults.TeamID > 0 && teamID == 0 {
+		m.lo.Warn("default team of inbox not found, skipping", "inbox_id", inboxID, "team_id", defaults.TeamID)
+	}
+	if defaults.UserID > 0 && userID == 0 {
+		m.lo.Warn("default agent of inbox not found or disabled, skipping", "inbox_id", inboxID, "user_id", defaults.UserID)
+	}
+	if teamID == 0 && userID == 0 {
+		return
+	}
+
+	systemUser, err := m.userStore.GetSystemUser()
+	if err != nil {
+		m.lo.Error("error fetching system user", "error", err)
+		return
+	}
+
+	// The team is assigned first as assigning a team clears the assigned agent.
+	if teamID > 0 {
+		if err := m.UpdateConversationTeamAssignee(uuid, teamID, systemUser); err != nil {
+			m.lo.Error("error assigning conversation to inbox default team", "conversation_uuid", uuid, "team_id", teamID, "error", err)
+		}
+	}
+	if userID > 0 {
+		if err := m.UpdateConversationUserAssignee(uuid, userID, systemUser); err != nil {
+			m.lo.Error("error assigning conversation to inbox default agent", "conversation_uuid", uuid, "user_id", userID, "error", err)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package conversation
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/abhinavxd/libredesk/internal/dbutil"
+)
+
+func TestInboxDefaultAssignees(t *testing.T) {
+	tests := []struct {
+		name     string
+		defaults inboxDefaultAssignee
+		teamID   int
+		userID   int
+	}{
+		{name: "not set", defaults: inboxDefaultAssignee{}},
+		{name: "team and agent", defaults: inboxDefaultAssignee{TeamID: 1, TeamExists: true, UserID: 2, UserExists: true}, teamID: 1, userID: 2},
+		{name: "team only", defaults: inboxDefaultAssignee{TeamID: 1, TeamExists: true}, teamID: 1},
+		{name: "deleted team", defaults: inboxDefaultAssignee{TeamID: 1, UserID: 2, UserExists: true}, userID: 2},
+		{name: "disabled agent", defaults: inboxDefaultAssignee{TeamID: 1, TeamExists: true, UserID: 2}, teamID: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			teamID, userID := tt.defaults.assignees()
+			if teamID != tt.teamID || userID != tt.userID {
+				t.Errorf("got team %d and agent %d, want team %d and agent %d", teamID, userID, tt.teamID, tt.userID)
+			}
+		})
+	}
+}
+
+func TestGetInboxDefaultAssignee(t *testing.T) {
+	db := newTestDB(t)
+
+	var q queries
+	if err := dbutil.ScanSQLFile("queries.sql", &q, db, efs); err != nil {
+		t.Fatalf("preparing queries: %v", err)
+	}
+
+	var ids struct {
+		TeamID   int `db:"team_id"`
+		AgentID  int `db:"agent_id"`
+		Disabled int `db:"disabled_id"`
+	}
+	err := db.QueryRowx(`
+		WITH team AS (
+			INSERT INTO teams (name, conversation_assignment_type) VALUES ('Support', 'Manual') RETURNING id
+		),
+		agent AS (
+			INSERT INTO users (type, first_name, email) VALUES ('agent', 'A', 'a@example.com') RETURNING id
+		),
+		disabled AS (
+			INSERT INTO users (type, first_name, email, enabled) VALUES ('agent', 'B', 'b@example.com', false) RETURNING id
+		)
+		SELECT team.id AS team_id, agent.id AS agent_id, disabled.id AS disabled_id FROM team, agent, disabled`).StructScan(&ids)
+	if err != nil {
+		t.Fatalf("inserting team and agents: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		config   string
+		expected inboxDefaultAssignee
+	}{
+		{name: "not set", config: `{}`},
+		{
+			name:     "team and agent",
+			config:   fmt.Sprintf(`{"default_assignee": {"team_id": %d, "user_id": %d}}`, ids.TeamID, ids.AgentID),
+			expected: inboxDefaultAssignee{TeamID: ids.TeamID, TeamExists: true, UserID: ids.AgentID, UserExists: true},
+		},
+		{
+			name:     "deleted team and disabled agent",
+			config:   fmt.Sprintf(`{"default_assignee": {"team_id": %d, "user_id": %d}}`, ids.TeamID+100, ids.Disabled),
+			expected: inboxDefaultAssignee{TeamID: ids.TeamID + 100, UserID: ids.Disabled},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var inboxID int
+			if err := db.Get(&inboxID, `INSERT INTO inboxes (name, channel, config) VALUES ($1, 'email', $2) RETURNING id`,
+				tt.name, tt.config); err != nil {
+				t.Fatalf("inserting inbox: %v", err)
+			}
+
+			var got inboxDefaultAssignee
+			if err := q.GetInboxDefaultAssignee.Get(&got, inboxID); err != nil {
+				t.Fatalf("fetching default assignee: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("got %+v, want %+v", got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package conversation
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/abhinavxd/libredesk/internal/envelope"
+	"github.com/abhinavxd/libredesk/internal/stringutil"
+	umodels "github.com/abhinavxd/libredesk/internal/user/models"
+)
+
+const (
+	// deletedPurgeInterval is how often soft-deleted conversations past the retention period are purged.
+	deletedPurgeInterval = time.Hour
+
+	// deletedPurgeBatchSize is the maximum number of conversations purged in a single run.
+	deletedPurgeBatchSize = 100
+)
+
+// SoftDeleteConversation hides a conversation from listings and search, recording the agent that deleted it.
+// It's purged for good once the retention period passes, until then it can be restored.
+func (m *Manager) SoftDeleteConversation(uuid string, actor umodels.User) error {
+	res, err := m.q.SoftDeleteConversation.Exec(uuid, actor.ID)
+	if err != nil {
+		m.lo.Error("error soft deleting conversation", "uuid", uuid, "error", err)
+		return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorDeleting", "name", "{globals.terms.conversation}"), nil)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return envelope.NewCodedError(envelope.NotFoundError, envelope.ErrCodeConversationNotFound, m.i18n.Ts("globals.messages.notFound", "name", "{globals.terms.conversation}"), nil)
+	}
+	m.lo.Info("conversation deleted", "uuid", uuid, "actor_id", actor.ID)
+	m.BroadcastConversationUpdate(uuid, "deleted_at", stringutil.FormatTimestamp(time.Now()))
+	return nil
+}
+
+// RestoreConversation restores a soft-deleted conversation.
+func (m *Manager) RestoreConversation(uuid string, actor umodels.User) error {
+	res, err := m.q.RestoreConversation.Exec(uuid)
+	if err != nil {
+		m.lo.Error("error restoring conversation", "uuid", uuid, "error", err)
+		return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorUpdating", "name", "{globals.terms.conversation}"), nil)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return envelope.NewCodedError(envelope.NotFoundError, envelope.ErrCodeConversationNotFound, m.i18n.Ts("globals.messages.notFound", "name", "{globals.terms.conversation}"), nil)
+	}
+	m.lo.Info("conversation restored", "uuid", uuid, "actor_id", actor.ID)
+	m.BroadcastConversationUpdate(uuid, "deleted_at", nil)
+	return nil
+}
+
+// DeleteConversation deletes a conversation for good along with its messages, CSAT responses, applied SLAs and
+// attachments, the attachment files are removed from the media store.
+func (m *Manager) DeleteConversation(uuid string) error {
+	var mediaUUIDs []string
+	if err := m.q.GetConversationMediaUUIDs.Select(&mediaUUIDs, uuid); err != nil {
+		m.lo.Error("error fetching conversation media", "uuid", uuid, "error", err)
+		return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorDeleting", "name", "{globals.terms.conversation}"), nil)
+	}
+
+	tx, err := m.db.BeginTxx(context.Background(), nil)
+	if err != nil {
+		m.lo.Error("error starting transaction", "error", err)
+		return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorDeleting", "name", "{globals.terms.conversation}"), nil)
+	}
+	defer tx.Rollback()
+
+	// CSAT responses and applied SLAs reference the conversation with a non null foreign key set to NULL on delete,
+	// they're deleted first or the conversation can't be deleted.
+	if _, err := tx.Stmtx(m.q.DeleteConversationCSATResponses).Exec(uuid); err != nil {
+		m.lo.Error("error deleting conversation CSAT responses", "uuid", uuid, "error", err)
+		return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorDeleting", "name", "{globals.terms.conversation}"), nil)
+	}
+	if _, err := tx.Stmtx(m.q.DeleteConversationAppliedSLAs).Exec(uuid); err != nil {
+		m.lo.Error("error deleting conversation applied SLAs", "uuid", uuid, "error", err)
+		return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorDeleting", "name", "{globals.terms.conversation}"), nil)
+	}
+	if _, err := tx.Stmtx(m.q.DeleteConversation).Exec(uuid); err != nil {
+		m.lo.Error("error deleting conversation", "uuid", uuid, "error", err)
+		return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorDeleting", "name", "{globals.terms.conversation}"), nil)
+	}
+	if err := tx.Commit(); err != nil {
+		m.lo.Error("error committing conversation delete", "uuid", uuid, "error", err)
+		return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorDeleting", "name", "{globals.terms.conversation}"), nil)
+	}
+
+	// Media isn't linked to messages with a foreign key, delete the records and the files once the conversation is gone.
+	for _, mediaUUID := range mediaUUIDs {
+		if err := m.mediaStore.Delete(mediaUUID); err != nil {
+			m.lo.Error("error deleting conversation media", "uuid", uuid, "media_uuid", mediaUUID, "error", err)
+		}
+	}
+	return nil
+}
+
+// RunDeletedConversationPurger periodically deletes for good the conversations soft-deleted for longer than
+// the retention. Purging is disabled if the retention is 0.
+func (m *Manager) RunDeletedConversationPurger(ctx context.Context, retention time.Duration) {
+	if retention <= 0 {
+		return
+	}
+	ticker := time.NewTicker(deletedPurgeInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.purgeDeletedConversations(ctx, retention)
+		}
+	}
+}
+
+// purgeDeletedConversations deletes the conversations soft-deleted for longer than the retention.
+func (m *Manager) purgeDeletedConversations(ctx context.Context, retention time.Duration) {
+	var uuids []string
+	if err := m.q.GetConversationsToPurge.SelectContext(ctx, &uuids, fmt.Sprintf("%d seconds", int64(retention.Seconds())), deletedPurgeBatchSize); err != nil {
+		m.lo.Error("error fetching deleted conversations to purge", "error", err)
+		return
+	}
+	var purged int
+	for _, uuid := range uuids {
+		if err := m.DeleteConversation(uuid); err != nil {
+			continue
+		}
+		purged++
+	}
+	if purged > 0 {
+		m.lo.Info(fmt.Sprintf("purged %d deleted conversations", purged))
+	}
+}
@@ -0,0 +1,133 @@
+package conversation
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/abhinavxd/libredesk/internal/dbutil"
+	"github.com/jmoiron/sqlx"
+	_ "github.com/lib/pq"
+	"github.com/zerodha/logf"
+)
+
+// testDBEnv is the DSN of a Postgres database for the tests that need one, they're skipped when it's not set.
+// Each run loads the schema in a new Postgres schema that's dropped afterwards.
+const testDBEnv = "LIBREDESK_TEST_DB"
+
+// deleteTestMedia records the deleted media files.
+type deleteTestMedia struct {
+	mediaStore
+	deleted []string
+}
+
+func (d *deleteTestMedia) Delete(name string) error {
+	d.deleted = append(d.deleted, name)
+	return nil
+}
+
+// newTestDB returns a connection to a new schema loaded with schema.sql.
+func newTestDB(t *testing.T) *sqlx.DB {
+	t.Helper()
+	dsn := os.Getenv(testDBEnv)
+	if dsn == "" {
+		t.Skipf("%s not set", testDBEnv)
+	}
+
+	admin, err := sqlx.Connect("postgres", dsn)
+	if err != nil {
+		t.Fatalf("connecting to test database: %v", err)
+	}
+	schema := fmt.Sprintf("libredesk_test_%d", time.Now().UnixNano())
+	if _, err := admin.Exec("CREATE SCHEMA " + schema); err != nil {
+		t.Fatalf("creating test schema: %v", err)
+	}
+	t.Cleanup(func() {
+		admin.Exec("DROP SCHEMA " + schema + " CASCADE")
+		admin.Close()
+	})
+
+	// Every connection of the pool uses the new schema, it's set as a connection parameter in both DSN formats.
+	if strings.Contains(dsn, "://") {
+		sep := "?"
+		if strings.Contains(dsn, "?") {
+			sep = "&"
+		}
+		dsn += sep + "search_path=" + schema + ",public"
+	} else {
+		dsn += " search_path=" + schema + ",public"
+	}
+	db, err := sqlx.Connect("postgres", dsn)
+	if err != nil {
+		t.Fatalf("connecting to test schema: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	b, err := os.ReadFile("../../schema.sql")
+	if err != nil {
+		t.Fatalf("reading schema: %v", err)
+	}
+	if _, err := db.Exec(string(b)); err != nil {
+		t.Fatalf("loading schema: %v", err)
+	}
+	return db
+}
+
+func TestDeleteConversationWithCSATAndSLA(t *testing.T) {
+	db := newTestDB(t)
+
+	var q queries
+	if err := dbutil.ScanSQLFile("queries.sql", &q, db, efs); err != nil {
+		t.Fatalf("preparing queries: %v", err)
+	}
+	lo := logf.New(logf.Opts{Writer: io.Discard})
+	m := &Manager{q: q, db: db, lo: &lo, mediaStore: &deleteTestMedia{}}
+
+	var uuid string
+	err := db.QueryRow(`
+		WITH contact AS (
+			INSERT INTO users (type, first_name, email) VALUES ('contact', 'Test', 'contact@example.com') RETURNING id
+		),
+		inbox AS (
+			INSERT INTO inboxes (name, channel) VALUES ('Support', 'email') RETURNING id
+		),
+		channel AS (
+			INSERT INTO contact_channels (contact_id, inbox_id, identifier)
+			SELECT contact.id, inbox.id, 'contact@example.com' FROM contact, inbox RETURNING id, contact_id, inbox_id
+		),
+		conversation AS (
+			INSERT INTO conversations (contact_id, inbox_id, contact_channel_id, status_id)
+			SELECT channel.contact_id, channel.inbox_id, channel.id, (SELECT id FROM conversation_statuses WHERE name = 'Open')
+			FROM channel RETURNING id, uuid
+		),
+		csat AS (
+			INSERT INTO csat_responses (conversation_id, rating) SELECT id, 4 FROM conversation
+		),
+		policy AS (
+			INSERT INTO sla_policies (name, first_response_time, resolution_time) VALUES ('Default', '1h', '1d') RETURNING id
+		),
+		applied AS (
+			INSERT INTO applied_slas (conversation_id, sla_policy_id) SELECT conversation.id, policy.id FROM conversation, policy
+		)
+		SELECT uuid FROM conversation`).Scan(&uuid)
+	if err != nil {
+		t.Fatalf("inserting conversation: %v", err)
+	}
+
+	if err := m.DeleteConversation(uuid); err != nil {
+		t.Fatalf("DeleteConversation() error = %v", err)
+	}
+
+	for _, table := range []string{"conversations", "csat_responses", "applied_slas"} {
+		var n int
+		if err := db.Get(&n, "SELECT COUNT(*) FROM "+table); err != nil {
+			t.Fatalf("counting %s: %v", table, err)
+		}
+		if n != 0 {
+			t.Errorf("%s has %d rows after delete, want 0", table, n)
+		}
+	}
+}
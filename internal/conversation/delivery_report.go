@@ -0,0 +1,143 @@
+package conversation
+
+import (
+	"bufio"
+	"bytes"
+	"database/sql"
+	"errors"
+	"io"
+	"net/textproto"
+	"strings"
+
+	"github.com/abhinavxd/libredesk/internal/conversation/models"
+)
+
+// errNoDeliveryStatus is returned for a delivery report without recipient fields.
+var errNoDeliveryStatus = errors.New("delivery report has no recipient status")
+
+// deliveryResult is the outcome of a delivery status notification for an outgoing message.
+type deliveryResult struct {
+	// MessageID is the Message-ID of the original message, without angle brackets.
+	MessageID string
+	// Status is the new status of the message, empty when the delivery is only delayed.
+	Status string
+	// Diagnostic describes why the delivery failed.
+	Diagnostic string
+}
+
+// parseDeliveryReport parses a delivery status notification (RFC 3464). The message is failed if the delivery to
+// any recipient failed and delivered if it was delivered or relayed to the other ones.
+func parseDeliveryReport(report models.DeliveryReport) (deliveryResult, error) {
+	var result deliveryResult
+
+	// The status part has a block of per message fields followed by a block of fields per recipient.
+	var (
+		blocks []textproto.MIMEHeader
+		r      = textproto.NewReader(bufio.NewReader(bytes.NewReader(report.Status)))
+	)
+	for {
+		fields, err := r.ReadMIMEHeader()
+		if len(fields) > 0 {
+			blocks = append(blocks, fields)
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return result, err
+		}
+	}
+	if len(blocks) < 2 {
+		return result, errNoDeliveryStatus
+	}
+
+	// Correlate with the Message-ID of the returned original message, the envelope ID is the fallback.
+	if len(report.OriginalHeaders) > 0 {
+		headers, err := textproto.NewReader(bufio.NewReader(bytes.NewReader(report.OriginalHeaders))).ReadMIMEHeader()
+		if err != nil && err != io.EOF {
+			return result, err
+		}
+		result.MessageID = strings.Trim(strings.TrimSpace(headers.Get("Message-Id")), "<>")
+	}
+	if result.MessageID == "" {
+		result.MessageID = strings.Trim(strings.TrimSpace(blocks[0].Get("Original-Envelope-Id")), "<>")
+	}
+
+	for _, recipient := range blocks[1:] {
+		switch strings.ToLower(strings.TrimSpace(recipient.Get("Action"))) {
+		case "failed":
+			result.Status = models.MessageStatusFailed
+			result.Diagnostic = deliveryDiagnostic(recipient)
+			return result, nil
+		case "delivered", "relayed", "expanded":
+			result.Status = models.MessageStatusDelivered
+		}
+	}
+	return result, nil
+}
+
+// deliveryDiagnostic returns the reason of a failed delivery to a recipient, prefixed with the recipient.
+func deliveryDiagnostic(recipient textproto.MIMEHeader) string {
+	diagnostic := recipient.Get("Diagnostic-Code")
+	if diagnostic == "" {
+		diagnostic = recipient.Get("Status")
+	}
+	// Fields are of the form `type; value`, e.g. `smtp; 550 5.1.1 User unknown`.
+	if _, value, ok := strings.Cut(diagnostic, ";"); ok {
+		diagnostic = value
+	}
+	diagnostic = strings.TrimSpace(diagnostic)
+	if _, addr, ok := strings.Cut(recipient.Get("Final-Recipient"), ";"); ok {
+		diagnostic = strings.TrimSpace(addr) + ": " + diagnostic
+	}
+	return diagnostic
+}
+
+// processDeliveryReport updates the status of the outgoing message a delivery status notification reports on and
+// records the notification by its Message-ID so it isn't processed again. It returns false if the message isn't found,
+// the notification is then processed as a regular incoming message.
+func (m *Manager) processDeliveryReport(report models.DeliveryReport, sourceID string) (bool, error) {
+	result, err := parseDeliveryReport(report)
+	if err != nil {
+		m.lo.Warn("error parsing delivery status notification", "error", err)
+		return false, nil
+	}
+	if result.MessageID == "" {
+		return false, nil
+	}
+
+	var message struct {
+		ID     int    `db:"id"`
+		UUID   string `db:"uuid"`
+		Status string `db:"status"`
+	}
+	if err := m.q.GetOutgoingMessageBySourceID.Get(&message, result.MessageID); err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		m.lo.Error("error fetching message for delivery status notification", "source_id", result.MessageID, "error", err)
+		return false, err
+	}
+
+	// Only sent messages move to delivered and a message can still fail after being relayed.
+	switch {
+	case result.Status == models.MessageStatusDelivered && message.Status == models.MessageStatusSent:
+		m.lo.Debug("message delivered", "uuid", message.UUID)
+		if err := m.UpdateMessageStatus(message.UUID, models.MessageStatusDelivered); err != nil {
+			return true, err
+		}
+	case result.Status == models.MessageStatusFailed && (message.Status == models.MessageStatusSent || message.Status == models.MessageStatusDelivered):
+		m.lo.Info("message delivery failed", "uuid", message.UUID, "diagnostic", result.Diagnostic)
+		if err := m.updateMessageStatus(message.UUID, models.MessageStatusFailed, result.Diagnostic); err != nil {
+			return true, err
+		}
+	}
+
+	if sourceID != "" {
+		if _, err := m.q.InsertDeliveryReport.Exec(sourceID, message.ID); err != nil {
+			m.lo.Error("error recording delivery status notification", "source_id", sourceID, "error", err)
+			return true, err
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,72 @@
+package conversation
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/abhinavxd/libredesk/internal/conversation/models"
+)
+
+func TestParseDeliveryReport(t *testing.T) {
+	const (
+		perMessage = "Reporting-MTA: dns; mx.example.com\r\nOriginal-Envelope-Id: <envelope-id@example.com>\r\n\r\n"
+		delivered  = "Final-Recipient: rfc822; jane@example.com\r\nAction: delivered\r\nStatus: 2.0.0\r\n\r\n"
+		delayed    = "Final-Recipient: rfc822; jane@example.com\r\nAction: delayed\r\nStatus: 4.4.7\r\n\r\n"
+		failed     = "Final-Recipient: rfc822; john@example.com\r\nAction: failed\r\nStatus: 5.1.1\r\nDiagnostic-Code: smtp; 550 5.1.1 User unknown\r\n"
+		headers    = "Message-ID: <original-id@example.com>\r\nSubject: Hello\r\n\r\n"
+	)
+	tests := []struct {
+		name     string
+		report   models.DeliveryReport
+		expected deliveryResult
+		err      error
+	}{
+		{
+			name:     "delivered",
+			report:   models.DeliveryReport{Status: []byte(perMessage + delivered), OriginalHeaders: []byte(headers)},
+			expected: deliveryResult{MessageID: "original-id@example.com", Status: models.MessageStatusDelivered},
+		},
+		{
+			name:     "failed for one recipient",
+			report:   models.DeliveryReport{Status: []byte(perMessage + delivered + failed), OriginalHeaders: []byte(headers)},
+			expected: deliveryResult{MessageID: "original-id@example.com", Status: models.MessageStatusFailed, Diagnostic: "john@example.com: 550 5.1.1 User unknown"},
+		},
+		{
+			name:     "failed without diagnostic code",
+			report:   models.DeliveryReport{Status: []byte(perMessage + strings.Replace(failed, "Diagnostic-Code: smtp; 550 5.1.1 User unknown\r\n", "", 1)), OriginalHeaders: []byte(headers)},
+			expected: deliveryResult{MessageID: "original-id@example.com", Status: models.MessageStatusFailed, Diagnostic: "john@example.com: 5.1.1"},
+		},
+		{
+			name:     "delayed",
+			report:   models.DeliveryReport{Status: []byte(perMessage + delayed), OriginalHeaders: []byte(headers)},
+			expected: deliveryResult{MessageID: "original-id@example.com"},
+		},
+		{
+			name:     "envelope id without original headers",
+			report:   models.DeliveryReport{Status: []byte(perMessage + delivered)},
+			expected: deliveryResult{MessageID: "envelope-id@example.com", Status: models.MessageStatusDelivered},
+		},
+		{
+			name:     "unix line endings",
+			report:   models.DeliveryReport{Status: []byte(strings.ReplaceAll(perMessage+failed, "\r\n", "\n")), OriginalHeaders: []byte(strings.ReplaceAll(headers, "\r\n", "\n"))},
+			expected: deliveryResult{MessageID: "original-id@example.com", Status: models.MessageStatusFailed, Diagnostic: "john@example.com: 550 5.1.1 User unknown"},
+		},
+		{
+			name:   "no recipient fields",
+			report: models.DeliveryReport{Status: []byte(perMessage)},
+			err:    errNoDeliveryStatus,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseDeliveryReport(tt.report)
+			if err != tt.err {
+				t.Fatalf("got error %v, want %v", err, tt.err)
+			}
+			if got != tt.expected {
+				t.Errorf("got %+v, want %+v", got, tt.expected)
+			}
+		})
+	}
+}
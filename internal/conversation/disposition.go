@@ -0,0 +1,17 @@
+package conversation
+
+import (
+	"strings"
+
+	mmodels "github.com/abhinavxd/libredesk/internal/media/models"
+)
+
+// resolveDisposition returns the disposition an attachment of an outgoing message is sent with. Images the content
+// references by their content ID, the media UUID, are inline so clients show them in place, everything else is a
+// regular attachment so clients list it.
+func resolveDisposition(media mmodels.Media, content string) string {
+	if strings.HasPrefix(strings.ToLower(media.ContentType), "image/") && strings.Contains(content, "cid:"+media.UUID) {
+		return mmodels.DispositionInline
+	}
+	return mmodels.DispositionAttachment
+}
@@ -0,0 +1,33 @@
+package conversation
+
+import (
+	"testing"
+
+	mmodels "github.com/abhinavxd/libredesk/internal/media/models"
+	"github.com/volatiletech/null/v9"
+)
+
+func TestResolveDisposition(t *testing.T) {
+	const uuid = "0b7d3f2e-1c4a-4f7e-9a51-3d2b6c8e9f10"
+	content := `<p>See below</p><img class="inline-image" src="cid:` + uuid + `" title="` + uuid + `">`
+	tests := []struct {
+		name     string
+		media    mmodels.Media
+		content  string
+		expected string
+	}{
+		{name: "referenced image", media: mmodels.Media{UUID: uuid, ContentType: "image/png"}, content: content, expected: mmodels.DispositionInline},
+		{name: "referenced image stored as attachment", media: mmodels.Media{UUID: uuid, ContentType: "IMAGE/JPEG", Disposition: null.StringFrom("attachment")}, content: content, expected: mmodels.DispositionInline},
+		{name: "unreferenced image stored as inline", media: mmodels.Media{UUID: uuid, ContentType: "image/png", Disposition: null.StringFrom("inline")}, content: "<p>No images</p>", expected: mmodels.DispositionAttachment},
+		{name: "referenced file", media: mmodels.Media{UUID: uuid, ContentType: "application/pdf"}, content: content, expected: mmodels.DispositionAttachment},
+		{name: "plain text content", media: mmodels.Media{UUID: uuid, ContentType: "image/png"}, content: "See below", expected: mmodels.DispositionAttachment},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := resolveDisposition(tt.media, tt.content); got != tt.expected {
+				t.Errorf("got %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package conversation
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/abhinavxd/libredesk/internal/conversation/models"
+	"github.com/abhinavxd/libredesk/internal/envelope"
+)
+
+const (
+	// defaultDraftTTL is the duration after which untouched drafts are deleted.
+	defaultDraftTTL = 30 * 24 * time.Hour
+	// draftCleanupInterval is the interval at which stale drafts are deleted.
+	draftCleanupInterval = 1 * time.Hour
+)
+
+// SaveDraft saves the draft of a reply or private note of a user in a conversation, replacing the existing one.
+// Drafts are private to the user and are not broadcasted.
+func (m *Manager) SaveDraft(userID int, conversationUUID string, content string, private bool) (models.Draft, error) {
+	var draft models.Draft
+	if err := m.q.UpsertDraft.Get(&draft, userID, conversationUUID, content, private); err != nil {
+		m.lo.Error("error saving draft", "user_id", userID, "conversation_uuid", conversationUUID, "error", err)
+		return draft, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorSaving", "name", "{globals.terms.draft}"), nil)
+	}
+	return draft, nil
+}
+
+// GetDraft returns the draft of a user in a conversation.
+func (m *Manager) GetDraft(userID int, conversationUUID string) (models.Draft, error) {
+	var draft models.Draft
+	if err := m.q.GetDraft.Get(&draft, userID, conversationUUID); err != nil {
+		if err == sql.ErrNoRows {
+			return draft, envelope.NewError(envelope.NotFoundError, m.i18n.Ts("globals.messages.notFound", "name", "{globals.terms.draft}"), nil)
+		}
+		m.lo.Error("error fetching draft", "user_id", userID, "conversation_uuid", conversationUUID, "error", err)
+		return draft, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.draft}"), nil)
+	}
+	return draft, nil
+}
+
+// DeleteDraft deletes the draft of a user in a conversation.
+func (m *Manager) DeleteDraft(userID int, conversationUUID string) error {
+	if _, err := m.q.DeleteDraft.Exec(userID, conversationUUID); err != nil {
+		m.lo.Error("error deleting draft", "user_id", userID, "conversation_uuid", conversationUUID, "error", err)
+		return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorDeleting", "name", "{globals.terms.draft}"), nil)
+	}
+	return nil
+}
+
+// RunDraftCleaner periodically deletes drafts that have not been updated for the passed TTL.
+func (m *Manager) RunDraftCleaner(ctx context.Context, ttl time.Duration) {
+	if ttl <= 0 {
+		ttl = defaultDraftTTL
+	}
+	ticker := time.NewTicker(draftCleanupInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.deleteStaleDrafts(ctx, ttl)
+		}
+	}
+}
+
+// deleteStaleDrafts deletes drafts older than the TTL.
+func (m *Manager) deleteStaleDrafts(ctx context.Context, ttl time.Duration) {
+	res, err := m.q.DeleteStaleDrafts.ExecContext(ctx, fmt.Sprintf("%d seconds", int64(ttl.Seconds())))
+	if err != nil {
+		m.lo.Error("error deleting stale drafts", "error", err)
+		return
+	}
+	rows, _ := res.RowsAffected()
+	if rows > 0 {
+		m.lo.Info(fmt.Sprintf("deleted %d stale drafts", rows))
+	}
+}
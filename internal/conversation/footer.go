@@ -0,0 +1,59 @@
+package conversation
+
+import (
+	"fmt"
+	"html"
+	"strings"
+	"text/template"
+
+	"github.com/abhinavxd/libredesk/internal/conversation/models"
+	imodels "github.com/abhinavxd/libredesk/internal/inbox/models"
+	"github.com/abhinavxd/libredesk/internal/stringutil"
+)
+
+// appendInboxFooter appends the footer of the inbox to the content of an outgoing email, after the agent signature
+// which is part of the content. The footer is rendered with the content, so it can use the same placeholders, and
+// `UnsubscribeURL` is added to the template data. Private notes never get the footer.
+func appendInboxFooter(message *models.Message, footer imodels.Footer, data map[string]any) error {
+	if message.Private || footer.IsEmpty() {
+		return nil
+	}
+
+	unsubscribeURL, err := renderUnsubscribeURL(footer.UnsubscribeURL, data)
+	if err != nil {
+		return err
+	}
+	data["UnsubscribeURL"] = unsubscribeURL
+
+	if message.ContentType == models.ContentTypeText {
+		text := footer.Text
+		if strings.TrimSpace(text) == "" {
+			text = stringutil.HTML2Text(footer.HTML)
+		}
+		message.Content = strings.TrimRight(message.Content, "\n") + "\n\n" + text
+		return nil
+	}
+
+	htmlFooter := footer.HTML
+	if strings.TrimSpace(htmlFooter) == "" {
+		htmlFooter = strings.ReplaceAll(html.EscapeString(footer.Text), "\n", "<br>")
+	}
+	message.Content += "<br>" + htmlFooter
+	return nil
+}
+
+// renderUnsubscribeURL renders the placeholders of the unsubscribe URL of an inbox footer.
+func renderUnsubscribeURL(url string, data map[string]any) (string, error) {
+	if url == "" {
+		return "", nil
+	}
+	tmpl, err := template.New("unsubscribe").Parse(url)
+	if err != nil {
+		return "", fmt.Errorf("parsing unsubscribe URL: %w", err)
+	}
+	var out strings.Builder
+	if err := tmpl.Execute(&out, data); err != nil {
+		return "", fmt.Errorf("rendering unsubscribe URL: %w", err)
+	}
+	return out.String(), nil
+}
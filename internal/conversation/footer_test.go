@@ -0,0 +1,75 @@
+package conversation
+
+import (
+	"testing"
+
+	"github.com/abhinavxd/libredesk/internal/conversation/models"
+	imodels "github.com/abhinavxd/libredesk/internal/inbox/models"
+)
+
+func TestAppendInboxFooter(t *testing.T) {
+	footer := imodels.Footer{
+		HTML:           `<p>Legal</p><a href="{{ .UnsubscribeURL }}">Unsubscribe</a>`,
+		Text:           "Legal\nUnsubscribe: {{ .UnsubscribeURL }}",
+		UnsubscribeURL: "https://example.com/unsubscribe?email={{ .Contact.Email | urlquery }}",
+	}
+	tests := []struct {
+		name     string
+		message  models.Message
+		footer   imodels.Footer
+		expected string
+	}{
+		{
+			name:     "html message",
+			message:  models.Message{Content: "<p>Hi</p>", ContentType: models.ContentTypeHTML},
+			footer:   footer,
+			expected: `<p>Hi</p><br><p>Legal</p><a href="{{ .UnsubscribeURL }}">Unsubscribe</a>`,
+		},
+		{
+			name:     "text message",
+			message:  models.Message{Content: "Hi\n", ContentType: models.ContentTypeText},
+			footer:   footer,
+			expected: "Hi\n\nLegal\nUnsubscribe: {{ .UnsubscribeURL }}",
+		},
+		{
+			name:     "html message with text footer only",
+			message:  models.Message{Content: "<p>Hi</p>", ContentType: models.ContentTypeHTML},
+			footer:   imodels.Footer{Text: "A & B\nLegal"},
+			expected: "<p>Hi</p><br>A &amp; B<br>Legal",
+		},
+		{
+			name:     "private note",
+			message:  models.Message{Content: "<p>Hi</p>", ContentType: models.ContentTypeHTML, Private: true},
+			footer:   footer,
+			expected: "<p>Hi</p>",
+		},
+		{
+			name:     "no footer",
+			message:  models.Message{Content: "<p>Hi</p>", ContentType: models.ContentTypeHTML},
+			expected: "<p>Hi</p>",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := map[string]any{"Contact": map[string]any{"Email": "a+b@example.com"}}
+			if err := appendInboxFooter(&tt.message, tt.footer, data); err != nil {
+				t.Fatal(err)
+			}
+			if tt.message.Content != tt.expected {
+				t.Errorf("got %q, want %q", tt.message.Content, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRenderUnsubscribeURL(t *testing.T) {
+	data := map[string]any{"Contact": map[string]any{"Email": "a+b@example.com"}}
+	got, err := renderUnsubscribeURL("https://example.com/unsubscribe?email={{ .Contact.Email | urlquery }}", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "https://example.com/unsubscribe?email=a%2Bb%40example.com"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
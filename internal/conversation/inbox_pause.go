@@ -0,0 +1,66 @@
+package conversation
+
+import (
+	"github.com/abhinavxd/libredesk/internal/envelope"
+	wsmodels "github.com/abhinavxd/libredesk/internal/ws/models"
+)
+
+// PauseInbox stops dispatching outgoing messages of the inbox, its pending messages stay pending until the inbox is resumed.
+// The pause is stored with the inbox, so it holds across restarts and instances.
+func (m *Manager) PauseInbox(inboxID int) error {
+	return m.setInboxPaused(inboxID, true)
+}
+
+// ResumeInbox resumes dispatching outgoing messages of a paused inbox, its pending messages are picked up on the next scan.
+func (m *Manager) ResumeInbox(inboxID int) error {
+	return m.setInboxPaused(inboxID, false)
+}
+
+// setInboxPaused stores the dispatch pause of the inbox and broadcasts it if it changed.
+func (m *Manager) setInboxPaused(inboxID int, paused bool) error {
+	if _, err := m.inboxStore.GetDBRecord(inboxID); err != nil {
+		return err
+	}
+	res, err := m.q.SetInboxDispatchPaused.Exec(inboxID, paused)
+	if err != nil {
+		m.lo.Error("error updating inbox dispatch pause", "inbox_id", inboxID, "paused", paused, "error", err)
+		return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorUpdating", "name", "{globals.terms.inbox}"), nil)
+	}
+	if n, _ := res.RowsAffected(); n > 0 {
+		m.lo.Info("updated dispatching for inbox", "inbox_id", inboxID, "paused", paused)
+		m.BroadcastInboxUpdate(inboxID, "paused", paused)
+	}
+	return nil
+}
+
+// IsInboxPaused returns true if dispatching is paused for the inbox.
+func (m *Manager) IsInboxPaused(inboxID int) (bool, error) {
+	var paused bool
+	if err := m.q.IsInboxDispatchPaused.Get(&paused, inboxID); err != nil {
+		m.lo.Error("error fetching inbox dispatch pause", "inbox_id", inboxID, "error", err)
+		return false, err
+	}
+	return paused, nil
+}
+
+// GetPausedInboxIDs returns the IDs of the inboxes with dispatching paused.
+func (m *Manager) GetPausedInboxIDs() ([]int, error) {
+	var out = make([]int, 0)
+	if err := m.q.GetDispatchPausedInboxIDs.Select(&out); err != nil {
+		m.lo.Error("error fetching paused inboxes", "error", err)
+		return out, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.inbox}"), nil)
+	}
+	return out, nil
+}
+
+// BroadcastInboxUpdate broadcasts an inbox update to all users.
+func (m *Manager) BroadcastInboxUpdate(inboxID int, prop string, value any) {
+	m.broadcastToUsers([]int{}, wsmodels.Message{
+		Type: wsmodels.MessageTypeInboxPropUpdate,
+		Data: map[string]interface{}{
+			"id":    inboxID,
+			"prop":  prop,
+			"value": value,
+		},
+	})
+}
@@ -0,0 +1,82 @@
+package conversation
+
+import (
+	"sync"
+	"time"
+)
+
+// incomingLimiter limits the number of incoming messages per key in a fixed window, a key exceeding
+// the limit is blocked for `blockFor` so that a single abusive sender can't flood the incoming queue.
+type incomingLimiter struct {
+	mu        sync.Mutex
+	limit     int
+	window    time.Duration
+	blockFor  time.Duration
+	keys      map[string]*incomingWindow
+	lastSweep time.Time
+}
+
+// incomingWindow is the message count of a key in the current window.
+type incomingWindow struct {
+	start        time.Time
+	count        int
+	blockedUntil time.Time
+}
+
+// newIncomingLimiter returns a limiter allowing `limit` messages per key every `window`, nil if the limit is disabled.
+func newIncomingLimiter(limit int, window, blockFor time.Duration) *incomingLimiter {
+	if limit <= 0 || window <= 0 {
+		return nil
+	}
+	if blockFor < window {
+		blockFor = window
+	}
+	return &incomingLimiter{
+		limit:    limit,
+		window:   window,
+		blockFor: blockFor,
+		keys:     make(map[string]*incomingWindow),
+	}
+}
+
+// allow counts a message for the key and returns false if the key is over its limit or blocked.
+func (l *incomingLimiter) allow(key string, now time.Time) bool {
+	if l == nil {
+		return true
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.sweep(now)
+
+	w, ok := l.keys[key]
+	if !ok {
+		w = &incomingWindow{start: now}
+		l.keys[key] = w
+	}
+	if now.Before(w.blockedUntil) {
+		return false
+	}
+	if now.Sub(w.start) >= l.window {
+		w.start, w.count = now, 0
+	}
+	w.count++
+	if w.count > l.limit {
+		w.blockedUntil = now.Add(l.blockFor)
+		return false
+	}
+	return true
+}
+
+// sweep removes the keys whose window and block have expired, at most once per window.
+func (l *incomingLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < l.window {
+		return
+	}
+	l.lastSweep = now
+	for key, w := range l.keys {
+		if now.Sub(w.start) >= l.window && !now.Before(w.blockedUntil) {
+			delete(l.keys, key)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package conversation
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIncomingLimiter(t *testing.T) {
+	var (
+		now = time.Now()
+		l   = newIncomingLimiter(2, time.Minute, 10*time.Minute)
+	)
+
+	tests := []struct {
+		name     string
+		key      string
+		at       time.Time
+		expected bool
+	}{
+		{name: "first", key: "a", at: now, expected: true},
+		{name: "second", key: "a", at: now.Add(time.Second), expected: true},
+		{name: "over limit", key: "a", at: now.Add(2 * time.Second), expected: false},
+		{name: "other key", key: "b", at: now.Add(2 * time.Second), expected: true},
+		{name: "blocked after window", key: "a", at: now.Add(2 * time.Minute), expected: false},
+		{name: "block expired", key: "a", at: now.Add(11 * time.Minute), expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := l.allow(tt.key, tt.at); got != tt.expected {
+				t.Errorf("got %v, want %v", got, tt.expected)
+			}
+		})
+	}
+
+	if newIncomingLimiter(0, time.Minute, 0).allow("a", now) != true {
+		t.Error("disabled limiter should allow")
+	}
+}
@@ -0,0 +1,21 @@
+package conversation
+
+import (
+	"github.com/abhinavxd/libredesk/internal/conversation/models"
+	"github.com/abhinavxd/libredesk/internal/langdetect"
+	"github.com/abhinavxd/libredesk/internal/stringutil"
+	"github.com/volatiletech/null/v9"
+)
+
+// minConversationLanguageConfidence is the minimum confidence of a detected message language for it to be set
+// as the conversation language.
+const minConversationLanguageConfidence = 0.5
+
+// detectMessageLanguage detects the language of the message without the quoted reply history and signature and
+// sets it on the message. Messages too short to tell are recorded with zero confidence and no language.
+func detectMessageLanguage(message *models.Message) {
+	text := stringutil.StripSignature(stringutil.StripQuotedReply(stringutil.HTML2Text(message.Content)))
+	res := langdetect.Detect(text)
+	message.Language = null.NewString(res.Lang, res.Lang != "")
+	message.LanguageConfidence = null.Float64From(res.Confidence)
+}
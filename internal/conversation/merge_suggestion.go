@@ -0,0 +1,71 @@
+package conversation
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/abhinavxd/libredesk/internal/conversation/models"
+	"github.com/abhinavxd/libredesk/internal/envelope"
+)
+
+const (
+	// maxMergeCandidates is the maximum number of merge candidates returned.
+	maxMergeCandidates = 5
+	// mergeCandidatesWindow is how far back, from the conversation's creation, merge candidates are looked up.
+	mergeCandidatesWindow = 14 * 24 * time.Hour
+	// minMergeSubjectSimilarity is the minimum trigram similarity of the subjects of conversations from different
+	// contacts for them to be suggested.
+	minMergeSubjectSimilarity = 0.6
+	// sameContactMergeWeight is the score weight of being from the same contact, subject similarity and recency
+	// are both between 0 and 1.
+	sameContactMergeWeight = 1.0
+	// recencyMergeWeight is the score weight of recency, lower than the similarity as an older duplicate is still
+	// a duplicate.
+	recencyMergeWeight = 0.5
+)
+
+// SuggestMerges returns the open conversations that look like duplicates of the conversation, the ones from the
+// same contact or with a similar subject created around the same time, ranked by similarity and recency.
+func (c *Manager) SuggestMerges(conversationUUID string) ([]models.MergeCandidate, error) {
+	var candidates = make([]models.MergeCandidate, 0)
+	if err := c.q.GetMergeCandidates.Select(&candidates, conversationUUID, mergeCandidatesWindow.Seconds(), minMergeSubjectSimilarity); err != nil {
+		c.lo.Error("error fetching merge candidates", "conversation_uuid", conversationUUID, "error", err)
+		return candidates, envelope.NewError(envelope.GeneralError, c.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.conversation}"), nil)
+	}
+	return rankMergeCandidates(candidates, time.Now(), maxMergeCandidates), nil
+}
+
+// rankMergeCandidates scores the candidates, sets the reason and returns the top `limit` candidates.
+func rankMergeCandidates(candidates []models.MergeCandidate, now time.Time, limit int) []models.MergeCandidate {
+	for i := range candidates {
+		cand := &candidates[i]
+
+		recency := 1 - now.Sub(cand.CreatedAt).Hours()/mergeCandidatesWindow.Hours()
+		recency = max(0, min(1, recency))
+		cand.Score = cand.SubjectSimilarity + recency*recencyMergeWeight
+		if cand.SameContact {
+			cand.Score += sameContactMergeWeight
+		}
+
+		var reasons []string
+		if cand.SameContact {
+			reasons = append(reasons, "Same contact")
+		}
+		if cand.SubjectSimilarity >= minMergeSubjectSimilarity {
+			reasons = append(reasons, fmt.Sprintf("%.0f%% similar subject", cand.SubjectSimilarity*100))
+		}
+		cand.Reason = strings.Join(reasons, ", ")
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].Score != candidates[j].Score {
+			return candidates[i].Score > candidates[j].Score
+		}
+		return candidates[i].ID > candidates[j].ID
+	})
+	if len(candidates) > limit {
+		candidates = candidates[:limit]
+	}
+	return candidates
+}
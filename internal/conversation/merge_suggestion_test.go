@@ -0,0 +1,55 @@
+package conversation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/abhinavxd/libredesk/internal/conversation/models"
+)
+
+func TestRankMergeCandidates(t *testing.T) {
+	now := time.Now()
+	candidates := []models.MergeCandidate{
+		{ID: 1, SubjectSimilarity: 0.9, CreatedAt: now.Add(-time.Hour)},
+		{ID: 2, SameContact: true, SubjectSimilarity: 0.1, CreatedAt: now.Add(-10 * 24 * time.Hour)},
+		{ID: 3, SameContact: true, SubjectSimilarity: 0.7, CreatedAt: now.Add(-time.Hour)},
+		{ID: 4, SubjectSimilarity: 0.9, CreatedAt: now.Add(-13 * 24 * time.Hour)},
+	}
+
+	got := rankMergeCandidates(candidates, now, 3)
+	if len(got) != 3 {
+		t.Fatalf("rankMergeCandidates() returned %d candidates, want 3", len(got))
+	}
+	want := []int{3, 1, 2}
+	for i, id := range want {
+		if got[i].ID != id {
+			t.Errorf("candidate %d = %d, want %d", i, got[i].ID, id)
+		}
+	}
+	if got[0].Reason != "Same contact, 70% similar subject" {
+		t.Errorf("reason = %q", got[0].Reason)
+	}
+	if got[1].Reason != "90% similar subject" {
+		t.Errorf("reason = %q", got[1].Reason)
+	}
+	if got[2].Reason != "Same contact" {
+		t.Errorf("reason = %q", got[2].Reason)
+	}
+}
+
+func TestRankMergeCandidatesRecency(t *testing.T) {
+	now := time.Now()
+	candidates := []models.MergeCandidate{
+		{ID: 1, SubjectSimilarity: 0.8, CreatedAt: now.Add(-12 * 24 * time.Hour)},
+		{ID: 2, SubjectSimilarity: 0.8, CreatedAt: now.Add(-time.Hour)},
+		// Older than the window, recency doesn't go negative.
+		{ID: 3, SubjectSimilarity: 0.8, CreatedAt: now.Add(-30 * 24 * time.Hour)},
+	}
+	got := rankMergeCandidates(candidates, now, 5)
+	if got[0].ID != 2 || got[1].ID != 1 || got[2].ID != 3 {
+		t.Errorf("rankMergeCandidates() order = %d, %d, %d, want 2, 1, 3", got[0].ID, got[1].ID, got[2].ID)
+	}
+	if got[2].Score != 0.8 {
+		t.Errorf("score = %v, want 0.8", got[2].Score)
+	}
+}
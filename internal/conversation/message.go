@@ -1,13 +1,14 @@
 package conversation
 
 import (
-	"bytes"
 	"context"
 	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"path/filepath"
+	"regexp"
 	"slices"
 	"strings"
 	"time"
@@ -27,8 +28,22 @@ import (
 
 const (
 	maxMessagesPerPage = 100
+
+	// defaultLastMessagePreviewLen is the default number of characters of the last message stored on the conversation for previews.
+	defaultLastMessagePreviewLen = 100
 )
 
+// messagesTypeFilters are the SQL conditions of the message type filters of GetConversationMessages.
+var messagesTypeFilters = map[string]string{
+	"":                                  "",
+	models.MessagesFilterNoActivities:   "AND m.type != 'activity'",
+	models.MessagesFilterNotes:          "AND m.type != 'activity' AND m.private",
+	models.MessagesFilterCustomerFacing: "AND m.type IN ('incoming', 'outgoing') AND NOT m.private",
+}
+
+// subjectRefNumRe matches a reference number in square brackets in a subject, the `#` is optional.
+var subjectRefNumRe = regexp.MustCompile(`\[#?([A-Za-z0-9-]*[0-9])\]`)
+
 // Run starts a pool of worker goroutines to handle message dispatching via inbox's channel and processes incoming messages. It scans for
 // pending outgoing messages at the specified read interval and pushes them to the outgoing queue to be sent.
 func (m *Manager) Run(ctx context.Context, incomingQWorkers, outgoingQWorkers, scanInterval time.Duration) {
@@ -50,6 +65,14 @@ func (m *Manager) Run(ctx context.Context, incomingQWorkers, outgoingQWorkers, s
 		}()
 	}
 
+	// Not added to the wait group, they stop on their own once the manager is closed.
+	go m.RunIncomingSpilloverDrainer(ctx)
+	go m.runOutgoingWorkerScaler(ctx, int(outgoingQWorkers))
+
+	// Requeue the sends interrupted by a crash or restart before scanning, then sweep stuck sends periodically.
+	m.reconcileOutgoingMessages(0, reasonSendInterrupted)
+	go m.runOutgoingReconciler(ctx)
+
 	// Scan pending outgoing messages and send them.
 	for {
 		select {
@@ -59,18 +82,20 @@ func (m *Manager) Run(ctx context.Context, incomingQWorkers, outgoingQWorkers, s
 			var (
 				pendingMessages = []models.Message{}
 				messageIDs      = m.getOutgoingProcessingMessageIDs()
+				throttledIDs    = m.sendLimiter.throttledInboxIDs()
 			)
 
-			// Get pending outgoing messages and skip the currently processing message ids.
-			if err := m.q.GetPendingMessages.Select(&pendingMessages, pq.Array(messageIDs)); err != nil {
+			// Get pending outgoing messages and skip the currently processing message ids and the throttled inboxes,
+			// paused inboxes are skipped by the query.
+			if err := m.q.GetPendingMessages.Select(&pendingMessages, pq.Array(messageIDs), m.outgoingPriorityOrder, int(m.outgoingPriorityAgeBoost.Seconds()), pq.Array(throttledIDs)); err != nil {
 				m.lo.Error("error fetching pending messages from db", "error", err)
 				continue
 			}
 
 			// Prepare and push the message to the outgoing queue.
 			for _, message := range pendingMessages {
-				// Put the message ID in the processing map.
-				m.outgoingProcessingMessages.Store(message.ID, message.ID)
+				// Put the message ID in the processing map with the time it was claimed.
+				m.outgoingProcessingMessages.Store(message.ID, time.Now())
 
 				// Push the message to the outgoing message queue.
 				m.outgoingMessageQueue <- message
@@ -124,13 +149,37 @@ func (m *Manager) MessageSenderWorker(ctx context.Context) {
 
 // sendOutgoingMessage sends an outgoing message.
 func (m *Manager) sendOutgoingMessage(message models.Message) {
-	defer m.outgoingProcessingMessages.Delete(message.ID)
+	// Only release the claim of this send, the message may have been claimed again if this send was stuck and swept.
+	claim, _ := m.outgoingProcessingMessages.Load(message.ID)
+	defer m.outgoingProcessingMessages.CompareAndDelete(message.ID, claim)
+
+	// Leave the message pending if the inbox got paused after the message was queued, or if that can't be checked.
+	if paused, err := m.IsInboxPaused(message.InboxID); err != nil || paused {
+		m.lo.Debug("inbox paused, leaving message pending", "inbox_id", message.InboxID, "message_id", message.ID)
+		return
+	}
+
+	// Leave the message pending if the inbox is already at its concurrent send limit, scans skip the inbox until a send
+	// of it is done.
+	if !m.sendLimiter.acquire(message.InboxID) {
+		m.lo.Debug("inbox at concurrent send limit, leaving message pending", "inbox_id", message.InboxID, "message_id", message.ID)
+		return
+	}
+	defer m.sendLimiter.release(message.InboxID)
+
+	// Leave the message pending if the inbox is over its provider send rate limit instead of failing it, scans skip
+	// the inbox for a while.
+	if !m.inboxStore.AllowSend(message.InboxID) {
+		m.lo.Debug("inbox send rate limit reached, leaving message pending", "inbox_id", message.InboxID, "message_id", message.ID)
+		m.sendLimiter.backOff(message.InboxID, rateLimitBackoff)
+		return
+	}
 
 	// Helper function to handle errors
 	handleError := func(err error, errorMsg string) bool {
 		if err != nil {
 			m.lo.Error(errorMsg, "error", err, "message_id", message.ID)
-			m.UpdateMessageStatus(message.UUID, models.MessageStatusFailed)
+			m.updateMessageStatus(message.UUID, models.MessageStatusFailed, err.Error())
 			return true
 		}
 		return false
@@ -148,18 +197,21 @@ func (m *Manager) sendOutgoingMessage(message models.Message) {
 		return
 	}
 
-	// Attach attachments to the message
-	if err := m.attachAttachmentsToMessage(&message); err != nil {
-		handleError(err, "error attaching attachments to message")
+	// Refuse to send to the inbox's own addresses, which would create a mail loop, and to blocked addresses.
+	if addr, blocked := m.checkBlockedRecipient(inbox, message); blocked {
+		handleError(fmt.Errorf("recipient %s is blocked, it's an address of the inbox or in the blocklist", addr), "refusing to send message")
 		return
 	}
 
-	// Set from and to addresses
-	message.From = inbox.FromAddress()
-	message.To, err = m.GetToAddress(message.ConversationID)
-	if handleError(err, "error fetching `to` address") {
+	// Set from and to addresses, the Cc recipients of the thread are added to the ones set on the message for reply all.
+	// The addresses of the inbox are never recipients.
+	message.From = m.replyFromAddress(inbox, message.ConversationID)
+	recipients, err := m.GetRecipients(message.ConversationID, message.ReplyAll, append([]string{message.From, inbox.FromAddress()}, inbox.Aliases()...)...)
+	if handleError(err, "error fetching recipients") {
 		return
 	}
+	message.To = recipients.To
+	message.CC = dedupeAddresses(append([]string{message.From}, message.To...), message.CC, recipients.CC)
 
 	// Set "In-Reply-To" and "References" headers, logging any errors but continuing to send the message.
 	// Include only the last 20 messages as references to avoid exceeding header size limits.
@@ -178,8 +230,21 @@ func (m *Manager) sendOutgoingMessage(message models.Message) {
 		message.InReplyTo = message.References[len(message.References)-1]
 	}
 
-	// Send message
+	// Mark the message as being sent until its status is updated, so a send interrupted by a crash is reconciled.
+	// Media can't be attached to the message once it's marked, marking waits for an attach in progress.
+	if _, err := m.q.MarkMessageSending.Exec(message.ID); err != nil {
+		m.lo.Error("error marking message as being sent", "message_id", message.ID, "error", err)
+	}
+
+	// Attach attachments to the message, read after marking it so media attached while it was queued is sent too.
+	if err := m.attachAttachmentsToMessage(&message); err != nil {
+		handleError(err, "error attaching attachments to message")
+		return
+	}
+
+	// Send message, the result is recorded so that inboxes with consecutive failures are reported as degraded.
 	err = inbox.Send(message)
+	m.inboxStore.RecordSend(message.InboxID, err)
 	if handleError(err, "error sending message") {
 		return
 	}
@@ -191,13 +256,14 @@ func (m *Manager) sendOutgoingMessage(message models.Message) {
 	// All automated messages are sent by the system user.
 	if systemUser, err := m.userStore.GetSystemUser(); err == nil && message.SenderID != systemUser.ID {
 		m.UpdateConversationFirstReplyAt(message.ConversationUUID, message.ConversationID, time.Now())
+		m.recordAssignmentReply(message.ConversationID, time.Now())
 		m.UpdateConversationLastReplyAt(message.ConversationUUID, message.ConversationID, time.Now())
 	} else if err != nil {
 		m.lo.Error("error fetching system user for updating first reply time", "error", err)
 	}
 }
 
-// RenderContentInTemplate renders message content in template.
+// RenderContentInTemplate renders message content, followed by the inbox footer, in template.
 func (m *Manager) RenderContentInTemplate(channel string, message *models.Message) error {
 	switch channel {
 	case inbox.ChannelEmail:
@@ -206,13 +272,15 @@ func (m *Manager) RenderContentInTemplate(channel string, message *models.Messag
 			m.lo.Error("error fetching conversation", "uuid", message.ConversationUUID, "error", err)
 			return fmt.Errorf("fetching conversation: %w", err)
 		}
-		// Pass conversation and contact data to the template for rendering any placeholders.
-		message.Content, err = m.template.RenderEmailWithTemplate(map[string]any{
+		// Pass conversation and contact data to the template for rendering any placeholders, the outgoing template
+		// of the conversation's locale is used when there's one.
+		data := map[string]any{
 			"Conversation": map[string]any{
 				"ReferenceNumber": conversation.ReferenceNumber,
 				"Subject":         conversation.Subject.String,
 				"Priority":        conversation.Priority.String,
 				"UUID":            conversation.UUID,
+				"Locale":          conversation.EffectiveLocale(),
 			},
 			"Contact": map[string]any{
 				"FirstName": conversation.Contact.FirstName,
@@ -226,11 +294,47 @@ func (m *Manager) RenderContentInTemplate(channel string, message *models.Messag
 				"FullName":  conversation.Contact.FullName(),
 				"Email":     conversation.Contact.Email,
 			},
-		}, message.Content)
+		}
+		ibx, err := m.inboxStore.Get(message.InboxID)
+		if err != nil {
+			return fmt.Errorf("fetching inbox: %w", err)
+		}
+		// Plain text only inboxes send the text of the content and the text footer with their placeholders rendered,
+		// without the template and tracking.
+		if ibx.PlainTextOnly() {
+			if err := toPlainText(message); err != nil {
+				return err
+			}
+		}
+		if err := appendInboxFooter(message, ibx.Footer(), data); err != nil {
+			m.lo.Error("error adding inbox footer", "id", message.ID, "error", err)
+			return fmt.Errorf("adding inbox footer: %w", err)
+		}
+		if ibx.PlainTextOnly() {
+			message.Content, err = m.template.RenderText(data, message.Content)
+			if err != nil {
+				m.lo.Error("could not render plain text email content", "id", message.ID, "error", err)
+				return fmt.Errorf("could not render plain text email content: %w", err)
+			}
+			return nil
+		}
+		message.Content, err = m.template.RenderEmailWithTemplate(conversation.EffectiveLocale(), data, message.Content)
 		if err != nil {
 			m.lo.Error("could not render email content using template", "id", message.ID, "error", err)
 			return fmt.Errorf("could not render email content using template: %w", err)
 		}
+		if err := m.addEmailTracking(message); err != nil {
+			m.lo.Error("error adding email tracking, sending without it", "id", message.ID, "error", err)
+		}
+	case inbox.ChannelWebhook:
+		// Webhook replies are posted as written, the receiving system renders them.
+		ibx, err := m.inboxStore.Get(message.InboxID)
+		if err != nil {
+			return fmt.Errorf("fetching inbox: %w", err)
+		}
+		if ibx.PlainTextOnly() {
+			return toPlainText(message)
+		}
 	default:
 		m.lo.Warn("unknown message channel", "channel", channel)
 		return fmt.Errorf("unknown message channel: %s", channel)
@@ -238,15 +342,20 @@ func (m *Manager) RenderContentInTemplate(channel string, message *models.Messag
 	return nil
 }
 
-// GetConversationMessages retrieves messages for a specific conversation.
-func (m *Manager) GetConversationMessages(conversationUUID string, page, pageSize int) ([]models.Message, int, error) {
+// GetConversationMessages retrieves messages for a specific conversation, `typeFilter` is one of the
+// `models.MessagesFilter*` filters or empty for all messages.
+func (m *Manager) GetConversationMessages(conversationUUID, typeFilter string, page, pageSize int) ([]models.Message, int, error) {
 	var (
 		messages = make([]models.Message, 0)
 		qArgs    []interface{}
 	)
 
+	if _, ok := messagesTypeFilters[typeFilter]; !ok {
+		return messages, pageSize, envelope.NewError(envelope.InputError, m.i18n.Ts("globals.messages.invalid", "name", "`type`"), nil)
+	}
+
 	qArgs = append(qArgs, conversationUUID)
-	query, pageSize, qArgs, err := m.generateMessagesQuery(m.q.GetMessages, qArgs, page, pageSize)
+	query, pageSize, qArgs, err := m.generateMessagesQuery(m.q.GetMessages, qArgs, typeFilter, page, pageSize)
 	if err != nil {
 		m.lo.Error("error generating messages query", "error", err)
 		return messages, pageSize, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.message}"), nil)
@@ -279,9 +388,48 @@ func (m *Manager) GetMessage(uuid string) (models.Message, error) {
 	return message, nil
 }
 
+// GetUnansweredSince returns the time since which the contact is waiting for a reply, i.e. the time of the oldest
+// incoming message without a sent or delivered public reply after it. Zero time is returned if all messages are answered.
+func (m *Manager) GetUnansweredSince(conversationID int) (time.Time, error) {
+	var since time.Time
+	if err := m.q.GetUnansweredSince.Get(&since, conversationID); err != nil {
+		if err == sql.ErrNoRows {
+			return since, nil
+		}
+		m.lo.Error("error fetching unanswered since", "conversation_id", conversationID, "error", err)
+		return since, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.message}"), nil)
+	}
+	return since, nil
+}
+
+// BusinessMinutesSince returns the business minutes elapsed since the passed time as per the business hours of the team.
+func (m *Manager) BusinessMinutesSince(start time.Time, assignedTeamID int) (int, error) {
+	return m.slaStore.BusinessMinutesSince(start, assignedTeamID)
+}
+
+// GetLatestIncomingMessage retrieves the most recent incoming message of a conversation.
+// An empty message is returned if the conversation has no incoming messages yet.
+func (m *Manager) GetLatestIncomingMessage(conversationID int) (models.Message, error) {
+	var message models.Message
+	if err := m.q.GetLatestIncomingMessage.Get(&message, conversationID); err != nil {
+		if err == sql.ErrNoRows {
+			return message, nil
+		}
+		m.lo.Error("error fetching latest incoming message", "conversation_id", conversationID, "error", err)
+		return message, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.message}"), nil)
+	}
+	return message, nil
+}
+
 // UpdateMessageStatus updates the status of a message.
 func (m *Manager) UpdateMessageStatus(uuid string, status string) error {
-	if _, err := m.q.UpdateMessageStatus.Exec(status, uuid); err != nil {
+	return m.updateMessageStatus(uuid, status, "")
+}
+
+// updateMessageStatus updates the status of a message and records the transition in its status history along with
+// the error for failed messages.
+func (m *Manager) updateMessageStatus(uuid, status, errMsg string) error {
+	if _, err := m.q.UpdateMessageStatus.Exec(status, uuid, errMsg); err != nil {
 		m.lo.Error("error updating message status", "error", err, "uuid", uuid)
 		return err
 	}
@@ -292,6 +440,16 @@ func (m *Manager) UpdateMessageStatus(uuid string, status string) error {
 	return nil
 }
 
+// GetMessageStatusHistory returns the status transitions of a message, oldest first.
+func (m *Manager) GetMessageStatusHistory(uuid string) ([]models.MessageStatusChange, error) {
+	var history = make([]models.MessageStatusChange, 0)
+	if err := m.q.GetMessageStatusHistory.Select(&history, uuid); err != nil {
+		m.lo.Error("error fetching message status history", "uuid", uuid, "error", err)
+		return history, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.message}"), nil)
+	}
+	return history, nil
+}
+
 // MarkMessageAsPending updates message status to `Pending`, so if it's a outgoing message it can be picked up again by a worker.
 func (m *Manager) MarkMessageAsPending(uuid string) error {
 	if err := m.UpdateMessageStatus(uuid, models.MessageStatusPending); err != nil {
@@ -358,11 +516,92 @@ func (m *Manager) SendReply(media []mmodels.Media, inboxID, senderID int, conver
 		Meta:             string(metaJSON),
 		SourceID:         null.StringFrom(sourceID),
 	}
+	if err := m.ValidateOutgoing(message); err != nil {
+		return err
+	}
 	return m.InsertMessage(&message)
 }
 
+// AttachToMessage attaches media uploaded by the agent to an outgoing message that isn't being sent yet, the dispatch
+// worker sends them with the message. The message row is locked while attaching, the worker marks the message as
+// being sent before reading its attachments so they're either sent with it or rejected here.
+func (m *Manager) AttachToMessage(messageUUID string, mediaIDs []int, uploadedBy int) error {
+	if len(mediaIDs) == 0 {
+		return nil
+	}
+
+	tx, err := m.db.BeginTxx(context.Background(), nil)
+	if err != nil {
+		m.lo.Error("error starting attach media transaction", "error", err)
+		return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorUpdating", "name", "{globals.terms.message}"), nil)
+	}
+	defer tx.Rollback()
+
+	var message struct {
+		ID               int    `db:"id"`
+		Type             string `db:"type"`
+		Status           string `db:"status"`
+		SendStarted      bool   `db:"send_started"`
+		ConversationUUID string `db:"conversation_uuid"`
+	}
+	if err := tx.Stmtx(m.q.LockMessage).Get(&message, messageUUID); err != nil {
+		if err == sql.ErrNoRows {
+			return envelope.NewCodedError(envelope.NotFoundError, envelope.ErrCodeMessageNotFound, m.i18n.Ts("globals.messages.notFound", "name", "{globals.terms.message}"), nil)
+		}
+		m.lo.Error("error fetching message to attach media", "uuid", messageUUID, "error", err)
+		return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorUpdating", "name", "{globals.terms.message}"), nil)
+	}
+	if message.Type != models.MessageOutgoing || message.Status != models.MessageStatusPending || message.SendStarted {
+		return envelope.NewError(envelope.InputError, m.i18n.T("conversation.messageNotPending"), nil)
+	}
+
+	for _, id := range mediaIDs {
+		res, err := tx.Stmtx(m.q.AttachUnlinkedMedia).Exec(id, mmodels.ModelMessages, message.ID, uploadedBy)
+		if err != nil {
+			m.lo.Error("error attaching media to message", "media_id", id, "message_id", message.ID, "error", err)
+			return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorUpdating", "name", "{globals.terms.message}"), nil)
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			m.lo.Warn("media not attachable to message", "media_id", id, "uploaded_by", uploadedBy)
+			return envelope.NewError(envelope.InputError, m.i18n.Ts("globals.messages.invalid", "name", "{globals.terms.media}"), nil)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		m.lo.Error("error committing attach media transaction", "error", err)
+		return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorUpdating", "name", "{globals.terms.message}"), nil)
+	}
+
+	m.BroadcastMessageUpdate(message.ConversationUUID, messageUUID, "attachments_added", mediaIDs)
+	return nil
+}
+
+// GetMessageConversationUUID returns the UUID of the conversation of the message.
+func (m *Manager) GetMessageConversationUUID(messageUUID string) (string, error) {
+	uuid, err := m.getConversationUUIDFromMessageUUID(messageUUID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", envelope.NewCodedError(envelope.NotFoundError, envelope.ErrCodeMessageNotFound, m.i18n.Ts("globals.messages.notFound", "name", "{globals.terms.message}"), nil)
+		}
+		return "", envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.message}"), nil)
+	}
+	return uuid, nil
+}
+
 // InsertMessage inserts a message and attaches the media to the message.
 func (m *Manager) InsertMessage(message *models.Message) error {
+	if err := validateSender(message); err != nil {
+		m.lo.Error("invalid message sender", "sender_type", message.SenderType, "sender_id", message.SenderID, "conversation_uuid", message.ConversationUUID, "error", err)
+		return envelope.NewError(envelope.InputError, m.i18n.Ts("globals.messages.invalid", "name", "{globals.terms.sender}"), nil)
+	}
+	if err := setContentType(message); err != nil {
+		m.lo.Error("invalid message content type", "content_type", message.ContentType, "conversation_uuid", message.ConversationUUID, "error", err)
+		return envelope.NewError(envelope.InputError, m.i18n.Ts("globals.messages.invalid", "name", "`content_type`"), nil)
+	}
+	if err := m.enforceContentSize(message); err != nil {
+		return err
+	}
+
 	// Private message is always sent.
 	if message.Private {
 		message.Status = models.MessageStatusSent
@@ -376,31 +615,82 @@ func (m *Manager) InsertMessage(message *models.Message) error {
 	// Convert HTML content to text for search.
 	message.TextContent = stringutil.HTML2Text(message.Content)
 
-	// Insert Message.
-	if err := m.q.InsertMessage.QueryRow(message.Type, message.Status, message.ConversationID, message.ConversationUUID, message.Content, message.TextContent, message.SenderID, message.SenderType,
-		message.Private, message.ContentType, message.SourceID, message.Meta).Scan(&message.ID, &message.UUID, &message.CreatedAt); err != nil {
+	// Record the signature of incoming messages so the UI can collapse it.
+	if message.Type == models.MessageIncoming {
+		message.Meta = m.setSignatureMeta(message.Meta, message.TextContent)
+	}
+
+	// Index the message for full-text search without the quoted reply history and signature, activities aren't indexed.
+	var searchText string
+	if message.Type != models.MessageActivity {
+		searchText = stringutil.StripSignature(stringutil.StripQuotedReply(message.TextContent))
+	}
+
+	// Hide CSAT message content as it contains a public link to the survey.
+	lastMessage := stringutil.SanitizeAndTruncate(message.Content, m.lastMessagePreviewLen)
+	if message.HasCSAT() {
+		lastMessage = "Please rate your experience with us"
+	}
+
+	// Insert the message, attach its media, add the sender as participant and update the conversation's last message
+	// in a single transaction so a failure midway doesn't leave a partially inserted message.
+	tx, err := m.db.BeginTxx(context.Background(), nil)
+	if err != nil {
+		m.lo.Error("error starting insert message transaction", "error", err)
+		return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorInserting", "name", "{globals.terms.message}"), nil)
+	}
+	defer tx.Rollback()
+
+	if err := tx.Stmtx(m.q.InsertMessage).QueryRow(message.Type, message.Status, message.ConversationID, message.ConversationUUID, message.Content, message.TextContent, message.SenderID, message.SenderType,
+		message.Private, message.ContentType, message.SourceID, message.Meta, searchText, message.Language, message.LanguageConfidence,
+		message.LanguageConfidence.Float64 >= minConversationLanguageConfidence).Scan(&message.ID, &message.UUID, &message.CreatedAt); err != nil {
 		m.lo.Error("error inserting message in db", "error", err)
 		return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorInserting", "name", "{globals.terms.message}"), nil)
 	}
 
-	// Attach message to the media.
+	// The media is attached before the message is visible as pending, agents can only attach the files they uploaded.
+	var uploadedBy int
+	if message.SenderType == models.SenderTypeAgent {
+		uploadedBy = message.SenderID
+	}
 	for _, media := range message.Media {
-		m.mediaStore.Attach(media.ID, mmodels.ModelMessages, message.ID)
+		res, err := tx.Stmtx(m.q.AttachUnlinkedMedia).Exec(media.ID, mmodels.ModelMessages, message.ID, uploadedBy)
+		if err != nil {
+			m.lo.Error("error attaching media to message", "media_id", media.ID, "message_id", message.ID, "error", err)
+			return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorInserting", "name", "{globals.terms.message}"), nil)
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			m.lo.Warn("media not attachable to message", "media_id", media.ID, "sender_id", message.SenderID)
+			return envelope.NewError(envelope.InputError, m.i18n.Ts("globals.messages.invalid", "name", "{globals.terms.media}"), nil)
+		}
 	}
 
-	// Add this user as a participant.
-	if err := m.addConversationParticipant(message.SenderID, message.ConversationUUID); err != nil {
-		return err
+	// Agents replying or adding notes are collaborators of the conversation, contacts aren't added. Adding is idempotent
+	// so an agent replying again isn't added twice, a follower replying becomes a collaborator.
+	if message.SenderType == models.SenderTypeAgent {
+		if _, err := tx.Stmtx(m.q.InsertConversationParticipant).Exec(message.SenderID, message.ConversationUUID, models.ParticipantRoleCollaborator, false); err != nil {
+			m.lo.Error("error adding conversation participant", "user_id", message.SenderID, "conversation_uuid", message.ConversationUUID, "error", err)
+			return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorCreating", "name", "{globals.terms.conversationParticipant}"), nil)
+		}
 	}
 
-	// Hide CSAT message content as it contains a public link to the survey.
-	lastMessage := message.TextContent
-	if message.HasCSAT() {
-		lastMessage = "Please rate your experience with us"
+	if _, err := tx.Stmtx(m.q.UpdateConversationLastMessage).Exec(message.ConversationID, message.ConversationUUID, lastMessage, message.SenderType, message.CreatedAt); err != nil {
+		m.lo.Error("error updating conversation last message", "conversation_uuid", message.ConversationUUID, "error", err)
+		return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorUpdating", "name", "{globals.terms.conversation}"), nil)
 	}
 
-	// Update conversation last message details in conversation.
-	m.UpdateConversationLastMessage(message.ConversationID, message.ConversationUUID, lastMessage, message.SenderType, message.CreatedAt)
+	if err := tx.Commit(); err != nil {
+		m.lo.Error("error committing insert message transaction", "error", err)
+		return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorInserting", "name", "{globals.terms.message}"), nil)
+	}
+
+	// A reply or note is sent, clear the agent's draft. Activities are recorded with the acting agent as sender, they
+	// leave the draft alone.
+	if message.SenderType == models.SenderTypeAgent && message.Type != models.MessageActivity {
+		if err := m.DeleteDraft(message.SenderID, message.ConversationUUID); err != nil {
+			m.lo.Error("error clearing draft after sending message", "conversation_uuid", message.ConversationUUID, "error", err)
+		}
+	}
 
 	// Broadcast new message.
 	m.BroadcastNewMessage(message)
@@ -423,12 +713,29 @@ func (m *Manager) RecordAssigneeUserChange(conversationUUID string, assigneeID i
 }
 
 // RecordAssigneeTeamChange records an activity for a team assignee change.
+// Assignments by agents to a team they aren't a member of are allowed, but flagged in the activity.
 func (m *Manager) RecordAssigneeTeamChange(conversationUUID string, teamID int, actor umodels.User) error {
 	team, err := m.teamStore.Get(teamID)
 	if err != nil {
 		return err
 	}
-	return m.InsertConversationActivity(models.ActivityAssignedTeamChange, conversationUUID, team.Name, actor)
+	activity := models.ActivityAssignedTeamChange
+	if isAgentActor(actor) {
+		member, err := m.teamStore.UserBelongsToTeam(teamID, actor.ID)
+		if err != nil {
+			return err
+		}
+		if !member {
+			m.lo.Info("conversation assigned to a team by a non-member", "uuid", conversationUUID, "team_id", teamID, "actor_id", actor.ID)
+			activity = models.ActivityAssignedTeamChangeNonMember
+		}
+	}
+	return m.InsertConversationActivity(activity, conversationUUID, team.Name, actor)
+}
+
+// RecordQueueChange records an activity for a conversation routed to a queue.
+func (m *Manager) RecordQueueChange(conversationUUID, queueName string, actor umodels.User) error {
+	return m.InsertConversationActivity(models.ActivityQueueChange, conversationUUID, queueName, actor)
 }
 
 // RecordPriorityChange records an activity for a priority change.
@@ -441,6 +748,11 @@ func (m *Manager) RecordStatusChange(status, conversationUUID string, actor umod
 	return m.InsertConversationActivity(models.ActivityStatusChange, conversationUUID, status, actor)
 }
 
+// RecordReopen records an activity for a conversation reopened from the passed status.
+func (m *Manager) RecordReopen(prevStatus, conversationUUID string, actor umodels.User) error {
+	return m.InsertConversationActivity(models.ActivityReopened, conversationUUID, prevStatus, actor)
+}
+
 // RecordSLASet records an activity for an SLA set.
 func (m *Manager) RecordSLASet(conversationUUID string, slaName string, actor umodels.User) error {
 	return m.InsertConversationActivity(models.ActivitySLASet, conversationUUID, slaName, actor)
@@ -456,6 +768,27 @@ func (m *Manager) RecordTagRemoval(conversationUUID string, tag string, actor um
 	return m.InsertConversationActivity(models.ActivityTagRemoved, conversationUUID, tag, actor)
 }
 
+// RecordTagChange records activities for the tags that were added and removed between the previous and the new tags list.
+func (m *Manager) RecordTagChange(conversationUUID string, prevTags, newTags []string, actor umodels.User) error {
+	for _, tag := range prevTags {
+		if slices.Contains(newTags, tag) {
+			continue
+		}
+		if err := m.RecordTagRemoval(conversationUUID, tag, actor); err != nil {
+			return err
+		}
+	}
+	for _, tag := range newTags {
+		if slices.Contains(prevTags, tag) {
+			continue
+		}
+		if err := m.RecordTagAddition(conversationUUID, tag, actor); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // InsertConversationActivity inserts an activity message.
 func (m *Manager) InsertConversationActivity(activityType, conversationUUID, newValue string, actor umodels.User) error {
 	content, err := m.getMessageActivityContent(activityType, newValue, actor.FullName())
@@ -482,6 +815,40 @@ func (m *Manager) InsertConversationActivity(activityType, conversationUUID, new
 	return nil
 }
 
+// validateSender checks the sender of the message is a known sender type with an ID, and that contacts only send
+// incoming messages.
+func validateSender(message *models.Message) error {
+	if message.SenderID <= 0 {
+		return fmt.Errorf("missing sender id")
+	}
+	switch message.SenderType {
+	case models.SenderTypeAgent:
+		return nil
+	case models.SenderTypeContact:
+		if message.Type != models.MessageIncoming {
+			return fmt.Errorf("contact can't send %s messages", message.Type)
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown sender type %q", message.SenderType)
+}
+
+// setContentType defaults an empty content type of the message, text for activities and private notes and HTML
+// for the rest, and returns an error if the content type isn't known.
+func setContentType(message *models.Message) error {
+	switch message.ContentType {
+	case models.ContentTypeText, models.ContentTypeHTML:
+		return nil
+	case "":
+		message.ContentType = models.ContentTypeHTML
+		if message.Type == models.MessageActivity || message.Private {
+			message.ContentType = models.ContentTypeText
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown content type %q", message.ContentType)
+}
+
 // getConversationUUIDFromMessageUUID returns conversation UUID from message UUID.
 func (m *Manager) getConversationUUIDFromMessageUUID(uuid string) (string, error) {
 	var conversationUUID string
@@ -500,6 +867,8 @@ func (m *Manager) getMessageActivityContent(activityType, newValue, actorName st
 		content = fmt.Sprintf("Assigned to %s by %s", newValue, actorName)
 	case models.ActivityAssignedTeamChange:
 		content = fmt.Sprintf("Assigned to %s team by %s", newValue, actorName)
+	case models.ActivityAssignedTeamChangeNonMember:
+		content = fmt.Sprintf("Assigned to %s team by %s, who isn't a member of the team", newValue, actorName)
 	case models.ActivitySelfAssign:
 		content = fmt.Sprintf("%s self-assigned this conversation", actorName)
 	case models.ActivityPriorityChange:
@@ -512,6 +881,16 @@ func (m *Manager) getMessageActivityContent(activityType, newValue, actorName st
 		content = fmt.Sprintf("%s removed tag %s", actorName, newValue)
 	case models.ActivitySLASet:
 		content = fmt.Sprintf("%s set %s SLA policy", actorName, newValue)
+	case models.ActivityReopened:
+		content = fmt.Sprintf("%s reopened the conversation, it was %s", actorName, newValue)
+	case models.ActivityQueueChange:
+		content = fmt.Sprintf("%s routed the conversation to %s queue", actorName, newValue)
+	case models.ActivityRecipientBlocked:
+		content = fmt.Sprintf("Message to %s was not sent, the address is blocked", newValue)
+	case models.ActivitySubjectChange:
+		content = fmt.Sprintf("%s changed the subject to %s", actorName, newValue)
+	case models.ActivityMessagesRetried:
+		content = fmt.Sprintf("%s retried %s failed messages", actorName, newValue)
 	default:
 		return "", fmt.Errorf("invalid activity type %s", activityType)
 	}
@@ -523,6 +902,14 @@ func (m *Manager) getMessageActivityContent(activityType, newValue, actorName st
 // conversations, and creates a new conversation if necessary. It also
 // inserts the message, uploads any attachments, and queues the conversation evaluation of automation rules.
 func (m *Manager) processIncomingMessage(in models.IncomingMessage) error {
+	// Delivery status notifications update the outgoing message they report on instead of being added to a
+	// conversation.
+	if in.DeliveryReport != nil {
+		if processed, err := m.processDeliveryReport(*in.DeliveryReport, in.Message.SourceID.String); processed || err != nil {
+			return err
+		}
+	}
+
 	// Find or create contact and set sender ID in message.
 	if err := m.userStore.CreateContact(&in.Contact); err != nil {
 		m.lo.Error("error upserting contact", "error", err)
@@ -545,19 +932,27 @@ func (m *Manager) processIncomingMessage(in models.IncomingMessage) error {
 		return err
 	}
 
-	// Upload message attachments.
+	// Oversized content is kept as an attachment instead of being truncated.
+	m.offloadOversizedContent(&in.Message)
+
+	// Upload message attachments, the message is inserted with the attachments that were uploaded and the failed ones
+	// are recorded in its meta.
 	if err := m.uploadMessageAttachments(&in.Message); err != nil {
-		// Log error but continue processing.
-		m.lo.Error("error uploading message attachments", "message_source_id", in.Message.SourceID, "error", err)
+		m.lo.Error("error uploading message attachments", "message_source_id", in.Message.SourceID.String, "error", err)
 	}
 
+	// Detect the message language, used by routing rules and translation.
+	detectMessageLanguage(&in.Message)
+
 	// Insert message.
 	if err = m.InsertMessage(&in.Message); err != nil {
 		return err
 	}
 
-	// Evaluate automation rules for new conversation.
+	// Assign the new conversation to the inbox defaults, after its first message so the assignment activity follows
+	// it, and evaluate automation rules for it.
 	if isNewConversation {
+		m.applyDefaultAssignee(in.Message.ConversationUUID, in.InboxID)
 		m.automation.EvaluateNewConversationRules(in.Message.ConversationUUID)
 		return nil
 	}
@@ -583,7 +978,23 @@ func (m *Manager) MessageExists(messageID string) (bool, error) {
 	_, err := m.findConversationID([]string{messageID})
 	if err != nil {
 		if errors.Is(err, errConversationNotFound) {
-			return false, nil
+			// Blocked messages are already recorded with the whole message, they're processed once released.
+			var blocked bool
+			if err := m.q.BlockedIncomingMessageExists.Get(&blocked, messageID); err != nil {
+				m.lo.Error("error fetching blocked message from db", "error", err)
+				return false, err
+			}
+			if blocked {
+				return true, nil
+			}
+
+			// Delivery status notifications aren't added to conversations, they're recorded once processed.
+			var reported bool
+			if err := m.q.DeliveryReportExists.Get(&reported, messageID); err != nil {
+				m.lo.Error("error fetching delivery report from db", "error", err)
+				return false, err
+			}
+			return reported, nil
 		}
 		m.lo.Error("error fetching message from db", "error", err)
 		return false, err
@@ -591,21 +1002,33 @@ func (m *Manager) MessageExists(messageID string) (bool, error) {
 	return true, nil
 }
 
-// EnqueueIncoming enqueues an incoming message for inserting in db.
+// EnqueueIncoming enqueues an incoming message for inserting in db, messages from senders over the
+// incoming rate limits are recorded as blocked instead.
 func (m *Manager) EnqueueIncoming(message models.IncomingMessage) error {
+	if reason := m.incomingBlockReason(message); reason != "" {
+		return m.recordBlockedIncoming(message, reason)
+	}
+	return m.enqueueIncoming(message)
+}
+
+// enqueueIncoming enqueues an incoming message, spilling it over to the database when the queue is full.
+func (m *Manager) enqueueIncoming(message models.IncomingMessage) error {
 	m.closedMu.Lock()
 	defer m.closedMu.Unlock()
 	if m.closed {
 		return errors.New("incoming message queue is closed")
 	}
 
-	select {
-	case m.incomingMessageQueue <- message:
-		return nil
-	default:
-		m.lo.Warn("WARNING: incoming message queue is full")
-		return errors.New("incoming message queue is full")
+	// Messages go to the spillover while it's being drained, so they are processed in the order they were received.
+	if !m.spilling.Load() {
+		select {
+		case m.incomingMessageQueue <- message:
+			return nil
+		default:
+			m.lo.Warn("WARNING: incoming message queue is full, spilling over to the database")
+		}
 	}
+	return m.spillIncoming(message)
 }
 
 // GetConversationByMessageID returns conversation by message id.
@@ -613,7 +1036,7 @@ func (m *Manager) GetConversationByMessageID(id int) (models.Conversation, error
 	var conversation = models.Conversation{}
 	if err := m.q.GetConversationByMessageID.Get(&conversation, id); err != nil {
 		if err == sql.ErrNoRows {
-			return conversation, envelope.NewError(envelope.NotFoundError, m.i18n.Ts("globals.messages.notFound", "name", "{globals.terms.conversation}"), nil)
+			return conversation, envelope.NewCodedError(envelope.NotFoundError, envelope.ErrCodeConversationNotFound, m.i18n.Ts("globals.messages.notFound", "name", "{globals.terms.conversation}"), nil)
 		}
 		m.lo.Error("error fetching message from DB", "error", err)
 		return conversation, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.conversation}"), nil)
@@ -621,8 +1044,12 @@ func (m *Manager) GetConversationByMessageID(id int) (models.Conversation, error
 	return conversation, nil
 }
 
-// generateMessagesQuery generates the SQL query for fetching messages in a conversation.
-func (c *Manager) generateMessagesQuery(baseQuery string, qArgs []interface{}, page, pageSize int) (string, int, []interface{}, error) {
+// generateMessagesQuery generates the SQL query for fetching messages in a conversation, filtered by `typeFilter`.
+func (c *Manager) generateMessagesQuery(baseQuery string, qArgs []interface{}, typeFilter string, page, pageSize int) (string, int, []interface{}, error) {
+	filterClause, ok := messagesTypeFilters[typeFilter]
+	if !ok {
+		return "", 0, nil, fmt.Errorf("invalid message type filter: %s", typeFilter)
+	}
 	if page <= 0 {
 		return "", 0, nil, errors.New("page must be greater than 0")
 	}
@@ -640,17 +1067,21 @@ func (c *Manager) generateMessagesQuery(baseQuery string, qArgs []interface{}, p
 	qArgs = append(qArgs, pageSize, offset)
 
 	// Include LIMIT and OFFSET in the SQL query
-	sqlQuery := fmt.Sprintf(baseQuery, fmt.Sprintf("LIMIT $%d OFFSET $%d", len(qArgs)-1, len(qArgs)))
+	sqlQuery := fmt.Sprintf(baseQuery, filterClause, fmt.Sprintf("LIMIT $%d OFFSET $%d", len(qArgs)-1, len(qArgs)))
 	return sqlQuery, pageSize, qArgs, nil
 }
 
-// uploadMessageAttachments uploads all attachments for a message.
-func (m *Manager) uploadMessageAttachments(message *models.Message) []error {
+// uploadMessageAttachments uploads all attachments for a message, attachments that fail to upload are recorded in the
+// message meta as `failed_attachments` and the returned error joins the errors of all of them.
+func (m *Manager) uploadMessageAttachments(message *models.Message) error {
 	if len(message.Attachments) == 0 {
 		return nil
 	}
 
-	var uploadErr []error
+	var (
+		uploadErr []error
+		failed    []models.FailedAttachment
+	)
 	for _, attachment := range message.Attachments {
 		// Check if this attachment already exists by the content ID, as inline images can be repeated across conversations.
 		contentID := attachment.ContentID
@@ -676,14 +1107,21 @@ func (m *Manager) uploadMessageAttachments(message *models.Message) []error {
 			message.Content = strings.ReplaceAll(message.Content, fmt.Sprintf("cid:%s", attachment.ContentID), fmt.Sprintf("cid:%s", contentID))
 		}
 
-		// Sanitize filename.
-		attachment.Name = stringutil.SanitizeFilename(attachment.Name)
+		// Sanitize filename and make sure its extension matches the content type so downloads open in the right app.
+		attachment.Name = stringutil.FixFileExtension(stringutil.SanitizeFilename(attachment.Name), attachment.ContentType)
 
 		m.lo.Debug("uploading message attachment", "name", attachment.Name, "content_id", contentID, "size", attachment.Size, "content_type", attachment.ContentType,
 			"content_id", contentID, "disposition", attachment.Disposition)
 
-		// Upload and insert entry in media table.
-		attachReader := bytes.NewReader(attachment.Content)
+		// Upload and insert entry in media table, the content is streamed from the attachment and only spooled to
+		// a temporary file when it can't be rewound for hashing.
+		attachReader, err := attachment.SeekableReader()
+		if err != nil {
+			m.lo.Error("failed to read attachment", "name", attachment.Name, "message_source_id", message.SourceID.String, "error", err)
+			uploadErr = append(uploadErr, fmt.Errorf("reading attachment %q: %w", attachment.Name, err))
+			failed = append(failed, models.FailedAttachment{Name: attachment.Name, ContentType: attachment.ContentType, Size: attachment.Size})
+			continue
+		}
 		media, err := m.mediaStore.UploadAndInsert(
 			attachment.Name,
 			attachment.ContentType,
@@ -697,21 +1135,30 @@ func (m *Manager) uploadMessageAttachments(message *models.Message) []error {
 			[]byte("{}"), /** meta **/
 		)
 		if err != nil {
-			uploadErr = append(uploadErr, err)
-			m.lo.Error("failed to upload attachment", "name", attachment.Name, "error", err)
+			attachReader.Close()
+			m.lo.Error("failed to upload attachment", "name", attachment.Name, "message_source_id", message.SourceID.String, "error", err)
+			uploadErr = append(uploadErr, fmt.Errorf("uploading attachment %q: %w", attachment.Name, err))
+			failed = append(failed, models.FailedAttachment{Name: attachment.Name, ContentType: attachment.ContentType, Size: attachment.Size})
+			continue
 		}
 
-		// If the attachment is an image, generate and upload thumbnail.
+		// If the attachment is an image, generate and upload thumbnail, identical files share the blob and its thumbnail.
+		// The attachment is kept without a thumbnail if this fails.
 		attachmentExt := strings.TrimPrefix(strings.ToLower(filepath.Ext(attachment.Name)), ".")
-		if slices.Contains(image.Exts, attachmentExt) {
-			if err := m.uploadThumbnailForMedia(media, attachment.Content); err != nil {
-				uploadErr = append(uploadErr, err)
-				m.lo.Error("error uploading thumbnail", "error", err)
+		if media.BlobName == media.UUID && slices.Contains(image.Exts, attachmentExt) {
+			if err := m.uploadThumbnailForMedia(media, attachReader); err != nil {
+				m.lo.Error("error uploading thumbnail", "name", attachment.Name, "error", err)
+				uploadErr = append(uploadErr, fmt.Errorf("uploading thumbnail of attachment %q: %w", attachment.Name, err))
 			}
 		}
+		attachReader.Close()
 		message.Media = append(message.Media, media)
 	}
-	return uploadErr
+
+	if len(failed) > 0 {
+		message.Meta = m.setMetaKey(message.Meta, "failed_attachments", failed)
+	}
+	return errors.Join(uploadErr...)
 }
 
 // findOrCreateConversation finds or creates a conversation for the given message.
@@ -730,10 +1177,18 @@ func (m *Manager) findOrCreateConversation(in *models.Message, inboxID, contactC
 		return new, err
 	}
 
+	// Some clients strip the threading headers, fall back to the reference number in the subject.
+	if conversationID == 0 {
+		conversationID, err = m.findConversationIDBySubject(in.Subject, contactID, inboxID)
+		if err != nil && err != errConversationNotFound {
+			return new, err
+		}
+	}
+
 	// Conversation not found, create one.
 	if conversationID == 0 {
 		new = true
-		lastMessage := stringutil.HTML2Text(in.Content)
+		lastMessage := stringutil.SanitizeAndTruncate(in.Content, m.lastMessagePreviewLen)
 		lastMessageAt := time.Now()
 		conversationID, conversationUUID, err = m.CreateConversation(contactID, contactChannelID, inboxID, lastMessage, lastMessageAt, in.Subject, false /**append reference number to subject**/)
 		if err != nil || conversationID == 0 {
@@ -771,6 +1226,34 @@ func (m *Manager) findConversationID(messageSourceIDs []string) (int, error) {
 	return conversationID, nil
 }
 
+// findConversationIDBySubject finds the contact's conversation in the inbox from a reference number in the
+// subject, like "Re: Order issue [#10245]".
+func (m *Manager) findConversationIDBySubject(subject string, contactID, inboxID int) (int, error) {
+	for _, refNum := range parseSubjectReferenceNumbers(subject) {
+		var conversationID int
+		if err := m.q.GetConversationIDByReferenceNumber.Get(&conversationID, refNum, contactID, inboxID); err != nil {
+			if err == sql.ErrNoRows {
+				continue
+			}
+			m.lo.Error("error fetching conversation by reference number", "reference_number", refNum, "error", err)
+			return 0, err
+		}
+		return conversationID, nil
+	}
+	return 0, errConversationNotFound
+}
+
+// parseSubjectReferenceNumbers returns the reference numbers in square brackets in the subject, last one first as
+// the reference number is appended to the subject.
+func parseSubjectReferenceNumbers(subject string) []string {
+	matches := subjectRefNumRe.FindAllStringSubmatch(subject, -1)
+	refNums := make([]string, 0, len(matches))
+	for i := len(matches) - 1; i >= 0; i-- {
+		refNums = append(refNums, matches[i][1])
+	}
+	return refNums
+}
+
 // attachAttachmentsToMessage attaches attachment blobs to message.
 func (m *Manager) attachAttachmentsToMessage(message *models.Message) error {
 	var attachments attachment.Attachments
@@ -782,17 +1265,25 @@ func (m *Manager) attachAttachmentsToMessage(message *models.Message) error {
 		return err
 	}
 
-	// Fetch blobs.
+	// Blobs are not loaded here, the inbox reads each one from the store when sending.
 	for _, media := range medias {
-		blob, err := m.mediaStore.GetBlob(media.UUID)
-		if err != nil {
-			m.lo.Error("error fetching media blob", "error", err)
-			return err
+		// Store the disposition the media is sent with, so it's shown the same way in the conversation.
+		disposition := resolveDisposition(media, message.Content)
+		if disposition != media.Disposition.String {
+			if err := m.mediaStore.UpdateDisposition(media.ID, disposition); err != nil {
+				m.lo.Error("error storing media disposition", "media_uuid", media.UUID, "error", err)
+			}
 		}
+
+		blobName := media.BlobName
 		attachment := attachment.Attachment{
-			Name:    media.Filename,
-			Content: blob,
-			Header:  attachment.MakeHeader(media.ContentType, media.UUID, media.Filename, "base64", media.Disposition.String),
+			Name:        media.Filename,
+			Size:        media.Size,
+			ContentType: media.ContentType,
+			Header:      attachment.MakeHeader(media.ContentType, media.UUID, media.Filename, "base64", disposition),
+			Open: func() (io.ReadCloser, error) {
+				return m.mediaStore.GetReader(blobName)
+			},
 		}
 		attachments = append(attachments, attachment)
 	}
@@ -816,12 +1307,11 @@ func (m *Manager) getOutgoingProcessingMessageIDs() []int {
 }
 
 // uploadThumbnailForMedia prepares and uploads a thumbnail for an image attachment.
-func (m *Manager) uploadThumbnailForMedia(media mmodels.Media, content []byte) error {
-	// Create a reader from the content
-	file := bytes.NewReader(content)
-
+func (m *Manager) uploadThumbnailForMedia(media mmodels.Media, file io.ReadSeeker) error {
 	// Seek to the beginning of the file
-	file.Seek(0, 0)
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("error rewinding attachment: %w", err)
+	}
 
 	// Create the thumbnail
 	thumbFile, err := image.CreateThumb(image.DefThumbSize, file)
@@ -830,7 +1320,7 @@ func (m *Manager) uploadThumbnailForMedia(media mmodels.Media, content []byte) e
 	}
 
 	// Generate thumbnail name
-	thumbName := fmt.Sprintf("thumb_%s", media.UUID)
+	thumbName := fmt.Sprintf("thumb_%s", media.BlobName)
 
 	// Upload the thumbnail
 	if _, err := m.mediaStore.Upload(thumbName, media.ContentType, thumbFile); err != nil {
@@ -839,3 +1329,37 @@ func (m *Manager) uploadThumbnailForMedia(media mmodels.Media, content []byte) e
 	}
 	return nil
 }
+
+// setSignatureMeta adds the signature detected in the text content of a message to its meta as
+// `signature: {start, end, text}`, the meta is returned as is if there's no signature. The offsets are in runes into
+// the text content, the UI renders the HTML content and matches the signature by its text instead.
+func (m *Manager) setSignatureMeta(meta, textContent string) string {
+	start, end, ok := stringutil.DetectSignature(textContent)
+	if !ok {
+		return meta
+	}
+	return m.setMetaKey(meta, "signature", map[string]any{
+		"start": start,
+		"end":   end,
+		"text":  string([]rune(textContent)[start:end]),
+	})
+}
+
+// setMetaKey sets a key in the JSON meta of a message, the meta is returned as is if it can't be parsed.
+func (m *Manager) setMetaKey(meta, key string, value any) string {
+	if meta == "" || meta == "null" {
+		meta = "{}"
+	}
+	var metaMap map[string]interface{}
+	if err := json.Unmarshal([]byte(meta), &metaMap); err != nil || metaMap == nil {
+		m.lo.Warn("error unmarshalling message meta", "key", key, "error", err)
+		return meta
+	}
+	metaMap[key] = value
+	b, err := json.Marshal(metaMap)
+	if err != nil {
+		m.lo.Error("error marshalling message meta", "key", key, "error", err)
+		return meta
+	}
+	return string(b)
+}
@@ -0,0 +1,167 @@
+package conversation
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/abhinavxd/libredesk/internal/conversation/models"
+)
+
+func TestValidateSender(t *testing.T) {
+	tests := []struct {
+		name    string
+		message models.Message
+		wantErr bool
+	}{
+		{name: "agent reply", message: models.Message{SenderID: 1, SenderType: models.SenderTypeAgent, Type: models.MessageOutgoing}},
+		{name: "agent activity", message: models.Message{SenderID: 1, SenderType: models.SenderTypeAgent, Type: models.MessageActivity}},
+		{name: "contact incoming", message: models.Message{SenderID: 2, SenderType: models.SenderTypeContact, Type: models.MessageIncoming}},
+		{name: "contact outgoing", message: models.Message{SenderID: 2, SenderType: models.SenderTypeContact, Type: models.MessageOutgoing}, wantErr: true},
+		{name: "unknown sender type", message: models.Message{SenderID: 1, SenderType: "bot", Type: models.MessageOutgoing}, wantErr: true},
+		{name: "empty sender type", message: models.Message{SenderID: 1, Type: models.MessageOutgoing}, wantErr: true},
+		{name: "missing sender id", message: models.Message{SenderType: models.SenderTypeAgent, Type: models.MessageOutgoing}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateSender(&tt.message); (err != nil) != tt.wantErr {
+				t.Errorf("got error %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSetContentType(t *testing.T) {
+	tests := []struct {
+		name     string
+		message  models.Message
+		expected string
+		wantErr  bool
+	}{
+		{name: "html kept", message: models.Message{Type: models.MessageActivity, ContentType: models.ContentTypeHTML}, expected: models.ContentTypeHTML},
+		{name: "text kept", message: models.Message{Type: models.MessageOutgoing, ContentType: models.ContentTypeText}, expected: models.ContentTypeText},
+		{name: "empty activity", message: models.Message{Type: models.MessageActivity}, expected: models.ContentTypeText},
+		{name: "empty private note", message: models.Message{Type: models.MessageOutgoing, Private: true}, expected: models.ContentTypeText},
+		{name: "empty outgoing", message: models.Message{Type: models.MessageOutgoing}, expected: models.ContentTypeHTML},
+		{name: "empty incoming", message: models.Message{Type: models.MessageIncoming}, expected: models.ContentTypeHTML},
+		{name: "unknown", message: models.Message{Type: models.MessageOutgoing, ContentType: "markdown"}, expected: "markdown", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := setContentType(&tt.message)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("got error %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.message.ContentType != tt.expected {
+				t.Errorf("got content type %q, want %q", tt.message.ContentType, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseSubjectReferenceNumbers(t *testing.T) {
+	tests := []struct {
+		name     string
+		subject  string
+		expected []string
+	}{
+		{name: "no reference", subject: "Re: Order issue", expected: []string{}},
+		{name: "appended reference", subject: "Re: Order issue [100]", expected: []string{"100"}},
+		{name: "hash reference", subject: "RE: Order issue [#10245]", expected: []string{"10245"}},
+		{name: "prefixed reference", subject: "Fwd: Order [#LD-204]", expected: []string{"LD-204"}},
+		{name: "last reference first", subject: "[#12] Re: Order issue [#345]", expected: []string{"345", "12"}},
+		{name: "non numeric tag", subject: "[External] Order issue", expected: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseSubjectReferenceNumbers(tt.subject)
+			if len(got) != len(tt.expected) {
+				t.Fatalf("got %v, want %v", got, tt.expected)
+			}
+			for i := range got {
+				if got[i] != tt.expected[i] {
+					t.Errorf("got %v, want %v", got, tt.expected)
+				}
+			}
+		})
+	}
+}
+
+func TestSubjectWithReferenceNumber(t *testing.T) {
+	tests := []struct {
+		name     string
+		subject  string
+		refNum   string
+		expected string
+	}{
+		{name: "appends reference", subject: "Refund request", refNum: "100", expected: "Refund request [100]"},
+		{name: "keeps existing reference", subject: "Refund request [100]", refNum: "100", expected: "Refund request [100]"},
+		{name: "keeps hash reference", subject: "Refund request [#100]", refNum: "100", expected: "Refund request [#100]"},
+		{name: "other reference", subject: "Refund request [200]", refNum: "100", expected: "Refund request [200] [100]"},
+		{name: "no reference number", subject: "Refund request", refNum: "", expected: "Refund request"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := subjectWithReferenceNumber(tt.subject, tt.refNum); got != tt.expected {
+				t.Errorf("got %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestGenerateMessagesQuery(t *testing.T) {
+	var m Manager
+	base := "SELECT * FROM conversation_messages m WHERE m.conversation_id = $1 %s ORDER BY m.created_at DESC %s"
+
+	query, pageSize, args, err := m.generateMessagesQuery(base, []interface{}{"uuid"}, "", 2, 500)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "SELECT * FROM conversation_messages m WHERE m.conversation_id = $1  ORDER BY m.created_at DESC LIMIT $2 OFFSET $3"; query != want {
+		t.Errorf("got %q, want %q", query, want)
+	}
+	if pageSize != maxMessagesPerPage || len(args) != 3 || args[2] != maxMessagesPerPage {
+		t.Errorf("got page size %d and args %v", pageSize, args)
+	}
+
+	query, _, _, err = m.generateMessagesQuery(base, []interface{}{"uuid"}, models.MessagesFilterNoActivities, 1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "SELECT * FROM conversation_messages m WHERE m.conversation_id = $1 AND m.type != 'activity' ORDER BY m.created_at DESC LIMIT $2 OFFSET $3"; query != want {
+		t.Errorf("got %q, want %q", query, want)
+	}
+
+	if _, _, _, err := m.generateMessagesQuery(base, []interface{}{"uuid"}, "unknown", 1, 10); err == nil {
+		t.Error("expected an error for an unknown type filter")
+	}
+}
+
+func TestSetSignatureMeta(t *testing.T) {
+	m := &Manager{}
+	text := "Hi,\nMy order hasn't arrived.\n\nRegards,\n-- \nJürgen Müller"
+
+	var meta struct {
+		Signature struct {
+			Start int    `json:"start"`
+			End   int    `json:"end"`
+			Text  string `json:"text"`
+		} `json:"signature"`
+	}
+	if err := json.Unmarshal([]byte(m.setSignatureMeta("{}", text)), &meta); err != nil {
+		t.Fatal(err)
+	}
+	if want := "-- \nJürgen Müller"; meta.Signature.Text != want {
+		t.Errorf("got signature text %q, want %q", meta.Signature.Text, want)
+	}
+	if got := string([]rune(text)[meta.Signature.Start:meta.Signature.End]); got != meta.Signature.Text {
+		t.Errorf("got signature offsets matching %q, want %q", got, meta.Signature.Text)
+	}
+
+	if got := m.setSignatureMeta("{}", "Hi,\nMy order hasn't arrived."); got != "{}" {
+		t.Errorf("got meta %s for a message without a signature", got)
+	}
+}
@@ -31,13 +31,19 @@ var (
 	MessageOutgoing = "outgoing"
 	MessageActivity = "activity"
 
+	// Filters of the messages of a conversation by type, no filter returns all messages.
+	MessagesFilterNoActivities   = "no_activities"
+	MessagesFilterNotes          = "notes"
+	MessagesFilterCustomerFacing = "customer_facing"
+
 	SenderTypeAgent   = "agent"
 	SenderTypeContact = "contact"
 
-	MessageStatusPending  = "pending"
-	MessageStatusSent     = "sent"
-	MessageStatusFailed   = "failed"
-	MessageStatusReceived = "received"
+	MessageStatusPending   = "pending"
+	MessageStatusSent      = "sent"
+	MessageStatusDelivered = "delivered"
+	MessageStatusFailed    = "failed"
+	MessageStatusReceived  = "received"
 
 	ActivityStatusChange       = "status_change"
 	ActivityPriorityChange     = "priority_change"
@@ -47,6 +53,18 @@ var (
 	ActivityTagAdded           = "tag_added"
 	ActivityTagRemoved         = "tag_removed"
 	ActivitySLASet             = "sla_set"
+	ActivityReopened           = "reopened"
+	ActivityQueueChange        = "queue_change"
+
+	// ActivityAssignedTeamChangeNonMember is a team assignment by an agent that isn't a member of the team.
+	ActivityAssignedTeamChangeNonMember = "assigned_team_change_non_member"
+	// ActivityMessagesRetried is a retry of the failed messages of a conversation, the value is the number requeued.
+	ActivityMessagesRetried = "messages_retried"
+	// ActivitySubjectChange is a change of the conversation subject, the value is the new subject.
+	ActivitySubjectChange = "subject_change"
+	// ActivityRecipientBlocked is an outgoing message that wasn't sent as the contact address is blocked, the value
+	// is the address.
+	ActivityRecipientBlocked = "recipient_blocked"
 
 	ContentTypeText = "text"
 	ContentTypeHTML = "html"
@@ -70,6 +88,7 @@ type Conversation struct {
 	LastReplyAt           null.Time       `db:"last_reply_at" json:"last_reply_at"`
 	AssignedUserID        null.Int        `db:"assigned_user_id" json:"assigned_user_id"`
 	AssignedTeamID        null.Int        `db:"assigned_team_id" json:"assigned_team_id"`
+	AssignedQueueID       null.Int        `db:"assigned_queue_id" json:"assigned_queue_id"`
 	AssigneeLastSeenAt    null.Time       `db:"assignee_last_seen_at" json:"assignee_last_seen_at"`
 	WaitingSince          null.Time       `db:"waiting_since" json:"waiting_since"`
 	Subject               null.String     `db:"subject" json:"subject"`
@@ -82,6 +101,11 @@ type Conversation struct {
 	LastMessageAt         null.Time       `db:"last_message_at" json:"last_message_at"`
 	LastMessage           null.String     `db:"last_message" json:"last_message"`
 	LastMessageSender     null.String     `db:"last_message_sender" json:"last_message_sender"`
+	Language              null.String     `db:"language" json:"language"`
+	Locale                null.String     `db:"locale" json:"locale"`
+	ManuallyAssignedAt    null.Time       `db:"manually_assigned_at" json:"manually_assigned_at"`
+	DeletedAt             null.Time       `db:"deleted_at" json:"deleted_at"`
+	DeletedBy             null.Int        `db:"deleted_by" json:"deleted_by"`
 	Contact               umodels.User    `db:"contact" json:"contact"`
 	SLAPolicyID           null.Int        `db:"sla_policy_id" json:"sla_policy_id"`
 	SlaPolicyName         null.String     `db:"sla_policy_name" json:"sla_policy_name"`
@@ -95,11 +119,110 @@ type Conversation struct {
 	Total                 int             `db:"total" json:"-"`
 }
 
+// EffectiveLocale returns the locale to render CSAT surveys and auto-sent templates in, the locale set by an agent
+// takes precedence over the detected language of the contact.
+func (c Conversation) EffectiveLocale() string {
+	if c.Locale.String != "" {
+		return c.Locale.String
+	}
+	return c.Language.String
+}
+
+// Roles of the participants of a conversation. The assignee role is the assigned user of the conversation, the
+// others are stored per participant.
+const (
+	ParticipantRoleAssignee     = "assignee"
+	ParticipantRoleCollaborator = "collaborator"
+	ParticipantRoleFollower     = "follower"
+	ParticipantRoleRequester    = "requester"
+)
+
+// ParticipantRoles are the roles a participant can be added with.
+var ParticipantRoles = []string{ParticipantRoleCollaborator, ParticipantRoleFollower, ParticipantRoleRequester}
+
 type ConversationParticipant struct {
 	ID        string      `db:"id" json:"id"`
 	FirstName string      `db:"first_name" json:"first_name"`
 	LastName  string      `db:"last_name" json:"last_name"`
 	AvatarURL null.String `db:"avatar_url" json:"avatar_url"`
+	Role      string      `db:"role" json:"role"`
+}
+
+// WatchedConversationActivity is the new activity in a conversation watched by a user, used for the watch digest.
+type WatchedConversationActivity struct {
+	UserID           int         `db:"user_id"`
+	Email            string      `db:"email"`
+	ConversationUUID string      `db:"conversation_uuid"`
+	ReferenceNumber  string      `db:"reference_number"`
+	Subject          null.String `db:"subject"`
+	LastMessage      null.String `db:"last_message"`
+	NewMessages      int         `db:"new_messages"`
+}
+
+// InboxConversationCounts is the number of open conversations of an inbox, and of those without an assigned agent.
+type InboxConversationCounts struct {
+	InboxID    int `db:"inbox_id" json:"inbox_id"`
+	Open       int `db:"open" json:"open"`
+	Unassigned int `db:"unassigned" json:"unassigned"`
+}
+
+// ConversationReminder is a personal reminder of an agent to follow up on a conversation.
+type ConversationReminder struct {
+	ID               int         `db:"id" json:"id"`
+	CreatedAt        time.Time   `db:"created_at" json:"created_at"`
+	UserID           int         `db:"user_id" json:"user_id"`
+	RemindAt         time.Time   `db:"remind_at" json:"remind_at"`
+	Note             string      `db:"note" json:"note"`
+	NotifiedAt       null.Time   `db:"notified_at" json:"notified_at"`
+	ConversationUUID string      `db:"conversation_uuid" json:"conversation_uuid"`
+	ReferenceNumber  string      `db:"reference_number" json:"reference_number"`
+	Subject          null.String `db:"subject" json:"subject"`
+	// Email of the user, only set on due reminders.
+	Email null.String `db:"email" json:"-"`
+}
+
+// MessageTrackingEvent is an open or click of a tracked outgoing email.
+type MessageTrackingEvent struct {
+	CreatedAt time.Time   `db:"created_at" json:"created_at"`
+	Type      string      `db:"type" json:"type"`
+	URL       null.String `db:"url" json:"url"`
+}
+
+// MessageStatusChange is a status transition of a message.
+type MessageStatusChange struct {
+	CreatedAt time.Time   `db:"created_at" json:"created_at"`
+	Status    string      `db:"status" json:"status"`
+	Error     null.String `db:"error" json:"error"`
+}
+
+// MessageTrackingLink is a link of an outgoing email rewritten to be tracked.
+type MessageTrackingLink struct {
+	MessageID int    `db:"message_id"`
+	URL       string `db:"url"`
+}
+
+// UnassignedEscalation is a conversation left unassigned past its inbox's unassigned escalation minutes.
+type UnassignedEscalation struct {
+	ID             int      `db:"id"`
+	UUID           string   `db:"uuid"`
+	AssignedTeamID null.Int `db:"assigned_team_id"`
+	Minutes        int      `db:"minutes"`
+	FallbackTeamID int      `db:"fallback_team_id"`
+	NotifyUserID   int      `db:"notify_user_id"`
+}
+
+// AgentWorkload holds the availability and the number of active conversations of a team agent.
+type AgentWorkload struct {
+	UserID                       int    `db:"user_id" json:"user_id"`
+	AvailabilityStatus           string `db:"availability_status" json:"availability_status"`
+	ActiveConversationsCount     int    `db:"active_conversations_count" json:"active_conversations_count"`
+	MaxAutoAssignedConversations int    `db:"max_auto_assigned_conversations" json:"max_auto_assigned_conversations"`
+	MaxOpenConversations         int    `db:"max_open_conversations" json:"max_open_conversations"`
+}
+
+// AtOpenConversationsCap returns true if the agent can't be assigned another open conversation, 0 is unlimited.
+func (w AgentWorkload) AtOpenConversationsCap() bool {
+	return w.MaxOpenConversations > 0 && w.ActiveConversationsCount >= w.MaxOpenConversations
 }
 
 type ConversationCounts struct {
@@ -114,39 +237,62 @@ type NewConversationsStats struct {
 	NewConversations int    `db:"new_conversations" json:"new_conversations"`
 }
 
+// ConversationFilter selects and sorts a conversations list the same way the list view does.
+type ConversationFilter struct {
+	// ListType is one of the conversation list types, e.g. `assigned`.
+	ListType string
+	// TeamID is the team of the `team_unassigned` list.
+	TeamID  int
+	Order   string
+	OrderBy string
+	// Filters is the JSON encoded list filters.
+	Filters string
+}
+
+// Recipients are the To and Cc addresses of a message.
+type Recipients struct {
+	To pq.StringArray `db:"to_addresses" json:"to"`
+	CC pq.StringArray `db:"cc_addresses" json:"cc"`
+	// OriginalTo is the address an incoming message was delivered to.
+	OriginalTo string `db:"original_to" json:"-"`
+}
+
 // Message represents a message in a conversation
 type Message struct {
-	ID               int                    `db:"id" json:"id,omitempty"`
-	CreatedAt        time.Time              `db:"created_at" json:"created_at"`
-	UpdatedAt        time.Time              `db:"updated_at" json:"updated_at"`
-	UUID             string                 `db:"uuid" json:"uuid"`
-	Type             string                 `db:"type" json:"type"`
-	Status           string                 `db:"status" json:"status"`
-	ConversationID   int                    `db:"conversation_id" json:"conversation_id"`
-	Content          string                 `db:"content" json:"content"`
-	TextContent      string                 `db:"text_content" json:"text_content"`
-	ContentType      string                 `db:"content_type" json:"content_type"`
-	Private          bool                   `db:"private" json:"private"`
-	SourceID         null.String            `db:"source_id" json:"-"`
-	SenderID         int                    `db:"sender_id" json:"sender_id"`
-	SenderType       string                 `db:"sender_type" json:"sender_type"`
-	InboxID          int                    `db:"inbox_id" json:"-"`
-	Meta             string                 `db:"meta" json:"meta"`
-	Attachments      attachment.Attachments `db:"attachments" json:"attachments"`
-	ConversationUUID string                 `db:"conversation_uuid" json:"-"`
-	From             string                 `db:"from"  json:"-"`
-	To               []string               `db:"from"  json:"-"`
-	AltContent       string                 `db:"alt_content" json:"-"`
-	Subject          string                 `db:"subject" json:"-"`
-	Channel          string                 `db:"channel" json:"-"`
-	CC               pq.StringArray         `db:"cc" json:"-"`
-	BCC              pq.StringArray         `db:"bcc" json:"-"`
-	References       []string               `json:"-"`
-	InReplyTo        string                 `json:"-"`
-	Headers          textproto.MIMEHeader   `json:"-"`
-	Media            []mmodels.Media        `db:"-" json:"-"`
-	IsCSAT           bool                   `db:"-" json:"-"`
-	Total            int                    `db:"total" json:"-"`
+	ID                 int                    `db:"id" json:"id,omitempty"`
+	CreatedAt          time.Time              `db:"created_at" json:"created_at"`
+	UpdatedAt          time.Time              `db:"updated_at" json:"updated_at"`
+	UUID               string                 `db:"uuid" json:"uuid"`
+	Type               string                 `db:"type" json:"type"`
+	Status             string                 `db:"status" json:"status"`
+	ConversationID     int                    `db:"conversation_id" json:"conversation_id"`
+	Content            string                 `db:"content" json:"content"`
+	TextContent        string                 `db:"text_content" json:"text_content"`
+	ContentType        string                 `db:"content_type" json:"content_type"`
+	Private            bool                   `db:"private" json:"private"`
+	SourceID           null.String            `db:"source_id" json:"-"`
+	SenderID           int                    `db:"sender_id" json:"sender_id"`
+	SenderType         string                 `db:"sender_type" json:"sender_type"`
+	InboxID            int                    `db:"inbox_id" json:"-"`
+	Meta               string                 `db:"meta" json:"meta"`
+	Language           null.String            `db:"language" json:"language"`
+	LanguageConfidence null.Float64           `db:"language_confidence" json:"language_confidence"`
+	Attachments        attachment.Attachments `db:"attachments" json:"attachments"`
+	ConversationUUID   string                 `db:"conversation_uuid" json:"-"`
+	From               string                 `db:"from"  json:"-"`
+	To                 []string               `db:"from"  json:"-"`
+	AltContent         string                 `db:"alt_content" json:"-"`
+	Subject            string                 `db:"subject" json:"-"`
+	Channel            string                 `db:"channel" json:"-"`
+	CC                 pq.StringArray         `db:"cc" json:"-"`
+	BCC                pq.StringArray         `db:"bcc" json:"-"`
+	ReplyAll           bool                   `db:"reply_all" json:"-"`
+	References         []string               `json:"-"`
+	InReplyTo          string                 `json:"-"`
+	Headers            textproto.MIMEHeader   `json:"-"`
+	Media              []mmodels.Media        `db:"-" json:"-"`
+	IsCSAT             bool                   `db:"-" json:"-"`
+	Total              int                    `db:"total" json:"-"`
 }
 
 // CensorCSATContent redacts the content of a CSAT message to prevent leaking the CSAT survey public link.
@@ -171,11 +317,84 @@ func (m *Message) HasCSAT() bool {
 	return isCsat
 }
 
+// AgentSuggestion is an agent recommended as assignee of a conversation.
+type AgentSuggestion struct {
+	UserID                    int    `db:"user_id" json:"user_id"`
+	FullName                  string `db:"full_name" json:"full_name"`
+	ContactConversationsCount int    `db:"contact_conversations_count" json:"contact_conversations_count"`
+	SimilarConversationsCount int    `db:"similar_conversations_count" json:"similar_conversations_count"`
+	Score                     int    `db:"-" json:"score"`
+	Reason                    string `db:"-" json:"reason"`
+}
+
+// MergeCandidate is an open conversation that looks like a duplicate of another conversation.
+type MergeCandidate struct {
+	ID                int         `db:"id" json:"id"`
+	UUID              string      `db:"uuid" json:"uuid"`
+	ReferenceNumber   string      `db:"reference_number" json:"reference_number"`
+	Subject           null.String `db:"subject" json:"subject"`
+	Status            string      `db:"status" json:"status"`
+	CreatedAt         time.Time   `db:"created_at" json:"created_at"`
+	LastMessageAt     null.Time   `db:"last_message_at" json:"last_message_at"`
+	SameContact       bool        `db:"same_contact" json:"same_contact"`
+	SubjectSimilarity float64     `db:"subject_similarity" json:"subject_similarity"`
+	Score             float64     `db:"-" json:"score"`
+	Reason            string      `db:"-" json:"reason"`
+}
+
+// FailedAttachment is an attachment of an incoming message that could not be uploaded, recorded in the message meta.
+type FailedAttachment struct {
+	Name        string `json:"name"`
+	ContentType string `json:"content_type"`
+	Size        int    `json:"size"`
+}
+
+// Reasons an incoming message was blocked.
+const (
+	BlockedReasonContactRateLimit = "contact_rate_limit"
+	BlockedReasonInboxRateLimit   = "inbox_rate_limit"
+)
+
+// BlockedIncomingMessage is an incoming message that was not processed as its sender exceeded the incoming rate limits.
+type BlockedIncomingMessage struct {
+	Total     int         `db:"total" json:"-"`
+	ID        int         `db:"id" json:"id"`
+	CreatedAt time.Time   `db:"created_at" json:"created_at"`
+	InboxID   int         `db:"inbox_id" json:"inbox_id"`
+	InboxName string      `db:"inbox_name" json:"inbox_name"`
+	Sender    string      `db:"sender" json:"sender"`
+	SourceID  null.String `db:"source_id" json:"source_id"`
+	Subject   null.String `db:"subject" json:"subject"`
+	Content   null.String `db:"content" json:"content"`
+	Reason    string      `db:"reason" json:"reason"`
+}
+
+// Draft represents an unsent reply or private note of an agent in a conversation.
+type Draft struct {
+	ID               int       `db:"id" json:"id"`
+	CreatedAt        time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt        time.Time `db:"updated_at" json:"updated_at"`
+	UserID           int       `db:"user_id" json:"user_id"`
+	ConversationUUID string    `db:"conversation_uuid" json:"conversation_uuid"`
+	Content          string    `db:"content" json:"content"`
+	Private          bool      `db:"private" json:"private"`
+}
+
 // IncomingMessage links a message with the contact information and inbox id.
 type IncomingMessage struct {
 	Message Message
 	Contact umodels.User
 	InboxID int
+	// DeliveryReport is set when the message is a delivery status notification for an outgoing message.
+	DeliveryReport *DeliveryReport
+}
+
+// DeliveryReport holds the parts of a delivery status notification (RFC 3464) received by an inbox.
+type DeliveryReport struct {
+	// Status is the content of the message/delivery-status part.
+	Status []byte
+	// OriginalHeaders are the headers of the original message returned with the notification.
+	OriginalHeaders []byte
 }
 
 type Status struct {
@@ -189,3 +408,63 @@ type Priority struct {
 	CreatedAt time.Time `db:"created_at" json:"created_at"`
 	Name      string    `db:"name" json:"name"`
 }
+
+// ContactExport is the personal data of a contact, exported on request of the contact.
+type ContactExport struct {
+	ExportedAt    time.Time                   `json:"exported_at"`
+	Contact       ContactExportProfile        `json:"contact"`
+	Conversations []ContactExportConversation `json:"conversations"`
+}
+
+// ContactExportProfile is the profile of an exported contact.
+type ContactExportProfile struct {
+	ID                     int             `json:"id"`
+	CreatedAt              time.Time       `json:"created_at"`
+	FirstName              string          `json:"first_name"`
+	LastName               string          `json:"last_name"`
+	Email                  null.String     `json:"email"`
+	PhoneNumber            null.String     `json:"phone_number"`
+	PhoneNumberCallingCode null.String     `json:"phone_number_calling_code"`
+	AvatarURL              null.String     `json:"avatar_url"`
+	CustomAttributes       json.RawMessage `json:"custom_attributes"`
+}
+
+// ContactExportConversation is an exported conversation of a contact.
+type ContactExportConversation struct {
+	ID               int                    `db:"id" json:"-"`
+	CreatedAt        time.Time              `db:"created_at" json:"created_at"`
+	UUID             string                 `db:"uuid" json:"uuid"`
+	ReferenceNumber  string                 `db:"reference_number" json:"reference_number"`
+	Subject          null.String            `db:"subject" json:"subject"`
+	InboxName        string                 `db:"inbox_name" json:"inbox_name"`
+	Status           null.String            `db:"status" json:"status"`
+	CustomAttributes json.RawMessage        `db:"custom_attributes" json:"custom_attributes"`
+	Messages         []ContactExportMessage `db:"-" json:"messages"`
+}
+
+// ContactExportMessage is an exported message of a contact's conversation.
+type ContactExportMessage struct {
+	ID             int                       `db:"id" json:"-"`
+	CreatedAt      time.Time                 `db:"created_at" json:"created_at"`
+	UUID           string                    `db:"uuid" json:"uuid"`
+	ConversationID int                       `db:"conversation_id" json:"-"`
+	Type           string                    `db:"type" json:"type"`
+	SenderType     string                    `db:"sender_type" json:"sender_type"`
+	ContentType    null.String               `db:"content_type" json:"content_type"`
+	Content        null.String               `db:"content" json:"content"`
+	TextContent    null.String               `db:"text_content" json:"text_content"`
+	Attachments    []ContactExportAttachment `db:"-" json:"attachments"`
+}
+
+// ContactExportAttachment is an exported message attachment, the file content is base64 encoded.
+type ContactExportAttachment struct {
+	ID          int       `db:"id" json:"-"`
+	CreatedAt   time.Time `db:"created_at" json:"created_at"`
+	UUID        string    `db:"uuid" json:"uuid"`
+	MessageID   int       `db:"message_id" json:"-"`
+	Filename    string    `db:"filename" json:"filename"`
+	ContentType string    `db:"content_type" json:"content_type"`
+	Size        null.Int  `db:"size" json:"size"`
+	BlobName    string    `db:"blob_name" json:"-"`
+	Content     []byte    `db:"-" json:"content"`
+}
@@ -0,0 +1,84 @@
+package conversation
+
+import (
+	"slices"
+
+	"github.com/abhinavxd/libredesk/internal/conversation/models"
+)
+
+// nextConversationMaxPages caps the pages of the list scanned for the next conversation.
+const nextConversationMaxPages = 20
+
+// GetNextConversation returns the conversation after `afterUUID` in the list selected by the filter, skipping
+// conversations another agent is viewing. The first conversation of the list is returned if `afterUUID` is empty or
+// no longer in the list, and nil once the list is exhausted.
+func (c *Manager) GetNextConversation(afterUUID string, filter models.ConversationFilter, userID int) (*models.Conversation, error) {
+	var teamIDs = []int{}
+	if filter.TeamID > 0 {
+		teamIDs = append(teamIDs, filter.TeamID)
+	}
+
+	picker := nextConversationPicker{
+		afterUUID: afterUUID,
+		skip:      func(uuid string) bool { return c.viewedByOthers(uuid, userID) },
+	}
+	for page := 1; page <= nextConversationMaxPages; page++ {
+		conversations, err := c.GetConversations(userID, teamIDs, []string{filter.ListType}, filter.Order, filter.OrderBy, filter.Filters, page, conversationsListMaxPageSize)
+		if err != nil {
+			return nil, err
+		}
+		if next := picker.feed(conversations); next != nil {
+			return next, nil
+		}
+		if len(conversations) < conversationsListMaxPageSize {
+			break
+		}
+	}
+	return picker.fallback(), nil
+}
+
+// viewedByOthers returns true if an agent other than the user is viewing the conversation.
+func (c *Manager) viewedByOthers(conversationUUID string, userID int) bool {
+	if c.wsHub == nil {
+		return false
+	}
+	return slices.ContainsFunc(c.wsHub.GetConversationViewers(conversationUUID), func(id int) bool { return id != userID })
+}
+
+// nextConversationPicker picks the next conversation after `afterUUID` from the pages of a conversations list.
+type nextConversationPicker struct {
+	afterUUID string
+	skip      func(uuid string) bool
+	found     bool
+	first     *models.Conversation
+}
+
+// feed scans a page of the list and returns the next conversation if it's in the page.
+func (p *nextConversationPicker) feed(conversations []models.Conversation) *models.Conversation {
+	for i := range conversations {
+		conv := conversations[i]
+		if conv.UUID == p.afterUUID {
+			p.found = true
+			continue
+		}
+		if p.skip != nil && p.skip(conv.UUID) {
+			continue
+		}
+		if p.found || p.afterUUID == "" {
+			return &conv
+		}
+		if p.first == nil {
+			p.first = &conv
+		}
+	}
+	return nil
+}
+
+// fallback returns the first conversation of the list if `afterUUID` wasn't found in it, e.g. it was resolved and
+// dropped out of the list, nil otherwise.
+func (p *nextConversationPicker) fallback() *models.Conversation {
+	if p.found {
+		return nil
+	}
+	return p.first
+}
@@ -0,0 +1,54 @@
+package conversation
+
+import (
+	"testing"
+
+	"github.com/abhinavxd/libredesk/internal/conversation/models"
+)
+
+func TestNextConversationPicker(t *testing.T) {
+	page := func(uuids ...string) []models.Conversation {
+		out := make([]models.Conversation, 0, len(uuids))
+		for _, uuid := range uuids {
+			out = append(out, models.Conversation{UUID: uuid})
+		}
+		return out
+	}
+	viewed := func(uuid string) bool { return uuid == "c3" }
+
+	tests := []struct {
+		name     string
+		after    string
+		pages    [][]models.Conversation
+		expected string
+	}{
+		{name: "first conversation", after: "", pages: [][]models.Conversation{page("c1", "c2")}, expected: "c1"},
+		{name: "next in page", after: "c1", pages: [][]models.Conversation{page("c1", "c2")}, expected: "c2"},
+		{name: "skip viewed by others", after: "c2", pages: [][]models.Conversation{page("c1", "c2", "c3", "c4")}, expected: "c4"},
+		{name: "next in following page", after: "c2", pages: [][]models.Conversation{page("c1", "c2"), page("c3", "c5")}, expected: "c5"},
+		{name: "exhausted", after: "c2", pages: [][]models.Conversation{page("c1", "c2")}, expected: ""},
+		{name: "after not in list", after: "gone", pages: [][]models.Conversation{page("c3", "c4")}, expected: "c4"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := nextConversationPicker{afterUUID: tt.after, skip: viewed}
+			var next *models.Conversation
+			for _, pg := range tt.pages {
+				if next = p.feed(pg); next != nil {
+					break
+				}
+			}
+			if next == nil {
+				next = p.fallback()
+			}
+			got := ""
+			if next != nil {
+				got = next.UUID
+			}
+			if got != tt.expected {
+				t.Errorf("got %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package conversation
+
+import (
+	"strings"
+	"time"
+
+	"github.com/abhinavxd/libredesk/internal/conversation/models"
+	"github.com/abhinavxd/libredesk/internal/envelope"
+	umodels "github.com/abhinavxd/libredesk/internal/user/models"
+	"github.com/volatiletech/null/v9"
+)
+
+// CreateOutboundConversation starts a conversation with a contact from an agent, the content is inserted as the
+// first outgoing message and picked up for sending like any other reply. The conversation is deleted if the message
+// can't be inserted so no empty conversation is left behind.
+func (m *Manager) CreateOutboundConversation(contactID, inboxID int, subject, content string, actor umodels.User) (models.Conversation, error) {
+	var conversation models.Conversation
+
+	subject = strings.TrimSpace(subject)
+	if subject == "" {
+		return conversation, envelope.NewError(envelope.InputError, m.i18n.Ts("globals.messages.fieldRequired", "name", "`subject`"), nil)
+	}
+	if strings.TrimSpace(content) == "" {
+		return conversation, envelope.NewError(envelope.InputError, m.i18n.Ts("globals.messages.fieldRequired", "name", "`content`"), nil)
+	}
+
+	inbox, err := m.inboxStore.GetDBRecord(inboxID)
+	if err != nil {
+		return conversation, err
+	}
+	if !inbox.Enabled {
+		return conversation, envelope.NewError(envelope.InputError, m.i18n.Ts("globals.messages.disabled", "name", "{globals.terms.inbox}"), nil)
+	}
+
+	contact, err := m.userStore.GetContact(contactID, "")
+	if err != nil {
+		return conversation, err
+	}
+	if contact.Email.String == "" {
+		return conversation, envelope.NewError(envelope.InputError, m.i18n.Ts("globals.messages.fieldRequired", "name", "`email`"), nil)
+	}
+
+	// Get the channel of the contact on the inbox, creating it if the contact never wrote to it.
+	channel := umodels.User{
+		Email:           contact.Email,
+		SourceChannelID: null.StringFrom(contact.Email.String),
+		FirstName:       contact.FirstName,
+		LastName:        contact.LastName,
+		InboxID:         inboxID,
+	}
+	if err := m.userStore.CreateContact(&channel); err != nil {
+		return conversation, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorCreating", "name", "{globals.terms.contact}"), nil)
+	}
+
+	conversationID, conversationUUID, err := m.CreateConversation(contact.ID, channel.ContactChannelID, inboxID, "" /**last_message**/, time.Now(), subject, true /**append reference number to subject**/)
+	if err != nil {
+		return conversation, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorCreating", "name", "{globals.terms.conversation}"), nil)
+	}
+
+	if err := m.SendReply(nil /**media**/, inboxID, actor.ID, conversationUUID, content, nil /**cc**/, nil /**bcc**/, map[string]any{} /**meta**/); err != nil {
+		if err := m.DeleteConversation(conversationUUID); err != nil {
+			m.lo.Error("error deleting outbound conversation", "conversation_uuid", conversationUUID, "error", err)
+		}
+		return conversation, err
+	}
+	return m.GetConversation(conversationID, "")
+}
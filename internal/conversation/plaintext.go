@@ -0,0 +1,28 @@
+package conversation
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/abhinavxd/libredesk/internal/conversation/models"
+	"github.com/abhinavxd/libredesk/internal/stringutil"
+)
+
+// errNoPlainTextContent is returned when an HTML message sent from a plain text only inbox has no text left once
+// converted, e.g. a message with only images.
+var errNoPlainTextContent = errors.New("message has no text content to send from a plain text only inbox")
+
+// toPlainText downgrades the HTML content of a message sent from a plain text only inbox to text.
+func toPlainText(message *models.Message) error {
+	if message.ContentType != models.ContentTypeHTML {
+		return nil
+	}
+	text := strings.ReplaceAll(stringutil.HTML2Text(message.Content), "\r\n", "\n")
+	if strings.TrimSpace(text) == "" {
+		return errNoPlainTextContent
+	}
+	message.Content = text
+	message.ContentType = models.ContentTypeText
+	message.AltContent = ""
+	return nil
+}
@@ -0,0 +1,52 @@
+package conversation
+
+import (
+	"testing"
+
+	"github.com/abhinavxd/libredesk/internal/conversation/models"
+)
+
+func TestToPlainText(t *testing.T) {
+	tests := []struct {
+		name        string
+		message     models.Message
+		expected    string
+		contentType string
+		err         error
+	}{
+		{
+			name:        "html message",
+			message:     models.Message{Content: `<p>Hi <b>Jane</b>,</p><p>See <a href="https://example.com/help">help</a></p>`, ContentType: models.ContentTypeHTML, AltContent: "alt"},
+			expected:    "Hi Jane,\n\nSee https://example.com/help",
+			contentType: models.ContentTypeText,
+		},
+		{
+			name:        "text message",
+			message:     models.Message{Content: "Hi <b>Jane</b>", ContentType: models.ContentTypeText},
+			expected:    "Hi <b>Jane</b>",
+			contentType: models.ContentTypeText,
+		},
+		{
+			name:        "html message without text",
+			message:     models.Message{Content: `<p><img src="logo.png"></p>`, ContentType: models.ContentTypeHTML},
+			expected:    `<p><img src="logo.png"></p>`,
+			contentType: models.ContentTypeHTML,
+			err:         errNoPlainTextContent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := tt.message
+			if err := toPlainText(&msg); err != tt.err {
+				t.Fatalf("got error %v, want %v", err, tt.err)
+			}
+			if msg.Content != tt.expected || msg.ContentType != tt.contentType {
+				t.Errorf("got %q (%s), want %q (%s)", msg.Content, msg.ContentType, tt.expected, tt.contentType)
+			}
+			if tt.err == nil && tt.message.ContentType == models.ContentTypeHTML && msg.AltContent != "" {
+				t.Errorf("alt content not cleared: %q", msg.AltContent)
+			}
+		})
+	}
+}
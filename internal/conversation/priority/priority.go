@@ -2,6 +2,7 @@
 package priority
 
 import (
+	"database/sql"
 	"embed"
 
 	"github.com/abhinavxd/libredesk/internal/conversation/priority/models"
@@ -33,8 +34,9 @@ type Opts struct {
 
 // queries contains prepared SQL queries.
 type queries struct {
-	GetAll *sqlx.Stmt `query:"get-all"`
-	Get    *sqlx.Stmt `query:"get"`
+	GetAll    *sqlx.Stmt `query:"get-all"`
+	Get       *sqlx.Stmt `query:"get"`
+	GetByName *sqlx.Stmt `query:"get-by-name"`
 }
 
 // New creates and returns a new instance of the Manager.
@@ -64,8 +66,24 @@ func (m *Manager) GetAll() ([]models.Priority, error) {
 func (m *Manager) Get(id int) (models.Priority, error) {
 	var priority models.Priority
 	if err := m.q.Get.Get(&priority, id); err != nil {
+		if err == sql.ErrNoRows {
+			return priority, envelope.NewError(envelope.InputError, m.i18n.Ts("globals.messages.invalid", "name", m.i18n.S("globals.terms.priority")), nil)
+		}
 		m.lo.Error("error fetching priority", "error", err)
 		return priority, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", m.i18n.S("globals.terms.priority")), nil)
 	}
 	return priority, nil
 }
+
+// GetByName retrieves a priority by its name, names are matched exactly so that unknown priorities are rejected.
+func (m *Manager) GetByName(name string) (models.Priority, error) {
+	var priority models.Priority
+	if err := m.q.GetByName.Get(&priority, name); err != nil {
+		if err == sql.ErrNoRows {
+			return priority, envelope.NewError(envelope.InputError, m.i18n.Ts("globals.messages.invalid", "name", m.i18n.S("globals.terms.priority")), nil)
+		}
+		m.lo.Error("error fetching priority", "name", name, "error", err)
+		return priority, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", m.i18n.S("globals.terms.priority")), nil)
+	}
+	return priority, nil
+}
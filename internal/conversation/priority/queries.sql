@@ -2,4 +2,7 @@
 SELECT created_at, id, name from conversation_priorities;
 
 -- name: get
-SELECT created_at, id, name from conversation_priorities WHERE id = $1;
\ No newline at end of file
+SELECT created_at, id, name from conversation_priorities WHERE id = $1;
+
+-- name: get-by-name
+SELECT created_at, id, name from conversation_priorities WHERE name = $1;
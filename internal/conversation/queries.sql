@@ -4,15 +4,29 @@ SET snoozed_until = NULL, status_id = (SELECT id FROM conversation_statuses WHER
 WHERE snoozed_until <= now();
 
 -- name: insert-conversation
+-- The initial priority is the VIP priority of the inbox for contacts with a truthy `vip` custom attribute,
+-- else the default priority of the inbox, else none.
 WITH 
 status_id AS (
    SELECT id FROM conversation_statuses WHERE name = $3
 ),
 reference_number AS (
    SELECT generate_reference_number($8) AS reference_number
+),
+priority_id AS (
+   SELECT p.id
+   FROM inboxes i
+   LEFT JOIN users u ON u.id = $1
+   INNER JOIN conversation_priorities p ON p.id = COALESCE(
+      CASE WHEN LOWER(COALESCE(u.custom_attributes->>'vip', '')) IN ('true', 'yes', '1')
+         THEN NULLIF((i.config->'priority_defaults'->>'vip_priority_id')::INT, 0)
+      END,
+      NULLIF((i.config->'priority_defaults'->>'default_priority_id')::INT, 0)
+   )
+   WHERE i.id = $4
 )
 INSERT INTO conversations
-(contact_id, contact_channel_id, status_id, inbox_id, last_message, last_message_at, subject, reference_number)
+(contact_id, contact_channel_id, status_id, inbox_id, last_message, last_message_at, subject, reference_number, priority_id)
 VALUES(
    $1, 
    $2, 
@@ -24,9 +38,10 @@ VALUES(
       WHEN $9 = TRUE THEN CONCAT($7::text, ' [', (SELECT reference_number FROM reference_number), ']')
       ELSE $7::text
    END, 
-   (SELECT reference_number FROM reference_number)
+   (SELECT reference_number FROM reference_number),
+   (SELECT id FROM priority_id)
 )
-RETURNING id, uuid;
+RETURNING id, uuid, COALESCE((SELECT name FROM conversation_priorities WHERE id = conversations.priority_id), '');
 
 -- name: get-conversations
 SELECT
@@ -59,7 +74,11 @@ SELECT
     FROM (
         SELECT 1 FROM conversation_messages 
         WHERE conversation_id = conversations.id 
-        AND created_at > conversations.assignee_last_seen_at
+        -- The requesting user's own read watermark takes precedence over the assignee's last seen time.
+        AND created_at > COALESCE(
+            (SELECT last_read_at FROM conversation_read_states WHERE conversation_id = conversations.id AND user_id = $1),
+            conversations.assignee_last_seen_at
+        )
         LIMIT 10
     ) t
     ) as unread_message_count,
@@ -79,7 +98,25 @@ SELECT
         WHERE conversation_id = conversations.id 
         ORDER BY created_at DESC LIMIT 1
     ) as_latest ON true
-WHERE 1=1 %s
+WHERE conversations.deleted_at IS NULL %s
+
+-- name: get-conversation-status-counts
+-- $1 is the requesting user, the `assigned` list condition compares with it. Grouped by status in the code once
+-- the filters are appended.
+SELECT conversation_statuses.name AS status, COUNT(*) AS count
+FROM conversations
+LEFT JOIN conversation_statuses ON status_id = conversation_statuses.id
+WHERE conversations.deleted_at IS NULL AND $1::BIGINT > 0 %s
+
+-- name: get-conversation-inbox-counts
+-- $1 is the requesting user, the `assigned` list condition compares with it. Grouped by inbox in the code once
+-- the filters are appended.
+SELECT conversations.inbox_id,
+    COUNT(*) FILTER (WHERE conversation_statuses.name = 'Open') AS open,
+    COUNT(*) FILTER (WHERE conversation_statuses.name = 'Open' AND conversations.assigned_user_id IS NULL) AS unassigned
+FROM conversations
+LEFT JOIN conversation_statuses ON status_id = conversation_statuses.id
+WHERE conversations.deleted_at IS NULL AND $1::BIGINT > 0 %s
 
 -- name: get-conversation
 WITH last_reply AS (
@@ -115,12 +152,18 @@ SELECT
    c.waiting_since,
    c.assigned_user_id,
    c.assigned_team_id,
+   c.assigned_queue_id,
    c.subject,
    c.contact_id,
    c.sla_policy_id,
    sla.name as sla_policy_name,
    c.last_message,
    c.custom_attributes,
+   c.language,
+   c.locale,
+   c.manually_assigned_at,
+   c.deleted_at,
+   c.deleted_by,
    (SELECT COALESCE(
        (SELECT json_agg(t.name)
        FROM tags t
@@ -169,6 +212,17 @@ SELECT
 FROM conversations c
 WHERE c.created_at > $1;
 
+-- name: get-conversations-to-auto-close
+-- Any update to the conversation including new messages and status changes postpones the auto-close.
+SELECT
+    c.id,
+    c.uuid
+FROM conversations c
+JOIN conversation_statuses s ON s.id = c.status_id
+WHERE s.name = ANY($1::TEXT[])
+AND c.updated_at < $2
+AND c.deleted_at IS NULL;
+
 -- name: get-contact-conversations
 SELECT
     c.uuid,
@@ -180,19 +234,41 @@ SELECT
 FROM users u
 JOIN conversations c ON c.contact_id = u.id
 WHERE c.contact_id = $1
+AND c.deleted_at IS NULL
 ORDER BY c.created_at DESC
 LIMIT 10;
 
+-- name: get-contact-past-conversation-count
+SELECT COUNT(*)
+FROM conversations
+WHERE contact_id = $1
+AND id < $2
+AND deleted_at IS NULL;
+
 -- name: get-conversation-uuid
 SELECT uuid from conversations where id = $1;
 
+-- name: lock-agent-for-assignment
+-- Locks the agent so that concurrent assignments to the agent count its open conversations one after the other.
+SELECT id FROM users WHERE id = $1 FOR UPDATE;
+
 -- name: update-conversation-assigned-user
+-- Assigns the conversation unless the user is at their maximum open conversations, not counting the conversation.
 UPDATE conversations
 SET assigned_user_id = $2,
 -- Reset assignee_last_seen_at when assigned to a new user.
 assignee_last_seen_at = NULL,
 updated_at = now()
-WHERE uuid = $1;
+WHERE uuid = $1
+AND NOT EXISTS (
+    SELECT 1 FROM users u
+    WHERE u.id = $2 AND COALESCE(u.max_open_conversations, 0) > 0
+    AND u.max_open_conversations <= (
+        SELECT COUNT(*) FROM conversations o
+        WHERE o.assigned_user_id = u.id AND o.id <> conversations.id
+        AND o.status_id IN (SELECT id FROM conversation_statuses WHERE name NOT IN ('Resolved', 'Closed'))
+    )
+);
 
 -- name: update-conversation-assigned-team
 UPDATE conversations
@@ -200,6 +276,49 @@ SET assigned_team_id = $2,
 updated_at = now()
 WHERE uuid = $1;
 
+-- name: route-conversation-to-queue
+-- Unassigns the agent so the conversation waits in the queue, a team queue also moves the conversation to the team.
+UPDATE conversations c
+SET assigned_queue_id = q.id,
+assigned_user_id = NULL,
+assigned_team_id = COALESCE(q.team_id, c.assigned_team_id),
+updated_at = now()
+FROM queues q
+WHERE c.uuid = $1 AND q.id = $2
+RETURNING q.name, c.assigned_team_id;
+
+-- name: pull-queue-conversation
+-- Assigns the longest waiting open conversation of the queue to the agent, skipping rows locked by concurrent pulls.
+-- Nothing is assigned if the agent is at their maximum open conversations.
+WITH next AS (
+    SELECT c.id
+    FROM conversations c
+    JOIN conversation_statuses s ON s.id = c.status_id
+    WHERE c.assigned_queue_id = $1
+    AND c.assigned_user_id IS NULL
+    AND c.deleted_at IS NULL
+    AND s.name NOT IN ('Resolved', 'Closed')
+    ORDER BY c.waiting_since ASC NULLS LAST, c.created_at ASC
+    LIMIT 1
+    FOR UPDATE OF c SKIP LOCKED
+)
+UPDATE conversations
+SET assigned_user_id = $2,
+assignee_last_seen_at = NULL,
+updated_at = now()
+FROM next
+WHERE conversations.id = next.id
+AND NOT EXISTS (
+    SELECT 1 FROM users u
+    WHERE u.id = $2 AND COALESCE(u.max_open_conversations, 0) > 0
+    AND u.max_open_conversations <= (
+        SELECT COUNT(*) FROM conversations o
+        WHERE o.assigned_user_id = u.id AND o.id <> conversations.id
+        AND o.status_id IN (SELECT id FROM conversation_statuses WHERE name NOT IN ('Resolved', 'Closed'))
+    )
+)
+RETURNING conversations.uuid;
+
 -- name: update-conversation-status
 UPDATE conversations
 SET status_id = (SELECT id FROM conversation_statuses WHERE name = $2),
@@ -212,6 +331,108 @@ WHERE uuid = $1;
 -- name: get-user-active-conversations-count
 SELECT COUNT(*) FROM conversations WHERE status_id IN (SELECT id FROM conversation_statuses WHERE name NOT IN ('Resolved', 'Closed')) and assigned_user_id = $1;
 
+-- name: get-team-agents-workload
+SELECT
+    u.id AS user_id,
+    u.availability_status,
+    t.max_auto_assigned_conversations,
+    COALESCE(u.max_open_conversations, 0) AS max_open_conversations,
+    COUNT(c.id) AS active_conversations_count
+FROM team_members tm
+JOIN teams t ON t.id = tm.team_id
+JOIN users u ON u.id = tm.user_id
+LEFT JOIN conversations c ON c.assigned_user_id = u.id
+    AND c.status_id IN (SELECT id FROM conversation_statuses WHERE name NOT IN ('Resolved', 'Closed'))
+WHERE tm.team_id = $1 AND u.deleted_at IS NULL AND u.type = 'agent' AND u.enabled = true
+GROUP BY u.id, u.availability_status, t.max_auto_assigned_conversations, u.max_open_conversations
+ORDER BY u.id;
+
+-- name: get-agent-workload
+-- Open conversations of the agent, leaving out the conversation with uuid $2 so reassigning it to the same agent
+-- isn't counted twice.
+SELECT
+    u.id AS user_id,
+    u.availability_status,
+    0 AS max_auto_assigned_conversations,
+    COALESCE(u.max_open_conversations, 0) AS max_open_conversations,
+    (
+        SELECT COUNT(*) FROM conversations c
+        WHERE c.assigned_user_id = u.id AND c.uuid::TEXT <> $2
+        AND c.status_id IN (SELECT id FROM conversation_statuses WHERE name NOT IN ('Resolved', 'Closed'))
+    ) AS active_conversations_count
+FROM users u
+WHERE u.id = $1 AND u.type = 'agent' AND u.deleted_at IS NULL;
+
+-- name: get-merge-candidates
+-- Open conversations created within $2 seconds of the conversation, from the same contact or with a subject at
+-- least $3 similar. Reference numbers appended to subjects are left out of the similarity.
+WITH target AS (
+    SELECT id, contact_id, created_at, regexp_replace(COALESCE(subject, ''), '\s*\[[^\]]*\]$', '') AS subject
+    FROM conversations WHERE uuid = $1
+),
+candidates AS (
+    SELECT
+        c.id,
+        c.uuid,
+        c.reference_number,
+        c.subject,
+        s.name AS status,
+        c.created_at,
+        c.last_message_at,
+        c.contact_id = t.contact_id AS same_contact,
+        similarity(regexp_replace(COALESCE(c.subject, ''), '\s*\[[^\]]*\]$', ''), t.subject) AS subject_similarity
+    FROM target t
+    INNER JOIN conversations c ON c.id <> t.id
+    INNER JOIN conversation_statuses s ON s.id = c.status_id
+    WHERE c.deleted_at IS NULL
+        AND s.name NOT IN ('Resolved', 'Closed')
+        AND c.created_at BETWEEN t.created_at - make_interval(secs => $2) AND t.created_at + make_interval(secs => $2)
+)
+SELECT * FROM candidates
+WHERE same_contact OR subject_similarity >= $3;
+
+-- name: get-assignee-suggestions
+-- Agents who handled the contact's other conversations, either as assignee or by replying, and agents
+-- assigned to recent conversations sharing a tag with this conversation.
+WITH target AS (
+    SELECT id, contact_id FROM conversations WHERE uuid = $1
+),
+contact_history AS (
+    SELECT h.user_id, COUNT(DISTINCT h.conversation_id) AS cnt
+    FROM (
+        SELECT c.assigned_user_id AS user_id, c.id AS conversation_id
+        FROM conversations c
+        JOIN target t ON c.contact_id = t.contact_id AND c.id <> t.id
+        WHERE c.assigned_user_id IS NOT NULL
+        UNION
+        SELECT m.sender_id AS user_id, m.conversation_id
+        FROM conversation_messages m
+        JOIN conversations c ON c.id = m.conversation_id
+        JOIN target t ON c.contact_id = t.contact_id AND c.id <> t.id
+        WHERE m.type = 'outgoing' AND m.sender_type = 'agent'
+    ) h
+    GROUP BY h.user_id
+),
+tag_history AS (
+    SELECT c.assigned_user_id AS user_id, COUNT(DISTINCT c.id) AS cnt
+    FROM target t
+    JOIN conversation_tags tct ON tct.conversation_id = t.id
+    JOIN conversation_tags ct ON ct.tag_id = tct.tag_id AND ct.conversation_id <> t.id
+    JOIN conversations c ON c.id = ct.conversation_id
+    WHERE c.assigned_user_id IS NOT NULL AND c.created_at > NOW() - $2::interval
+    GROUP BY c.assigned_user_id
+)
+SELECT
+    u.id AS user_id,
+    concat(u.first_name, ' ', u.last_name) AS full_name,
+    COALESCE(ch.cnt, 0) AS contact_conversations_count,
+    COALESCE(th.cnt, 0) AS similar_conversations_count
+FROM users u
+LEFT JOIN contact_history ch ON ch.user_id = u.id
+LEFT JOIN tag_history th ON th.user_id = u.id
+WHERE (ch.user_id IS NOT NULL OR th.user_id IS NOT NULL)
+    AND u.type = 'agent' AND u.enabled = true AND u.deleted_at IS NULL;
+
 -- name: update-conversation-priority
 UPDATE conversations 
 SET priority_id = (SELECT id FROM conversation_priorities WHERE name = $2),
@@ -224,6 +445,34 @@ SET assignee_last_seen_at = now(),
     updated_at = now()
 WHERE uuid = $1;
 
+-- name: set-conversations-read-state
+-- Moves the read watermark of the user to now when read, or to just before the latest message when unread so
+-- that it counts as unread. Returns the resulting unread message count, capped at 10 like the conversations list.
+WITH upserted AS (
+    INSERT INTO conversation_read_states (user_id, conversation_id, last_read_at)
+    SELECT $2, c.id,
+        CASE WHEN $3::BOOLEAN THEN NOW()
+        ELSE COALESCE(c.last_message_at, c.created_at) - INTERVAL '1 millisecond'
+        END
+    FROM conversations c
+    WHERE c.uuid = ANY($1::UUID[])
+    ON CONFLICT (user_id, conversation_id) DO UPDATE
+    SET last_read_at = EXCLUDED.last_read_at,
+        updated_at = NOW()
+    RETURNING conversation_id, last_read_at
+)
+SELECT c.uuid,
+    (
+    SELECT COUNT(*) FROM (
+        SELECT 1 FROM conversation_messages m
+        WHERE m.conversation_id = u.conversation_id
+        AND m.created_at > u.last_read_at
+        LIMIT 10
+    ) t
+    ) AS unread_message_count
+FROM upserted u
+JOIN conversations c ON c.id = u.conversation_id;
+
 -- name: update-conversation-last-message
 UPDATE conversations SET last_message = $3, last_message_sender = $4, last_message_at = $5, updated_at = NOW() WHERE CASE 
     WHEN $1 > 0 THEN id = $1
@@ -231,18 +480,191 @@ UPDATE conversations SET last_message = $3, last_message_sender = $4, last_messa
 END
 
 -- name: get-conversation-participants
-SELECT users.id as id, first_name, last_name, avatar_url 
-FROM conversation_participants
-INNER JOIN users ON users.id = conversation_participants.user_id
-WHERE conversation_id =
-(
-    SELECT id FROM conversations WHERE uuid = $1
-);
+-- The current assignee is listed with the assignee role, the other agents with their participant role.
+SELECT DISTINCT ON (users.id)
+    users.id as id,
+    first_name,
+    last_name,
+    avatar_url,
+    CASE WHEN c.assigned_user_id = users.id THEN 'assignee' ELSE cp.role::TEXT END AS role
+FROM conversation_participants cp
+INNER JOIN conversations c ON c.id = cp.conversation_id
+INNER JOIN users ON users.id = cp.user_id
+WHERE c.uuid = $1
+ORDER BY users.id;
 
 -- name: insert-conversation-participant
+-- The role of an existing participant is replaced if $4 is true, otherwise only followers are promoted to the new role.
 INSERT INTO conversation_participants
-(user_id, conversation_id)
-VALUES($1, (SELECT id FROM conversations WHERE uuid = $2));
+(user_id, conversation_id, role)
+VALUES($1, (SELECT id FROM conversations WHERE uuid = $2), $3)
+ON CONFLICT (conversation_id, user_id) DO UPDATE
+SET role = EXCLUDED.role, updated_at = NOW()
+WHERE $4::BOOLEAN OR conversation_participants.role = 'follower';
+
+-- name: delete-conversation-participant
+DELETE FROM conversation_participants
+WHERE user_id = $1 AND conversation_id = (SELECT id FROM conversations WHERE uuid = $2);
+
+-- name: insert-conversation-watcher
+INSERT INTO conversation_watchers (user_id, conversation_id)
+VALUES ($1, (SELECT id FROM conversations WHERE uuid = $2))
+ON CONFLICT (conversation_id, user_id) DO NOTHING;
+
+-- name: delete-conversation-watcher
+DELETE FROM conversation_watchers
+WHERE user_id = $1 AND conversation_id = (SELECT id FROM conversations WHERE uuid = $2);
+
+-- name: get-conversation-watchers
+SELECT users.id, users.first_name, users.last_name, users.avatar_url
+FROM conversation_watchers cw
+INNER JOIN users ON users.id = cw.user_id
+WHERE cw.conversation_id = (SELECT id FROM conversations WHERE uuid = $1)
+ORDER BY users.id;
+
+-- name: get-watch-digest-activity
+-- Returns the new replies in the conversations watched by users due for a digest, i.e. users who haven't
+-- got one in the passed interval. Conversations assigned to the watcher and their own messages are left out.
+SELECT
+    cw.user_id,
+    u.email,
+    c.uuid AS conversation_uuid,
+    c.reference_number,
+    c.subject,
+    c.last_message,
+    COUNT(m.id) AS new_messages
+FROM conversation_watchers cw
+INNER JOIN users u ON u.id = cw.user_id AND u.type = 'agent' AND u.enabled AND u.deleted_at IS NULL
+INNER JOIN conversations c ON c.id = cw.conversation_id
+LEFT JOIN conversation_watch_digests d ON d.user_id = cw.user_id
+INNER JOIN conversation_messages m ON m.conversation_id = c.id
+    AND m.created_at > GREATEST(COALESCE(d.last_sent_at, cw.created_at), cw.created_at)
+    AND m.type IN ('incoming', 'outgoing')
+    AND m.sender_id <> cw.user_id
+WHERE c.assigned_user_id IS DISTINCT FROM cw.user_id
+    AND (d.last_sent_at IS NULL OR d.last_sent_at < NOW() - $1::INTERVAL)
+GROUP BY cw.user_id, u.email, c.id
+ORDER BY cw.user_id, MAX(m.created_at) DESC;
+
+-- name: upsert-watch-digest-sent-at
+INSERT INTO conversation_watch_digests (user_id, last_sent_at)
+VALUES ($1, NOW())
+ON CONFLICT (user_id) DO UPDATE SET last_sent_at = NOW();
+
+-- name: insert-conversation-reminder
+WITH ins AS (
+    INSERT INTO conversation_reminders (conversation_id, user_id, remind_at, note)
+    VALUES ((SELECT id FROM conversations WHERE uuid = $1), $2, $3, $4)
+    RETURNING *
+)
+SELECT ins.id, ins.created_at, ins.user_id, ins.remind_at, ins.note, ins.notified_at,
+    c.uuid AS conversation_uuid, c.reference_number, c.subject
+FROM ins
+INNER JOIN conversations c ON c.id = ins.conversation_id;
+
+-- name: get-user-reminders
+-- Returns the reminders of a user that are not yet due, soonest first.
+SELECT r.id, r.created_at, r.user_id, r.remind_at, r.note, r.notified_at,
+    c.uuid AS conversation_uuid, c.reference_number, c.subject
+FROM conversation_reminders r
+INNER JOIN conversations c ON c.id = r.conversation_id
+WHERE r.user_id = $1 AND r.notified_at IS NULL
+ORDER BY r.remind_at;
+
+-- name: delete-conversation-reminder
+DELETE FROM conversation_reminders WHERE id = $1 AND user_id = $2;
+
+-- name: get-due-reminders
+-- Locks the due reminders until the transaction ends, reminders locked by another instance are skipped.
+SELECT r.id, r.created_at, r.user_id, r.remind_at, r.note, r.notified_at,
+    c.uuid AS conversation_uuid, c.reference_number, c.subject, u.email
+FROM conversation_reminders r
+INNER JOIN conversations c ON c.id = r.conversation_id
+INNER JOIN users u ON u.id = r.user_id
+WHERE r.notified_at IS NULL AND r.remind_at <= NOW()
+ORDER BY r.remind_at
+LIMIT $1
+FOR UPDATE OF r SKIP LOCKED;
+
+-- name: mark-reminder-notified
+UPDATE conversation_reminders SET notified_at = NOW() WHERE id = $1 RETURNING notified_at;
+
+-- name: upsert-message-tracking-link
+-- Returns the existing link when a message is sent again, e.g. on retry.
+INSERT INTO message_tracking_links (message_id, url)
+VALUES ($1, $2)
+ON CONFLICT (message_id, url) DO UPDATE SET url = EXCLUDED.url
+RETURNING uuid;
+
+-- name: get-message-tracking-link
+SELECT message_id, url FROM message_tracking_links WHERE uuid = $1;
+
+-- name: insert-message-open-event
+INSERT INTO message_tracking_events (message_id, "type")
+SELECT id, 'open' FROM conversation_messages WHERE uuid = $1 AND type = 'outgoing';
+
+-- name: insert-message-click-event
+INSERT INTO message_tracking_events (message_id, "type", url)
+VALUES ($1, 'click', $2);
+
+-- name: get-message-tracking-events
+SELECT e.created_at, e."type", e.url
+FROM message_tracking_events e
+INNER JOIN conversation_messages m ON m.id = e.message_id
+WHERE m.uuid = $1
+ORDER BY e.created_at;
+
+-- name: get-unassigned-conversations-to-escalate
+-- Open conversations without an assigned agent for longer than the unassigned escalation minutes of their inbox.
+SELECT
+    c.id,
+    c.uuid,
+    c.assigned_team_id,
+    (i.config->'unassigned_escalation'->>'minutes')::INT AS minutes,
+    COALESCE((i.config->'unassigned_escalation'->>'fallback_team_id')::INT, 0) AS fallback_team_id,
+    COALESCE((i.config->'unassigned_escalation'->>'notify_user_id')::INT, 0) AS notify_user_id
+FROM conversations c
+INNER JOIN inboxes i ON i.id = c.inbox_id AND i.deleted_at IS NULL
+INNER JOIN conversation_statuses s ON s.id = c.status_id
+WHERE c.assigned_user_id IS NULL
+    AND c.unassigned_escalated_at IS NULL
+    AND c.deleted_at IS NULL
+    AND s.name = 'Open'
+    AND COALESCE((i.config->'unassigned_escalation'->>'minutes')::INT, 0) > 0
+    AND c.created_at < NOW() - make_interval(mins => (i.config->'unassigned_escalation'->>'minutes')::INT);
+
+-- name: get-inbox-default-assignee
+-- The default team and agent of an inbox, with whether they still exist. Disabled and deleted agents don't count.
+SELECT
+    COALESCE((i.config->'default_assignee'->>'team_id')::INT, 0) AS team_id,
+    EXISTS (
+        SELECT 1 FROM teams t WHERE t.id = (i.config->'default_assignee'->>'team_id')::INT
+    ) AS team_exists,
+    COALESCE((i.config->'default_assignee'->>'user_id')::INT, 0) AS user_id,
+    EXISTS (
+        SELECT 1 FROM users u
+        WHERE u.id = (i.config->'default_assignee'->>'user_id')::INT
+        AND u.type = 'agent' AND u.enabled AND u.deleted_at IS NULL
+    ) AS user_exists
+FROM inboxes i
+WHERE i.id = $1;
+
+-- name: set-unassigned-escalated
+UPDATE conversations SET unassigned_escalated_at = NOW() WHERE id = $1;
+
+-- name: set-conversation-manually-assigned
+UPDATE conversations SET manually_assigned_at = NOW() WHERE uuid = $1;
+
+-- name: update-conversation-subject
+-- The subject the conversation was created with is kept in the meta the first time it's changed.
+UPDATE conversations
+SET meta = CASE WHEN meta->'original_subject' IS NULL THEN meta || jsonb_build_object('original_subject', subject) ELSE meta END,
+    subject = $2,
+    updated_at = NOW()
+WHERE uuid = $1;
+
+-- name: update-conversation-locale
+UPDATE conversations SET locale = NULLIF($2, ''), updated_at = NOW() WHERE uuid = $1;
 
 -- name: get-unassigned-conversations
 SELECT
@@ -254,7 +676,7 @@ SELECT
     inb.name as inbox_name
 FROM conversations c
     JOIN inboxes inb ON c.inbox_id = inb.id 
-WHERE assigned_user_id IS NULL AND assigned_team_id IS NOT NULL;
+WHERE assigned_user_id IS NULL AND assigned_team_id IS NOT NULL AND c.deleted_at IS NULL;
 
 -- name: get-dashboard-counts
 SELECT json_build_object(
@@ -268,7 +690,7 @@ SELECT json_build_object(
 )
 FROM conversations c
 INNER JOIN conversation_statuses s ON c.status_id = s.id
-WHERE s.name not in ('Resolved', 'Closed') AND 1=1 %s;
+WHERE s.name not in ('Resolved', 'Closed') AND c.deleted_at IS NULL %s;
 
 -- name: get-dashboard-charts
 WITH new_conversations AS (
@@ -329,6 +751,51 @@ UPDATE conversations
 SET first_reply_at = $2
 WHERE first_reply_at IS NULL AND id = $1;
 
+-- name: insert-conversation-assignment
+-- Records the current assignee of the conversation unless it's unchanged since the last assignment, unassignments
+-- included. If $2 is true, a reassignment to another team or agent before the first reply restarts the first response
+-- time, an unassignment doesn't.
+WITH c AS (
+    SELECT id, assigned_user_id, assigned_team_id, first_reply_at
+    FROM conversations
+    WHERE uuid = $1
+),
+prev AS (
+    SELECT a.assigned_user_id, a.assigned_team_id
+    FROM conversation_assignments a, c
+    WHERE a.conversation_id = c.id
+    ORDER BY a.id DESC
+    LIMIT 1
+),
+ins AS (
+    INSERT INTO conversation_assignments (conversation_id, assigned_user_id, assigned_team_id)
+    SELECT c.id, c.assigned_user_id, c.assigned_team_id
+    FROM c
+    WHERE NOT EXISTS (
+        SELECT 1 FROM prev
+        WHERE prev.assigned_user_id IS NOT DISTINCT FROM c.assigned_user_id
+        AND prev.assigned_team_id IS NOT DISTINCT FROM c.assigned_team_id
+    )
+)
+UPDATE conversations
+SET first_reply_started_at = NOW()
+FROM c
+WHERE conversations.id = c.id
+AND $2::BOOLEAN
+AND c.first_reply_at IS NULL
+AND EXISTS (
+    SELECT 1 FROM prev
+    WHERE (prev.assigned_team_id IS NOT NULL AND c.assigned_team_id IS NOT NULL AND prev.assigned_team_id <> c.assigned_team_id)
+    OR (prev.assigned_user_id IS NOT NULL AND c.assigned_user_id IS NOT NULL AND prev.assigned_user_id <> c.assigned_user_id)
+);
+
+-- name: update-assignment-first-reply-at
+-- Sets the first reply of the current assignment of the conversation.
+UPDATE conversation_assignments
+SET first_reply_at = $2
+WHERE id = (SELECT id FROM conversation_assignments WHERE conversation_id = $1 ORDER BY id DESC LIMIT 1)
+AND first_reply_at IS NULL;
+
 -- name: update-conversation-last-reply-at
 UPDATE conversations
 SET last_reply_at = $2
@@ -373,7 +840,8 @@ AND tag_id IN (
 SELECT t.name
 FROM conversation_tags ct
 JOIN tags t ON ct.tag_id = t.id
-WHERE ct.conversation_id = (SELECT id FROM conversations WHERE uuid = $1);
+WHERE ct.conversation_id = (SELECT id FROM conversations WHERE uuid = $1)
+ORDER BY t.name;
 
 -- name: get-to-address
 SELECT cc.identifier 
@@ -381,6 +849,29 @@ FROM conversations c
 INNER JOIN contact_channels cc ON cc.id = c.contact_channel_id 
 WHERE c.id = $1;
 
+-- name: get-latest-incoming-recipients
+SELECT
+    ARRAY(SELECT jsonb_array_elements_text(CASE WHEN jsonb_typeof(m.meta->'to') = 'array' THEN m.meta->'to' ELSE '[]'::JSONB END)) AS to_addresses,
+    ARRAY(SELECT jsonb_array_elements_text(CASE WHEN jsonb_typeof(m.meta->'cc') = 'array' THEN m.meta->'cc' ELSE '[]'::JSONB END)) AS cc_addresses,
+    COALESCE(m.meta->>'original_to', '') AS original_to
+FROM conversation_messages m
+WHERE m.conversation_id = $1 AND m.type = 'incoming' AND m.private = false
+ORDER BY m.created_at DESC
+LIMIT 1;
+
+-- name: set-message-translation
+-- Caches the translation by language in meta, the detected language of the message is kept once set.
+UPDATE conversation_messages
+SET meta = jsonb_set(
+        COALESCE(meta, '{}'::JSONB) || jsonb_build_object('translations', COALESCE(meta->'translations', '{}'::JSONB)),
+        ARRAY['translations', $2::TEXT],
+        to_jsonb($3::TEXT)
+    ) || CASE
+        WHEN $4::TEXT <> '' AND meta->>'source_lang' IS NULL THEN jsonb_build_object('source_lang', $4::TEXT)
+        ELSE '{}'::JSONB
+    END
+WHERE uuid = $1;
+
 -- name: get-conversation-uuid-from-message-uuid
 SELECT c.uuid AS conversation_uuid
 FROM conversation_messages m
@@ -388,10 +879,16 @@ JOIN conversations c ON m.conversation_id = c.id
 WHERE m.uuid = $1;
 
 -- name: unassign-open-conversations
-UPDATE conversations
-SET assigned_user_id = NULL,
-    updated_at = now()
-WHERE assigned_user_id = $1 AND status_id in (SELECT id FROM conversation_statuses WHERE name NOT IN ('Resolved', 'Closed'));
+-- Unassigns the agent from their open conversations and records the unassignments.
+WITH unassigned AS (
+    UPDATE conversations
+    SET assigned_user_id = NULL,
+        updated_at = now()
+    WHERE assigned_user_id = $1 AND status_id in (SELECT id FROM conversation_statuses WHERE name NOT IN ('Resolved', 'Closed'))
+    RETURNING id, assigned_team_id
+)
+INSERT INTO conversation_assignments (conversation_id, assigned_user_id, assigned_team_id)
+SELECT id, NULL, assigned_team_id FROM unassigned;
 
 -- name: update-conversation-custom-attributes
 UPDATE conversations
@@ -399,6 +896,15 @@ SET custom_attributes = $2,
     updated_at = now()
 WHERE uuid = $1;
 
+-- name: set-conversation-custom-attribute
+UPDATE conversations
+SET custom_attributes = CASE WHEN $3::jsonb = 'null'::jsonb THEN custom_attributes - $2::text
+        ELSE custom_attributes || jsonb_build_object($2::text, $3::jsonb)
+    END,
+    updated_at = now()
+WHERE uuid = $1
+RETURNING custom_attributes;
+
 -- MESSAGE queries.
 -- name: get-message-source-ids
 SELECT 
@@ -411,6 +917,9 @@ ORDER BY id DESC
 LIMIT $2;
 
 -- name: get-pending-messages
+-- Oldest first, or by priority when $2 is set: High conversations before Medium and unset ones before Low, raised
+-- by one level for every $3 seconds the message has been waiting, 0 disables the raise. Messages of the inboxes in $4,
+-- throttled by their send limits, are left pending.
 SELECT
     m.created_at,
     m.id,
@@ -422,15 +931,27 @@ SELECT
     m.conversation_id,
     m.content_type,
     m.source_id,
+    m.meta,
     ARRAY(SELECT jsonb_array_elements_text(m.meta->'cc')) AS cc,
     ARRAY(SELECT jsonb_array_elements_text(m.meta->'bcc')) AS bcc,
+    COALESCE((m.meta->>'reply_all')::BOOLEAN, false) AS reply_all,
     c.inbox_id,
     c.uuid as conversation_uuid,
     c.subject
 FROM conversation_messages m
 INNER JOIN conversations c ON c.id = m.conversation_id
+LEFT JOIN conversation_priorities p ON p.id = c.priority_id
+INNER JOIN inboxes i ON i.id = c.inbox_id AND NOT i.dispatch_paused
 WHERE m.status = 'pending'
 AND NOT(m.id = ANY($1::INT[]))
+AND NOT(c.inbox_id = ANY($4::INT[]))
+ORDER BY
+    CASE WHEN $2::BOOLEAN THEN
+        CASE p.name WHEN 'High' THEN 2 WHEN 'Low' THEN 0 ELSE 1 END
+        + CASE WHEN $3::INT > 0 THEN FLOOR(EXTRACT(EPOCH FROM NOW() - m.created_at) / $3::INT)::INT ELSE 0 END
+    ELSE 0 END DESC,
+    m.created_at,
+    m.id;
 
 -- name: get-message
 SELECT
@@ -444,6 +965,8 @@ SELECT
     m.sender_type,
     m.sender_id,
     m.meta,
+    m.language,
+    m.language_confidence,
     COALESCE(
         json_agg(
             json_build_object(
@@ -452,7 +975,8 @@ SELECT
                 'uuid', media.uuid,
                 'size', media.size,
                 'content_id', media.content_id,
-                'disposition', media.disposition
+                'disposition', media.disposition,
+                'blob_name', COALESCE(media.blob_name, media.uuid::TEXT)
             ) ORDER BY media.filename
         ) FILTER (WHERE media.id IS NOT NULL),
         '[]'::json
@@ -464,6 +988,39 @@ GROUP BY
     m.id, m.created_at, m.updated_at, m.status, m.type, m.content, m.uuid, m.private, m.sender_type
 ORDER BY m.created_at;
 
+-- name: get-unanswered-since
+-- Returns the time of the oldest incoming message that has no public reply after it, replies count once they're
+-- sent, pending and failed replies never reached the contact.
+SELECT m.created_at
+FROM conversation_messages m
+WHERE m.conversation_id = $1 AND m.type = 'incoming'
+AND NOT EXISTS (
+    SELECT 1 FROM conversation_messages r
+    WHERE r.conversation_id = $1 AND r.type = 'outgoing' AND r.private = false AND r.status IN ('sent', 'delivered')
+    AND r.created_at > m.created_at
+)
+ORDER BY m.created_at ASC
+LIMIT 1;
+
+-- name: get-latest-incoming-message
+SELECT
+    m.created_at,
+    m.updated_at,
+    m.uuid,
+    m.type,
+    m.status,
+    m.content,
+    m.text_content,
+    m.content_type,
+    m.sender_id,
+    m.sender_type,
+    m.meta
+FROM conversation_messages m
+WHERE m.conversation_id = $1
+AND m.type = 'incoming'
+ORDER BY m.created_at DESC
+LIMIT 1;
+
 -- name: get-messages
 SELECT
    COUNT(*) OVER() AS total,
@@ -477,6 +1034,8 @@ SELECT
    m.sender_id,
    m.sender_type,
    m.meta,
+   m.language,
+   m.language_confidence,
    COALESCE(
      (SELECT json_agg(
        json_build_object(
@@ -485,7 +1044,8 @@ SELECT
          'uuid', uuid,
          'size', size,
          'content_id', content_id,
-         'disposition', disposition
+         'disposition', disposition,
+         'blob_name', COALESCE(blob_name, uuid::TEXT)
        ) ORDER BY filename
      ) FROM media 
      WHERE model_type = 'messages' AND model_id = m.id),
@@ -493,7 +1053,7 @@ SELECT
 FROM conversation_messages m
 WHERE m.conversation_id = (
    SELECT id FROM conversations WHERE uuid = $1 LIMIT 1
-)
+) %s
 ORDER BY m.created_at DESC %s
 
 -- name: insert-message
@@ -509,20 +1069,33 @@ inserted_msg AS (
    INSERT INTO conversation_messages (
        "type", status, conversation_id, "content", 
        text_content, sender_id, sender_type, private,
-       content_type, source_id, meta
+       content_type, source_id, meta, search_vector,
+       "language", language_confidence
    )
    VALUES (
        $1, $2, (SELECT id FROM conversation_id),
-       $5, $6, $7, $8, $9, $10, $11, $12
+       $5, $6, $7, $8, $9, $10, $11, $12,
+       to_tsvector('simple', NULLIF($13, '')),
+       $14, $15
    )
    RETURNING id, uuid, created_at, conversation_id
 ),
+-- Outgoing messages start their status history with the status they're inserted with.
+initial_status AS (
+   INSERT INTO message_status_history (message_id, status)
+   SELECT id, $2 FROM inserted_msg WHERE $1 = 'outgoing'
+),
 updated_conversation AS (
    UPDATE conversations 
    SET waiting_since = CASE
        WHEN $8 = 'contact' THEN NOW()
        WHEN $8 = 'agent' THEN NULL
        ELSE waiting_since
+   END,
+   -- The conversation language follows the latest confidently detected contact message.
+   "language" = CASE
+       WHEN $8 = 'contact' AND $16::BOOLEAN THEN $14
+       ELSE "language"
    END
    WHERE id = (SELECT id FROM conversation_id)
 )
@@ -533,6 +1106,12 @@ SELECT conversation_id
 FROM conversation_messages
 WHERE source_id = ANY($1::text []);
 
+-- name: get-conversation-id-by-reference-number
+-- Restricted to the contact and inbox so a subject can't be used to post into another contact's conversation.
+SELECT id
+FROM conversations
+WHERE reference_number = $1 AND contact_id = $2 AND inbox_id = $3;
+
 -- name: get-conversation-by-message-id
 SELECT
     c.id,
@@ -544,7 +1123,59 @@ JOIN conversations c ON m.conversation_id = c.id
 WHERE m.id = $1;
 
 -- name: update-message-status
-update conversation_messages set status = $1, updated_at = now() where uuid = $2;
+WITH updated AS (
+    UPDATE conversation_messages SET status = $1, send_started_at = NULL, updated_at = NOW() WHERE uuid = $2
+    RETURNING id, status
+)
+INSERT INTO message_status_history (message_id, status, error)
+SELECT id, status, NULLIF($3, '') FROM updated;
+
+-- name: get-outgoing-message-by-source-id
+SELECT id, uuid, status FROM conversation_messages WHERE source_id = $1 AND type = 'outgoing' LIMIT 1;
+
+-- name: insert-delivery-report
+INSERT INTO delivery_reports (source_id, message_id) VALUES ($1, $2) ON CONFLICT (source_id) DO NOTHING;
+
+-- name: delivery-report-exists
+SELECT EXISTS (SELECT 1 FROM delivery_reports WHERE source_id = $1);
+
+-- name: mark-message-sending
+UPDATE conversation_messages SET send_started_at = NOW() WHERE id = $1;
+
+-- name: release-interrupted-messages
+-- Unmarks the pending messages marked as being sent more than $1 seconds ago, except the ones being sent ($2), and
+-- records why they are requeued in their status history.
+WITH released AS (
+    UPDATE conversation_messages
+    SET send_started_at = NULL
+    WHERE status = 'pending'
+    AND send_started_at IS NOT NULL
+    AND send_started_at <= NOW() - make_interval(secs => $1)
+    AND NOT (id = ANY($2::INT[]))
+    RETURNING id, status
+)
+INSERT INTO message_status_history (message_id, status, error)
+SELECT id, status, $3 FROM released
+RETURNING message_id;
+
+-- name: get-message-status-history
+SELECT h.created_at, h.status, h.error
+FROM message_status_history h
+INNER JOIN conversation_messages m ON m.id = h.message_id
+WHERE m.uuid = $1
+ORDER BY h.created_at ASC, h.id ASC;
+
+-- name: get-conversation-failed-messages
+-- Failed outgoing messages of a conversation created in the last $2 seconds, 0 is no limit, oldest first.
+SELECT m.uuid
+FROM conversation_messages m
+INNER JOIN conversations c ON c.id = m.conversation_id
+WHERE c.uuid = $1
+    AND m.type = 'outgoing'
+    AND m.private = false
+    AND m.status = 'failed'
+    AND ($2::FLOAT = 0 OR m.created_at > NOW() - make_interval(secs => $2::FLOAT))
+ORDER BY m.created_at ASC, m.id ASC;
 
 -- name: remove-conversation-assignee
 UPDATE conversations
@@ -556,6 +1187,13 @@ WHERE uuid = $1;
 
 -- name: re-open-conversation
 -- Open conversation if it is not already open and unset the assigned user if they are away and reassigning.
+-- Returns the status the conversation was reopened from.
+WITH prev AS (
+  SELECT c.id, s.name AS status
+  FROM conversations c
+  JOIN conversation_statuses s ON s.id = c.status_id
+  WHERE c.uuid = $1 AND s.name NOT IN ('Open')
+)
 UPDATE conversations
 SET 
   status_id = (SELECT id FROM conversation_statuses WHERE name = 'Open'),
@@ -569,11 +1207,204 @@ SET
     ) THEN NULL
     ELSE assigned_user_id
   END
-WHERE 
-  uuid = $1
-  AND status_id IN (
-    SELECT id FROM conversation_statuses WHERE name NOT IN ('Open')
-  )
+FROM prev
+WHERE conversations.id = prev.id
+RETURNING prev.status;
 
 -- name: delete-conversation
-DELETE FROM conversations WHERE uuid = $1;
\ No newline at end of file
+DELETE FROM conversations WHERE uuid = $1;
+
+-- name: delete-conversation-csat-responses
+-- CSAT responses can't outlive their conversation, the foreign key can't be set to NULL.
+DELETE FROM csat_responses WHERE conversation_id = (SELECT id FROM conversations WHERE uuid = $1);
+
+-- name: delete-conversation-applied-slas
+-- Applied SLAs can't outlive their conversation, the foreign key can't be set to NULL.
+DELETE FROM applied_slas WHERE conversation_id = (SELECT id FROM conversations WHERE uuid = $1);
+
+-- name: soft-delete-conversation
+UPDATE conversations
+SET deleted_at = NOW(),
+    deleted_by = $2,
+    updated_at = NOW()
+WHERE uuid = $1 AND deleted_at IS NULL;
+
+-- name: restore-conversation
+UPDATE conversations
+SET deleted_at = NULL,
+    deleted_by = NULL,
+    updated_at = NOW()
+WHERE uuid = $1 AND deleted_at IS NOT NULL;
+
+-- name: get-conversations-to-purge
+SELECT uuid FROM conversations
+WHERE deleted_at < NOW() - $1::INTERVAL
+ORDER BY deleted_at
+LIMIT $2;
+
+-- name: get-conversation-media-uuids
+SELECT media.uuid
+FROM media
+JOIN conversation_messages m ON m.id = media.model_id
+WHERE media.model_type = 'messages'
+AND m.conversation_id = (SELECT id FROM conversations WHERE uuid = $1);
+
+-- name: get-contact-export-conversations
+SELECT c.id, c.created_at, c.uuid, c.reference_number, c.subject, inb.name AS inbox_name, s.name AS status, c.custom_attributes
+FROM conversations c
+JOIN inboxes inb ON inb.id = c.inbox_id
+LEFT JOIN conversation_statuses s ON s.id = c.status_id
+WHERE c.contact_id = $1
+ORDER BY c.created_at;
+
+-- name: get-contact-export-messages
+SELECT m.id, m.created_at, m.uuid, m.conversation_id, m.type, m.sender_type, m.content_type, m.content, m.text_content
+FROM conversation_messages m
+JOIN conversations c ON c.id = m.conversation_id
+WHERE c.contact_id = $1
+AND m.type IN ('incoming', 'outgoing')
+AND m.private = false
+ORDER BY m.created_at;
+
+-- name: get-contact-export-media
+SELECT media.id, media.created_at, media.uuid, media.model_id AS message_id, media.filename, media.content_type, media.size, COALESCE(media.blob_name, media.uuid::TEXT) AS blob_name
+FROM media
+JOIN conversation_messages m ON m.id = media.model_id
+JOIN conversations c ON c.id = m.conversation_id
+WHERE media.model_type = 'messages'
+AND c.contact_id = $1
+AND m.type IN ('incoming', 'outgoing')
+AND m.private = false
+ORDER BY media.id;
+
+-- name: get-contact-media-uuids
+SELECT media.uuid
+FROM media
+JOIN conversation_messages m ON m.id = media.model_id
+JOIN conversations c ON c.id = m.conversation_id
+WHERE media.model_type = 'messages'
+AND c.contact_id = $1;
+
+-- name: erase-contact-messages
+-- Blanks the content of the contact's messages, the rows are kept for reports.
+UPDATE conversation_messages m
+SET content = '', text_content = '', meta = '{}'::jsonb, search_vector = NULL, updated_at = NOW()
+FROM conversations c
+WHERE c.id = m.conversation_id
+AND c.contact_id = $1
+AND m.type IN ('incoming', 'outgoing');
+
+-- name: erase-contact-conversations
+WITH csat AS (
+    UPDATE csat_responses
+    SET feedback = NULL, updated_at = NOW()
+    WHERE conversation_id IN (SELECT id FROM conversations WHERE contact_id = $1)
+),
+drafts AS (
+    DELETE FROM conversation_drafts
+    WHERE conversation_id IN (SELECT id FROM conversations WHERE contact_id = $1)
+)
+UPDATE conversations
+SET subject = NULL, last_message = NULL, meta = '{}'::jsonb, custom_attributes = '{}'::jsonb, updated_at = NOW()
+WHERE contact_id = $1;
+
+-- name: erase-contact
+WITH notes AS (
+    DELETE FROM contact_notes WHERE contact_id = $1
+),
+channels AS (
+    UPDATE contact_channels SET identifier = '', updated_at = NOW() WHERE contact_id = $1
+)
+UPDATE users
+SET first_name = $2, last_name = NULL, email = NULL, phone_number = NULL, phone_number_calling_code = NULL,
+    country = NULL, avatar_url = NULL, custom_attributes = '{}'::jsonb, updated_at = NOW()
+WHERE id = $1 AND type = 'contact';
+
+-- name: upsert-draft
+INSERT INTO conversation_drafts (user_id, conversation_id, content, private)
+VALUES ($1, (SELECT id FROM conversations WHERE uuid = $2), $3, $4)
+ON CONFLICT (conversation_id, user_id) DO UPDATE
+SET content = EXCLUDED.content,
+    private = EXCLUDED.private,
+    updated_at = NOW()
+RETURNING id, created_at, updated_at, user_id, $2::uuid AS conversation_uuid, content, private;
+
+-- name: get-draft
+SELECT d.id, d.created_at, d.updated_at, d.user_id, c.uuid AS conversation_uuid, d.content, d.private
+FROM conversation_drafts d
+JOIN conversations c ON c.id = d.conversation_id
+WHERE d.user_id = $1 AND c.uuid = $2;
+
+-- name: delete-draft
+DELETE FROM conversation_drafts
+WHERE user_id = $1 AND conversation_id = (SELECT id FROM conversations WHERE uuid = $2);
+
+-- name: delete-stale-drafts
+DELETE FROM conversation_drafts
+WHERE updated_at < NOW() - $1::interval;
+
+-- name: insert-blocked-incoming-message
+INSERT INTO blocked_incoming_messages (inbox_id, sender, source_id, "subject", content, reason, payload)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT (inbox_id, source_id) DO NOTHING;
+
+-- name: blocked-incoming-message-exists
+SELECT EXISTS (SELECT 1 FROM blocked_incoming_messages WHERE source_id = $1);
+
+-- name: get-blocked-incoming-messages
+SELECT
+    COUNT(*) OVER() AS total,
+    b.id,
+    b.created_at,
+    b.inbox_id,
+    i.name AS inbox_name,
+    b.sender,
+    b.source_id,
+    b."subject",
+    b.content,
+    b.reason
+FROM blocked_incoming_messages b
+JOIN inboxes i ON i.id = b.inbox_id
+ORDER BY b.created_at DESC
+LIMIT $1 OFFSET $2;
+
+-- name: delete-blocked-incoming-message
+DELETE FROM blocked_incoming_messages WHERE id = $1;
+
+-- name: get-blocked-incoming-message-payload
+SELECT payload FROM blocked_incoming_messages WHERE id = $1;
+
+-- name: insert-incoming-spillover
+INSERT INTO incoming_message_spillover (inbox_id, payload) VALUES ($1, $2);
+
+-- name: get-incoming-spillover
+SELECT id, payload FROM incoming_message_spillover ORDER BY id LIMIT $1;
+
+-- name: delete-incoming-spillover
+DELETE FROM incoming_message_spillover WHERE id = ANY($1::BIGINT[]);
+
+-- name: attach-unlinked-media
+-- Only media not attached to anything yet, not uploaded for another model and uploaded by $4 can be attached, so
+-- another message's attachments or another agent's uploads can't be taken. $4 is 0 for media not uploaded by an agent.
+UPDATE media
+SET model_type = $2,
+    model_id = $3
+WHERE id = $1 AND model_id IS NULL
+AND (model_type IS NULL OR model_type = $2)
+AND COALESCE(uploaded_by, 0) = $4;
+
+-- name: lock-message
+SELECT m.id, m.type, m.status, m.send_started_at IS NOT NULL AS send_started, c.uuid AS conversation_uuid
+FROM conversation_messages m
+JOIN conversations c ON c.id = m.conversation_id
+WHERE m.uuid = $1
+FOR UPDATE OF m;
+
+-- name: set-inbox-dispatch-paused
+UPDATE inboxes SET dispatch_paused = $2, updated_at = NOW() WHERE id = $1 AND dispatch_paused <> $2;
+
+-- name: is-inbox-dispatch-paused
+SELECT dispatch_paused FROM inboxes WHERE id = $1;
+
+-- name: get-dispatch-paused-inbox-ids
+SELECT id FROM inboxes WHERE dispatch_paused AND deleted_at IS NULL ORDER BY id;
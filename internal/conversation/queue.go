@@ -0,0 +1,111 @@
+package conversation
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/abhinavxd/libredesk/internal/conversation/models"
+	"github.com/abhinavxd/libredesk/internal/dbutil"
+	"github.com/abhinavxd/libredesk/internal/envelope"
+	umodels "github.com/abhinavxd/libredesk/internal/user/models"
+	"github.com/volatiletech/null/v9"
+)
+
+// RouteToQueue moves the conversation to the queue, unassigning its agent so that the conversation waits for an
+// agent of the queue to pull it. A team queue also assigns the conversation to the team.
+func (c *Manager) RouteToQueue(conversationUUID string, queueID int, actor umodels.User) error {
+	var routed struct {
+		QueueName      string   `db:"name"`
+		AssignedTeamID null.Int `db:"assigned_team_id"`
+	}
+	if err := c.q.RouteConversationToQueue.Get(&routed, conversationUUID, queueID); err != nil {
+		if err == sql.ErrNoRows {
+			return envelope.NewError(envelope.NotFoundError, c.i18n.Ts("globals.messages.notFound", "name", "{globals.terms.queue}"), nil)
+		}
+		c.lo.Error("error routing conversation to queue", "conversation_uuid", conversationUUID, "queue_id", queueID, "error", err)
+		return envelope.NewError(envelope.GeneralError, c.i18n.Ts("globals.messages.errorUpdating", "name", "{globals.terms.conversation}"), nil)
+	}
+	c.recordAssignment(conversationUUID)
+
+	c.BroadcastConversationUpdate(conversationUUID, "assigned_queue_id", queueID)
+	c.BroadcastConversationUpdate(conversationUUID, "assigned_user_id", nil)
+	if routed.AssignedTeamID.Valid {
+		c.BroadcastConversationUpdate(conversationUUID, "assigned_team_id", routed.AssignedTeamID.Int)
+	}
+
+	// Routing succeeded, a failure to record the activity is logged in the call.
+	c.RecordQueueChange(conversationUUID, routed.QueueName, actor)
+	return nil
+}
+
+// PullFromQueue assigns the longest waiting open conversation of the queue to the actor and returns it.
+// Concurrent pulls never get the same conversation, and the actor's maximum open conversations is enforced as for
+// any other assignment.
+func (c *Manager) PullFromQueue(queueID int, actor umodels.User) (models.Conversation, error) {
+	conversationUUID, err := c.pullQueueConversation(queueID, actor.ID)
+	if err != nil {
+		return models.Conversation{}, err
+	}
+
+	c.BroadcastConversationUpdate(conversationUUID, "assigned_user_id", actor.ID)
+	c.RecordAssigneeUserChange(conversationUUID, actor.ID, actor)
+	return c.GetConversation(0, conversationUUID)
+}
+
+// pullQueueConversation assigns the longest waiting open conversation of the queue to the agent and records the
+// assignment, returning the UUID of the conversation.
+func (c *Manager) pullQueueConversation(queueID, userID int) (string, error) {
+	tx, err := c.db.BeginTxx(context.Background(), nil)
+	if err != nil {
+		c.lo.Error("error starting db txn", "error", err)
+		return "", envelope.NewError(envelope.GeneralError, c.i18n.Ts("globals.messages.errorUpdating", "name", "{globals.terms.conversation}"), nil)
+	}
+	defer tx.Rollback()
+
+	if err := c.lockAgentForAssignment(tx, userID); err != nil {
+		return "", err
+	}
+	var conversationUUID string
+	if err := tx.Stmtx(c.q.PullQueueConversation).Get(&conversationUUID, queueID, userID); err != nil {
+		if err == sql.ErrNoRows {
+			if err := c.checkOpenConversationsCap("", userID); err != nil {
+				return "", err
+			}
+			return "", envelope.NewError(envelope.NotFoundError, c.i18n.T("conversation.queueEmpty"), nil)
+		}
+		c.lo.Error("error pulling conversation from queue", "queue_id", queueID, "user_id", userID, "error", err)
+		return "", envelope.NewError(envelope.GeneralError, c.i18n.Ts("globals.messages.errorUpdating", "name", "{globals.terms.conversation}"), nil)
+	}
+	if _, err := tx.Stmtx(c.q.InsertConversationAssignment).Exec(conversationUUID, c.resetFirstReplyOnReassign); err != nil {
+		c.lo.Error("error recording conversation assignment", "uuid", conversationUUID, "error", err)
+		return "", envelope.NewError(envelope.GeneralError, c.i18n.Ts("globals.messages.errorUpdating", "name", "{globals.terms.conversation}"), nil)
+	}
+	if err := tx.Commit(); err != nil {
+		c.lo.Error("error committing queue pull", "queue_id", queueID, "user_id", userID, "error", err)
+		return "", envelope.NewError(envelope.GeneralError, c.i18n.Ts("globals.messages.errorUpdating", "name", "{globals.terms.conversation}"), nil)
+	}
+	return conversationUUID, nil
+}
+
+// GetQueueConversationsList retrieves the conversations waiting in the queue for an agent, with optional filtering,
+// ordering, and pagination.
+func (c *Manager) GetQueueConversationsList(queueID int, order, orderBy, filters string, page, pageSize int) ([]models.Conversation, error) {
+	var queueFilters []dbutil.Filter
+	if filters != "" {
+		if err := json.Unmarshal([]byte(filters), &queueFilters); err != nil {
+			return nil, envelope.NewError(envelope.InputError, c.i18n.Ts("globals.messages.invalid", "name", "`filters`"), nil)
+		}
+	}
+	queueFilters = append(queueFilters,
+		dbutil.Filter{Model: "conversations", Field: "assigned_queue_id", Operator: "equals", Value: fmt.Sprintf("%d", queueID)},
+		dbutil.Filter{Model: "conversations", Field: "assigned_user_id", Operator: "not set"},
+	)
+	b, err := json.Marshal(queueFilters)
+	if err != nil {
+		c.lo.Error("error marshalling queue filters", "error", err)
+		return nil, envelope.NewError(envelope.GeneralError, c.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.conversation}"), nil)
+	}
+	return c.GetConversations(0, []int{}, []string{models.AllConversations}, order, orderBy, string(b), page, pageSize)
+}
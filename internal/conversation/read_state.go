@@ -0,0 +1,54 @@
+package conversation
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/abhinavxd/libredesk/internal/envelope"
+	"github.com/abhinavxd/libredesk/internal/stringutil"
+	wsmodels "github.com/abhinavxd/libredesk/internal/ws/models"
+	"github.com/lib/pq"
+)
+
+// maxReadStateBatchSize is the maximum number of conversations whose read state can be set at once.
+const maxReadStateBatchSize = 100
+
+// ConversationReadState is the unread message count of a conversation for a user after its read state is set.
+type ConversationReadState struct {
+	UUID               string `db:"uuid" json:"uuid"`
+	UnreadMessageCount int    `db:"unread_message_count" json:"unread_message_count"`
+}
+
+// SetConversationReadState marks the conversations read or unread for the user in a single update by moving
+// the user's read watermark of each conversation. Marking unread leaves the latest message unread.
+// The resulting unread message counts are sent to the user's clients in a single message.
+func (m *Manager) SetConversationReadState(uuids []string, userID int, read bool) ([]ConversationReadState, error) {
+	uuids = stringutil.RemoveEmpty(uuids)
+	slices.Sort(uuids)
+	uuids = slices.Compact(uuids)
+
+	var states = make([]ConversationReadState, 0, len(uuids))
+	if len(uuids) == 0 {
+		return states, nil
+	}
+	if len(uuids) > maxReadStateBatchSize {
+		return nil, envelope.NewError(envelope.InputError, m.i18n.Ts("conversation.tooManyConversations", "max", fmt.Sprintf("%d", maxReadStateBatchSize)), nil)
+	}
+
+	if err := m.q.SetConversationsReadState.Select(&states, pq.Array(uuids), userID, read); err != nil {
+		m.lo.Error("error setting conversations read state", "user_id", userID, "read", read, "error", err)
+		return nil, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorUpdating", "name", "{globals.terms.conversation}"), nil)
+	}
+
+	// The watermarks are per user, only the user's own clients are sent the updated counts.
+	if len(states) > 0 {
+		m.broadcastToUsers([]int{userID}, wsmodels.Message{
+			Type: wsmodels.MessageTypeConversationsReadState,
+			Data: map[string]any{
+				"read":          read,
+				"conversations": states,
+			},
+		})
+	}
+	return states, nil
+}
@@ -0,0 +1,94 @@
+package conversation
+
+import (
+	"database/sql"
+	"net/mail"
+	"slices"
+	"strings"
+
+	"github.com/abhinavxd/libredesk/internal/conversation/models"
+	"github.com/abhinavxd/libredesk/internal/inbox"
+)
+
+// GetRecipients reconstructs the recipients of a reply from the conversation thread. Replies go to the contact and,
+// with `replyAll`, also to the To and Cc recipients of the latest incoming message. Addresses in `exclude`, e.g. the
+// inbox's own address, are left out.
+func (m *Manager) GetRecipients(conversationID int, replyAll bool, exclude ...string) (models.Recipients, error) {
+	to, err := m.GetToAddress(conversationID)
+	if err != nil {
+		return models.Recipients{}, err
+	}
+	if !replyAll {
+		return buildReplyRecipients(to, models.Recipients{}, exclude), nil
+	}
+
+	thread, err := m.getThreadRecipients(conversationID)
+	if err != nil {
+		return models.Recipients{}, err
+	}
+	return buildReplyRecipients(to, thread, exclude), nil
+}
+
+// getThreadRecipients returns the recipients of the latest incoming message of the conversation.
+func (m *Manager) getThreadRecipients(conversationID int) (models.Recipients, error) {
+	var thread models.Recipients
+	if err := m.q.GetLatestIncomingRecipients.Get(&thread, conversationID); err != nil && err != sql.ErrNoRows {
+		m.lo.Error("error fetching thread recipients", "error", err, "conversation_id", conversationID)
+		return models.Recipients{}, err
+	}
+	return thread, nil
+}
+
+// replyFromAddress returns the address to reply from in the conversation, the inbox's alias the contact wrote to in
+// the latest incoming message or else the inbox's from address.
+func (m *Manager) replyFromAddress(inb inbox.Inbox, conversationID int) string {
+	thread, err := m.getThreadRecipients(conversationID)
+	if err != nil {
+		return inb.FromAddress()
+	}
+	candidates := append([]string{thread.OriginalTo}, thread.To...)
+	return inb.ReplyFromAddress(append(candidates, thread.CC...))
+}
+
+// buildReplyRecipients returns the reply recipients for the contact addresses and the recipients of the thread,
+// addresses in `exclude` are dropped and the Cc recipients never repeat a To recipient.
+func buildReplyRecipients(contact []string, thread models.Recipients, exclude []string) models.Recipients {
+	to := dedupeAddresses(exclude, contact, thread.To)
+	return models.Recipients{
+		To: to,
+		CC: dedupeAddresses(append(slices.Clone(exclude), to...), thread.CC),
+	}
+}
+
+// dedupeAddresses merges the address lists in order, dropping empty and duplicate addresses and the ones in `exclude`.
+// Addresses are compared case-insensitively by their bare email address.
+func dedupeAddresses(exclude []string, lists ...[]string) []string {
+	seen := make(map[string]struct{}, len(exclude))
+	for _, addr := range exclude {
+		seen[normalizeAddress(addr)] = struct{}{}
+	}
+	var out = make([]string, 0)
+	for _, list := range lists {
+		for _, addr := range list {
+			key := normalizeAddress(addr)
+			if key == "" {
+				continue
+			}
+			if _, ok := seen[key]; ok {
+				continue
+			}
+			seen[key] = struct{}{}
+			out = append(out, strings.TrimSpace(addr))
+		}
+	}
+	return out
+}
+
+// normalizeAddress returns the lower cased bare email address of `addr`, which may include a display name.
+func normalizeAddress(addr string) string {
+	addr = strings.TrimSpace(addr)
+	if a, err := mail.ParseAddress(addr); err == nil {
+		addr = a.Address
+	}
+	return strings.ToLower(addr)
+}
@@ -0,0 +1,54 @@
+package conversation
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/abhinavxd/libredesk/internal/conversation/models"
+)
+
+func TestBuildReplyRecipients(t *testing.T) {
+	tests := []struct {
+		name    string
+		contact []string
+		thread  models.Recipients
+		exclude []string
+		wantTo  []string
+		wantCC  []string
+	}{
+		{
+			name:    "reply to sender",
+			contact: []string{"alice@example.com"},
+			exclude: []string{"Support <support@example.com>"},
+			wantTo:  []string{"alice@example.com"},
+			wantCC:  []string{},
+		},
+		{
+			name:    "reply all drops the inbox address",
+			contact: []string{"alice@example.com"},
+			thread:  models.Recipients{To: []string{"support@example.com", "bob@example.com"}, CC: []string{"carol@example.com"}},
+			exclude: []string{"Support <support@example.com>"},
+			wantTo:  []string{"alice@example.com", "bob@example.com"},
+			wantCC:  []string{"carol@example.com"},
+		},
+		{
+			name:    "cc never repeats a to recipient",
+			contact: []string{"alice@example.com"},
+			thread:  models.Recipients{CC: []string{"ALICE@example.com", "", "carol@example.com", "carol@example.com"}},
+			wantTo:  []string{"alice@example.com"},
+			wantCC:  []string{"carol@example.com"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := buildReplyRecipients(tt.contact, tt.thread, tt.exclude)
+			if !slices.Equal(got.To, tt.wantTo) {
+				t.Errorf("got to %v, want %v", got.To, tt.wantTo)
+			}
+			if !slices.Equal(got.CC, tt.wantCC) {
+				t.Errorf("got cc %v, want %v", got.CC, tt.wantCC)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package conversation
+
+import (
+	"context"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const (
+	// outgoingReconcileInterval is how often stuck outgoing sends are swept.
+	outgoingReconcileInterval = time.Minute
+
+	// Reasons recorded in the status history of requeued messages.
+	reasonSendInterrupted = "requeued, the send was interrupted by a restart and may have been delivered"
+	reasonSendStuck       = "requeued, the send did not complete within the processing timeout"
+)
+
+// runOutgoingReconciler periodically releases the outgoing messages stuck being sent for longer than the processing
+// timeout, so they are picked up again by the pending messages scan.
+func (m *Manager) runOutgoingReconciler(ctx context.Context) {
+	if m.processingTimeout <= 0 {
+		return
+	}
+	ticker := time.NewTicker(outgoingReconcileInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.releaseStaleClaims(time.Now().Add(-m.processingTimeout))
+			m.reconcileOutgoingMessages(m.processingTimeout, reasonSendStuck)
+		}
+	}
+}
+
+// releaseStaleClaims removes the outgoing messages claimed before `before` from the processing map.
+func (m *Manager) releaseStaleClaims(before time.Time) {
+	m.outgoingProcessingMessages.Range(func(key, value any) bool {
+		if claimedAt, ok := value.(time.Time); ok && claimedAt.Before(before) {
+			if m.outgoingProcessingMessages.CompareAndDelete(key, value) {
+				m.lo.Warn("releasing outgoing message stuck in processing", "message_id", key, "claimed_at", claimedAt)
+			}
+		}
+		return true
+	})
+}
+
+// reconcileOutgoingMessages unmarks the pending messages marked as being sent for longer than `olderThan` that aren't
+// being processed, recording `reason` in their status history. They are then requeued by the pending messages scan.
+func (m *Manager) reconcileOutgoingMessages(olderThan time.Duration, reason string) {
+	var ids []int
+	if err := m.q.ReleaseInterruptedMessages.Select(&ids, olderThan.Seconds(), pq.Array(m.getOutgoingProcessingMessageIDs()), reason); err != nil {
+		m.lo.Error("error reconciling outgoing messages", "error", err)
+		return
+	}
+	if len(ids) > 0 {
+		m.lo.Warn("requeued interrupted outgoing messages", "count", len(ids), "message_ids", ids, "reason", reason)
+	}
+}
@@ -0,0 +1,26 @@
+package conversation
+
+import (
+	"io"
+	"testing"
+	"time"
+
+	"github.com/zerodha/logf"
+)
+
+func TestReleaseStaleClaims(t *testing.T) {
+	lo := logf.New(logf.Opts{Writer: io.Discard})
+	m := &Manager{lo: &lo}
+	now := time.Now()
+	m.outgoingProcessingMessages.Store(1, now.Add(-time.Hour))
+	m.outgoingProcessingMessages.Store(2, now)
+
+	m.releaseStaleClaims(now.Add(-10 * time.Minute))
+
+	if _, ok := m.outgoingProcessingMessages.Load(1); ok {
+		t.Error("stale claim was not released")
+	}
+	if _, ok := m.outgoingProcessingMessages.Load(2); !ok {
+		t.Error("recent claim was released")
+	}
+}
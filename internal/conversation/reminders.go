@@ -0,0 +1,134 @@
+package conversation
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"github.com/abhinavxd/libredesk/internal/conversation/models"
+	"github.com/abhinavxd/libredesk/internal/envelope"
+	notifier "github.com/abhinavxd/libredesk/internal/notification"
+	npmodels "github.com/abhinavxd/libredesk/internal/notification/preference/models"
+	wsmodels "github.com/abhinavxd/libredesk/internal/ws/models"
+)
+
+const (
+	// reminderBatchSize is the maximum number of due reminders notified per scan.
+	reminderBatchSize = 100
+
+	// maxReminderNoteLen is the maximum length of a reminder note in characters.
+	maxReminderNoteLen = 500
+)
+
+// SetReminder sets a personal reminder for the user to follow up on the conversation at the given time.
+// Reminders are private, only the user is notified when one is due.
+func (m *Manager) SetReminder(conversationUUID string, userID int, at time.Time, note string) (models.ConversationReminder, error) {
+	var reminder models.ConversationReminder
+	if !at.After(time.Now()) {
+		return reminder, envelope.NewError(envelope.InputError, m.i18n.T("conversation.reminderInPast"), nil)
+	}
+	if utf8.RuneCountInString(note) > maxReminderNoteLen {
+		return reminder, envelope.NewError(envelope.InputError, m.i18n.Ts("conversation.reminderNoteTooLong", "max", strconv.Itoa(maxReminderNoteLen)), nil)
+	}
+	if err := m.q.InsertConversationReminder.Get(&reminder, conversationUUID, userID, at, note); err != nil {
+		m.lo.Error("error inserting conversation reminder", "user_id", userID, "conversation_uuid", conversationUUID, "error", err)
+		return reminder, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorCreating", "name", "{globals.terms.reminder}"), nil)
+	}
+	return reminder, nil
+}
+
+// GetUserReminders returns the reminders of the user that are not yet due.
+func (m *Manager) GetUserReminders(userID int) ([]models.ConversationReminder, error) {
+	var reminders = make([]models.ConversationReminder, 0)
+	if err := m.q.GetUserReminders.Select(&reminders, userID); err != nil {
+		m.lo.Error("error fetching user reminders", "user_id", userID, "error", err)
+		return reminders, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.reminder}"), nil)
+	}
+	return reminders, nil
+}
+
+// CancelReminder deletes a reminder of the user, reminders of other users are not found.
+func (m *Manager) CancelReminder(id, userID int) error {
+	res, err := m.q.DeleteConversationReminder.Exec(id, userID)
+	if err != nil {
+		m.lo.Error("error deleting conversation reminder", "id", id, "user_id", userID, "error", err)
+		return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorDeleting", "name", "{globals.terms.reminder}"), nil)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return envelope.NewError(envelope.NotFoundError, m.i18n.Ts("globals.messages.notFound", "name", "{globals.terms.reminder}"), nil)
+	}
+	return nil
+}
+
+// SendDueReminders notifies users of their due reminders, in the app over the websocket and through the notification
+// providers they enabled for reminders. It's run on the automation time trigger. A reminder is marked notified only
+// once its notification is queued, a failed one is sent again on the next run.
+func (m *Manager) SendDueReminders() error {
+	tx, err := m.db.BeginTxx(context.Background(), nil)
+	if err != nil {
+		return fmt.Errorf("starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Due reminders are locked until the transaction ends, reminders locked by another instance are skipped.
+	var reminders = make([]models.ConversationReminder, 0)
+	if err := tx.Stmtx(m.q.GetDueReminders).Select(&reminders, reminderBatchSize); err != nil {
+		return fmt.Errorf("fetching due reminders: %w", err)
+	}
+	if len(reminders) == 0 {
+		return nil
+	}
+
+	rootURL, err := m.settingsStore.GetAppRootURL()
+	if err != nil {
+		return fmt.Errorf("fetching app root URL: %w", err)
+	}
+
+	var notified = make([]models.ConversationReminder, 0, len(reminders))
+	for _, r := range reminders {
+		if r.Email.Valid && r.Email.String != "" {
+			if err := m.notifier.Send(notifier.Message{
+				UserIDs:         []int{r.UserID},
+				RecipientEmails: []string{r.Email.String},
+				Subject:         m.i18n.Ts("notification.reminderSubject", "reference", r.ReferenceNumber, "subject", r.Subject.String),
+				Content:         m.renderReminder(rootURL, r),
+				ContentType:     "html",
+				Provider:        notifier.ProviderEmail,
+				EventType:       npmodels.EventReminder,
+			}); err != nil {
+				m.lo.Error("error sending reminder notification", "id", r.ID, "user_id", r.UserID, "error", err)
+				continue
+			}
+		}
+
+		if err := tx.Stmtx(m.q.MarkReminderNotified).Get(&r.NotifiedAt, r.ID); err != nil {
+			return fmt.Errorf("marking reminder notified: %w", err)
+		}
+		notified = append(notified, r)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("committing transaction: %w", err)
+	}
+
+	for _, r := range notified {
+		m.broadcastToUsers([]int{r.UserID}, wsmodels.Message{
+			Type: wsmodels.MessageTypeConversationReminder,
+			Data: r,
+		})
+	}
+	return nil
+}
+
+// renderReminder renders the HTML body of a reminder notification.
+func (m *Manager) renderReminder(rootURL string, r models.ConversationReminder) string {
+	body := fmt.Sprintf(`<p>%s</p><p><a href="%s/inboxes/all/conversation/%s">#%s %s</a></p>`,
+		html.EscapeString(m.i18n.T("notification.reminderIntro")), rootURL, r.ConversationUUID,
+		html.EscapeString(r.ReferenceNumber), html.EscapeString(r.Subject.String))
+	if r.Note != "" {
+		body += "<blockquote>" + html.EscapeString(r.Note) + "</blockquote>"
+	}
+	return body
+}
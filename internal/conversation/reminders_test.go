@@ -0,0 +1,67 @@
+package conversation
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/abhinavxd/libredesk/internal/conversation/models"
+	"github.com/knadh/go-i18n"
+	"github.com/volatiletech/null/v9"
+	"github.com/zerodha/logf"
+)
+
+func TestSetReminderValidation(t *testing.T) {
+	i, err := i18n.New([]byte(`{"_.code": "en", "_.name": "English"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lo := logf.New(logf.Opts{Writer: io.Discard})
+	m := &Manager{lo: &lo, i18n: i}
+
+	tests := []struct {
+		name string
+		at   time.Time
+		note string
+	}{
+		{name: "past", at: time.Now().Add(-time.Minute)},
+		{name: "now", at: time.Now()},
+		{name: "long note", at: time.Now().Add(time.Hour), note: strings.Repeat("é", maxReminderNoteLen+1)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := m.SetReminder("uuid", 1, tt.at, tt.note); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+func TestRenderReminder(t *testing.T) {
+	i, err := i18n.New([]byte(`{"_.code": "en", "_.name": "English"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := &Manager{i18n: i}
+
+	got := m.renderReminder("https://desk.example.com", models.ConversationReminder{
+		ConversationUUID: "abc",
+		ReferenceNumber:  "100",
+		Subject:          null.StringFrom("Refund <urgent>"),
+		Note:             "Call <back>",
+	})
+	for _, want := range []string{
+		`href="https://desk.example.com/inboxes/all/conversation/abc"`,
+		"#100 Refund &lt;urgent&gt;",
+		"<blockquote>Call &lt;back&gt;</blockquote>",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("got %q, want it to contain %q", got, want)
+		}
+	}
+
+	if got := m.renderReminder("", models.ConversationReminder{}); strings.Contains(got, "blockquote") {
+		t.Errorf("got note quote without note: %q", got)
+	}
+}
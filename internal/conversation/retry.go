@@ -0,0 +1,49 @@
+package conversation
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/abhinavxd/libredesk/internal/conversation/models"
+	"github.com/abhinavxd/libredesk/internal/envelope"
+	umodels "github.com/abhinavxd/libredesk/internal/user/models"
+)
+
+// failedRetryCooldown is the minimum time between two retries of the failed messages of a conversation,
+// so a conversation with an inbox that's still broken doesn't get its messages requeued in a loop.
+const failedRetryCooldown = time.Minute
+
+// RetryFailedMessages requeues the failed outgoing messages of a conversation, oldest first, and returns how many
+// were requeued. Messages older than the configured retry max age are left failed so stale replies aren't sent.
+func (m *Manager) RetryFailedMessages(conversationUUID string, actor umodels.User) (int, error) {
+	now := time.Now()
+	if last, ok := m.failedRetries.Load(conversationUUID); ok && now.Sub(last.(time.Time)) < failedRetryCooldown {
+		return 0, envelope.NewCodedError(envelope.InputError, envelope.ErrCodeRetryTooSoon, m.i18n.T("conversation.retryTooSoon"), nil)
+	}
+	m.failedRetries.Store(conversationUUID, now)
+
+	var uuids []string
+	if err := m.q.GetConversationFailedMessages.Select(&uuids, conversationUUID, m.messageRetryMaxAge.Seconds()); err != nil {
+		m.lo.Error("error fetching failed messages", "conversation_uuid", conversationUUID, "error", err)
+		return 0, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.message}"), nil)
+	}
+	if len(uuids) == 0 {
+		return 0, nil
+	}
+
+	var requeued int
+	for _, uuid := range uuids {
+		if err := m.MarkMessageAsPending(uuid); err != nil {
+			m.lo.Error("error requeueing failed message", "conversation_uuid", conversationUUID, "uuid", uuid, "error", err)
+			continue
+		}
+		requeued++
+	}
+
+	if requeued > 0 {
+		if err := m.InsertConversationActivity(models.ActivityMessagesRetried, conversationUUID, strconv.Itoa(requeued), actor); err != nil {
+			m.lo.Error("error recording messages retry activity", "conversation_uuid", conversationUUID, "error", err)
+		}
+	}
+	return requeued, nil
+}
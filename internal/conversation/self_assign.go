@@ -0,0 +1,89 @@
+package conversation
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+
+	"github.com/abhinavxd/libredesk/internal/dbutil"
+	"github.com/abhinavxd/libredesk/internal/envelope"
+	umodels "github.com/abhinavxd/libredesk/internal/user/models"
+)
+
+// CurrentUserFilterValue is the filter value that resolves to the user requesting the conversations list,
+// e.g. `assigned_user_id equals @me` lists the conversations assigned to the requesting agent.
+const CurrentUserFilterValue = "@me"
+
+// currentUserFilterFields are the conversation fields whose filter values can be `CurrentUserFilterValue`.
+var currentUserFilterFields = []string{"assigned_user_id"}
+
+// AssignToSelf assigns the conversation to the acting agent, recording a self-assign activity, and returns true if it
+// was assigned. It's a no-op returning false if the conversation is already assigned to the agent.
+func (c *Manager) AssignToSelf(conversationUUID string, actor umodels.User) (bool, error) {
+	if actor.ID == 0 || actor.Type != umodels.UserTypeAgent {
+		return false, envelope.NewError(envelope.InputError, c.i18n.Ts("globals.messages.invalid", "name", "{globals.terms.agent}"), nil)
+	}
+	conversation, err := c.GetConversation(0, conversationUUID)
+	if err != nil {
+		return false, err
+	}
+	if conversation.AssignedUserID.Int == actor.ID {
+		return false, nil
+	}
+	if err := c.UpdateConversationUserAssignee(conversationUUID, actor.ID, actor); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// resolveCurrentUserFilters replaces `CurrentUserFilterValue` in the values of the conversation list filters with
+// the passed user ID, including the values of `in` filters.
+func resolveCurrentUserFilters(filtersJSON string, userID int) (string, error) {
+	var filters []dbutil.Filter
+	if err := json.Unmarshal([]byte(filtersJSON), &filters); err != nil {
+		return "", fmt.Errorf("invalid filters JSON: %w", err)
+	}
+
+	var (
+		id      = strconv.Itoa(userID)
+		changed = false
+	)
+	for i, f := range filters {
+		if f.Model != "conversations" || !slices.Contains(currentUserFilterFields, f.Field) {
+			continue
+		}
+		if f.Value == CurrentUserFilterValue {
+			filters[i].Value = id
+			changed = true
+			continue
+		}
+		if f.Operator != "in" {
+			continue
+		}
+		var values []string
+		if err := json.Unmarshal([]byte(f.Value), &values); err != nil || !slices.Contains(values, CurrentUserFilterValue) {
+			continue
+		}
+		for j, v := range values {
+			if v == CurrentUserFilterValue {
+				values[j] = id
+			}
+		}
+		b, err := json.Marshal(values)
+		if err != nil {
+			return "", err
+		}
+		filters[i].Value = string(b)
+		changed = true
+	}
+	if !changed {
+		return filtersJSON, nil
+	}
+
+	b, err := json.Marshal(filters)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
@@ -0,0 +1,50 @@
+package conversation
+
+import "testing"
+
+func TestResolveCurrentUserFilters(t *testing.T) {
+	tests := []struct {
+		name     string
+		filters  string
+		expected string
+		wantErr  bool
+	}{
+		{
+			name:     "no filters",
+			filters:  `[]`,
+			expected: `[]`,
+		},
+		{
+			name:     "equals me",
+			filters:  `[{"model":"conversations","field":"assigned_user_id","operator":"equals","value":"@me"}]`,
+			expected: `[{"model":"conversations","field":"assigned_user_id","operator":"equals","value":"7"}]`,
+		},
+		{
+			name:     "in with me",
+			filters:  `[{"model":"conversations","field":"assigned_user_id","operator":"in","value":"[\"3\",\"@me\"]"}]`,
+			expected: `[{"model":"conversations","field":"assigned_user_id","operator":"in","value":"[\"3\",\"7\"]"}]`,
+		},
+		{
+			name:     "other fields untouched",
+			filters:  `[{"model":"conversations","field":"subject","operator":"equals","value":"@me"}]`,
+			expected: `[{"model":"conversations","field":"subject","operator":"equals","value":"@me"}]`,
+		},
+		{
+			name:    "invalid json",
+			filters: `{`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveCurrentUserFilters(tt.filters, 7)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.expected {
+				t.Errorf("got %s, want %s", got, tt.expected)
+			}
+		})
+	}
+}
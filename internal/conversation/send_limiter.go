@@ -0,0 +1,84 @@
+package conversation
+
+import (
+	"sync"
+	"time"
+)
+
+// rateLimitBackoff is how long the messages of an inbox over its send rate limit are left out of the pending scans.
+const rateLimitBackoff = time.Second
+
+// inboxSendLimiter caps the number of messages being sent concurrently through a single inbox, so that
+// a slow provider occupies at most `max` sender workers and the rest keep sending for other inboxes.
+// It also tracks the inboxes backing off after hitting their send rate limit.
+type inboxSendLimiter struct {
+	mu           sync.Mutex
+	max          int
+	inFlight     map[int]int
+	backoffUntil map[int]time.Time
+}
+
+// newInboxSendLimiter returns a limiter allowing `max` concurrent sends per inbox, 0 disables the limit.
+func newInboxSendLimiter(max int) *inboxSendLimiter {
+	return &inboxSendLimiter{
+		max:          max,
+		inFlight:     make(map[int]int),
+		backoffUntil: make(map[int]time.Time),
+	}
+}
+
+// acquire reserves a send slot for the inbox and returns false if the inbox is at its limit.
+func (l *inboxSendLimiter) acquire(inboxID int) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.max > 0 && l.inFlight[inboxID] >= l.max {
+		return false
+	}
+	l.inFlight[inboxID]++
+	return true
+}
+
+// release frees a send slot reserved with acquire.
+func (l *inboxSendLimiter) release(inboxID int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.inFlight[inboxID] <= 1 {
+		delete(l.inFlight, inboxID)
+		return
+	}
+	l.inFlight[inboxID]--
+}
+
+// backOff holds back the sends of the inbox for d.
+func (l *inboxSendLimiter) backOff(inboxID int, d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.backoffUntil[inboxID] = time.Now().Add(d)
+}
+
+// throttledInboxIDs returns the inboxes at their concurrent send limit or backing off, the scans leave their pending
+// messages out instead of queueing them again only to be released.
+func (l *inboxSendLimiter) throttledInboxIDs() []int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var (
+		now = time.Now()
+		ids = make([]int, 0, len(l.backoffUntil))
+	)
+	for id, until := range l.backoffUntil {
+		if now.After(until) {
+			delete(l.backoffUntil, id)
+			continue
+		}
+		ids = append(ids, id)
+	}
+	if l.max > 0 {
+		for id, n := range l.inFlight {
+			if _, ok := l.backoffUntil[id]; !ok && n >= l.max {
+				ids = append(ids, id)
+			}
+		}
+	}
+	return ids
+}
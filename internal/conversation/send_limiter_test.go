@@ -0,0 +1,53 @@
+package conversation
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestInboxSendLimiter(t *testing.T) {
+	l := newInboxSendLimiter(2)
+
+	if !l.acquire(1) || !l.acquire(1) {
+		t.Fatal("expected two slots for inbox 1")
+	}
+	if l.acquire(1) {
+		t.Error("expected inbox 1 to be at its limit")
+	}
+	if !l.acquire(2) {
+		t.Error("expected inbox 2 to be unaffected by inbox 1")
+	}
+
+	l.release(1)
+	if !l.acquire(1) {
+		t.Error("expected a slot for inbox 1 after release")
+	}
+}
+
+func TestInboxSendLimiterUnlimited(t *testing.T) {
+	l := newInboxSendLimiter(0)
+	for i := 0; i < 100; i++ {
+		if !l.acquire(1) {
+			t.Fatalf("expected no limit, got blocked at %d", i)
+		}
+	}
+}
+
+func TestInboxSendLimiterThrottledInboxIDs(t *testing.T) {
+	l := newInboxSendLimiter(1)
+	l.acquire(1)
+	l.backOff(2, time.Minute)
+	l.backOff(3, -time.Second)
+
+	got := l.throttledInboxIDs()
+	slices.Sort(got)
+	if want := []int{1, 2}; !slices.Equal(got, want) {
+		t.Errorf("got throttled inboxes %v, want %v", got, want)
+	}
+
+	l.release(1)
+	if got := l.throttledInboxIDs(); !slices.Equal(got, []int{2}) {
+		t.Errorf("got throttled inboxes %v after release, want [2]", got)
+	}
+}
@@ -0,0 +1,146 @@
+package conversation
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"fmt"
+	"time"
+
+	"github.com/abhinavxd/libredesk/internal/attachment"
+	"github.com/abhinavxd/libredesk/internal/conversation/models"
+	"github.com/lib/pq"
+)
+
+const (
+	// spilloverDrainInterval is the interval at which spilled over incoming messages are moved back to the queue.
+	spilloverDrainInterval = 1 * time.Second
+	spilloverBatchSize     = 100
+)
+
+// spilledMessage is an incoming message persisted to the spillover table when the incoming queue is full.
+type spilledMessage struct {
+	ID      int64  `db:"id"`
+	Payload []byte `db:"payload"`
+}
+
+// spillIncoming persists an incoming message to the spillover table, the caller must hold `closedMu`.
+func (m *Manager) spillIncoming(message models.IncomingMessage) error {
+	payload, err := encodeIncoming(message)
+	if err != nil {
+		m.lo.Error("error encoding incoming message for spillover", "error", err)
+		return fmt.Errorf("incoming message queue is full: %w", err)
+	}
+	if _, err := m.q.InsertIncomingSpillover.Exec(message.InboxID, payload); err != nil {
+		m.lo.Error("error inserting incoming message spillover", "error", err)
+		return fmt.Errorf("incoming message queue is full: %w", err)
+	}
+	m.spilling.Store(true)
+	return nil
+}
+
+// RunIncomingSpilloverDrainer periodically moves spilled over incoming messages back to the incoming queue
+// in the order they were received, as capacity frees up.
+func (m *Manager) RunIncomingSpilloverDrainer(ctx context.Context) {
+	ticker := time.NewTicker(spilloverDrainInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := m.drainIncomingSpillover(); err != nil {
+				m.lo.Error("error draining incoming message spillover", "error", err)
+			}
+		}
+	}
+}
+
+// drainIncomingSpillover moves the oldest spilled over messages to the incoming queue until the queue or the spillover is exhausted.
+func (m *Manager) drainIncomingSpillover() error {
+	for {
+		queued, done, err := m.queueSpilledMessages()
+		if err != nil {
+			return err
+		}
+		if len(queued) > 0 {
+			if _, err := m.q.DeleteIncomingSpillover.Exec(pq.Array(queued)); err != nil {
+				return fmt.Errorf("deleting queued spillover messages: %w", err)
+			}
+		}
+		if done {
+			return nil
+		}
+	}
+}
+
+// queueSpilledMessages pushes a batch of the oldest spilled over messages to the incoming queue and returns the IDs
+// of the queued ones, and whether draining should stop as the queue is full or the spillover is empty.
+// The lock is held so that new messages keep going to the spillover until it's empty, preserving the order.
+func (m *Manager) queueSpilledMessages() ([]int64, bool, error) {
+	m.closedMu.Lock()
+	defer m.closedMu.Unlock()
+	if m.closed {
+		return nil, true, nil
+	}
+
+	free := cap(m.incomingMessageQueue) - len(m.incomingMessageQueue)
+	if free <= 0 {
+		return nil, true, nil
+	}
+
+	var spilled []spilledMessage
+	if err := m.q.GetIncomingSpillover.Select(&spilled, min(free, spilloverBatchSize)); err != nil {
+		return nil, true, fmt.Errorf("fetching spillover messages: %w", err)
+	}
+	if len(spilled) == 0 {
+		m.spilling.Store(false)
+		return nil, true, nil
+	}
+
+	var queued = make([]int64, 0, len(spilled))
+	for _, s := range spilled {
+		message, err := decodeIncoming(s.Payload)
+		if err != nil {
+			// Drop undecodable messages so they don't block the ones after them.
+			m.lo.Error("error decoding spillover message, dropping it", "id", s.ID, "error", err)
+			queued = append(queued, s.ID)
+			continue
+		}
+		select {
+		case m.incomingMessageQueue <- message:
+			queued = append(queued, s.ID)
+		default:
+			return queued, true, nil
+		}
+	}
+	return queued, false, nil
+}
+
+// encodeIncoming encodes an incoming message with gob, as unlike JSON it keeps the fields hidden from API responses.
+func encodeIncoming(message models.IncomingMessage) ([]byte, error) {
+	// Attachments read with `Open` are loaded as functions aren't encoded.
+	attachments := make(attachment.Attachments, len(message.Message.Attachments))
+	for i, a := range message.Message.Attachments {
+		content, err := a.ReadContent()
+		if err != nil {
+			return nil, fmt.Errorf("reading attachment %q: %w", a.Name, err)
+		}
+		a.Content, a.Open = content, nil
+		attachments[i] = a
+	}
+	message.Message.Attachments = attachments
+
+	var b bytes.Buffer
+	if err := gob.NewEncoder(&b).Encode(message); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// decodeIncoming decodes an incoming message encoded with encodeIncoming.
+func decodeIncoming(payload []byte) (models.IncomingMessage, error) {
+	var message models.IncomingMessage
+	err := gob.NewDecoder(bytes.NewReader(payload)).Decode(&message)
+	return message, err
+}
@@ -0,0 +1,35 @@
+package conversation
+
+import (
+	"testing"
+
+	"github.com/abhinavxd/libredesk/internal/attachment"
+	"github.com/abhinavxd/libredesk/internal/conversation/models"
+	"github.com/volatiletech/null/v9"
+)
+
+func TestEncodeDecodeIncoming(t *testing.T) {
+	in := models.IncomingMessage{InboxID: 3}
+	in.Message.SourceID = null.StringFrom("<id@example.com>")
+	in.Message.Subject = "Order status"
+	in.Message.InReplyTo = "<parent@example.com>"
+	in.Message.Attachments = attachment.Attachments{{Name: "a.txt", Content: []byte("hello")}}
+	in.Contact.Email = null.StringFrom("john@example.com")
+
+	payload, err := encodeIncoming(in)
+	if err != nil {
+		t.Fatalf("encoding: %v", err)
+	}
+	out, err := decodeIncoming(payload)
+	if err != nil {
+		t.Fatalf("decoding: %v", err)
+	}
+
+	if out.InboxID != in.InboxID || out.Message.SourceID != in.Message.SourceID || out.Message.Subject != in.Message.Subject ||
+		out.Message.InReplyTo != in.Message.InReplyTo || out.Contact.Email != in.Contact.Email {
+		t.Errorf("got %+v, want %+v", out, in)
+	}
+	if len(out.Message.Attachments) != 1 || string(out.Message.Attachments[0].Content) != "hello" {
+		t.Errorf("got attachments %+v", out.Message.Attachments)
+	}
+}
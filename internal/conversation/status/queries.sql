@@ -5,6 +5,13 @@ select id,
 from conversation_statuses
 where id = $1;
 
+-- name: get-status-by-name
+select id,
+    created_at,
+    name
+from conversation_statuses
+where name = $1;
+
 -- name: get-all-statuses
 select id, 
     created_at,
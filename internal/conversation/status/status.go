@@ -2,6 +2,7 @@
 package status
 
 import (
+	"database/sql"
 	"embed"
 	"fmt"
 	"slices"
@@ -39,11 +40,12 @@ type Opts struct {
 
 // queries contains prepared SQL queries.
 type queries struct {
-	GetStatus      *sqlx.Stmt `query:"get-status"`
-	GetAllStatuses *sqlx.Stmt `query:"get-all-statuses"`
-	InsertStatus   *sqlx.Stmt `query:"insert-status"`
-	DeleteStatus   *sqlx.Stmt `query:"delete-status"`
-	UpdateStatus   *sqlx.Stmt `query:"update-status"`
+	GetStatus       *sqlx.Stmt `query:"get-status"`
+	GetStatusByName *sqlx.Stmt `query:"get-status-by-name"`
+	GetAllStatuses  *sqlx.Stmt `query:"get-all-statuses"`
+	InsertStatus    *sqlx.Stmt `query:"insert-status"`
+	DeleteStatus    *sqlx.Stmt `query:"delete-status"`
+	UpdateStatus    *sqlx.Stmt `query:"update-status"`
 }
 
 // New creates and returns a new instance of the Manager.
@@ -129,12 +131,28 @@ func (m *Manager) Update(id int, name string) error {
 func (m *Manager) Get(id int) (models.Status, error) {
 	var status models.Status
 	if err := m.q.GetStatus.Get(&status, id); err != nil {
+		if err == sql.ErrNoRows {
+			return status, envelope.NewError(envelope.InputError, m.i18n.Ts("globals.messages.invalid", "name", m.i18n.T("globals.terms.status")), nil)
+		}
 		m.lo.Error("error fetching status", "error", err)
 		return status, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", m.i18n.Ts("globals.terms.status")), nil)
 	}
 	return status, nil
 }
 
+// GetByName retrieves a status by its name, names are matched exactly so that unknown statuses are rejected.
+func (m *Manager) GetByName(name string) (models.Status, error) {
+	var status models.Status
+	if err := m.q.GetStatusByName.Get(&status, name); err != nil {
+		if err == sql.ErrNoRows {
+			return status, envelope.NewError(envelope.InputError, m.i18n.Ts("globals.messages.invalid", "name", m.i18n.T("globals.terms.status")), nil)
+		}
+		m.lo.Error("error fetching status", "name", name, "error", err)
+		return status, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", m.i18n.Ts("globals.terms.status")), nil)
+	}
+	return status, nil
+}
+
 // validateStatusName checks if the status name is valid.
 func (m *Manager) validateStatusName(name string) error {
 	if len(name) == 0 {
@@ -0,0 +1,166 @@
+package conversation
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/abhinavxd/libredesk/internal/conversation/models"
+	"github.com/abhinavxd/libredesk/internal/dbutil"
+	"github.com/abhinavxd/libredesk/internal/envelope"
+)
+
+// statusCountsTTL is how long the status counts of a list are cached. Status and assignment changes clear the
+// cache right away, the TTL covers changes made without a broadcast, e.g. auto-close.
+const statusCountsTTL = 30 * time.Second
+
+// statusCountProps are the conversation properties that change the status counts of the lists.
+var statusCountProps = map[string]bool{
+	"status":            true,
+	"assigned_user_id":  true,
+	"assigned_team_id":  true,
+	"assigned_queue_id": true,
+	"deleted_at":        true,
+}
+
+// GetStatusCounts returns the number of conversations by status name in the list of the filter.
+func (c *Manager) GetStatusCounts(filter models.ConversationFilter, userID int) (map[string]int, error) {
+	key := fmt.Sprintf("%d:%s:%d:%s", userID, filter.ListType, filter.TeamID, filter.Filters)
+	if counts, ok := c.statusCounts.get(key); ok {
+		return counts, nil
+	}
+
+	var teamIDs = []int{}
+	if filter.TeamID > 0 {
+		teamIDs = append(teamIDs, filter.TeamID)
+	}
+	query, qArgs, filtersJSON, err := makeConversationsScopeQuery(userID, teamIDs, []string{filter.ListType}, c.q.GetConversationStatusCounts, filter.Filters)
+	if err != nil {
+		c.lo.Error("error creating conversation status counts query", "error", err)
+		return nil, envelope.NewError(envelope.InputError, c.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.conversation}"), nil)
+	}
+	query, qArgs, err = dbutil.BuildFilteredQuery(query, qArgs, filtersJSON, conversationsListAllowedFields)
+	if err != nil {
+		c.lo.Error("error creating conversation status counts query", "error", err)
+		return nil, envelope.NewError(envelope.InputError, c.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.conversation}"), nil)
+	}
+	query += " GROUP BY conversation_statuses.name"
+
+	// Start a read-only txn.
+	tx, err := c.db.BeginTxx(context.Background(), &sql.TxOptions{
+		ReadOnly: true,
+	})
+	if err != nil {
+		c.lo.Error("error starting read-only transaction", "error", err)
+		return nil, envelope.NewError(envelope.GeneralError, c.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.conversation}"), nil)
+	}
+	defer tx.Rollback()
+
+	var rows []struct {
+		Status sql.NullString `db:"status"`
+		Count  int            `db:"count"`
+	}
+	if err := tx.Select(&rows, query, qArgs...); err != nil {
+		c.lo.Error("error fetching conversation status counts", "error", err)
+		return nil, envelope.NewError(envelope.GeneralError, c.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.conversation}"), nil)
+	}
+
+	counts := make(map[string]int, len(rows))
+	for _, row := range rows {
+		if row.Status.Valid {
+			counts[row.Status.String] = row.Count
+		}
+	}
+	c.statusCounts.set(key, counts)
+	return maps.Clone(counts), nil
+}
+
+// GetInboxCounts returns the open and unassigned conversation counts by inbox ID in the list of the filter, inboxes
+// without open conversations in the list are left out.
+func (c *Manager) GetInboxCounts(filter models.ConversationFilter, userID int) (map[int]models.InboxConversationCounts, error) {
+	var teamIDs = []int{}
+	if filter.TeamID > 0 {
+		teamIDs = append(teamIDs, filter.TeamID)
+	}
+	query, qArgs, filtersJSON, err := makeConversationsScopeQuery(userID, teamIDs, []string{filter.ListType}, c.q.GetConversationInboxCounts, filter.Filters)
+	if err != nil {
+		c.lo.Error("error creating conversation inbox counts query", "error", err)
+		return nil, envelope.NewError(envelope.InputError, c.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.conversation}"), nil)
+	}
+	query, qArgs, err = dbutil.BuildFilteredQuery(query, qArgs, filtersJSON, conversationsListAllowedFields)
+	if err != nil {
+		c.lo.Error("error creating conversation inbox counts query", "error", err)
+		return nil, envelope.NewError(envelope.InputError, c.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.conversation}"), nil)
+	}
+	query += " GROUP BY conversations.inbox_id"
+
+	// Start a read-only txn.
+	tx, err := c.db.BeginTxx(context.Background(), &sql.TxOptions{
+		ReadOnly: true,
+	})
+	if err != nil {
+		c.lo.Error("error starting read-only transaction", "error", err)
+		return nil, envelope.NewError(envelope.GeneralError, c.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.conversation}"), nil)
+	}
+	defer tx.Rollback()
+
+	var rows []models.InboxConversationCounts
+	if err := tx.Select(&rows, query, qArgs...); err != nil {
+		c.lo.Error("error fetching conversation inbox counts", "error", err)
+		return nil, envelope.NewError(envelope.GeneralError, c.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.conversation}"), nil)
+	}
+
+	counts := make(map[int]models.InboxConversationCounts, len(rows))
+	for _, row := range rows {
+		counts[row.InboxID] = row
+	}
+	return counts, nil
+}
+
+// statusCountsCache caches the status counts of the conversations lists by user and filter.
+type statusCountsCache struct {
+	mu      sync.Mutex
+	entries map[string]statusCountsEntry
+}
+
+type statusCountsEntry struct {
+	counts    map[string]int
+	expiresAt time.Time
+}
+
+// get returns a copy of the cached counts of the key if they haven't expired.
+func (s *statusCountsCache) get(key string) (map[string]int, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.entries[key]
+	if !ok || time.Now().After(entry.expiresAt) {
+		return nil, false
+	}
+	return maps.Clone(entry.counts), true
+}
+
+// set caches the counts of the key, dropping the expired entries.
+func (s *statusCountsCache) set(key string, counts map[string]int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	if s.entries == nil {
+		s.entries = make(map[string]statusCountsEntry)
+	}
+	for k, entry := range s.entries {
+		if now.After(entry.expiresAt) {
+			delete(s.entries, k)
+		}
+	}
+	s.entries[key] = statusCountsEntry{counts: counts, expiresAt: now.Add(statusCountsTTL)}
+}
+
+// clear drops all the cached counts.
+func (s *statusCountsCache) clear() {
+	s.mu.Lock()
+	s.entries = nil
+	s.mu.Unlock()
+}
@@ -0,0 +1,40 @@
+package conversation
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStatusCountsCache(t *testing.T) {
+	var cache statusCountsCache
+	if _, ok := cache.get("1:assigned:0:"); ok {
+		t.Fatal("expected a miss on an empty cache")
+	}
+
+	cache.set("1:assigned:0:", map[string]int{"Open": 2})
+	counts, ok := cache.get("1:assigned:0:")
+	if !ok || counts["Open"] != 2 {
+		t.Fatalf("got %v, %v, want the cached counts", counts, ok)
+	}
+
+	// Callers get a copy of the cached counts.
+	counts["Open"] = 10
+	if counts, _ := cache.get("1:assigned:0:"); counts["Open"] != 2 {
+		t.Errorf("cached counts were modified, got %v", counts)
+	}
+
+	// Expired entries are misses and are dropped on the next set.
+	cache.entries["1:all:0:"] = statusCountsEntry{counts: map[string]int{"Open": 1}, expiresAt: time.Now().Add(-time.Second)}
+	if _, ok := cache.get("1:all:0:"); ok {
+		t.Error("expected a miss on an expired entry")
+	}
+	cache.set("2:assigned:0:", map[string]int{})
+	if _, ok := cache.entries["1:all:0:"]; ok {
+		t.Error("expected the expired entry to be dropped")
+	}
+
+	cache.clear()
+	if _, ok := cache.get("1:assigned:0:"); ok {
+		t.Error("expected a miss after clear")
+	}
+}
@@ -0,0 +1,58 @@
+package conversation
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/abhinavxd/libredesk/internal/conversation/models"
+	"github.com/abhinavxd/libredesk/internal/envelope"
+)
+
+const (
+	// maxAssigneeSuggestions is the maximum number of assignee suggestions returned.
+	maxAssigneeSuggestions = 5
+	// similarConversationsWindow is how far back conversations with shared tags are looked up.
+	similarConversationsWindow = "90 days"
+	// contactConversationWeight is the score weight of having handled the same contact, as it is a stronger
+	// signal than having handled conversations with the same tags.
+	contactConversationWeight = 3
+)
+
+// SuggestAssignee returns agents ranked by how likely they are the right assignee for the conversation, based on
+// who handled the contact's previous conversations and who handled recent conversations with the same tags.
+func (c *Manager) SuggestAssignee(conversationUUID string) ([]models.AgentSuggestion, error) {
+	var suggestions = make([]models.AgentSuggestion, 0)
+	if err := c.q.GetAssigneeSuggestions.Select(&suggestions, conversationUUID, similarConversationsWindow); err != nil {
+		c.lo.Error("error fetching assignee suggestions", "conversation_uuid", conversationUUID, "error", err)
+		return suggestions, envelope.NewError(envelope.GeneralError, c.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.agent}"), nil)
+	}
+	return c.rankSuggestions(suggestions, maxAssigneeSuggestions), nil
+}
+
+// rankSuggestions scores the suggestions, sets the reason and returns the top `limit` suggestions.
+func (c *Manager) rankSuggestions(suggestions []models.AgentSuggestion, limit int) []models.AgentSuggestion {
+	for i := range suggestions {
+		s := &suggestions[i]
+		s.Score = s.ContactConversationsCount*contactConversationWeight + s.SimilarConversationsCount
+
+		var reasons []string
+		if s.ContactConversationsCount > 0 {
+			reasons = append(reasons, c.i18n.Ts("conversation.suggestionContactConversations", "count", strconv.Itoa(s.ContactConversationsCount)))
+		}
+		if s.SimilarConversationsCount > 0 {
+			reasons = append(reasons, c.i18n.Ts("conversation.suggestionSimilarConversations", "count", strconv.Itoa(s.SimilarConversationsCount)))
+		}
+		s.Reason = strings.Join(reasons, ", ")
+	}
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		if suggestions[i].Score != suggestions[j].Score {
+			return suggestions[i].Score > suggestions[j].Score
+		}
+		return suggestions[i].UserID < suggestions[j].UserID
+	})
+	if len(suggestions) > limit {
+		suggestions = suggestions[:limit]
+	}
+	return suggestions
+}
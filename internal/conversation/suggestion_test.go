@@ -0,0 +1,106 @@
+package conversation
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/abhinavxd/libredesk/internal/conversation/models"
+	"github.com/knadh/go-i18n"
+)
+
+func TestRankSuggestions(t *testing.T) {
+	i, err := i18n.New([]byte(`{"_.code": "en", "_.name": "English"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := &Manager{i18n: i}
+
+	tests := []struct {
+		name        string
+		suggestions []models.AgentSuggestion
+		limit       int
+		expectedIDs []int
+	}{
+		{
+			name: "contact conversations weigh more than similar ones",
+			suggestions: []models.AgentSuggestion{
+				{UserID: 1, SimilarConversationsCount: 2},
+				{UserID: 2, ContactConversationsCount: 1},
+			},
+			limit:       5,
+			expectedIDs: []int{2, 1},
+		},
+		{
+			name: "ties are ordered by user",
+			suggestions: []models.AgentSuggestion{
+				{UserID: 3, SimilarConversationsCount: 3},
+				{UserID: 2, ContactConversationsCount: 1},
+				{UserID: 1, SimilarConversationsCount: 3},
+			},
+			limit:       5,
+			expectedIDs: []int{1, 2, 3},
+		},
+		{
+			name: "limited to the top suggestions",
+			suggestions: []models.AgentSuggestion{
+				{UserID: 1, SimilarConversationsCount: 1},
+				{UserID: 2, SimilarConversationsCount: 2},
+				{UserID: 3, SimilarConversationsCount: 3},
+			},
+			limit:       2,
+			expectedIDs: []int{3, 2},
+		},
+		{
+			name:        "none",
+			suggestions: []models.AgentSuggestion{},
+			limit:       5,
+			expectedIDs: []int{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ranked := m.rankSuggestions(tt.suggestions, tt.limit)
+			ids := make([]int, 0, len(ranked))
+			for _, s := range ranked {
+				ids = append(ids, s.UserID)
+			}
+			if !slices.Equal(ids, tt.expectedIDs) {
+				t.Errorf("got ranking %v, want %v", ids, tt.expectedIDs)
+			}
+		})
+	}
+}
+
+func TestRankSuggestionsReason(t *testing.T) {
+	i, err := i18n.New([]byte(`{
+		"_.code": "en",
+		"_.name": "English",
+		"conversation.suggestionContactConversations": "contact {count}",
+		"conversation.suggestionSimilarConversations": "similar {count}"
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := &Manager{i18n: i}
+
+	tests := []struct {
+		name       string
+		suggestion models.AgentSuggestion
+		score      int
+		reason     string
+	}{
+		{name: "contact", suggestion: models.AgentSuggestion{ContactConversationsCount: 2}, score: 6, reason: "contact 2"},
+		{name: "similar", suggestion: models.AgentSuggestion{SimilarConversationsCount: 1}, score: 1, reason: "similar 1"},
+		{name: "both", suggestion: models.AgentSuggestion{ContactConversationsCount: 1, SimilarConversationsCount: 4}, score: 7, reason: "contact 1, similar 4"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ranked := m.rankSuggestions([]models.AgentSuggestion{tt.suggestion}, 1)
+			if ranked[0].Score != tt.score || ranked[0].Reason != tt.reason {
+				t.Errorf("got score %d and reason %q, want %d and %q", ranked[0].Score, ranked[0].Reason, tt.score, tt.reason)
+			}
+		})
+	}
+}
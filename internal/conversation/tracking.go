@@ -0,0 +1,124 @@
+package conversation
+
+import (
+	"database/sql"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/abhinavxd/libredesk/internal/conversation/models"
+	"github.com/abhinavxd/libredesk/internal/envelope"
+)
+
+const (
+	// TrackingEventOpen and TrackingEventClick are the types of email tracking events.
+	TrackingEventOpen  = "open"
+	TrackingEventClick = "click"
+)
+
+// reTrackableLink matches the http(s) href attributes of links in HTML content.
+var reTrackableLink = regexp.MustCompile(`(?i)(<a\s[^>]*?href\s*=\s*)(["'])(https?://[^"']+)(["'])`)
+
+// addEmailTracking rewrites the links of an outgoing email to tracked redirects and appends an open tracking pixel,
+// if tracking is enabled on the inbox and not disabled globally. Private notes and CSAT messages are not tracked.
+func (m *Manager) addEmailTracking(message *models.Message) error {
+	if m.disableEmailTracking || message.Private || message.HasCSAT() {
+		return nil
+	}
+	inbox, err := m.inboxStore.GetDBRecord(message.InboxID)
+	if err != nil {
+		return fmt.Errorf("fetching inbox: %w", err)
+	}
+	if !inbox.TrackingEnabled {
+		return nil
+	}
+	rootURL, err := m.settingsStore.GetAppRootURL()
+	if err != nil {
+		return fmt.Errorf("fetching app root URL: %w", err)
+	}
+
+	content, err := rewriteTrackedLinks(message.Content, func(link string) (string, error) {
+		var linkUUID string
+		if err := m.q.UpsertMessageTrackingLink.Get(&linkUUID, message.ID, link); err != nil {
+			return "", fmt.Errorf("saving tracking link: %w", err)
+		}
+		return rootURL + "/tracking/click/" + linkUUID, nil
+	})
+	if err != nil {
+		return err
+	}
+	message.Content = appendTrackingPixel(content, rootURL+"/tracking/open/"+message.UUID)
+	return nil
+}
+
+// rewriteTrackedLinks replaces the http(s) links in the HTML content with the URLs returned by `track`.
+func rewriteTrackedLinks(content string, track func(link string) (string, error)) (string, error) {
+	var trackErr error
+	out := reTrackableLink.ReplaceAllStringFunc(content, func(match string) string {
+		if trackErr != nil {
+			return match
+		}
+		parts := reTrackableLink.FindStringSubmatch(match)
+		// Links are HTML escaped in the content, e.g. `&amp;` in query strings.
+		link := strings.ReplaceAll(parts[3], "&amp;", "&")
+		if _, err := url.ParseRequestURI(link); err != nil {
+			return match
+		}
+		tracked, err := track(link)
+		if err != nil {
+			trackErr = err
+			return match
+		}
+		return parts[1] + parts[2] + tracked + parts[4]
+	})
+	if trackErr != nil {
+		return content, trackErr
+	}
+	return out, nil
+}
+
+// appendTrackingPixel adds an invisible image loading the pixel URL before the closing body tag, or at the end.
+func appendTrackingPixel(content, pixelURL string) string {
+	pixel := fmt.Sprintf(`<img src="%s" width="1" height="1" alt="" style="display:none;border:0;" />`, pixelURL)
+	if i := strings.LastIndex(strings.ToLower(content), "</body>"); i >= 0 {
+		return content[:i] + pixel + content[i:]
+	}
+	return content + pixel
+}
+
+// RecordMessageOpen records an open of the outgoing message, unknown messages are ignored.
+func (m *Manager) RecordMessageOpen(messageUUID string) error {
+	if _, err := m.q.InsertMessageOpenEvent.Exec(messageUUID); err != nil {
+		m.lo.Error("error recording message open", "uuid", messageUUID, "error", err)
+		return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorCreating", "name", "{globals.terms.message}"), nil)
+	}
+	return nil
+}
+
+// RecordMessageClick records a click of a tracked link and returns the URL of the link to redirect to.
+func (m *Manager) RecordMessageClick(linkUUID string) (string, error) {
+	var link models.MessageTrackingLink
+	if err := m.q.GetMessageTrackingLink.Get(&link, linkUUID); err != nil {
+		if err == sql.ErrNoRows {
+			return "", envelope.NewError(envelope.NotFoundError, m.i18n.Ts("globals.messages.notFound", "name", "URL"), nil)
+		}
+		m.lo.Error("error fetching message tracking link", "uuid", linkUUID, "error", err)
+		return "", envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", "URL"), nil)
+	}
+	if _, err := m.q.InsertMessageClickEvent.Exec(link.MessageID, link.URL); err != nil {
+		// The redirect is still done so the contact isn't left with a broken link.
+		m.lo.Error("error recording message click", "uuid", linkUUID, "error", err)
+	}
+	return link.URL, nil
+}
+
+// GetMessageTrackingEvents returns the opens and clicks of an outgoing message.
+func (m *Manager) GetMessageTrackingEvents(messageUUID string) ([]models.MessageTrackingEvent, error) {
+	var events = make([]models.MessageTrackingEvent, 0)
+	if err := m.q.GetMessageTrackingEvents.Select(&events, messageUUID); err != nil {
+		m.lo.Error("error fetching message tracking events", "uuid", messageUUID, "error", err)
+		return events, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.message}"), nil)
+	}
+	return events, nil
+}
@@ -0,0 +1,80 @@
+package conversation
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRewriteTrackedLinks(t *testing.T) {
+	track := func(link string) (string, error) {
+		return "https://desk.example.com/tracking/click/" + link[len(link)-1:], nil
+	}
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{
+			name:     "http links",
+			content:  `<p><a href="https://example.com/a">A</a> and <a class="x" href='http://example.com/b'>B</a></p>`,
+			expected: `<p><a href="https://desk.example.com/tracking/click/a">A</a> and <a class="x" href='https://desk.example.com/tracking/click/b'>B</a></p>`,
+		},
+		{
+			name:     "mailto and relative links kept",
+			content:  `<a href="mailto:help@example.com">Mail</a><a href="/uploads/x">File</a>`,
+			expected: `<a href="mailto:help@example.com">Mail</a><a href="/uploads/x">File</a>`,
+		},
+		{
+			name:     "images kept",
+			content:  `<img src="https://example.com/logo.png">`,
+			expected: `<img src="https://example.com/logo.png">`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := rewriteTrackedLinks(tt.content, track)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("got %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRewriteTrackedLinksUnescapesAmpersands(t *testing.T) {
+	var tracked string
+	_, err := rewriteTrackedLinks(`<a href="https://example.com/?a=1&amp;b=2">x</a>`, func(link string) (string, error) {
+		tracked = link
+		return "https://desk.example.com/tracking/click/1", nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tracked != "https://example.com/?a=1&b=2" {
+		t.Errorf("got tracked link %q, want unescaped URL", tracked)
+	}
+}
+
+func TestRewriteTrackedLinksError(t *testing.T) {
+	content := `<a href="https://example.com">x</a>`
+	got, err := rewriteTrackedLinks(content, func(string) (string, error) { return "", errors.New("db down") })
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if got != content {
+		t.Errorf("got %q, want the content unchanged", got)
+	}
+}
+
+func TestAppendTrackingPixel(t *testing.T) {
+	pixel := `<img src="https://desk.example.com/tracking/open/u" width="1" height="1" alt="" style="display:none;border:0;" />`
+	if got := appendTrackingPixel("<html><body><p>Hi</p></BODY></html>", "https://desk.example.com/tracking/open/u"); got != "<html><body><p>Hi</p>"+pixel+"</BODY></html>" {
+		t.Errorf("got %q, want the pixel before the closing body tag", got)
+	}
+	if got := appendTrackingPixel("<p>Hi</p>", "https://desk.example.com/tracking/open/u"); got != "<p>Hi</p>"+pixel {
+		t.Errorf("got %q, want the pixel appended", got)
+	}
+}
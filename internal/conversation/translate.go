@@ -0,0 +1,80 @@
+package conversation
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+
+	"github.com/abhinavxd/libredesk/internal/envelope"
+	"github.com/abhinavxd/libredesk/internal/stringutil"
+)
+
+// langCodeRe matches BCP 47 like language codes, e.g. `en` and `pt-BR`.
+var langCodeRe = regexp.MustCompile(`^[a-zA-Z]{2,3}(-[a-zA-Z0-9]{2,8})?$`)
+
+// translator translates text to a language and returns the translation and the detected language of the text.
+type translator interface {
+	Translate(text, targetLang string) (string, string, error)
+}
+
+// SetTranslator sets the provider used to translate messages.
+func (m *Manager) SetTranslator(t translator) {
+	m.translator = t
+}
+
+// TranslateMessage returns the message content translated to the target language. Translations are cached in the
+// message meta by language along with the detected language of the message.
+func (m *Manager) TranslateMessage(messageUUID string, targetLang string) (string, error) {
+	if !langCodeRe.MatchString(targetLang) {
+		return "", envelope.NewError(envelope.InputError, m.i18n.Ts("globals.messages.invalid", "name", "`lang`"), nil)
+	}
+
+	message, err := m.GetMessage(messageUUID)
+	if err != nil {
+		return "", err
+	}
+	if cached, ok := cachedTranslation(message.Meta, targetLang); ok {
+		return cached, nil
+	}
+
+	translated, sourceLang, err := m.translate(stringutil.HTML2Text(message.Content), targetLang)
+	if err != nil {
+		return "", err
+	}
+	if _, err := m.q.SetMessageTranslation.Exec(messageUUID, targetLang, translated, sourceLang); err != nil {
+		m.lo.Error("error caching message translation", "uuid", messageUUID, "lang", targetLang, "error", err)
+	}
+	return translated, nil
+}
+
+// TranslateText translates text to the target language, used to translate replies to the contact's language.
+func (m *Manager) TranslateText(text, targetLang string) (string, error) {
+	if !langCodeRe.MatchString(targetLang) {
+		return "", envelope.NewError(envelope.InputError, m.i18n.Ts("globals.messages.invalid", "name", "`lang`"), nil)
+	}
+	translated, _, err := m.translate(text, targetLang)
+	return translated, err
+}
+
+// translate translates text with the translator, returning the translation and the detected source language.
+func (m *Manager) translate(text, targetLang string) (string, string, error) {
+	if m.translator == nil {
+		return "", "", envelope.NewError(envelope.GeneralError, m.i18n.T("conversation.translationNotConfigured"), nil)
+	}
+	if strings.TrimSpace(text) == "" {
+		return "", "", nil
+	}
+	return m.translator.Translate(text, targetLang)
+}
+
+// cachedTranslation returns the translation to the language stored in the message meta.
+func cachedTranslation(meta string, lang string) (string, bool) {
+	var m struct {
+		Translations map[string]string `json:"translations"`
+	}
+	if meta == "" || json.Unmarshal([]byte(meta), &m) != nil {
+		return "", false
+	}
+	t, ok := m.Translations[lang]
+	return t, ok
+}
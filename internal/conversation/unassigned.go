@@ -0,0 +1,69 @@
+package conversation
+
+import (
+	"fmt"
+	"html"
+
+	"github.com/abhinavxd/libredesk/internal/conversation/models"
+	"github.com/abhinavxd/libredesk/internal/envelope"
+	notifier "github.com/abhinavxd/libredesk/internal/notification"
+	npmodels "github.com/abhinavxd/libredesk/internal/notification/preference/models"
+)
+
+// GetUnassignedConversationsToEscalate returns the open conversations left without an assigned agent for longer
+// than the unassigned escalation minutes of their inbox, that haven't been escalated yet.
+func (m *Manager) GetUnassignedConversationsToEscalate() ([]models.UnassignedEscalation, error) {
+	var out = make([]models.UnassignedEscalation, 0)
+	if err := m.q.GetUnassignedToEscalate.Select(&out); err != nil {
+		m.lo.Error("error fetching unassigned conversations to escalate", "error", err)
+		return out, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.conversation}"), nil)
+	}
+	return out, nil
+}
+
+// MarkUnassignedEscalated records that the conversation was escalated for being left unassigned, so it's escalated once.
+func (m *Manager) MarkUnassignedEscalated(conversationID int) error {
+	if _, err := m.q.SetUnassignedEscalated.Exec(conversationID); err != nil {
+		m.lo.Error("error marking conversation as escalated", "conversation_id", conversationID, "error", err)
+		return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorUpdating", "name", "{globals.terms.conversation}"), nil)
+	}
+	return nil
+}
+
+// SendUnassignedConversationAlert emails the user that the conversation has been left unassigned for `minutes`.
+// Sent as an SLA alert, so it follows the user's SLA alert notification preference.
+func (m *Manager) SendUnassignedConversationAlert(conversationUUID string, userID, minutes int) error {
+	conversation, err := m.GetConversation(0, conversationUUID)
+	if err != nil {
+		return err
+	}
+	user, err := m.userStore.GetAgent(userID, "")
+	if err != nil {
+		m.lo.Error("error fetching agent", "user_id", userID, "error", err)
+		return fmt.Errorf("fetching agent: %w", err)
+	}
+	rootURL, err := m.settingsStore.GetAppRootURL()
+	if err != nil {
+		return fmt.Errorf("fetching app root URL: %w", err)
+	}
+
+	var (
+		subject = m.i18n.Ts("notification.unassignedAlertSubject", "reference", conversation.ReferenceNumber)
+		content = fmt.Sprintf(`<p>%s</p><p><a href="%s/inboxes/unassigned/conversation/%s">#%s %s</a></p>`,
+			html.EscapeString(m.i18n.Ts("notification.unassignedAlertIntro", "minutes", fmt.Sprint(minutes))),
+			rootURL, conversation.UUID, html.EscapeString(conversation.ReferenceNumber), html.EscapeString(conversation.Subject.String))
+	)
+	if err := m.notifier.Send(notifier.Message{
+		UserIDs:         []int{user.ID},
+		RecipientEmails: []string{user.Email.String},
+		Subject:         subject,
+		Content:         content,
+		ContentType:     "html",
+		Provider:        notifier.ProviderEmail,
+		EventType:       npmodels.EventSLAAlert,
+	}); err != nil {
+		m.lo.Error("error sending unassigned conversation alert", "conversation_uuid", conversationUUID, "user_id", userID, "error", err)
+		return fmt.Errorf("sending notification message: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package conversation
+
+import (
+	"strings"
+
+	"github.com/abhinavxd/libredesk/internal/conversation/models"
+	"github.com/abhinavxd/libredesk/internal/envelope"
+	mmodels "github.com/abhinavxd/libredesk/internal/media/models"
+	"github.com/abhinavxd/libredesk/internal/stringutil"
+)
+
+// ValidateOutgoing checks that an outgoing reply can be sent before it's inserted and queued, so that a reply that
+// would fail once picked up by a worker is rejected when it's composed instead. The conversation must have a
+// recipient unless the message is a private note, the content must not be empty once converted to text unless there are attachments and every
+// attachment must be an uploaded, non empty file in the store that's not attached to another message.
+// All the reasons the message is invalid are returned in the error's data.
+func (m *Manager) ValidateOutgoing(message models.Message) error {
+	var reasons []string
+
+	conversation, err := m.GetConversation(message.ConversationID, message.ConversationUUID)
+	if err != nil {
+		return err
+	}
+	if !message.Private {
+		to, err := m.GetToAddress(conversation.ID)
+		if err != nil {
+			return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.email}"), nil)
+		}
+		if len(stringutil.RemoveEmpty(to)) == 0 {
+			reasons = append(reasons, m.i18n.T("conversation.noRecipients"))
+		}
+	}
+
+	if len(message.Media) == 0 && isEmptyContent(message.Content) {
+		reasons = append(reasons, m.i18n.T("conversation.emptyMessage"))
+	}
+
+	for _, media := range message.Media {
+		if !validAttachment(media) || !m.blobExists(media) {
+			reasons = append(reasons, m.i18n.Ts("conversation.invalidAttachment", "name", media.Filename))
+		}
+	}
+
+	if len(reasons) > 0 {
+		return envelope.NewError(envelope.InputError, strings.Join(reasons, ". "), reasons)
+	}
+	return nil
+}
+
+// blobExists returns true if the file of the media can be read from the store.
+func (m *Manager) blobExists(media mmodels.Media) bool {
+	rd, err := m.mediaStore.GetReader(media.BlobName)
+	if err != nil {
+		m.lo.Warn("attachment blob not found", "media_id", media.ID, "blob_name", media.BlobName, "error", err)
+		return false
+	}
+	rd.Close()
+	return true
+}
+
+// isEmptyContent returns true if the HTML content has no text once converted to text.
+func isEmptyContent(content string) bool {
+	return strings.TrimSpace(stringutil.HTML2Text(content)) == ""
+}
+
+// validAttachment returns true if the media is an uploaded, non empty file that's not attached to another message.
+func validAttachment(media mmodels.Media) bool {
+	return media.ID > 0 && media.Size > 0 && !media.ModelID.Valid
+}
@@ -0,0 +1,48 @@
+package conversation
+
+import (
+	"testing"
+
+	mmodels "github.com/abhinavxd/libredesk/internal/media/models"
+	"github.com/volatiletech/null/v9"
+)
+
+func TestIsEmptyContent(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    bool
+	}{
+		{"empty", "", true},
+		{"whitespace", "  \n ", true},
+		{"empty markup", "<p><br></p><p>&nbsp;</p>", true},
+		{"text", "<p>Hello</p>", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isEmptyContent(tt.content); got != tt.want {
+				t.Errorf("isEmptyContent(%q) = %v, want %v", tt.content, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidAttachment(t *testing.T) {
+	tests := []struct {
+		name  string
+		media mmodels.Media
+		want  bool
+	}{
+		{"uploaded", mmodels.Media{ID: 1, Size: 10}, true},
+		{"empty file", mmodels.Media{ID: 1}, false},
+		{"not uploaded", mmodels.Media{Size: 10}, false},
+		{"attached elsewhere", mmodels.Media{ID: 1, Size: 10, ModelID: null.IntFrom(5)}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := validAttachment(tt.media); got != tt.want {
+				t.Errorf("validAttachment() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
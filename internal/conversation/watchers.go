@@ -0,0 +1,129 @@
+package conversation
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"strings"
+	"time"
+
+	"github.com/abhinavxd/libredesk/internal/conversation/models"
+	"github.com/abhinavxd/libredesk/internal/envelope"
+	notifier "github.com/abhinavxd/libredesk/internal/notification"
+	npmodels "github.com/abhinavxd/libredesk/internal/notification/preference/models"
+)
+
+const (
+	// watchDigestScanInterval is how often users due for a watched conversations digest are checked.
+	watchDigestScanInterval = 5 * time.Minute
+
+	// defaultWatchDigestInterval is the default minimum time between two digests to the same user.
+	defaultWatchDigestInterval = 24 * time.Hour
+)
+
+// WatchConversation subscribes the user to the conversation's activity digest, watching again is a no-op.
+func (m *Manager) WatchConversation(userID int, uuid string) error {
+	if _, err := m.q.InsertConversationWatcher.Exec(userID, uuid); err != nil {
+		m.lo.Error("error watching conversation", "user_id", userID, "conversation_uuid", uuid, "error", err)
+		return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorUpdating", "name", "{globals.terms.conversation}"), nil)
+	}
+	return nil
+}
+
+// UnwatchConversation unsubscribes the user from the conversation's activity digest.
+func (m *Manager) UnwatchConversation(userID int, uuid string) error {
+	if _, err := m.q.DeleteConversationWatcher.Exec(userID, uuid); err != nil {
+		m.lo.Error("error unwatching conversation", "user_id", userID, "conversation_uuid", uuid, "error", err)
+		return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorUpdating", "name", "{globals.terms.conversation}"), nil)
+	}
+	return nil
+}
+
+// GetConversationWatchers returns the users watching the conversation.
+func (m *Manager) GetConversationWatchers(uuid string) ([]models.ConversationParticipant, error) {
+	var watchers = make([]models.ConversationParticipant, 0)
+	if err := m.q.GetConversationWatchers.Select(&watchers, uuid); err != nil {
+		m.lo.Error("error fetching conversation watchers", "conversation_uuid", uuid, "error", err)
+		return watchers, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.conversation}"), nil)
+	}
+	return watchers, nil
+}
+
+// RunWatchDigest periodically emails users a digest of the new activity in the conversations they watch but aren't
+// assigned to, at most once every `interval`. The digest is an opt-in notification event, so it's only sent to users
+// who enabled it and is held back during their quiet hours.
+func (m *Manager) RunWatchDigest(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = defaultWatchDigestInterval
+	}
+	ticker := time.NewTicker(watchDigestScanInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := m.sendWatchDigests(interval); err != nil {
+				m.lo.Error("error sending watched conversations digests", "error", err)
+			}
+		}
+	}
+}
+
+// sendWatchDigests sends a digest to every user due for one with new activity in their watched conversations.
+func (m *Manager) sendWatchDigests(interval time.Duration) error {
+	var activity = make([]models.WatchedConversationActivity, 0)
+	if err := m.q.GetWatchDigestActivity.Select(&activity, fmt.Sprintf("%d seconds", int64(interval.Seconds()))); err != nil {
+		return fmt.Errorf("fetching watched conversations activity: %w", err)
+	}
+	if len(activity) == 0 {
+		return nil
+	}
+
+	rootURL, err := m.settingsStore.GetAppRootURL()
+	if err != nil {
+		return fmt.Errorf("fetching app root URL: %w", err)
+	}
+
+	for userID, items := range groupWatchActivity(activity) {
+		if err := m.notifier.Send(notifier.Message{
+			UserIDs:         []int{userID},
+			RecipientEmails: []string{items[0].Email},
+			Subject:         m.i18n.Ts("notification.watchDigestSubject", "count", fmt.Sprint(len(items))),
+			Content:         m.renderWatchDigest(rootURL, items),
+			ContentType:     "html",
+			Provider:        notifier.ProviderEmail,
+			EventType:       npmodels.EventWatchDigest,
+		}); err != nil {
+			m.lo.Error("error sending watched conversations digest", "user_id", userID, "error", err)
+			continue
+		}
+		if _, err := m.q.UpsertWatchDigestSentAt.Exec(userID); err != nil {
+			m.lo.Error("error saving watched conversations digest time", "user_id", userID, "error", err)
+		}
+	}
+	return nil
+}
+
+// groupWatchActivity groups the watched conversations activity by user.
+func groupWatchActivity(activity []models.WatchedConversationActivity) map[int][]models.WatchedConversationActivity {
+	var out = make(map[int][]models.WatchedConversationActivity)
+	for _, a := range activity {
+		out[a.UserID] = append(out[a.UserID], a)
+	}
+	return out
+}
+
+// renderWatchDigest renders the HTML body of a digest listing the watched conversations with new activity.
+func (m *Manager) renderWatchDigest(rootURL string, items []models.WatchedConversationActivity) string {
+	var b strings.Builder
+	b.WriteString("<p>" + html.EscapeString(m.i18n.T("notification.watchDigestIntro")) + "</p><ul>")
+	for _, a := range items {
+		fmt.Fprintf(&b, `<li><a href="%s/inboxes/all/conversation/%s">#%s %s</a> <small>(%s)</small><br>%s</li>`,
+			rootURL, a.ConversationUUID, html.EscapeString(a.ReferenceNumber), html.EscapeString(a.Subject.String),
+			html.EscapeString(m.i18n.Ts("notification.watchDigestNewMessages", "count", fmt.Sprint(a.NewMessages))),
+			html.EscapeString(a.LastMessage.String))
+	}
+	b.WriteString("</ul>")
+	return b.String()
+}
@@ -0,0 +1,26 @@
+package conversation
+
+import (
+	"testing"
+
+	"github.com/abhinavxd/libredesk/internal/conversation/models"
+)
+
+func TestGroupWatchActivity(t *testing.T) {
+	activity := []models.WatchedConversationActivity{
+		{UserID: 1, ConversationUUID: "a"},
+		{UserID: 2, ConversationUUID: "a"},
+		{UserID: 1, ConversationUUID: "b"},
+	}
+
+	got := groupWatchActivity(activity)
+	if len(got) != 2 {
+		t.Fatalf("got %d users, want 2", len(got))
+	}
+	if len(got[1]) != 2 || got[1][0].ConversationUUID != "a" || got[1][1].ConversationUUID != "b" {
+		t.Errorf("got %+v for user 1, want conversations a and b in order", got[1])
+	}
+	if len(got[2]) != 1 {
+		t.Errorf("got %d conversations for user 2, want 1", len(got[2]))
+	}
+}
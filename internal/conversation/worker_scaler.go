@@ -0,0 +1,123 @@
+package conversation
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	// outgoingScaleInterval is how often the outgoing queue depth is checked for scaling sender workers.
+	outgoingScaleInterval = time.Second
+
+	// defaultOutgoingWorkerIdleTimeout is how long an extra sender worker waits for a message before exiting.
+	defaultOutgoingWorkerIdleTimeout = time.Minute
+)
+
+// outgoingWorkerScaler decides how many extra sender workers to run on top of the fixed pool started by Run.
+type outgoingWorkerScaler struct {
+	// max is the maximum number of sender workers including the fixed pool, scaling is disabled if it's
+	// not above the fixed pool size.
+	max int
+	// targetDepth is the outgoing queue depth above which extra workers are spawned, one for every
+	// `targetDepth` queued messages over it.
+	targetDepth int
+	// idleTimeout is how long an extra worker waits for a message before exiting.
+	idleTimeout time.Duration
+}
+
+// newOutgoingWorkerScaler returns a scaler, non-positive target depth and idle timeout fall back to defaults.
+func newOutgoingWorkerScaler(max, targetDepth int, idleTimeout time.Duration) outgoingWorkerScaler {
+	if targetDepth <= 0 {
+		targetDepth = 1
+	}
+	if idleTimeout <= 0 {
+		idleTimeout = defaultOutgoingWorkerIdleTimeout
+	}
+	return outgoingWorkerScaler{max: max, targetDepth: targetDepth, idleTimeout: idleTimeout}
+}
+
+// workersToAdd returns the number of workers to spawn for the passed queue depth and running worker count.
+func (s outgoingWorkerScaler) workersToAdd(queueDepth, running int) int {
+	if running >= s.max || queueDepth <= s.targetDepth {
+		return 0
+	}
+	excess := queueDepth - s.targetDepth
+	n := (excess + s.targetDepth - 1) / s.targetDepth
+	return min(n, s.max-running)
+}
+
+// runOutgoingWorkerScaler spawns extra sender workers while the outgoing queue is backed up, extra workers exit
+// on their own once idle. Spawned workers are added to the wait group so Close joins them.
+func (m *Manager) runOutgoingWorkerScaler(ctx context.Context, minWorkers int) {
+	if m.outgoingScaler.max <= minWorkers {
+		return
+	}
+
+	ticker := time.NewTicker(outgoingScaleInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			running := minWorkers + int(m.extraSenderWorkers.Load())
+			n := m.outgoingScaler.workersToAdd(len(m.outgoingMessageQueue), running)
+			if n == 0 {
+				continue
+			}
+			if !m.spawnExtraSenderWorkers(ctx, n) {
+				return
+			}
+			m.lo.Debug("scaled up outgoing sender workers", "added", n, "workers", running+n)
+		}
+	}
+}
+
+// spawnExtraSenderWorkers starts `n` extra sender workers and returns false if the manager is closed.
+func (m *Manager) spawnExtraSenderWorkers(ctx context.Context, n int) bool {
+	// Hold the read lock so no worker is added to the wait group once Close has started waiting on it.
+	m.closedMu.RLock()
+	defer m.closedMu.RUnlock()
+	if m.closed {
+		return false
+	}
+	for range n {
+		m.extraSenderWorkers.Add(1)
+		m.wg.Add(1)
+		go func() {
+			defer m.wg.Done()
+			defer m.extraSenderWorkers.Add(-1)
+			m.extraSenderWorker(ctx)
+		}()
+	}
+	return true
+}
+
+// extraSenderWorker sends outgoing messages like MessageSenderWorker but exits after being idle for the scaler's idle timeout.
+func (m *Manager) extraSenderWorker(ctx context.Context) {
+	idle := time.NewTimer(m.outgoingScaler.idleTimeout)
+	defer idle.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-idle.C:
+			m.lo.Debug("idle outgoing sender worker exiting")
+			return
+		case message, ok := <-m.outgoingMessageQueue:
+			if !ok {
+				return
+			}
+			m.sendOutgoingMessage(message)
+			if !idle.Stop() {
+				select {
+				case <-idle.C:
+				default:
+				}
+			}
+			idle.Reset(m.outgoingScaler.idleTimeout)
+		}
+	}
+}
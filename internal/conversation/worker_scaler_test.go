@@ -0,0 +1,39 @@
+package conversation
+
+import "testing"
+
+func TestOutgoingWorkerScalerWorkersToAdd(t *testing.T) {
+	s := newOutgoingWorkerScaler(10, 100, 0)
+
+	tests := []struct {
+		name     string
+		depth    int
+		running  int
+		expected int
+	}{
+		{name: "empty queue", depth: 0, running: 2, expected: 0},
+		{name: "at target depth", depth: 100, running: 2, expected: 0},
+		{name: "just over target depth", depth: 101, running: 2, expected: 1},
+		{name: "one worker per target depth over", depth: 350, running: 2, expected: 3},
+		{name: "capped at max", depth: 5000, running: 2, expected: 8},
+		{name: "at max", depth: 5000, running: 10, expected: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := s.workersToAdd(tt.depth, tt.running); got != tt.expected {
+				t.Errorf("got %d, want %d", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestNewOutgoingWorkerScalerDefaults(t *testing.T) {
+	s := newOutgoingWorkerScaler(5, 0, 0)
+	if s.targetDepth != 1 {
+		t.Errorf("got target depth %d, want 1", s.targetDepth)
+	}
+	if s.idleTimeout != defaultOutgoingWorkerIdleTimeout {
+		t.Errorf("got idle timeout %v, want %v", s.idleTimeout, defaultOutgoingWorkerIdleTimeout)
+	}
+}
@@ -2,20 +2,21 @@ package conversation
 
 import (
 	"encoding/json"
-	"time"
+	"slices"
 
 	cmodels "github.com/abhinavxd/libredesk/internal/conversation/models"
+	"github.com/abhinavxd/libredesk/internal/stringutil"
 	wsmodels "github.com/abhinavxd/libredesk/internal/ws/models"
 )
 
-// BroadcastNewMessage broadcasts a new message to all users.
+// BroadcastNewMessage broadcasts a new message to the users allowed to view its conversation.
 func (m *Manager) BroadcastNewMessage(message *cmodels.Message) {
-	m.broadcastToUsers([]int{}, wsmodels.Message{
+	m.broadcastToConversation(message.ConversationUUID, wsmodels.Message{
 		Type: wsmodels.MessageTypeNewMessage,
 		Data: map[string]interface{}{
 			"conversation_uuid": message.ConversationUUID,
 			"content":           message.TextContent,
-			"created_at":        message.CreatedAt.Format(time.RFC3339),
+			"created_at":        stringutil.FormatTimestamp(message.CreatedAt),
 			"uuid":              message.UUID,
 			"private":           message.Private,
 			"type":              message.Type,
@@ -24,7 +25,7 @@ func (m *Manager) BroadcastNewMessage(message *cmodels.Message) {
 	})
 }
 
-// BroadcastMessageUpdate broadcasts a message update to all users.
+// BroadcastMessageUpdate broadcasts a message update to the users allowed to view its conversation.
 func (m *Manager) BroadcastMessageUpdate(conversationUUID, messageUUID, prop string, value any) {
 	message := wsmodels.Message{
 		Type: wsmodels.MessageTypeMessagePropUpdate,
@@ -35,11 +36,16 @@ func (m *Manager) BroadcastMessageUpdate(conversationUUID, messageUUID, prop str
 			"value":             value,
 		},
 	}
-	m.broadcastToUsers([]int{}, message)
+	m.broadcastToConversation(conversationUUID, message)
 }
 
-// BroadcastConversationUpdate broadcasts a conversation update to all users.
+// BroadcastConversationUpdate broadcasts a conversation update to the users allowed to view the conversation.
+// Assignment updates are also sent to the users that could view it before, so they can drop it from their lists.
+// Updates of the properties counted by status clear the cached status counts, clients refetch the counts on them.
 func (m *Manager) BroadcastConversationUpdate(conversationUUID, prop string, value any) {
+	if statusCountProps[prop] {
+		m.statusCounts.clear()
+	}
 	message := wsmodels.Message{
 		Type: wsmodels.MessageTypeConversationPropertyUpdate,
 		Data: map[string]interface{}{
@@ -48,7 +54,39 @@ func (m *Manager) BroadcastConversationUpdate(conversationUUID, prop string, val
 			"value": value,
 		},
 	}
-	m.broadcastToUsers([]int{}, message)
+	if !assignmentProps[prop] {
+		m.broadcastToConversation(conversationUUID, message)
+		return
+	}
+
+	prev := m.wsHub.GetConversationSubscribers(conversationUUID)
+	m.wsHub.InvalidateConversationAccess(conversationUUID)
+	users := m.wsHub.GetConversationSubscribers(conversationUUID)
+	for _, id := range prev {
+		if !slices.Contains(users, id) {
+			users = append(users, id)
+		}
+	}
+	if len(users) > 0 {
+		m.broadcastToUsers(users, message)
+	}
+}
+
+// assignmentProps are the conversation properties that change the users allowed to view it.
+var assignmentProps = map[string]bool{
+	"assigned_user_id":  true,
+	"assigned_team_id":  true,
+	"assigned_queue_id": true,
+}
+
+// broadcastToConversation broadcasts a message to the users allowed to view the conversation.
+func (m *Manager) broadcastToConversation(conversationUUID string, message wsmodels.Message) {
+	messageBytes, err := json.Marshal(message)
+	if err != nil {
+		m.lo.Error("error marshalling WS message", "error", err)
+		return
+	}
+	m.wsHub.BroadcastToConversationSubscribers(conversationUUID, messageBytes)
 }
 
 // broadcastToUsers broadcasts a message to a list of users, if the list is empty it broadcasts to all users.
@@ -2,14 +2,22 @@
 package csat
 
 import (
+	"crypto/subtle"
 	"database/sql"
 	"embed"
 	"errors"
 	"fmt"
+	"net/url"
+	"slices"
+	"time"
 
+	cmodels "github.com/abhinavxd/libredesk/internal/conversation/models"
 	"github.com/abhinavxd/libredesk/internal/csat/models"
 	"github.com/abhinavxd/libredesk/internal/dbutil"
 	"github.com/abhinavxd/libredesk/internal/envelope"
+	imodels "github.com/abhinavxd/libredesk/internal/inbox/models"
+	"github.com/abhinavxd/libredesk/internal/stringutil"
+	tmodels "github.com/abhinavxd/libredesk/internal/team/models"
 	"github.com/jmoiron/sqlx"
 	"github.com/knadh/go-i18n"
 	"github.com/zerodha/logf"
@@ -22,28 +30,61 @@ var (
 )
 
 const (
-	csatURL = "%s/csat/%s"
+	csatURL     = "%s/csat/%s?token=%s"
+	tokenLength = 32
+
+	defaultPageSize = 50
+	maxPageSize     = 100
 )
 
+type inboxStore interface {
+	GetDBRecord(id int) (imodels.Inbox, error)
+}
+
+type teamStore interface {
+	Get(id int) (tmodels.Team, error)
+}
+
 // Manager manages CSAT.
 type Manager struct {
-	q    queries
-	lo   *logf.Logger
-	i18n *i18n.I18n
+	q              queries
+	inboxStore     inboxStore
+	teamStore      teamStore
+	sendOnStatuses []string
+	questions      []models.Question
+	submitLimiter  *submitLimiter
+	moderator      Moderator
+	lo             *logf.Logger
+	i18n           *i18n.I18n
 }
 
 // Opts contains options for initializing the Manager.
 type Opts struct {
-	DB   *sqlx.DB
-	Lo   *logf.Logger
-	I18n *i18n.I18n
+	DB         *sqlx.DB
+	InboxStore inboxStore
+	TeamStore  teamStore
+	// SendOnStatuses are the conversation statuses that trigger a survey, empty disables the automatic surveys.
+	SendOnStatuses []string
+	// Questions are the questions of the survey, rated from 1 to 5. Empty asks DefaultQuestions.
+	Questions []models.Question
+	// SubmitRateLimit is the number of survey submissions allowed per IP every SubmitRateWindow, 0 disables the limit.
+	SubmitRateLimit  int
+	SubmitRateWindow time.Duration
+	// Moderator flags submitted feedback for review, nil skips moderation.
+	Moderator Moderator
+	Lo        *logf.Logger
+	I18n      *i18n.I18n
 }
 
 // queries contains prepared SQL queries.
 type queries struct {
-	Insert *sqlx.Stmt `query:"insert"`
-	Get    *sqlx.Stmt `query:"get"`
-	Update *sqlx.Stmt `query:"update"`
+	Insert                *sqlx.Stmt `query:"insert"`
+	Get                   *sqlx.Stmt `query:"get"`
+	Update                *sqlx.Stmt `query:"update"`
+	ExistsForConversation *sqlx.Stmt `query:"exists-for-conversation"`
+	HasAgentReply         *sqlx.Stmt `query:"has-agent-reply"`
+	GetResponses          *sqlx.Stmt `query:"get-responses"`
+	GetStats              *sqlx.Stmt `query:"get-stats"`
 }
 
 // New creates and returns a new instance of the Manager.
@@ -52,20 +93,81 @@ func New(opts Opts) (*Manager, error) {
 	if err := dbutil.ScanSQLFile("queries.sql", &q, opts.DB, efs); err != nil {
 		return nil, err
 	}
+	questions := opts.Questions
+	if len(questions) == 0 {
+		questions = models.DefaultQuestions
+	}
 	return &Manager{
-		q:    q,
-		lo:   opts.Lo,
-		i18n: opts.I18n,
+		q:              q,
+		inboxStore:     opts.InboxStore,
+		teamStore:      opts.TeamStore,
+		sendOnStatuses: opts.SendOnStatuses,
+		questions:      questions,
+		submitLimiter:  newSubmitLimiter(opts.SubmitRateLimit, opts.SubmitRateWindow),
+		moderator:      opts.Moderator,
+		lo:             opts.Lo,
+		i18n:           opts.I18n,
 	}, nil
 }
 
-// Create creates a new CSAT for the given conversation ID.
+// ShouldSend returns true if a survey should be sent for the conversation moving to the status. Surveys are sent
+// once per conversation, only for the configured statuses, when both the inbox and the assigned team have them
+// enabled and an agent has replied to the contact.
+func (m *Manager) ShouldSend(conversation cmodels.Conversation, status string) (bool, error) {
+	if !slices.Contains(m.sendOnStatuses, status) {
+		return false, nil
+	}
+
+	inbox, err := m.inboxStore.GetDBRecord(conversation.InboxID)
+	if err != nil {
+		return false, err
+	}
+	if !inbox.CSATEnabled {
+		return false, nil
+	}
+
+	if conversation.AssignedTeamID.Int > 0 {
+		team, err := m.teamStore.Get(conversation.AssignedTeamID.Int)
+		if err != nil {
+			return false, err
+		}
+		if !team.CSATEnabled {
+			return false, nil
+		}
+	}
+
+	var exists bool
+	if err := m.q.ExistsForConversation.Get(&exists, conversation.ID); err != nil {
+		m.lo.Error("error checking existing CSAT", "conversation_id", conversation.ID, "error", err)
+		return false, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.csatSurvey}"), nil)
+	}
+	if exists {
+		return false, nil
+	}
+
+	var replied bool
+	if err := m.q.HasAgentReply.Get(&replied, conversation.ID); err != nil {
+		m.lo.Error("error checking agent reply for CSAT", "conversation_id", conversation.ID, "error", err)
+		return false, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.csatSurvey}"), nil)
+	}
+	return replied, nil
+}
+
+// Create creates a new CSAT for the given conversation ID, a conversation has at most one CSAT.
 func (m *Manager) Create(conversationID int) (models.CSATResponse, error) {
 	var (
 		uuid string
 		rsp  models.CSATResponse
 	)
-	if err := m.q.Insert.QueryRow(conversationID).Scan(&uuid); err != nil {
+	token, err := stringutil.RandomAlphanumeric(tokenLength)
+	if err != nil {
+		m.lo.Error("error generating CSAT token", "error", err)
+		return rsp, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorCreating", "name", "{globals.terms.csatSurvey}"), nil)
+	}
+	if err := m.q.Insert.QueryRow(conversationID, token).Scan(&uuid); err != nil {
+		if err == sql.ErrNoRows {
+			return rsp, envelope.NewCodedError(envelope.ConflictError, envelope.ErrCodeDuplicate, m.i18n.Ts("globals.messages.errorAlreadyExists", "name", "{globals.terms.csatSurvey}"), nil)
+		}
 		m.lo.Error("error creating CSAT", "error", err)
 		return rsp, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorCreating", "name", "{globals.terms.csatSurvey}"), nil)
 	}
@@ -78,7 +180,7 @@ func (m *Manager) Get(uuid string) (models.CSATResponse, error) {
 	err := m.q.Get.Get(&csat, uuid)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return csat, envelope.NewError(envelope.InputError, m.i18n.Ts("globals.messages.notFound", "name", "{globals.terms.csatSurvey}"), nil)
+			return csat, envelope.NewCodedError(envelope.InputError, envelope.ErrCodeCSATNotFound, m.i18n.Ts("globals.messages.notFound", "name", "{globals.terms.csatSurvey}"), nil)
 		}
 		m.lo.Error("error getting CSAT", "error", err)
 		return csat, err
@@ -86,18 +188,49 @@ func (m *Manager) Get(uuid string) (models.CSATResponse, error) {
 	return csat, nil
 }
 
-// UpdateResponse updates the CSAT response for the given csat.
-func (m *Manager) UpdateResponse(uuid string, score int, feedback string) error {
+// GetWithToken retrieves the CSAT for the given UUID if the token matches. A wrong token returns the same
+// not found error as a missing CSAT so that the response doesn't reveal which UUIDs exist.
+func (m *Manager) GetWithToken(uuid, token string) (models.CSATResponse, error) {
 	csat, err := m.Get(uuid)
+	if err != nil {
+		return csat, err
+	}
+	// Surveys sent before tokens were added have none, their links have to keep working.
+	if csat.Token.Valid && subtle.ConstantTimeCompare([]byte(csat.Token.String), []byte(token)) != 1 {
+		return models.CSATResponse{}, envelope.NewCodedError(envelope.InputError, envelope.ErrCodeCSATNotFound, m.i18n.Ts("globals.messages.notFound", "name", "{globals.terms.csatSurvey}"), nil)
+	}
+	return csat, nil
+}
+
+// AllowSubmission records a survey submission from the IP and returns false if the IP is over the rate limit.
+func (m *Manager) AllowSubmission(ip string) bool {
+	return m.submitLimiter.allow(ip, time.Now())
+}
+
+// Questions returns the questions of the survey.
+func (m *Manager) Questions() []models.Question {
+	return m.questions
+}
+
+// UpdateResponse updates the CSAT response for the given csat after verifying its token. The score of the first
+// question of the survey is also stored as the single rating of the response, 0 if it wasn't answered.
+func (m *Manager) UpdateResponse(uuid, token string, answers []models.Answer, feedback string) error {
+	csat, err := m.GetWithToken(uuid, token)
 	if err != nil {
 		return err
 	}
 
 	if csat.Score > 0 || !csat.ResponseTimestamp.IsZero() {
-		return envelope.NewError(envelope.InputError, m.i18n.T("csat.alreadySubmitted"), nil)
+		return envelope.NewCodedError(envelope.InputError, envelope.ErrCodeCSATAlreadySubmitted, m.i18n.T("csat.alreadySubmitted"), nil)
 	}
 
-	_, err = m.q.Update.Exec(uuid, score, feedback)
+	valid, err := validateAnswers(m.questions, answers)
+	if err != nil {
+		m.lo.Debug("invalid CSAT answers", "uuid", uuid, "error", err)
+		return envelope.NewError(envelope.InputError, m.i18n.T("csat.invalidAnswers"), nil)
+	}
+
+	_, err = m.q.Update.Exec(uuid, overallRating(m.questions, valid), valid, feedback, m.moderate(feedback))
 	if err != nil {
 		m.lo.Error("error updating CSAT", "error", err)
 		return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorSaving", "name", "{globals.terms.csatResponse}"), nil)
@@ -105,7 +238,52 @@ func (m *Manager) UpdateResponse(uuid string, score int, feedback string) error
 	return nil
 }
 
-// MakePublicURL returns the public URL for the given CSAT UUID.
-func (m *Manager) MakePublicURL(appBaseURL, uuid string) string {
-	return fmt.Sprintf(csatURL, appBaseURL, uuid)
+// moderate returns true if the feedback should be flagged. Feedback the moderator fails to check is flagged
+// so that it gets reviewed, the raw feedback is stored either way.
+func (m *Manager) moderate(feedback string) bool {
+	if m.moderator == nil || feedback == "" {
+		return false
+	}
+	flagged, err := m.moderator.Flag(feedback)
+	if err != nil {
+		m.lo.Error("error moderating CSAT feedback", "error", err)
+		return true
+	}
+	return flagged
+}
+
+// GetResponses returns the submitted CSAT responses, newest first. flaggedOnly returns only the responses with
+// feedback flagged by moderation.
+func (m *Manager) GetResponses(flaggedOnly bool, page, pageSize int) ([]models.CSATResponse, error) {
+	if pageSize > maxPageSize {
+		return nil, envelope.NewError(envelope.InputError, m.i18n.Ts("globals.messages.pageTooLarge", "max", fmt.Sprintf("%d", maxPageSize)), nil)
+	}
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = defaultPageSize
+	}
+
+	var responses = make([]models.CSATResponse, 0)
+	if err := m.q.GetResponses.Select(&responses, flaggedOnly, pageSize, (page-1)*pageSize); err != nil {
+		m.lo.Error("error fetching CSAT responses", "error", err)
+		return nil, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.csatResponse}"), nil)
+	}
+	return responses, nil
+}
+
+// Stats returns the number of responses and the average score of each question of the survey.
+func (m *Manager) Stats() ([]models.QuestionStats, error) {
+	var stats = make([]models.QuestionStats, 0)
+	if err := m.q.GetStats.Select(&stats); err != nil {
+		m.lo.Error("error fetching CSAT stats", "error", err)
+		return nil, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.csatResponse}"), nil)
+	}
+	return mergeQuestionStats(m.questions, stats), nil
+}
+
+// MakePublicURL returns the public URL of the CSAT, including its token.
+func (m *Manager) MakePublicURL(appBaseURL string, csat models.CSATResponse) string {
+	return fmt.Sprintf(csatURL, appBaseURL, csat.UUID, url.QueryEscape(csat.Token.String))
 }
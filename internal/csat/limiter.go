@@ -0,0 +1,66 @@
+package csat
+
+import (
+	"sync"
+	"time"
+)
+
+// submitLimiter limits survey submissions per IP in a fixed window, so the public endpoint can't be used
+// to guess survey tokens.
+type submitLimiter struct {
+	mu        sync.Mutex
+	limit     int
+	window    time.Duration
+	ips       map[string]*submitWindow
+	lastSweep time.Time
+}
+
+// submitWindow is the submission count of an IP in the current window.
+type submitWindow struct {
+	start time.Time
+	count int
+}
+
+// newSubmitLimiter returns a limiter allowing `limit` submissions per IP every `window`, nil if disabled.
+func newSubmitLimiter(limit int, window time.Duration) *submitLimiter {
+	if limit <= 0 || window <= 0 {
+		return nil
+	}
+	return &submitLimiter{
+		limit:  limit,
+		window: window,
+		ips:    make(map[string]*submitWindow),
+	}
+}
+
+// allow records a submission from the IP and returns false if the IP is over the limit. A nil limiter allows everything.
+func (l *submitLimiter) allow(ip string, now time.Time) bool {
+	if l == nil {
+		return true
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.sweep(now)
+
+	w, ok := l.ips[ip]
+	if !ok || now.Sub(w.start) >= l.window {
+		w = &submitWindow{start: now}
+		l.ips[ip] = w
+	}
+	w.count++
+	return w.count <= l.limit
+}
+
+// sweep drops expired windows once per window so the map doesn't grow with every IP ever seen.
+func (l *submitLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < l.window {
+		return
+	}
+	l.lastSweep = now
+	for ip, w := range l.ips {
+		if now.Sub(w.start) >= l.window {
+			delete(l.ips, ip)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package csat
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSubmitLimiter(t *testing.T) {
+	var (
+		now = time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+		l   = newSubmitLimiter(2, time.Minute)
+	)
+
+	for i := 0; i < 2; i++ {
+		if !l.allow("10.0.0.1", now) {
+			t.Fatalf("submission %d should be allowed", i+1)
+		}
+	}
+	if l.allow("10.0.0.1", now.Add(time.Second)) {
+		t.Error("submission over the limit should be denied")
+	}
+	if !l.allow("10.0.0.2", now.Add(time.Second)) {
+		t.Error("other IPs should not be limited")
+	}
+	if !l.allow("10.0.0.1", now.Add(time.Minute)) {
+		t.Error("submission in a new window should be allowed")
+	}
+}
+
+func TestSubmitLimiterDisabled(t *testing.T) {
+	l := newSubmitLimiter(0, time.Minute)
+	if l != nil {
+		t.Fatal("limiter should be nil when disabled")
+	}
+	if !l.allow("10.0.0.1", time.Now()) {
+		t.Error("nil limiter should allow submissions")
+	}
+}
@@ -2,19 +2,100 @@
 package models
 
 import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
 	"time"
 
 	"github.com/volatiletech/null/v9"
 )
 
+// QuestionOverall is the ID of the overall rating question, the only question of surveys before they had
+// multiple questions.
+const QuestionOverall = "overall"
+
+// DefaultQuestions are the questions of a survey when none are configured.
+var DefaultQuestions = []Question{
+	{ID: QuestionOverall, Label: "We would greatly appreciate if you could rate your recent interaction with us to help us improve the quality of our services.", Required: true},
+}
+
+// Question is a question of the survey, rated with a score from 1 to 5.
+type Question struct {
+	ID       string `json:"id"`
+	Label    string `json:"label"`
+	Required bool   `json:"required"`
+}
+
+// Answer is the score given to a question of the survey.
+type Answer struct {
+	QuestionID string `json:"question_id"`
+	Score      int    `json:"score"`
+}
+
+// Answers is the list of answers of a survey response, stored as JSONB.
+type Answers []Answer
+
+// Scan implements the sql.Scanner interface for Answers.
+func (a *Answers) Scan(src interface{}) error {
+	if src == nil {
+		*a = nil
+		return nil
+	}
+
+	switch v := src.(type) {
+	case []byte:
+		return json.Unmarshal(v, a)
+	default:
+		return fmt.Errorf("unsupported type for Answers: %T", src)
+	}
+}
+
+// Value implements the driver.Valuer interface for Answers.
+func (a Answers) Value() (driver.Value, error) {
+	if a == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal(a)
+}
+
+// Score returns the score given to the question, 0 if it wasn't answered.
+func (a Answers) Score(questionID string) int {
+	for _, answer := range a {
+		if answer.QuestionID == questionID {
+			return answer.Score
+		}
+	}
+	return 0
+}
+
+// QuestionStats holds the aggregated scores of a question of the survey.
+type QuestionStats struct {
+	QuestionID   string  `db:"question_id" json:"question_id"`
+	Label        string  `db:"-" json:"label"`
+	Responses    int     `db:"responses" json:"responses"`
+	AverageScore float64 `db:"average_score" json:"average_score"`
+}
+
 // CSATResponse represents a customer satisfaction survey response.
 type CSATResponse struct {
-	ID                int         `db:"id"`
-	UUID              string      `db:"uuid"`
-	CreatedAt         time.Time   `db:"created_at"`
-	UpdatedAt         time.Time   `db:"updated_at"`
-	ConversationID    int         `db:"conversation_id"`
-	Score             int         `db:"rating"`
-	Feedback          null.String `db:"feedback"`
-	ResponseTimestamp null.Time   `db:"response_timestamp"`
+	ID                int         `db:"id" json:"id"`
+	UUID              string      `db:"uuid" json:"uuid"`
+	CreatedAt         time.Time   `db:"created_at" json:"created_at"`
+	UpdatedAt         time.Time   `db:"updated_at" json:"updated_at"`
+	ConversationID    int         `db:"conversation_id" json:"conversation_id"`
+	Score             int         `db:"rating" json:"rating"`
+	Feedback          null.String `db:"feedback" json:"feedback"`
+	ResponseTimestamp null.Time   `db:"response_timestamp" json:"response_timestamp"`
+	// Flagged is set when moderation flagged the feedback, the feedback is kept as submitted.
+	Flagged bool `db:"flagged" json:"flagged"`
+	// Token is the secret in the public survey URL, NULL for surveys created before tokens were required.
+	Token null.String `db:"token" json:"-"`
+	// Answers are the scores given to each question. Score is the score of the first question, kept for
+	// clients reading a single rating.
+	Answers Answers `db:"answers" json:"answers"`
+
+	// Conversation fields, only set when listing responses.
+	ConversationUUID            string `db:"conversation_uuid" json:"conversation_uuid,omitempty"`
+	ConversationReferenceNumber string `db:"conversation_reference_number" json:"conversation_reference_number,omitempty"`
+	Total                       int    `db:"total" json:"-"`
 }
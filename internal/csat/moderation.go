@@ -0,0 +1,49 @@
+package csat
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Moderator flags survey feedback that shouldn't be shown to agents as is, like profanity or personal data.
+type Moderator interface {
+	Flag(feedback string) (bool, error)
+}
+
+// WordFilter is a Moderator that flags feedback containing any of its words or phrases, matched case-insensitively
+// against whole words. Punctuation and spacing between the words of a phrase are ignored.
+type WordFilter struct {
+	phrases []string
+}
+
+// NewWordFilter returns a WordFilter for the words, nil if there are none so that moderation is skipped.
+func NewWordFilter(words []string) *WordFilter {
+	f := &WordFilter{phrases: make([]string, 0, len(words))}
+	for _, w := range words {
+		if p := strings.Join(tokenize(w), " "); p != "" {
+			f.phrases = append(f.phrases, " "+p+" ")
+		}
+	}
+	if len(f.phrases) == 0 {
+		return nil
+	}
+	return f
+}
+
+// Flag returns true if the feedback contains one of the filtered words or phrases.
+func (f *WordFilter) Flag(feedback string) (bool, error) {
+	text := " " + strings.Join(tokenize(feedback), " ") + " "
+	for _, p := range f.phrases {
+		if strings.Contains(text, p) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// tokenize returns the lower-cased words of the text, split at anything other than letters and numbers.
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
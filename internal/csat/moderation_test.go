@@ -0,0 +1,39 @@
+package csat
+
+import "testing"
+
+func TestWordFilter(t *testing.T) {
+	if NewWordFilter([]string{" ", ""}) != nil {
+		t.Fatal("expected nil filter for empty word list")
+	}
+
+	f := NewWordFilter([]string{"Darn", "scam", "waste of  time", "credit-card"})
+	tests := []struct {
+		name     string
+		feedback string
+		expected bool
+	}{
+		{name: "clean", feedback: "Great support, thanks!", expected: false},
+		{name: "match", feedback: "This is a scam.", expected: true},
+		{name: "case insensitive", feedback: "DARN slow replies", expected: true},
+		{name: "whole words only", feedback: "Scampi was delicious", expected: false},
+		{name: "phrase", feedback: "What a waste of time!", expected: true},
+		{name: "phrase across punctuation", feedback: "Total waste, of time.", expected: true},
+		{name: "phrase whole words only", feedback: "No waste of timers here", expected: false},
+		{name: "partial phrase", feedback: "A waste of money", expected: false},
+		{name: "hyphenated entry", feedback: "They asked for my credit card number", expected: true},
+		{name: "empty", feedback: "", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := f.Flag(tt.feedback)
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("got %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
@@ -1,8 +1,10 @@
 -- name: insert
 INSERT INTO csat_responses (
-        conversation_id
+        conversation_id,
+        token
     )
-VALUES ($1)
+VALUES ($1, $2)
+ON CONFLICT (conversation_id) DO NOTHING
 RETURNING uuid;
 
 -- name: get
@@ -12,14 +14,64 @@ SELECT id,
     updated_at,
     conversation_id,
     rating,
+    answers,
     feedback,
-    response_timestamp
+    response_timestamp,
+    token,
+    flagged
 FROM csat_responses
 WHERE uuid = $1;
 
 -- name: update
 UPDATE csat_responses
 SET rating = $2,
-    feedback = $3,
+    answers = $3,
+    feedback = $4,
+    flagged = $5,
     response_timestamp = NOW()
 WHERE uuid = $1;
+
+-- name: exists-for-conversation
+SELECT EXISTS (SELECT 1 FROM csat_responses WHERE conversation_id = $1);
+
+-- name: has-agent-reply
+SELECT EXISTS (
+    SELECT 1
+    FROM conversation_messages
+    WHERE conversation_id = $1
+        AND type = 'outgoing'
+        AND private = false
+        AND sender_type = 'agent'
+        AND COALESCE((meta->>'is_csat')::BOOLEAN, false) = false
+        AND sender_id NOT IN (SELECT id FROM users WHERE type = 'agent' AND email IN ('System', 'Automation'))
+);
+
+-- name: get-responses
+SELECT csat.id,
+    csat.uuid,
+    csat.created_at,
+    csat.updated_at,
+    csat.conversation_id,
+    csat.rating,
+    csat.answers,
+    csat.feedback,
+    csat.response_timestamp,
+    csat.flagged,
+    c.uuid AS conversation_uuid,
+    c.reference_number AS conversation_reference_number,
+    COUNT(*) OVER() AS total
+FROM csat_responses csat
+JOIN conversations c ON c.id = csat.conversation_id
+WHERE csat.response_timestamp IS NOT NULL
+    AND ($1 = false OR csat.flagged = true)
+ORDER BY csat.response_timestamp DESC, csat.id DESC
+LIMIT $2 OFFSET $3;
+
+-- name: get-stats
+SELECT a->>'question_id' AS question_id,
+    COUNT(*) AS responses,
+    ROUND(AVG((a->>'score')::INT), 2)::FLOAT AS average_score
+FROM csat_responses csat
+CROSS JOIN LATERAL jsonb_array_elements(csat.answers) a
+WHERE csat.response_timestamp IS NOT NULL
+GROUP BY a->>'question_id';
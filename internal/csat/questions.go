@@ -0,0 +1,94 @@
+package csat
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/abhinavxd/libredesk/internal/csat/models"
+)
+
+const (
+	minScore = 1
+	maxScore = 5
+)
+
+var (
+	errUnknownQuestion = errors.New("unknown question")
+	errDuplicateAnswer = errors.New("question answered more than once")
+	errInvalidScore    = errors.New("invalid score")
+	errMissingAnswer   = errors.New("required question not answered")
+	errNoAnswers       = errors.New("no question answered")
+)
+
+// validateAnswers validates the answers against the questions of the survey and returns them in the order of the
+// questions. Every answer must be for a known question with a score from 1 to 5, required questions must be answered
+// and at least one question has to be.
+func validateAnswers(questions []models.Question, answers []models.Answer) (models.Answers, error) {
+	byQuestion := make(map[string]models.Answer, len(answers))
+	for _, answer := range answers {
+		if _, ok := byQuestion[answer.QuestionID]; ok {
+			return nil, fmt.Errorf("%w: %s", errDuplicateAnswer, answer.QuestionID)
+		}
+		byQuestion[answer.QuestionID] = answer
+	}
+
+	var out = make(models.Answers, 0, len(answers))
+	for _, q := range questions {
+		answer, ok := byQuestion[q.ID]
+		if !ok {
+			if q.Required {
+				return nil, fmt.Errorf("%w: %s", errMissingAnswer, q.ID)
+			}
+			continue
+		}
+		if answer.Score < minScore || answer.Score > maxScore {
+			return nil, fmt.Errorf("%w for question %s: %d", errInvalidScore, q.ID, answer.Score)
+		}
+		out = append(out, answer)
+		delete(byQuestion, q.ID)
+	}
+	for _, answer := range answers {
+		if _, ok := byQuestion[answer.QuestionID]; ok {
+			return nil, fmt.Errorf("%w: %s", errUnknownQuestion, answer.QuestionID)
+		}
+	}
+	if len(out) == 0 {
+		return nil, errNoAnswers
+	}
+	return out, nil
+}
+
+// overallRating returns the score given to the first question of the survey, 0 if it wasn't answered.
+func overallRating(questions []models.Question, answers models.Answers) int {
+	if len(questions) == 0 {
+		return 0
+	}
+	return answers.Score(questions[0].ID)
+}
+
+// mergeQuestionStats returns the stats of every question of the survey in order, questions without responses
+// included. Stats of questions no longer asked are kept at the end, labelled with their ID.
+func mergeQuestionStats(questions []models.Question, stats []models.QuestionStats) []models.QuestionStats {
+	byQuestion := make(map[string]models.QuestionStats, len(stats))
+	for _, s := range stats {
+		byQuestion[s.QuestionID] = s
+	}
+
+	var out = make([]models.QuestionStats, 0, len(questions)+len(stats))
+	for _, q := range questions {
+		s, ok := byQuestion[q.ID]
+		if !ok {
+			s = models.QuestionStats{QuestionID: q.ID}
+		}
+		s.Label = q.Label
+		out = append(out, s)
+		delete(byQuestion, q.ID)
+	}
+	for _, s := range stats {
+		if _, ok := byQuestion[s.QuestionID]; ok {
+			s.Label = s.QuestionID
+			out = append(out, s)
+		}
+	}
+	return out
+}
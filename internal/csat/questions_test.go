@@ -0,0 +1,114 @@
+package csat
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/abhinavxd/libredesk/internal/csat/models"
+)
+
+func TestValidateAnswers(t *testing.T) {
+	questions := []models.Question{
+		{ID: "overall", Required: true},
+		{ID: "resolution_speed"},
+		{ID: "agent_friendliness"},
+	}
+
+	tests := []struct {
+		name     string
+		answers  []models.Answer
+		expected []string
+		err      error
+	}{
+		{
+			name:     "ordered by question",
+			answers:  []models.Answer{{QuestionID: "agent_friendliness", Score: 4}, {QuestionID: "overall", Score: 5}},
+			expected: []string{"overall", "agent_friendliness"},
+		},
+		{name: "required question missing", answers: []models.Answer{{QuestionID: "resolution_speed", Score: 3}}, err: errMissingAnswer},
+		{name: "score too low", answers: []models.Answer{{QuestionID: "overall", Score: 0}}, err: errInvalidScore},
+		{name: "score too high", answers: []models.Answer{{QuestionID: "overall", Score: 6}}, err: errInvalidScore},
+		{name: "unknown question", answers: []models.Answer{{QuestionID: "overall", Score: 5}, {QuestionID: "price", Score: 1}}, err: errUnknownQuestion},
+		{name: "duplicate answer", answers: []models.Answer{{QuestionID: "overall", Score: 5}, {QuestionID: "overall", Score: 1}}, err: errDuplicateAnswer},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := validateAnswers(questions, tt.answers)
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("got error %v, want %v", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if len(got) != len(tt.expected) {
+				t.Fatalf("got %d answers, want %d", len(got), len(tt.expected))
+			}
+			for i := range got {
+				if got[i].QuestionID != tt.expected[i] {
+					t.Errorf("at index %d got question %s, want %s", i, got[i].QuestionID, tt.expected[i])
+				}
+			}
+		})
+	}
+
+	if _, err := validateAnswers([]models.Question{{ID: "overall"}}, nil); !errors.Is(err, errNoAnswers) {
+		t.Errorf("got error %v, want %v", err, errNoAnswers)
+	}
+}
+
+func TestOverallRating(t *testing.T) {
+	questions := []models.Question{{ID: "overall"}, {ID: "resolution_speed"}}
+
+	tests := []struct {
+		name     string
+		answers  models.Answers
+		expected int
+	}{
+		{name: "first question answered", answers: models.Answers{{QuestionID: "overall", Score: 4}, {QuestionID: "resolution_speed", Score: 2}}, expected: 4},
+		{name: "first question not answered", answers: models.Answers{{QuestionID: "resolution_speed", Score: 2}}, expected: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := overallRating(questions, tt.answers); got != tt.expected {
+				t.Errorf("got rating %d, want %d", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestMergeQuestionStats(t *testing.T) {
+	questions := []models.Question{{ID: "overall", Label: "Overall"}, {ID: "resolution_speed", Label: "Speed"}}
+	stats := []models.QuestionStats{
+		{QuestionID: "retired", Responses: 2, AverageScore: 3},
+		{QuestionID: "overall", Responses: 10, AverageScore: 4.5},
+	}
+
+	got := mergeQuestionStats(questions, stats)
+	expected := []models.QuestionStats{
+		{QuestionID: "overall", Label: "Overall", Responses: 10, AverageScore: 4.5},
+		{QuestionID: "resolution_speed", Label: "Speed"},
+		{QuestionID: "retired", Label: "retired", Responses: 2, AverageScore: 3},
+	}
+	if len(got) != len(expected) {
+		t.Fatalf("got %d stats, want %d", len(got), len(expected))
+	}
+	for i := range got {
+		if got[i] != expected[i] {
+			t.Errorf("at index %d got %+v, want %+v", i, got[i], expected[i])
+		}
+	}
+}
+
+func TestAnswersScore(t *testing.T) {
+	answers := models.Answers{{QuestionID: "overall", Score: 4}}
+	if got := answers.Score("overall"); got != 4 {
+		t.Errorf("got %d, want 4", got)
+	}
+	if got := answers.Score("resolution_speed"); got != 0 {
+		t.Errorf("got %d, want 0", got)
+	}
+}
@@ -6,6 +6,18 @@ import (
 	"github.com/lib/pq"
 )
 
+const (
+	AppliesToContact      = "contact"
+	AppliesToConversation = "conversation"
+
+	DataTypeText     = "text"
+	DataTypeNumber   = "number"
+	DataTypeCheckbox = "checkbox"
+	DataTypeDate     = "date"
+	DataTypeLink     = "link"
+	DataTypeList     = "list"
+)
+
 type CustomAttribute struct {
 	ID          int            `db:"id" json:"id"`
 	CreatedAt   time.Time      `db:"created_at" json:"created_at"`
@@ -18,4 +30,5 @@ type CustomAttribute struct {
 	DataType    string         `db:"data_type" json:"data_type"`
 	Regex       string         `db:"regex" json:"regex"`
 	RegexHint   string         `db:"regex_hint" json:"regex_hint"`
+	Required    bool           `db:"required" json:"required"`
 }
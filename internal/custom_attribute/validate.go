@@ -0,0 +1,176 @@
+package customAttribute
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/abhinavxd/libredesk/internal/custom_attribute/models"
+	"github.com/abhinavxd/libredesk/internal/envelope"
+)
+
+var (
+	errRequired      = errors.New("value is required")
+	errInvalidType   = errors.New("invalid value type")
+	errInvalidOption = errors.New("value is not one of the list options")
+	errRegexMismatch = errors.New("value does not match the regex")
+)
+
+// dateLayouts are the accepted layouts for date attributes, the date input in the UI sends the first one.
+var dateLayouts = []string{"2006-01-02", time.RFC3339}
+
+// ValidateValues validates custom attribute values against the definitions of the passed `appliesTo`.
+// Required attributes must be present and non-empty, values for keys without a definition are left as is.
+func (m *Manager) ValidateValues(appliesTo string, values map[string]any) error {
+	definitions, err := m.GetAll(appliesTo)
+	if err != nil {
+		return err
+	}
+	for _, def := range definitions {
+		value, ok := values[def.Key]
+		if !ok && def.Required {
+			return envelope.NewError(envelope.InputError, m.i18n.Ts("globals.messages.fieldRequired", "name", def.Name), nil)
+		}
+		if !ok {
+			continue
+		}
+		if err := validateValue(def, value); err != nil {
+			return m.validationError(def, err)
+		}
+	}
+	return nil
+}
+
+// ParseValue converts the string value of a custom attribute to the type of its definition and validates it.
+// Used when the value comes from a string source like an automation rule action.
+func (m *Manager) ParseValue(appliesTo, key, value string) (any, error) {
+	definitions, err := m.GetAll(appliesTo)
+	if err != nil {
+		return nil, err
+	}
+	idx := slices.IndexFunc(definitions, func(def models.CustomAttribute) bool { return def.Key == key })
+	if idx < 0 {
+		return nil, envelope.NewError(envelope.InputError, m.i18n.Ts("globals.messages.notFound", "name", key), nil)
+	}
+	def := definitions[idx]
+	parsed, err := parseValue(def, value)
+	if err == nil {
+		err = validateValue(def, parsed)
+	}
+	if err != nil {
+		return nil, m.validationError(def, err)
+	}
+	return parsed, nil
+}
+
+// validationError converts a validation error to an envelope error.
+func (m *Manager) validationError(def models.CustomAttribute, err error) error {
+	switch {
+	case errors.Is(err, errRequired):
+		return envelope.NewError(envelope.InputError, m.i18n.Ts("globals.messages.fieldRequired", "name", def.Name), nil)
+	case errors.Is(err, errRegexMismatch) && def.RegexHint != "":
+		return envelope.NewError(envelope.InputError, def.RegexHint, nil)
+	}
+	m.lo.Debug("invalid custom attribute value", "key", def.Key, "error", err)
+	return envelope.NewError(envelope.InputError, m.i18n.Ts("globals.messages.invalid", "name", def.Name), nil)
+}
+
+// parseValue converts a string value to the type of the attribute definition, empty string is treated as no value.
+func parseValue(def models.CustomAttribute, value string) (any, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, nil
+	}
+	switch def.DataType {
+	case models.DataTypeNumber:
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, errInvalidType
+		}
+		return n, nil
+	case models.DataTypeCheckbox:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, errInvalidType
+		}
+		return b, nil
+	}
+	return value, nil
+}
+
+// validateValue validates a single value against the attribute definition, nil and empty string are treated as no value.
+func validateValue(def models.CustomAttribute, value any) error {
+	if value == nil || value == "" {
+		if def.Required {
+			return errRequired
+		}
+		return nil
+	}
+
+	switch def.DataType {
+	case models.DataTypeNumber:
+		switch v := value.(type) {
+		case float64, int:
+		case string:
+			// The number input in the UI sends the value as a string.
+			if _, err := strconv.ParseFloat(v, 64); err != nil {
+				return errInvalidType
+			}
+		default:
+			return errInvalidType
+		}
+	case models.DataTypeCheckbox:
+		if _, ok := value.(bool); !ok {
+			return errInvalidType
+		}
+	case models.DataTypeDate:
+		v, ok := value.(string)
+		if !ok {
+			return errInvalidType
+		}
+		for _, layout := range dateLayouts {
+			if _, err := time.Parse(layout, v); err == nil {
+				return nil
+			}
+		}
+		return errInvalidType
+	case models.DataTypeLink:
+		v, ok := value.(string)
+		if !ok {
+			return errInvalidType
+		}
+		u, err := url.ParseRequestURI(v)
+		if err != nil || u.Host == "" {
+			return errInvalidType
+		}
+	case models.DataTypeList:
+		v, ok := value.(string)
+		if !ok {
+			return errInvalidType
+		}
+		if !slices.Contains(def.Values, v) {
+			return errInvalidOption
+		}
+	case models.DataTypeText:
+		v, ok := value.(string)
+		if !ok {
+			return errInvalidType
+		}
+		if def.Regex == "" {
+			return nil
+		}
+		re, err := regexp.Compile(def.Regex)
+		if err != nil {
+			return fmt.Errorf("invalid regex %q: %w", def.Regex, err)
+		}
+		if !re.MatchString(v) {
+			return errRegexMismatch
+		}
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package customAttribute
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/abhinavxd/libredesk/internal/custom_attribute/models"
+)
+
+func TestValidateValue(t *testing.T) {
+	tests := []struct {
+		name    string
+		def     models.CustomAttribute
+		value   any
+		wantErr error
+	}{
+		{name: "empty optional", def: models.CustomAttribute{DataType: models.DataTypeText}, value: nil},
+		{name: "empty required", def: models.CustomAttribute{DataType: models.DataTypeText, Required: true}, value: "", wantErr: errRequired},
+		{name: "text regex match", def: models.CustomAttribute{DataType: models.DataTypeText, Regex: `^ORD-\d+$`}, value: "ORD-123"},
+		{name: "text regex mismatch", def: models.CustomAttribute{DataType: models.DataTypeText, Regex: `^ORD-\d+$`}, value: "123", wantErr: errRegexMismatch},
+		{name: "number", def: models.CustomAttribute{DataType: models.DataTypeNumber}, value: float64(4)},
+		{name: "number as string", def: models.CustomAttribute{DataType: models.DataTypeNumber}, value: "4.5"},
+		{name: "invalid number", def: models.CustomAttribute{DataType: models.DataTypeNumber}, value: "four", wantErr: errInvalidType},
+		{name: "checkbox", def: models.CustomAttribute{DataType: models.DataTypeCheckbox}, value: true},
+		{name: "invalid checkbox", def: models.CustomAttribute{DataType: models.DataTypeCheckbox}, value: "yes", wantErr: errInvalidType},
+		{name: "date", def: models.CustomAttribute{DataType: models.DataTypeDate}, value: "2025-01-31"},
+		{name: "invalid date", def: models.CustomAttribute{DataType: models.DataTypeDate}, value: "31/01/2025", wantErr: errInvalidType},
+		{name: "link", def: models.CustomAttribute{DataType: models.DataTypeLink}, value: "https://example.com/orders/1"},
+		{name: "invalid link", def: models.CustomAttribute{DataType: models.DataTypeLink}, value: "example", wantErr: errInvalidType},
+		{name: "list option", def: models.CustomAttribute{DataType: models.DataTypeList, Values: []string{"a", "b"}}, value: "b"},
+		{name: "invalid list option", def: models.CustomAttribute{DataType: models.DataTypeList, Values: []string{"a", "b"}}, value: "c", wantErr: errInvalidOption},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateValue(tt.def, tt.value)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("got error %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseValue(t *testing.T) {
+	tests := []struct {
+		name     string
+		dataType string
+		value    string
+		expected any
+		wantErr  bool
+	}{
+		{name: "empty", dataType: models.DataTypeText, value: " ", expected: nil},
+		{name: "text", dataType: models.DataTypeText, value: "abc", expected: "abc"},
+		{name: "number", dataType: models.DataTypeNumber, value: "42", expected: float64(42)},
+		{name: "invalid number", dataType: models.DataTypeNumber, value: "x", wantErr: true},
+		{name: "checkbox", dataType: models.DataTypeCheckbox, value: "true", expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseValue(models.CustomAttribute{DataType: tt.dataType}, tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.expected {
+				t.Errorf("got %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
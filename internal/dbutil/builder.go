@@ -41,26 +41,11 @@ func BuildPaginatedQuery(baseQuery string, existingArgs []any, opts PaginationOp
 		return "", nil, fmt.Errorf("invalid page size: %d", opts.PageSize)
 	}
 
-	var filters []Filter
-	if filtersJSON != "" {
-		if err := json.Unmarshal([]byte(filtersJSON), &filters); err != nil {
-			return "", nil, fmt.Errorf("invalid filters JSON: %w", err)
-		}
-	}
-
-	whereClause, filterArgs, err := buildWhereClause(filters, existingArgs, allowedFields)
+	query, args, err := BuildFilteredQuery(baseQuery, existingArgs, filtersJSON, allowedFields)
 	if err != nil {
 		return "", nil, err
 	}
 
-	query := baseQuery
-	args := existingArgs
-
-	if whereClause != "" {
-		query += " AND " + whereClause
-		args = append(args, filterArgs...)
-	}
-
 	if opts.OrderBy != "" {
 		// Validate OrderBy.
 		parts := strings.Split(opts.OrderBy, ".")
@@ -88,6 +73,29 @@ func BuildPaginatedQuery(baseQuery string, existingArgs []any, opts PaginationOp
 	return query, args, nil
 }
 
+// BuildFilteredQuery appends the conditions of the filters JSON to the given base query, which must end in a WHERE clause.
+func BuildFilteredQuery(baseQuery string, existingArgs []any, filtersJSON string, allowedFields AllowedFields) (string, []any, error) {
+	var filters []Filter
+	if filtersJSON != "" {
+		if err := json.Unmarshal([]byte(filtersJSON), &filters); err != nil {
+			return "", nil, fmt.Errorf("invalid filters JSON: %w", err)
+		}
+	}
+
+	whereClause, filterArgs, err := buildWhereClause(filters, existingArgs, allowedFields)
+	if err != nil {
+		return "", nil, err
+	}
+
+	query := baseQuery
+	args := existingArgs
+	if whereClause != "" {
+		query += " AND " + whereClause
+		args = append(args, filterArgs...)
+	}
+	return query, args, nil
+}
+
 // buildWhereClause builds a WHERE clause from the given filters and returns the WHERE clause and the arguments to be passed to the query.
 func buildWhereClause(filters []Filter, existingArgs []interface{}, allowedFields AllowedFields) (string, []interface{}, error) {
 	conditions := []string{}
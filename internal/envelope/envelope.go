@@ -19,10 +19,24 @@ const (
 	UnauthorizedError = "UnauthorizedException"
 )
 
+// Error codes identify specific errors within an error type, so clients can react to them and localize their
+// messages. They are sent as `error_code` in the error envelope.
+const (
+	ErrCodeConversationNotFound = "conversation_not_found"
+	ErrCodeMessageNotFound      = "message_not_found"
+	ErrCodeInboxNotFound        = "inbox_not_found"
+	ErrCodeCSATNotFound         = "csat_not_found"
+	ErrCodeCSATAlreadySubmitted = "csat_already_submitted"
+	ErrCodeOIDCNotFound         = "oidc_not_found"
+	ErrCodeDuplicate            = "duplicate"
+	ErrCodeRetryTooSoon         = "retry_too_soon"
+)
+
 // Error is the error type used for all API errors.
 type Error struct {
 	Code      int         // HTTP status code.
 	ErrorType string      // Type of the error.
+	ErrorCode string      // Specific error code, empty if the error has none.
 	Message   string      // Error message.
 	Data      interface{} // Additional data related to the error.
 }
@@ -73,6 +87,13 @@ func NewError(etype string, message string, data interface{}) error {
 	return err
 }
 
+// NewCodedError creates and returns a new instance of Error with custom error metadata and an error code.
+func NewCodedError(etype, code string, message string, data interface{}) error {
+	err := NewError(etype, message, data).(Error)
+	err.ErrorCode = code
+	return err
+}
+
 // NewErrorWithCode creates and returns a new instance of Error with custom error metadata and an HTTP status code.
 func NewErrorWithCode(etype string, code int, message string, data interface{}) error {
 	return Error{
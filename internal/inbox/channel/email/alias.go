@@ -0,0 +1,59 @@
+package email
+
+import (
+	"net/mail"
+	"strings"
+)
+
+// Aliases returns the addresses other than the from address the inbox receives and replies with.
+func (e *Email) Aliases() []string {
+	return e.aliases
+}
+
+// ReplyFromAddress returns the address to reply from to a contact that wrote to `recipients`: the first recipient
+// that's the from address of the inbox or one of its aliases, plus-addressed variants included, with the display name
+// of the from address. The from address is returned if no recipient matches, e.g. the alias was removed since.
+func (e *Email) ReplyFromAddress(recipients []string) string {
+	from, err := mail.ParseAddress(e.from)
+	if err != nil {
+		return e.from
+	}
+	own := append([]string{from.Address}, e.aliases...)
+	for _, rcpt := range recipients {
+		addr := bareAddress(rcpt)
+		if addr == "" || !isOwnAddress(addr, own) {
+			continue
+		}
+		if strings.EqualFold(addr, from.Address) {
+			return e.from
+		}
+		return (&mail.Address{Name: from.Name, Address: addr}).String()
+	}
+	return e.from
+}
+
+// isOwnAddress returns true if the address is one of `own` addresses, or a plus-addressed variant of one,
+// e.g. `support+billing@example.com` for `support@example.com`.
+func isOwnAddress(addr string, own []string) bool {
+	local, domain, ok := strings.Cut(addr, "@")
+	if !ok {
+		return false
+	}
+	base, _, _ := strings.Cut(local, "+")
+	for _, o := range own {
+		o = bareAddress(o)
+		if strings.EqualFold(addr, o) || strings.EqualFold(base+"@"+domain, o) {
+			return true
+		}
+	}
+	return false
+}
+
+// bareAddress returns the email address of `addr`, which may include a display name.
+func bareAddress(addr string) string {
+	addr = strings.TrimSpace(addr)
+	if a, err := mail.ParseAddress(addr); err == nil {
+		return a.Address
+	}
+	return addr
+}
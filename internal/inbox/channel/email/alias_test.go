@@ -0,0 +1,32 @@
+package email
+
+import "testing"
+
+func TestReplyFromAddress(t *testing.T) {
+	e := &Email{
+		from:    "Support <support@example.com>",
+		aliases: []string{"help@example.com", "Billing <billing@example.org>"},
+	}
+	tests := []struct {
+		name       string
+		recipients []string
+		expected   string
+	}{
+		{name: "no recipients", expected: "Support <support@example.com>"},
+		{name: "from address", recipients: []string{"support@example.com"}, expected: "Support <support@example.com>"},
+		{name: "alias", recipients: []string{"someone@example.net", "Help <HELP@example.com>"}, expected: `"Support" <HELP@example.com>`},
+		{name: "alias with display name", recipients: []string{"billing@example.org"}, expected: `"Support" <billing@example.org>`},
+		{name: "plus-addressed from address", recipients: []string{"support+orders@example.com"}, expected: `"Support" <support+orders@example.com>`},
+		{name: "plus-addressed alias", recipients: []string{"help+vip@example.com"}, expected: `"Support" <help+vip@example.com>`},
+		{name: "removed alias", recipients: []string{"sales@example.com"}, expected: "Support <support@example.com>"},
+		{name: "other domain", recipients: []string{"support@example.net"}, expected: "Support <support@example.com>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := e.ReplyFromAddress(tt.recipients); got != tt.expected {
+				t.Errorf("got %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
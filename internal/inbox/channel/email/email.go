@@ -3,10 +3,14 @@ package email
 
 import (
 	"context"
+	"fmt"
 	"sync"
+	"time"
 
 	"github.com/abhinavxd/libredesk/internal/inbox"
+	imodels "github.com/abhinavxd/libredesk/internal/inbox/models"
 	"github.com/knadh/smtppool"
+	"github.com/volatiletech/null/v9"
 	"github.com/zerodha/logf"
 )
 
@@ -16,9 +20,19 @@ const (
 
 // Config holds the email inbox configuration with multiple SMTP servers and IMAP clients.
 type Config struct {
-	SMTP []SMTPConfig `json:"smtp"`
-	IMAP []IMAPConfig `json:"imap"`
-	From string       `json:"from"`
+	SMTP      []SMTPConfig      `json:"smtp"`
+	IMAP      []IMAPConfig      `json:"imap"`
+	From      string            `json:"from"`
+	RateLimit imodels.RateLimit `json:"rate_limit"`
+	// Aliases are the other addresses the inbox receives on, replies are sent from the one the contact wrote to.
+	Aliases []string `json:"aliases"`
+	// Footer is appended to all outgoing emails of the inbox.
+	Footer imodels.Footer `json:"footer"`
+	// PlainTextOnly sends all outgoing emails as plain text, without the HTML template.
+	PlainTextOnly bool `json:"plain_text_only"`
+	// DeliveryReports returns the delivery status notifications of outgoing emails to the inbox address, they
+	// update the status of the messages they report on instead of creating conversations.
+	DeliveryReports bool `json:"delivery_reports"`
 }
 
 // SMTPConfig represents an SMTP server's credentials with the smtppool options.
@@ -49,13 +63,31 @@ type IMAPConfig struct {
 type Email struct {
 	id           int
 	smtpPools    []*smtppool.Pool
+	streamPools  []*streamPool
 	imapCfg      []IMAPConfig
 	headers      map[string]string
 	lo           *logf.Logger
 	from         string
+	aliases      []string
+	rateLimit    imodels.RateLimit
+	footer       imodels.Footer
+	plainText    bool
+	dsn          bool
 	messageStore inbox.MessageStore
 	userStore    inbox.UserStore
 	wg           sync.WaitGroup
+
+	// Result of the last poll of each IMAP mailbox, keyed by mailbox address.
+	pollMu      sync.Mutex
+	pollResults map[string]pollResult
+}
+
+// pollResult holds the result of the last poll of an IMAP mailbox.
+type pollResult struct {
+	lastPollAt  time.Time
+	lastError   string
+	lastErrorAt time.Time
+	failing     bool
 }
 
 // Opts holds the options required for the email inbox.
@@ -72,15 +104,30 @@ func New(store inbox.MessageStore, userStore inbox.UserStore, opts Opts) (*Email
 	if err != nil {
 		return nil, err
 	}
+	streamPools := make([]*streamPool, 0, len(opts.Config.SMTP))
+	for _, cfg := range opts.Config.SMTP {
+		opt, err := newSMTPOpt(cfg)
+		if err != nil {
+			return nil, err
+		}
+		streamPools = append(streamPools, newStreamPool(opt))
+	}
 	e := &Email{
 		id:           opts.ID,
 		headers:      opts.Headers,
 		from:         opts.Config.From,
+		aliases:      opts.Config.Aliases,
 		imapCfg:      opts.Config.IMAP,
+		rateLimit:    opts.Config.RateLimit,
+		footer:       opts.Config.Footer,
+		plainText:    opts.Config.PlainTextOnly,
+		dsn:          opts.Config.DeliveryReports,
 		lo:           opts.Lo,
 		smtpPools:    pools,
+		streamPools:  streamPools,
 		messageStore: store,
 		userStore:    userStore,
+		pollResults:  make(map[string]pollResult),
 	}
 	return e, nil
 }
@@ -120,10 +167,87 @@ func (e *Email) Channel() string {
 	return ChannelEmail
 }
 
+// RateLimit returns the outgoing message rate limit of the inbox, shared by all of its SMTP servers.
+func (e *Email) RateLimit() imodels.RateLimit {
+	return e.rateLimit
+}
+
+// Footer returns the footer appended to the outgoing emails of the inbox.
+func (e *Email) Footer() imodels.Footer {
+	return e.footer
+}
+
+// PlainTextOnly returns true if the outgoing emails of the inbox are sent as plain text.
+func (e *Email) PlainTextOnly() bool {
+	return e.plainText
+}
+
+// HealthCheck returns the connectivity health of the inbox based on the last poll of each IMAP mailbox.
+// The inbox is degraded if any mailbox failed its last poll and down if all of them did.
+func (e *Email) HealthCheck() imodels.Health {
+	e.pollMu.Lock()
+	defer e.pollMu.Unlock()
+
+	health := imodels.Health{
+		InboxID: e.id,
+		Channel: ChannelEmail,
+		Status:  imodels.HealthStatusUnknown,
+	}
+	if len(e.pollResults) == 0 {
+		return health
+	}
+
+	var failing int
+	for _, res := range e.pollResults {
+		if !res.lastPollAt.IsZero() && res.lastPollAt.After(health.LastPollAt.Time) {
+			health.LastPollAt = null.TimeFrom(res.lastPollAt)
+		}
+		if !res.failing {
+			continue
+		}
+		failing++
+		if res.lastErrorAt.After(health.LastErrorAt.Time) {
+			health.LastError = res.lastError
+			health.LastErrorAt = null.TimeFrom(res.lastErrorAt)
+		}
+	}
+
+	switch {
+	case failing == 0:
+		health.Status = imodels.HealthStatusHealthy
+	case failing < len(e.pollResults):
+		health.Status = imodels.HealthStatusDegraded
+	default:
+		health.Status = imodels.HealthStatusDown
+	}
+	health.Connected = failing < len(e.pollResults)
+	return health
+}
+
+// recordPoll records the result of polling an IMAP mailbox.
+func (e *Email) recordPoll(cfg IMAPConfig, err error) {
+	e.pollMu.Lock()
+	defer e.pollMu.Unlock()
+
+	key := fmt.Sprintf("%s@%s:%d/%s", cfg.Username, cfg.Host, cfg.Port, cfg.Mailbox)
+	res := e.pollResults[key]
+	res.failing = err != nil
+	if err != nil {
+		res.lastError = err.Error()
+		res.lastErrorAt = time.Now()
+	} else {
+		res.lastPollAt = time.Now()
+	}
+	e.pollResults[key] = res
+}
+
 // closeSMTPPool closes the smtp pool.
 func (e *Email) closeSMTPPool() error {
 	for _, p := range e.smtpPools {
 		p.Close()
 	}
+	for _, p := range e.streamPools {
+		p.close()
+	}
 	return nil
 }
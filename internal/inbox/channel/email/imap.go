@@ -51,9 +51,13 @@ func (e *Email) ReadIncomingMessages(ctx context.Context, cfg IMAPConfig) error
 				return nil
 			}
 
-			if err := e.processMailbox(ctx, scanInboxSince, cfg); err != nil && err != context.Canceled {
+			err := e.processMailbox(ctx, scanInboxSince, cfg)
+			if err != nil && err != context.Canceled {
 				e.lo.Error("error searching emails", "error", err)
 			}
+			if err != context.Canceled {
+				e.recordPoll(cfg, err)
+			}
 			e.lo.Debug("email search complete", "mailbox", cfg.Mailbox, "inbox_id", e.Identifier())
 		}
 	}
@@ -225,7 +229,13 @@ func (e *Email) processEnvelope(ctx context.Context, client *imapclient.Client,
 		Type:            umodels.UserTypeContact,
 	}
 
-	// Set CC addresses in meta.
+	// Set To and CC addresses in meta, replying to all recipients uses them.
+	var toAddr = make([]string, 0, len(env.To))
+	for _, to := range env.To {
+		if to.Addr() != "" {
+			toAddr = append(toAddr, to.Addr())
+		}
+	}
 	var ccAddr = make([]string, 0, len(env.Cc))
 	for _, cc := range env.Cc {
 		if cc.Addr() != "" {
@@ -233,6 +243,7 @@ func (e *Email) processEnvelope(ctx context.Context, client *imapclient.Client,
 		}
 	}
 	meta, err := json.Marshal(map[string]interface{}{
+		"to": toAddr,
 		"cc": ccAddr,
 	})
 	if err != nil {
@@ -359,11 +370,29 @@ func (e *Email) processFullMessage(item imapclient.FetchItemDataBodySection, inc
 	incomingMsg.Message.InReplyTo = inReplyTo
 	incomingMsg.Message.References = references
 
-	// Process attachments
+	// Record the address the message was delivered to, it's the alias the contact wrote to when the inbox
+	// was only Bcc'd or the message was forwarded to it.
+	if originalTo := originalRecipient(envelope); originalTo != "" {
+		var meta map[string]any
+		if err := json.Unmarshal([]byte(incomingMsg.Message.Meta), &meta); err == nil {
+			meta["original_to"] = originalTo
+			if b, err := json.Marshal(meta); err == nil {
+				incomingMsg.Message.Meta = string(b)
+			}
+		}
+	}
+
+	// Delivery status notifications are enqueued with their report, the outgoing message they report on gets its
+	// status updated.
+	if e.dsn {
+		incomingMsg.DeliveryReport = deliveryReport(envelope)
+	}
+
+	// Process attachments, they're read from the decoded parts when uploaded instead of being copied.
 	for _, att := range envelope.Attachments {
 		incomingMsg.Message.Attachments = append(incomingMsg.Message.Attachments, attachment.Attachment{
 			Name:        att.FileName,
-			Content:     att.Content,
+			Open:        attachment.BytesOpener(att.Content),
 			ContentType: att.ContentType,
 			ContentID:   att.ContentID,
 			Size:        len(att.Content),
@@ -380,7 +409,7 @@ func (e *Email) processFullMessage(item imapclient.FetchItemDataBodySection, inc
 
 		incomingMsg.Message.Attachments = append(incomingMsg.Message.Attachments, attachment.Attachment{
 			Name:        inline.FileName,
-			Content:     inline.Content,
+			Open:        attachment.BytesOpener(inline.Content),
 			ContentType: inline.ContentType,
 			ContentID:   inline.ContentID,
 			Size:        len(inline.Content),
@@ -409,3 +438,40 @@ func getContactName(imapAddr imap.Address) (string, string) {
 	}
 	return names[0], names[1]
 }
+
+// deliveryReport returns the report parts of a delivery status notification, nil if the email isn't one.
+func deliveryReport(envelope *enmime.Envelope) *models.DeliveryReport {
+	if envelope.Root == nil {
+		return nil
+	}
+	status := envelope.Root.DepthMatchFirst(func(p *enmime.Part) bool {
+		return p.ContentType == "message/delivery-status" || p.ContentType == "message/global-delivery-status"
+	})
+	if status == nil {
+		return nil
+	}
+	report := &models.DeliveryReport{Status: status.Content}
+
+	// The original message is returned either whole or as its headers only.
+	original := envelope.Root.DepthMatchFirst(func(p *enmime.Part) bool {
+		switch p.ContentType {
+		case "text/rfc822-headers", "message/rfc822", "message/global", "message/global-headers":
+			return true
+		}
+		return false
+	})
+	if original != nil {
+		report.OriginalHeaders = original.Content
+	}
+	return report
+}
+
+// originalRecipient returns the address the message was delivered to from the headers set by the receiving server.
+func originalRecipient(envelope *enmime.Envelope) string {
+	for _, header := range []string{"X-Original-To", "Delivered-To"} {
+		if addr := bareAddress(envelope.GetHeader(header)); addr != "" {
+			return addr
+		}
+	}
+	return ""
+}
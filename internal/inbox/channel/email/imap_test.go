@@ -0,0 +1,57 @@
+package email
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jhillyerd/enmime"
+)
+
+func TestDeliveryReport(t *testing.T) {
+	const dsn = "From: MAILER-DAEMON@mx.example.com\r\n" +
+		"To: support@example.com\r\n" +
+		"Subject: Undelivered Mail Returned to Sender\r\n" +
+		"MIME-Version: 1.0\r\n" +
+		"Content-Type: multipart/report; report-type=delivery-status; boundary=\"b\"\r\n" +
+		"\r\n" +
+		"--b\r\n" +
+		"Content-Type: text/plain\r\n" +
+		"\r\n" +
+		"Your message could not be delivered.\r\n" +
+		"--b\r\n" +
+		"Content-Type: message/delivery-status\r\n" +
+		"\r\n" +
+		"Reporting-MTA: dns; mx.example.com\r\n" +
+		"\r\n" +
+		"Final-Recipient: rfc822; jane@example.com\r\n" +
+		"Action: failed\r\n" +
+		"Status: 5.1.1\r\n" +
+		"--b\r\n" +
+		"Content-Type: text/rfc822-headers\r\n" +
+		"\r\n" +
+		"Message-ID: <original-id@example.com>\r\n" +
+		"--b--\r\n"
+
+	env, err := enmime.ReadEnvelope(strings.NewReader(dsn))
+	if err != nil {
+		t.Fatalf("reading envelope: %v", err)
+	}
+	report := deliveryReport(env)
+	if report == nil {
+		t.Fatal("expected a delivery report")
+	}
+	if !strings.Contains(string(report.Status), "Action: failed") {
+		t.Errorf("unexpected status part %q", report.Status)
+	}
+	if !strings.Contains(string(report.OriginalHeaders), "<original-id@example.com>") {
+		t.Errorf("unexpected original headers %q", report.OriginalHeaders)
+	}
+
+	env, err = enmime.ReadEnvelope(strings.NewReader("From: jane@example.com\r\nSubject: Hi\r\n\r\nHello\r\n"))
+	if err != nil {
+		t.Fatalf("reading envelope: %v", err)
+	}
+	if report := deliveryReport(env); report != nil {
+		t.Errorf("got report %+v for a regular email", report)
+	}
+}
@@ -24,37 +24,11 @@ func NewSmtpPool(configs []SMTPConfig) ([]*smtppool.Pool, error) {
 	pools := make([]*smtppool.Pool, 0, len(configs))
 
 	for _, cfg := range configs {
-		var auth smtp.Auth
-		switch cfg.AuthProtocol {
-		case "cram":
-			auth = smtp.CRAMMD5Auth(cfg.Username, cfg.Password)
-		case "plain":
-			auth = smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.Host)
-		case "login":
-			auth = &smtppool.LoginAuth{Username: cfg.Username, Password: cfg.Password}
-		case "", "none":
-			// No authentication
-		default:
-			return nil, fmt.Errorf("unknown SMTP auth type '%s'", cfg.AuthProtocol)
-		}
-		cfg.Opt.Auth = auth
-
-		// TLS config
-		if cfg.TLSType != "none" {
-			cfg.TLSConfig = &tls.Config{}
-			if cfg.TLSSkipVerify {
-				cfg.TLSConfig.InsecureSkipVerify = cfg.TLSSkipVerify
-			} else {
-				cfg.TLSConfig.ServerName = cfg.Host
-			}
-
-			// SSL/TLS, not STARTTLS
-			if cfg.TLSType == "tls" {
-				cfg.Opt.SSL = true
-			}
+		opt, err := newSMTPOpt(cfg)
+		if err != nil {
+			return nil, err
 		}
-
-		pool, err := smtppool.New(cfg.Opt)
+		pool, err := smtppool.New(opt)
 		if err != nil {
 			return nil, err
 		}
@@ -64,42 +38,61 @@ func NewSmtpPool(configs []SMTPConfig) ([]*smtppool.Pool, error) {
 	return pools, nil
 }
 
+// newSMTPOpt returns the smtppool options of an SMTP server with its authentication and TLS set up.
+func newSMTPOpt(cfg SMTPConfig) (smtppool.Opt, error) {
+	var auth smtp.Auth
+	switch cfg.AuthProtocol {
+	case "cram":
+		auth = smtp.CRAMMD5Auth(cfg.Username, cfg.Password)
+	case "plain":
+		auth = smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.Host)
+	case "login":
+		auth = &smtppool.LoginAuth{Username: cfg.Username, Password: cfg.Password}
+	case "", "none":
+		// No authentication
+	default:
+		return smtppool.Opt{}, fmt.Errorf("unknown SMTP auth type '%s'", cfg.AuthProtocol)
+	}
+	cfg.Opt.Auth = auth
+
+	// TLS config
+	if cfg.TLSType != "none" {
+		cfg.TLSConfig = &tls.Config{}
+		if cfg.TLSSkipVerify {
+			cfg.TLSConfig.InsecureSkipVerify = cfg.TLSSkipVerify
+		} else {
+			cfg.TLSConfig.ServerName = cfg.Host
+		}
+
+		// SSL/TLS, not STARTTLS
+		if cfg.TLSType == "tls" {
+			cfg.Opt.SSL = true
+		}
+	}
+	return cfg.Opt, nil
+}
+
 // Send sends an email using one of the configured SMTP servers.
 func (e *Email) Send(m models.Message) error {
 	// Select a random SMTP server if there are multiple
-	var (
-		serverCount = len(e.smtpPools)
-		server      *smtppool.Pool
-	)
-	if serverCount > 1 {
-		server = e.smtpPools[rand.Intn(serverCount)]
-	} else {
-		server = e.smtpPools[0]
+	var server int
+	if len(e.smtpPools) > 1 {
+		server = rand.Intn(len(e.smtpPools))
 	}
 
-	// Prepare attachments if there are any
-	var attachments []smtppool.Attachment
-	if m.Attachments != nil {
-		attachments = make([]smtppool.Attachment, 0, len(m.Attachments))
-		for _, file := range m.Attachments {
-			attachment := smtppool.Attachment{
-				Filename: file.Name,
-				Header:   file.Header,
-				Content:  make([]byte, len(file.Content)),
-			}
-			copy(attachment.Content, file.Content)
-			attachments = append(attachments, attachment)
-		}
+	email := smtppool.Email{
+		From:    m.From,
+		To:      m.To,
+		Cc:      m.CC,
+		Bcc:     m.BCC,
+		Subject: m.Subject,
+		Headers: textproto.MIMEHeader{},
 	}
 
-	email := smtppool.Email{
-		From:        m.From,
-		To:          m.To,
-		Cc:          m.CC,
-		Bcc:         m.BCC,
-		Subject:     m.Subject,
-		Attachments: attachments,
-		Headers:     textproto.MIMEHeader{},
+	// Use the inbox address as the envelope sender so delivery status notifications come back to the mailbox polled
+	// by the inbox, replies sent from an alias would otherwise have them go to the alias.
+	if e.dsn && e.from != "" {
+		email.Sender = e.from
 	}
 
 	// Attach SMTP level headers
@@ -134,7 +127,7 @@ func (e *Email) Send(m models.Message) error {
 
 	// Set email content
 	switch m.ContentType {
-	case "plain":
+	case "plain", models.ContentTypeText:
 		email.Text = []byte(m.Content)
 	default:
 		email.HTML = []byte(m.Content)
@@ -142,5 +135,15 @@ func (e *Email) Send(m models.Message) error {
 			email.Text = []byte(m.AltContent)
 		}
 	}
-	return server.Send(email)
+
+	// The pool builds messages in memory, messages with attachments are streamed on the stream pool instead.
+	// The pool doesn't take the MAIL and RCPT parameters requesting delivery status notifications either, emails of
+	// inboxes tracking their delivery are sent on the stream pool too, with the Message-ID as envelope ID.
+	if e.dsn {
+		return e.streamPools[server].send(email, m.Attachments, m.SourceID.String)
+	}
+	if len(m.Attachments) > 0 {
+		return e.streamPools[server].send(email, m.Attachments, "")
+	}
+	return e.smtpPools[server].Send(email)
 }
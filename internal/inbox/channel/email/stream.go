@@ -0,0 +1,552 @@
+package email
+
+import (
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/abhinavxd/libredesk/internal/attachment"
+	"github.com/knadh/smtppool"
+)
+
+const (
+	// base64LineLength is the maximum length of base64 encoded lines, RFC 2045.
+	base64LineLength = 76
+
+	// defaultWaitTimeout is the timeout to get a connection and to connect to the SMTP server when the pool wait
+	// timeout isn't set, as in smtppool.
+	defaultWaitTimeout = 2 * time.Second
+
+	// maxHeaderLineLength is the length header lines are folded at, RFC 5322 section 2.1.1.
+	maxHeaderLineLength = 78
+)
+
+// streamPool holds the connections to an SMTP server emails are streamed on. Unlike the pool, which builds the whole
+// message in memory, the message is written straight to the connection and the attachments are streamed from their
+// readers, so large files are never loaded in memory. Like the pool, at most MaxConns connections are open at a time
+// and connections are reset and reused across emails.
+type streamPool struct {
+	opt smtppool.Opt
+
+	// conns holds a slot per allowed connection, nil for the slots without an open connection.
+	conns chan *streamConn
+}
+
+// streamConn is an open connection of a stream pool.
+type streamConn struct {
+	client       *smtp.Client
+	lastActivity time.Time
+}
+
+// newStreamPool returns a stream pool for the SMTP server.
+func newStreamPool(opt smtppool.Opt) *streamPool {
+	p := &streamPool{
+		opt:   opt,
+		conns: make(chan *streamConn, max(opt.MaxConns, 1)),
+	}
+	for i := 0; i < cap(p.conns); i++ {
+		p.conns <- nil
+	}
+	return p
+}
+
+// send sends an email with attachments. If envID is set and the server supports DSN, delivery status notifications
+// are requested for the email with envID as its envelope ID.
+func (p *streamPool) send(email smtppool.Email, attachments []attachment.Attachment, envID string) error {
+	from, recipients, err := envelopeAddresses(email)
+	if err != nil {
+		return err
+	}
+
+	c, err := p.borrow()
+	if err != nil {
+		return err
+	}
+	err = sendOn(c.client, email, attachments, from, recipients, envID)
+	p.release(c, err)
+	return err
+}
+
+// borrow returns an idle connection, or a new one if there is none, waiting for a free slot up to the pool wait
+// timeout.
+func (p *streamPool) borrow() (*streamConn, error) {
+	var c *streamConn
+	select {
+	case c = <-p.conns:
+	case <-time.After(waitTimeout(p.opt)):
+		return nil, errors.New("timed out waiting for free conn in pool")
+	}
+
+	// Connections are reset before reuse as some servers reject a new transaction otherwise. Connections idling
+	// longer than the idle timeout or failing the reset are replaced.
+	if c != nil {
+		if p.opt.IdleTimeout >= time.Second && time.Since(c.lastActivity) > p.opt.IdleTimeout {
+			c.client.Close()
+			c = nil
+		} else if err := c.client.Reset(); err != nil {
+			c.client.Close()
+			c = nil
+		}
+	}
+	if c == nil {
+		client, err := dialSMTP(p.opt)
+		if err != nil {
+			p.conns <- nil
+			return nil, err
+		}
+		c = &streamConn{client: client}
+	}
+	return c, nil
+}
+
+// release gives the connection back to the pool. As in smtppool, connections are closed after any error other than
+// an SMTP error response.
+func (p *streamPool) release(c *streamConn, lastErr error) {
+	if lastErr != nil {
+		if _, ok := lastErr.(*textproto.Error); !ok {
+			c.client.Close()
+			p.conns <- nil
+			return
+		}
+	}
+	c.lastActivity = time.Now()
+	p.conns <- c
+}
+
+// close closes the idle connections of the pool.
+func (p *streamPool) close() {
+	for i := 0; i < cap(p.conns); i++ {
+		select {
+		case c := <-p.conns:
+			if c != nil {
+				c.client.Quit()
+			}
+			p.conns <- nil
+		default:
+			return
+		}
+	}
+}
+
+// sendOn sends an email with attachments on an SMTP connection.
+func sendOn(client *smtp.Client, email smtppool.Email, attachments []attachment.Attachment, from string, recipients []string, envID string) error {
+	if ok, _ := client.Extension("DSN"); ok && envID != "" {
+		if err := sendDSNEnvelope(client, from, recipients, envID); err != nil {
+			return err
+		}
+	} else {
+		if err := client.Mail(from); err != nil {
+			return err
+		}
+		for _, rcpt := range recipients {
+			if err := client.Rcpt(rcpt); err != nil {
+				return err
+			}
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if err := writeMessage(w, email, attachments); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+// waitTimeout returns the pool wait timeout, defaulting as in smtppool.
+func waitTimeout(opt smtppool.Opt) time.Duration {
+	if opt.PoolWaitTimeout < time.Second {
+		return defaultWaitTimeout
+	}
+	return opt.PoolWaitTimeout
+}
+
+// dialSMTP connects and authenticates to the SMTP server the way smtppool does for its connections.
+func dialSMTP(opt smtppool.Opt) (*smtp.Client, error) {
+	var (
+		conn    net.Conn
+		err     error
+		addr    = net.JoinHostPort(opt.Host, strconv.Itoa(opt.Port))
+		timeout = waitTimeout(opt)
+	)
+	if opt.TLSConfig != nil && opt.SSL {
+		conn, err = tls.DialWithDialer(&net.Dialer{Timeout: timeout}, "tcp", addr, opt.TLSConfig)
+	} else {
+		conn, err = net.DialTimeout("tcp", addr, timeout)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := smtp.NewClient(conn, opt.Host)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if opt.HelloHostname != "" {
+		if err := client.Hello(opt.HelloHostname); err != nil {
+			client.Close()
+			return nil, err
+		}
+	}
+	if opt.TLSConfig != nil && !opt.SSL {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			client.Close()
+			return nil, errors.New("SMTP STARTTLS extension not found")
+		}
+		if err := client.StartTLS(opt.TLSConfig); err != nil {
+			client.Close()
+			return nil, err
+		}
+	}
+	if opt.Auth != nil {
+		if ok, _ := client.Extension("AUTH"); !ok {
+			client.Close()
+			return nil, errors.New("SMTP AUTH extension not found")
+		}
+		if err := client.Auth(opt.Auth); err != nil {
+			client.Close()
+			return nil, err
+		}
+	}
+	return client, nil
+}
+
+// sendDSNEnvelope sends the MAIL and RCPT commands with the RFC 3461 parameters requesting notifications of the
+// delivery and failure of the email to every recipient, with the headers of the email returned in them. net/smtp
+// doesn't take command parameters, the commands are written on the connection as Client.Mail and Client.Rcpt do.
+func sendDSNEnvelope(client *smtp.Client, from string, recipients []string, envID string) error {
+	if err := smtpCmd(client, 250, "%s", mailDSNCommand(client, from, envID)); err != nil {
+		return err
+	}
+	for _, rcpt := range recipients {
+		if err := smtpCmd(client, 25, "RCPT TO:<%s> NOTIFY=SUCCESS,FAILURE,DELAY", rcpt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mailDSNCommand returns the MAIL command of an email with the DSN parameters.
+func mailDSNCommand(client *smtp.Client, from, envID string) string {
+	cmd := "MAIL FROM:<" + from + "> RET=HDRS ENVID=" + xtext(envID)
+	if ok, _ := client.Extension("8BITMIME"); ok {
+		cmd += " BODY=8BITMIME"
+	}
+	return cmd
+}
+
+// smtpCmd sends a command on the SMTP connection and checks the response code.
+func smtpCmd(client *smtp.Client, expectCode int, format string, args ...any) error {
+	if strings.ContainsAny(fmt.Sprintf(format, args...), "\r\n") {
+		return errors.New("smtp: A line must not contain CR or LF")
+	}
+	id, err := client.Text.Cmd(format, args...)
+	if err != nil {
+		return err
+	}
+	client.Text.StartResponse(id)
+	defer client.Text.EndResponse(id)
+	_, _, err = client.Text.ReadResponse(expectCode)
+	return err
+}
+
+// xtext encodes a DSN parameter value, RFC 3461 section 4: characters outside of "!" to "~", "+" and "=" are
+// written as "+" followed by their hexadecimal value.
+func xtext(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c < '!' || c > '~' || c == '+' || c == '=' {
+			fmt.Fprintf(&b, "+%02X", c)
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// envelopeAddresses returns the envelope sender, the sender if set or else the from address, and the recipients of
+// an email.
+func envelopeAddresses(email smtppool.Email) (string, []string, error) {
+	sender := email.Sender
+	if sender == "" {
+		sender = email.From
+	}
+	from, err := mail.ParseAddress(sender)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var recipients []string
+	for _, list := range [][]string{email.To, email.Cc, email.Bcc} {
+		for _, a := range list {
+			addr, err := mail.ParseAddress(a)
+			if err != nil {
+				return "", nil, err
+			}
+			recipients = append(recipients, addr.Address)
+		}
+	}
+	return from.Address, recipients, nil
+}
+
+// writeMessage writes the MIME message of an email, with the same structure as smtppool: a multipart/mixed message
+// with the text and HTML bodies as multipart/alternative followed by the attachments.
+func writeMessage(w io.Writer, email smtppool.Email, attachments []attachment.Attachment) error {
+	headers, err := messageHeaders(email)
+	if err != nil {
+		return err
+	}
+
+	var (
+		isMixed       = len(attachments) > 0
+		isAlternative = len(email.Text) > 0 && len(email.HTML) > 0
+		mw            *multipart.Writer
+	)
+	if isMixed || isAlternative {
+		mw = multipart.NewWriter(w)
+	}
+	switch {
+	case isMixed:
+		headers.Set("Content-Type", "multipart/mixed; boundary="+mw.Boundary())
+	case isAlternative:
+		headers.Set("Content-Type", "multipart/alternative; boundary="+mw.Boundary())
+	case len(email.HTML) > 0:
+		headers.Set("Content-Type", "text/html; charset=UTF-8")
+		headers.Set("Content-Transfer-Encoding", "quoted-printable")
+	default:
+		headers.Set("Content-Type", "text/plain; charset=UTF-8")
+		headers.Set("Content-Transfer-Encoding", "quoted-printable")
+	}
+	if err := writeHeaders(w, headers); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, "\r\n"); err != nil {
+		return err
+	}
+
+	if mw == nil {
+		body := email.Text
+		if len(email.HTML) > 0 {
+			body = email.HTML
+		}
+		return writeQuotedPrintable(w, body)
+	}
+
+	if len(email.Text) > 0 || len(email.HTML) > 0 {
+		bodyWriter := mw
+		if isMixed && isAlternative {
+			bodyWriter = multipart.NewWriter(w)
+			if _, err := mw.CreatePart(textproto.MIMEHeader{"Content-Type": {"multipart/alternative;\r\n boundary=" + bodyWriter.Boundary()}}); err != nil {
+				return err
+			}
+		}
+		for _, body := range []struct {
+			contentType string
+			content     []byte
+		}{{"text/plain", email.Text}, {"text/html", email.HTML}} {
+			if len(body.content) == 0 {
+				continue
+			}
+			part, err := bodyWriter.CreatePart(textproto.MIMEHeader{
+				"Content-Type":              {body.contentType + "; charset=UTF-8"},
+				"Content-Transfer-Encoding": {"quoted-printable"},
+			})
+			if err != nil {
+				return err
+			}
+			if err := writeQuotedPrintable(part, body.content); err != nil {
+				return err
+			}
+		}
+		if bodyWriter != mw {
+			if err := bodyWriter.Close(); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, a := range attachments {
+		part, err := mw.CreatePart(a.Header)
+		if err != nil {
+			return err
+		}
+		if err := writeAttachment(part, a); err != nil {
+			return fmt.Errorf("writing attachment %s: %w", a.Name, err)
+		}
+	}
+	return mw.Close()
+}
+
+// messageHeaders returns the headers of an email with the address, subject, date and MIME headers set unless they're
+// in the email headers already.
+func messageHeaders(email smtppool.Email) (textproto.MIMEHeader, error) {
+	headers := make(textproto.MIMEHeader, len(email.Headers)+6)
+	for k, v := range email.Headers {
+		headers[k] = v
+	}
+
+	from, err := mail.ParseAddress(email.From)
+	if err != nil {
+		return nil, err
+	}
+	addrHeaders := []struct {
+		name  string
+		addrs []string
+	}{{"Reply-To", email.ReplyTo}, {"To", email.To}, {"Cc", email.Cc}}
+	for _, h := range addrHeaders {
+		if _, ok := headers[h.name]; ok || len(h.addrs) == 0 {
+			continue
+		}
+		formatted := make([]string, 0, len(h.addrs))
+		for _, a := range h.addrs {
+			addr, err := mail.ParseAddress(a)
+			if err != nil {
+				return nil, err
+			}
+			formatted = append(formatted, addr.String())
+		}
+		headers.Set(h.name, strings.Join(formatted, ", "))
+	}
+	if _, ok := headers["From"]; !ok {
+		headers.Set("From", from.String())
+	}
+	if _, ok := headers["Subject"]; !ok && email.Subject != "" {
+		headers.Set("Subject", email.Subject)
+	}
+	if _, ok := headers["Date"]; !ok {
+		headers.Set("Date", time.Now().Format(time.RFC1123Z))
+	}
+	headers.Set("Mime-Version", "1.0")
+	return headers, nil
+}
+
+// writeHeaders writes the headers sorted by name and folded, values other than content types and dispositions are
+// encoded as RFC 2047 words when needed.
+func writeHeaders(w io.Writer, headers textproto.MIMEHeader) error {
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	for _, name := range names {
+		for _, v := range headers[name] {
+			if name != "Content-Type" && name != "Content-Disposition" {
+				v = mime.QEncoding.Encode("UTF-8", v)
+			}
+			if _, err := io.WriteString(w, foldHeader(name+": "+v)+"\r\n"); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// foldHeader folds a header line longer than 78 characters at the spaces between its words, RFC 5322 section 2.2.3.
+// Words longer than a line are kept whole, lines only have to be under 998 characters.
+func foldHeader(line string) string {
+	if len(line) <= maxHeaderLineLength {
+		return line
+	}
+
+	var (
+		b   strings.Builder
+		n   int
+		sep string
+	)
+	for _, word := range strings.Fields(line) {
+		if n > 0 && n+1+len(word) > maxHeaderLineLength {
+			b.WriteString("\r\n")
+			n, sep = 0, " "
+		}
+		b.WriteString(sep)
+		b.WriteString(word)
+		n += len(sep) + len(word)
+		sep = " "
+	}
+	return b.String()
+}
+
+// writeQuotedPrintable writes a body quoted-printable encoded.
+func writeQuotedPrintable(w io.Writer, body []byte) error {
+	qp := quotedprintable.NewWriter(w)
+	if _, err := qp.Write(body); err != nil {
+		return err
+	}
+	return qp.Close()
+}
+
+// writeAttachment streams the content of an attachment base64 encoded in lines of 76 characters.
+func writeAttachment(w io.Writer, a attachment.Attachment) error {
+	r, err := a.Reader()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	lw := &lineWriter{w: w, max: base64LineLength}
+	enc := base64.NewEncoder(base64.StdEncoding, lw)
+	if _, err := io.Copy(enc, r); err != nil {
+		return err
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+	return lw.Close()
+}
+
+// lineWriter breaks the written bytes in CRLF terminated lines of at most max bytes.
+type lineWriter struct {
+	w   io.Writer
+	max int
+	n   int
+}
+
+func (l *lineWriter) Write(p []byte) (int, error) {
+	var written int
+	for len(p) > 0 {
+		chunk := min(l.max-l.n, len(p))
+		n, err := l.w.Write(p[:chunk])
+		written += n
+		l.n += n
+		if err != nil {
+			return written, err
+		}
+		p = p[chunk:]
+		if l.n == l.max {
+			if _, err := io.WriteString(l.w, "\r\n"); err != nil {
+				return written, err
+			}
+			l.n = 0
+		}
+	}
+	return written, nil
+}
+
+// Close terminates the last line.
+func (l *lineWriter) Close() error {
+	if l.n == 0 {
+		return nil
+	}
+	l.n = 0
+	_, err := io.WriteString(l.w, "\r\n")
+	return err
+}
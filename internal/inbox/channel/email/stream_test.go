@@ -0,0 +1,264 @@
+package email
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"net/smtp"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/abhinavxd/libredesk/internal/attachment"
+	"github.com/jhillyerd/enmime"
+	"github.com/knadh/smtppool"
+)
+
+func TestWriteMessage(t *testing.T) {
+	content := bytes.Repeat([]byte("0123456789"), 100)
+	var opened bool
+	attachments := []attachment.Attachment{{
+		Name:   "report.txt",
+		Header: attachment.MakeHeader("text/plain", "", "report.txt", "base64", attachment.DispositionAttachment),
+		Open: func() (io.ReadCloser, error) {
+			opened = true
+			return io.NopCloser(bytes.NewReader(content)), nil
+		},
+	}}
+	email := smtppool.Email{
+		From:    "Support <support@example.com>",
+		To:      []string{"jane@example.com"},
+		Subject: "Your report",
+		Text:    []byte("See the attached report."),
+		HTML:    []byte("<p>See the attached report.</p>"),
+	}
+
+	var out bytes.Buffer
+	if err := writeMessage(&out, email, attachments); err != nil {
+		t.Fatalf("writeMessage() error = %v", err)
+	}
+	if !opened {
+		t.Fatal("attachment wasn't read with Open")
+	}
+	for _, line := range strings.Split(out.String(), "\r\n") {
+		if len(line) > 998 {
+			t.Fatalf("line longer than 998 characters: %q", line)
+		}
+	}
+
+	env, err := enmime.ReadEnvelope(&out)
+	if err != nil {
+		t.Fatalf("parsing written message: %v", err)
+	}
+	if env.GetHeader("Subject") != "Your report" {
+		t.Errorf("subject = %q", env.GetHeader("Subject"))
+	}
+	if strings.TrimSpace(env.Text) != "See the attached report." {
+		t.Errorf("text = %q", env.Text)
+	}
+	if !strings.Contains(env.HTML, "<p>See the attached report.</p>") {
+		t.Errorf("html = %q", env.HTML)
+	}
+	if len(env.Attachments) != 1 {
+		t.Fatalf("got %d attachments, want 1", len(env.Attachments))
+	}
+	if got := env.Attachments[0]; got.FileName != "report.txt" || !bytes.Equal(got.Content, content) {
+		t.Errorf("attachment = %q with %d bytes, want report.txt with %d bytes", got.FileName, len(got.Content), len(content))
+	}
+}
+
+func TestLineWriter(t *testing.T) {
+	var out bytes.Buffer
+	lw := &lineWriter{w: &out, max: 4}
+	lw.Write([]byte("abcdef"))
+	lw.Write([]byte("gh"))
+	lw.Write([]byte("ij"))
+	lw.Close()
+	if want := "abcd\r\nefgh\r\nij\r\n"; out.String() != want {
+		t.Errorf("got %q, want %q", out.String(), want)
+	}
+}
+
+func TestXtext(t *testing.T) {
+	tests := []struct {
+		in, expected string
+	}{
+		{"abc@example.com", "abc@example.com"},
+		{"a+b=c", "a+2Bb+3Dc"},
+		{"with space", "with+20space"},
+		{"é", "+C3+A9"},
+	}
+	for _, tt := range tests {
+		if got := xtext(tt.in); got != tt.expected {
+			t.Errorf("xtext(%q) = %q, want %q", tt.in, got, tt.expected)
+		}
+	}
+}
+
+func TestSendDSNEnvelope(t *testing.T) {
+	clientConn, serverConn := net.Pipe()
+	defer clientConn.Close()
+
+	// Fake SMTP server recording the MAIL and RCPT commands.
+	commands := make(chan []string, 1)
+	go func() {
+		defer serverConn.Close()
+		var (
+			received []string
+			r        = bufio.NewReader(serverConn)
+		)
+		io.WriteString(serverConn, "220 mx.example.com\r\n")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				commands <- received
+				return
+			}
+			line = strings.TrimRight(line, "\r\n")
+			switch {
+			case strings.HasPrefix(line, "EHLO"):
+				io.WriteString(serverConn, "250-mx.example.com\r\n250-DSN\r\n250 8BITMIME\r\n")
+			case strings.HasPrefix(line, "MAIL"), strings.HasPrefix(line, "RCPT"):
+				received = append(received, line)
+				io.WriteString(serverConn, "250 OK\r\n")
+			default:
+				io.WriteString(serverConn, "502 Unsupported\r\n")
+			}
+		}
+	}()
+
+	client, err := smtp.NewClient(clientConn, "mx.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := client.Extension("DSN"); !ok {
+		t.Fatal("DSN extension not found")
+	}
+	if err := sendDSNEnvelope(client, "support@example.com", []string{"jane@example.com", "john@example.com"}, "id+1@example.com"); err != nil {
+		t.Fatalf("sendDSNEnvelope() error = %v", err)
+	}
+	clientConn.Close()
+
+	expected := []string{
+		"MAIL FROM:<support@example.com> RET=HDRS ENVID=id+2B1@example.com BODY=8BITMIME",
+		"RCPT TO:<jane@example.com> NOTIFY=SUCCESS,FAILURE,DELAY",
+		"RCPT TO:<john@example.com> NOTIFY=SUCCESS,FAILURE,DELAY",
+	}
+	if got := <-commands; !slices.Equal(got, expected) {
+		t.Errorf("got commands %q, want %q", got, expected)
+	}
+}
+
+func TestFoldHeader(t *testing.T) {
+	var refs []string
+	for i := 0; i < 20; i++ {
+		refs = append(refs, fmt.Sprintf("<%d.1700000000@example.com>", i))
+	}
+	line := "References: " + strings.Join(refs, " ")
+
+	folded := foldHeader(line)
+	lines := strings.Split(folded, "\r\n")
+	if len(lines) < 2 {
+		t.Fatalf("header wasn't folded: %q", folded)
+	}
+	for i, l := range lines {
+		if len(l) > maxHeaderLineLength {
+			t.Errorf("line %d longer than %d characters: %q", i, maxHeaderLineLength, l)
+		}
+		if i > 0 && !strings.HasPrefix(l, " ") {
+			t.Errorf("continuation line %d doesn't start with a space: %q", i, l)
+		}
+	}
+	if unfolded := strings.ReplaceAll(folded, "\r\n", ""); unfolded != line {
+		t.Errorf("unfolded header = %q, want %q", unfolded, line)
+	}
+	if got := foldHeader("Subject: Hello"); got != "Subject: Hello" {
+		t.Errorf("short header folded: %q", got)
+	}
+}
+
+func TestStreamPoolReusesConnections(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	// Fake SMTP server counting the connections and the messages received.
+	var (
+		mu       sync.Mutex
+		conns    int
+		messages int
+	)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			mu.Lock()
+			conns++
+			mu.Unlock()
+			go func() {
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				io.WriteString(conn, "220 mx.example.com\r\n")
+				for {
+					line, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					switch line = strings.TrimRight(line, "\r\n"); {
+					case strings.HasPrefix(line, "EHLO"):
+						io.WriteString(conn, "250 mx.example.com\r\n")
+					case line == "DATA":
+						io.WriteString(conn, "354 Go ahead\r\n")
+						for {
+							l, err := r.ReadString('\n')
+							if err != nil {
+								return
+							}
+							if l == ".\r\n" {
+								break
+							}
+						}
+						mu.Lock()
+						messages++
+						mu.Unlock()
+						io.WriteString(conn, "250 OK\r\n")
+					case line == "QUIT":
+						io.WriteString(conn, "221 Bye\r\n")
+						return
+					default:
+						io.WriteString(conn, "250 OK\r\n")
+					}
+				}
+			}()
+		}
+	}()
+
+	addr := ln.Addr().(*net.TCPAddr)
+	p := newStreamPool(smtppool.Opt{Host: "127.0.0.1", Port: addr.Port, MaxConns: 1})
+	defer p.close()
+
+	email := smtppool.Email{
+		From:    "support@example.com",
+		To:      []string{"jane@example.com"},
+		Subject: "Hello",
+		Text:    []byte("Hello"),
+	}
+	for i := 0; i < 3; i++ {
+		if err := p.send(email, nil, ""); err != nil {
+			t.Fatalf("send() error = %v", err)
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if conns != 1 || messages != 3 {
+		t.Errorf("got %d messages on %d connections, want 3 messages on 1 connection", messages, conns)
+	}
+}
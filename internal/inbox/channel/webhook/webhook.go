@@ -0,0 +1,394 @@
+// Package webhook provides an inbox that receives messages pushed by other systems over a signed HTTP webhook and
+// delivers replies to a reply URL.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/abhinavxd/libredesk/internal/conversation/models"
+	"github.com/abhinavxd/libredesk/internal/envelope"
+	"github.com/abhinavxd/libredesk/internal/inbox"
+	imodels "github.com/abhinavxd/libredesk/internal/inbox/models"
+	umodels "github.com/abhinavxd/libredesk/internal/user/models"
+	"github.com/volatiletech/null/v9"
+	"github.com/zerodha/logf"
+)
+
+const (
+	ChannelWebhook = "webhook"
+
+	// SignatureHeader is the header carrying the hex encoded HMAC-SHA256 of the request body, prefixed with `sha256=`.
+	SignatureHeader = "X-Libredesk-Signature"
+	signaturePrefix = "sha256="
+
+	replyTimeout = 10 * time.Second
+)
+
+var (
+	// ErrInvalidSignature is returned when the signature of a payload doesn't match its body.
+	ErrInvalidSignature = errors.New("invalid signature")
+	// ErrInvalidPayload is returned when a payload can't be parsed or misses required fields.
+	ErrInvalidPayload = errors.New("invalid payload")
+)
+
+// Config holds the webhook inbox configuration.
+type Config struct {
+	// Secret signs incoming payloads and outgoing replies.
+	Secret string `json:"secret"`
+	// ReplyURL receives the replies sent from the inbox, replies fail when it isn't set.
+	ReplyURL  string            `json:"reply_url"`
+	From      string            `json:"from"`
+	RateLimit imodels.RateLimit `json:"rate_limit"`
+	// PlainTextOnly posts all replies as plain text, for systems that can't display HTML.
+	PlainTextOnly bool `json:"plain_text_only"`
+}
+
+// Payload is a message pushed to the inbox.
+type Payload struct {
+	// SourceID is the ID of the message in the sending system, messages are only ingested once.
+	SourceID string `json:"source_id"`
+	// InReplyTo is the source ID of a previous message of the conversation, a reply sent from the inbox or a
+	// message pushed before. Without it a new conversation is created.
+	InReplyTo   string  `json:"in_reply_to"`
+	Subject     string  `json:"subject"`
+	Content     string  `json:"content"`
+	ContentType string  `json:"content_type"`
+	Contact     Contact `json:"contact"`
+}
+
+// Contact is the sender of a pushed message.
+type Contact struct {
+	Email     string `json:"email"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+}
+
+// Reply is the body posted to the reply URL for a message sent from the inbox.
+type Reply struct {
+	UUID             string       `json:"uuid"`
+	SourceID         string       `json:"source_id"`
+	ConversationUUID string       `json:"conversation_uuid"`
+	InReplyTo        string       `json:"in_reply_to"`
+	To               []string     `json:"to"`
+	CC               []string     `json:"cc"`
+	Subject          string       `json:"subject"`
+	Content          string       `json:"content"`
+	ContentType      string       `json:"content_type"`
+	Attachments      []Attachment `json:"attachments"`
+}
+
+// Attachment is a file of a reply, the content is base64 encoded.
+type Attachment struct {
+	Name        string `json:"name"`
+	ContentType string `json:"content_type"`
+	Content     []byte `json:"content"`
+}
+
+// Webhook is an inbox receiving messages over a signed webhook.
+type Webhook struct {
+	id           int
+	secret       []byte
+	replyURL     string
+	from         string
+	rateLimit    imodels.RateLimit
+	plainText    bool
+	client       *http.Client
+	messageStore inbox.MessageStore
+	userStore    inbox.UserStore
+	lo           *logf.Logger
+
+	// Time of the last accepted payload and the last error, reported by the health check.
+	mu             sync.Mutex
+	lastReceivedAt time.Time
+	lastError      string
+	lastErrorAt    time.Time
+}
+
+// Opts holds the options required for the webhook inbox.
+type Opts struct {
+	ID     int
+	Config Config
+	Lo     *logf.Logger
+}
+
+// New returns a new instance of the webhook inbox.
+func New(store inbox.MessageStore, userStore inbox.UserStore, opts Opts) (*Webhook, error) {
+	if len(opts.Config.Secret) < inbox.WebhookSecretMinLength {
+		return nil, fmt.Errorf("webhook secret should be at least %d characters", inbox.WebhookSecretMinLength)
+	}
+	return &Webhook{
+		id:           opts.ID,
+		secret:       []byte(opts.Config.Secret),
+		replyURL:     opts.Config.ReplyURL,
+		from:         opts.Config.From,
+		rateLimit:    opts.Config.RateLimit,
+		plainText:    opts.Config.PlainTextOnly,
+		client:       &http.Client{Timeout: replyTimeout},
+		messageStore: store,
+		userStore:    userStore,
+		lo:           opts.Lo,
+	}, nil
+}
+
+// Identifier returns the unique identifier of the inbox which is the database ID.
+func (w *Webhook) Identifier() int {
+	return w.id
+}
+
+// Receive waits for the inbox to be stopped, messages are pushed to the inbox with Ingest.
+func (w *Webhook) Receive(ctx context.Context) error {
+	<-ctx.Done()
+	return nil
+}
+
+// Close closes the inbox.
+func (w *Webhook) Close() error {
+	w.client.CloseIdleConnections()
+	return nil
+}
+
+// FromAddress returns the from address for this inbox.
+func (w *Webhook) FromAddress() string {
+	return w.from
+}
+
+// ReplyFromAddress returns the from address of the inbox, webhook inboxes have no aliases.
+func (w *Webhook) ReplyFromAddress(recipients []string) string {
+	return w.from
+}
+
+// Aliases returns no addresses, webhook inboxes have no aliases.
+func (w *Webhook) Aliases() []string {
+	return nil
+}
+
+// Footer returns an empty footer, replies are posted as written.
+func (w *Webhook) Footer() imodels.Footer {
+	return imodels.Footer{}
+}
+
+// Channel returns the channel name for this inbox.
+func (w *Webhook) Channel() string {
+	return ChannelWebhook
+}
+
+// RateLimit returns the outgoing message rate limit of the inbox.
+func (w *Webhook) RateLimit() imodels.RateLimit {
+	return w.rateLimit
+}
+
+// PlainTextOnly returns true if the replies of the inbox are posted as plain text.
+func (w *Webhook) PlainTextOnly() bool {
+	return w.plainText
+}
+
+// HealthCheck returns the health of the inbox, it's degraded when the last payload failed to be ingested.
+func (w *Webhook) HealthCheck() imodels.Health {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	health := imodels.Health{
+		InboxID:   w.id,
+		Channel:   ChannelWebhook,
+		Status:    imodels.HealthStatusHealthy,
+		Connected: true,
+	}
+	if !w.lastReceivedAt.IsZero() {
+		health.LastPollAt = null.TimeFrom(w.lastReceivedAt)
+	}
+	if !w.lastErrorAt.IsZero() {
+		health.LastError = w.lastError
+		health.LastErrorAt = null.TimeFrom(w.lastErrorAt)
+		if w.lastErrorAt.After(w.lastReceivedAt) {
+			health.Status = imodels.HealthStatusDegraded
+		}
+	}
+	return health
+}
+
+// VerifySignature returns true if the signature is the HMAC-SHA256 of the body with the inbox secret.
+func (w *Webhook) VerifySignature(body []byte, signature string) bool {
+	sig, err := hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(signature), signaturePrefix))
+	if err != nil || len(sig) == 0 {
+		return false
+	}
+	return hmac.Equal(sig, sign(w.secret, body))
+}
+
+// Ingest verifies the signature of a pushed payload and enqueues its message. Payloads of messages that were already
+// ingested and of blocked contacts are accepted and dropped, so senders don't retry them.
+func (w *Webhook) Ingest(body []byte, signature string) error {
+	if !w.VerifySignature(body, signature) {
+		return ErrInvalidSignature
+	}
+
+	var payload Payload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		err = fmt.Errorf("%w: %v", ErrInvalidPayload, err)
+		w.recordError(err)
+		return err
+	}
+	in, err := w.incomingMessage(payload)
+	if err != nil {
+		w.recordError(err)
+		return err
+	}
+
+	exists, err := w.messageStore.MessageExists(payload.SourceID)
+	if err != nil {
+		return fmt.Errorf("checking if message exists in DB: %w", err)
+	}
+	if exists {
+		w.lo.Debug("message already exists, ignoring", "source_id", payload.SourceID)
+		return nil
+	}
+
+	// Check if the contact is blocked / disabled, if so, ignore the message.
+	if contact, err := w.userStore.GetContact(0, in.Contact.Email.String); err != nil {
+		envErr, ok := err.(envelope.Error)
+		if !ok || envErr.ErrorType != envelope.NotFoundError {
+			return fmt.Errorf("checking if user is blocked: %w", err)
+		}
+	} else if !contact.Enabled {
+		w.lo.Debug("contact is blocked, ignoring message", "email", in.Contact.Email.String)
+		return nil
+	}
+
+	if err := w.messageStore.EnqueueIncoming(in); err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	w.lastReceivedAt = time.Now()
+	w.mu.Unlock()
+	return nil
+}
+
+// incomingMessage maps a payload to an incoming message of the inbox.
+func (w *Webhook) incomingMessage(payload Payload) (models.IncomingMessage, error) {
+	var (
+		email       = strings.ToLower(strings.TrimSpace(payload.Contact.Email))
+		contentType = payload.ContentType
+	)
+	switch {
+	case strings.TrimSpace(payload.SourceID) == "":
+		return models.IncomingMessage{}, fmt.Errorf("%w: empty `source_id`", ErrInvalidPayload)
+	case strings.TrimSpace(payload.Content) == "":
+		return models.IncomingMessage{}, fmt.Errorf("%w: empty `content`", ErrInvalidPayload)
+	case email == "":
+		return models.IncomingMessage{}, fmt.Errorf("%w: empty `contact.email`", ErrInvalidPayload)
+	}
+	switch contentType {
+	case "":
+		contentType = models.ContentTypeText
+	case models.ContentTypeText, models.ContentTypeHTML:
+	default:
+		return models.IncomingMessage{}, fmt.Errorf("%w: unknown `content_type` %q", ErrInvalidPayload, contentType)
+	}
+
+	firstName := payload.Contact.FirstName
+	if firstName == "" {
+		firstName = strings.Split(email, "@")[0]
+	}
+	in := models.IncomingMessage{
+		Message: models.Message{
+			Channel:     ChannelWebhook,
+			SenderType:  models.SenderTypeContact,
+			Type:        models.MessageIncoming,
+			InboxID:     w.id,
+			Status:      models.MessageStatusReceived,
+			Subject:     payload.Subject,
+			Content:     payload.Content,
+			ContentType: contentType,
+			SourceID:    null.StringFrom(payload.SourceID),
+			InReplyTo:   payload.InReplyTo,
+			Meta:        "{}",
+		},
+		Contact: umodels.User{
+			InboxID:         w.id,
+			FirstName:       firstName,
+			LastName:        payload.Contact.LastName,
+			SourceChannel:   null.StringFrom(ChannelWebhook),
+			SourceChannelID: null.StringFrom(email),
+			Email:           null.StringFrom(email),
+			Type:            umodels.UserTypeContact,
+		},
+		InboxID: w.id,
+	}
+	return in, nil
+}
+
+// Send posts the message to the reply URL of the inbox, signed like the incoming payloads.
+func (w *Webhook) Send(message models.Message) error {
+	if w.replyURL == "" {
+		return errors.New("no reply URL configured for the webhook inbox")
+	}
+
+	reply := Reply{
+		UUID:             message.UUID,
+		SourceID:         message.SourceID.String,
+		ConversationUUID: message.ConversationUUID,
+		InReplyTo:        message.InReplyTo,
+		To:               message.To,
+		CC:               message.CC,
+		Subject:          message.Subject,
+		Content:          message.Content,
+		ContentType:      message.ContentType,
+		Attachments:      make([]Attachment, 0, len(message.Attachments)),
+	}
+	// Stored attachments are read now, the JSON payload needs their content.
+	for _, att := range message.Attachments {
+		content, err := att.ReadContent()
+		if err != nil {
+			return fmt.Errorf("reading attachment %s: %w", att.Name, err)
+		}
+		reply.Attachments = append(reply.Attachments, Attachment{Name: att.Name, ContentType: att.ContentType, Content: content})
+	}
+	body, err := json.Marshal(reply)
+	if err != nil {
+		return fmt.Errorf("marshalling reply: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, w.replyURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("creating reply request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(SignatureHeader, signaturePrefix+hex.EncodeToString(sign(w.secret, body)))
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("posting reply: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("reply URL returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// recordError records the last error of the inbox for the health check.
+func (w *Webhook) recordError(err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.lastError = err.Error()
+	w.lastErrorAt = time.Now()
+}
+
+// sign returns the HMAC-SHA256 of the body with the secret.
+func sign(secret, body []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return mac.Sum(nil)
+}
@@ -0,0 +1,159 @@
+package webhook
+
+import (
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/abhinavxd/libredesk/internal/conversation/models"
+	"github.com/abhinavxd/libredesk/internal/envelope"
+	umodels "github.com/abhinavxd/libredesk/internal/user/models"
+	"github.com/zerodha/logf"
+)
+
+const testSecret = "0123456789abcdef"
+
+type fakeMessageStore struct {
+	existing map[string]bool
+	enqueued []models.IncomingMessage
+}
+
+func (s *fakeMessageStore) MessageExists(id string) (bool, error) {
+	return s.existing[id], nil
+}
+
+func (s *fakeMessageStore) EnqueueIncoming(in models.IncomingMessage) error {
+	s.enqueued = append(s.enqueued, in)
+	return nil
+}
+
+type fakeUserStore struct {
+	blocked map[string]bool
+}
+
+func (s *fakeUserStore) GetContact(id int, email string) (umodels.User, error) {
+	if blocked, ok := s.blocked[email]; ok {
+		return umodels.User{Enabled: !blocked}, nil
+	}
+	return umodels.User{}, envelope.NewError(envelope.NotFoundError, "not found", nil)
+}
+
+func newTestWebhook(t *testing.T, replyURL string) (*Webhook, *fakeMessageStore) {
+	t.Helper()
+	lo := logf.New(logf.Opts{Writer: io.Discard})
+	store := &fakeMessageStore{existing: map[string]bool{"existing": true}}
+	users := &fakeUserStore{blocked: map[string]bool{"blocked@example.com": true}}
+	w, err := New(store, users, Opts{ID: 3, Config: Config{Secret: testSecret, ReplyURL: replyURL}, Lo: &lo})
+	if err != nil {
+		t.Fatalf("creating webhook inbox: %v", err)
+	}
+	return w, store
+}
+
+func signature(body string) string {
+	return signaturePrefix + hex.EncodeToString(sign([]byte(testSecret), []byte(body)))
+}
+
+func TestNewShortSecret(t *testing.T) {
+	lo := logf.New(logf.Opts{Writer: io.Discard})
+	if _, err := New(&fakeMessageStore{}, &fakeUserStore{}, Opts{Config: Config{Secret: "short"}, Lo: &lo}); err == nil {
+		t.Error("expected error for short secret")
+	}
+}
+
+func TestIngest(t *testing.T) {
+	const valid = `{"source_id":"msg-1","in_reply_to":"msg-0","subject":"Hi","content":"Hello","contact":{"email":"Jane@Example.com"}}`
+	tests := []struct {
+		name      string
+		body      string
+		signature string
+		err       error
+		enqueued  bool
+	}{
+		{name: "valid", body: valid, signature: signature(valid), enqueued: true},
+		{name: "signature without prefix", body: valid, signature: signature(valid)[len(signaturePrefix):], enqueued: true},
+		{name: "missing signature", body: valid, err: ErrInvalidSignature},
+		{name: "wrong signature", body: valid, signature: signature(valid + " "), err: ErrInvalidSignature},
+		{name: "malformed signature", body: valid, signature: "sha256=zz", err: ErrInvalidSignature},
+		{name: "malformed json", body: `{`, signature: signature(`{`), err: ErrInvalidPayload},
+		{name: "missing source id", body: `{"content":"Hello","contact":{"email":"a@example.com"}}`, err: ErrInvalidPayload},
+		{name: "missing content", body: `{"source_id":"msg-2","contact":{"email":"a@example.com"}}`, err: ErrInvalidPayload},
+		{name: "missing contact email", body: `{"source_id":"msg-3","content":"Hello"}`, err: ErrInvalidPayload},
+		{name: "unknown content type", body: `{"source_id":"msg-4","content":"Hello","content_type":"md","contact":{"email":"a@example.com"}}`, err: ErrInvalidPayload},
+		{name: "existing message", body: `{"source_id":"existing","content":"Hello","contact":{"email":"a@example.com"}}`},
+		{name: "blocked contact", body: `{"source_id":"msg-5","content":"Hello","contact":{"email":"blocked@example.com"}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, store := newTestWebhook(t, "")
+			sig := tt.signature
+			if sig == "" && tt.err != ErrInvalidSignature {
+				sig = signature(tt.body)
+			}
+			if err := w.Ingest([]byte(tt.body), sig); !errors.Is(err, tt.err) {
+				t.Fatalf("got error %v, want %v", err, tt.err)
+			}
+			if got := len(store.enqueued) == 1; got != tt.enqueued {
+				t.Fatalf("enqueued %d messages, want enqueued %v", len(store.enqueued), tt.enqueued)
+			}
+		})
+	}
+}
+
+func TestIngestMapping(t *testing.T) {
+	const body = `{"source_id":"msg-1","in_reply_to":"msg-0","subject":"Hi","content":"<p>Hello</p>","content_type":"html","contact":{"email":"Jane@Example.com","last_name":"Doe"}}`
+	w, store := newTestWebhook(t, "")
+	if err := w.Ingest([]byte(body), signature(body)); err != nil {
+		t.Fatalf("ingesting: %v", err)
+	}
+
+	in := store.enqueued[0]
+	if in.InboxID != 3 || in.Message.InboxID != 3 || in.Contact.InboxID != 3 {
+		t.Errorf("inbox IDs not set: %d %d %d", in.InboxID, in.Message.InboxID, in.Contact.InboxID)
+	}
+	if in.Message.Channel != ChannelWebhook || in.Message.Type != models.MessageIncoming || in.Message.SenderType != models.SenderTypeContact {
+		t.Errorf("unexpected message channel, type or sender type: %+v", in.Message)
+	}
+	if in.Message.SourceID.String != "msg-1" || in.Message.InReplyTo != "msg-0" {
+		t.Errorf("got source ID %q and in reply to %q", in.Message.SourceID.String, in.Message.InReplyTo)
+	}
+	if in.Message.ContentType != models.ContentTypeHTML || in.Message.Content != "<p>Hello</p>" || in.Message.Subject != "Hi" {
+		t.Errorf("unexpected content: %+v", in.Message)
+	}
+	if in.Contact.Email.String != "jane@example.com" || in.Contact.SourceChannelID.String != "jane@example.com" {
+		t.Errorf("got contact email %q and source channel ID %q", in.Contact.Email.String, in.Contact.SourceChannelID.String)
+	}
+	if in.Contact.FirstName != "jane" || in.Contact.LastName != "Doe" {
+		t.Errorf("got contact name %q %q", in.Contact.FirstName, in.Contact.LastName)
+	}
+}
+
+func TestSend(t *testing.T) {
+	var (
+		gotBody []byte
+		gotSig  string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		gotBody, _ = io.ReadAll(r.Body)
+		gotSig = r.Header.Get(SignatureHeader)
+	}))
+	defer srv.Close()
+
+	w, _ := newTestWebhook(t, srv.URL)
+	msg := models.Message{UUID: "uuid", ConversationUUID: "conv", Content: "Reply", ContentType: models.ContentTypeText, To: []string{"jane@example.com"}}
+	if err := w.Send(msg); err != nil {
+		t.Fatalf("sending: %v", err)
+	}
+	if !w.VerifySignature(gotBody, gotSig) {
+		t.Errorf("reply signature %q doesn't match body %s", gotSig, gotBody)
+	}
+
+	noReply, _ := newTestWebhook(t, "")
+	if err := noReply.Send(msg); err == nil {
+		t.Error("expected error without reply URL")
+	}
+}
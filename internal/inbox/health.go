@@ -0,0 +1,95 @@
+package inbox
+
+import (
+	"time"
+
+	imodels "github.com/abhinavxd/libredesk/internal/inbox/models"
+	"github.com/volatiletech/null/v9"
+)
+
+// maxConsecutiveSendFailures is the number of consecutive failed sends after which an inbox is marked degraded.
+const maxConsecutiveSendFailures = 3
+
+// sendHealth holds the outgoing message delivery results of an inbox.
+type sendHealth struct {
+	lastSendAt          time.Time
+	lastError           string
+	lastErrorAt         time.Time
+	consecutiveFailures int
+	throttled           int64
+}
+
+// RecordSend records the result of sending an outgoing message through an inbox, the inbox is
+// marked degraded after `maxConsecutiveSendFailures` consecutive failures and recovers on the next successful send.
+func (m *Manager) RecordSend(inboxID int, err error) {
+	m.sendMu.Lock()
+	defer m.sendMu.Unlock()
+
+	h, ok := m.sendHealth[inboxID]
+	if !ok {
+		h = &sendHealth{}
+		m.sendHealth[inboxID] = h
+	}
+
+	if err == nil {
+		if h.consecutiveFailures >= maxConsecutiveSendFailures {
+			m.lo.Info("inbox recovered, outgoing message sent", "inbox_id", inboxID)
+		}
+		h.lastSendAt = time.Now()
+		h.consecutiveFailures = 0
+		return
+	}
+
+	h.consecutiveFailures++
+	h.lastError = err.Error()
+	h.lastErrorAt = time.Now()
+	if h.consecutiveFailures == maxConsecutiveSendFailures {
+		m.lo.Warn("inbox marked degraded after consecutive send failures", "inbox_id", inboxID, "failures", h.consecutiveFailures, "error", err)
+	}
+}
+
+// Health returns the health of all inboxes, combining the connectivity reported by each running inbox
+// with the outgoing message delivery results.
+func (m *Manager) Health() ([]imodels.Health, error) {
+	records, err := m.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	m.sendMu.Lock()
+	defer m.sendMu.Unlock()
+
+	var out = make([]imodels.Health, 0, len(records))
+	for _, record := range records {
+		health := imodels.Health{Status: imodels.HealthStatusDisabled}
+		if inb, ok := m.inboxes[record.ID]; ok {
+			health = inb.HealthCheck()
+			health.RateLimit = inb.RateLimit()
+		} else if record.Enabled {
+			// Enabled inboxes that are not running failed to initialize.
+			health.Status = imodels.HealthStatusDown
+		}
+		health.InboxID = record.ID
+		health.Name = record.Name
+		health.Channel = record.Channel
+
+		if h, ok := m.sendHealth[record.ID]; ok {
+			if !h.lastSendAt.IsZero() {
+				health.LastSendAt = null.TimeFrom(h.lastSendAt)
+			}
+			health.ConsecutiveSendFailures = h.consecutiveFailures
+			health.ThrottledCount = h.throttled
+			if h.consecutiveFailures > 0 && h.lastErrorAt.After(health.LastErrorAt.Time) {
+				health.LastError = h.lastError
+				health.LastErrorAt = null.TimeFrom(h.lastErrorAt)
+			}
+			if h.consecutiveFailures >= maxConsecutiveSendFailures && (health.Status == imodels.HealthStatusHealthy || health.Status == imodels.HealthStatusUnknown) {
+				health.Status = imodels.HealthStatusDegraded
+			}
+		}
+		out = append(out, health)
+	}
+	return out, nil
+}
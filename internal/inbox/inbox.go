@@ -8,12 +8,15 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 	"sync"
 
 	"github.com/abhinavxd/libredesk/internal/conversation/models"
 	"github.com/abhinavxd/libredesk/internal/dbutil"
 	"github.com/abhinavxd/libredesk/internal/envelope"
 	imodels "github.com/abhinavxd/libredesk/internal/inbox/models"
+	"github.com/abhinavxd/libredesk/internal/stringutil"
 	umodels "github.com/abhinavxd/libredesk/internal/user/models"
 	"github.com/jmoiron/sqlx"
 	"github.com/knadh/go-i18n"
@@ -21,7 +24,11 @@ import (
 )
 
 const (
-	ChannelEmail = "email"
+	ChannelEmail   = "email"
+	ChannelWebhook = "webhook"
+
+	// WebhookSecretMinLength is the minimum length of the secret webhook inbox payloads are signed with.
+	WebhookSecretMinLength = 16
 )
 
 var (
@@ -51,13 +58,25 @@ type MessageHandler interface {
 	Send(models.Message) error
 }
 
+// HealthChecker provides a method for reporting the connectivity health of an inbox.
+type HealthChecker interface {
+	HealthCheck() imodels.Health
+}
+
 // Inbox combines the operations of an inbox including its lifecycle, identification, and message handling.
 type Inbox interface {
 	Closer
 	Identifier
 	MessageHandler
+	HealthChecker
 	FromAddress() string
+	ReplyFromAddress(recipients []string) string
+	Aliases() []string
+	Footer() imodels.Footer
+	// PlainTextOnly returns true if the channel of the inbox can't display HTML, outgoing messages are sent as text.
+	PlainTextOnly() bool
 	Channel() string
+	RateLimit() imodels.RateLimit
 }
 
 // MessageStore defines methods for storing and processing messages.
@@ -88,6 +107,13 @@ type Manager struct {
 	msgStore     MessageStore
 	usrStore  UserStore
 	wg        sync.WaitGroup
+
+	// Outgoing message delivery results per inbox ID, recorded by the message sender workers.
+	sendMu     sync.Mutex
+	sendHealth map[int]*sendHealth
+
+	// Send rate limiters per inbox ID, guarded by mu.
+	limiters map[int]*tokenBucket
 }
 
 // Prepared queries.
@@ -111,9 +137,11 @@ func New(lo *logf.Logger, db *sqlx.DB, i18n *i18n.I18n) (*Manager, error) {
 	m := &Manager{
 		lo:        lo,
 		inboxes:   make(map[int]Inbox),
-		receivers: make(map[int]context.CancelFunc),
-		queries:   q,
-		i18n:      i18n,
+		receivers:  make(map[int]context.CancelFunc),
+		queries:    q,
+		i18n:       i18n,
+		sendHealth: make(map[int]*sendHealth),
+		limiters:   make(map[int]*tokenBucket),
 	}
 	return m, nil
 }
@@ -133,6 +161,7 @@ func (m *Manager) Register(i Inbox) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	m.inboxes[i.Identifier()] = i
+	m.limiters[i.Identifier()] = newTokenBucket(i.RateLimit())
 }
 
 // Get retrieves the initialized inbox instance with the specified ID from memory.
@@ -151,7 +180,7 @@ func (m *Manager) GetDBRecord(id int) (imodels.Inbox, error) {
 	var inbox imodels.Inbox
 	if err := m.queries.GetInbox.Get(&inbox, id); err != nil {
 		if err == sql.ErrNoRows {
-			return inbox, envelope.NewError(envelope.InputError, m.i18n.Ts("globals.messages.notFound", "name", "{globals.terms.inbox}"), nil)
+			return inbox, envelope.NewCodedError(envelope.InputError, envelope.ErrCodeInboxNotFound, m.i18n.Ts("globals.messages.notFound", "name", "{globals.terms.inbox}"), nil)
 		}
 		m.lo.Error("error fetching inbox", "error", err)
 		return inbox, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.inbox}"), nil)
@@ -171,7 +200,12 @@ func (m *Manager) GetAll() ([]imodels.Inbox, error) {
 
 // Create creates an inbox in the DB.
 func (m *Manager) Create(inbox imodels.Inbox) error {
-	if _, err := m.queries.InsertInbox.Exec(inbox.Channel, inbox.Config, inbox.Name, inbox.From, inbox.CSATEnabled); err != nil {
+	if inbox.Channel == ChannelWebhook {
+		if _, err := m.webhookConfig(inbox.Config, ""); err != nil {
+			return err
+		}
+	}
+	if _, err := m.queries.InsertInbox.Exec(inbox.Channel, inbox.Config, inbox.Name, inbox.From, inbox.CSATEnabled, inbox.TrackingEnabled); err != nil {
 		m.lo.Error("error creating inbox", "error", err)
 		return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorCreating", "name", "{globals.terms.inbox}"), nil)
 	}
@@ -199,6 +233,7 @@ func (m *Manager) InitInboxes(initFn initFn) error {
 			continue
 		}
 		m.inboxes[inbox.Identifier()] = inbox
+		m.limiters[inbox.Identifier()] = newTokenBucket(inbox.RateLimit())
 	}
 	return nil
 }
@@ -221,6 +256,7 @@ func (m *Manager) Reload(ctx context.Context, initFn initFn) error {
 
 	// Clear and reload inboxes.
 	m.inboxes = make(map[int]Inbox)
+	m.limiters = make(map[int]*tokenBucket)
 	inboxRecords, err := m.getActive()
 	if err != nil {
 		return fmt.Errorf("error fetching active inboxes: %v", err)
@@ -237,6 +273,7 @@ func (m *Manager) Reload(ctx context.Context, initFn initFn) error {
 			continue
 		}
 		m.inboxes[inbox.Identifier()] = inbox
+		m.limiters[inbox.Identifier()] = newTokenBucket(inbox.RateLimit())
 	}
 
 	// Start new receivers.
@@ -269,8 +306,16 @@ func (m *Manager) Update(id int, inbox imodels.Inbox) error {
 			SMTP []map[string]interface{} `json:"smtp"`
 		}
 		var updateCfg struct {
-			IMAP []map[string]interface{} `json:"imap"`
-			SMTP []map[string]interface{} `json:"smtp"`
+			IMAP                 []map[string]interface{}      `json:"imap"`
+			SMTP                 []map[string]interface{}      `json:"smtp"`
+			RateLimit            *imodels.RateLimit            `json:"rate_limit,omitempty"`
+			UnassignedEscalation *imodels.UnassignedEscalation `json:"unassigned_escalation,omitempty"`
+			Aliases              []string                      `json:"aliases,omitempty"`
+			PriorityDefaults     *imodels.PriorityDefaults     `json:"priority_defaults,omitempty"`
+			DefaultAssignee      *imodels.DefaultAssignee      `json:"default_assignee,omitempty"`
+			Footer               *imodels.Footer               `json:"footer,omitempty"`
+			PlainTextOnly        bool                          `json:"plain_text_only,omitempty"`
+			DeliveryReports      bool                          `json:"delivery_reports,omitempty"`
 		}
 
 		if err := json.Unmarshal(current.Config, &currentCfg); err != nil {
@@ -312,10 +357,24 @@ func (m *Manager) Update(id int, inbox imodels.Inbox) error {
 			return err
 		}
 		inbox.Config = updatedConfig
+	case ChannelWebhook:
+		var currentCfg struct {
+			Secret string `json:"secret"`
+		}
+		if err := json.Unmarshal(current.Config, &currentCfg); err != nil {
+			m.lo.Error("error unmarshalling current config", "id", id, "error", err)
+			return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorParsing", "name", "{globals.terms.config}"), nil)
+		}
+		// Preserve the existing secret if update has an empty secret.
+		updatedConfig, err := m.webhookConfig(inbox.Config, currentCfg.Secret)
+		if err != nil {
+			return err
+		}
+		inbox.Config = updatedConfig
 	}
 
 	// Update the inbox in the DB.
-	if _, err := m.queries.Update.Exec(id, inbox.Channel, inbox.Config, inbox.Name, inbox.From, inbox.CSATEnabled, inbox.Enabled); err != nil {
+	if _, err := m.queries.Update.Exec(id, inbox.Channel, inbox.Config, inbox.Name, inbox.From, inbox.CSATEnabled, inbox.Enabled, inbox.TrackingEnabled); err != nil {
 		m.lo.Error("error updating inbox", "error", err)
 		return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorUpdating", "name", "{globals.terms.inbox}"), nil)
 	}
@@ -323,6 +382,28 @@ func (m *Manager) Update(id int, inbox imodels.Inbox) error {
 	return nil
 }
 
+// webhookConfig validates the config of a webhook inbox, an empty secret or the masked secret returned with the
+// inbox is replaced with the given current secret.
+func (m *Manager) webhookConfig(config []byte, currentSecret string) ([]byte, error) {
+	if len(config) == 0 {
+		return nil, envelope.NewError(envelope.InputError, m.i18n.Ts("globals.messages.empty", "name", "{globals.terms.config}"), nil)
+	}
+	var cfg map[string]interface{}
+	if err := json.Unmarshal(config, &cfg); err != nil {
+		m.lo.Error("error unmarshalling webhook config", "error", err)
+		return nil, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorParsing", "name", "{globals.terms.config}"), nil)
+	}
+	secret, _ := cfg["secret"].(string)
+	if secret == "" || strings.Contains(secret, stringutil.PasswordDummy) {
+		secret = currentSecret
+	}
+	if len(secret) < WebhookSecretMinLength {
+		return nil, envelope.NewError(envelope.InputError, m.i18n.Ts("inbox.webhookSecretTooShort", "min", strconv.Itoa(WebhookSecretMinLength)), nil)
+	}
+	cfg["secret"] = secret
+	return json.Marshal(cfg)
+}
+
 // Toggle toggles the status of an inbox in the DB.
 func (m *Manager) Toggle(id int) error {
 	if _, err := m.queries.Toggle.Exec(id); err != nil {
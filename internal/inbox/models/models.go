@@ -6,43 +6,56 @@ import (
 	"time"
 
 	"github.com/abhinavxd/libredesk/internal/stringutil"
+	"github.com/volatiletech/null/v9"
 )
 
 // Inbox represents a inbox record in DB.
 type Inbox struct {
-	ID          int             `db:"id" json:"id"`
-	CreatedAt   time.Time       `db:"created_at" json:"created_at"`
-	UpdatedAt   time.Time       `db:"updated_at" json:"updated_at"`
-	Name        string          `db:"name" json:"name"`
-	Channel     string          `db:"channel" json:"channel"`
-	Enabled     bool            `db:"enabled" json:"enabled"`
-	CSATEnabled bool            `db:"csat_enabled" json:"csat_enabled"`
-	From        string          `db:"from" json:"from"`
-	Config      json.RawMessage `db:"config" json:"config"`
-}
-
-// ClearPasswords masks all config passwords
+	ID              int             `db:"id" json:"id"`
+	CreatedAt       time.Time       `db:"created_at" json:"created_at"`
+	UpdatedAt       time.Time       `db:"updated_at" json:"updated_at"`
+	Name            string          `db:"name" json:"name"`
+	Channel         string          `db:"channel" json:"channel"`
+	Enabled         bool            `db:"enabled" json:"enabled"`
+	CSATEnabled     bool            `db:"csat_enabled" json:"csat_enabled"`
+	TrackingEnabled bool            `db:"tracking_enabled" json:"tracking_enabled"`
+	From            string          `db:"from" json:"from"`
+	Config          json.RawMessage `db:"config" json:"config"`
+}
+
+// ClearPasswords masks all config passwords, the rest of the config is left as is.
 func (m *Inbox) ClearPasswords() error {
 	switch m.Channel {
 	case "email":
-		var cfg struct {
-			IMAP []map[string]interface{} `json:"imap"`
-			SMTP []map[string]interface{} `json:"smtp"`
-		}
-
+		var cfg map[string]interface{}
 		if err := json.Unmarshal(m.Config, &cfg); err != nil {
 			return err
 		}
 
 		dummyPassword := strings.Repeat(stringutil.PasswordDummy, 10)
 
-		for i := range cfg.IMAP {
-			cfg.IMAP[i]["password"] = dummyPassword
+		for _, key := range []string{"imap", "smtp"} {
+			servers, _ := cfg[key].([]interface{})
+			for _, s := range servers {
+				if server, ok := s.(map[string]interface{}); ok {
+					server["password"] = dummyPassword
+				}
+			}
+		}
+
+		clearedConfig, err := json.Marshal(cfg)
+		if err != nil {
+			return err
 		}
 
-		for i := range cfg.SMTP {
-			cfg.SMTP[i]["password"] = dummyPassword
+		m.Config = clearedConfig
+
+	case "webhook":
+		var cfg map[string]interface{}
+		if err := json.Unmarshal(m.Config, &cfg); err != nil {
+			return err
 		}
+		cfg["secret"] = strings.Repeat(stringutil.PasswordDummy, 10)
 
 		clearedConfig, err := json.Marshal(cfg)
 		if err != nil {
@@ -57,3 +70,83 @@ func (m *Inbox) ClearPasswords() error {
 
 	return nil
 }
+
+// Inbox health statuses.
+const (
+	HealthStatusHealthy  = "healthy"
+	HealthStatusDegraded = "degraded"
+	HealthStatusDown     = "down"
+	HealthStatusUnknown  = "unknown"
+	HealthStatusDisabled = "disabled"
+)
+
+// Health represents the connectivity health of an inbox.
+type Health struct {
+	InboxID                 int       `json:"inbox_id"`
+	Name                    string    `json:"name"`
+	Channel                 string    `json:"channel"`
+	Status                  string    `json:"status"`
+	Connected               bool      `json:"connected"`
+	LastPollAt              null.Time `json:"last_poll_at"`
+	LastSendAt              null.Time `json:"last_send_at"`
+	LastError               string    `json:"last_error"`
+	LastErrorAt             null.Time `json:"last_error_at"`
+	ConsecutiveSendFailures int       `json:"consecutive_send_failures"`
+	RateLimit               RateLimit `json:"rate_limit"`
+	ThrottledCount          int64     `json:"throttled_count"`
+}
+
+// Stats is the overview of an inbox shown to agents, with the conversation counts of the inbox in a conversations list.
+type Stats struct {
+	ID              int    `json:"id"`
+	Name            string `json:"name"`
+	Channel         string `json:"channel"`
+	Enabled         bool   `json:"enabled"`
+	Paused          bool   `json:"paused"`
+	HealthStatus    string `json:"health_status"`
+	OpenCount       int    `json:"open_count"`
+	UnassignedCount int    `json:"unassigned_count"`
+}
+
+// UnassignedEscalation escalates conversations of an inbox left without an assigned agent for `Minutes` after
+// being created, by moving them to the fallback team and alerting the user to notify. 0 minutes disables it.
+type UnassignedEscalation struct {
+	Minutes        int `json:"minutes"`
+	FallbackTeamID int `json:"fallback_team_id"`
+	NotifyUserID   int `json:"notify_user_id"`
+}
+
+// PriorityDefaults sets the initial priority of new conversations of an inbox. Contacts with a truthy `vip` custom
+// attribute get `VIPPriorityID`, other contacts get `DefaultPriorityID`. 0 leaves the conversation without a priority.
+type PriorityDefaults struct {
+	DefaultPriorityID int `json:"default_priority_id"`
+	VIPPriorityID     int `json:"vip_priority_id"`
+}
+
+// DefaultAssignee is the team and agent new conversations of an inbox are assigned to when they are created, before
+// the automation rules run. 0 leaves the conversation unassigned.
+type DefaultAssignee struct {
+	TeamID int `json:"team_id"`
+	UserID int `json:"user_id"`
+}
+
+// Footer is appended to every outgoing email of an inbox after the message content, e.g. a legal disclaimer.
+// `HTML` is used for HTML messages and `Text` for plain text ones, falling back to each other when one is empty.
+// The `{{ .UnsubscribeURL }}` placeholder renders `UnsubscribeURL`, which can use the contact and conversation
+// placeholders of the outgoing email template, e.g. `https://example.com/unsubscribe?email={{ .Contact.Email | urlquery }}`.
+type Footer struct {
+	HTML           string `json:"html"`
+	Text           string `json:"text"`
+	UnsubscribeURL string `json:"unsubscribe_url"`
+}
+
+// IsEmpty returns true if the footer has no content.
+func (f Footer) IsEmpty() bool {
+	return strings.TrimSpace(f.HTML) == "" && strings.TrimSpace(f.Text) == ""
+}
+
+// RateLimit is the outgoing message rate limit of an inbox, a zero `PerSecond` disables the limit.
+type RateLimit struct {
+	PerSecond float64 `json:"per_second"`
+	Burst     int     `json:"burst"`
+}
@@ -0,0 +1,73 @@
+package inbox
+
+import (
+	"sync"
+	"time"
+
+	imodels "github.com/abhinavxd/libredesk/internal/inbox/models"
+)
+
+// tokenBucket is a token bucket rate limiter, tokens refill at `rate` per second up to `burst`.
+type tokenBucket struct {
+	mu       sync.Mutex
+	rate     float64
+	burst    float64
+	tokens   float64
+	lastFill time.Time
+}
+
+// newTokenBucket returns a full token bucket for the rate limit, nil if the limit is disabled.
+// Burst defaults to the rate rounded up so that at least one message can be sent.
+func newTokenBucket(limit imodels.RateLimit) *tokenBucket {
+	if limit.PerSecond <= 0 {
+		return nil
+	}
+	burst := float64(limit.Burst)
+	if burst < 1 {
+		burst = max(1, float64(int(limit.PerSecond+0.999)))
+	}
+	return &tokenBucket{
+		rate:     limit.PerSecond,
+		burst:    burst,
+		tokens:   burst,
+		lastFill: time.Now(),
+	}
+}
+
+// allow takes a token from the bucket and returns false if none are available at `now`.
+func (b *tokenBucket) allow(now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if elapsed := now.Sub(b.lastFill).Seconds(); elapsed > 0 {
+		b.tokens = min(b.burst, b.tokens+elapsed*b.rate)
+		b.lastFill = now
+	}
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// AllowSend reports whether a message can be sent through the inbox now without exceeding its configured
+// send rate limit, throttled sends are counted and reported in the inbox health.
+func (m *Manager) AllowSend(inboxID int) bool {
+	m.mu.RLock()
+	bucket := m.limiters[inboxID]
+	m.mu.RUnlock()
+
+	if bucket == nil || bucket.allow(time.Now()) {
+		return true
+	}
+
+	m.sendMu.Lock()
+	defer m.sendMu.Unlock()
+	h, ok := m.sendHealth[inboxID]
+	if !ok {
+		h = &sendHealth{}
+		m.sendHealth[inboxID] = h
+	}
+	h.throttled++
+	return false
+}
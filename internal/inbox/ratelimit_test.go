@@ -0,0 +1,47 @@
+package inbox
+
+import (
+	"testing"
+	"time"
+
+	imodels "github.com/abhinavxd/libredesk/internal/inbox/models"
+)
+
+func TestTokenBucket(t *testing.T) {
+	if b := newTokenBucket(imodels.RateLimit{}); b != nil {
+		t.Fatal("expected no bucket for a disabled rate limit")
+	}
+
+	b := newTokenBucket(imodels.RateLimit{PerSecond: 2, Burst: 2})
+	now := b.lastFill
+
+	tests := []struct {
+		name     string
+		at       time.Duration
+		expected bool
+	}{
+		{name: "first token of the burst", at: 0, expected: true},
+		{name: "second token of the burst", at: 0, expected: true},
+		{name: "burst exhausted", at: 0, expected: false},
+		{name: "refilled after half a second", at: 500 * time.Millisecond, expected: true},
+		{name: "empty again", at: 500 * time.Millisecond, expected: false},
+		{name: "refill is capped at burst", at: 10 * time.Second, expected: true},
+		{name: "second token after long idle", at: 10 * time.Second, expected: true},
+		{name: "no third token after long idle", at: 10 * time.Second, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := b.allow(now.Add(tt.at)); got != tt.expected {
+				t.Errorf("got %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestTokenBucketDefaultBurst(t *testing.T) {
+	b := newTokenBucket(imodels.RateLimit{PerSecond: 0.5})
+	if b.burst != 1 {
+		t.Errorf("got burst %v, want 1", b.burst)
+	}
+}
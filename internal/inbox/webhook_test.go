@@ -0,0 +1,61 @@
+package inbox
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/abhinavxd/libredesk/internal/stringutil"
+	"github.com/knadh/go-i18n"
+	"github.com/zerodha/logf"
+)
+
+func TestWebhookConfig(t *testing.T) {
+	i, err := i18n.New([]byte(`{"_.code": "en", "_.name": "English"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lo := logf.New(logf.Opts{Writer: io.Discard})
+	m := &Manager{lo: &lo, i18n: i}
+
+	const current = "0123456789abcdef0123456789abcdef"
+	tests := []struct {
+		name     string
+		secret   string
+		current  string
+		expected string
+		err      bool
+	}{
+		{name: "new secret", secret: "fedcba9876543210fedcba9876543210", current: current, expected: "fedcba9876543210fedcba9876543210"},
+		{name: "empty keeps current", secret: "", current: current, expected: current},
+		{name: "masked keeps current", secret: strings.Repeat(stringutil.PasswordDummy, 10), current: current, expected: current},
+		{name: "masked without current", secret: strings.Repeat(stringutil.PasswordDummy, 10), err: true},
+		{name: "too short", secret: "short", current: current, err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, _ := json.Marshal(map[string]string{"secret": tt.secret})
+			got, err := m.webhookConfig(config, tt.current)
+			if tt.err {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			var cfg struct {
+				Secret string `json:"secret"`
+			}
+			if err := json.Unmarshal(got, &cfg); err != nil {
+				t.Fatal(err)
+			}
+			if cfg.Secret != tt.expected {
+				t.Errorf("got secret %q, want %q", cfg.Secret, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,155 @@
+// Package langdetect detects the language of a text using the script of its letters and common stopwords,
+// good enough for routing and translation without pulling in a statistical model.
+package langdetect
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+const (
+	// minLetters and minWords are the minimum text size for detection, shorter texts are too ambiguous
+	// and are reported as undetected with zero confidence.
+	minLetters = 12
+	minWords   = 3
+
+	// stopwordsForFullConfidence is the number of stopword hits of the best language needed for full confidence.
+	stopwordsForFullConfidence = 4
+)
+
+// Result is the detected language of a text.
+type Result struct {
+	// Lang is the ISO 639-1 language code, empty when the language could not be detected.
+	Lang string
+	// Confidence is between 0 and 1.
+	Confidence float64
+}
+
+// scripts maps the language of the scripts that are used by a single language.
+var scripts = []struct {
+	lang  string
+	table *unicode.RangeTable
+}{
+	{"el", unicode.Greek},
+	{"he", unicode.Hebrew},
+	{"ar", unicode.Arabic},
+	{"hi", unicode.Devanagari},
+	{"th", unicode.Thai},
+	{"ko", unicode.Hangul},
+	{"ru", unicode.Cyrillic},
+}
+
+// stopwords are frequent words of the languages written in the Latin script.
+var stopwords = map[string][]string{
+	"en": {"the", "and", "is", "are", "you", "of", "to", "in", "it", "that", "this", "for", "with", "was", "have", "not", "my", "please", "thanks", "can", "would", "will", "be"},
+	"de": {"der", "die", "das", "und", "ist", "nicht", "ich", "sie", "mit", "ein", "eine", "zu", "den", "auf", "für", "bitte", "danke", "haben", "wir", "mein", "sind", "auch"},
+	"fr": {"le", "la", "les", "et", "est", "je", "vous", "pas", "une", "des", "pour", "dans", "que", "avec", "merci", "mon", "sur", "nous", "ce", "au", "du"},
+	"es": {"el", "la", "los", "las", "y", "es", "que", "de", "no", "por", "para", "con", "una", "gracias", "mi", "está", "pero", "del", "se", "lo", "hola"},
+	"it": {"il", "la", "che", "di", "e", "non", "per", "una", "sono", "con", "grazie", "mi", "del", "della", "ho", "ciao", "questo", "anche", "gli"},
+	"pt": {"o", "a", "os", "que", "de", "não", "para", "com", "uma", "obrigado", "obrigada", "meu", "minha", "está", "do", "da", "em", "você", "olá"},
+	"nl": {"de", "het", "een", "en", "is", "niet", "ik", "van", "op", "voor", "met", "dat", "bedankt", "mijn", "zijn", "wij", "ook", "graag"},
+}
+
+// stopwordIndex maps a stopword to the languages it belongs to.
+var stopwordIndex = func() map[string][]string {
+	idx := make(map[string][]string)
+	for lang, words := range stopwords {
+		for _, w := range words {
+			idx[w] = append(idx[w], lang)
+		}
+	}
+	return idx
+}()
+
+// Detect returns the language of the text. Texts shorter than a few words are not detected.
+func Detect(text string) Result {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '\''
+	})
+
+	var letters, han, kana int
+	scriptCounts := make(map[string]int, len(scripts))
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		switch {
+		case unicode.Is(unicode.Han, r):
+			han++
+		case unicode.Is(unicode.Hiragana, r), unicode.Is(unicode.Katakana, r):
+			kana++
+		default:
+			for _, s := range scripts {
+				if unicode.Is(s.table, r) {
+					scriptCounts[s.lang]++
+					break
+				}
+			}
+		}
+	}
+	if letters < minLetters {
+		return Result{}
+	}
+
+	// CJK text has no spaces between words, so it's detected by script alone.
+	if han+kana > letters/2 {
+		if kana > 0 {
+			return result("ja", float64(han+kana)/float64(letters))
+		}
+		return result("zh", float64(han)/float64(letters))
+	}
+	for _, s := range scripts {
+		if n := scriptCounts[s.lang]; n > letters/2 {
+			return result(s.lang, float64(n)/float64(letters))
+		}
+	}
+
+	if len(words) < minWords {
+		return Result{}
+	}
+	return detectLatin(words)
+}
+
+// detectLatin detects the language of Latin script words by counting stopwords. Confidence is the share of
+// the best language among all stopword hits, scaled down when there are only a few hits.
+func detectLatin(words []string) Result {
+	var (
+		hits  = make(map[string]int)
+		total int
+	)
+	for _, w := range words {
+		langs, ok := stopwordIndex[w]
+		if !ok {
+			continue
+		}
+		for _, lang := range langs {
+			hits[lang]++
+			total++
+		}
+	}
+
+	var (
+		best     string
+		bestHits int
+	)
+	for lang, n := range hits {
+		// Ties go to the alphabetically first language so the result is deterministic.
+		if n > bestHits || (n == bestHits && lang < best) {
+			best, bestHits = lang, n
+		}
+	}
+	if bestHits == 0 {
+		return Result{}
+	}
+
+	share := float64(bestHits) / float64(total)
+	coverage := math.Min(1, float64(bestHits)/stopwordsForFullConfidence)
+	return result(best, share*coverage)
+}
+
+// result returns a result with the confidence rounded to two decimals.
+func result(lang string, confidence float64) Result {
+	return Result{Lang: lang, Confidence: math.Round(confidence*100) / 100}
+}
@@ -0,0 +1,49 @@
+package langdetect
+
+import "testing"
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name          string
+		text          string
+		expected      string
+		minConfidence float64
+	}{
+		{name: "english", text: "Hi, I have not received my order and it was supposed to be here last week. Can you please check?", expected: "en", minConfidence: 0.6},
+		{name: "german", text: "Hallo, ich habe meine Bestellung nicht erhalten und die Lieferung ist auch nicht auf dem Weg. Bitte helfen Sie mir.", expected: "de", minConfidence: 0.6},
+		{name: "french", text: "Bonjour, je n'ai pas reçu ma commande et je voudrais savoir où elle est. Merci pour votre aide.", expected: "fr", minConfidence: 0.5},
+		{name: "spanish", text: "Hola, no he recibido mi pedido y quiero saber por qué. Gracias por la ayuda con esto.", expected: "es", minConfidence: 0.5},
+		{name: "russian", text: "Здравствуйте, я не получил свой заказ.", expected: "ru", minConfidence: 0.9},
+		{name: "japanese", text: "注文した商品がまだ届いていません。確認してください。", expected: "ja", minConfidence: 0.9},
+		{name: "chinese", text: "我的订单还没有收到，请帮我查一下物流信息。", expected: "zh", minConfidence: 0.9},
+		{name: "short", text: "Thanks!", expected: ""},
+		{name: "few words", text: "Order 12345 refund", expected: ""},
+		{name: "no stopwords", text: "Invoice attached regarding shipment delays", expected: ""},
+		{name: "empty", text: "", expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Detect(tt.text)
+			if got.Lang != tt.expected {
+				t.Fatalf("got lang %q (confidence %.2f), want %q", got.Lang, got.Confidence, tt.expected)
+			}
+			if tt.expected == "" && got.Confidence != 0 {
+				t.Errorf("got confidence %.2f for undetected text, want 0", got.Confidence)
+			}
+			if got.Confidence < tt.minConfidence || got.Confidence > 1 {
+				t.Errorf("got confidence %.2f, want between %.2f and 1", got.Confidence, tt.minConfidence)
+			}
+		})
+	}
+}
+
+func TestDetectLowConfidenceForFewStopwords(t *testing.T) {
+	got := Detect("Package delivered to warehouse yesterday")
+	if got.Lang != "en" {
+		t.Fatalf("got lang %q, want en", got.Lang)
+	}
+	if got.Confidence >= 0.5 {
+		t.Errorf("got confidence %.2f, want below 0.5 for a single stopword", got.Confidence)
+	}
+}
@@ -0,0 +1,143 @@
+package macro
+
+import (
+	"encoding/json"
+	"slices"
+
+	amodels "github.com/abhinavxd/libredesk/internal/automation/models"
+	cmodels "github.com/abhinavxd/libredesk/internal/conversation/models"
+	"github.com/abhinavxd/libredesk/internal/envelope"
+	"github.com/abhinavxd/libredesk/internal/macro/models"
+	umodels "github.com/abhinavxd/libredesk/internal/user/models"
+)
+
+// conversationStore executes the actions of a macro, the same executor automation rules use.
+type conversationStore interface {
+	GetConversation(id int, uuid string) (cmodels.Conversation, error)
+	ApplyActions(uuid string, actions []amodels.RuleAction, user umodels.User) error
+	ApplyAction(action amodels.RuleAction, conversation cmodels.Conversation, user umodels.User) error
+}
+
+// SetConversationStore sets the conversation store macros are applied with.
+func (m *Manager) SetConversationStore(store conversationStore) {
+	m.conversationStore = store
+}
+
+// IsActionAllowed returns true if the action is allowed in a macro. Replies aren't actions of a macro, the
+// message content of the macro is the reply.
+func IsActionAllowed(action string) bool {
+	switch action {
+	case amodels.ActionSendPrivateNote, amodels.ActionReply:
+		return false
+	case amodels.ActionAssignTeam, amodels.ActionAssignUser, amodels.ActionSetStatus, amodels.ActionSetPriority, amodels.ActionAddTags, amodels.ActionSetTags, amodels.ActionRemoveTags:
+		return true
+	default:
+		return false
+	}
+}
+
+// HasActionPermission returns true if the permissions allow executing the action.
+func HasActionPermission(action string, permissions []string) bool {
+	requiredPerm, exists := amodels.ActionPermissions[action]
+	if !exists {
+		return false
+	}
+	return slices.Contains(permissions, requiredPerm)
+}
+
+// ApplyMacro applies the actions of the macro to the conversation, then sends the message content of the macro as a
+// reply. Every step is validated against the actor's permissions before any is executed, and the actions are applied
+// in a single transaction so the reply is only sent once all of them were applied. Emails and notifications of the
+// actions are sent once the actions are committed.
+func (m *Manager) ApplyMacro(conversationUUID string, macroID int, actor umodels.User) error {
+	macro, err := m.Get(macroID)
+	if err != nil {
+		return err
+	}
+	if !isVisibleTo(macro, actor) {
+		return envelope.NewError(envelope.PermissionError, m.i18n.Ts("globals.messages.denied", "name", "{globals.terms.permission}"), nil)
+	}
+	steps, err := m.macroSteps(macro, actor)
+	if err != nil {
+		return err
+	}
+	if len(steps) == 0 {
+		return envelope.NewError(envelope.InputError, m.i18n.T("macro.couldNotApply"), nil)
+	}
+
+	actions, reply := steps, amodels.RuleAction{}
+	if last := steps[len(steps)-1]; last.Type == amodels.ActionReply {
+		actions, reply = steps[:len(steps)-1], last
+	}
+	if len(actions) > 0 {
+		if err := m.conversationStore.ApplyActions(conversationUUID, actions, actor); err != nil {
+			m.lo.Error("error applying macro actions", "macro_id", macro.ID, "conversation_uuid", conversationUUID, "error", err)
+			return err
+		}
+	}
+
+	if reply.Type != "" {
+		// The reply sees the changes of the actions, e.g. the team it was assigned to.
+		conversation, err := m.conversationStore.GetConversation(0, conversationUUID)
+		if err != nil {
+			return err
+		}
+		if err := m.conversationStore.ApplyAction(reply, conversation, actor); err != nil {
+			m.lo.Error("error sending macro reply", "macro_id", macro.ID, "conversation_uuid", conversationUUID, "error", err)
+			if len(actions) == 0 {
+				return err
+			}
+			return envelope.NewError(envelope.GeneralError, m.i18n.T("macro.replyNotSent"), nil)
+		}
+	}
+
+	m.IncrementUsageCount(macro.ID)
+	return nil
+}
+
+// isVisibleTo returns true if the macro can be used by the user: macros are visible to all agents, to the members
+// of a team or to a single agent.
+func isVisibleTo(macro models.Macro, user umodels.User) bool {
+	switch macro.Visibility {
+	case "all":
+		return true
+	case "team":
+		return macro.TeamID != nil && slices.Contains(user.Teams.IDs(), *macro.TeamID)
+	case "user":
+		return macro.UserID != nil && *macro.UserID == user.ID
+	default:
+		return false
+	}
+}
+
+// macroSteps returns the actions of the macro followed by its reply, after checking each is allowed for the actor.
+func (m *Manager) macroSteps(macro models.Macro, actor umodels.User) ([]amodels.RuleAction, error) {
+	var actions []amodels.RuleAction
+	if len(macro.Actions) > 0 {
+		if err := json.Unmarshal(macro.Actions, &actions); err != nil {
+			m.lo.Error("error unmarshalling macro actions", "macro_id", macro.ID, "error", err)
+			return nil, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorParsing", "name", "{globals.terms.macroAction}"), nil)
+		}
+	}
+
+	for _, act := range actions {
+		if !IsActionAllowed(act.Type) {
+			return nil, envelope.NewError(envelope.PermissionError, m.i18n.Ts("macro.actionNotAllowed", "name", act.Type), nil)
+		}
+		if len(act.Value) == 0 {
+			return nil, envelope.NewError(envelope.InputError, m.i18n.Ts("macro.emptyActionValue", "name", act.Type), nil)
+		}
+		if !HasActionPermission(act.Type, actor.Permissions) {
+			return nil, envelope.NewError(envelope.PermissionError, m.i18n.T("macro.permissionDenied"), nil)
+		}
+	}
+
+	if macro.MessageContent != "" {
+		reply := amodels.RuleAction{Type: amodels.ActionReply, Value: []string{macro.MessageContent}}
+		if !HasActionPermission(reply.Type, actor.Permissions) {
+			return nil, envelope.NewError(envelope.PermissionError, m.i18n.T("macro.permissionDenied"), nil)
+		}
+		actions = append(actions, reply)
+	}
+	return actions, nil
+}
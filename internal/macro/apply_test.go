@@ -0,0 +1,127 @@
+package macro
+
+import (
+	"io"
+	"testing"
+
+	authzmodels "github.com/abhinavxd/libredesk/internal/authz/models"
+	amodels "github.com/abhinavxd/libredesk/internal/automation/models"
+	"github.com/abhinavxd/libredesk/internal/macro/models"
+	tmodels "github.com/abhinavxd/libredesk/internal/team/models"
+	umodels "github.com/abhinavxd/libredesk/internal/user/models"
+	"github.com/knadh/go-i18n"
+	"github.com/zerodha/logf"
+)
+
+func TestMacroSteps(t *testing.T) {
+	lo := logf.New(logf.Opts{Writer: io.Discard})
+	i, err := i18n.New([]byte(`{"_.code": "en", "_.name": "English"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := &Manager{lo: &lo, i18n: i}
+
+	agent := umodels.User{Permissions: []string{
+		authzmodels.PermConversationsUpdateStatus,
+		authzmodels.PermConversationsUpdateTeamAssignee,
+		authzmodels.PermConversationsUpdateTags,
+		authzmodels.PermMessagesWrite,
+	}}
+	actions := `[{"type": "set_status", "value": ["2"]}, {"type": "assign_team", "value": ["1"]}, {"type": "add_tags", "value": ["billing"]}]`
+
+	tests := []struct {
+		name     string
+		macro    models.Macro
+		actor    umodels.User
+		expected []string
+		wantErr  bool
+	}{
+		{
+			name:     "actions in order then the reply",
+			macro:    models.Macro{Actions: []byte(actions), MessageContent: "Thanks, we're on it."},
+			actor:    agent,
+			expected: []string{amodels.ActionSetStatus, amodels.ActionAssignTeam, amodels.ActionAddTags, amodels.ActionReply},
+		},
+		{
+			name:     "no reply without message content",
+			macro:    models.Macro{Actions: []byte(actions)},
+			actor:    agent,
+			expected: []string{amodels.ActionSetStatus, amodels.ActionAssignTeam, amodels.ActionAddTags},
+		},
+		{
+			name:    "action not allowed in macros",
+			macro:   models.Macro{Actions: []byte(`[{"type": "send_private_note", "value": ["note"]}]`)},
+			actor:   agent,
+			wantErr: true,
+		},
+		{
+			name:    "empty action value",
+			macro:   models.Macro{Actions: []byte(`[{"type": "set_status", "value": []}]`)},
+			actor:   agent,
+			wantErr: true,
+		},
+		{
+			name:    "missing action permission",
+			macro:   models.Macro{Actions: []byte(actions)},
+			actor:   umodels.User{Permissions: []string{authzmodels.PermConversationsUpdateStatus}},
+			wantErr: true,
+		},
+		{
+			name:    "missing reply permission",
+			macro:   models.Macro{MessageContent: "Hello"},
+			actor:   umodels.User{},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := m.macroSteps(tt.macro, tt.actor)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if len(got) != len(tt.expected) {
+				t.Fatalf("got %d steps, want %d", len(got), len(tt.expected))
+			}
+			for i := range got {
+				if got[i].Type != tt.expected[i] {
+					t.Errorf("at index %d got %s, want %s", i, got[i].Type, tt.expected[i])
+				}
+			}
+		})
+	}
+}
+
+func TestIsVisibleTo(t *testing.T) {
+	var (
+		teamID  = 2
+		otherID = 3
+		userID  = 7
+		agent   = umodels.User{ID: userID, Teams: tmodels.Teams{{ID: teamID}}}
+	)
+	tests := []struct {
+		name     string
+		macro    models.Macro
+		expected bool
+	}{
+		{"visible to all", models.Macro{Visibility: "all"}, true},
+		{"team of the agent", models.Macro{Visibility: "team", TeamID: &teamID}, true},
+		{"another team", models.Macro{Visibility: "team", TeamID: &otherID}, false},
+		{"team macro without team", models.Macro{Visibility: "team"}, false},
+		{"the agent's macro", models.Macro{Visibility: "user", UserID: &userID}, true},
+		{"another agent's macro", models.Macro{Visibility: "user", UserID: &otherID}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isVisibleTo(tt.macro, agent); got != tt.expected {
+				t.Errorf("isVisibleTo() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
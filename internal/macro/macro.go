@@ -5,12 +5,14 @@ import (
 	"database/sql"
 	"embed"
 	"encoding/json"
+	"strings"
 
 	"github.com/abhinavxd/libredesk/internal/dbutil"
 	"github.com/abhinavxd/libredesk/internal/envelope"
 	"github.com/abhinavxd/libredesk/internal/macro/models"
 	"github.com/jmoiron/sqlx"
 	"github.com/knadh/go-i18n"
+	"github.com/lib/pq"
 	"github.com/zerodha/logf"
 )
 
@@ -19,11 +21,18 @@ var (
 	efs embed.FS
 )
 
+const (
+	// Default and maximum number of suggestions returned by Suggest.
+	defaultSuggestLimit = 10
+	maxSuggestLimit     = 50
+)
+
 // Manager is the macro manager.
 type Manager struct {
-	q    queries
-	lo   *logf.Logger
-	i18n *i18n.I18n
+	q                 queries
+	conversationStore conversationStore
+	lo                *logf.Logger
+	i18n              *i18n.I18n
 }
 
 // Predefined queries.
@@ -34,6 +43,7 @@ type queries struct {
 	Update        *sqlx.Stmt `query:"update"`
 	Delete        *sqlx.Stmt `query:"delete"`
 	IncUsageCount *sqlx.Stmt `query:"increment-usage-count"`
+	Suggest       *sqlx.Stmt `query:"suggest"`
 }
 
 // Opts contains the dependencies for the macro manager.
@@ -121,3 +131,28 @@ func (m *Manager) IncrementUsageCount(id int) error {
 	}
 	return nil
 }
+
+// Suggest returns the macros whose name or a word of it starts with the typed prefix, e.g. `/ref` suggests the
+// refund macro, ranked by how often they were used. Only the macros shared with all agents, with one of the agent's
+// teams or owned by the agent are returned.
+func (m *Manager) Suggest(prefix string, limit, userID int, teamIDs []int) ([]models.Macro, error) {
+	macros := make([]models.Macro, 0)
+	prefix = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(prefix), "/"))
+	if prefix == "" {
+		return macros, nil
+	}
+	if limit <= 0 {
+		limit = defaultSuggestLimit
+	}
+	limit = min(limit, maxSuggestLimit)
+	if err := m.q.Suggest.Select(&macros, escapeLike(prefix), userID, pq.Array(teamIDs), limit); err != nil {
+		m.lo.Error("error fetching macro suggestions", "error", err)
+		return macros, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", m.i18n.P("globals.terms.macro")), nil)
+	}
+	return macros, nil
+}
+
+// escapeLike escapes the LIKE pattern characters in `s` so it's matched literally.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
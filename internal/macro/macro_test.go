@@ -0,0 +1,17 @@
+package macro
+
+import "testing"
+
+func TestEscapeLike(t *testing.T) {
+	tests := map[string]string{
+		"refund":    "refund",
+		"100%":      `100\%`,
+		"re_fund":   `re\_fund`,
+		`back\path`: `back\\path`,
+	}
+	for in, want := range tests {
+		if got := escapeLike(in); got != want {
+			t.Errorf("escapeLike(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
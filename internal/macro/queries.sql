@@ -64,4 +64,31 @@ UPDATE
 SET
     usage_count = usage_count + 1
 WHERE
-    id = $1;
\ No newline at end of file
+    id = $1;
+
+-- name: suggest
+-- Macros whose name or a word of it starts with the prefix, visible to all agents, the agent's teams or the agent.
+SELECT
+    id,
+    name,
+    message_content,
+    created_at,
+    updated_at,
+    visibility,
+    user_id,
+    team_id,
+    actions,
+    usage_count
+FROM
+    macros
+WHERE
+    (name ILIKE $1 || '%' OR name ILIKE '% ' || $1 || '%')
+    AND (
+        visibility = 'all'
+        OR (visibility = 'team' AND team_id = ANY($3::INT[]))
+        OR (visibility = 'user' AND user_id = $2)
+    )
+ORDER BY
+    usage_count DESC,
+    name
+LIMIT $4;
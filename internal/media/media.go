@@ -3,8 +3,10 @@ package media
 
 import (
 	"context"
+	"crypto/sha256"
 	"database/sql"
 	"embed"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
@@ -26,12 +28,16 @@ var (
 	efs embed.FS
 )
 
+// thumbPrefix is the prefix of the names of image thumbnails, which are named after the blob of their image.
+const thumbPrefix = "thumb_"
+
 // Store defines the interface for media storage operations.
 type Store interface {
 	Put(name, contentType string, content io.ReadSeeker) (string, error)
 	Delete(name string) error
 	GetURL(name string) string
 	GetBlob(name string) ([]byte, error)
+	GetReader(name string) (io.ReadCloser, error)
 	Name() string
 }
 
@@ -40,6 +46,7 @@ type Manager struct {
 	store   Store
 	lo      *logf.Logger
 	i18n    *i18n.I18n
+	db      *sqlx.DB
 	queries queries
 }
 
@@ -61,6 +68,7 @@ func New(opt Opts) (*Manager, error) {
 		store:   opt.Store,
 		lo:      opt.Lo,
 		i18n:    opt.I18n,
+		db:      opt.DB,
 		queries: q,
 	}, nil
 }
@@ -75,22 +83,72 @@ type queries struct {
 	GetByModel              *sqlx.Stmt `query:"get-model-media"`
 	GetUnlinkedMessageMedia *sqlx.Stmt `query:"get-unlinked-message-media"`
 	ContentIDExists         *sqlx.Stmt `query:"content-id-exists"`
+	GetBlobByHash           *sqlx.Stmt `query:"get-blob-by-hash"`
+	CountBlobReferences     *sqlx.Stmt `query:"count-blob-references"`
+	UpdateDisposition       *sqlx.Stmt `query:"update-media-disposition"`
 }
 
 // UploadAndInsert uploads file on storage and inserts an entry in db.
+// Files identical to an already stored file share its blob instead of being uploaded again.
 func (m *Manager) UploadAndInsert(srcFilename, contentType, contentID string, modelType null.String, modelID null.Int, content io.ReadSeeker, fileSize int, disposition null.String, meta []byte) (models.Media, error) {
 	var uuid = uuid.New()
-	_, err := m.Upload(uuid.String(), contentType, content)
+
+	hash, err := contentHash(content)
 	if err != nil {
-		return models.Media{}, err
+		m.lo.Error("error hashing media content", "error", err)
+		return models.Media{}, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorUploading", "name", "{globals.terms.media}"), nil)
+	}
+
+	// User avatars are referenced by URL, which for remote stores contains the blob name, so they always get their own blob.
+	if modelType.String != models.ModelUser {
+		id, err := m.insertShared(disposition, srcFilename, contentType, contentID, modelType, uuid.String(), hash, modelID, fileSize, meta)
+		if err != nil {
+			return models.Media{}, err
+		}
+		if id > 0 {
+			return m.Get(id, "")
+		}
 	}
 
-	media, err := m.Insert(disposition, srcFilename, contentType, contentID, modelType, uuid.String(), modelID, fileSize, meta)
+	if _, err := m.Upload(uuid.String(), contentType, content); err != nil {
+		return models.Media{}, err
+	}
+	id, err := m.insert(m.queries.Insert, disposition, srcFilename, contentType, contentID, modelType, uuid.String(), uuid.String(), hash, modelID, fileSize, meta, 0)
 	if err != nil {
 		m.store.Delete(uuid.String())
 		return models.Media{}, err
 	}
-	return media, nil
+	return m.Get(id, "")
+}
+
+// insertShared inserts the media in the blob of an already stored file with the same content hash, returning 0 if
+// there's no such file. The media sharing the blob is locked until the insert is committed, so the blob can't be
+// deleted by a concurrent delete of its last other reference in between.
+func (m *Manager) insertShared(disposition null.String, fileName, contentType, contentID string, modelType null.String, uuid, hash string, modelID null.Int, fileSize int, meta []byte) (int, error) {
+	tx, err := m.db.BeginTxx(context.Background(), nil)
+	if err != nil {
+		m.lo.Error("error starting db txn", "error", err)
+		return 0, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorUploading", "name", "{globals.terms.media}"), nil)
+	}
+	defer tx.Rollback()
+
+	var blobName string
+	if err := tx.Stmtx(m.queries.GetBlobByHash).Get(&blobName, m.store.Name(), hash); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, nil
+		}
+		m.lo.Error("error fetching media by content hash", "error", err)
+		return 0, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorUploading", "name", "{globals.terms.media}"), nil)
+	}
+	id, err := m.insert(tx.Stmtx(m.queries.Insert), disposition, fileName, contentType, contentID, modelType, uuid, blobName, hash, modelID, fileSize, meta, 0)
+	if err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		m.lo.Error("error committing media insert", "error", err)
+		return 0, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorInserting", "name", "{globals.terms.media}"), nil)
+	}
+	return id, nil
 }
 
 // Upload saves the media file to the storage backend and returns the generated filename.
@@ -103,14 +161,37 @@ func (m *Manager) Upload(fileName, contentType string, content io.ReadSeeker) (s
 	return fName, nil
 }
 
-// Insert inserts media details into the database and returns the inserted media record.
-func (m *Manager) Insert(disposition null.String, fileName, contentType, contentID string, modelType null.String, uuid string, modelID null.Int, fileSize int, meta []byte) (models.Media, error) {
+// Insert inserts media details into the database and returns the inserted media record. uploadedBy is the agent
+// who uploaded the file, 0 if it wasn't uploaded by an agent.
+func (m *Manager) Insert(disposition null.String, fileName, contentType, contentID string, modelType null.String, uuid string, modelID null.Int, fileSize int, meta []byte, uploadedBy int) (models.Media, error) {
+	id, err := m.insert(m.queries.Insert, disposition, fileName, contentType, contentID, modelType, uuid, uuid, "", modelID, fileSize, meta, uploadedBy)
+	if err != nil {
+		return models.Media{}, err
+	}
+	return m.Get(id, "")
+}
+
+// insert inserts media details stored in the passed blob into the database with the insert statement and returns the
+// ID of the inserted media record.
+func (m *Manager) insert(stmt *sqlx.Stmt, disposition null.String, fileName, contentType, contentID string, modelType null.String, uuid, blobName, hash string, modelID null.Int, fileSize int, meta []byte, uploadedBy int) (int, error) {
 	var id int
-	if err := m.queries.Insert.QueryRow(m.store.Name(), fileName, contentType, fileSize, meta, modelID, modelType, disposition, contentID, uuid).Scan(&id); err != nil {
+	if err := stmt.QueryRow(m.store.Name(), fileName, contentType, fileSize, meta, modelID, modelType, disposition, contentID, uuid, blobName, hash, uploadedBy).Scan(&id); err != nil {
 		m.lo.Error("error inserting media", "error", err)
-		return models.Media{}, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorInserting", "name", "{globals.terms.media}"), nil)
+		return 0, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorInserting", "name", "{globals.terms.media}"), nil)
 	}
-	return m.Get(id, "")
+	return id, nil
+}
+
+// contentHash returns the hex encoded SHA-256 hash of the content and rewinds it.
+func contentHash(content io.ReadSeeker) (string, error) {
+	h := sha256.New()
+	if _, err := io.Copy(h, content); err != nil {
+		return "", err
+	}
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
 }
 
 // Get retrieves the media record by its ID and returns the media.
@@ -123,7 +204,7 @@ func (m *Manager) Get(id int, uuid string) (models.Media, error) {
 		m.lo.Error("error fetching media", "error", err)
 		return media, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.media}"), nil)
 	}
-	media.URL = m.store.GetURL(media.UUID)
+	media.URL = m.GetMediaURL(media.UUID, media.BlobName)
 	return media, nil
 }
 
@@ -145,11 +226,25 @@ func (m *Manager) GetBlob(name string) ([]byte, error) {
 	return m.store.GetBlob(name)
 }
 
+// GetReader opens the content of a media file by its name for reading, the caller must close it.
+func (m *Manager) GetReader(name string) (io.ReadCloser, error) {
+	return m.store.GetReader(name)
+}
+
 // GetURL returns the URL for accessing a media file by its name.
 func (m *Manager) GetURL(name string) string {
 	return m.store.GetURL(name)
 }
 
+// GetMediaURL returns the URL for accessing a media file. Files in the local store are served by the app using the
+// media UUID so that access to the linked model is checked, other stores link to the blob which may be shared by identical files.
+func (m *Manager) GetMediaURL(uuid, blobName string) string {
+	if blobName == "" || m.store.Name() == "fs" {
+		return m.store.GetURL(uuid)
+	}
+	return m.store.GetURL(blobName)
+}
+
 // Attach associates a media file with a specific model by its ID and model name.
 func (m *Manager) Attach(id int, model string, modelID int) error {
 	if _, err := m.queries.Attach.Exec(id, model, modelID); err != nil {
@@ -159,6 +254,15 @@ func (m *Manager) Attach(id int, model string, modelID int) error {
 	return nil
 }
 
+// UpdateDisposition sets the content disposition of a media file, `inline` or `attachment`.
+func (m *Manager) UpdateDisposition(id int, disposition string) error {
+	if _, err := m.queries.UpdateDisposition.Exec(id, disposition); err != nil {
+		m.lo.Error("error updating media disposition", "media_id", id, "disposition", disposition, "error", err)
+		return fmt.Errorf("updating disposition of media:%d: %w", id, err)
+	}
+	return nil
+}
+
 // GetByModel retrieves all media files attached to a specific model.
 func (m *Manager) GetByModel(modelID int, model string) ([]models.Media, error) {
 	var media = make([]models.Media, 0)
@@ -169,18 +273,61 @@ func (m *Manager) GetByModel(modelID int, model string) ([]models.Media, error)
 	return media, nil
 }
 
-// Delete deletes a media file from both the storage backend and the database.
+// Delete deletes a media record from the database and its file and thumbnail from the storage backend, unless the
+// file is shared with other media records. Names that are not media UUIDs, like thumbnails, only have a file in the
+// storage backend.
 func (m *Manager) Delete(name string) error {
-	if err := m.store.Delete(name); err != nil {
-		m.lo.Error("error deleting media from store", "error", err)
-		// If the file does not exist, ignore the error.
-		if !errors.Is(err, os.ErrNotExist) {
-			return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorDeleting", "name", "{globals.terms.media}"), nil)
-		}
+	if _, err := uuid.Parse(name); err != nil {
+		return m.deleteFile(name)
+	}
+
+	blobName, refs, err := m.deleteRecord(name)
+	if err != nil {
+		return err
+	}
+	// Keep the file if other media records still reference it.
+	if refs > 0 {
+		return nil
+	}
+	if err := m.deleteFile(thumbPrefix + blobName); err != nil {
+		return err
 	}
-	// Delete the media record from the database.
-	if _, err := m.queries.Delete.Exec(name); err != nil {
+	return m.deleteFile(blobName)
+}
+
+// deleteRecord deletes the media record with the UUID and returns the name of its blob with the number of media
+// records still referencing it. The references are counted in the same transaction after the delete, which waits for
+// uploads sharing the blob to be committed, so a blob that was just shared is never counted as unreferenced.
+func (m *Manager) deleteRecord(uuid string) (string, int, error) {
+	tx, err := m.db.BeginTxx(context.Background(), nil)
+	if err != nil {
+		m.lo.Error("error starting db txn", "error", err)
+		return "", 0, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorDeleting", "name", "{globals.terms.media}"), nil)
+	}
+	defer tx.Rollback()
+
+	// Media without a record still has its file deleted.
+	blobName := uuid
+	if err := tx.Stmtx(m.queries.Delete).QueryRow(uuid).Scan(&blobName); err != nil && !errors.Is(err, sql.ErrNoRows) {
 		m.lo.Error("error deleting media from db", "error", err)
+		return "", 0, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorDeleting", "name", "{globals.terms.media}"), nil)
+	}
+	var refs int
+	if err := tx.Stmtx(m.queries.CountBlobReferences).Get(&refs, blobName); err != nil {
+		m.lo.Error("error counting media blob references", "error", err)
+		return "", 0, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorDeleting", "name", "{globals.terms.media}"), nil)
+	}
+	if err := tx.Commit(); err != nil {
+		m.lo.Error("error committing media delete", "error", err)
+		return "", 0, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorDeleting", "name", "{globals.terms.media}"), nil)
+	}
+	return blobName, refs, nil
+}
+
+// deleteFile deletes a file from the storage backend, files that don't exist are ignored.
+func (m *Manager) deleteFile(name string) error {
+	if err := m.store.Delete(name); err != nil && !errors.Is(err, os.ErrNotExist) {
+		m.lo.Error("error deleting media from store", "name", name, "error", err)
 		return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorDeleting", "name", "{globals.terms.media}"), nil)
 	}
 	return nil
@@ -10,7 +10,8 @@ const (
 	ModelMessages = "messages"
 	ModelUser     = "users"
 
-	DispositionInline = "inline"
+	DispositionInline     = "inline"
+	DispositionAttachment = "attachment"
 )
 
 // Media represents an uploaded object.
@@ -25,6 +26,7 @@ type Media struct {
 	Size        int         `db:"size" json:"size"`
 	Store       string      `db:"store" json:"store"`
 	Disposition null.String `db:"disposition" json:"disposition"`
+	BlobName    string      `db:"blob_name" json:"-"`
 	URL         string      `json:"url"`
 	ContentID   string      `json:"-"`
 	Content     []byte      `json:"-"`
@@ -1,5 +1,5 @@
 -- name: insert-media
-INSERT INTO media (store, filename, content_type, size, meta, model_id, model_type, disposition, content_id, uuid)
+INSERT INTO media (store, filename, content_type, size, meta, model_id, model_type, disposition, content_id, uuid, blob_name, content_hash, uploaded_by)
 VALUES(
   $1, 
   $2, 
@@ -10,12 +10,15 @@ VALUES(
   NULLIF($7, ''),
   $8,
   $9,
-  $10
+  $10,
+  $11,
+  NULLIF($12, ''),
+  NULLIF($13, 0)
 )
 RETURNING id;
 
 -- name: get-media
-SELECT id, created_at, "uuid", store, filename, content_type, model_id, model_type, "size", disposition
+SELECT id, created_at, "uuid", store, filename, content_type, model_id, model_type, "size", disposition, COALESCE(blob_name, uuid::TEXT) AS blob_name
 FROM media
 WHERE 
    ($1 > 0 AND id = $1)
@@ -23,13 +26,21 @@ WHERE
    ($2 != '' AND uuid = $2::uuid)
 
 -- name: get-media-by-uuid
-SELECT id, created_at, "uuid", store, filename, content_type, model_id, model_type, "size", disposition
+SELECT id, created_at, "uuid", store, filename, content_type, model_id, model_type, "size", disposition, COALESCE(blob_name, uuid::TEXT) AS blob_name
 FROM media
 WHERE uuid = $1;
 
 -- name: delete-media
 DELETE FROM media
-WHERE uuid = $1;
+WHERE uuid = $1
+RETURNING COALESCE(blob_name, uuid::TEXT);
+
+-- name: get-blob-by-hash
+-- The media is locked so that it can't be deleted before the media sharing its blob is inserted.
+SELECT COALESCE(blob_name, uuid::TEXT) FROM media WHERE store = $1 AND content_hash = $2 LIMIT 1 FOR SHARE;
+
+-- name: count-blob-references
+SELECT COUNT(*) FROM media WHERE blob_name = $1 OR (blob_name IS NULL AND uuid = $1::UUID);
 
 -- name: attach-to-model
 UPDATE media
@@ -37,14 +48,17 @@ SET model_type = $2,
     model_id = $3
 WHERE id = $1;
 
+-- name: update-media-disposition
+UPDATE media SET disposition = $2, updated_at = NOW() WHERE id = $1;
+
 -- name: get-model-media
-SELECT id, created_at, "uuid", store, filename, content_type, model_id, model_type, "size", disposition
+SELECT id, created_at, "uuid", store, filename, content_type, model_id, model_type, "size", disposition, COALESCE(blob_name, uuid::TEXT) AS blob_name
 FROM media
 WHERE model_type = $1
     AND model_id = $2;
 
 -- name: get-unlinked-message-media
-SELECT id, created_at, "uuid", store, filename, content_type, model_id, model_type, "size", disposition
+SELECT id, created_at, "uuid", store, filename, content_type, model_id, model_type, "size", disposition, COALESCE(blob_name, uuid::TEXT) AS blob_name
 FROM media
 WHERE model_type = 'messages' 
   AND (model_id IS NULL OR model_id = 0) 
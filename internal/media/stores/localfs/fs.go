@@ -58,6 +58,11 @@ func (c *Client) GetBlob(url string) ([]byte, error) {
 	return b, err
 }
 
+// GetReader accepts a URL and opens the file for reading.
+func (c *Client) GetReader(url string) (io.ReadCloser, error) {
+	return os.Open(filepath.Join(getDir(c.opts.UploadPath), filepath.Base(url)))
+}
+
 // Delete accepts a filename and removes it from disk.
 func (c *Client) Delete(file string) error {
 	dir := getDir(c.opts.UploadPath)
@@ -37,7 +37,10 @@ type Client struct {
 // New creates and initializes a new S3 client with the provided options.
 // It sets up the `simples3` client for interacting with AWS S3 APIs.
 func New(opt Opt) (media.Store, error) {
-	var cl *simples3.S3
+	var (
+		cl  *simples3.S3
+		err error
+	)
 
 	if opt.URL == "" {
 		opt.URL = fmt.Sprintf("https://s3.%s.amazonaws.com", opt.Region)
@@ -49,8 +52,12 @@ func New(opt Opt) (media.Store, error) {
 		opt.Expiry = 7 * 24 * time.Hour // Default to 7 days
 	}
 
+	// Without keys, credentials are taken from the IAM role of the instance.
 	if opt.AccessKey == "" && opt.SecretKey == "" {
-		cl, _ = simples3.NewUsingIAM(opt.Region)
+		cl, err = simples3.NewUsingIAM(opt.Region)
+		if err != nil {
+			return nil, fmt.Errorf("getting s3 credentials from IAM: %w", err)
+		}
 	} else {
 		cl = simples3.New(opt.Region, opt.AccessKey, opt.SecretKey)
 	}
@@ -106,16 +113,7 @@ func (c *Client) GetURL(name string) string {
 // GetBlob retrieves the file content from S3 as a byte slice.
 // It parses the URL, downloads the file, and returns its content or an error.
 func (c *Client) GetBlob(uurl string) ([]byte, error) {
-	if p, err := url.Parse(uurl); err != nil {
-		uurl = filepath.Base(uurl)
-	} else {
-		uurl = filepath.Base(p.Path)
-	}
-
-	file, err := c.s3.FileDownload(simples3.DownloadInput{
-		Bucket:    c.opts.Bucket,
-		ObjectKey: c.makeBucketPath(filepath.Base(uurl)),
-	})
+	file, err := c.GetReader(uurl)
 	if err != nil {
 		return nil, err
 	}
@@ -128,6 +126,20 @@ func (c *Client) GetBlob(uurl string) ([]byte, error) {
 	return b, nil
 }
 
+// GetReader parses the URL and returns a reader streaming the file from S3, the caller must close it.
+func (c *Client) GetReader(uurl string) (io.ReadCloser, error) {
+	if p, err := url.Parse(uurl); err != nil {
+		uurl = filepath.Base(uurl)
+	} else {
+		uurl = filepath.Base(p.Path)
+	}
+
+	return c.s3.FileDownload(simples3.DownloadInput{
+		Bucket:    c.opts.Bucket,
+		ObjectKey: c.makeBucketPath(filepath.Base(uurl)),
+	})
+}
+
 // Delete removes the file identified by name from S3.
 // It returns an error if the deletion fails.
 func (c *Client) Delete(name string) error {
@@ -0,0 +1,667 @@
+package migrations
+
+import (
+	"github.com/jmoiron/sqlx"
+	"github.com/knadh/koanf/v2"
+	"github.com/knadh/stuffbin"
+)
+
+// V0_7_0 updates the database schema to v0.7.0.
+func V0_7_0(db *sqlx.DB, fs stuffbin.FileSystem, ko *koanf.Koanf) error {
+	// Create table for persisting the round robin assignment cursor of automation rules per team.
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS automation_assignment_cursors (
+			team_id INT PRIMARY KEY REFERENCES teams(id) ON DELETE CASCADE ON UPDATE CASCADE,
+			last_assigned_user_id BIGINT REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
+			updated_at TIMESTAMPTZ DEFAULT NOW()
+		);
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Add required flag to custom attribute definitions.
+	_, err = db.Exec(`
+		ALTER TABLE custom_attribute_definitions ADD COLUMN IF NOT EXISTS required BOOLEAN DEFAULT FALSE NOT NULL;
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create table for agent reply and note drafts.
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS conversation_drafts (
+			id BIGSERIAL PRIMARY KEY,
+			created_at TIMESTAMPTZ DEFAULT NOW(),
+			updated_at TIMESTAMPTZ DEFAULT NOW(),
+			user_id BIGINT REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
+			conversation_id BIGINT REFERENCES conversations(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
+			content TEXT NOT NULL,
+			private BOOLEAN DEFAULT FALSE NOT NULL
+		);
+		CREATE UNIQUE INDEX IF NOT EXISTS index_unique_conversation_drafts_on_conversation_id_and_user_id ON conversation_drafts (conversation_id, user_id);
+		CREATE INDEX IF NOT EXISTS index_conversation_drafts_on_updated_at ON conversation_drafts (updated_at);
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Add preferred locale to users, used to pick localized email templates.
+	_, err = db.Exec(`
+		ALTER TABLE users ADD COLUMN IF NOT EXISTS locale TEXT NULL;
+		DO $$
+		BEGIN
+			IF NOT EXISTS (
+				SELECT 1 FROM pg_constraint WHERE conname = 'constraint_users_on_locale'
+			) THEN
+				ALTER TABLE users ADD CONSTRAINT constraint_users_on_locale CHECK (LENGTH(locale) <= 20);
+			END IF;
+		END$$;
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Add content hash to media so identical files share a stored blob.
+	_, err = db.Exec(`
+		ALTER TABLE media ADD COLUMN IF NOT EXISTS blob_name TEXT NULL;
+		ALTER TABLE media ADD COLUMN IF NOT EXISTS content_hash TEXT NULL;
+		CREATE INDEX IF NOT EXISTS index_media_on_content_hash ON media(content_hash);
+		CREATE INDEX IF NOT EXISTS index_media_on_blob_name ON media(blob_name);
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Add the uploader to media so agents can only attach the files they uploaded.
+	_, err = db.Exec(`
+		ALTER TABLE media ADD COLUMN IF NOT EXISTS uploaded_by INT REFERENCES users(id) ON DELETE SET NULL ON UPDATE CASCADE NULL;
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Add full-text search vectors for searching across all conversations.
+	_, err = db.Exec(`
+		ALTER TABLE conversation_messages ADD COLUMN IF NOT EXISTS search_vector TSVECTOR NULL;
+		UPDATE conversation_messages SET search_vector = to_tsvector('simple', COALESCE(text_content, ''))
+		WHERE search_vector IS NULL AND "type" != 'activity';
+		CREATE INDEX IF NOT EXISTS index_conversation_messages_on_search_vector ON conversation_messages USING GIN (search_vector);
+		CREATE INDEX IF NOT EXISTS index_conversations_on_subject_search ON conversations USING GIN (to_tsvector('simple', COALESCE("subject", '')));
+		CREATE INDEX IF NOT EXISTS index_users_on_name_email_search ON users USING GIN (to_tsvector('simple', first_name || ' ' || COALESCE(last_name, '') || ' ' || COALESCE(email, '')));
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Add the dispatch pause of inboxes, outgoing messages of a paused inbox stay pending.
+	_, err = db.Exec(`
+		ALTER TABLE inboxes ADD COLUMN IF NOT EXISTS dispatch_paused BOOL DEFAULT false NOT NULL;
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create table for incoming messages blocked by the sender rate limits, kept for review.
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS blocked_incoming_messages (
+			id BIGSERIAL PRIMARY KEY,
+			created_at TIMESTAMPTZ DEFAULT NOW(),
+			inbox_id INT REFERENCES inboxes(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
+			sender TEXT NOT NULL,
+			source_id TEXT NULL,
+			"subject" TEXT NULL,
+			content TEXT NULL,
+			reason TEXT NOT NULL,
+			payload BYTEA NULL
+		);
+		CREATE UNIQUE INDEX IF NOT EXISTS index_unique_blocked_incoming_messages_on_inbox_id_and_source_id ON blocked_incoming_messages (inbox_id, source_id);
+		CREATE INDEX IF NOT EXISTS index_blocked_incoming_messages_on_created_at ON blocked_incoming_messages (created_at);
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create table for the processed delivery status notifications of outgoing emails.
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS delivery_reports (
+			id BIGSERIAL PRIMARY KEY,
+			created_at TIMESTAMPTZ DEFAULT NOW(),
+			source_id TEXT NOT NULL,
+			message_id BIGINT REFERENCES conversation_messages(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL
+		);
+		CREATE UNIQUE INDEX IF NOT EXISTS index_unique_delivery_reports_on_source_id ON delivery_reports (source_id);
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create table for incoming messages that overflow the incoming queue.
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS incoming_message_spillover (
+			id BIGSERIAL PRIMARY KEY,
+			created_at TIMESTAMPTZ DEFAULT NOW(),
+			inbox_id INT REFERENCES inboxes(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
+			payload BYTEA NOT NULL
+		);
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create table for API tokens, only the hash of the token is stored.
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS api_tokens (
+			id SERIAL PRIMARY KEY,
+			created_at TIMESTAMPTZ DEFAULT NOW(),
+			user_id INT REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
+			"name" TEXT NOT NULL,
+			token_hash TEXT NOT NULL,
+			prefix TEXT NOT NULL,
+			scopes TEXT[] DEFAULT '{}'::TEXT[] NOT NULL,
+			last_used_at TIMESTAMPTZ NULL,
+			expires_at TIMESTAMPTZ NULL,
+			revoked_at TIMESTAMPTZ NULL,
+			CONSTRAINT constraint_api_tokens_on_name CHECK (length("name") <= 140)
+		);
+		CREATE UNIQUE INDEX IF NOT EXISTS index_unique_api_tokens_on_token_hash ON api_tokens (token_hash);
+		CREATE INDEX IF NOT EXISTS index_api_tokens_on_user_id ON api_tokens (user_id);
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Custom attribute updates from a conversation used to need only a login, grant the new permission to
+	// the default roles so existing agents keep the access.
+	_, err = db.Exec(`
+		UPDATE roles
+		SET permissions = array_append(permissions, 'conversations:update_custom_attributes')
+		WHERE name IN ('Admin', 'Agent') AND NOT ('conversations:update_custom_attributes' = ANY(permissions));
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create table for the audit log of administrative actions.
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS audit_logs (
+			id BIGSERIAL PRIMARY KEY,
+			created_at TIMESTAMPTZ DEFAULT NOW(),
+			actor_id INT REFERENCES users(id) ON DELETE SET NULL ON UPDATE CASCADE NULL,
+			actor_name TEXT NOT NULL,
+			"action" TEXT NOT NULL,
+			target_type TEXT NOT NULL,
+			target_id INT NULL,
+			changes JSONB DEFAULT '{}'::jsonb NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS index_audit_logs_on_created_at ON audit_logs (created_at);
+		CREATE INDEX IF NOT EXISTS index_audit_logs_on_target_type_and_target_id ON audit_logs (target_type, target_id);
+		CREATE INDEX IF NOT EXISTS index_audit_logs_on_actor_id ON audit_logs (actor_id);
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Grant the audit log permission to the Admin role.
+	_, err = db.Exec(`
+		UPDATE roles
+		SET permissions = array_append(permissions, 'audit_logs:read')
+		WHERE name = 'Admin' AND NOT ('audit_logs:read' = ANY(permissions));
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Allow turning CSAT surveys off per team.
+	_, err = db.Exec(`
+		ALTER TABLE teams ADD COLUMN IF NOT EXISTS csat_enabled BOOL DEFAULT TRUE NOT NULL;
+	`)
+	if err != nil {
+		return err
+	}
+
+	// One-time token required to view and submit a CSAT survey, NULL for surveys sent before.
+	_, err = db.Exec(`
+		ALTER TABLE csat_responses ADD COLUMN IF NOT EXISTS token TEXT NULL;
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Flag set on CSAT feedback by moderation.
+	_, err = db.Exec(`
+		ALTER TABLE csat_responses ADD COLUMN IF NOT EXISTS flagged BOOL DEFAULT FALSE NOT NULL;
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Send a single CSAT survey per conversation, duplicates sent before keep the answered survey or else the first one.
+	_, err = db.Exec(`
+		DELETE FROM csat_responses WHERE id IN (
+			SELECT id FROM (
+				SELECT id, ROW_NUMBER() OVER (
+					PARTITION BY conversation_id ORDER BY response_timestamp IS NULL, id
+				) AS n
+				FROM csat_responses
+			) d WHERE n > 1
+		);
+		CREATE UNIQUE INDEX IF NOT EXISTS index_csat_responses_on_conversation_id ON csat_responses(conversation_id);
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create table for conversation queues agents pull conversations from.
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS queues (
+			id SERIAL PRIMARY KEY,
+			created_at TIMESTAMPTZ DEFAULT NOW(),
+			updated_at TIMESTAMPTZ DEFAULT NOW(),
+			"name" TEXT NOT NULL,
+			description TEXT DEFAULT '' NOT NULL,
+			team_id INT REFERENCES teams(id) ON DELETE CASCADE ON UPDATE CASCADE NULL,
+			CONSTRAINT constraint_queues_on_name CHECK (length("name") <= 140),
+			CONSTRAINT constraint_queues_on_description CHECK (length(description) <= 1000),
+			CONSTRAINT constraint_queues_on_name_unique UNIQUE ("name")
+		);
+		ALTER TABLE conversations ADD COLUMN IF NOT EXISTS assigned_queue_id INT REFERENCES queues(id) ON DELETE SET NULL ON UPDATE CASCADE;
+		CREATE INDEX IF NOT EXISTS index_conversations_on_assigned_queue_id ON conversations (assigned_queue_id);
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Grant the queues permission to the Admin role.
+	_, err = db.Exec(`
+		UPDATE roles
+		SET permissions = array_append(permissions, 'queues:manage')
+		WHERE name = 'Admin' AND NOT ('queues:manage' = ANY(permissions));
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Remove contacts added as conversation participants by their incoming messages, participants are agents.
+	_, err = db.Exec(`
+		DELETE FROM conversation_participants cp
+		USING users u
+		WHERE u.id = cp.user_id AND u.type = 'contact';
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Per user notification preferences, users without a stored preference get the defaults.
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS notification_preferences (
+			id SERIAL PRIMARY KEY,
+			created_at TIMESTAMPTZ DEFAULT NOW(),
+			updated_at TIMESTAMPTZ DEFAULT NOW(),
+			user_id INT REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
+			event_type TEXT NOT NULL,
+			channel TEXT NOT NULL,
+			enabled BOOLEAN NOT NULL,
+			CONSTRAINT constraint_notification_preferences_unique UNIQUE (user_id, event_type, channel)
+		);
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Quiet hours of users and the notifications held back during them.
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS notification_quiet_hours (
+			user_id INT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE,
+			created_at TIMESTAMPTZ DEFAULT NOW(),
+			updated_at TIMESTAMPTZ DEFAULT NOW(),
+			enabled BOOLEAN DEFAULT false NOT NULL,
+			start_time TEXT NOT NULL,
+			end_time TEXT NOT NULL,
+			timezone TEXT NOT NULL,
+			digest BOOLEAN DEFAULT false NOT NULL,
+			CONSTRAINT constraint_notification_quiet_hours_on_timezone CHECK (length(timezone) <= 140)
+		);
+		CREATE TABLE IF NOT EXISTS deferred_notifications (
+			id SERIAL PRIMARY KEY,
+			created_at TIMESTAMPTZ DEFAULT NOW(),
+			user_id INT REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
+			recipient_email TEXT NOT NULL,
+			event_type TEXT NOT NULL,
+			provider TEXT NOT NULL,
+			subject TEXT NOT NULL,
+			content TEXT NOT NULL,
+			content_type TEXT DEFAULT '' NOT NULL,
+			digest BOOLEAN DEFAULT false NOT NULL,
+			alt_content TEXT DEFAULT '' NOT NULL,
+			headers JSONB DEFAULT '{}'::jsonb NOT NULL,
+			attachments JSONB DEFAULT '[]'::jsonb NOT NULL,
+			deliver_at TIMESTAMPTZ NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS index_deferred_notifications_on_deliver_at ON deferred_notifications (deliver_at);
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`
+		ALTER TABLE conversations ADD COLUMN IF NOT EXISTS "language" TEXT NULL;
+		ALTER TABLE conversation_messages ADD COLUMN IF NOT EXISTS "language" TEXT NULL;
+		ALTER TABLE conversation_messages ADD COLUMN IF NOT EXISTS language_confidence REAL NULL;
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS conversation_watchers (
+			id BIGSERIAL PRIMARY KEY,
+			created_at TIMESTAMPTZ DEFAULT NOW(),
+			user_id BIGINT REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
+			conversation_id BIGINT REFERENCES conversations(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL
+		);
+		CREATE UNIQUE INDEX IF NOT EXISTS index_unique_conversation_watchers_on_conversation_id_and_user_id ON conversation_watchers (conversation_id, user_id);
+		CREATE INDEX IF NOT EXISTS index_conversation_watchers_on_user_id ON conversation_watchers (user_id);
+		CREATE TABLE IF NOT EXISTS conversation_watch_digests (
+			user_id BIGINT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE,
+			last_sent_at TIMESTAMPTZ NOT NULL
+		);
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`
+		ALTER TABLE inboxes ADD COLUMN IF NOT EXISTS tracking_enabled BOOL DEFAULT FALSE NOT NULL;
+		CREATE TABLE IF NOT EXISTS message_tracking_links (
+			id BIGSERIAL PRIMARY KEY,
+			"uuid" UUID DEFAULT gen_random_uuid() NOT NULL UNIQUE,
+			created_at TIMESTAMPTZ DEFAULT NOW(),
+			message_id BIGINT REFERENCES conversation_messages(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
+			url TEXT NOT NULL
+		);
+		CREATE UNIQUE INDEX IF NOT EXISTS index_unique_message_tracking_links_on_message_id_and_url ON message_tracking_links (message_id, url);
+		CREATE TABLE IF NOT EXISTS message_tracking_events (
+			id BIGSERIAL PRIMARY KEY,
+			created_at TIMESTAMPTZ DEFAULT NOW(),
+			message_id BIGINT REFERENCES conversation_messages(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
+			"type" TEXT NOT NULL,
+			url TEXT NULL
+		);
+		CREATE INDEX IF NOT EXISTS index_message_tracking_events_on_message_id ON message_tracking_events (message_id);
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`
+		ALTER TABLE conversations ADD COLUMN IF NOT EXISTS unassigned_escalated_at TIMESTAMPTZ NULL;
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`
+		ALTER TABLE conversations ADD COLUMN IF NOT EXISTS manually_assigned_at TIMESTAMPTZ NULL;
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Add preferred timezone to users.
+	_, err = db.Exec(`
+		ALTER TABLE users ADD COLUMN IF NOT EXISTS timezone TEXT NULL;
+		DO $$
+		BEGIN
+			IF NOT EXISTS (
+				SELECT 1 FROM pg_constraint WHERE conname = 'constraint_users_on_timezone'
+			) THEN
+				ALTER TABLE users ADD CONSTRAINT constraint_users_on_timezone CHECK (LENGTH(timezone) <= 140);
+			END IF;
+		END$$;
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Add agent set locale override to conversations.
+	_, err = db.Exec(`
+		ALTER TABLE conversations ADD COLUMN IF NOT EXISTS locale TEXT NULL;
+		DO $$
+		BEGIN
+			IF NOT EXISTS (
+				SELECT 1 FROM pg_constraint WHERE conname = 'constraint_conversations_on_locale'
+			) THEN
+				ALTER TABLE conversations ADD CONSTRAINT constraint_conversations_on_locale CHECK (LENGTH(locale) <= 20);
+			END IF;
+		END$$;
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Add soft-delete to conversations.
+	_, err = db.Exec(`
+		ALTER TABLE conversations ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ NULL;
+		ALTER TABLE conversations ADD COLUMN IF NOT EXISTS deleted_by BIGINT REFERENCES users(id) ON DELETE SET NULL ON UPDATE CASCADE NULL;
+		CREATE INDEX IF NOT EXISTS index_conversations_on_deleted_at ON conversations (deleted_at) WHERE deleted_at IS NOT NULL;
+		UPDATE roles
+		SET permissions = array_append(permissions, 'conversations:delete')
+		WHERE name = 'Admin' AND NOT ('conversations:delete' = ANY(permissions));
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Add contact data export and erasure permissions to the Admin role.
+	_, err = db.Exec(`
+		UPDATE roles
+		SET permissions = array_append(permissions, 'contacts:export')
+		WHERE name = 'Admin' AND NOT ('contacts:export' = ANY(permissions));
+		UPDATE roles
+		SET permissions = array_append(permissions, 'contacts:erase')
+		WHERE name = 'Admin' AND NOT ('contacts:erase' = ANY(permissions));
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Add the permission to edit conversation subjects to the Admin role.
+	_, err = db.Exec(`
+		UPDATE roles
+		SET permissions = array_append(permissions, 'conversations:update_subject')
+		WHERE name = 'Admin' AND NOT ('conversations:update_subject' = ANY(permissions));
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create table for the per-user read watermarks of conversations.
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS conversation_read_states (
+			id BIGSERIAL PRIMARY KEY,
+			created_at TIMESTAMPTZ DEFAULT NOW(),
+			updated_at TIMESTAMPTZ DEFAULT NOW(),
+			user_id BIGINT REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
+			conversation_id BIGINT REFERENCES conversations(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
+			last_read_at TIMESTAMPTZ NOT NULL
+		);
+		CREATE UNIQUE INDEX IF NOT EXISTS index_unique_conversation_read_states_on_user_id_and_conversation_id ON conversation_read_states (user_id, conversation_id);
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create table for the status transitions of messages.
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS message_status_history (
+			id BIGSERIAL PRIMARY KEY,
+			created_at TIMESTAMPTZ DEFAULT NOW(),
+			message_id BIGINT REFERENCES conversation_messages(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
+			status message_status NOT NULL,
+			error TEXT NULL
+		);
+		CREATE INDEX IF NOT EXISTS index_message_status_history_on_message_id ON message_status_history (message_id);
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Add the role of conversation participants, existing participants took part by replying.
+	_, err = db.Exec(`
+		DO $$
+		BEGIN
+			IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'conversation_participant_role') THEN
+				CREATE TYPE "conversation_participant_role" AS ENUM ('collaborator', 'follower', 'requester');
+			END IF;
+		END$$;
+		ALTER TABLE conversation_participants ADD COLUMN IF NOT EXISTS "role" conversation_participant_role DEFAULT 'collaborator' NOT NULL;
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create table for the assignments of conversations and their response times.
+	_, err = db.Exec(`
+		ALTER TABLE conversations ADD COLUMN IF NOT EXISTS first_reply_started_at TIMESTAMPTZ NULL;
+		CREATE TABLE IF NOT EXISTS conversation_assignments (
+			id BIGSERIAL PRIMARY KEY,
+			created_at TIMESTAMPTZ DEFAULT NOW(),
+			conversation_id BIGINT REFERENCES conversations(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
+			assigned_user_id BIGINT REFERENCES users(id) ON DELETE SET NULL ON UPDATE CASCADE NULL,
+			assigned_team_id INT REFERENCES teams(id) ON DELETE SET NULL ON UPDATE CASCADE NULL,
+			first_reply_at TIMESTAMPTZ NULL
+		);
+		CREATE INDEX IF NOT EXISTS index_conversation_assignments_on_conversation_id ON conversation_assignments (conversation_id);
+		CREATE INDEX IF NOT EXISTS index_conversation_assignments_on_assigned_user_id ON conversation_assignments (assigned_user_id);
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Mark outgoing messages being sent so sends interrupted by a crash are reconciled on startup.
+	_, err = db.Exec(`
+		ALTER TABLE conversation_messages ADD COLUMN IF NOT EXISTS send_started_at TIMESTAMPTZ NULL;
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Add per-agent cap on open assigned conversations.
+	_, err = db.Exec(`
+		ALTER TABLE users ADD COLUMN IF NOT EXISTS max_open_conversations INT NULL;
+		DO $$
+		BEGIN
+			IF NOT EXISTS (
+				SELECT 1 FROM pg_constraint WHERE conname = 'constraint_users_on_max_open_conversations'
+			) THEN
+				ALTER TABLE users ADD CONSTRAINT constraint_users_on_max_open_conversations CHECK (max_open_conversations > 0);
+			END IF;
+		END$$;
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Store the score of each question of multi-question CSAT surveys, existing ratings are answers to the
+	// overall question.
+	_, err = db.Exec(`
+		ALTER TABLE csat_responses ADD COLUMN IF NOT EXISTS answers JSONB DEFAULT '[]'::jsonb NOT NULL;
+		UPDATE csat_responses
+		SET answers = jsonb_build_array(jsonb_build_object('question_id', 'overall', 'score', rating))
+		WHERE rating > 0 AND answers = '[]'::jsonb;
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Add webhook channel for inboxes receiving messages pushed over a signed HTTP webhook.
+	_, err = db.Exec(`
+		DO $$
+		BEGIN
+			IF NOT EXISTS (
+				SELECT 1 FROM pg_enum e
+				JOIN pg_type t ON t.oid = e.enumtypid
+				WHERE t.typname = 'channels'
+				AND e.enumlabel = 'webhook'
+			) THEN
+				ALTER TYPE channels ADD VALUE 'webhook';
+			END IF;
+		END
+		$$;
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create table for the personal reminders agents set on conversations.
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS conversation_reminders (
+			id BIGSERIAL PRIMARY KEY,
+			created_at TIMESTAMPTZ DEFAULT NOW(),
+			user_id BIGINT REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
+			conversation_id BIGINT REFERENCES conversations(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
+			remind_at TIMESTAMPTZ NOT NULL,
+			note TEXT DEFAULT '' NOT NULL,
+			notified_at TIMESTAMPTZ NULL,
+			CONSTRAINT constraint_conversation_reminders_on_note CHECK (length(note) <= 500)
+		);
+		CREATE INDEX IF NOT EXISTS index_conversation_reminders_on_user_id ON conversation_reminders (user_id);
+		CREATE INDEX IF NOT EXISTS index_conversation_reminders_on_remind_at ON conversation_reminders (remind_at) WHERE notified_at IS NULL;
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Add agent skills for skill aware assignment.
+	_, err = db.Exec(`
+		ALTER TABLE users ADD COLUMN IF NOT EXISTS skills TEXT[] DEFAULT '{}'::TEXT[] NOT NULL;
+		CREATE INDEX IF NOT EXISTS index_users_on_skills ON users USING GIN (skills);
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Add delivered message status, set from the delivery status notifications of outgoing emails.
+	_, err = db.Exec(`
+		DO $$
+		BEGIN
+			IF NOT EXISTS (
+				SELECT 1 FROM pg_enum e
+				JOIN pg_type t ON t.oid = e.enumtypid
+				WHERE t.typname = 'message_status'
+				AND e.enumlabel = 'delivered'
+			) THEN
+				ALTER TYPE message_status ADD VALUE 'delivered' AFTER 'sent';
+			END IF;
+		END
+		$$;
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create table for queuing failed automation rule actions for retry.
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS automation_failed_actions (
+			id BIGSERIAL PRIMARY KEY,
+			created_at TIMESTAMPTZ DEFAULT NOW(),
+			updated_at TIMESTAMPTZ DEFAULT NOW(),
+			-- Rule name is kept so the failure stays readable after the rule is deleted.
+			rule_id INT REFERENCES automation_rules(id) ON DELETE SET NULL ON UPDATE CASCADE NULL,
+			rule_name TEXT NOT NULL DEFAULT '',
+			conversation_id BIGINT REFERENCES conversations(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
+			action JSONB NOT NULL,
+			attempts INT DEFAULT 1 NOT NULL,
+			last_error TEXT NOT NULL DEFAULT '',
+			status TEXT DEFAULT 'pending' NOT NULL,
+			next_attempt_at TIMESTAMPTZ DEFAULT NOW() NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS index_automation_failed_actions_on_status_and_next_attempt_at ON automation_failed_actions (status, next_attempt_at);
+	`)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -3,6 +3,7 @@ package notifier
 import (
 	"context"
 	"fmt"
+	"slices"
 	"sync"
 
 	"github.com/abhinavxd/libredesk/internal/attachment"
@@ -15,7 +16,8 @@ const (
 
 // Message represents a message to be sent as a notification.
 type Message struct {
-	// Recipients of the message
+	// Recipients of the message, when set with EventType the user at each index is the owner of the email
+	// address at the same index in RecipientEmails.
 	UserIDs []int
 	// Email addresses of the recipients
 	RecipientEmails []string
@@ -33,6 +35,9 @@ type Message struct {
 	AltContent string
 	// Additional email headers
 	Headers map[string][]string
+	// EventType of the notification, recipients who opted out of the event type are filtered out.
+	// Left empty for notifications that are always sent, e.g. password resets.
+	EventType string
 }
 
 // Notifier defines the interface for sending notifications through various providers.
@@ -43,9 +48,17 @@ type Notifier interface {
 	Name() string
 }
 
+// PreferenceStore filters recipients by their notification preferences and quiet hours.
+type PreferenceStore interface {
+	FilterRecipients(userIDs []int, eventType, channel string) ([]int, error)
+	// HoldForQuietHours stores the message for later delivery to the recipients in their quiet hours and returns their IDs.
+	HoldForQuietHours(userIDs []int, emails []string, message Message) ([]int, error)
+}
+
 // Service manages message providers and a worker pool.
 type Service struct {
 	providers      map[string]Notifier
+	prefStore      PreferenceStore
 	messageChannel chan Message
 	concurrency    int
 	lo             *logf.Logger
@@ -64,8 +77,18 @@ func NewService(providers map[string]Notifier, concurrency, capacity int, logger
 	}
 }
 
-// Send sends a message to the message channel.
+// SetPreferenceStore sets the store consulted for the notification preferences of recipients.
+func (s *Service) SetPreferenceStore(store PreferenceStore) {
+	s.prefStore = store
+}
+
+// Send sends a message to the message channel, recipients who opted out of the message's event type are skipped.
 func (s *Service) Send(message Message) error {
+	message = s.filterRecipients(message)
+	if len(message.RecipientEmails) == 0 {
+		return nil
+	}
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	if s.closed {
@@ -81,6 +104,57 @@ func (s *Service) Send(message Message) error {
 	}
 }
 
+// filterRecipients drops the recipients who opted out of the message's event type for its provider and the ones
+// in their quiet hours, whose notification is held back for later. Recipients are left as is if the preferences
+// can't be fetched, so notifications aren't lost.
+func (s *Service) filterRecipients(message Message) Message {
+	if s.prefStore == nil || message.EventType == "" || len(message.UserIDs) == 0 {
+		return message
+	}
+	if len(message.UserIDs) != len(message.RecipientEmails) {
+		s.lo.Warn("notification user IDs don't match recipient emails, skipping preferences", "event_type", message.EventType)
+		return message
+	}
+
+	allowed, err := s.prefStore.FilterRecipients(message.UserIDs, message.EventType, message.Provider)
+	if err != nil {
+		s.lo.Error("error filtering notification recipients", "event_type", message.EventType, "error", err)
+		return message
+	}
+
+	var (
+		userIDs = make([]int, 0, len(allowed))
+		emails  = make([]string, 0, len(allowed))
+	)
+	for i, id := range message.UserIDs {
+		if slices.Contains(allowed, id) {
+			userIDs = append(userIDs, id)
+			emails = append(emails, message.RecipientEmails[i])
+		}
+	}
+	if len(userIDs) < len(message.UserIDs) {
+		s.lo.Debug("skipped notification recipients who opted out", "event_type", message.EventType, "skipped", len(message.UserIDs)-len(userIDs))
+	}
+	if len(userIDs) == 0 {
+		message.UserIDs, message.RecipientEmails = userIDs, emails
+		return message
+	}
+
+	held, err := s.prefStore.HoldForQuietHours(userIDs, emails, message)
+	if err != nil {
+		s.lo.Error("error holding notification for quiet hours", "event_type", message.EventType, "error", err)
+	}
+	message.UserIDs = make([]int, 0, len(userIDs))
+	message.RecipientEmails = make([]string, 0, len(emails))
+	for i, id := range userIDs {
+		if !slices.Contains(held, id) {
+			message.UserIDs = append(message.UserIDs, id)
+			message.RecipientEmails = append(message.RecipientEmails, emails[i])
+		}
+	}
+	return message
+}
+
 // Run starts the worker pool to process messages.
 func (s *Service) Run(ctx context.Context) {
 	for range s.concurrency {
@@ -0,0 +1,90 @@
+package models
+
+import (
+	"time"
+
+	"github.com/abhinavxd/libredesk/internal/attachment"
+)
+
+// Event types agents can opt in or out of notifications for.
+const (
+	EventAssignment = "assignment"
+	EventMention    = "mention"
+	EventNewMessage = "new_message"
+	EventSLAAlert   = "sla_alert"
+	// EventWatchDigest is the periodic digest of activity in watched conversations, off unless opted in.
+	EventWatchDigest = "watch_digest"
+	// EventReminder is a personal conversation reminder of the user coming due.
+	EventReminder = "reminder"
+)
+
+// Channels notifications are delivered through, the channel matches the name of the notifier provider.
+const (
+	ChannelEmail = "email"
+	ChannelPush  = "push"
+	ChannelSlack = "slack"
+)
+
+// Events lists the event types in the order they are shown.
+var Events = []string{EventAssignment, EventMention, EventNewMessage, EventSLAAlert, EventWatchDigest, EventReminder}
+
+// Channels lists the notification channels in the order they are shown.
+var Channels = []string{ChannelEmail, ChannelPush, ChannelSlack}
+
+// Defaults are the preferences of users who haven't changed them, combinations not listed are off.
+var Defaults = map[string]map[string]bool{
+	EventAssignment: {ChannelEmail: true, ChannelPush: true},
+	EventMention:    {ChannelEmail: true, ChannelPush: true},
+	EventNewMessage: {ChannelPush: true},
+	EventSLAAlert:   {ChannelEmail: true, ChannelPush: true},
+	EventReminder:   {ChannelEmail: true, ChannelPush: true},
+}
+
+// Preference is whether a user gets notifications of an event type through a channel.
+type Preference struct {
+	EventType string `db:"event_type" json:"event_type"`
+	Channel   string `db:"channel" json:"channel"`
+	Enabled   bool   `db:"enabled" json:"enabled"`
+}
+
+// UserPreference is a stored preference of a user.
+type UserPreference struct {
+	UserID int `db:"user_id"`
+	Preference
+}
+
+// QuietHours is the daily window during which a user's non-urgent notifications are held back. Start and end
+// are `HH:MM` in the user's timezone, a window with the end before the start spans midnight.
+type QuietHours struct {
+	Enabled   bool   `db:"enabled" json:"enabled"`
+	StartTime string `db:"start_time" json:"start_time"`
+	EndTime   string `db:"end_time" json:"end_time"`
+	Timezone  string `db:"timezone" json:"timezone"`
+	// Digest sends the held back notifications as a single digest after the window instead of one by one.
+	Digest bool `db:"digest" json:"digest"`
+}
+
+// UserQuietHours is the quiet hours of a user.
+type UserQuietHours struct {
+	UserID int `db:"user_id"`
+	QuietHours
+}
+
+// DeferredNotification is a notification held back during quiet hours, delivered at `DeliverAt`.
+type DeferredNotification struct {
+	ID             int       `db:"id"`
+	CreatedAt      time.Time `db:"created_at"`
+	UserID         int       `db:"user_id"`
+	RecipientEmail string    `db:"recipient_email"`
+	EventType      string    `db:"event_type"`
+	Provider       string    `db:"provider"`
+	Subject        string    `db:"subject"`
+	Content        string    `db:"content"`
+	ContentType    string    `db:"content_type"`
+	Digest         bool      `db:"digest"`
+	DeliverAt      time.Time `db:"deliver_at"`
+	AltContent     string    `db:"alt_content"`
+	// Headers is the JSON of the email headers of the notification.
+	Headers     []byte                 `db:"headers"`
+	Attachments attachment.Attachments `db:"attachments"`
+}
@@ -0,0 +1,166 @@
+// Package preference handles the notification preferences of users.
+package preference
+
+import (
+	"embed"
+	"slices"
+
+	"github.com/abhinavxd/libredesk/internal/dbutil"
+	"github.com/abhinavxd/libredesk/internal/envelope"
+	"github.com/abhinavxd/libredesk/internal/notification/preference/models"
+	"github.com/jmoiron/sqlx"
+	"github.com/knadh/go-i18n"
+	"github.com/lib/pq"
+	"github.com/zerodha/logf"
+)
+
+var (
+	//go:embed queries.sql
+	efs embed.FS
+)
+
+// Manager manages notification preferences.
+type Manager struct {
+	db   *sqlx.DB
+	q    queries
+	lo   *logf.Logger
+	i18n *i18n.I18n
+	// urgentEvents are the event types sent during quiet hours.
+	urgentEvents []string
+}
+
+// Opts contains options for initializing the Manager.
+type Opts struct {
+	DB   *sqlx.DB
+	Lo   *logf.Logger
+	I18n *i18n.I18n
+	// UrgentEvents are the event types that bypass quiet hours.
+	UrgentEvents []string
+}
+
+// queries contains prepared SQL queries.
+type queries struct {
+	GetUserPreferences    *sqlx.Stmt `query:"get-user-preferences"`
+	GetUsersPreferences   *sqlx.Stmt `query:"get-users-preferences"`
+	Upsert                *sqlx.Stmt `query:"upsert"`
+	DeleteUserPreferences *sqlx.Stmt `query:"delete-user-preferences"`
+
+	GetQuietHours               *sqlx.Stmt `query:"get-quiet-hours"`
+	GetUsersQuietHours          *sqlx.Stmt `query:"get-users-quiet-hours"`
+	UpsertQuietHours            *sqlx.Stmt `query:"upsert-quiet-hours"`
+	InsertDeferredNotification  *sqlx.Stmt `query:"insert-deferred-notification"`
+	GetDueDeferredNotifications *sqlx.Stmt `query:"get-due-deferred-notifications"`
+	DeleteDeferredNotifications *sqlx.Stmt `query:"delete-deferred-notifications"`
+}
+
+// New creates and returns a new instance of the Manager.
+func New(opts Opts) (*Manager, error) {
+	var q queries
+	if err := dbutil.ScanSQLFile("queries.sql", &q, opts.DB, efs); err != nil {
+		return nil, err
+	}
+	return &Manager{
+		db:           opts.DB,
+		q:            q,
+		lo:           opts.Lo,
+		i18n:         opts.I18n,
+		urgentEvents: opts.UrgentEvents,
+	}, nil
+}
+
+// GetAll returns the preferences of a user for every event type and channel, falling back to the defaults.
+func (m *Manager) GetAll(userID int) ([]models.Preference, error) {
+	var stored = make([]models.Preference, 0)
+	if err := m.q.GetUserPreferences.Select(&stored, userID); err != nil {
+		m.lo.Error("error fetching notification preferences", "user_id", userID, "error", err)
+		return nil, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.notificationPreference}"), nil)
+	}
+	return mergeDefaults(stored), nil
+}
+
+// Update saves the passed preferences of a user, preferences not passed are left as is.
+func (m *Manager) Update(userID int, prefs []models.Preference) error {
+	for _, p := range prefs {
+		if !slices.Contains(models.Events, p.EventType) {
+			return envelope.NewError(envelope.InputError, m.i18n.Ts("globals.messages.invalid", "name", "`event_type`"), nil)
+		}
+		if !slices.Contains(models.Channels, p.Channel) {
+			return envelope.NewError(envelope.InputError, m.i18n.Ts("globals.messages.invalid", "name", "`channel`"), nil)
+		}
+	}
+
+	tx, err := m.db.Beginx()
+	if err != nil {
+		m.lo.Error("error starting transaction", "error", err)
+		return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorUpdating", "name", "{globals.terms.notificationPreference}"), nil)
+	}
+	defer tx.Rollback()
+
+	for _, p := range prefs {
+		if _, err := tx.Stmtx(m.q.Upsert).Exec(userID, p.EventType, p.Channel, p.Enabled); err != nil {
+			m.lo.Error("error saving notification preference", "user_id", userID, "event_type", p.EventType, "channel", p.Channel, "error", err)
+			return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorUpdating", "name", "{globals.terms.notificationPreference}"), nil)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		m.lo.Error("error committing notification preferences", "user_id", userID, "error", err)
+		return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorUpdating", "name", "{globals.terms.notificationPreference}"), nil)
+	}
+	return nil
+}
+
+// Reset deletes the saved preferences of a user, so the defaults apply again.
+func (m *Manager) Reset(userID int) error {
+	if _, err := m.q.DeleteUserPreferences.Exec(userID); err != nil {
+		m.lo.Error("error deleting notification preferences", "user_id", userID, "error", err)
+		return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorDeleting", "name", "{globals.terms.notificationPreference}"), nil)
+	}
+	return nil
+}
+
+// FilterRecipients returns the users in `userIDs` who get notifications of the event type through the channel.
+func (m *Manager) FilterRecipients(userIDs []int, eventType, channel string) ([]int, error) {
+	var stored = make([]models.UserPreference, 0, len(userIDs))
+	if err := m.q.GetUsersPreferences.Select(&stored, pq.Array(userIDs), eventType, channel); err != nil {
+		m.lo.Error("error fetching notification preferences", "event_type", eventType, "channel", channel, "error", err)
+		return nil, err
+	}
+	return filterRecipients(userIDs, stored, eventType, channel), nil
+}
+
+// mergeDefaults returns the preference for every event type and channel, stored preferences override the defaults.
+func mergeDefaults(stored []models.Preference) []models.Preference {
+	var out = make([]models.Preference, 0, len(models.Events)*len(models.Channels))
+	for _, event := range models.Events {
+		for _, channel := range models.Channels {
+			pref := models.Preference{EventType: event, Channel: channel, Enabled: models.Defaults[event][channel]}
+			if idx := slices.IndexFunc(stored, func(p models.Preference) bool {
+				return p.EventType == event && p.Channel == channel
+			}); idx >= 0 {
+				pref.Enabled = stored[idx].Enabled
+			}
+			out = append(out, pref)
+		}
+	}
+	return out
+}
+
+// filterRecipients returns the users who get notifications of the event type through the channel, users without a
+// stored preference get the default.
+func filterRecipients(userIDs []int, stored []models.UserPreference, eventType, channel string) []int {
+	enabled := make(map[int]bool, len(stored))
+	for _, p := range stored {
+		enabled[p.UserID] = p.Enabled
+	}
+	var out = make([]int, 0, len(userIDs))
+	for _, id := range userIDs {
+		on, ok := enabled[id]
+		if !ok {
+			on = models.Defaults[eventType][channel]
+		}
+		if on {
+			out = append(out, id)
+		}
+	}
+	return out
+}
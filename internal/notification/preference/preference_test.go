@@ -0,0 +1,66 @@
+package preference
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/abhinavxd/libredesk/internal/notification/preference/models"
+)
+
+func TestMergeDefaults(t *testing.T) {
+	got := mergeDefaults([]models.Preference{
+		{EventType: models.EventAssignment, Channel: models.ChannelEmail, Enabled: false},
+		{EventType: models.EventNewMessage, Channel: models.ChannelEmail, Enabled: true},
+	})
+	if len(got) != len(models.Events)*len(models.Channels) {
+		t.Fatalf("got %d preferences, want %d", len(got), len(models.Events)*len(models.Channels))
+	}
+
+	tests := []struct {
+		event    string
+		channel  string
+		expected bool
+	}{
+		{event: models.EventAssignment, channel: models.ChannelEmail, expected: false},
+		{event: models.EventNewMessage, channel: models.ChannelEmail, expected: true},
+		{event: models.EventMention, channel: models.ChannelEmail, expected: true},
+		{event: models.EventMention, channel: models.ChannelSlack, expected: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.event+"/"+tt.channel, func(t *testing.T) {
+			idx := slices.IndexFunc(got, func(p models.Preference) bool {
+				return p.EventType == tt.event && p.Channel == tt.channel
+			})
+			if idx < 0 {
+				t.Fatal("preference missing")
+			}
+			if got[idx].Enabled != tt.expected {
+				t.Errorf("got enabled %v, want %v", got[idx].Enabled, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFilterRecipients(t *testing.T) {
+	stored := []models.UserPreference{
+		{UserID: 1, Preference: models.Preference{Enabled: false}},
+		{UserID: 3, Preference: models.Preference{Enabled: true}},
+	}
+
+	tests := []struct {
+		name     string
+		event    string
+		expected []int
+	}{
+		{name: "default on", event: models.EventAssignment, expected: []int{2, 3}},
+		{name: "default off", event: models.EventNewMessage, expected: []int{3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := filterRecipients([]int{1, 2, 3}, stored, tt.event, models.ChannelEmail)
+			if !slices.Equal(got, tt.expected) {
+				t.Errorf("got %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
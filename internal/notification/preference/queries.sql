@@ -0,0 +1,57 @@
+-- name: get-user-preferences
+SELECT event_type, channel, enabled
+FROM notification_preferences
+WHERE user_id = $1;
+
+-- name: get-users-preferences
+SELECT user_id, event_type, channel, enabled
+FROM notification_preferences
+WHERE user_id = ANY($1::INT[]) AND event_type = $2 AND channel = $3;
+
+-- name: upsert
+INSERT INTO notification_preferences (user_id, event_type, channel, enabled)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (user_id, event_type, channel) DO UPDATE
+SET enabled = EXCLUDED.enabled,
+    updated_at = NOW();
+
+-- name: delete-user-preferences
+DELETE FROM notification_preferences
+WHERE user_id = $1;
+
+-- name: get-quiet-hours
+SELECT enabled, start_time, end_time, timezone, digest
+FROM notification_quiet_hours
+WHERE user_id = $1;
+
+-- name: get-users-quiet-hours
+SELECT user_id, enabled, start_time, end_time, timezone, digest
+FROM notification_quiet_hours
+WHERE user_id = ANY($1::INT[]) AND enabled = true;
+
+-- name: upsert-quiet-hours
+INSERT INTO notification_quiet_hours (user_id, enabled, start_time, end_time, timezone, digest)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (user_id) DO UPDATE
+SET enabled = EXCLUDED.enabled,
+    start_time = EXCLUDED.start_time,
+    end_time = EXCLUDED.end_time,
+    timezone = EXCLUDED.timezone,
+    digest = EXCLUDED.digest,
+    updated_at = NOW();
+
+-- name: insert-deferred-notification
+INSERT INTO deferred_notifications (user_id, recipient_email, event_type, provider, subject, content, content_type, digest, deliver_at, alt_content, headers, attachments)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12);
+
+-- name: get-due-deferred-notifications
+SELECT id, created_at, user_id, recipient_email, event_type, provider, subject, content, content_type, digest, deliver_at,
+    alt_content, headers, attachments
+FROM deferred_notifications
+WHERE deliver_at <= NOW()
+ORDER BY user_id, created_at
+LIMIT $1;
+
+-- name: delete-deferred-notifications
+DELETE FROM deferred_notifications
+WHERE id = ANY($1::INT[]);
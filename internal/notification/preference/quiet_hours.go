@@ -0,0 +1,268 @@
+package preference
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"html"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/abhinavxd/libredesk/internal/attachment"
+	"github.com/abhinavxd/libredesk/internal/envelope"
+	notifier "github.com/abhinavxd/libredesk/internal/notification"
+	"github.com/abhinavxd/libredesk/internal/notification/preference/models"
+	"github.com/lib/pq"
+)
+
+const (
+	// deferredBatchSize is the max number of due deferred notifications delivered per scan.
+	deferredBatchSize = 500
+
+	// defaultDeferredScanInterval is how often due deferred notifications are looked up when not configured.
+	defaultDeferredScanInterval = time.Minute
+)
+
+// GetQuietHours returns the quiet hours of a user, quiet hours are disabled for users who haven't set them.
+func (m *Manager) GetQuietHours(userID int) (models.QuietHours, error) {
+	var qh models.QuietHours
+	if err := m.q.GetQuietHours.Get(&qh, userID); err != nil {
+		if err == sql.ErrNoRows {
+			return models.QuietHours{StartTime: "22:00", EndTime: "08:00", Timezone: "UTC"}, nil
+		}
+		m.lo.Error("error fetching quiet hours", "user_id", userID, "error", err)
+		return qh, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.quietHours}"), nil)
+	}
+	return qh, nil
+}
+
+// UpdateQuietHours saves the quiet hours of a user.
+func (m *Manager) UpdateQuietHours(userID int, qh models.QuietHours) error {
+	if _, err := parseClock(qh.StartTime); err != nil {
+		return envelope.NewError(envelope.InputError, m.i18n.Ts("globals.messages.invalid", "name", "`start_time`"), nil)
+	}
+	if _, err := parseClock(qh.EndTime); err != nil {
+		return envelope.NewError(envelope.InputError, m.i18n.Ts("globals.messages.invalid", "name", "`end_time`"), nil)
+	}
+	if _, err := time.LoadLocation(qh.Timezone); err != nil || qh.Timezone == "" {
+		return envelope.NewError(envelope.InputError, m.i18n.Ts("globals.messages.invalid", "name", "`timezone`"), nil)
+	}
+	if _, err := m.q.UpsertQuietHours.Exec(userID, qh.Enabled, qh.StartTime, qh.EndTime, qh.Timezone, qh.Digest); err != nil {
+		m.lo.Error("error saving quiet hours", "user_id", userID, "error", err)
+		return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorUpdating", "name", "{globals.terms.quietHours}"), nil)
+	}
+	return nil
+}
+
+// HoldForQuietHours stores the notification for delivery after the quiet hours of the users in `userIDs` who are
+// currently in their quiet hours and returns their IDs. Urgent event types are never held back.
+func (m *Manager) HoldForQuietHours(userIDs []int, emails []string, message notifier.Message) ([]int, error) {
+	if slices.Contains(m.urgentEvents, message.EventType) {
+		return nil, nil
+	}
+
+	var quietHours = make([]models.UserQuietHours, 0)
+	if err := m.q.GetUsersQuietHours.Select(&quietHours, pq.Array(userIDs)); err != nil {
+		m.lo.Error("error fetching quiet hours", "error", err)
+		return nil, err
+	}
+
+	// The whole notification is stored so it's sent as it would have been, attachments opened from a store are
+	// read now.
+	headers, err := json.Marshal(message.Headers)
+	if err != nil {
+		return nil, err
+	}
+	attachments := make(attachment.Attachments, len(message.Attachments))
+	for i, a := range message.Attachments {
+		if a.Content, err = a.ReadContent(); err != nil {
+			m.lo.Error("error reading notification attachment", "name", a.Name, "error", err)
+			return nil, err
+		}
+		a.Open = nil
+		attachments[i] = a
+	}
+	attachmentsJSON, err := json.Marshal(attachments)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		now  = time.Now()
+		held = make([]int, 0)
+	)
+	for _, qh := range quietHours {
+		quiet, end := quietWindow(qh.QuietHours, now)
+		if !quiet {
+			continue
+		}
+		idx := slices.Index(userIDs, qh.UserID)
+		if idx < 0 || idx >= len(emails) {
+			continue
+		}
+		if _, err := m.q.InsertDeferredNotification.Exec(qh.UserID, emails[idx], message.EventType, message.Provider,
+			message.Subject, message.Content, message.ContentType, qh.Digest, end, message.AltContent, headers, attachmentsJSON); err != nil {
+			m.lo.Error("error deferring notification", "user_id", qh.UserID, "error", err)
+			return held, err
+		}
+		held = append(held, qh.UserID)
+	}
+	return held, nil
+}
+
+// RunDeferredSender delivers the notifications held back during quiet hours once the window is over.
+func (m *Manager) RunDeferredSender(ctx context.Context, n *notifier.Service, interval time.Duration) {
+	if interval <= 0 {
+		interval = defaultDeferredScanInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := m.sendDeferred(n); err != nil {
+				m.lo.Error("error sending deferred notifications", "error", err)
+			}
+		}
+	}
+}
+
+// sendDeferred sends the due deferred notifications, the ones of users with digest enabled are sent as a single digest.
+// Only the sent notifications are deleted, the others are retried on the next scan.
+func (m *Manager) sendDeferred(n *notifier.Service) error {
+	var due = make([]models.DeferredNotification, 0)
+	if err := m.q.GetDueDeferredNotifications.Select(&due, deferredBatchSize); err != nil {
+		return fmt.Errorf("fetching due deferred notifications: %w", err)
+	}
+	if len(due) == 0 {
+		return nil
+	}
+
+	var (
+		sent    = make([]int, 0, len(due))
+		digests = make(map[int][]models.DeferredNotification)
+	)
+	for _, d := range due {
+		if d.Digest {
+			digests[d.UserID] = append(digests[d.UserID], d)
+			continue
+		}
+		message, err := deferredMessage(d)
+		if err != nil {
+			m.lo.Error("error reading deferred notification", "id", d.ID, "error", err)
+			continue
+		}
+		if err := n.Send(message); err != nil {
+			m.lo.Error("error sending deferred notification", "id", d.ID, "error", err)
+			continue
+		}
+		sent = append(sent, d.ID)
+	}
+	for userID, items := range digests {
+		if err := n.Send(notifier.Message{
+			UserIDs:         []int{userID},
+			RecipientEmails: []string{items[0].RecipientEmail},
+			Subject:         m.i18n.Ts("notification.quietHoursDigestSubject", "count", fmt.Sprint(len(items))),
+			Content:         m.renderDigest(items),
+			ContentType:     "html",
+			Provider:        items[0].Provider,
+		}); err != nil {
+			m.lo.Error("error sending notification digest", "user_id", userID, "error", err)
+			continue
+		}
+		for _, d := range items {
+			sent = append(sent, d.ID)
+		}
+	}
+
+	if len(sent) == 0 {
+		return nil
+	}
+	if _, err := m.q.DeleteDeferredNotifications.Exec(pq.Array(sent)); err != nil {
+		return fmt.Errorf("deleting sent deferred notifications: %w", err)
+	}
+	return nil
+}
+
+// deferredMessage returns the notification message of a deferred notification. It's without the event type so the
+// notification isn't held back again.
+func deferredMessage(d models.DeferredNotification) (notifier.Message, error) {
+	var headers map[string][]string
+	if len(d.Headers) > 0 {
+		if err := json.Unmarshal(d.Headers, &headers); err != nil {
+			return notifier.Message{}, fmt.Errorf("decoding headers: %w", err)
+		}
+	}
+	// MIME headers of attachments aren't stored, they're made again from the attachment.
+	for i, a := range d.Attachments {
+		if a.Header == nil {
+			d.Attachments[i].Header = attachment.MakeHeader(a.ContentType, a.ContentID, a.Name, "base64", a.Disposition)
+		}
+	}
+	return notifier.Message{
+		UserIDs:         []int{d.UserID},
+		RecipientEmails: []string{d.RecipientEmail},
+		Subject:         d.Subject,
+		Content:         d.Content,
+		ContentType:     d.ContentType,
+		AltContent:      d.AltContent,
+		Headers:         headers,
+		Attachments:     d.Attachments,
+		Provider:        d.Provider,
+	}, nil
+}
+
+// renderDigest renders the HTML body of a digest listing the held back notifications.
+func (m *Manager) renderDigest(items []models.DeferredNotification) string {
+	var b strings.Builder
+	b.WriteString("<p>" + html.EscapeString(m.i18n.T("notification.quietHoursDigestIntro")) + "</p><ul>")
+	for _, d := range items {
+		fmt.Fprintf(&b, "<li>%s <small>(%s)</small></li>", html.EscapeString(d.Subject), d.CreatedAt.UTC().Format("2006-01-02 15:04 MST"))
+	}
+	b.WriteString("</ul>")
+	return b.String()
+}
+
+// quietWindow returns whether `now` is within the quiet hours and the time the current window ends.
+func quietWindow(qh models.QuietHours, now time.Time) (bool, time.Time) {
+	start, err := parseClock(qh.StartTime)
+	if err != nil {
+		return false, time.Time{}
+	}
+	end, err := parseClock(qh.EndTime)
+	if err != nil || start == end {
+		return false, time.Time{}
+	}
+	loc, err := time.LoadLocation(qh.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+
+	var (
+		t       = now.In(loc)
+		minute  = t.Hour()*60 + t.Minute()
+		endTime = time.Date(t.Year(), t.Month(), t.Day(), end/60, end%60, 0, 0, loc)
+	)
+	if start < end {
+		return minute >= start && minute < end, endTime
+	}
+	// Window spans midnight.
+	if minute >= start {
+		return true, endTime.AddDate(0, 0, 1)
+	}
+	return minute < end, endTime
+}
+
+// parseClock parses a `HH:MM` time of day into minutes since midnight.
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, err
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
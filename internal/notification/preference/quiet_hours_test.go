@@ -0,0 +1,48 @@
+package preference
+
+import (
+	"testing"
+	"time"
+
+	"github.com/abhinavxd/libredesk/internal/notification/preference/models"
+)
+
+func TestQuietWindow(t *testing.T) {
+	at := func(s string) time.Time {
+		tm, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tm
+	}
+	overnight := models.QuietHours{StartTime: "22:00", EndTime: "07:00", Timezone: "Asia/Kolkata"}
+	daytime := models.QuietHours{StartTime: "12:00", EndTime: "13:30", Timezone: "UTC"}
+
+	tests := []struct {
+		name      string
+		qh        models.QuietHours
+		now       time.Time
+		wantQuiet bool
+		wantEnd   time.Time
+	}{
+		{name: "before overnight window", qh: overnight, now: at("2025-01-10T16:00:00Z"), wantQuiet: false},
+		{name: "overnight window before midnight", qh: overnight, now: at("2025-01-10T17:00:00Z"), wantQuiet: true, wantEnd: at("2025-01-11T01:30:00Z")},
+		{name: "overnight window after midnight", qh: overnight, now: at("2025-01-10T20:00:00Z"), wantQuiet: true, wantEnd: at("2025-01-11T01:30:00Z")},
+		{name: "after overnight window", qh: overnight, now: at("2025-01-11T01:30:00Z"), wantQuiet: false},
+		{name: "daytime window", qh: daytime, now: at("2025-01-10T13:00:00Z"), wantQuiet: true, wantEnd: at("2025-01-10T13:30:00Z")},
+		{name: "empty window", qh: models.QuietHours{StartTime: "10:00", EndTime: "10:00", Timezone: "UTC"}, now: at("2025-01-10T10:00:00Z"), wantQuiet: false},
+		{name: "invalid time", qh: models.QuietHours{StartTime: "25:00", EndTime: "10:00", Timezone: "UTC"}, now: at("2025-01-10T09:00:00Z"), wantQuiet: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			quiet, end := quietWindow(tt.qh, tt.now)
+			if quiet != tt.wantQuiet {
+				t.Fatalf("got quiet %v, want %v", quiet, tt.wantQuiet)
+			}
+			if quiet && !end.Equal(tt.wantEnd) {
+				t.Errorf("got end %v, want %v", end, tt.wantEnd)
+			}
+		})
+	}
+}
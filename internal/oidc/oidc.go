@@ -69,7 +69,7 @@ func (o *Manager) Get(id int, includeSecret bool) (models.OIDC, error) {
 	var oidc models.OIDC
 	if err := o.q.GetOIDC.Get(&oidc, id); err != nil {
 		if err == sql.ErrNoRows {
-			return oidc, envelope.NewError(envelope.NotFoundError, o.i18n.Ts("globals.messages.notFound", "name", "{globals.terms.oidcProvider}"), nil)
+			return oidc, envelope.NewCodedError(envelope.NotFoundError, envelope.ErrCodeOIDCNotFound, o.i18n.Ts("globals.messages.notFound", "name", "{globals.terms.oidcProvider}"), nil)
 		}
 
 		o.lo.Error("error fetching oidc", "error", err)
@@ -124,13 +124,14 @@ func (o *Manager) GetAllEnabled() ([]models.OIDC, error) {
 	return oidc, nil
 }
 
-// Create adds a new oidc.
-func (o *Manager) Create(oidc models.OIDC) error {
-	if _, err := o.q.InsertOIDC.Exec(oidc.Name, oidc.Provider, oidc.ProviderURL, oidc.ClientID, oidc.ClientSecret); err != nil {
+// Create adds a new oidc and returns it.
+func (o *Manager) Create(oidc models.OIDC) (models.OIDC, error) {
+	var created models.OIDC
+	if err := o.q.InsertOIDC.Get(&created, oidc.Name, oidc.Provider, oidc.ProviderURL, oidc.ClientID, oidc.ClientSecret); err != nil {
 		o.lo.Error("error inserting oidc", "error", err)
-		return envelope.NewError(envelope.GeneralError, o.i18n.Ts("globals.messages.errorCreating", "name", "{globals.terms.oidcProvider}"), nil)
+		return created, envelope.NewError(envelope.GeneralError, o.i18n.Ts("globals.messages.errorCreating", "name", "{globals.terms.oidcProvider}"), nil)
 	}
-	return nil
+	return created, nil
 }
 
 // Update updates a oidc by id.
@@ -9,7 +9,8 @@ SELECT * FROM oidc WHERE id = $1;
 
 -- name: insert-oidc
 INSERT INTO oidc (name, provider, provider_url, client_id, client_secret) 
-VALUES ($1, $2, $3, $4, $5);
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
 
 -- name: update-oidc
 UPDATE oidc 
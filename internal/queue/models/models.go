@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+
+	"github.com/volatiletech/null/v9"
+)
+
+// Queue is a pool of conversations waiting for an agent to pull them. A queue of a team only holds
+// conversations of that team.
+type Queue struct {
+	ID          int       `db:"id" json:"id"`
+	CreatedAt   time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt   time.Time `db:"updated_at" json:"updated_at"`
+	Name        string    `db:"name" json:"name"`
+	Description string    `db:"description" json:"description"`
+	TeamID      null.Int  `db:"team_id" json:"team_id"`
+}
@@ -0,0 +1,27 @@
+-- name: get-all
+SELECT id, created_at, updated_at, name, description, team_id
+FROM queues
+ORDER BY name;
+
+-- name: get
+SELECT id, created_at, updated_at, name, description, team_id
+FROM queues
+WHERE id = $1;
+
+-- name: insert
+INSERT INTO queues (name, description, team_id)
+VALUES ($1, $2, $3)
+RETURNING id, created_at, updated_at, name, description, team_id;
+
+-- name: update
+UPDATE queues
+SET name = $2,
+    description = $3,
+    team_id = $4,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING id, created_at, updated_at, name, description, team_id;
+
+-- name: delete
+DELETE FROM queues
+WHERE id = $1;
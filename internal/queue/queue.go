@@ -0,0 +1,117 @@
+// Package queue handles the management of conversation queues.
+package queue
+
+import (
+	"database/sql"
+	"embed"
+
+	"github.com/abhinavxd/libredesk/internal/dbutil"
+	"github.com/abhinavxd/libredesk/internal/envelope"
+	"github.com/abhinavxd/libredesk/internal/queue/models"
+	"github.com/jmoiron/sqlx"
+	"github.com/knadh/go-i18n"
+	"github.com/volatiletech/null/v9"
+	"github.com/zerodha/logf"
+)
+
+var (
+	//go:embed queries.sql
+	efs embed.FS
+)
+
+// Manager manages queues.
+type Manager struct {
+	q    queries
+	lo   *logf.Logger
+	i18n *i18n.I18n
+}
+
+// Opts contains options for initializing the Manager.
+type Opts struct {
+	DB   *sqlx.DB
+	Lo   *logf.Logger
+	I18n *i18n.I18n
+}
+
+// queries contains prepared SQL queries.
+type queries struct {
+	GetAll *sqlx.Stmt `query:"get-all"`
+	Get    *sqlx.Stmt `query:"get"`
+	Insert *sqlx.Stmt `query:"insert"`
+	Update *sqlx.Stmt `query:"update"`
+	Delete *sqlx.Stmt `query:"delete"`
+}
+
+// New creates and returns a new instance of the Manager.
+func New(opts Opts) (*Manager, error) {
+	var q queries
+	if err := dbutil.ScanSQLFile("queries.sql", &q, opts.DB, efs); err != nil {
+		return nil, err
+	}
+	return &Manager{
+		q:    q,
+		lo:   opts.Lo,
+		i18n: opts.I18n,
+	}, nil
+}
+
+// GetAll retrieves all queues.
+func (m *Manager) GetAll() ([]models.Queue, error) {
+	var queues = make([]models.Queue, 0)
+	if err := m.q.GetAll.Select(&queues); err != nil {
+		m.lo.Error("error fetching queues", "error", err)
+		return nil, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", m.i18n.P("globals.terms.queue")), nil)
+	}
+	return queues, nil
+}
+
+// Get retrieves a queue by ID.
+func (m *Manager) Get(id int) (models.Queue, error) {
+	var queue models.Queue
+	if err := m.q.Get.Get(&queue, id); err != nil {
+		if err == sql.ErrNoRows {
+			return queue, envelope.NewError(envelope.NotFoundError, m.i18n.Ts("globals.messages.notFound", "name", "{globals.terms.queue}"), nil)
+		}
+		m.lo.Error("error fetching queue", "id", id, "error", err)
+		return queue, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.queue}"), nil)
+	}
+	return queue, nil
+}
+
+// Create creates a new queue, teamID restricts the queue to the conversations of the team.
+func (m *Manager) Create(name, description string, teamID null.Int) (models.Queue, error) {
+	var queue models.Queue
+	if err := m.q.Insert.Get(&queue, name, description, teamID); err != nil {
+		if dbutil.IsUniqueViolationError(err) {
+			return queue, envelope.NewCodedError(envelope.ConflictError, envelope.ErrCodeDuplicate, m.i18n.Ts("globals.messages.errorAlreadyExists", "name", "{globals.terms.queue}"), nil)
+		}
+		m.lo.Error("error inserting queue", "error", err)
+		return queue, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorCreating", "name", "{globals.terms.queue}"), nil)
+	}
+	return queue, nil
+}
+
+// Update updates a queue by ID.
+func (m *Manager) Update(id int, name, description string, teamID null.Int) (models.Queue, error) {
+	var queue models.Queue
+	if err := m.q.Update.Get(&queue, id, name, description, teamID); err != nil {
+		if err == sql.ErrNoRows {
+			return queue, envelope.NewError(envelope.NotFoundError, m.i18n.Ts("globals.messages.notFound", "name", "{globals.terms.queue}"), nil)
+		}
+		if dbutil.IsUniqueViolationError(err) {
+			return queue, envelope.NewCodedError(envelope.ConflictError, envelope.ErrCodeDuplicate, m.i18n.Ts("globals.messages.errorAlreadyExists", "name", "{globals.terms.queue}"), nil)
+		}
+		m.lo.Error("error updating queue", "id", id, "error", err)
+		return queue, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorUpdating", "name", "{globals.terms.queue}"), nil)
+	}
+	return queue, nil
+}
+
+// Delete deletes a queue by ID, conversations in the queue are left without one.
+func (m *Manager) Delete(id int) error {
+	if _, err := m.q.Delete.Exec(id); err != nil {
+		m.lo.Error("error deleting queue", "id", id, "error", err)
+		return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorDeleting", "name", "{globals.terms.queue}"), nil)
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+
+	cmodels "github.com/abhinavxd/libredesk/internal/conversation/models"
+	"github.com/volatiletech/null/v9"
+)
+
+// AgentStatsFilter filters agent stats to conversations created in the [From, To) range, TeamID 0 includes all agents.
+type AgentStatsFilter struct {
+	From   time.Time `json:"from"`
+	To     time.Time `json:"to"`
+	TeamID int       `json:"team_id"`
+}
+
+// AgentStats holds the performance metrics of an agent, durations are in seconds.
+type AgentStats struct {
+	AgentID               int          `db:"agent_id" json:"agent_id"`
+	FirstName             string       `db:"first_name" json:"first_name"`
+	LastName              string       `db:"last_name" json:"last_name"`
+	Email                 null.String  `db:"email" json:"email"`
+	ConversationsHandled  int          `db:"conversations_handled" json:"conversations_handled"`
+	ConversationsResolved int          `db:"conversations_resolved" json:"conversations_resolved"`
+	AvgFirstReplySeconds  null.Float64 `db:"avg_first_reply_seconds" json:"avg_first_reply_seconds"`
+	AvgResolutionSeconds  null.Float64 `db:"avg_resolution_seconds" json:"avg_resolution_seconds"`
+	CSATAverage           null.Float64 `db:"csat_average" json:"csat_average"`
+	CSATResponses         int          `db:"csat_responses" json:"csat_responses"`
+	CSATFlagged           int          `db:"csat_flagged" json:"csat_flagged"`
+}
+
+// TeamWorkload holds the current workload of a team, open conversations are the ones not resolved or closed.
+type TeamWorkload struct {
+	TeamID             int                     `json:"team_id"`
+	OpenCount          int                     `json:"open_count"`
+	UnassignedCount    int                     `json:"unassigned_count"`
+	OldestWaitingSince null.Time               `json:"oldest_waiting_since"`
+	OldestWaitingAge   int64                   `json:"oldest_waiting_age_seconds"`
+	Agents             []cmodels.AgentWorkload `json:"agents"`
+}
@@ -0,0 +1,35 @@
+-- name: get-agent-stats
+-- Stats of agents for the conversations assigned to them that were created in the date range. The first reply time
+-- is of the agent's own assignments of those conversations, from when the conversation was assigned to them.
+SELECT
+    u.id AS agent_id,
+    u.first_name,
+    COALESCE(u.last_name, '') AS last_name,
+    u.email,
+    COUNT(c.id) AS conversations_handled,
+    COUNT(c.resolved_at) AS conversations_resolved,
+    fr.avg_first_reply_seconds,
+    AVG(EXTRACT(EPOCH FROM c.resolved_at - c.created_at)) AS avg_resolution_seconds,
+    AVG(csat.rating) AS csat_average,
+    COUNT(csat.rating) AS csat_responses,
+    COUNT(*) FILTER (WHERE csat.flagged) AS csat_flagged
+FROM users u
+JOIN conversations c ON c.assigned_user_id = u.id AND c.created_at >= $1 AND c.created_at < $2
+LEFT JOIN LATERAL (
+    SELECT rating, flagged
+    FROM csat_responses
+    WHERE conversation_id = c.id AND rating > 0
+    ORDER BY created_at DESC
+    LIMIT 1
+) csat ON true
+LEFT JOIN LATERAL (
+    SELECT AVG(EXTRACT(EPOCH FROM a.first_reply_at - a.created_at)) AS avg_first_reply_seconds
+    FROM conversation_assignments a
+    JOIN conversations ac ON ac.id = a.conversation_id
+    WHERE a.assigned_user_id = u.id AND a.first_reply_at IS NOT NULL
+    AND ac.created_at >= $1 AND ac.created_at < $2
+) fr ON true
+WHERE u.type = 'agent' AND u.deleted_at IS NULL
+AND ($3 = 0 OR EXISTS (SELECT 1 FROM team_members tm WHERE tm.user_id = u.id AND tm.team_id = $3))
+GROUP BY u.id, fr.avg_first_reply_seconds
+ORDER BY conversations_handled DESC, u.id;
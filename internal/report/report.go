@@ -0,0 +1,174 @@
+// Package report aggregates reporting metrics from conversations, messages and CSAT responses.
+package report
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	cmodels "github.com/abhinavxd/libredesk/internal/conversation/models"
+	"github.com/abhinavxd/libredesk/internal/dbutil"
+	"github.com/abhinavxd/libredesk/internal/envelope"
+	"github.com/abhinavxd/libredesk/internal/report/models"
+	"github.com/jmoiron/sqlx"
+	"github.com/knadh/go-i18n"
+	"github.com/zerodha/logf"
+)
+
+var (
+	//go:embed queries.sql
+	efs embed.FS
+)
+
+// conversationStore is the conversation listing the team workload is built on.
+type conversationStore interface {
+	GetConversations(userID int, teamIDs []int, listTypes []string, order, orderBy, filters string, page, pageSize int) ([]cmodels.Conversation, error)
+	GetTeamAgentsWorkload(teamID int) ([]cmodels.AgentWorkload, error)
+}
+
+// Manager manages reports.
+type Manager struct {
+	q                 queries
+	conversationStore conversationStore
+	lo                *logf.Logger
+	i18n              *i18n.I18n
+	cacheTTL          time.Duration
+
+	cacheMu sync.Mutex
+	cache   map[string]cachedAgentStats
+}
+
+// cachedAgentStats holds agent stats computed for a filter, as the aggregation is expensive.
+type cachedAgentStats struct {
+	stats     []models.AgentStats
+	expiresAt time.Time
+}
+
+// Opts contains options for initializing the Manager.
+type Opts struct {
+	DB                *sqlx.DB
+	ConversationStore conversationStore
+	Lo                *logf.Logger
+	I18n              *i18n.I18n
+	// CacheTTL is how long computed stats are served from memory, 0 disables caching.
+	CacheTTL time.Duration
+}
+
+// queries contains prepared SQL queries.
+type queries struct {
+	GetAgentStats *sqlx.Stmt `query:"get-agent-stats"`
+}
+
+// New creates and returns a new instance of the Manager.
+func New(opts Opts) (*Manager, error) {
+	var q queries
+	if err := dbutil.ScanSQLFile("queries.sql", &q, opts.DB, efs); err != nil {
+		return nil, err
+	}
+	return &Manager{
+		q:                 q,
+		conversationStore: opts.ConversationStore,
+		lo:                opts.Lo,
+		i18n:              opts.I18n,
+		cacheTTL:          opts.CacheTTL,
+		cache:             make(map[string]cachedAgentStats),
+	}, nil
+}
+
+// AgentStats returns per-agent conversations handled, average first reply and resolution times and CSAT average
+// for the conversations assigned to agents that were created in the filter's date range.
+func (m *Manager) AgentStats(filter models.AgentStatsFilter) ([]models.AgentStats, error) {
+	if filter.From.IsZero() || filter.To.IsZero() || !filter.From.Before(filter.To) {
+		return nil, envelope.NewError(envelope.InputError, m.i18n.Ts("globals.messages.invalid", "name", "{globals.terms.dateRange}"), nil)
+	}
+
+	key := fmt.Sprintf("%d:%d:%d", filter.From.Unix(), filter.To.Unix(), filter.TeamID)
+	if stats, ok := m.getCached(key); ok {
+		return stats, nil
+	}
+
+	var stats = make([]models.AgentStats, 0)
+	if err := m.q.GetAgentStats.Select(&stats, filter.From, filter.To, filter.TeamID); err != nil {
+		m.lo.Error("error fetching agent stats", "error", err)
+		return nil, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.report}"), nil)
+	}
+	m.setCached(key, stats)
+	return stats, nil
+}
+
+// TeamWorkload returns the open and unassigned conversation counts of the team, the age of its longest waiting
+// open conversation and the open conversations of each agent in the team.
+func (m *Manager) TeamWorkload(teamID int) (models.TeamWorkload, error) {
+	var workload = models.TeamWorkload{TeamID: teamID}
+	if teamID <= 0 {
+		return workload, envelope.NewError(envelope.InputError, m.i18n.Ts("globals.messages.invalid", "name", "{globals.terms.team}"), nil)
+	}
+
+	filters, err := json.Marshal([]dbutil.Filter{
+		{Model: "conversations", Field: "assigned_team_id", Operator: "equals", Value: fmt.Sprintf("%d", teamID)},
+		{Model: "conversation_statuses", Field: "name", Operator: "not equals", Value: cmodels.StatusResolved},
+		{Model: "conversation_statuses", Field: "name", Operator: "not equals", Value: cmodels.StatusClosed},
+	})
+	if err != nil {
+		m.lo.Error("error marshalling team workload filters", "error", err)
+		return workload, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.report}"), nil)
+	}
+
+	// Only the total is needed, the oldest waiting conversation sorts first as NULLs sort last in ascending order.
+	open, err := m.conversationStore.GetConversations(0, nil, []string{cmodels.AllConversations}, "ASC", "conversations.waiting_since", string(filters), 1, 1)
+	if err != nil {
+		return workload, err
+	}
+	if len(open) > 0 {
+		workload.OpenCount = open[0].Total
+		if open[0].WaitingSince.Valid {
+			workload.OldestWaitingSince = open[0].WaitingSince
+			workload.OldestWaitingAge = int64(time.Since(open[0].WaitingSince.Time).Seconds())
+		}
+	}
+
+	unassigned, err := m.conversationStore.GetConversations(0, []int{teamID}, []string{cmodels.TeamUnassignedConversations}, "", "", string(filters), 1, 1)
+	if err != nil {
+		return workload, err
+	}
+	if len(unassigned) > 0 {
+		workload.UnassignedCount = unassigned[0].Total
+	}
+
+	if workload.Agents, err = m.conversationStore.GetTeamAgentsWorkload(teamID); err != nil {
+		return workload, err
+	}
+	return workload, nil
+}
+
+// getCached returns the unexpired cached stats for the key.
+func (m *Manager) getCached(key string) ([]models.AgentStats, bool) {
+	if m.cacheTTL <= 0 {
+		return nil, false
+	}
+	m.cacheMu.Lock()
+	defer m.cacheMu.Unlock()
+	c, ok := m.cache[key]
+	if !ok || time.Now().After(c.expiresAt) {
+		return nil, false
+	}
+	return c.stats, true
+}
+
+// setCached caches the stats for the key, expired entries are evicted on every write.
+func (m *Manager) setCached(key string, stats []models.AgentStats) {
+	if m.cacheTTL <= 0 {
+		return
+	}
+	m.cacheMu.Lock()
+	defer m.cacheMu.Unlock()
+	now := time.Now()
+	for k, c := range m.cache {
+		if now.After(c.expiresAt) {
+			delete(m.cache, k)
+		}
+	}
+	m.cache[key] = cachedAgentStats{stats: stats, expiresAt: now.Add(m.cacheTTL)}
+}
@@ -0,0 +1,36 @@
+package report
+
+import (
+	"testing"
+	"time"
+
+	"github.com/abhinavxd/libredesk/internal/report/models"
+)
+
+func TestAgentStatsCache(t *testing.T) {
+	tests := []struct {
+		name     string
+		ttl      time.Duration
+		wait     time.Duration
+		expected bool
+	}{
+		{name: "cached", ttl: time.Minute, expected: true},
+		{name: "expired", ttl: time.Millisecond, wait: 5 * time.Millisecond},
+		{name: "disabled", ttl: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &Manager{cacheTTL: tt.ttl, cache: make(map[string]cachedAgentStats)}
+			m.setCached("key", []models.AgentStats{{AgentID: 1}})
+			time.Sleep(tt.wait)
+			stats, ok := m.getCached("key")
+			if ok != tt.expected {
+				t.Fatalf("got cached %v, want %v", ok, tt.expected)
+			}
+			if ok && (len(stats) != 1 || stats[0].AgentID != 1) {
+				t.Errorf("got stats %+v", stats)
+			}
+		})
+	}
+}
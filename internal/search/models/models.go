@@ -1,6 +1,10 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"github.com/volatiletech/null/v9"
+)
 
 type Conversation struct {
 	CreatedAt       time.Time `db:"created_at" json:"created_at"`
@@ -23,3 +27,40 @@ type Contact struct {
 	LastName  string    `db:"last_name" json:"last_name"`
 	Email     string    `db:"email" json:"email"`
 }
+
+// SearchFilter narrows down a full-text conversation search, zero values match everything.
+type SearchFilter struct {
+	InboxID        int    `json:"inbox_id"`
+	TeamID         int    `json:"team_id"`
+	AssignedUserID int    `json:"assigned_user_id"`
+	Status         string `json:"status"`
+}
+
+// SearchScope is the set of conversations a user can read, derived from the `conversations:read_*` permissions of
+// the user the same way as the conversation lists.
+type SearchScope struct {
+	UserID         int
+	TeamIDs        []int
+	ReadAll        bool
+	ReadAssigned   bool
+	ReadUnassigned bool
+	ReadTeamInbox  bool
+}
+
+// ConversationResult is a conversation summary returned by a full-text conversation search.
+type ConversationResult struct {
+	Total           int         `db:"total" json:"-"`
+	CreatedAt       time.Time   `db:"created_at" json:"created_at"`
+	UUID            string      `db:"uuid" json:"uuid"`
+	ReferenceNumber string      `db:"reference_number" json:"reference_number"`
+	Subject         null.String `db:"subject" json:"subject"`
+	Status          null.String `db:"status" json:"status"`
+	InboxName       string      `db:"inbox_name" json:"inbox_name"`
+	ContactName     string      `db:"contact_name" json:"contact_name"`
+	ContactEmail    null.String `db:"contact_email" json:"contact_email"`
+	LastMessage     null.String `db:"last_message" json:"last_message"`
+	LastMessageAt   null.Time   `db:"last_message_at" json:"last_message_at"`
+	MessageUUID     null.String `db:"message_uuid" json:"message_uuid"`
+	Snippet         string      `db:"snippet" json:"snippet"`
+	Rank            float64     `db:"rank" json:"rank"`
+}
@@ -5,7 +5,8 @@ SELECT
     conversations.reference_number,
     conversations.subject
 FROM conversations
-WHERE reference_number::text = $1;
+WHERE reference_number::text = $1
+AND deleted_at IS NULL;
 
 -- name: search-conversations-by-contact-email
 SELECT
@@ -16,6 +17,7 @@ SELECT
 FROM conversations
 JOIN users ON conversations.contact_id = users.id
 WHERE users.email = $1
+AND conversations.deleted_at IS NULL
 ORDER BY conversations.created_at DESC
 LIMIT 1000;
 
@@ -28,6 +30,7 @@ SELECT
 FROM conversation_messages m
     JOIN conversations c ON m.conversation_id = c.id
 WHERE m.type != 'activity' and m.text_content ILIKE '%' || $1 || '%'
+AND c.deleted_at IS NULL
 LIMIT 30;
 
 -- name: search-contacts
@@ -42,3 +45,93 @@ WHERE type = 'contact'
 AND deleted_at IS NULL
 AND email ILIKE '%' || $1 || '%'
 LIMIT 15;
+
+
+-- name: search-all-conversations
+-- Full-text search over the subject, messages and contact of conversations, ranked by relevance and recency.
+-- The snippet is HTML escaped with the matches wrapped in <mark> tags. Only conversations the user can read are
+-- searched ($8-$13), the same scope as the conversation lists, so private notes of other conversations never match.
+WITH q AS (
+    SELECT websearch_to_tsquery('simple', $1) AS query
+),
+message_matches AS (
+    SELECT DISTINCT ON (m.conversation_id)
+        m.conversation_id,
+        m.id AS message_id,
+        ts_rank(m.search_vector, q.query) AS rank
+    FROM conversation_messages m, q
+    WHERE m.search_vector @@ q.query
+    ORDER BY m.conversation_id, ts_rank(m.search_vector, q.query) DESC, m.created_at DESC
+),
+candidates AS (
+    SELECT conversation_id FROM message_matches
+    UNION
+    SELECT c.id FROM conversations c, q
+    WHERE to_tsvector('simple', COALESCE(c."subject", '')) @@ q.query
+    UNION
+    SELECT c.id FROM conversations c JOIN users u ON u.id = c.contact_id, q
+    WHERE to_tsvector('simple', u.first_name || ' ' || COALESCE(u.last_name, '') || ' ' || COALESCE(u.email, '')) @@ q.query
+),
+ranked AS (
+    SELECT
+        c.id,
+        mm.message_id,
+        (
+            COALESCE(mm.rank, 0)
+            + ts_rank(to_tsvector('simple', COALESCE(c."subject", '')), q.query) * 2
+            + ts_rank(to_tsvector('simple', u.first_name || ' ' || COALESCE(u.last_name, '') || ' ' || COALESCE(u.email, '')), q.query)
+        )
+        -- Decay the relevance by the age of the conversation in months.
+        / (1 + EXTRACT(EPOCH FROM NOW() - COALESCE(c.last_message_at, c.created_at)) / 2592000) AS rank
+    FROM candidates
+    JOIN conversations c ON c.id = candidates.conversation_id
+    JOIN users u ON u.id = c.contact_id
+    LEFT JOIN message_matches mm ON mm.conversation_id = c.id
+    LEFT JOIN conversation_statuses s ON s.id = c.status_id
+    CROSS JOIN q
+    WHERE c.deleted_at IS NULL
+    AND ($2 = 0 OR c.inbox_id = $2)
+    AND ($3 = 0 OR c.assigned_team_id = $3)
+    AND ($4 = 0 OR c.assigned_user_id = $4)
+    AND ($5 = '' OR s.name = $5)
+    AND (
+        $8
+        OR ($9 AND c.assigned_user_id = $12)
+        OR ($10 AND c.assigned_user_id IS NULL AND c.assigned_team_id IS NULL)
+        OR ($11 AND c.assigned_user_id IS NULL AND c.assigned_team_id = ANY($13::INT[]))
+    )
+),
+paged AS (
+    SELECT id, message_id, rank, COUNT(*) OVER() AS total
+    FROM ranked
+    ORDER BY rank DESC, id DESC
+    LIMIT $6 OFFSET $7
+)
+SELECT
+    paged.total,
+    paged.rank,
+    c.created_at,
+    c.uuid,
+    c.reference_number,
+    c."subject",
+    s.name AS status,
+    inb.name AS inbox_name,
+    TRIM(u.first_name || ' ' || COALESCE(u.last_name, '')) AS contact_name,
+    u.email AS contact_email,
+    c.last_message,
+    c.last_message_at,
+    m.uuid AS message_uuid,
+    ts_headline(
+        'simple',
+        REPLACE(REPLACE(REPLACE(COALESCE(m.text_content, c."subject", ''), '&', '&amp;'), '<', '&lt;'), '>', '&gt;'),
+        q.query,
+        'StartSel=<mark>, StopSel=</mark>, MaxWords=30, MinWords=10, MaxFragments=1'
+    ) AS snippet
+FROM paged
+JOIN conversations c ON c.id = paged.id
+JOIN users u ON u.id = c.contact_id
+JOIN inboxes inb ON inb.id = c.inbox_id
+LEFT JOIN conversation_statuses s ON s.id = c.status_id
+LEFT JOIN conversation_messages m ON m.id = paged.message_id
+CROSS JOIN q
+ORDER BY paged.rank DESC, paged.id DESC;
@@ -3,12 +3,14 @@ package search
 
 import (
 	"embed"
+	"fmt"
 
 	"github.com/abhinavxd/libredesk/internal/dbutil"
 	"github.com/abhinavxd/libredesk/internal/envelope"
 	models "github.com/abhinavxd/libredesk/internal/search/models"
 	"github.com/jmoiron/sqlx"
 	"github.com/knadh/go-i18n"
+	"github.com/lib/pq"
 	"github.com/zerodha/logf"
 )
 
@@ -17,6 +19,11 @@ var (
    efs embed.FS
 )
 
+const (
+	maxSearchPageSize     = 100
+	defaultSearchPageSize = 20
+)
+
 // Manager is the search manager
 type Manager struct {
    q    queries
@@ -37,6 +44,7 @@ type queries struct {
    SearchConversationsByContactEmail *sqlx.Stmt `query:"search-conversations-by-contact-email"`
    SearchMessages                    *sqlx.Stmt `query:"search-messages"`
    SearchContacts                    *sqlx.Stmt `query:"search-contacts"`
+   SearchAllConversations            *sqlx.Stmt `query:"search-all-conversations"`
 }
 
 // New creates a new search manager
@@ -82,4 +90,26 @@ func (s *Manager) Contacts(query string) ([]models.Contact, error) {
    	return nil, envelope.NewError(envelope.GeneralError, s.i18n.Ts("globals.messages.errorSearching", "name", s.i18n.Ts("globals.terms.contact")), nil)
    }
    return results, nil
-}
\ No newline at end of file
+}
+
+// SearchConversations runs a full-text search across the subject, messages and contact of the conversations in the
+// scope, results are ranked by relevance and recency and contain a snippet of the best matching message.
+func (s *Manager) SearchConversations(query string, filter models.SearchFilter, scope models.SearchScope, page, pageSize int) ([]models.ConversationResult, error) {
+	if pageSize > maxSearchPageSize {
+		return nil, envelope.NewError(envelope.InputError, s.i18n.Ts("globals.messages.pageTooLarge", "max", fmt.Sprintf("%d", maxSearchPageSize)), nil)
+	}
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = defaultSearchPageSize
+	}
+
+	var results = make([]models.ConversationResult, 0)
+	if err := s.q.SearchAllConversations.Select(&results, query, filter.InboxID, filter.TeamID, filter.AssignedUserID, filter.Status, pageSize, (page-1)*pageSize,
+		scope.ReadAll, scope.ReadAssigned, scope.ReadUnassigned, scope.ReadTeamInbox, scope.UserID, pq.Array(scope.TeamIDs)); err != nil {
+		s.lo.Error("error searching conversations", "error", err)
+		return nil, envelope.NewError(envelope.GeneralError, s.i18n.Ts("globals.messages.errorSearching", "name", s.i18n.Ts("globals.terms.conversation")), nil)
+	}
+	return results, nil
+}
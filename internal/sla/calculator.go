@@ -122,6 +122,83 @@ func (m *Manager) CalculateDeadline(start time.Time, slaMinutes int, businessHou
 	return currentTime, nil
 }
 
+// CalculateBusinessMinutes computes the business minutes elapsed between start and end considering the provided holidays,
+// working hours, and time zone.
+func (m *Manager) CalculateBusinessMinutes(start, end time.Time, businessHours models.BusinessHours, timeZone string) (int, error) {
+	if !end.After(start) {
+		return 0, nil
+	}
+
+	// If business is always open, all minutes are business minutes.
+	if businessHours.IsAlwaysOpen {
+		return int(end.Sub(start).Minutes()), nil
+	}
+
+	// Load the specified time zone.
+	loc, err := time.LoadLocation(timeZone)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time zone %s: %v", timeZone, err)
+	}
+
+	// Unmarshal working hours.
+	var workingHours map[string]models.WorkingHours
+	if err := json.Unmarshal(businessHours.Hours, &workingHours); err != nil {
+		return 0, fmt.Errorf("could not unmarshal working hours for business minutes calculation: %v", err)
+	}
+
+	// Unmarshal holidays.
+	var holidays = []models.Holiday{}
+	if len(businessHours.Holidays) > 0 {
+		if err := json.Unmarshal(businessHours.Holidays, &holidays); err != nil {
+			return 0, fmt.Errorf("could not unmarshal holidays for business minutes calculation: %v", err)
+		}
+	}
+	holidaysMap := make(map[string]struct{})
+	for _, holiday := range holidays {
+		holidaysMap[holiday.Date] = struct{}{}
+	}
+
+	var (
+		minutes     float64
+		currentTime = start.In(loc)
+		endTime     = end.In(loc)
+	)
+	for currentTime.Before(endTime) {
+		dateStr := currentTime.Format(time.DateOnly)
+		dayOfWeek := currentTime.Weekday().String()
+		workHours, exists := workingHours[dayOfWeek]
+		if _, isHoliday := holidaysMap[dateStr]; isHoliday || !exists {
+			currentTime = nextDay(currentTime, loc)
+			continue
+		}
+
+		startOfWork, err := parseTime(currentTime, workHours.Open, loc)
+		if err != nil {
+			return 0, fmt.Errorf("invalid open time %s for %s: %v", workHours.Open, dayOfWeek, err)
+		}
+		endOfWork, err := parseTime(currentTime, workHours.Close, loc)
+		if err != nil {
+			return 0, fmt.Errorf("invalid close time %s for %s: %v", workHours.Close, dayOfWeek, err)
+		}
+
+		// Count the overlap of the working hours with the [currentTime, endTime] range.
+		from, to := startOfWork, endOfWork
+		if currentTime.After(from) {
+			from = currentTime
+		}
+		if endTime.Before(to) {
+			to = endTime
+		}
+		if to.After(from) {
+			minutes += to.Sub(from).Minutes()
+		}
+
+		currentTime = nextDay(currentTime, loc)
+	}
+
+	return int(minutes), nil
+}
+
 // nextDay advances the time to the start of the next day in the specified time zone.
 func nextDay(t time.Time, loc *time.Location) time.Time {
 	return time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
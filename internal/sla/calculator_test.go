@@ -511,3 +511,77 @@ func TestCalculateDeadline(t *testing.T) {
 		})
 	}
 }
+
+func TestCalculateBusinessMinutes(t *testing.T) {
+	locUTC := time.UTC
+	weekdays := mustMarshalJSON(map[string]models.WorkingHours{
+		"Monday":    {Open: "09:00", Close: "17:00"},
+		"Tuesday":   {Open: "09:00", Close: "17:00"},
+		"Wednesday": {Open: "09:00", Close: "17:00"},
+		"Thursday":  {Open: "09:00", Close: "17:00"},
+		"Friday":    {Open: "09:00", Close: "17:00"},
+	})
+
+	tests := []struct {
+		name           string
+		start          time.Time
+		end            time.Time
+		businessHours  models.BusinessHours
+		expectedResult int
+	}{
+		{
+			name:           "Always Open Business",
+			start:          time.Date(2023, 10, 10, 9, 0, 0, 0, locUTC),
+			end:            time.Date(2023, 10, 10, 13, 30, 0, 0, locUTC),
+			businessHours:  models.BusinessHours{IsAlwaysOpen: true},
+			expectedResult: 270,
+		},
+		{
+			name:           "End Before Start",
+			start:          time.Date(2023, 10, 10, 13, 0, 0, 0, locUTC),
+			end:            time.Date(2023, 10, 10, 9, 0, 0, 0, locUTC),
+			businessHours:  models.BusinessHours{Hours: weekdays},
+			expectedResult: 0,
+		},
+		{
+			name:           "Within Same Working Day",
+			start:          time.Date(2023, 10, 10, 10, 0, 0, 0, locUTC),
+			end:            time.Date(2023, 10, 10, 14, 0, 0, 0, locUTC),
+			businessHours:  models.BusinessHours{Hours: weekdays},
+			expectedResult: 240,
+		},
+		{
+			name:           "Outside Working Hours Is Not Counted",
+			start:          time.Date(2023, 10, 10, 16, 0, 0, 0, locUTC),
+			end:            time.Date(2023, 10, 11, 10, 0, 0, 0, locUTC),
+			businessHours:  models.BusinessHours{Hours: weekdays},
+			expectedResult: 120,
+		},
+		{
+			name:           "Over The Weekend",
+			start:          time.Date(2023, 10, 13, 16, 0, 0, 0, locUTC), // Friday
+			end:            time.Date(2023, 10, 16, 10, 0, 0, 0, locUTC), // Monday
+			businessHours:  models.BusinessHours{Hours: weekdays},
+			expectedResult: 120,
+		},
+		{
+			name:  "Holiday Is Not Counted",
+			start: time.Date(2023, 10, 10, 16, 0, 0, 0, locUTC),
+			end:   time.Date(2023, 10, 12, 10, 0, 0, 0, locUTC),
+			businessHours: models.BusinessHours{
+				Hours:    weekdays,
+				Holidays: mustMarshalJSON([]models.Holiday{{Date: "2023-10-11"}}),
+			},
+			expectedResult: 120,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &Manager{}
+			result, err := m.CalculateBusinessMinutes(tt.start, tt.end, tt.businessHours, "UTC")
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedResult, result)
+		})
+	}
+}
@@ -15,6 +15,7 @@ import (
 	"github.com/abhinavxd/libredesk/internal/dbutil"
 	"github.com/abhinavxd/libredesk/internal/envelope"
 	notifier "github.com/abhinavxd/libredesk/internal/notification"
+	pmodels "github.com/abhinavxd/libredesk/internal/notification/preference/models"
 	"github.com/abhinavxd/libredesk/internal/sla/models"
 	"github.com/abhinavxd/libredesk/internal/stringutil"
 	tmodels "github.com/abhinavxd/libredesk/internal/team/models"
@@ -402,7 +403,7 @@ func (m *Manager) SendNotification(scheduledNotification models.ScheduledSLANoti
 		}
 
 		// Render the email template.
-		content, subject, err := m.template.RenderStoredEmailTemplate(tmpl,
+		content, subject, err := m.template.RenderStoredEmailTemplate(tmpl, agent.Locale.String,
 			map[string]any{
 				"SLA": map[string]any{
 					"DueIn":     dueIn,
@@ -436,12 +437,14 @@ func (m *Manager) SendNotification(scheduledNotification models.ScheduledSLANoti
 
 		// Enqueue email notification.
 		if err := m.notifier.Send(notifier.Message{
+			UserIDs: []int{agent.ID},
 			RecipientEmails: []string{
 				agent.Email.String,
 			},
-			Subject:  subject,
-			Content:  content,
-			Provider: notifier.ProviderEmail,
+			Subject:   subject,
+			Content:   content,
+			Provider:  notifier.ProviderEmail,
+			EventType: pmodels.EventSLAAlert,
 		}); err != nil {
 			m.lo.Error("error sending email notification", "error", err)
 		}
@@ -460,6 +463,16 @@ func (m *Manager) Close() error {
 	return nil
 }
 
+// BusinessMinutesSince returns the business minutes elapsed since the passed time, using the business hours of the team
+// falling back to the default business hours.
+func (m *Manager) BusinessMinutesSince(start time.Time, assignedTeamID int) (int, error) {
+	businessHrs, timezone, err := m.getBusinessHoursAndTimezone(assignedTeamID)
+	if err != nil {
+		return 0, err
+	}
+	return m.CalculateBusinessMinutes(start, time.Now(), businessHrs, timezone)
+}
+
 // getBusinessHoursAndTimezone returns the business hours ID and timezone for a team, falling back to app settings i.e. default helpdesk settings.
 func (m *Manager) getBusinessHoursAndTimezone(assignedTeamID int) (bmodels.BusinessHours, string, error) {
 	var (
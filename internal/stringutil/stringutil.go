@@ -5,23 +5,39 @@ import (
 	"crypto/rand"
 	"encoding/base64"
 	"fmt"
+	"mime"
 	"net/mail"
 	"net/url"
 	"path/filepath"
 	"regexp"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/k3a/html2text"
 )
 
 const (
 	PasswordDummy = "•"
+
+	// TimestampLayout is the ISO-8601 layout with milliseconds and the UTC offset used for timestamps sent to clients.
+	TimestampLayout = "2006-01-02T15:04:05.000Z07:00"
 )
 
 var (
 	regexpNonAlNum = regexp.MustCompile(`[^a-zA-Z0-9\-_\.]+`)
 	regexpSpaces   = regexp.MustCompile(`[\s]+`)
+	regexpLocale   = regexp.MustCompile(`^[a-zA-Z]{2,3}(-[a-zA-Z0-9]{2,8})*$`)
+
+	// Quoted reply markers, matched against a single trimmed line of text.
+	regexpQuoteWrote          = regexp.MustCompile(`(?i)^On\s.+\swrote:$`)
+	regexpQuoteOriginal       = regexp.MustCompile(`(?i)^-{2,}\s*Original Message\s*-{2,}$`)
+	regexpQuoteForwarded      = regexp.MustCompile(`(?i)^-{2,}\s*Forwarded message\s*-{2,}$`)
+	regexpQuoteOutlookDivider = regexp.MustCompile(`^_{10,}$`)
+	regexpQuoteOutlookHeader  = regexp.MustCompile(`(?i)^(Sent|Date|To|Subject):\s`)
+
+	// Signature markers, matched against a single trimmed line of text.
+	regexpSignatureMobile = regexp.MustCompile(`(?i)^(Sent from my \w+|Sent from (Mail|Yahoo Mail|Outlook) for \w+|Get Outlook for \w+)`)
 )
 
 // HTML2Text converts HTML to text.
@@ -29,6 +45,134 @@ func HTML2Text(html string) string {
 	return strings.TrimSpace(html2text.HTML2Text(html))
 }
 
+// SanitizeAndTruncate converts HTML to text, strips the quoted reply history and signature, collapses whitespace and truncates
+// the text to `maxLen` characters. Truncation counts runes, not bytes, so multibyte characters are never cut, 0 or less disables truncation.
+func SanitizeAndTruncate(html string, maxLen int) string {
+	text := StripSignature(StripQuotedReply(HTML2Text(html)))
+	text = strings.TrimSpace(regexpSpaces.ReplaceAllString(text, " "))
+	return TruncateRunes(text, maxLen)
+}
+
+// StripQuotedReply removes the quoted history of previous messages from the text of an email reply.
+// It cuts the text at the first line that starts a quote, i.e. "On <date> <name> wrote:", "-----Original Message-----",
+// an Outlook reply header or a trailing block of lines starting with ">". If nothing is left the text is returned as is.
+func StripQuotedReply(text string) string {
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	cut := len(lines)
+	for i := range lines {
+		if isQuoteStart(lines, i) {
+			cut = i
+			break
+		}
+	}
+
+	// Trailing block of ">" quoted lines, quotes interleaved with replies are kept.
+	for cut > 0 {
+		line := strings.TrimSpace(lines[cut-1])
+		if line != "" && !strings.HasPrefix(line, ">") {
+			break
+		}
+		cut--
+	}
+
+	stripped := strings.TrimSpace(strings.Join(lines[:cut], "\n"))
+	if stripped == "" {
+		return strings.TrimSpace(text)
+	}
+	return stripped
+}
+
+// DetectSignature returns the start and end offsets, in runes, of the signature in the text of an email, excluding any
+// quoted reply history after it. A signature starts at the "-- " delimiter or a mobile client signature like "Sent from my iPhone".
+// Detection is conservative, sign-offs like "Regards," are kept as they're often followed by content, and the first line of text is never
+// a signature.
+func DetectSignature(text string) (start, end int, ok bool) {
+	lines := strings.Split(text, "\n")
+
+	// Signatures end where the quoted reply starts.
+	endLine := len(lines)
+	for i := range lines {
+		if isQuoteStart(lines, i) {
+			endLine = i
+			break
+		}
+	}
+
+	startLine := -1
+	for i := 1; i < endLine; i++ {
+		line := strings.TrimSpace(lines[i])
+		if line == "--" || regexpSignatureMobile.MatchString(line) {
+			startLine = i
+			break
+		}
+	}
+	if startLine < 0 || countNonEmpty(lines[:startLine]) == 0 {
+		return 0, 0, false
+	}
+
+	// Convert line indexes to rune offsets, trailing blank lines are not part of the signature.
+	for endLine > startLine && strings.TrimSpace(lines[endLine-1]) == "" {
+		endLine--
+	}
+	start = utf8.RuneCountInString(strings.Join(lines[:startLine], "\n")) + 1
+	end = start + utf8.RuneCountInString(strings.Join(lines[startLine:endLine], "\n"))
+	return start, end, true
+}
+
+// StripSignature removes the signature detected by DetectSignature from the text.
+func StripSignature(text string) string {
+	start, end, ok := DetectSignature(text)
+	if !ok {
+		return text
+	}
+	runes := []rune(text)
+	return strings.TrimSpace(string(runes[:start]) + string(runes[end:]))
+}
+
+// countNonEmpty returns the number of lines that are not blank.
+func countNonEmpty(lines []string) int {
+	var n int
+	for _, l := range lines {
+		if strings.TrimSpace(l) != "" {
+			n++
+		}
+	}
+	return n
+}
+
+// isQuoteStart returns true if the line at index i starts a quoted reply.
+func isQuoteStart(lines []string, i int) bool {
+	line := strings.TrimSpace(lines[i])
+	switch {
+	case line == "":
+		return false
+	case regexpQuoteWrote.MatchString(line), regexpQuoteOriginal.MatchString(line), regexpQuoteForwarded.MatchString(line):
+		return true
+	case i+1 < len(lines) && strings.HasPrefix(line, "On ") && regexpQuoteWrote.MatchString(line+" "+strings.TrimSpace(lines[i+1])):
+		// Clients wrap long "On ... wrote:" lines.
+		return true
+	case regexpQuoteOutlookDivider.MatchString(line):
+		return true
+	case strings.HasPrefix(strings.ToLower(line), "from:"):
+		// Outlook reply header, a "From:" line followed by other header lines.
+		for j := i + 1; j < len(lines) && j <= i+3; j++ {
+			if regexpQuoteOutlookHeader.MatchString(strings.TrimSpace(lines[j])) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// TruncateRunes truncates the string to at most `maxLen` runes, 0 or less returns the string as is.
+func TruncateRunes(s string, maxLen int) string {
+	if maxLen <= 0 || utf8.RuneCountInString(s) <= maxLen {
+		return s
+	}
+	runes := []rune(s)
+	return string(runes[:maxLen])
+}
+
 // SanitizeFilename sanitizes the provided filename.
 func SanitizeFilename(fName string) string {
 	// Trim whitespace.
@@ -45,6 +189,90 @@ func SanitizeFilename(fName string) string {
 	return filepath.Base(name)
 }
 
+// genericContentTypes don't tell the file type, filenames sent with them are left as is.
+var genericContentTypes = map[string]bool{
+	"":                           true,
+	"application/octet-stream":   true,
+	"binary/octet-stream":        true,
+	"application/unknown":        true,
+	"application/download":       true,
+	"application/x-download":     true,
+	"application/force-download": true,
+}
+
+// preferredExtensions are the extensions used for content types with several registered extensions, the
+// extensions returned by the mime package for them depend on the system's mime.types files.
+var preferredExtensions = map[string]string{
+	"application/pdf":    ".pdf",
+	"application/zip":    ".zip",
+	"application/msword": ".doc",
+	"application/vnd.openxmlformats-officedocument.wordprocessingml.document": ".docx",
+	"application/vnd.ms-excel": ".xls",
+	"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet":         ".xlsx",
+	"application/vnd.ms-powerpoint":                                             ".ppt",
+	"application/vnd.openxmlformats-officedocument.presentationml.presentation": ".pptx",
+	"image/jpeg":       ".jpg",
+	"image/png":        ".png",
+	"image/gif":        ".gif",
+	"image/webp":       ".webp",
+	"image/svg+xml":    ".svg",
+	"image/tiff":       ".tiff",
+	"text/plain":       ".txt",
+	"text/csv":         ".csv",
+	"text/html":        ".html",
+	"text/calendar":    ".ics",
+	"message/rfc822":   ".eml",
+	"audio/mpeg":       ".mp3",
+	"video/mp4":        ".mp4",
+	"application/json": ".json",
+	"application/xml":  ".xml",
+	"text/xml":         ".xml",
+}
+
+// FixFileExtension makes sure the filename has an extension, e.g. "file" sent as `application/pdf` becomes
+// "file.pdf". The extension of the content type is appended to names without an extension or with an unknown one,
+// known extensions are kept even if they're of another type as senders often use a loose content type, e.g. a CSV
+// file sent as `application/vnd.ms-excel`. Filenames are left as is for generic or unknown content types.
+func FixFileExtension(name, contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil || genericContentTypes[mediaType] {
+		return name
+	}
+
+	ext := strings.ToLower(filepath.Ext(name))
+	if ext != "" && knownExtension(ext) {
+		return name
+	}
+
+	want, ok := preferredExtensions[mediaType]
+	if !ok {
+		exts, _ := mime.ExtensionsByType(mediaType)
+		if len(exts) == 0 {
+			return name
+		}
+		want = exts[0]
+	}
+
+	base := name
+	if base == "" {
+		base = "attachment"
+	}
+	return base + want
+}
+
+// knownExtension returns true if the extension is registered for a content type.
+func knownExtension(ext string) bool {
+	if mime.TypeByExtension(ext) != "" {
+		return true
+	}
+	for _, e := range preferredExtensions {
+		if e == ext {
+			return true
+		}
+	}
+	return false
+}
+
 // RandomAlphanumeric generates a random alphanumeric string of length n.
 func RandomAlphanumeric(n int) (string, error) {
 	const dictionary = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
@@ -191,3 +419,23 @@ func ValidEmail(email string) bool {
 	}
 	return addr.Name == "" && addr.Address == email
 }
+
+// FormatTimestamp formats a time with `TimestampLayout` in UTC, so timestamps in websocket events, activities and
+// previews are formatted the same and clients render them in the user's timezone.
+func FormatTimestamp(t time.Time) string {
+	return t.UTC().Format(TimestampLayout)
+}
+
+// ValidLocale returns true if locale is a language tag such as `de` or `pt-BR`.
+func ValidLocale(locale string) bool {
+	return len(locale) <= 20 && regexpLocale.MatchString(locale)
+}
+
+// ValidTimezone returns true if tz is an IANA timezone name such as `Asia/Kolkata`.
+func ValidTimezone(tz string) bool {
+	if tz == "" || tz == "Local" {
+		return false
+	}
+	_, err := time.LoadLocation(tz)
+	return err == nil
+}
@@ -141,3 +141,189 @@ func TestFormatDuration(t *testing.T) {
 		})
 	}
 }
+
+func TestSanitizeAndTruncate(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		maxLen   int
+		expected string
+	}{
+		{name: "short text", input: "Hello", maxLen: 10, expected: "Hello"},
+		{name: "html and whitespace", input: "<p>Hello\n\n  <b>world</b></p>", maxLen: 50, expected: "Hello world"},
+		{name: "truncated", input: "Hello world", maxLen: 5, expected: "Hello"},
+		{name: "multibyte characters", input: "こんにちは世界", maxLen: 5, expected: "こんにちは"},
+		{name: "emoji", input: "👋🌍 hi", maxLen: 1, expected: "👋"},
+		{name: "no limit", input: "Hello world", maxLen: 0, expected: "Hello world"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SanitizeAndTruncate(tt.input, tt.maxLen); got != tt.expected {
+				t.Errorf("got %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestStripQuotedReply(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{name: "no quote", input: "Thanks!\nJohn", expected: "Thanks!\nJohn"},
+		{name: "gmail", input: "Thanks, works now\r\nOn Mon, Jan 6, 2025 at 10:00 AM John <john@x.com> wrote:\r\nHello", expected: "Thanks, works now"},
+		{name: "wrapped wrote line", input: "Sure\nOn Mon, Jan 6, 2025 at 10:00 AM John Doe <john@example.com>\nwrote:\n> Hello", expected: "Sure"},
+		{name: "original message", input: "See below\n\n-----Original Message-----\nFrom: a@b.com", expected: "See below"},
+		{name: "outlook header", input: "Done\n\nFrom: Support <support@example.com>\nSent: Monday, January 6, 2025\nTo: John", expected: "Done"},
+		{name: "from line in body is kept", input: "From: the team\nWe shipped it.", expected: "From: the team\nWe shipped it."},
+		{name: "trailing quoted lines", input: "Yes please\n\n> Do you want a refund?\n> Thanks", expected: "Yes please"},
+		{name: "interleaved quotes are kept", input: "> Question?\nAnswer.", expected: "> Question?\nAnswer."},
+		{name: "only a quote", input: "> Hello", expected: "> Hello"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := StripQuotedReply(tt.input); got != tt.expected {
+				t.Errorf("got %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestDetectSignature(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		signature string
+	}{
+		{name: "no signature", input: "Hello\nCan you help?"},
+		{name: "delimiter", input: "Hello\n-- \nJohn Doe\nACME Inc.", signature: "-- \nJohn Doe\nACME Inc."},
+		{name: "mobile", input: "Sounds good\n\nSent from my iPhone", signature: "Sent from my iPhone"},
+		{name: "sign-off kept", input: "Please refund the order.\n\nRegards,\nJohn\n+1 555 0100"},
+		{name: "sign-off followed by content", input: "Hi\nThanks!\nAlso, one\nmore\nthing\nto\nask"},
+		{name: "underscores aren't a delimiter", input: "Hi\n__\nJohn"},
+		{name: "first line is never a signature", input: "-- \nJohn"},
+		{name: "stops at quoted reply", input: "Ok\n-- \nJohn\n\nOn Mon, Jan 6, 2025 at 10:00 AM Support <s@x.com> wrote:\nHi", signature: "-- \nJohn"},
+		{name: "multibyte", input: "こんにちは\n-- \n山田", signature: "-- \n山田"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end, ok := DetectSignature(tt.input)
+			if ok != (tt.signature != "") {
+				t.Fatalf("got ok %v, want %v", ok, tt.signature != "")
+			}
+			if !ok {
+				return
+			}
+			if got := string([]rune(tt.input)[start:end]); got != tt.signature {
+				t.Errorf("got %q, want %q", got, tt.signature)
+			}
+		})
+	}
+}
+
+func TestStripSignature(t *testing.T) {
+	if got := StripSignature("Sounds good\n\nSent from my iPhone"); got != "Sounds good" {
+		t.Errorf("got %q, want %q", got, "Sounds good")
+	}
+}
+
+func TestFixFileExtension(t *testing.T) {
+	tests := []struct {
+		name        string
+		filename    string
+		contentType string
+		expected    string
+	}{
+		{name: "missing extension", filename: "file", contentType: "application/pdf", expected: "file.pdf"},
+		{name: "matching extension", filename: "invoice.pdf", contentType: "application/pdf", expected: "invoice.pdf"},
+		{name: "matching alternate extension", filename: "photo.jpeg", contentType: "image/jpeg", expected: "photo.jpeg"},
+		{name: "uppercase extension", filename: "photo.PNG", contentType: "image/png", expected: "photo.PNG"},
+		{name: "content type params", filename: "notes", contentType: "text/plain; charset=utf-8", expected: "notes.txt"},
+		{name: "known extension of another type kept", filename: "report.txt", contentType: "application/pdf", expected: "report.txt"},
+		{name: "csv sent as excel", filename: "data.csv", contentType: "application/vnd.ms-excel", expected: "data.csv"},
+		{name: "unknown extension kept", filename: "report.final", contentType: "application/pdf", expected: "report.final.pdf"},
+		{name: "generic content type", filename: "file", contentType: "application/octet-stream", expected: "file"},
+		{name: "empty content type", filename: "file", contentType: "", expected: "file"},
+		{name: "invalid content type", filename: "file", contentType: "not a type", expected: "file"},
+		{name: "unknown content type", filename: "file", contentType: "application/x-libredesk-unknown", expected: "file"},
+		{name: "empty name", filename: "", contentType: "image/png", expected: "attachment.png"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FixFileExtension(tt.filename, tt.contentType); got != tt.expected {
+				t.Errorf("got %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFormatTimestamp(t *testing.T) {
+	ist := time.FixedZone("IST", 5*60*60+30*60)
+	tests := []struct {
+		name     string
+		t        time.Time
+		expected string
+	}{
+		{name: "utc", t: time.Date(2025, 3, 1, 10, 4, 5, 0, time.UTC), expected: "2025-03-01T10:04:05.000Z"},
+		{name: "offset converted to utc", t: time.Date(2025, 3, 1, 10, 4, 5, 0, ist), expected: "2025-03-01T04:34:05.000Z"},
+		{name: "milliseconds", t: time.Date(2025, 3, 1, 10, 4, 5, 123456789, time.UTC), expected: "2025-03-01T10:04:05.123Z"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FormatTimestamp(tt.t); got != tt.expected {
+				t.Errorf("got %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestValidLocale(t *testing.T) {
+	tests := []struct {
+		locale   string
+		expected bool
+	}{
+		{locale: "de", expected: true},
+		{locale: "pt-BR", expected: true},
+		{locale: "zh-Hant-TW", expected: true},
+		{locale: "", expected: false},
+		{locale: "d", expected: false},
+		{locale: "en_US", expected: false},
+		{locale: "de-", expected: false},
+		{locale: "en-abcdefghijklmnopqrstu", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.locale, func(t *testing.T) {
+			if got := ValidLocale(tt.locale); got != tt.expected {
+				t.Errorf("ValidLocale(%q) = %v, want %v", tt.locale, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestValidTimezone(t *testing.T) {
+	tests := []struct {
+		tz       string
+		expected bool
+	}{
+		{tz: "Asia/Kolkata", expected: true},
+		{tz: "UTC", expected: true},
+		{tz: "", expected: false},
+		{tz: "Local", expected: false},
+		{tz: "Mars/Olympus", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.tz, func(t *testing.T) {
+			if got := ValidTimezone(tt.tz); got != tt.expected {
+				t.Errorf("ValidTimezone(%q) = %v, want %v", tt.tz, got, tt.expected)
+			}
+		})
+	}
+}
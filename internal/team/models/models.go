@@ -20,6 +20,7 @@ type Team struct {
 	BusinessHoursID              null.Int    `db:"business_hours_id" json:"business_hours_id,omitempty"`
 	SLAPolicyID                  null.Int    `db:"sla_policy_id" json:"sla_policy_id,omitempty"`
 	MaxAutoAssignedConversations int         `db:"max_auto_assigned_conversations" json:"max_auto_assigned_conversations"`
+	CSATEnabled                  bool        `db:"csat_enabled" json:"csat_enabled"`
 }
 
 type Teams []Team
@@ -0,0 +1,134 @@
+package template
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"html/template"
+	"reflect"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Funcs returns the helper functions available in all templates. They are pure string and date helpers that take
+// the piped value last like sprig, e.g. `{{ .Contact.FirstName | default "there" | title }}`. Nothing here touches
+// the filesystem, environment or network, so templates edited by agents can't run arbitrary code.
+func Funcs() template.FuncMap {
+	return template.FuncMap{
+		"upper":      func(v any) string { return strings.ToUpper(toString(v)) },
+		"lower":      func(v any) string { return strings.ToLower(toString(v)) },
+		"title":      func(v any) string { return title(toString(v)) },
+		"trim":       func(v any) string { return strings.TrimSpace(toString(v)) },
+		"trunc":      truncate,
+		"default":    defaultValue,
+		"replace":    func(old, new string, v any) string { return strings.ReplaceAll(toString(v), old, new) },
+		"contains":   func(substr string, v any) bool { return strings.Contains(toString(v), substr) },
+		"hasPrefix":  func(prefix string, v any) bool { return strings.HasPrefix(toString(v), prefix) },
+		"hasSuffix":  func(suffix string, v any) bool { return strings.HasSuffix(toString(v), suffix) },
+		"join":       func(sep string, v []string) string { return strings.Join(v, sep) },
+		"now":        time.Now,
+		"formatDate": formatDate,
+	}
+}
+
+// toString converts a template value to a string, invalid null types and nil are empty.
+func toString(v any) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return t
+	case fmt.Stringer:
+		return t.String()
+	case driver.Valuer:
+		val, err := t.Value()
+		if err != nil || val == nil {
+			return ""
+		}
+		return fmt.Sprint(val)
+	}
+	return fmt.Sprint(v)
+}
+
+// title uppercases the first letter of every word.
+func title(s string) string {
+	prev := ' '
+	return strings.Map(func(r rune) rune {
+		defer func() { prev = r }()
+		if unicode.IsSpace(prev) {
+			return unicode.ToUpper(r)
+		}
+		return r
+	}, s)
+}
+
+// truncate truncates the value to `n` characters.
+func truncate(n int, v any) string {
+	s := toString(v)
+	if n < 0 || utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n])
+}
+
+// defaultValue returns `def` if the value is empty, i.e. nil, a zero value or an invalid null type.
+func defaultValue(def any, v any) any {
+	if isEmpty(v) {
+		return def
+	}
+	return v
+}
+
+// isEmpty returns true for nil, zero values, invalid null types and empty slices and maps.
+func isEmpty(v any) bool {
+	if v == nil {
+		return true
+	}
+	if z, ok := v.(interface{ IsZero() bool }); ok {
+		return z.IsZero()
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Map, reflect.Array, reflect.String:
+		return rv.Len() == 0
+	}
+	return rv.IsZero()
+}
+
+// formatDate formats a time with the Go layout, e.g. `{{ formatDate "2006-01-02" .Conversation.CreatedAt }}`.
+// Accepts time values, valid null times and RFC3339 strings, anything else is formatted as empty.
+func formatDate(layout string, v any) string {
+	var t time.Time
+	switch d := v.(type) {
+	case time.Time:
+		t = d
+	case *time.Time:
+		if d == nil {
+			return ""
+		}
+		t = *d
+	case string:
+		parsed, err := time.Parse(time.RFC3339, d)
+		if err != nil {
+			return ""
+		}
+		t = parsed
+	case driver.Valuer:
+		val, err := d.Value()
+		if err != nil {
+			return ""
+		}
+		tv, ok := val.(time.Time)
+		if !ok {
+			return ""
+		}
+		t = tv
+	default:
+		return ""
+	}
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(layout)
+}
@@ -0,0 +1,60 @@
+package template
+
+import (
+	"bytes"
+	"html/template"
+	"testing"
+	"time"
+
+	"github.com/volatiletech/null/v9"
+)
+
+func TestFuncs(t *testing.T) {
+	created := time.Date(2025, 3, 14, 9, 30, 0, 0, time.UTC)
+	data := map[string]any{
+		"Name":      "jane doe",
+		"Empty":     "",
+		"NullEmail": null.String{},
+		"Email":     null.StringFrom("jane@example.com"),
+		"Created":   created,
+		"NullTime":  null.TimeFrom(created),
+		"Tags":      []string{"billing", "refund"},
+	}
+
+	tests := []struct {
+		name     string
+		tmpl     string
+		expected string
+	}{
+		{name: "upper", tmpl: `{{ .Name | upper }}`, expected: "JANE DOE"},
+		{name: "title", tmpl: `{{ .Name | title }}`, expected: "Jane Doe"},
+		{name: "trunc", tmpl: `{{ .Name | trunc 4 }}`, expected: "jane"},
+		{name: "default empty", tmpl: `{{ .Empty | default "there" }}`, expected: "there"},
+		{name: "default missing", tmpl: `{{ .Missing | default "there" }}`, expected: "there"},
+		{name: "default null", tmpl: `{{ .NullEmail | default "n/a" }}`, expected: "n/a"},
+		{name: "default set", tmpl: `{{ .Name | default "there" }}`, expected: "jane doe"},
+		{name: "null string", tmpl: `{{ .Email | lower }}`, expected: "jane@example.com"},
+		{name: "replace", tmpl: `{{ .Name | replace "jane" "john" }}`, expected: "john doe"},
+		{name: "contains", tmpl: `{{ if .Name | contains "doe" }}yes{{ end }}`, expected: "yes"},
+		{name: "join", tmpl: `{{ .Tags | join ", " }}`, expected: "billing, refund"},
+		{name: "format date", tmpl: `{{ .Created | formatDate "02 Jan 2006" }}`, expected: "14 Mar 2025"},
+		{name: "format null time", tmpl: `{{ formatDate "2006-01-02" .NullTime }}`, expected: "2025-03-14"},
+		{name: "format invalid date", tmpl: `{{ formatDate "2006-01-02" .Name }}`, expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := template.New("test").Funcs(Funcs()).Parse(tt.tmpl)
+			if err != nil {
+				t.Fatalf("error parsing template: %v", err)
+			}
+			var buf bytes.Buffer
+			if err := tmpl.Execute(&buf, data); err != nil {
+				t.Fatalf("error executing template: %v", err)
+			}
+			if got := buf.String(); got != tt.expected {
+				t.Errorf("got %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
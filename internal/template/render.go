@@ -6,6 +6,7 @@ import (
 	"strings"
 	"text/template"
 
+	"github.com/abhinavxd/libredesk/internal/template/models"
 	"github.com/valyala/fasthttp"
 )
 
@@ -18,6 +19,7 @@ const (
 	// Built-in templates fetched from memory stored in `static` directory.
 	TmplResetPassword = "reset-password"
 	TmplWelcome       = "welcome"
+	TmplCSATReply     = "csat-reply"
 
 	// Template names for rendering.
 	TmplBase    = "base"
@@ -25,11 +27,12 @@ const (
 )
 
 // RenderEmailWithTemplate renders content inside the default outgoing email template.
-func (m *Manager) RenderEmailWithTemplate(data any, content string) (string, error) {
+// The outgoing template for the passed locale, stored as `<name>.<locale>`, is used when present.
+func (m *Manager) RenderEmailWithTemplate(locale string, data any, content string) (string, error) {
 	m.mutex.RLock()
 	defer m.mutex.RUnlock()
 
-	defaultTmpl, err := m.getDefaultOutgoingEmailTemplate()
+	defaultTmpl, err := m.getOutgoingEmailTemplate(locale)
 	if err != nil {
 		m.lo.Error("error fetching default outgoing email template", "error", err)
 	}
@@ -61,9 +64,49 @@ func (m *Manager) RenderEmailWithTemplate(data any, content string) (string, err
 	return rendered.String(), nil
 }
 
+// RenderText renders the placeholders of text content on its own, without the outgoing email template. It's used for
+// plain text emails which don't get the HTML layout.
+func (m *Manager) RenderText(data any, content string) (string, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	tmpl, err := template.New(TmplContent).Funcs(m.funcMap).Parse(content)
+	if err != nil {
+		return "", fmt.Errorf("parsing content template: %w", err)
+	}
+	var rendered strings.Builder
+	if err := tmpl.Execute(&rendered, data); err != nil {
+		return "", fmt.Errorf("executing content template: %w", err)
+	}
+	return rendered.String(), nil
+}
+
+// localizedNames returns the template names to look up for a locale in the order of preference,
+// e.g. `welcome.pt-BR`, `welcome.pt` and finally `welcome`.
+func localizedNames(name, locale string) []string {
+	locale = strings.TrimSpace(locale)
+	if locale == "" {
+		return []string{name}
+	}
+	names := []string{name + "." + locale}
+	if lang, _, ok := strings.Cut(locale, "-"); ok && lang != "" {
+		names = append(names, name+"."+lang)
+	}
+	return append(names, name)
+}
+
 // RenderStoredEmailTemplate fetches and renders an email template from the database, including subject and body and returns the rendered content.
-func (m *Manager) RenderStoredEmailTemplate(name string, data any) (string, string, error) {
-	tmpl, err := m.getByName(name)
+// The template for the passed locale, stored as `<name>.<locale>`, is used when present, falling back to the default template.
+func (m *Manager) RenderStoredEmailTemplate(name, locale string, data any) (string, string, error) {
+	var (
+		tmpl models.Template
+		err  error
+	)
+	for _, n := range localizedNames(name, locale) {
+		if tmpl, err = m.getByName(n); err != ErrTemplateNotFound {
+			break
+		}
+	}
 	if err != nil {
 		if err == ErrTemplateNotFound {
 			return "", "", fmt.Errorf("template %s not found", name)
@@ -83,7 +126,7 @@ func (m *Manager) RenderStoredEmailTemplate(name string, data any) (string, stri
 		return sb.String(), nil
 	}
 
-	defaultTmpl, err := m.getDefaultOutgoingEmailTemplate()
+	defaultTmpl, err := m.getOutgoingEmailTemplate(locale)
 	if err != nil {
 		m.lo.Error("error fetching default outgoing email template", "error", err)
 	}
@@ -122,9 +165,16 @@ func (m *Manager) RenderStoredEmailTemplate(name string, data any) (string, stri
 
 // RenderInMemoryTemplate executes an in-memory template with data and returns the rendered content.
 // This is for system emails like reset password and welcome email etc.
-func (m *Manager) RenderInMemoryTemplate(name string, data interface{}) (string, error) {
+// The template for the passed locale, defined as `<name>.<locale>`, is used when present, falling back to the default template.
+func (m *Manager) RenderInMemoryTemplate(name, locale string, data interface{}) (string, error) {
 	m.mutex.RLock()
 	defer m.mutex.RUnlock()
+	for _, n := range localizedNames(name, locale) {
+		if m.tpls.Lookup(n) != nil {
+			name = n
+			break
+		}
+	}
 	var buf bytes.Buffer
 	if err := m.tpls.ExecuteTemplate(&buf, name, data); err != nil {
 		return "", fmt.Errorf("executing in-memory template %q: %w", name, err)
@@ -132,6 +182,20 @@ func (m *Manager) RenderInMemoryTemplate(name string, data interface{}) (string,
 	return buf.String(), nil
 }
 
+// RenderLocalizedWebPage renders a web template like RenderWebPage, the template for the passed locale, defined as
+// `<name>.<locale>`, is used when present.
+func (m *Manager) RenderLocalizedWebPage(ctx *fasthttp.RequestCtx, tmplFile, locale string, data map[string]interface{}) error {
+	m.mutex.RLock()
+	for _, n := range localizedNames(tmplFile, locale) {
+		if m.webTpls.Lookup(n) != nil {
+			tmplFile = n
+			break
+		}
+	}
+	m.mutex.RUnlock()
+	return m.RenderWebPage(ctx, tmplFile, data)
+}
+
 // RenderWebPage renders a template to the http.ResponseWriter with data.
 func (m *Manager) RenderWebPage(ctx *fasthttp.RequestCtx, tmplFile string, data map[string]interface{}) error {
 	m.mutex.RLock()
@@ -0,0 +1,42 @@
+package template
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLocalizedNames(t *testing.T) {
+	tests := []struct {
+		name     string
+		locale   string
+		expected []string
+	}{
+		{name: "no locale", locale: "", expected: []string{"welcome"}},
+		{name: "language", locale: "mr", expected: []string{"welcome.mr", "welcome"}},
+		{name: "language and region", locale: "pt-BR", expected: []string{"welcome.pt-BR", "welcome.pt", "welcome"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := localizedNames("welcome", tt.locale); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("got %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRenderText(t *testing.T) {
+	m := &Manager{funcMap: Funcs()}
+	data := map[string]any{
+		"Contact":        map[string]any{"FirstName": "ada"},
+		"UnsubscribeURL": "https://example.com/unsubscribe?a=1&b=2",
+	}
+	got, err := m.RenderText(data, "Hi {{ .Contact.FirstName | title }},\n\nUnsubscribe: {{ .UnsubscribeURL }}")
+	if err != nil {
+		t.Fatalf("RenderText() error = %v", err)
+	}
+	want := "Hi Ada,\n\nUnsubscribe: https://example.com/unsubscribe?a=1&b=2"
+	if got != want {
+		t.Errorf("RenderText() = %q, want %q", got, want)
+	}
+}
@@ -152,6 +152,23 @@ func (m *Manager) getDefaultOutgoingEmailTemplate() (models.Template, error) {
 	return template, nil
 }
 
+// getOutgoingEmailTemplate returns the outgoing email template for the locale, stored as `<name>.<locale>` where
+// name is the name of the default outgoing template, falling back to the default outgoing template.
+func (m *Manager) getOutgoingEmailTemplate(locale string) (models.Template, error) {
+	defaultTmpl, err := m.getDefaultOutgoingEmailTemplate()
+	if err != nil {
+		return defaultTmpl, err
+	}
+	names := localizedNames(defaultTmpl.Name, locale)
+	for _, n := range names[:len(names)-1] {
+		tmpl, err := m.getByName(n)
+		if err == nil && tmpl.Type == TypeEmailOutgoing {
+			return tmpl, nil
+		}
+	}
+	return defaultTmpl, nil
+}
+
 // getByName returns a template by name.
 func (m *Manager) getByName(name string) (models.Template, error) {
 	var template models.Template
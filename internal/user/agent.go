@@ -9,6 +9,7 @@ import (
 
 	"github.com/abhinavxd/libredesk/internal/dbutil"
 	"github.com/abhinavxd/libredesk/internal/envelope"
+	"github.com/abhinavxd/libredesk/internal/stringutil"
 	"github.com/abhinavxd/libredesk/internal/user/models"
 	"github.com/lib/pq"
 	"github.com/volatiletech/null/v9"
@@ -55,7 +56,7 @@ func (u *Manager) CreateAgent(user *models.User) error {
 		return envelope.NewError(envelope.GeneralError, u.i18n.Ts("globals.messages.errorCreating", "name", "{globals.terms.user}"), nil)
 	}
 	user.Email = null.NewString(strings.TrimSpace(strings.ToLower(user.Email.String)), user.Email.Valid)
-	if err := u.q.InsertAgent.QueryRow(user.Email, user.FirstName, user.LastName, password, user.AvatarURL, pq.Array(user.Roles)).Scan(&user.ID); err != nil {
+	if err := u.q.InsertAgent.QueryRow(user.Email, user.FirstName, user.LastName, password, user.AvatarURL, pq.Array(user.Roles), user.Locale).Scan(&user.ID); err != nil {
 		if dbutil.IsUniqueViolationError(err) {
 			return envelope.NewError(envelope.GeneralError, u.i18n.T("user.sameEmailAlreadyExists"), nil)
 		}
@@ -85,8 +86,17 @@ func (u *Manager) UpdateAgent(id int, user models.User) error {
 		u.lo.Debug("setting new password for user", "user_id", id)
 	}
 
+	if user.Timezone.Valid && !stringutil.ValidTimezone(user.Timezone.String) {
+		return envelope.NewError(envelope.InputError, u.i18n.Ts("globals.messages.invalid", "name", "`timezone`"), nil)
+	}
+
+	// 0 clears the cap on open conversations.
+	if user.MaxOpenConversations.Valid && user.MaxOpenConversations.Int < 0 {
+		return envelope.NewError(envelope.InputError, u.i18n.Ts("globals.messages.invalid", "name", "`max_open_conversations`"), nil)
+	}
+
 	// Update user in the database.
-	if _, err := u.q.UpdateAgent.Exec(id, user.FirstName, user.LastName, user.Email, pq.Array(user.Roles), user.AvatarURL, hashedPassword, user.Enabled, user.AvailabilityStatus); err != nil {
+	if _, err := u.q.UpdateAgent.Exec(id, user.FirstName, user.LastName, user.Email, pq.Array(user.Roles), user.AvatarURL, hashedPassword, user.Enabled, user.AvailabilityStatus, user.Locale, user.Timezone, user.MaxOpenConversations); err != nil {
 		u.lo.Error("error updating user", "error", err)
 		return envelope.NewError(envelope.GeneralError, u.i18n.Ts("globals.messages.errorUpdating", "name", "{globals.terms.user}"), nil)
 	}
@@ -0,0 +1,107 @@
+package user
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/abhinavxd/libredesk/internal/envelope"
+	"github.com/abhinavxd/libredesk/internal/stringutil"
+	"github.com/abhinavxd/libredesk/internal/user/models"
+	"github.com/lib/pq"
+	"github.com/volatiletech/null/v9"
+)
+
+const (
+	// apiTokenPrefix makes tokens easy to recognize, e.g. by secret scanners.
+	apiTokenPrefix = "ld_"
+	apiTokenLength = 40
+	// apiTokenDisplayLength is the number of leading characters of a token stored in plain text to tell tokens apart.
+	apiTokenDisplayLength = 8
+)
+
+// GetAPITokens returns the active API tokens of an user.
+func (u *Manager) GetAPITokens(userID int) ([]models.APIToken, error) {
+	var tokens = make([]models.APIToken, 0)
+	if err := u.q.GetAPITokens.Select(&tokens, userID); err != nil {
+		u.lo.Error("error fetching api tokens", "user_id", userID, "error", err)
+		return tokens, envelope.NewError(envelope.GeneralError, u.i18n.Ts("globals.messages.errorFetching", "name", u.i18n.P("globals.terms.apiToken")), nil)
+	}
+	return tokens, nil
+}
+
+// CreateAPIToken creates an API token for the user, the plain token is only returned here and only its hash is stored.
+// Scopes restrict the token to a subset of the user's permissions, no scopes allows all of them.
+func (u *Manager) CreateAPIToken(user models.User, name string, scopes []string, expiresAt null.Time) (models.APIToken, error) {
+	var token models.APIToken
+	for _, scope := range scopes {
+		if !slices.Contains(user.Permissions, scope) {
+			return token, envelope.NewError(envelope.InputError, u.i18n.Ts("globals.messages.invalid", "name", scope), nil)
+		}
+	}
+
+	random, err := stringutil.RandomAlphanumeric(apiTokenLength)
+	if err != nil {
+		u.lo.Error("error generating api token", "error", err)
+		return token, envelope.NewError(envelope.GeneralError, u.i18n.Ts("globals.messages.errorCreating", "name", "{globals.terms.apiToken}"), nil)
+	}
+	plain := apiTokenPrefix + random
+
+	if err := u.q.InsertAPIToken.Get(&token, user.ID, name, hashAPIToken(plain), plain[:apiTokenDisplayLength], pq.StringArray(scopes), expiresAt); err != nil {
+		u.lo.Error("error inserting api token", "user_id", user.ID, "error", err)
+		return token, envelope.NewError(envelope.GeneralError, u.i18n.Ts("globals.messages.errorCreating", "name", "{globals.terms.apiToken}"), nil)
+	}
+	token.Token = plain
+	return token, nil
+}
+
+// RevokeAPIToken revokes an API token of the user.
+func (u *Manager) RevokeAPIToken(id, userID int) error {
+	res, err := u.q.RevokeAPIToken.Exec(id, userID)
+	if err != nil {
+		u.lo.Error("error revoking api token", "id", id, "error", err)
+		return envelope.NewError(envelope.GeneralError, u.i18n.Ts("globals.messages.errorDeleting", "name", "{globals.terms.apiToken}"), nil)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return envelope.NewError(envelope.NotFoundError, u.i18n.Ts("globals.messages.notFound", "name", "{globals.terms.apiToken}"), nil)
+	}
+	return nil
+}
+
+// ValidateAPIToken returns the active token matching the plain token along with its enabled agent and records its use.
+func (u *Manager) ValidateAPIToken(plain string) (models.APIToken, models.User, error) {
+	var token models.APIToken
+	if !strings.HasPrefix(plain, apiTokenPrefix) {
+		return token, models.User{}, envelope.NewError(envelope.UnauthorizedError, u.i18n.Ts("globals.messages.invalid", "name", "{globals.terms.apiToken}"), nil)
+	}
+	if err := u.q.GetAPITokenByHash.Get(&token, hashAPIToken(plain)); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return token, models.User{}, envelope.NewError(envelope.UnauthorizedError, u.i18n.Ts("globals.messages.invalid", "name", "{globals.terms.apiToken}"), nil)
+		}
+		u.lo.Error("error fetching api token", "error", err)
+		return token, models.User{}, envelope.NewError(envelope.GeneralError, u.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.apiToken}"), nil)
+	}
+
+	user, err := u.GetAgent(token.UserID, "")
+	if err != nil {
+		return token, user, err
+	}
+	if !user.Enabled {
+		return token, user, envelope.NewError(envelope.UnauthorizedError, u.i18n.T("user.accountDisabled"), nil)
+	}
+
+	if _, err := u.q.UpdateAPITokenLastUsedAt.Exec(token.ID); err != nil {
+		u.lo.Error("error updating api token last used at", "id", token.ID, "error", err)
+	}
+	return token, user, nil
+}
+
+// hashAPIToken returns the hex encoded SHA-256 hash of a token. Tokens are long random strings so a fast
+// unsalted hash is enough and lets tokens be looked up by their hash.
+func hashAPIToken(plain string) string {
+	sum := sha256.Sum256([]byte(plain))
+	return hex.EncodeToString(sum[:])
+}
@@ -13,6 +13,8 @@ import (
 
 const (
 	SystemUserEmail = "System"
+	// AutomationUserEmail identifies the user automation rules act as.
+	AutomationUserEmail = "Automation"
 
 	// User types
 	UserTypeAgent   = "agent"
@@ -42,6 +44,10 @@ type User struct {
 	Password               string          `db:"password" json:"-"`
 	LastActiveAt           null.Time       `db:"last_active_at" json:"last_active_at"`
 	LastLoginAt            null.Time       `db:"last_login_at" json:"last_login_at"`
+	Locale                 null.String     `db:"locale" json:"locale"`
+	Timezone               null.String     `db:"timezone" json:"timezone"`
+	MaxOpenConversations   null.Int        `db:"max_open_conversations" json:"max_open_conversations"`
+	Skills                 pq.StringArray  `db:"skills" json:"skills"`
 	Roles                  pq.StringArray  `db:"roles" json:"roles"`
 	Permissions            pq.StringArray  `db:"permissions" json:"permissions"`
 	Meta                   pq.StringArray  `db:"meta" json:"meta"`
@@ -76,3 +82,22 @@ func (u *User) FullName() string {
 func (u *User) HasAdminRole() bool {
 	return slices.Contains(u.Roles, rmodels.RoleAdmin)
 }
+
+// APIToken is an access token that authenticates API requests as its user.
+type APIToken struct {
+	ID         int            `db:"id" json:"id"`
+	CreatedAt  time.Time      `db:"created_at" json:"created_at"`
+	UserID     int            `db:"user_id" json:"user_id"`
+	Name       string         `db:"name" json:"name"`
+	Prefix     string         `db:"prefix" json:"prefix"`
+	Scopes     pq.StringArray `db:"scopes" json:"scopes"`
+	LastUsedAt null.Time      `db:"last_used_at" json:"last_used_at"`
+	ExpiresAt  null.Time      `db:"expires_at" json:"expires_at"`
+	// Token is the plain token, only set when the token is created.
+	Token string `db:"-" json:"token,omitempty"`
+}
+
+// HasScope returns true if the token allows the permission, tokens without scopes allow all of the user's permissions.
+func (t *APIToken) HasScope(perm string) bool {
+	return len(t.Scopes) == 0 || slices.Contains(t.Scopes, perm)
+}
@@ -34,6 +34,15 @@ func (u *Manager) CreateNote(userID, authorID int, note string) error {
 	return nil
 }
 
+// UpdateNote updates the content of a note for a user.
+func (u *Manager) UpdateNote(noteID, contactID int, note string) error {
+	if _, err := u.q.UpdateNote.Exec(noteID, contactID, note); err != nil {
+		u.lo.Error("error updating user note", "error", err)
+		return envelope.NewError(envelope.GeneralError, u.i18n.Ts("globals.messages.errorUpdating", "name", u.i18n.P("globals.terms.note")), nil)
+	}
+	return nil
+}
+
 // DeleteNote deletes a note for a user.
 func (u *Manager) DeleteNote(noteID int, contactID int) error {
 	if _, err := u.q.DeleteNote.Exec(noteID, contactID); err != nil {
@@ -1,7 +1,7 @@
 -- name: get-users
 SELECT COUNT(*) OVER() as total, users.id, users.avatar_url, users.type, users.created_at, users.updated_at, users.first_name, users.last_name, users.email, users.enabled
 FROM users
-WHERE users.email != 'System' AND users.deleted_at IS NULL AND type = $1
+WHERE users.email NOT IN ('System', 'Automation') AND users.deleted_at IS NULL AND type = $1
 
 -- name: soft-delete-agent
 WITH soft_delete AS (
@@ -26,7 +26,7 @@ SELECT 1;
 -- name: get-agents-compact
 SELECT u.id, u.type, u.first_name, u.last_name, u.enabled, u.avatar_url
 FROM users u
-WHERE u.email != 'System' AND u.deleted_at IS NULL AND u.type = 'agent'
+WHERE u.email NOT IN ('System', 'Automation') AND u.deleted_at IS NULL AND u.type = 'agent'
 ORDER BY u.updated_at DESC;
 
 -- name: get-user
@@ -48,6 +48,10 @@ SELECT
     u.last_login_at,
     u.phone_number_calling_code,
     u.phone_number,
+    u.locale,
+    u.timezone,
+    u.max_open_conversations,
+    u.skills,
     array_agg(DISTINCT r.name) FILTER (WHERE r.name IS NOT NULL) AS roles,
     COALESCE(
         (SELECT json_agg(json_build_object('id', t.id, 'name', t.name, 'emoji', t.emoji))
@@ -92,6 +96,9 @@ SET first_name = COALESCE($2, first_name),
  password = COALESCE($7, password),
  enabled = COALESCE($8, enabled),
  availability_status = COALESCE($9, availability_status),
+ locale = COALESCE($10, locale),
+ timezone = COALESCE($11, timezone),
+ max_open_conversations = CASE WHEN $12::INT IS NULL THEN max_open_conversations ELSE NULLIF($12::INT, 0) END,
  updated_at = now()
 WHERE id = $1;
 
@@ -101,6 +108,41 @@ SET custom_attributes = $2,
 updated_at = now()
 WHERE id = $1;
 
+-- name: get-skills
+SELECT skills FROM users WHERE id = $1 AND type = 'agent' AND deleted_at IS NULL;
+
+-- name: set-skills
+UPDATE users
+SET skills = $2,
+updated_at = now()
+WHERE id = $1 AND type = 'agent' AND deleted_at IS NULL;
+
+-- name: add-skill
+UPDATE users
+SET skills = array_append(skills, $2::TEXT),
+updated_at = now()
+WHERE id = $1 AND type = 'agent' AND deleted_at IS NULL AND NOT ($2::TEXT = ANY(skills));
+
+-- name: remove-skill
+UPDATE users
+SET skills = array_remove(skills, $2::TEXT),
+updated_at = now()
+WHERE id = $1 AND type = 'agent' AND deleted_at IS NULL;
+
+-- name: match-agents-by-skill
+-- Returns the enabled agents having all the skills in $1, members of the team $2 when it's set.
+SELECT u.id
+FROM users u
+WHERE u.type = 'agent' AND u.enabled AND u.deleted_at IS NULL
+    AND u.skills @> $1::TEXT[]
+    AND ($2::INT = 0 OR EXISTS (SELECT 1 FROM team_members tm WHERE tm.user_id = u.id AND tm.team_id = $2))
+ORDER BY u.id;
+
+-- name: update-timezone
+UPDATE users
+SET timezone = NULLIF($2, ''), updated_at = now()
+WHERE id = $1;
+
 -- name: update-avatar
 UPDATE users  
 SET avatar_url = $2, updated_at = now()
@@ -137,8 +179,8 @@ WHERE reset_password_token = $2 AND reset_password_token_expiry > now() AND type
 
 -- name: insert-agent
 WITH inserted_user AS (
-  INSERT INTO users (email, type, first_name, last_name, "password", avatar_url)
-  VALUES ($1, 'agent', $2, $3, $4, $5)
+  INSERT INTO users (email, type, first_name, last_name, "password", avatar_url, locale)
+  VALUES ($1, 'agent', $2, $3, $4, $5, $7)
   RETURNING id AS user_id
 )
 INSERT INTO user_roles (user_id, role_id)
@@ -147,6 +189,13 @@ FROM inserted_user, unnest($6::text[]) role_name
 JOIN roles r ON r.name = role_name
 RETURNING user_id;
 
+-- name: upsert-automation-user
+INSERT INTO users (email, type, first_name, last_name, avatar_url, enabled)
+VALUES ($1, 'agent', $2, '', NULLIF($3, ''), false)
+ON CONFLICT (email, type) WHERE deleted_at IS NULL
+DO UPDATE SET first_name = EXCLUDED.first_name, avatar_url = EXCLUDED.avatar_url, updated_at = now()
+RETURNING id;
+
 -- name: insert-contact
 WITH contact AS (
    INSERT INTO users (email, type, first_name, last_name, "password", avatar_url)
@@ -202,6 +251,11 @@ ORDER BY cn.created_at DESC;
 INSERT INTO contact_notes (contact_id, user_id, note)
 VALUES ($1, $2, $3);
 
+-- name: update-note
+UPDATE contact_notes
+SET note = $3, updated_at = NOW()
+WHERE id = $1 AND contact_id = $2;
+
 -- name: delete-note
 DELETE FROM contact_notes
 WHERE id = $1 AND contact_id = $2;
@@ -219,4 +273,30 @@ SELECT
     u.avatar_url
 FROM contact_notes cn
 INNER JOIN users u ON u.id = cn.user_id
-WHERE cn.id = $1;
\ No newline at end of file
+WHERE cn.id = $1;
+
+-- name: get-api-tokens
+SELECT id, created_at, user_id, name, prefix, scopes, last_used_at, expires_at
+FROM api_tokens
+WHERE user_id = $1 AND revoked_at IS NULL
+ORDER BY created_at DESC;
+
+-- name: get-api-token-by-hash
+SELECT id, created_at, user_id, name, prefix, scopes, last_used_at, expires_at
+FROM api_tokens
+WHERE token_hash = $1 AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > now());
+
+-- name: insert-api-token
+INSERT INTO api_tokens (user_id, name, token_hash, prefix, scopes, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, created_at, user_id, name, prefix, scopes, last_used_at, expires_at;
+
+-- name: revoke-api-token
+UPDATE api_tokens
+SET revoked_at = now()
+WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL;
+
+-- name: update-api-token-last-used-at
+UPDATE api_tokens
+SET last_used_at = now()
+WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < now() - interval '1 minute');
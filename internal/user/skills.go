@@ -0,0 +1,99 @@
+package user
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/abhinavxd/libredesk/internal/envelope"
+	"github.com/lib/pq"
+)
+
+// maxSkillLen is the maximum length of a skill name.
+const maxSkillLen = 100
+
+// NormalizeSkills trims and lowercases the skill names and drops empty and duplicate ones, skills are matched
+// case-insensitively.
+func NormalizeSkills(skills []string) []string {
+	var out = make([]string, 0, len(skills))
+	for _, s := range skills {
+		s = strings.ToLower(strings.TrimSpace(s))
+		if s == "" || slices.Contains(out, s) {
+			continue
+		}
+		out = append(out, s)
+	}
+	return out
+}
+
+// GetSkills returns the skills of an agent.
+func (u *Manager) GetSkills(userID int) ([]string, error) {
+	var skills pq.StringArray
+	if err := u.q.GetSkills.Get(&skills, userID); err != nil {
+		u.lo.Error("error fetching agent skills", "user_id", userID, "error", err)
+		return nil, envelope.NewError(envelope.GeneralError, u.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.skill}"), nil)
+	}
+	return []string(skills), nil
+}
+
+// SetSkills replaces the skills of an agent.
+func (u *Manager) SetSkills(userID int, skills []string) ([]string, error) {
+	skills = NormalizeSkills(skills)
+	if err := u.validateSkills(skills); err != nil {
+		return nil, err
+	}
+	if _, err := u.q.SetSkills.Exec(userID, pq.Array(skills)); err != nil {
+		u.lo.Error("error setting agent skills", "user_id", userID, "error", err)
+		return nil, envelope.NewError(envelope.GeneralError, u.i18n.Ts("globals.messages.errorUpdating", "name", "{globals.terms.skill}"), nil)
+	}
+	return skills, nil
+}
+
+// AddSkill adds a skill to an agent, adding a skill the agent already has is a no-op.
+func (u *Manager) AddSkill(userID int, skill string) error {
+	skills := NormalizeSkills([]string{skill})
+	if len(skills) == 0 {
+		return envelope.NewError(envelope.InputError, u.i18n.Ts("globals.messages.empty", "name", "`skill`"), nil)
+	}
+	if err := u.validateSkills(skills); err != nil {
+		return err
+	}
+	if _, err := u.q.AddSkill.Exec(userID, skills[0]); err != nil {
+		u.lo.Error("error adding agent skill", "user_id", userID, "skill", skills[0], "error", err)
+		return envelope.NewError(envelope.GeneralError, u.i18n.Ts("globals.messages.errorUpdating", "name", "{globals.terms.skill}"), nil)
+	}
+	return nil
+}
+
+// RemoveSkill removes a skill from an agent.
+func (u *Manager) RemoveSkill(userID int, skill string) error {
+	skills := NormalizeSkills([]string{skill})
+	if len(skills) == 0 {
+		return envelope.NewError(envelope.InputError, u.i18n.Ts("globals.messages.empty", "name", "`skill`"), nil)
+	}
+	if _, err := u.q.RemoveSkill.Exec(userID, skills[0]); err != nil {
+		u.lo.Error("error removing agent skill", "user_id", userID, "skill", skills[0], "error", err)
+		return envelope.NewError(envelope.GeneralError, u.i18n.Ts("globals.messages.errorUpdating", "name", "{globals.terms.skill}"), nil)
+	}
+	return nil
+}
+
+// MatchAgentsBySkill returns the IDs of the enabled agents having all the skills, restricted to the members of the
+// team when teamID is set. Every agent matches when no skills are passed.
+func (u *Manager) MatchAgentsBySkill(skills []string, teamID int) ([]int, error) {
+	var ids = make([]int, 0)
+	if err := u.q.MatchAgentsBySkill.Select(&ids, pq.Array(NormalizeSkills(skills)), teamID); err != nil {
+		u.lo.Error("error matching agents by skill", "skills", skills, "team_id", teamID, "error", err)
+		return nil, envelope.NewError(envelope.GeneralError, u.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.agent}"), nil)
+	}
+	return ids, nil
+}
+
+// validateSkills returns an error if a skill name is too long.
+func (u *Manager) validateSkills(skills []string) error {
+	for _, s := range skills {
+		if len(s) > maxSkillLen {
+			return envelope.NewError(envelope.InputError, u.i18n.Ts("globals.messages.invalid", "name", "`skill`"), nil)
+		}
+	}
+	return nil
+}
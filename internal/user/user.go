@@ -57,28 +57,41 @@ type Opts struct {
 
 // queries contains prepared SQL queries.
 type queries struct {
-	GetUser                *sqlx.Stmt `query:"get-user"`
-	GetUsers               string     `query:"get-users"`
-	GetNotes               *sqlx.Stmt `query:"get-notes"`
-	GetNote                *sqlx.Stmt `query:"get-note"`
-	GetAgentsCompact       *sqlx.Stmt `query:"get-agents-compact"`
-	UpdateContact          *sqlx.Stmt `query:"update-contact"`
-	UpdateAgent            *sqlx.Stmt `query:"update-agent"`
-	UpdateCustomAttributes *sqlx.Stmt `query:"update-custom-attributes"`
-	UpdateAvatar           *sqlx.Stmt `query:"update-avatar"`
-	UpdateAvailability     *sqlx.Stmt `query:"update-availability"`
-	UpdateLastActiveAt     *sqlx.Stmt `query:"update-last-active-at"`
-	UpdateInactiveOffline  *sqlx.Stmt `query:"update-inactive-offline"`
-	UpdateLastLoginAt      *sqlx.Stmt `query:"update-last-login-at"`
-	SoftDeleteAgent        *sqlx.Stmt `query:"soft-delete-agent"`
-	SetUserPassword        *sqlx.Stmt `query:"set-user-password"`
-	SetResetPasswordToken  *sqlx.Stmt `query:"set-reset-password-token"`
-	SetPassword            *sqlx.Stmt `query:"set-password"`
-	DeleteNote             *sqlx.Stmt `query:"delete-note"`
-	InsertAgent            *sqlx.Stmt `query:"insert-agent"`
-	InsertContact          *sqlx.Stmt `query:"insert-contact"`
-	InsertNote             *sqlx.Stmt `query:"insert-note"`
-	ToggleEnable           *sqlx.Stmt `query:"toggle-enable"`
+	GetUser                  *sqlx.Stmt `query:"get-user"`
+	GetUsers                 string     `query:"get-users"`
+	GetNotes                 *sqlx.Stmt `query:"get-notes"`
+	GetNote                  *sqlx.Stmt `query:"get-note"`
+	GetAgentsCompact         *sqlx.Stmt `query:"get-agents-compact"`
+	UpdateContact            *sqlx.Stmt `query:"update-contact"`
+	UpdateAgent              *sqlx.Stmt `query:"update-agent"`
+	UpdateCustomAttributes   *sqlx.Stmt `query:"update-custom-attributes"`
+	GetSkills                *sqlx.Stmt `query:"get-skills"`
+	SetSkills                *sqlx.Stmt `query:"set-skills"`
+	AddSkill                 *sqlx.Stmt `query:"add-skill"`
+	RemoveSkill              *sqlx.Stmt `query:"remove-skill"`
+	MatchAgentsBySkill       *sqlx.Stmt `query:"match-agents-by-skill"`
+	UpdateAvatar             *sqlx.Stmt `query:"update-avatar"`
+	UpdateTimezone           *sqlx.Stmt `query:"update-timezone"`
+	UpdateAvailability       *sqlx.Stmt `query:"update-availability"`
+	UpdateLastActiveAt       *sqlx.Stmt `query:"update-last-active-at"`
+	UpdateInactiveOffline    *sqlx.Stmt `query:"update-inactive-offline"`
+	UpdateLastLoginAt        *sqlx.Stmt `query:"update-last-login-at"`
+	SoftDeleteAgent          *sqlx.Stmt `query:"soft-delete-agent"`
+	SetUserPassword          *sqlx.Stmt `query:"set-user-password"`
+	SetResetPasswordToken    *sqlx.Stmt `query:"set-reset-password-token"`
+	SetPassword              *sqlx.Stmt `query:"set-password"`
+	DeleteNote               *sqlx.Stmt `query:"delete-note"`
+	InsertAgent              *sqlx.Stmt `query:"insert-agent"`
+	InsertContact            *sqlx.Stmt `query:"insert-contact"`
+	UpsertAutomationUser     *sqlx.Stmt `query:"upsert-automation-user"`
+	GetAPITokens             *sqlx.Stmt `query:"get-api-tokens"`
+	GetAPITokenByHash        *sqlx.Stmt `query:"get-api-token-by-hash"`
+	InsertAPIToken           *sqlx.Stmt `query:"insert-api-token"`
+	RevokeAPIToken           *sqlx.Stmt `query:"revoke-api-token"`
+	UpdateAPITokenLastUsedAt *sqlx.Stmt `query:"update-api-token-last-used-at"`
+	InsertNote               *sqlx.Stmt `query:"insert-note"`
+	UpdateNote               *sqlx.Stmt `query:"update-note"`
+	ToggleEnable             *sqlx.Stmt `query:"toggle-enable"`
 }
 
 // New creates and returns a new instance of the Manager.
@@ -157,6 +170,17 @@ func (u *Manager) GetSystemUser() (models.User, error) {
 	return u.Get(0, models.SystemUserEmail, models.UserTypeAgent)
 }
 
+// GetOrCreateAutomationUser returns the user automation rules act as, creating it if it does not exist.
+// The user is disabled and has no password so it can never log in, name and avatar are updated on every call.
+func (u *Manager) GetOrCreateAutomationUser(name, avatarURL string) (models.User, error) {
+	var id int
+	if err := u.q.UpsertAutomationUser.Get(&id, models.AutomationUserEmail, name, avatarURL); err != nil {
+		u.lo.Error("error upserting automation user", "error", err)
+		return models.User{}, envelope.NewError(envelope.GeneralError, u.i18n.Ts("globals.messages.errorCreating", "name", "{globals.terms.user}"), nil)
+	}
+	return u.Get(id, "", models.UserTypeAgent)
+}
+
 // UpdateAvatar updates the user avatar.
 func (u *Manager) UpdateAvatar(id int, path string) error {
 	if _, err := u.q.UpdateAvatar.Exec(id, null.NewString(path, path != "")); err != nil {
@@ -166,6 +190,18 @@ func (u *Manager) UpdateAvatar(id int, path string) error {
 	return nil
 }
 
+// UpdateTimezone sets the timezone of an user, an empty timezone clears it.
+func (u *Manager) UpdateTimezone(id int, tz string) error {
+	if tz != "" && !stringutil.ValidTimezone(tz) {
+		return envelope.NewError(envelope.InputError, u.i18n.Ts("globals.messages.invalid", "name", "`timezone`"), nil)
+	}
+	if _, err := u.q.UpdateTimezone.Exec(id, tz); err != nil {
+		u.lo.Error("error updating user timezone", "error", err)
+		return envelope.NewError(envelope.GeneralError, u.i18n.Ts("globals.messages.errorUpdating", "name", "{globals.terms.user}"), nil)
+	}
+	return nil
+}
+
 // UpdateLastLoginAt updates the last login timestamp of an user.
 func (u *Manager) UpdateLastLoginAt(id int) error {
 	if _, err := u.q.UpdateLastLoginAt.Exec(id); err != nil {
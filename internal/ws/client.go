@@ -47,6 +47,9 @@ type Client struct {
 
 	// Buffered channel of outbound ws messages.
 	Send chan models.WSMessage
+
+	// UUID of the conversation the client is viewing, guarded by the hub's clientsMutex.
+	viewing string
 }
 
 // Serve handles heartbeats and sending messages to the client.
@@ -100,7 +103,24 @@ func (c *Client) processIncomingMessage(data []byte) {
 		c.SendMessage([]byte("pong"), websocket.TextMessage)
 		return
 	}
-	c.SendError("unknown incoming message type")
+
+	var msg models.IncomingMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		c.SendError("invalid incoming message")
+		return
+	}
+	switch msg.Type {
+	case models.MessageTypeConversationView:
+		if msg.UUID == "" {
+			c.SendError("invalid conversation uuid")
+			return
+		}
+		c.Hub.ViewConversation(c, msg.UUID)
+	case models.MessageTypeConversationLeave:
+		c.Hub.LeaveConversation(c)
+	default:
+		c.SendError("unknown incoming message type")
+	}
 }
 
 // close closes the client connection.
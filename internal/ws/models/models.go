@@ -6,9 +6,26 @@ const (
 	MessageTypeConversationPropertyUpdate = "conversation_prop_update"
 	MessageTypeNewMessage                 = "new_message"
 	MessageTypeNewConversation            = "new_conversation"
+	MessageTypeInboxPropUpdate            = "inbox_prop_update"
+	MessageTypeConversationViewers        = "conversation_viewers"
+	MessageTypeConversationsReadState     = "conversations_read_state"
+	MessageTypeConversationReminder       = "conversation_reminder"
 	MessageTypeError                      = "error"
 )
 
+// Incoming message types sent by clients.
+const (
+	MessageTypeConversationView  = "conversation_view"
+	MessageTypeConversationLeave = "conversation_leave"
+)
+
+// IncomingMessage is a message sent by a client.
+type IncomingMessage struct {
+	Type string `json:"type"`
+	// UUID of the conversation for the conversation view message.
+	UUID string `json:"uuid"`
+}
+
 // WSMessage represents a WS message.
 type WSMessage struct {
 	MessageType int
@@ -0,0 +1,121 @@
+package ws
+
+import (
+	"encoding/json"
+	"log"
+	"slices"
+
+	"github.com/abhinavxd/libredesk/internal/ws/models"
+	"github.com/fasthttp/websocket"
+)
+
+// SetViewAuthorizer sets the function checking if a user can view a conversation before they're added as a viewer
+// or sent its updates.
+func (h *Hub) SetViewAuthorizer(fn func(userID int, conversationUUID string) bool) {
+	h.clientsMutex.Lock()
+	h.canView = fn
+	h.clientsMutex.Unlock()
+
+	h.accessMutex.Lock()
+	defer h.accessMutex.Unlock()
+	clear(h.accessCache)
+}
+
+// ViewConversation marks the client as viewing the conversation, leaving the conversation it was viewing before.
+// The viewers of the conversations are sent the updated list of viewers.
+func (h *Hub) ViewConversation(client *Client, conversationUUID string) {
+	h.clientsMutex.Lock()
+	canView := h.canView
+	h.clientsMutex.Unlock()
+
+	// Checked without holding the lock as it can hit the DB.
+	if canView != nil && !canView(client.ID, conversationUUID) {
+		client.SendError("conversation not found")
+		return
+	}
+
+	h.clientsMutex.Lock()
+	defer h.clientsMutex.Unlock()
+	// The client may have disconnected while the access was checked.
+	if client.Closed.Get() || client.viewing == conversationUUID {
+		return
+	}
+	h.leaveConversation(client)
+	if h.viewers[conversationUUID] == nil {
+		h.viewers[conversationUUID] = make(map[*Client]struct{})
+	}
+	h.viewers[conversationUUID][client] = struct{}{}
+	client.viewing = conversationUUID
+	h.broadcastViewers(conversationUUID)
+}
+
+// LeaveConversation marks the client as no longer viewing a conversation.
+func (h *Hub) LeaveConversation(client *Client) {
+	h.clientsMutex.Lock()
+	defer h.clientsMutex.Unlock()
+	h.leaveConversation(client)
+}
+
+// GetConversationViewers returns the IDs of the users viewing a conversation, in ascending order.
+func (h *Hub) GetConversationViewers(conversationUUID string) []int {
+	h.clientsMutex.Lock()
+	defer h.clientsMutex.Unlock()
+	return h.conversationViewers(conversationUUID)
+}
+
+// BroadcastToConversation sends a message to the clients viewing a conversation.
+func (h *Hub) BroadcastToConversation(conversationUUID string, data []byte) {
+	h.clientsMutex.Lock()
+	defer h.clientsMutex.Unlock()
+	h.broadcastToConversation(conversationUUID, data)
+}
+
+// leaveConversation removes the client from the viewers of the conversation it's viewing, the caller must hold clientsMutex.
+func (h *Hub) leaveConversation(client *Client) {
+	uuid := client.viewing
+	if uuid == "" {
+		return
+	}
+	client.viewing = ""
+	delete(h.viewers[uuid], client)
+	if len(h.viewers[uuid]) == 0 {
+		delete(h.viewers, uuid)
+		return
+	}
+	h.broadcastViewers(uuid)
+}
+
+// conversationViewers returns the unique user IDs viewing the conversation, the caller must hold clientsMutex.
+func (h *Hub) conversationViewers(conversationUUID string) []int {
+	var out = make([]int, 0, len(h.viewers[conversationUUID]))
+	for client := range h.viewers[conversationUUID] {
+		if !slices.Contains(out, client.ID) {
+			out = append(out, client.ID)
+		}
+	}
+	slices.Sort(out)
+	return out
+}
+
+// broadcastViewers sends the viewers of the conversation to its viewers, the caller must hold clientsMutex.
+func (h *Hub) broadcastViewers(conversationUUID string) {
+	b, err := json.Marshal(models.Message{
+		Type: models.MessageTypeConversationViewers,
+		Data: map[string]any{
+			"uuid":    conversationUUID,
+			"viewers": h.conversationViewers(conversationUUID),
+		},
+	})
+	if err != nil {
+		log.Println("error marshalling conversation viewers", err)
+		return
+	}
+	h.broadcastToConversation(conversationUUID, b)
+}
+
+// broadcastToConversation sends a message to the clients viewing a conversation, the caller must hold clientsMutex.
+func (h *Hub) broadcastToConversation(conversationUUID string, data []byte) {
+	for client := range h.viewers[conversationUUID] {
+		client.SendMessage(data, websocket.TextMessage)
+	}
+}
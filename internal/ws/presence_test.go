@@ -0,0 +1,68 @@
+package ws
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/abhinavxd/libredesk/internal/ws/models"
+)
+
+func newTestClient(h *Hub, userID int) *Client {
+	c := &Client{ID: userID, Hub: h, Send: make(chan models.WSMessage, 100)}
+	h.AddClient(c)
+	return c
+}
+
+func TestConversationViewers(t *testing.T) {
+	h := NewHub(nil)
+	var (
+		a1 = newTestClient(h, 1)
+		a2 = newTestClient(h, 1)
+		b  = newTestClient(h, 2)
+	)
+
+	h.ViewConversation(a1, "c1")
+	h.ViewConversation(a2, "c1")
+	h.ViewConversation(b, "c1")
+	if got := h.GetConversationViewers("c1"); !slices.Equal(got, []int{1, 2}) {
+		t.Fatalf("got viewers %v, want [1 2]", got)
+	}
+	if len(b.Send) == 0 {
+		t.Error("viewer was not sent the viewers update")
+	}
+
+	// Viewing another conversation leaves the previous one.
+	h.ViewConversation(b, "c2")
+	if got := h.GetConversationViewers("c1"); !slices.Equal(got, []int{1}) {
+		t.Errorf("got viewers %v, want [1]", got)
+	}
+
+	// The user is still viewing from another client.
+	h.RemoveClient(a1)
+	if got := h.GetConversationViewers("c1"); !slices.Equal(got, []int{1}) {
+		t.Errorf("got viewers %v, want [1]", got)
+	}
+
+	h.LeaveConversation(a2)
+	if got := h.GetConversationViewers("c1"); len(got) != 0 {
+		t.Errorf("got viewers %v, want none", got)
+	}
+	if _, ok := h.viewers["c1"]; ok {
+		t.Error("conversation without viewers was not removed")
+	}
+}
+
+func TestViewConversationAuthorizer(t *testing.T) {
+	h := NewHub(nil)
+	h.SetViewAuthorizer(func(userID int, conversationUUID string) bool { return userID == 1 })
+	var (
+		allowed = newTestClient(h, 1)
+		denied  = newTestClient(h, 2)
+	)
+
+	h.ViewConversation(allowed, "c1")
+	h.ViewConversation(denied, "c1")
+	if got := h.GetConversationViewers("c1"); !slices.Equal(got, []int{1}) {
+		t.Errorf("got viewers %v, want [1]", got)
+	}
+}
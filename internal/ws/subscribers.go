@@ -0,0 +1,112 @@
+package ws
+
+import (
+	"slices"
+	"time"
+
+	"github.com/fasthttp/websocket"
+)
+
+// accessCacheTTL is how long a decision of the view authorizer is reused when picking the subscribers of a conversation.
+const accessCacheTTL = time.Minute
+
+// accessDecision is a cached result of the view authorizer for a user and a conversation.
+type accessDecision struct {
+	allowed bool
+	expires time.Time
+}
+
+// GetConversationSubscribers returns the IDs of the connected users allowed to view a conversation, in ascending order.
+// Users that aren't permitted to see the conversation are never included.
+func (h *Hub) GetConversationSubscribers(conversationUUID string) []int {
+	h.clientsMutex.Lock()
+	var (
+		canView = h.canView
+		users   = make([]int, 0, len(h.clients))
+	)
+	for userID, clients := range h.clients {
+		if len(clients) > 0 {
+			users = append(users, userID)
+		}
+	}
+	h.clientsMutex.Unlock()
+	slices.Sort(users)
+
+	if canView == nil {
+		return users
+	}
+
+	// Checked without holding the clients lock as it can hit the DB.
+	var out = make([]int, 0, len(users))
+	for _, userID := range users {
+		if h.canAccess(canView, userID, conversationUUID) {
+			out = append(out, userID)
+		}
+	}
+	return out
+}
+
+// BroadcastToConversationSubscribers sends a message to the clients of the users allowed to view a conversation.
+// Nothing is sent if no connected user can view the conversation.
+func (h *Hub) BroadcastToConversationSubscribers(conversationUUID string, data []byte) {
+	h.broadcastToSubscribers(h.GetConversationSubscribers(conversationUUID), data)
+}
+
+// InvalidateConversationAccess drops the cached access decisions of a conversation, to be called when the
+// users allowed to view it change, e.g. on reassignment.
+func (h *Hub) InvalidateConversationAccess(conversationUUID string) {
+	h.accessMutex.Lock()
+	defer h.accessMutex.Unlock()
+	delete(h.accessCache, conversationUUID)
+}
+
+// canAccess returns the cached access decision of the user for the conversation, asking the authorizer when there's none.
+func (h *Hub) canAccess(canView func(userID int, conversationUUID string) bool, userID int, conversationUUID string) bool {
+	now := time.Now()
+	h.accessMutex.Lock()
+	d, ok := h.accessCache[conversationUUID][userID]
+	h.accessMutex.Unlock()
+	if ok && now.Before(d.expires) {
+		return d.allowed
+	}
+
+	allowed := canView(userID, conversationUUID)
+
+	h.accessMutex.Lock()
+	defer h.accessMutex.Unlock()
+	h.pruneAccessCache(now)
+	if h.accessCache[conversationUUID] == nil {
+		h.accessCache[conversationUUID] = make(map[int]accessDecision)
+	}
+	h.accessCache[conversationUUID][userID] = accessDecision{allowed: allowed, expires: now.Add(accessCacheTTL)}
+	return allowed
+}
+
+// pruneAccessCache removes the expired access decisions at most once per TTL, the caller must hold accessMutex.
+func (h *Hub) pruneAccessCache(now time.Time) {
+	if now.Before(h.accessPrunedAt.Add(accessCacheTTL)) {
+		return
+	}
+	h.accessPrunedAt = now
+	for uuid, decisions := range h.accessCache {
+		for userID, d := range decisions {
+			if !now.Before(d.expires) {
+				delete(decisions, userID)
+			}
+		}
+		if len(decisions) == 0 {
+			delete(h.accessCache, uuid)
+		}
+	}
+}
+
+// broadcastToSubscribers sends a message to all the clients of the users.
+func (h *Hub) broadcastToSubscribers(userIDs []int, data []byte) {
+	h.clientsMutex.Lock()
+	defer h.clientsMutex.Unlock()
+	for _, userID := range userIDs {
+		for _, client := range h.clients[userID] {
+			client.SendMessage(data, websocket.TextMessage)
+		}
+	}
+}
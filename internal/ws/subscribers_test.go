@@ -0,0 +1,64 @@
+package ws
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestConversationSubscribers(t *testing.T) {
+	h := NewHub(nil)
+	var (
+		allowed = map[int]bool{1: true}
+		checks  int
+	)
+	h.SetViewAuthorizer(func(userID int, conversationUUID string) bool {
+		checks++
+		return allowed[userID]
+	})
+	var (
+		a = newTestClient(h, 1)
+		b = newTestClient(h, 2)
+	)
+
+	if got := h.GetConversationSubscribers("c1"); !slices.Equal(got, []int{1}) {
+		t.Fatalf("got subscribers %v, want [1]", got)
+	}
+
+	h.BroadcastToConversationSubscribers("c1", []byte("{}"))
+	if len(a.Send) != 1 {
+		t.Errorf("allowed user was sent %d messages, want 1", len(a.Send))
+	}
+	if len(b.Send) != 0 {
+		t.Errorf("denied user was sent %d messages, want 0", len(b.Send))
+	}
+
+	// Decisions are cached until the access is invalidated.
+	allowed[2] = true
+	if got := h.GetConversationSubscribers("c1"); !slices.Equal(got, []int{1}) {
+		t.Errorf("got subscribers %v, want cached [1]", got)
+	}
+	if checks != 2 {
+		t.Errorf("authorizer called %d times, want 2", checks)
+	}
+	h.InvalidateConversationAccess("c1")
+	if got := h.GetConversationSubscribers("c1"); !slices.Equal(got, []int{1, 2}) {
+		t.Errorf("got subscribers %v, want [1 2]", got)
+	}
+
+	// Disconnected users aren't subscribers.
+	h.RemoveClient(a)
+	if got := h.GetConversationSubscribers("c1"); !slices.Equal(got, []int{2}) {
+		t.Errorf("got subscribers %v, want [2]", got)
+	}
+}
+
+func TestBroadcastToConversationSubscribersNone(t *testing.T) {
+	h := NewHub(nil)
+	h.SetViewAuthorizer(func(userID int, conversationUUID string) bool { return false })
+	c := newTestClient(h, 1)
+
+	h.BroadcastToConversationSubscribers("c1", []byte("{}"))
+	if len(c.Send) != 0 {
+		t.Errorf("message was sent to %d clients without access, want 0", len(c.Send))
+	}
+}
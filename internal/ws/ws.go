@@ -3,6 +3,7 @@ package ws
 
 import (
 	"sync"
+	"time"
 
 	"github.com/abhinavxd/libredesk/internal/ws/models"
 	"github.com/fasthttp/websocket"
@@ -14,6 +15,16 @@ type Hub struct {
 	clients      map[int][]*Client
 	clientsMutex sync.Mutex
 
+	// Conversation UUID to the clients viewing it, guarded by clientsMutex. A client views at most one conversation.
+	viewers map[string]map[*Client]struct{}
+	// canView returns true if the user can view the conversation, all conversations can be viewed if it's not set.
+	canView func(userID int, conversationUUID string) bool
+
+	// Conversation UUID to the cached access decisions of users, used to pick the subscribers of conversation updates.
+	accessCache    map[string]map[int]accessDecision
+	accessPrunedAt time.Time
+	accessMutex    sync.Mutex
+
 	userStore userStore
 }
 
@@ -26,6 +37,8 @@ func NewHub(userStore userStore) *Hub {
 	return &Hub{
 		clients:      make(map[int][]*Client, 10000),
 		clientsMutex: sync.Mutex{},
+		viewers:      make(map[string]map[*Client]struct{}),
+		accessCache:  make(map[string]map[int]accessDecision),
 		userStore:    userStore,
 	}
 }
@@ -41,6 +54,7 @@ func (h *Hub) AddClient(client *Client) {
 func (h *Hub) RemoveClient(client *Client) {
 	h.clientsMutex.Lock()
 	defer h.clientsMutex.Unlock()
+	h.leaveConversation(client)
 	if clients, ok := h.clients[client.ID]; ok {
 		for i, c := range clients {
 			if c == client {
@@ -1,9 +1,9 @@
 CREATE EXTENSION IF NOT EXISTS pg_trgm;
 
-DROP TYPE IF EXISTS "channels" CASCADE; CREATE TYPE "channels" AS ENUM ('email');
+DROP TYPE IF EXISTS "channels" CASCADE; CREATE TYPE "channels" AS ENUM ('email', 'webhook');
 DROP TYPE IF EXISTS "message_type" CASCADE; CREATE TYPE "message_type" AS ENUM ('incoming','outgoing','activity');
 DROP TYPE IF EXISTS "message_sender_type" CASCADE; CREATE TYPE "message_sender_type" AS ENUM ('agent','contact');
-DROP TYPE IF EXISTS "message_status" CASCADE; CREATE TYPE "message_status" AS ENUM ('received','sent','failed','pending');
+DROP TYPE IF EXISTS "message_status" CASCADE; CREATE TYPE "message_status" AS ENUM ('received','sent','delivered','failed','pending');
 DROP TYPE IF EXISTS "content_type" CASCADE; CREATE TYPE "content_type" AS ENUM ('text','html');
 DROP TYPE IF EXISTS "conversation_assignment_type" CASCADE; CREATE TYPE "conversation_assignment_type" AS ENUM ('Round robin','Manual');
 DROP TYPE IF EXISTS "template_type" CASCADE; CREATE TYPE "template_type" AS ENUM ('email_outgoing', 'email_notification');
@@ -17,6 +17,7 @@ DROP TYPE IF EXISTS "user_availability_status" CASCADE; CREATE TYPE "user_availa
 DROP TYPE IF EXISTS "applied_sla_status" CASCADE; CREATE TYPE "applied_sla_status" AS ENUM ('pending', 'breached', 'met', 'partially_met');
 DROP TYPE IF EXISTS "sla_metric" CASCADE; CREATE TYPE "sla_metric" AS ENUM ('first_response', 'resolution');
 DROP TYPE IF EXISTS "sla_notification_type" CASCADE; CREATE TYPE "sla_notification_type" AS ENUM ('warning', 'breach');
+DROP TYPE IF EXISTS "conversation_participant_role" CASCADE; CREATE TYPE "conversation_participant_role" AS ENUM ('collaborator', 'follower', 'requester');
 
 -- Sequence to generate reference number for conversations.
 DROP SEQUENCE IF EXISTS conversation_reference_number_sequence; CREATE SEQUENCE conversation_reference_number_sequence START 100;
@@ -67,8 +68,12 @@ CREATE TABLE inboxes (
 	channel channels NOT NULL,
 	enabled bool DEFAULT TRUE NOT NULL,
 	csat_enabled bool DEFAULT false NOT NULL,
+	-- Outgoing email open and click tracking.
+	tracking_enabled bool DEFAULT false NOT NULL,
 	config jsonb DEFAULT '{}'::jsonb NOT NULL,
 	"from" TEXT NULL,
+	-- Outgoing messages of the inbox stay pending while dispatching is paused.
+	dispatch_paused bool DEFAULT false NOT NULL,
 	CONSTRAINT constraint_inboxes_on_name CHECK (length("name") <= 140)
 );
 
@@ -81,6 +86,8 @@ CREATE TABLE teams (
 	emoji TEXT NULL,
 	conversation_assignment_type conversation_assignment_type NOT NULL,
 	max_auto_assigned_conversations INT DEFAULT 0 NOT NULL,
+	-- CSAT surveys are sent only when enabled on both the inbox and the team.
+	csat_enabled BOOL DEFAULT TRUE NOT NULL,
 
 	-- Set to NULL when business hours or SLA policy is deleted.
 	business_hours_id INT REFERENCES business_hours(id) ON DELETE SET NULL ON UPDATE CASCADE NULL,
@@ -93,6 +100,21 @@ CREATE TABLE teams (
 	CONSTRAINT constraint_teams_on_name_unique UNIQUE ("name")
 );
 
+DROP TABLE IF EXISTS queues CASCADE;
+CREATE TABLE queues (
+	id SERIAL PRIMARY KEY,
+	created_at TIMESTAMPTZ DEFAULT NOW(),
+	updated_at TIMESTAMPTZ DEFAULT NOW(),
+	"name" TEXT NOT NULL,
+	description TEXT DEFAULT '' NOT NULL,
+
+	-- Conversations routed to a team's queue are assigned to the team, queue is deleted with the team.
+	team_id INT REFERENCES teams(id) ON DELETE CASCADE ON UPDATE CASCADE NULL,
+	CONSTRAINT constraint_queues_on_name CHECK (length("name") <= 140),
+	CONSTRAINT constraint_queues_on_description CHECK (length(description) <= 1000),
+	CONSTRAINT constraint_queues_on_name_unique UNIQUE ("name")
+);
+
 DROP TABLE IF EXISTS roles CASCADE;
 CREATE TABLE roles (
     id SERIAL PRIMARY KEY,
@@ -127,16 +149,28 @@ CREATE TABLE users (
 	availability_status user_availability_status DEFAULT 'offline' NOT NULL,
 	last_active_at TIMESTAMPTZ NULL,
 	last_login_at TIMESTAMPTZ NULL,
+	locale TEXT NULL,
+	-- IANA timezone timestamps are shown in, the browser's timezone is used when not set.
+	timezone TEXT NULL,
+	-- Maximum open conversations the agent can be assigned at once, NULL is unlimited.
+	max_open_conversations INT NULL,
+	-- Skills of the agent, matched against the skills conversations require for skill aware assignment.
+	skills TEXT[] DEFAULT '{}'::TEXT[] NOT NULL,
     CONSTRAINT constraint_users_on_country CHECK (LENGTH(country) <= 140),
     CONSTRAINT constraint_users_on_phone_number CHECK (LENGTH(phone_number) <= 20),
 	CONSTRAINT constraint_users_on_phone_number_calling_code CHECK (LENGTH(phone_number_calling_code) <= 10),
     CONSTRAINT constraint_users_on_email_length CHECK (LENGTH(email) <= 320),
     CONSTRAINT constraint_users_on_first_name CHECK (LENGTH(first_name) <= 140),
-    CONSTRAINT constraint_users_on_last_name CHECK (LENGTH(last_name) <= 140)
+    CONSTRAINT constraint_users_on_last_name CHECK (LENGTH(last_name) <= 140),
+	CONSTRAINT constraint_users_on_locale CHECK (LENGTH(locale) <= 20),
+	CONSTRAINT constraint_users_on_max_open_conversations CHECK (max_open_conversations > 0),
+	CONSTRAINT constraint_users_on_timezone CHECK (LENGTH(timezone) <= 140)
 );
 CREATE UNIQUE INDEX index_unique_users_on_email_and_type_when_deleted_at_is_null ON users (email, type) 
 WHERE deleted_at IS NULL;
+CREATE INDEX index_users_on_skills ON users USING GIN (skills);
 CREATE INDEX index_tgrm_users_on_email ON users USING GIN (email gin_trgm_ops);
+CREATE INDEX index_users_on_name_email_search ON users USING GIN (to_tsvector('simple', first_name || ' ' || COALESCE(last_name, '') || ' ' || COALESCE(email, '')));
 
 DROP TABLE IF EXISTS user_roles CASCADE;
 CREATE TABLE user_roles (
@@ -198,6 +232,9 @@ CREATE TABLE conversations (
     assigned_user_id BIGINT REFERENCES users(id) ON DELETE SET NULL ON UPDATE CASCADE,
     assigned_team_id INT REFERENCES teams(id) ON DELETE SET NULL ON UPDATE CASCADE,
 
+	-- Queue the conversation waits in for an agent to pull it, set to NULL when the queue is deleted.
+	assigned_queue_id INT REFERENCES queues(id) ON DELETE SET NULL ON UPDATE CASCADE,
+
 	-- Set to NULL when SLA policy is deleted.
 	sla_policy_id INT REFERENCES sla_policies(id) ON DELETE SET NULL ON UPDATE CASCADE,
 	
@@ -223,13 +260,33 @@ CREATE TABLE conversations (
 	last_message TEXT NULL,
 	last_message_sender message_sender_type NULL,
 	next_sla_deadline_at TIMESTAMPTZ NULL,
-	snoozed_until TIMESTAMPTZ NULL
+	snoozed_until TIMESTAMPTZ NULL,
+	-- Set once the conversation is escalated for being left unassigned, see the inbox's unassigned escalation config.
+	unassigned_escalated_at TIMESTAMPTZ NULL,
+	-- Last assignment made by an agent, automation assignments are suppressed for the reassignment cooldown after it.
+	manually_assigned_at TIMESTAMPTZ NULL,
+	-- Start of the first response time, set on reassignment before the first reply if `conversation.first_reply_on_reassign`
+	-- is `reset`. The first response time is measured from the creation of the conversation when it's NULL.
+	first_reply_started_at TIMESTAMPTZ NULL,
+	-- Locale set by an agent, overrides the detected language when rendering CSAT surveys and auto-sent templates.
+	locale TEXT NULL,
+	-- Soft-deleted conversations are hidden from listings and search, and purged after the retention period.
+	deleted_at TIMESTAMPTZ NULL,
+	deleted_by BIGINT REFERENCES users(id) ON DELETE SET NULL ON UPDATE CASCADE NULL,
+
+	-- Detected language of the contact's messages.
+	"language" TEXT NULL,
+
+	CONSTRAINT constraint_conversations_on_locale CHECK (LENGTH(locale) <= 20)
 );
 CREATE INDEX index_conversations_on_assigned_user_id ON conversations (assigned_user_id);
 CREATE INDEX index_conversations_on_assigned_team_id ON conversations (assigned_team_id);
+CREATE INDEX index_conversations_on_assigned_queue_id ON conversations (assigned_queue_id);
+CREATE INDEX index_conversations_on_deleted_at ON conversations (deleted_at) WHERE deleted_at IS NOT NULL;
 CREATE INDEX index_conversations_on_snoozed_until ON conversations (snoozed_until);
 CREATE INDEX index_conversations_on_contact_id ON conversations (contact_id);
 CREATE INDEX index_conversations_on_inbox_id ON conversations (inbox_id);
+CREATE INDEX index_conversations_on_subject_search ON conversations USING GIN (to_tsvector('simple', COALESCE("subject", '')));
 CREATE INDEX index_conversations_on_status_id ON conversations (status_id);
 CREATE INDEX index_conversations_on_priority_id ON conversations (priority_id);
 CREATE INDEX index_conversations_on_created_at ON conversations (created_at);
@@ -253,8 +310,20 @@ CREATE TABLE conversation_messages (
     source_id TEXT NULL,
  	sender_id BIGINT REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
     sender_type message_sender_type NOT NULL,
-    meta JSONB DEFAULT '{}'::JSONB NULL
+    meta JSONB DEFAULT '{}'::JSONB NULL,
+
+	-- Full-text search vector of the text content, without quoted replies and signatures.
+	search_vector TSVECTOR NULL,
+
+	-- Detected language of incoming messages, empty with zero confidence when the message is too short to tell.
+	"language" TEXT NULL,
+	language_confidence REAL NULL,
+
+	-- Set while an outgoing message is being handed to the inbox, a pending message still marked after a restart
+	-- was interrupted mid-send and is requeued.
+	send_started_at TIMESTAMPTZ NULL
 );
+CREATE INDEX index_conversation_messages_on_search_vector ON conversation_messages USING GIN (search_vector);
 CREATE INDEX index_trgm_conversation_messages_on_text_content ON conversation_messages USING GIN (text_content gin_trgm_ops);
 CREATE INDEX index_conversation_messages_on_conversation_id ON conversation_messages (conversation_id);
 CREATE INDEX index_conversation_messages_on_created_at ON conversation_messages (created_at);
@@ -280,6 +349,155 @@ CREATE TABLE automation_rules (
 CREATE INDEX index_automation_rules_on_enabled_and_weight ON automation_rules(enabled, weight);
 CREATE INDEX index_automation_rules_on_type_and_weight ON automation_rules(type, weight);
 
+DROP TABLE IF EXISTS automation_assignment_cursors CASCADE;
+CREATE TABLE automation_assignment_cursors (
+	-- Cascade deletes when team or user is deleted.
+	team_id INT PRIMARY KEY REFERENCES teams(id) ON DELETE CASCADE ON UPDATE CASCADE,
+	last_assigned_user_id BIGINT REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
+	updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+DROP TABLE IF EXISTS automation_failed_actions CASCADE;
+CREATE TABLE automation_failed_actions (
+	id BIGSERIAL PRIMARY KEY,
+	created_at TIMESTAMPTZ DEFAULT NOW(),
+	updated_at TIMESTAMPTZ DEFAULT NOW(),
+	-- Rule name is kept so the failure stays readable after the rule is deleted.
+	rule_id INT REFERENCES automation_rules(id) ON DELETE SET NULL ON UPDATE CASCADE NULL,
+	rule_name TEXT NOT NULL DEFAULT '',
+	conversation_id BIGINT REFERENCES conversations(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
+	action JSONB NOT NULL,
+	attempts INT DEFAULT 1 NOT NULL,
+	last_error TEXT NOT NULL DEFAULT '',
+	status TEXT DEFAULT 'pending' NOT NULL,
+	next_attempt_at TIMESTAMPTZ DEFAULT NOW() NOT NULL
+);
+CREATE INDEX index_automation_failed_actions_on_status_and_next_attempt_at ON automation_failed_actions (status, next_attempt_at);
+
+DROP TABLE IF EXISTS blocked_incoming_messages CASCADE;
+CREATE TABLE blocked_incoming_messages (
+	id BIGSERIAL PRIMARY KEY,
+	created_at TIMESTAMPTZ DEFAULT NOW(),
+	-- Cascade deletes when inbox is deleted.
+	inbox_id INT REFERENCES inboxes(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
+	sender TEXT NOT NULL,
+	source_id TEXT NULL,
+	"subject" TEXT NULL,
+	content TEXT NULL,
+	reason TEXT NOT NULL,
+	-- Gob encoded incoming message, processed as is when the message is released.
+	payload BYTEA NULL
+);
+CREATE UNIQUE INDEX index_unique_blocked_incoming_messages_on_inbox_id_and_source_id ON blocked_incoming_messages (inbox_id, source_id);
+CREATE INDEX index_blocked_incoming_messages_on_created_at ON blocked_incoming_messages (created_at);
+
+DROP TABLE IF EXISTS delivery_reports CASCADE;
+CREATE TABLE delivery_reports (
+	id BIGSERIAL PRIMARY KEY,
+	created_at TIMESTAMPTZ DEFAULT NOW(),
+	-- Message-ID of the delivery status notification, it's read again on every poll of the mailbox.
+	source_id TEXT NOT NULL,
+	-- Cascade deletes when the reported message is deleted.
+	message_id BIGINT REFERENCES conversation_messages(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL
+);
+CREATE UNIQUE INDEX index_unique_delivery_reports_on_source_id ON delivery_reports (source_id);
+
+DROP TABLE IF EXISTS incoming_message_spillover CASCADE;
+CREATE TABLE incoming_message_spillover (
+	id BIGSERIAL PRIMARY KEY,
+	created_at TIMESTAMPTZ DEFAULT NOW(),
+	-- Cascade deletes when inbox is deleted.
+	inbox_id INT REFERENCES inboxes(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
+	-- Gob encoded incoming message.
+	payload BYTEA NOT NULL
+);
+
+DROP TABLE IF EXISTS api_tokens CASCADE;
+CREATE TABLE api_tokens (
+	id SERIAL PRIMARY KEY,
+	created_at TIMESTAMPTZ DEFAULT NOW(),
+	-- Cascade deletes when user is deleted.
+	user_id INT REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
+	"name" TEXT NOT NULL,
+	-- SHA-256 hash of the token, the plain token is only shown once on creation.
+	token_hash TEXT NOT NULL,
+	prefix TEXT NOT NULL,
+	-- Permissions the token is limited to, empty allows all permissions of the user.
+	scopes TEXT[] DEFAULT '{}'::TEXT[] NOT NULL,
+	last_used_at TIMESTAMPTZ NULL,
+	expires_at TIMESTAMPTZ NULL,
+	revoked_at TIMESTAMPTZ NULL,
+	CONSTRAINT constraint_api_tokens_on_name CHECK (length("name") <= 140)
+);
+CREATE UNIQUE INDEX index_unique_api_tokens_on_token_hash ON api_tokens (token_hash);
+CREATE INDEX index_api_tokens_on_user_id ON api_tokens (user_id);
+
+DROP TABLE IF EXISTS notification_preferences CASCADE;
+CREATE TABLE notification_preferences (
+	id SERIAL PRIMARY KEY,
+	created_at TIMESTAMPTZ DEFAULT NOW(),
+	updated_at TIMESTAMPTZ DEFAULT NOW(),
+	-- Cascade deletes when user is deleted.
+	user_id INT REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
+	-- Users without a stored preference for an event type and channel get the default.
+	event_type TEXT NOT NULL,
+	channel TEXT NOT NULL,
+	enabled BOOLEAN NOT NULL,
+	CONSTRAINT constraint_notification_preferences_unique UNIQUE (user_id, event_type, channel)
+);
+
+DROP TABLE IF EXISTS notification_quiet_hours CASCADE;
+CREATE TABLE notification_quiet_hours (
+	user_id INT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE,
+	created_at TIMESTAMPTZ DEFAULT NOW(),
+	updated_at TIMESTAMPTZ DEFAULT NOW(),
+	enabled BOOLEAN DEFAULT false NOT NULL,
+	-- Time of day as HH:MM in the timezone, the window spans midnight when the end is before the start.
+	start_time TEXT NOT NULL,
+	end_time TEXT NOT NULL,
+	timezone TEXT NOT NULL,
+	-- Send the held back notifications as a single digest after the window.
+	digest BOOLEAN DEFAULT false NOT NULL,
+	CONSTRAINT constraint_notification_quiet_hours_on_timezone CHECK (length(timezone) <= 140)
+);
+
+DROP TABLE IF EXISTS deferred_notifications CASCADE;
+CREATE TABLE deferred_notifications (
+	id SERIAL PRIMARY KEY,
+	created_at TIMESTAMPTZ DEFAULT NOW(),
+	user_id INT REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
+	recipient_email TEXT NOT NULL,
+	event_type TEXT NOT NULL,
+	provider TEXT NOT NULL,
+	subject TEXT NOT NULL,
+	content TEXT NOT NULL,
+	content_type TEXT DEFAULT '' NOT NULL,
+	digest BOOLEAN DEFAULT false NOT NULL,
+	alt_content TEXT DEFAULT '' NOT NULL,
+	headers JSONB DEFAULT '{}'::jsonb NOT NULL,
+	attachments JSONB DEFAULT '[]'::jsonb NOT NULL,
+	-- End of the quiet hours window the notification was held back in.
+	deliver_at TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX index_deferred_notifications_on_deliver_at ON deferred_notifications (deliver_at);
+
+DROP TABLE IF EXISTS audit_logs CASCADE;
+CREATE TABLE audit_logs (
+	id BIGSERIAL PRIMARY KEY,
+	created_at TIMESTAMPTZ DEFAULT NOW(),
+	-- Actor is kept as NULL when the user is deleted, actor_name keeps the log readable.
+	actor_id INT REFERENCES users(id) ON DELETE SET NULL ON UPDATE CASCADE NULL,
+	actor_name TEXT NOT NULL,
+	"action" TEXT NOT NULL,
+	target_type TEXT NOT NULL,
+	target_id INT NULL,
+	-- Changed fields as {"field": {"old": x, "new": y}}, credentials are redacted.
+	changes JSONB DEFAULT '{}'::jsonb NOT NULL
+);
+CREATE INDEX index_audit_logs_on_created_at ON audit_logs (created_at);
+CREATE INDEX index_audit_logs_on_target_type_and_target_id ON audit_logs (target_type, target_id);
+CREATE INDEX index_audit_logs_on_actor_id ON audit_logs (actor_id);
+
 DROP TABLE IF EXISTS macros CASCADE;
 CREATE TABLE macros (
    id SERIAL PRIMARY KEY,
@@ -297,6 +515,21 @@ CREATE TABLE macros (
    CONSTRAINT message_content_length CHECK (length(message_content) <= 5000)
 );
 
+DROP TABLE IF EXISTS conversation_assignments CASCADE;
+CREATE TABLE conversation_assignments (
+	id BIGSERIAL PRIMARY KEY,
+	-- Time of the assignment.
+	created_at TIMESTAMPTZ DEFAULT NOW(),
+	-- Cascade deletes when conversation is deleted.
+	conversation_id BIGINT REFERENCES conversations(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
+	assigned_user_id BIGINT REFERENCES users(id) ON DELETE SET NULL ON UPDATE CASCADE NULL,
+	assigned_team_id INT REFERENCES teams(id) ON DELETE SET NULL ON UPDATE CASCADE NULL,
+	-- First reply sent while the assignment was the current one.
+	first_reply_at TIMESTAMPTZ NULL
+);
+CREATE INDEX index_conversation_assignments_on_conversation_id ON conversation_assignments (conversation_id);
+CREATE INDEX index_conversation_assignments_on_assigned_user_id ON conversation_assignments (assigned_user_id);
+
 DROP TABLE IF EXISTS conversation_participants CASCADE;
 CREATE TABLE conversation_participants (
 	id BIGSERIAL PRIMARY KEY,
@@ -304,10 +537,107 @@ CREATE TABLE conversation_participants (
 	updated_at TIMESTAMPTZ DEFAULT NOW(),
 	-- Cascade deletes when user or conversation is deleted.
 	user_id BIGINT REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
-	conversation_id BIGINT REFERENCES conversations(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL
+	conversation_id BIGINT REFERENCES conversations(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
+	-- The assignee isn't stored as a role, it's the conversation's assigned user.
+	"role" conversation_participant_role DEFAULT 'collaborator' NOT NULL
 );
 CREATE UNIQUE INDEX index_unique_conversation_participants_on_conversation_id_and_user_id ON conversation_participants (conversation_id, user_id);
 
+DROP TABLE IF EXISTS message_tracking_links CASCADE;
+CREATE TABLE message_tracking_links (
+	id BIGSERIAL PRIMARY KEY,
+	"uuid" UUID DEFAULT gen_random_uuid() NOT NULL UNIQUE,
+	created_at TIMESTAMPTZ DEFAULT NOW(),
+	-- Cascade deletes when message is deleted.
+	message_id BIGINT REFERENCES conversation_messages(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
+	url TEXT NOT NULL
+);
+CREATE UNIQUE INDEX index_unique_message_tracking_links_on_message_id_and_url ON message_tracking_links (message_id, url);
+
+DROP TABLE IF EXISTS message_tracking_events CASCADE;
+CREATE TABLE message_tracking_events (
+	id BIGSERIAL PRIMARY KEY,
+	created_at TIMESTAMPTZ DEFAULT NOW(),
+	-- Cascade deletes when message is deleted.
+	message_id BIGINT REFERENCES conversation_messages(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
+	-- Either `open` or `click`, the URL is set for clicks.
+	"type" TEXT NOT NULL,
+	url TEXT NULL
+);
+CREATE INDEX index_message_tracking_events_on_message_id ON message_tracking_events (message_id);
+
+DROP TABLE IF EXISTS message_status_history CASCADE;
+CREATE TABLE message_status_history (
+	id BIGSERIAL PRIMARY KEY,
+	created_at TIMESTAMPTZ DEFAULT NOW(),
+	-- Cascade deletes when message is deleted.
+	message_id BIGINT REFERENCES conversation_messages(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
+	status message_status NOT NULL,
+	-- Set when the message failed to send.
+	error TEXT NULL
+);
+CREATE INDEX index_message_status_history_on_message_id ON message_status_history (message_id);
+
+DROP TABLE IF EXISTS conversation_read_states CASCADE;
+CREATE TABLE conversation_read_states (
+	id BIGSERIAL PRIMARY KEY,
+	created_at TIMESTAMPTZ DEFAULT NOW(),
+	updated_at TIMESTAMPTZ DEFAULT NOW(),
+	-- Cascade deletes when user or conversation is deleted.
+	user_id BIGINT REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
+	conversation_id BIGINT REFERENCES conversations(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
+	-- Messages created after it are unread for the user.
+	last_read_at TIMESTAMPTZ NOT NULL
+);
+CREATE UNIQUE INDEX index_unique_conversation_read_states_on_user_id_and_conversation_id ON conversation_read_states (user_id, conversation_id);
+
+DROP TABLE IF EXISTS conversation_watchers CASCADE;
+CREATE TABLE conversation_watchers (
+	id BIGSERIAL PRIMARY KEY,
+	created_at TIMESTAMPTZ DEFAULT NOW(),
+	-- Cascade deletes when user or conversation is deleted.
+	user_id BIGINT REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
+	conversation_id BIGINT REFERENCES conversations(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL
+);
+CREATE UNIQUE INDEX index_unique_conversation_watchers_on_conversation_id_and_user_id ON conversation_watchers (conversation_id, user_id);
+CREATE INDEX index_conversation_watchers_on_user_id ON conversation_watchers (user_id);
+
+DROP TABLE IF EXISTS conversation_watch_digests CASCADE;
+CREATE TABLE conversation_watch_digests (
+	user_id BIGINT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE,
+	last_sent_at TIMESTAMPTZ NOT NULL
+);
+
+DROP TABLE IF EXISTS conversation_reminders CASCADE;
+CREATE TABLE conversation_reminders (
+	id BIGSERIAL PRIMARY KEY,
+	created_at TIMESTAMPTZ DEFAULT NOW(),
+	-- Cascade deletes when user or conversation is deleted.
+	user_id BIGINT REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
+	conversation_id BIGINT REFERENCES conversations(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
+	remind_at TIMESTAMPTZ NOT NULL,
+	note TEXT DEFAULT '' NOT NULL,
+	-- Set when the user was notified, notified reminders are kept for reference.
+	notified_at TIMESTAMPTZ NULL,
+	CONSTRAINT constraint_conversation_reminders_on_note CHECK (length(note) <= 500)
+);
+CREATE INDEX index_conversation_reminders_on_user_id ON conversation_reminders (user_id);
+CREATE INDEX index_conversation_reminders_on_remind_at ON conversation_reminders (remind_at) WHERE notified_at IS NULL;
+
+DROP TABLE IF EXISTS conversation_drafts CASCADE;
+CREATE TABLE conversation_drafts (
+	id BIGSERIAL PRIMARY KEY,
+	created_at TIMESTAMPTZ DEFAULT NOW(),
+	updated_at TIMESTAMPTZ DEFAULT NOW(),
+	-- Cascade deletes when user or conversation is deleted.
+	user_id BIGINT REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
+	conversation_id BIGINT REFERENCES conversations(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
+	content TEXT NOT NULL,
+	private BOOLEAN DEFAULT FALSE NOT NULL
+);
+CREATE UNIQUE INDEX index_unique_conversation_drafts_on_conversation_id_and_user_id ON conversation_drafts (conversation_id, user_id);
+CREATE INDEX index_conversation_drafts_on_updated_at ON conversation_drafts (updated_at);
+
 DROP TABLE IF EXISTS media CASCADE;
 CREATE TABLE media (
 	id SERIAL PRIMARY KEY,
@@ -323,11 +653,19 @@ CREATE TABLE media (
 	disposition media_disposition NULL,
 	"size" INT NULL,
 	meta jsonb DEFAULT '{}'::jsonb NOT NULL,
+	-- Name of the stored file, shared by media with identical content. NULL for media stored under its own UUID.
+	blob_name TEXT NULL,
+	-- SHA-256 hash of the content.
+	content_hash TEXT NULL,
+	-- Agent who uploaded the file, only they can attach it to a message. NULL for media not uploaded by an agent.
+	uploaded_by INT REFERENCES users(id) ON DELETE SET NULL ON UPDATE CASCADE NULL,
 	CONSTRAINT constraint_media_on_filename CHECK (length(filename) <= 1000),
 	CONSTRAINT constraint_media_on_content_id CHECK (length(content_id) <= 300)
 );
 CREATE INDEX index_media_on_model_type_and_model_id ON media(model_type, model_id);
 CREATE INDEX index_media_on_content_id ON media(content_id);
+CREATE INDEX index_media_on_content_hash ON media(content_hash);
+CREATE INDEX index_media_on_blob_name ON media(blob_name);
 
 DROP TABLE IF EXISTS oidc CASCADE;
 CREATE TABLE oidc (
@@ -415,10 +753,21 @@ CREATE TABLE csat_responses (
     rating INT DEFAULT 0 NOT NULL,
     feedback TEXT NULL,
     response_timestamp TIMESTAMPTZ NULL,
+
+    -- Token required in the public survey URL, NULL for surveys sent before tokens were added.
+    token TEXT NULL,
+
+    -- Set when moderation flagged the feedback, the feedback is kept as submitted.
+    flagged BOOL DEFAULT FALSE NOT NULL,
+
+    -- Score of each question, e.g. [{"question_id": "overall", "score": 5}]. rating is the score of the first question.
+    answers JSONB DEFAULT '[]'::jsonb NOT NULL,
     CONSTRAINT constraint_csat_responses_on_rating CHECK (rating >= 0 AND rating <= 5),
     CONSTRAINT constraint_csat_responses_on_feedback CHECK (length(feedback) <= 1000)
 );
 CREATE INDEX index_csat_responses_on_uuid ON csat_responses(uuid);
+-- A single survey is sent per conversation.
+CREATE UNIQUE INDEX index_csat_responses_on_conversation_id ON csat_responses(conversation_id);
 
 DROP TABLE IF EXISTS views CASCADE;
 CREATE TABLE views (
@@ -510,6 +859,7 @@ CREATE TABLE custom_attribute_definitions (
 	data_type TEXT NOT NULL,
 	regex TEXT NULL,
 	regex_hint TEXT NULL,
+	required BOOLEAN DEFAULT FALSE NOT NULL,
 	CONSTRAINT constraint_custom_attribute_definitions_on_name CHECK (length("name") <= 140),
 	CONSTRAINT constraint_custom_attribute_definitions_on_description CHECK (length(description) <= 300),
 	CONSTRAINT constraint_custom_attribute_definitions_on_key CHECK (length(key) <= 140),
@@ -591,7 +941,7 @@ VALUES
 	(
 		'Agent',
 		'Role for all agents with limited access to conversations.',
-		'{conversations:read_all,conversations:read_unassigned,conversations:read_assigned,conversations:read_team_inbox,conversations:read,conversations:update_user_assignee,conversations:update_team_assignee,conversations:update_priority,conversations:update_status,conversations:update_tags,messages:read,messages:write,view:manage}'
+		'{conversations:read_all,conversations:read_unassigned,conversations:read_assigned,conversations:read_team_inbox,conversations:read,conversations:update_user_assignee,conversations:update_team_assignee,conversations:update_priority,conversations:update_status,conversations:update_tags,conversations:update_custom_attributes,messages:read,messages:write,view:manage}'
 	);
 
 INSERT INTO
@@ -600,7 +950,7 @@ VALUES
 	(
 		'Admin',
 		'Role for users who have complete access to everything.',
-		'{custom_attributes:manage,contacts:read_all,contacts:read,contacts:write,contacts:block,contact_notes:read,contact_notes:write,contact_notes:delete,conversations:write,ai:manage,general_settings:manage,notification_settings:manage,oidc:manage,conversations:read_all,conversations:read_unassigned,conversations:read_assigned,conversations:read_team_inbox,conversations:read,conversations:update_user_assignee,conversations:update_team_assignee,conversations:update_priority,conversations:update_status,conversations:update_tags,messages:read,messages:write,view:manage,status:manage,tags:manage,macros:manage,users:manage,teams:manage,automations:manage,inboxes:manage,roles:manage,reports:manage,templates:manage,business_hours:manage,sla:manage}'
+		'{custom_attributes:manage,contacts:read_all,contacts:read,contacts:write,contacts:block,contacts:export,contacts:erase,contact_notes:read,contact_notes:write,contact_notes:delete,conversations:write,ai:manage,general_settings:manage,notification_settings:manage,oidc:manage,conversations:read_all,conversations:read_unassigned,conversations:read_assigned,conversations:read_team_inbox,conversations:read,conversations:update_user_assignee,conversations:update_team_assignee,conversations:update_priority,conversations:update_status,conversations:update_tags,conversations:update_custom_attributes,conversations:update_subject,conversations:delete,messages:read,messages:write,view:manage,status:manage,tags:manage,macros:manage,users:manage,teams:manage,automations:manage,inboxes:manage,roles:manage,reports:manage,templates:manage,business_hours:manage,sla:manage,audit_logs:read,queues:manage}'
 	);
 
 